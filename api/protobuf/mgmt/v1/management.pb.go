@@ -0,0 +1,795 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: api/protobuf/mgmt/v1/management.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetServerStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetServerStatusRequest) Reset() {
+	*x = GetServerStatusRequest{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServerStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServerStatusRequest) ProtoMessage() {}
+
+func (x *GetServerStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServerStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetServerStatusRequest) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{0}
+}
+
+type GetServerStatusResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Version           string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Status            string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	ActiveConnections uint64                 `protobuf:"varint,3,opt,name=active_connections,json=activeConnections,proto3" json:"active_connections,omitempty"`
+	UptimeSeconds     uint64                 `protobuf:"varint,4,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *GetServerStatusResponse) Reset() {
+	*x = GetServerStatusResponse{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetServerStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetServerStatusResponse) ProtoMessage() {}
+
+func (x *GetServerStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetServerStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetServerStatusResponse) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetServerStatusResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *GetServerStatusResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GetServerStatusResponse) GetActiveConnections() uint64 {
+	if x != nil {
+		return x.ActiveConnections
+	}
+	return 0
+}
+
+func (x *GetServerStatusResponse) GetUptimeSeconds() uint64 {
+	if x != nil {
+		return x.UptimeSeconds
+	}
+	return 0
+}
+
+type CreateDatabaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDatabaseRequest) Reset() {
+	*x = CreateDatabaseRequest{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDatabaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDatabaseRequest) ProtoMessage() {}
+
+func (x *CreateDatabaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDatabaseRequest.ProtoReflect.Descriptor instead.
+func (*CreateDatabaseRequest) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateDatabaseRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DropDatabaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DropDatabaseRequest) Reset() {
+	*x = DropDatabaseRequest{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DropDatabaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DropDatabaseRequest) ProtoMessage() {}
+
+func (x *DropDatabaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DropDatabaseRequest.ProtoReflect.Descriptor instead.
+func (*DropDatabaseRequest) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DropDatabaseRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ListDatabasesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDatabasesRequest) Reset() {
+	*x = ListDatabasesRequest{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDatabasesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDatabasesRequest) ProtoMessage() {}
+
+func (x *ListDatabasesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDatabasesRequest.ProtoReflect.Descriptor instead.
+func (*ListDatabasesRequest) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{4}
+}
+
+type ListDatabasesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Names         []string               `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDatabasesResponse) Reset() {
+	*x = ListDatabasesResponse{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDatabasesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDatabasesResponse) ProtoMessage() {}
+
+func (x *ListDatabasesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDatabasesResponse.ProtoReflect.Descriptor instead.
+func (*ListDatabasesResponse) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListDatabasesResponse) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+type BackupRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	BackupLocation string                 `protobuf:"bytes,1,opt,name=backup_location,json=backupLocation,proto3" json:"backup_location,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BackupRequest) Reset() {
+	*x = BackupRequest{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupRequest) ProtoMessage() {}
+
+func (x *BackupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupRequest.ProtoReflect.Descriptor instead.
+func (*BackupRequest) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BackupRequest) GetBackupLocation() string {
+	if x != nil {
+		return x.BackupLocation
+	}
+	return ""
+}
+
+type BackupResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BackupPath      string                 `protobuf:"bytes,1,opt,name=backup_path,json=backupPath,proto3" json:"backup_path,omitempty"`
+	BackupSizeBytes uint64                 `protobuf:"varint,2,opt,name=backup_size_bytes,json=backupSizeBytes,proto3" json:"backup_size_bytes,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *BackupResponse) Reset() {
+	*x = BackupResponse{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupResponse) ProtoMessage() {}
+
+func (x *BackupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupResponse.ProtoReflect.Descriptor instead.
+func (*BackupResponse) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BackupResponse) GetBackupPath() string {
+	if x != nil {
+		return x.BackupPath
+	}
+	return ""
+}
+
+func (x *BackupResponse) GetBackupSizeBytes() uint64 {
+	if x != nil {
+		return x.BackupSizeBytes
+	}
+	return 0
+}
+
+type RestoreRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BackupPath    string                 `protobuf:"bytes,1,opt,name=backup_path,json=backupPath,proto3" json:"backup_path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreRequest) Reset() {
+	*x = RestoreRequest{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreRequest) ProtoMessage() {}
+
+func (x *RestoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreRequest.ProtoReflect.Descriptor instead.
+func (*RestoreRequest) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RestoreRequest) GetBackupPath() string {
+	if x != nil {
+		return x.BackupPath
+	}
+	return ""
+}
+
+type CompactDatabaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompactDatabaseRequest) Reset() {
+	*x = CompactDatabaseRequest{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompactDatabaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactDatabaseRequest) ProtoMessage() {}
+
+func (x *CompactDatabaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactDatabaseRequest.ProtoReflect.Descriptor instead.
+func (*CompactDatabaseRequest) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CompactDatabaseRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type FlushAllRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlushAllRequest) Reset() {
+	*x = FlushAllRequest{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlushAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlushAllRequest) ProtoMessage() {}
+
+func (x *FlushAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlushAllRequest.ProtoReflect.Descriptor instead.
+func (*FlushAllRequest) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{10}
+}
+
+type GetStorageStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStorageStatsRequest) Reset() {
+	*x = GetStorageStatsRequest{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStorageStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStorageStatsRequest) ProtoMessage() {}
+
+func (x *GetStorageStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStorageStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStorageStatsRequest) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{11}
+}
+
+type DatabaseStorageStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	SizeBytes     uint64                 `protobuf:"varint,2,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	TableCount    uint64                 `protobuf:"varint,3,opt,name=table_count,json=tableCount,proto3" json:"table_count,omitempty"`
+	EstimatedRows uint64                 `protobuf:"varint,4,opt,name=estimated_rows,json=estimatedRows,proto3" json:"estimated_rows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DatabaseStorageStats) Reset() {
+	*x = DatabaseStorageStats{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DatabaseStorageStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DatabaseStorageStats) ProtoMessage() {}
+
+func (x *DatabaseStorageStats) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DatabaseStorageStats.ProtoReflect.Descriptor instead.
+func (*DatabaseStorageStats) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DatabaseStorageStats) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DatabaseStorageStats) GetSizeBytes() uint64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *DatabaseStorageStats) GetTableCount() uint64 {
+	if x != nil {
+		return x.TableCount
+	}
+	return 0
+}
+
+func (x *DatabaseStorageStats) GetEstimatedRows() uint64 {
+	if x != nil {
+		return x.EstimatedRows
+	}
+	return 0
+}
+
+type GetStorageStatsResponse struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Databases     []*DatabaseStorageStats `protobuf:"bytes,1,rep,name=databases,proto3" json:"databases,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStorageStatsResponse) Reset() {
+	*x = GetStorageStatsResponse{}
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStorageStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStorageStatsResponse) ProtoMessage() {}
+
+func (x *GetStorageStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_protobuf_mgmt_v1_management_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStorageStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStorageStatsResponse) Descriptor() ([]byte, []int) {
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetStorageStatsResponse) GetDatabases() []*DatabaseStorageStats {
+	if x != nil {
+		return x.Databases
+	}
+	return nil
+}
+
+var File_api_protobuf_mgmt_v1_management_proto protoreflect.FileDescriptor
+
+const file_api_protobuf_mgmt_v1_management_proto_rawDesc = "" +
+	"\n" +
+	"%api/protobuf/mgmt/v1/management.proto\x12\amgmt.v1\x1a\x1bgoogle/protobuf/empty.proto\"\x18\n" +
+	"\x16GetServerStatusRequest\"\xa1\x01\n" +
+	"\x17GetServerStatusResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12-\n" +
+	"\x12active_connections\x18\x03 \x01(\x04R\x11activeConnections\x12%\n" +
+	"\x0euptime_seconds\x18\x04 \x01(\x04R\ruptimeSeconds\"+\n" +
+	"\x15CreateDatabaseRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\")\n" +
+	"\x13DropDatabaseRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x16\n" +
+	"\x14ListDatabasesRequest\"-\n" +
+	"\x15ListDatabasesResponse\x12\x14\n" +
+	"\x05names\x18\x01 \x03(\tR\x05names\"8\n" +
+	"\rBackupRequest\x12'\n" +
+	"\x0fbackup_location\x18\x01 \x01(\tR\x0ebackupLocation\"]\n" +
+	"\x0eBackupResponse\x12\x1f\n" +
+	"\vbackup_path\x18\x01 \x01(\tR\n" +
+	"backupPath\x12*\n" +
+	"\x11backup_size_bytes\x18\x02 \x01(\x04R\x0fbackupSizeBytes\"1\n" +
+	"\x0eRestoreRequest\x12\x1f\n" +
+	"\vbackup_path\x18\x01 \x01(\tR\n" +
+	"backupPath\",\n" +
+	"\x16CompactDatabaseRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x11\n" +
+	"\x0fFlushAllRequest\"\x18\n" +
+	"\x16GetStorageStatsRequest\"\x91\x01\n" +
+	"\x14DatabaseStorageStats\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x02 \x01(\x04R\tsizeBytes\x12\x1f\n" +
+	"\vtable_count\x18\x03 \x01(\x04R\n" +
+	"tableCount\x12%\n" +
+	"\x0eestimated_rows\x18\x04 \x01(\x04R\restimatedRows\"V\n" +
+	"\x17GetStorageStatsResponse\x12;\n" +
+	"\tdatabases\x18\x01 \x03(\v2\x1d.mgmt.v1.DatabaseStorageStatsR\tdatabases2\xa0\x05\n" +
+	"\x11ManagementService\x12T\n" +
+	"\x0fGetServerStatus\x12\x1f.mgmt.v1.GetServerStatusRequest\x1a .mgmt.v1.GetServerStatusResponse\x12H\n" +
+	"\x0eCreateDatabase\x12\x1e.mgmt.v1.CreateDatabaseRequest\x1a\x16.google.protobuf.Empty\x12D\n" +
+	"\fDropDatabase\x12\x1c.mgmt.v1.DropDatabaseRequest\x1a\x16.google.protobuf.Empty\x12N\n" +
+	"\rListDatabases\x12\x1d.mgmt.v1.ListDatabasesRequest\x1a\x1e.mgmt.v1.ListDatabasesResponse\x129\n" +
+	"\x06Backup\x12\x16.mgmt.v1.BackupRequest\x1a\x17.mgmt.v1.BackupResponse\x12:\n" +
+	"\aRestore\x12\x17.mgmt.v1.RestoreRequest\x1a\x16.google.protobuf.Empty\x12J\n" +
+	"\x0fCompactDatabase\x12\x1f.mgmt.v1.CompactDatabaseRequest\x1a\x16.google.protobuf.Empty\x12<\n" +
+	"\bFlushAll\x12\x18.mgmt.v1.FlushAllRequest\x1a\x16.google.protobuf.Empty\x12T\n" +
+	"\x0fGetStorageStats\x12\x1f.mgmt.v1.GetStorageStatsRequest\x1a .mgmt.v1.GetStorageStatsResponseB1Z/github.com/turtacn/guocedb/api/protobuf/mgmt/v1b\x06proto3"
+
+var (
+	file_api_protobuf_mgmt_v1_management_proto_rawDescOnce sync.Once
+	file_api_protobuf_mgmt_v1_management_proto_rawDescData []byte
+)
+
+func file_api_protobuf_mgmt_v1_management_proto_rawDescGZIP() []byte {
+	file_api_protobuf_mgmt_v1_management_proto_rawDescOnce.Do(func() {
+		file_api_protobuf_mgmt_v1_management_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_protobuf_mgmt_v1_management_proto_rawDesc), len(file_api_protobuf_mgmt_v1_management_proto_rawDesc)))
+	})
+	return file_api_protobuf_mgmt_v1_management_proto_rawDescData
+}
+
+var file_api_protobuf_mgmt_v1_management_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_api_protobuf_mgmt_v1_management_proto_goTypes = []any{
+	(*GetServerStatusRequest)(nil),  // 0: mgmt.v1.GetServerStatusRequest
+	(*GetServerStatusResponse)(nil), // 1: mgmt.v1.GetServerStatusResponse
+	(*CreateDatabaseRequest)(nil),   // 2: mgmt.v1.CreateDatabaseRequest
+	(*DropDatabaseRequest)(nil),     // 3: mgmt.v1.DropDatabaseRequest
+	(*ListDatabasesRequest)(nil),    // 4: mgmt.v1.ListDatabasesRequest
+	(*ListDatabasesResponse)(nil),   // 5: mgmt.v1.ListDatabasesResponse
+	(*BackupRequest)(nil),           // 6: mgmt.v1.BackupRequest
+	(*BackupResponse)(nil),          // 7: mgmt.v1.BackupResponse
+	(*RestoreRequest)(nil),          // 8: mgmt.v1.RestoreRequest
+	(*CompactDatabaseRequest)(nil),  // 9: mgmt.v1.CompactDatabaseRequest
+	(*FlushAllRequest)(nil),         // 10: mgmt.v1.FlushAllRequest
+	(*GetStorageStatsRequest)(nil),  // 11: mgmt.v1.GetStorageStatsRequest
+	(*DatabaseStorageStats)(nil),    // 12: mgmt.v1.DatabaseStorageStats
+	(*GetStorageStatsResponse)(nil), // 13: mgmt.v1.GetStorageStatsResponse
+	(*emptypb.Empty)(nil),           // 14: google.protobuf.Empty
+}
+var file_api_protobuf_mgmt_v1_management_proto_depIdxs = []int32{
+	12, // 0: mgmt.v1.GetStorageStatsResponse.databases:type_name -> mgmt.v1.DatabaseStorageStats
+	0,  // 1: mgmt.v1.ManagementService.GetServerStatus:input_type -> mgmt.v1.GetServerStatusRequest
+	2,  // 2: mgmt.v1.ManagementService.CreateDatabase:input_type -> mgmt.v1.CreateDatabaseRequest
+	3,  // 3: mgmt.v1.ManagementService.DropDatabase:input_type -> mgmt.v1.DropDatabaseRequest
+	4,  // 4: mgmt.v1.ManagementService.ListDatabases:input_type -> mgmt.v1.ListDatabasesRequest
+	6,  // 5: mgmt.v1.ManagementService.Backup:input_type -> mgmt.v1.BackupRequest
+	8,  // 6: mgmt.v1.ManagementService.Restore:input_type -> mgmt.v1.RestoreRequest
+	9,  // 7: mgmt.v1.ManagementService.CompactDatabase:input_type -> mgmt.v1.CompactDatabaseRequest
+	10, // 8: mgmt.v1.ManagementService.FlushAll:input_type -> mgmt.v1.FlushAllRequest
+	11, // 9: mgmt.v1.ManagementService.GetStorageStats:input_type -> mgmt.v1.GetStorageStatsRequest
+	1,  // 10: mgmt.v1.ManagementService.GetServerStatus:output_type -> mgmt.v1.GetServerStatusResponse
+	14, // 11: mgmt.v1.ManagementService.CreateDatabase:output_type -> google.protobuf.Empty
+	14, // 12: mgmt.v1.ManagementService.DropDatabase:output_type -> google.protobuf.Empty
+	5,  // 13: mgmt.v1.ManagementService.ListDatabases:output_type -> mgmt.v1.ListDatabasesResponse
+	7,  // 14: mgmt.v1.ManagementService.Backup:output_type -> mgmt.v1.BackupResponse
+	14, // 15: mgmt.v1.ManagementService.Restore:output_type -> google.protobuf.Empty
+	14, // 16: mgmt.v1.ManagementService.CompactDatabase:output_type -> google.protobuf.Empty
+	14, // 17: mgmt.v1.ManagementService.FlushAll:output_type -> google.protobuf.Empty
+	13, // 18: mgmt.v1.ManagementService.GetStorageStats:output_type -> mgmt.v1.GetStorageStatsResponse
+	10, // [10:19] is the sub-list for method output_type
+	1,  // [1:10] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_api_protobuf_mgmt_v1_management_proto_init() }
+func file_api_protobuf_mgmt_v1_management_proto_init() {
+	if File_api_protobuf_mgmt_v1_management_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_protobuf_mgmt_v1_management_proto_rawDesc), len(file_api_protobuf_mgmt_v1_management_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_protobuf_mgmt_v1_management_proto_goTypes,
+		DependencyIndexes: file_api_protobuf_mgmt_v1_management_proto_depIdxs,
+		MessageInfos:      file_api_protobuf_mgmt_v1_management_proto_msgTypes,
+	}.Build()
+	File_api_protobuf_mgmt_v1_management_proto = out.File
+	file_api_protobuf_mgmt_v1_management_proto_goTypes = nil
+	file_api_protobuf_mgmt_v1_management_proto_depIdxs = nil
+}
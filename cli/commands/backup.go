@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/turtacn/guocedb/cli/config"
+	"github.com/turtacn/guocedb/storage/backup"
+)
+
+// NewBackupCmd creates the backup command.
+func NewBackupCmd(cfgFile *string) *cobra.Command {
+	var (
+		output  string
+		dataDir string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up the data directory to a file",
+		Long: `Write a point-in-time consistent backup of every database in the data
+directory, along with the catalog metadata, to a file or, with --out -, to
+stdout. The server must be stopped, as the data directory is locked while
+it runs.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := resolveDataDir(*cfgFile, dataDir)
+			if err != nil {
+				return err
+			}
+			return runBackup(dir, output)
+		},
+	}
+
+	cmd.Flags().StringVar(&output, "out", "", "backup file, - for stdout (required)")
+	cmd.Flags().StringVar(&dataDir, "data-dir", "", "data directory (overrides config)")
+	cmd.MarkFlagRequired("out")
+
+	return cmd
+}
+
+// NewRestoreCmd creates the restore command.
+func NewRestoreCmd(cfgFile *string) *cobra.Command {
+	var (
+		input   string
+		dataDir string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Rebuild a data directory from a backup",
+		Long: `Rebuild the data directory from a backup written by the backup command,
+read from a file or, with --in -, from stdin. The data directory must not
+exist or be empty.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := resolveDataDir(*cfgFile, dataDir)
+			if err != nil {
+				return err
+			}
+			return runRestore(input, dir)
+		},
+	}
+
+	cmd.Flags().StringVar(&input, "in", "", "backup file, - for stdin (required)")
+	cmd.Flags().StringVar(&dataDir, "data-dir", "", "data directory (overrides config)")
+	cmd.MarkFlagRequired("in")
+
+	return cmd
+}
+
+// resolveDataDir returns the data directory given on the command line, or
+// the one of the configuration otherwise.
+func resolveDataDir(cfgFile, dataDir string) (string, error) {
+	if dataDir != "" {
+		return dataDir, nil
+	}
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return cfg.Storage.DataDir, nil
+}
+
+func runBackup(dataDir, output string) error {
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create backup file %s: %w", output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := backup.BackupDir(dataDir, w); err != nil {
+		return err
+	}
+
+	if output != "-" {
+		fmt.Fprintf(os.Stderr, "Backed up %s to %s\n", dataDir, output)
+	}
+	return nil
+}
+
+func runRestore(input, dataDir string) error {
+	var r io.Reader = os.Stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return fmt.Errorf("failed to open backup file %s: %w", input, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	h, err := backup.Restore(r, dataDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Restored backup of %s to %s\n", h.Created.Format("2006-01-02 15:04:05 MST"), dataDir)
+	return nil
+}
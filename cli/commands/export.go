@@ -20,15 +20,31 @@ func NewExportCmd() *cobra.Command {
 		schemaOnly bool
 		dataOnly   bool
 		addr       string
+		format     string
+		table      string
+		null       string
 	)
 	
 	cmd := &cobra.Command{
 		Use:   "export",
-		Short: "Export database to SQL dump",
+		Short: "Export database to SQL dump or a table to CSV",
 		Long: `Export database schema and/or data to SQL dump format.
-The output can be used to recreate the database structure and data.`,
+The output can be used to recreate the database structure and data.
+
+With --format csv, the rows of the table given with --table are written as
+CSV, with a header row with the names of the columns.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runExport(addr, database, tables, output, schemaOnly, dataOnly)
+			if table != "" {
+				tables = append(tables, table)
+			}
+			switch format {
+			case "sql":
+				return runExport(addr, database, tables, output, schemaOnly, dataOnly)
+			case "csv":
+				return runExportCSV(addr, database, tables, output, null)
+			default:
+				return fmt.Errorf("unsupported format: %s (supported: sql, csv)", format)
+			}
 		},
 	}
 	
@@ -38,12 +54,66 @@ The output can be used to recreate the database structure and data.`,
 	cmd.Flags().BoolVar(&schemaOnly, "schema-only", false, "export schema only (no data)")
 	cmd.Flags().BoolVar(&dataOnly, "data-only", false, "export data only (no schema)")
 	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:3306", "server address")
+	cmd.Flags().StringVar(&format, "format", "sql", "output format: sql|csv")
+	cmd.Flags().StringVar(&table, "table", "", "table to export, required with --format csv")
+	cmd.Flags().StringVar(&output, "out", "", "output file, same as --output")
+	cmd.Flags().StringVar(&null, "null", export.DefaultCSVNull, `text of NULL values in CSV, "" for empty fields`)
 	
 	cmd.MarkFlagRequired("database")
 	
 	return cmd
 }
 
+func runExportCSV(addr, database string, tables []string, output, null string) error {
+	if len(tables) != 1 {
+		return fmt.Errorf("--format csv exports a single table, given with --table")
+	}
+
+	db, err := connect(addr, database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file %s: %w", output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	opts := export.DefaultCSVOptions()
+	opts.Null = null
+	n, err := export.ExportCSV(db, tables[0], w, opts)
+	if err != nil {
+		return err
+	}
+
+	if output != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d rows of table %s to %s\n", n, tables[0], output)
+	}
+	return nil
+}
+
+// connect opens a connection to the database at the server and checks the
+// server can be reached.
+func connect(addr, database string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("root@tcp(%s)/%s", addr, database)
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cannot connect to database %s at %s: %w", database, addr, err)
+	}
+	return db, nil
+}
+
 func runExport(addr, database string, tables []string, output string, schemaOnly, dataOnly bool) error {
 	// Validate flags
 	if schemaOnly && dataOnly {
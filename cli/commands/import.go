@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/spf13/cobra"
+	"github.com/turtacn/guocedb/internal/export"
+)
+
+// NewImportCmd creates the import command.
+func NewImportCmd() *cobra.Command {
+	var (
+		database  string
+		table     string
+		input     string
+		format    string
+		null      string
+		batchSize int
+		addr      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import rows into a table from CSV",
+		Long: `Import rows into a table from a CSV file.
+The first row of the file is a header with the names of the columns of the
+fields. Columns missing from it get their default values.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "csv" {
+				return fmt.Errorf("unsupported format: %s (supported: csv)", format)
+			}
+			return runImportCSV(addr, database, table, input, null, batchSize)
+		},
+	}
+
+	cmd.Flags().StringVar(&database, "database", "", "database of the table (required)")
+	cmd.Flags().StringVar(&table, "table", "", "table to import into (required)")
+	cmd.Flags().StringVar(&input, "in", "", "input file (default: stdin)")
+	cmd.Flags().StringVar(&format, "format", "csv", "input format: csv")
+	cmd.Flags().StringVar(&null, "null", export.DefaultCSVNull, `text of NULL values, "" for empty fields`)
+	cmd.Flags().IntVar(&batchSize, "batch-size", export.DefaultCSVOptions().BatchSize, "rows inserted with each INSERT")
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:3306", "server address")
+
+	cmd.MarkFlagRequired("database")
+	cmd.MarkFlagRequired("table")
+
+	return cmd
+}
+
+func runImportCSV(addr, database, table, input, null string, batchSize int) error {
+	db, err := connect(addr, database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var r io.Reader = os.Stdin
+	if input != "" {
+		f, err := os.Open(input)
+		if err != nil {
+			return fmt.Errorf("failed to open input file %s: %w", input, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	opts := export.DefaultCSVOptions()
+	opts.Null = null
+	opts.BatchSize = batchSize
+	n, err := export.ImportCSV(db, table, r, opts)
+	if err != nil {
+		return fmt.Errorf("import into table %s stopped after %d rows: %w", table, n, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Imported %d rows into table %s\n", n, table)
+	return nil
+}
@@ -92,6 +92,8 @@ func runServe(ctx context.Context, cfgFile, host string, port int, dataDir strin
 	
 	// 5. Initialize compute layer
 	catalog := sql.NewCatalog()
+	catalog.AddDatabase(sql.NewInformationSchemaDatabase(catalog))
+	sal.RegisterEngines(catalog.EngineRegistry)
 	analyzer := analyzer.NewAnalyzer(catalog)
 	optimizer := optimizer.NewOptimizer()
 	engine := executor.NewEngine(analyzer, optimizer, catalog)
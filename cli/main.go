@@ -30,6 +30,9 @@ It provides a distributed, scalable database solution with MySQL protocol compat
 		commands.NewServeCmd(&cfgFile),
 		commands.NewStatusCmd(),
 		commands.NewExportCmd(),
+		commands.NewImportCmd(),
+		commands.NewBackupCmd(&cfgFile),
+		commands.NewRestoreCmd(&cfgFile),
 		commands.NewDiagnosticCmd(),
 		commands.NewVersionCmd(),
 	)
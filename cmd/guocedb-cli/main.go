@@ -2,14 +2,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
-	// This import would be the generated protobuf code
-	// mgmtv1 "github.com/turtacn/guocedb/api/protobuf/mgmt/v1"
+
+	mgmtv1 "github.com/turtacn/guocedb/api/protobuf/mgmt/v1"
 )
 
 var (
@@ -35,41 +37,47 @@ func main() {
 	addStatusCommand(rootCmd)
 	addCreateDBCommand(rootCmd)
 	addDropDBCommand(rootCmd)
+	addSQLCommand(rootCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatalf("Failed to execute command: %v", err)
 	}
 }
 
+// newManagementClient connects to the server and returns a client for its
+// management service, along with a context for a single call. The returned
+// function must be called when the client is no longer needed.
+func newManagementClient() (mgmtv1.ManagementServiceClient, context.Context, func()) {
+	conn, err := createGRPCClient()
+	if err != nil {
+		log.Fatalf("Failed to connect to server: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	return mgmtv1.NewManagementServiceClient(conn), ctx, func() {
+		cancel()
+		conn.Close()
+	}
+}
+
 // addStatusCommand adds the 'status' subcommand.
 func addStatusCommand(rootCmd *cobra.Command) {
 	var statusCmd = &cobra.Command{
 		Use:   "status",
 		Short: "Get the status of the guocedb server.",
 		Run: func(cmd *cobra.Command, args []string) {
-			/*
-				// This is how it would work with the generated code.
-				conn, err := createGRPCClient()
-				if err != nil {
-					log.Fatalf("Failed to connect to server: %v", err)
-				}
-				defer conn.Close()
-
-				client := mgmtv1.NewManagementServiceClient(conn)
-				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				defer cancel()
-
-				resp, err := client.GetServerStatus(ctx, &mgmtv1.GetServerStatusRequest{})
-				if err != nil {
-					log.Fatalf("Failed to get server status: %v", err)
-				}
-
-				fmt.Printf("Version: %s\n", resp.Version)
-				fmt.Printf("Status: %s\n", resp.Status)
-				fmt.Printf("Active Connections: %d\n", resp.ActiveConnections)
-				fmt.Printf("Uptime (seconds): %d\n", resp.UptimeSeconds)
-			*/
-			fmt.Println("Status command is not fully implemented without protoc generation.")
+			client, ctx, done := newManagementClient()
+			defer done()
+
+			resp, err := client.GetServerStatus(ctx, &mgmtv1.GetServerStatusRequest{})
+			if err != nil {
+				log.Fatalf("Failed to get server status: %v", err)
+			}
+
+			fmt.Printf("Version: %s\n", resp.Version)
+			fmt.Printf("Status: %s\n", resp.Status)
+			fmt.Printf("Active Connections: %d\n", resp.ActiveConnections)
+			fmt.Printf("Uptime (seconds): %d\n", resp.UptimeSeconds)
 		},
 	}
 	rootCmd.AddCommand(statusCmd)
@@ -83,7 +91,13 @@ func addCreateDBCommand(rootCmd *cobra.Command) {
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			dbName := args[0]
-			fmt.Printf("CreateDB command for '%s' is not fully implemented without protoc generation.\n", dbName)
+			client, ctx, done := newManagementClient()
+			defer done()
+
+			if _, err := client.CreateDatabase(ctx, &mgmtv1.CreateDatabaseRequest{Name: dbName}); err != nil {
+				log.Fatalf("Failed to create database '%s': %v", dbName, err)
+			}
+			fmt.Printf("Database '%s' created.\n", dbName)
 		},
 	}
 	rootCmd.AddCommand(createDBCmd)
@@ -97,7 +111,13 @@ func addDropDBCommand(rootCmd *cobra.Command) {
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			dbName := args[0]
-			fmt.Printf("DropDB command for '%s' is not fully implemented without protoc generation.\n", dbName)
+			client, ctx, done := newManagementClient()
+			defer done()
+
+			if _, err := client.DropDatabase(ctx, &mgmtv1.DropDatabaseRequest{Name: dbName}); err != nil {
+				log.Fatalf("Failed to drop database '%s': %v", dbName, err)
+			}
+			fmt.Printf("Database '%s' dropped.\n", dbName)
 		},
 	}
 	rootCmd.AddCommand(dropDBCmd)
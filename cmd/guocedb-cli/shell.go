@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// defaultSQLAddr is the address of the MySQL server of guocedb, which
+	// the sql subcommand connects to unless given --server.
+	defaultSQLAddr = "localhost:3306"
+	// historyFile is the file, in the home directory, the statements run
+	// in the shell are saved to.
+	historyFile = ".guocedb_history"
+	// maxHistory is the number of statements kept in the history.
+	maxHistory = 1000
+
+	prompt         = "guocedb> "
+	continuePrompt = "      -> "
+)
+
+// addSQLCommand adds the 'sql' subcommand.
+func addSQLCommand(rootCmd *cobra.Command) {
+	var user, password, database string
+	var sqlCmd = &cobra.Command{
+		Use:   "sql",
+		Short: "Run SQL statements in an interactive shell.",
+		Long: `sql connects to the MySQL server of guocedb and runs the statements typed,
+which may span several lines and end with ';'. Type \? for the commands of the shell.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			addr := serverAddr
+			if !cmd.Flag("server").Changed {
+				addr = defaultSQLAddr
+			}
+
+			cfg := mysqldriver.NewConfig()
+			cfg.User = user
+			cfg.Passwd = password
+			cfg.Net = "tcp"
+			cfg.Addr = addr
+			cfg.DBName = database
+			db, err := sql.Open("mysql", cfg.FormatDSN())
+			if err != nil {
+				log.Fatalf("Failed to connect to server: %v", err)
+			}
+			defer db.Close()
+
+			// The prompts are only shown to people, not to scripts.
+			interactive := false
+			if fi, err := os.Stdin.Stat(); err == nil {
+				interactive = fi.Mode()&os.ModeCharDevice != 0
+			}
+
+			var history *shellHistory
+			if home, err := os.UserHomeDir(); err == nil && interactive {
+				history = loadHistory(filepath.Join(home, historyFile))
+			}
+
+			if err := runShell(context.Background(), db, os.Stdin, os.Stdout, interactive, history); err != nil {
+				log.Fatalf("Failed to run shell: %v", err)
+			}
+		},
+	}
+	sqlCmd.Flags().StringVarP(&user, "user", "u", "root", "User to connect as.")
+	sqlCmd.Flags().StringVarP(&password, "password", "p", "", "Password of the user.")
+	sqlCmd.Flags().StringVarP(&database, "database", "D", "", "Database to use.")
+	rootCmd.AddCommand(sqlCmd)
+}
+
+// shell reads SQL statements and runs them on a single connection, so
+// that the session, as the database in use, lasts between them.
+type shell struct {
+	conn    *sql.Conn
+	out     io.Writer
+	history *shellHistory
+	timing  bool
+}
+
+// runShell runs the statements read from in on db, writing their results
+// to out, until in ends or \q is typed. Prompts are only written if
+// interactive is set. Statements are saved to history, if it isn't nil.
+func runShell(ctx context.Context, db *sql.DB, in io.Reader, out io.Writer, interactive bool, history *shellHistory) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	s := &shell{conn: conn, out: out, history: history, timing: true}
+	if interactive {
+		fmt.Fprintln(out, `Welcome to the guocedb shell. Type \? for help, \q to quit.`)
+	}
+
+	r := bufio.NewReader(in)
+	var buf string
+	for {
+		if interactive {
+			if buf == "" {
+				fmt.Fprint(out, prompt)
+			} else {
+				fmt.Fprint(out, continuePrompt)
+			}
+		}
+
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		eof := err == io.EOF
+
+		if buf == "" && strings.HasPrefix(strings.TrimSpace(line), `\`) {
+			if quit := s.command(ctx, strings.TrimSpace(line)); quit {
+				return nil
+			}
+		} else {
+			var stmts []string
+			stmts, buf = splitStatements(buf + line)
+			for _, stmt := range stmts {
+				s.history.add(stmt)
+				s.run(ctx, stmt)
+			}
+		}
+
+		if eof {
+			// A last statement without ';' is run, as the mysql client
+			// does in batch mode.
+			if stmt := strings.TrimSpace(buf); stmt != "" {
+				s.history.add(stmt)
+				s.run(ctx, stmt)
+			}
+			if interactive {
+				fmt.Fprintln(out)
+			}
+			return nil
+		}
+	}
+}
+
+// command runs a command of the shell, which starts with a backslash. It
+// returns whether the shell must end.
+func (s *shell) command(ctx context.Context, line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSuffix(strings.TrimSpace(arg), ";")
+
+	switch name {
+	case `\q`, `\quit`:
+		return true
+	case `\d`:
+		if arg == "" {
+			s.run(ctx, "SHOW TABLES")
+		} else {
+			s.run(ctx, "DESCRIBE TABLE "+arg)
+		}
+	case `\timing`:
+		s.timing = !s.timing
+		if s.timing {
+			fmt.Fprintln(s.out, "Timing is on.")
+		} else {
+			fmt.Fprintln(s.out, "Timing is off.")
+		}
+	case `\history`:
+		for i, stmt := range s.history.entries() {
+			fmt.Fprintf(s.out, "%5d  %s\n", i+1, stmt)
+		}
+	case `\?`, `\h`, `\help`:
+		fmt.Fprint(s.out, `Statements may span several lines and end with ';'.
+
+\d [table]  List the tables, or describe the columns of a table.
+\timing     Toggle showing how long statements take.
+\history    List the statements run.
+\q          Quit.
+`)
+	default:
+		fmt.Fprintf(s.out, "Unknown command '%s'. Type \\? for help.\n", name)
+	}
+	return false
+}
+
+// run runs a statement and writes its results, or its error. Errors don't
+// end the shell.
+func (s *shell) run(ctx context.Context, stmt string) {
+	start := time.Now()
+	var summary string
+	var err error
+	if returnsRows(stmt) {
+		summary, err = s.query(ctx, stmt)
+	} else {
+		summary, err = s.exec(ctx, stmt)
+	}
+	if err != nil {
+		var mysqlErr *mysqldriver.MySQLError
+		if errors.As(err, &mysqlErr) {
+			fmt.Fprintf(s.out, "ERROR %d (%s): %s\n", mysqlErr.Number, mysqlErr.SQLState, mysqlErr.Message)
+		} else {
+			fmt.Fprintf(s.out, "ERROR: %v\n", err)
+		}
+		return
+	}
+
+	if s.timing {
+		summary += fmt.Sprintf(" (%.2f sec)", time.Since(start).Seconds())
+	}
+	fmt.Fprintf(s.out, "%s\n\n", summary)
+}
+
+func (s *shell) query(ctx context.Context, stmt string) (string, error) {
+	rows, err := s.conn.QueryContext(ctx, stmt)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]sql.RawBytes, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	var result [][]string
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return "", err
+		}
+		row := make([]string, len(cols))
+		for i, v := range values {
+			if v == nil {
+				row[i] = "NULL"
+			} else {
+				row[i] = string(v)
+			}
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if len(result) == 0 {
+		return "Empty set", nil
+	}
+	writeTable(s.out, cols, result)
+	if len(result) == 1 {
+		return "1 row in set", nil
+	}
+	return fmt.Sprintf("%d rows in set", len(result)), nil
+}
+
+func (s *shell) exec(ctx context.Context, stmt string) (string, error) {
+	res, err := s.conn.ExecContext(ctx, stmt)
+	if err != nil {
+		return "", err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return "Query OK", nil
+	}
+	if affected == 1 {
+		return "Query OK, 1 row affected", nil
+	}
+	return fmt.Sprintf("Query OK, %d rows affected", affected), nil
+}
+
+// returnsRows returns whether the statement returns a result set, rather
+// than the number of rows it changed.
+func returnsRows(stmt string) bool {
+	first := strings.ToLower(strings.TrimLeft(stmt, " \t\r\n("))
+	if i := strings.IndexAny(first, " \t\r\n("); i >= 0 {
+		first = first[:i]
+	}
+
+	switch first {
+	case "select", "show", "describe", "desc", "explain", "with":
+		return true
+	default:
+		return false
+	}
+}
+
+// writeTable writes rows as a table whose columns are aligned, as the
+// mysql client does.
+func writeTable(w io.Writer, cols []string, rows [][]string) {
+	widths := make([]int, len(cols))
+	for i, col := range cols {
+		widths[i] = utf8.RuneCountInString(col)
+	}
+	for _, row := range rows {
+		for i, v := range row {
+			if n := utf8.RuneCountInString(v); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var sep strings.Builder
+	sep.WriteString("+")
+	for _, width := range widths {
+		sep.WriteString(strings.Repeat("-", width+2))
+		sep.WriteString("+")
+	}
+
+	writeRow := func(row []string) {
+		var b strings.Builder
+		b.WriteString("|")
+		for i, v := range row {
+			b.WriteString(" ")
+			b.WriteString(v)
+			b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(v)))
+			b.WriteString(" |")
+		}
+		fmt.Fprintln(w, b.String())
+	}
+
+	fmt.Fprintln(w, sep.String())
+	writeRow(cols)
+	fmt.Fprintln(w, sep.String())
+	for _, row := range rows {
+		writeRow(row)
+	}
+	fmt.Fprintln(w, sep.String())
+}
+
+// splitStatements splits s in the statements ended by ';', returning them
+// without it, along with the rest of s, which is part of a statement not
+// ended yet. Semicolons within quotes and backquotes don't end statements.
+func splitStatements(s string) ([]string, string) {
+	var stmts []string
+	var quote rune
+	var escaped bool
+	start := 0
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				escaped = true
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ';':
+			if stmt := strings.TrimSpace(s[start:i]); stmt != "" {
+				stmts = append(stmts, stmt)
+			}
+			start = i + 1
+		}
+	}
+
+	rest := s[start:]
+	if strings.TrimSpace(rest) == "" {
+		rest = ""
+	}
+	return stmts, rest
+}
+
+// shellHistory is the list of statements run in the shell, which is saved
+// to a file so that it's kept between runs.
+type shellHistory struct {
+	path  string
+	stmts []string
+}
+
+// loadHistory reads the history saved in path. A missing or unreadable
+// file is an empty history.
+func loadHistory(path string) *shellHistory {
+	h := &shellHistory{path: path}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" {
+				h.stmts = append(h.stmts, line)
+			}
+		}
+	}
+	if len(h.stmts) > maxHistory {
+		h.stmts = h.stmts[len(h.stmts)-maxHistory:]
+	}
+	return h
+}
+
+// add adds a statement to the history and saves it. Statements of several
+// lines are saved in a single one.
+func (h *shellHistory) add(stmt string) {
+	if h == nil {
+		return
+	}
+	stmt = strings.Join(strings.Split(strings.TrimSpace(stmt), "\n"), " ") + ";"
+	if n := len(h.stmts); n > 0 && h.stmts[n-1] == stmt {
+		return
+	}
+
+	h.stmts = append(h.stmts, stmt)
+	if len(h.stmts) > maxHistory {
+		h.stmts = h.stmts[len(h.stmts)-maxHistory:]
+	}
+
+	// Failing to save the history doesn't stop the shell.
+	if h.path != "" {
+		_ = os.WriteFile(h.path, []byte(strings.Join(h.stmts, "\n")+"\n"), 0600)
+	}
+}
+
+func (h *shellHistory) entries() []string {
+	if h == nil {
+		return nil
+	}
+	return h.stmts
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	"github.com/turtacn/guocedb/compute/server"
+	sqle "github.com/turtacn/guocedb/compute/sql"
+)
+
+// startTestServer serves an empty testdb database and returns a client
+// connected to it.
+func startTestServer(t *testing.T) *sql.DB {
+	catalog := sqle.NewCatalog()
+	catalog.AddDatabase(mem.NewDatabase("testdb"))
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+	handler := server.NewHandler(engine, server.NewSessionManager(server.DefaultSessionBuilder, nil, "localhost:0"))
+
+	authServer := auth.NewNativeSingle("root", "", auth.AllPermissions)
+	l, err := mysql.NewListener("tcp", "127.0.0.1:0", authServer.Mysql(), handler, 0, 0)
+	require.NoError(t, err)
+	go l.Accept()
+	t.Cleanup(l.Close)
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", l.Addr()))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestShell(t *testing.T) {
+	require := require.New(t)
+	db := startTestServer(t)
+
+	script := `CREATE TABLE users (id BIGINT PRIMARY KEY, name TEXT);
+INSERT INTO users (id, name)
+VALUES (1, 'alice'), (2, 'bob; the builder');
+SELECT id, name
+  FROM users
+  ORDER BY id;
+SELECT * FROM missing;
+\timing
+\d users
+SELECT name FROM users WHERE id = 3; SELECT COUNT(*) AS n FROM users
+`
+	history := loadHistory(filepath.Join(t.TempDir(), historyFile))
+	var out bytes.Buffer
+	require.NoError(runShell(context.Background(), db, strings.NewReader(script), &out, false, history))
+	output := out.String()
+
+	require.Contains(output, "Query OK, 2 rows affected")
+	require.Contains(output, `+----+------------------+
+| id | name             |
++----+------------------+
+| 1  | alice            |
+| 2  | bob; the builder |
++----+------------------+
+2 rows in set (`)
+
+	// Errors are written, and the shell goes on.
+	require.Contains(output, "ERROR 1146 (42S02): ")
+	require.Contains(output, "Timing is off.")
+	require.Contains(output, `| name | type  |
++------+-------+
+| id   | INT64 |
+| name | TEXT  |
++------+-------+
+2 rows in set
+`)
+	require.Contains(output, "Empty set\n")
+	require.Contains(output, `+---+
+| n |
++---+
+| 2 |
++---+
+1 row in set
+`)
+
+	require.Equal([]string{
+		"CREATE TABLE users (id BIGINT PRIMARY KEY, name TEXT);",
+		"INSERT INTO users (id, name) VALUES (1, 'alice'), (2, 'bob; the builder');",
+		"SELECT id, name   FROM users   ORDER BY id;",
+		"SELECT * FROM missing;",
+		"SELECT name FROM users WHERE id = 3;",
+		"SELECT COUNT(*) AS n FROM users;",
+	}, history.entries())
+
+	// The history is kept between runs.
+	require.Equal(history.entries(), loadHistory(history.path).entries())
+}
+
+func TestShell_Quit(t *testing.T) {
+	db := startTestServer(t)
+
+	var out bytes.Buffer
+	script := "SELECT 1 AS one;\n\\q\nSELECT 2 AS two;\n"
+	require.NoError(t, runShell(context.Background(), db, strings.NewReader(script), &out, true, nil))
+	require.Contains(t, out.String(), prompt)
+	require.Contains(t, out.String(), "| one |")
+	require.NotContains(t, out.String(), "| two |")
+}
+
+func TestSplitStatements(t *testing.T) {
+	stmts, rest := splitStatements("SELECT 1; SELECT ';' ; SELECT `a;b`, \"c\\\";\" FROM t; SELECT")
+	require.Equal(t, []string{"SELECT 1", "SELECT ';'", "SELECT `a;b`, \"c\\\";\" FROM t"}, stmts)
+	require.Equal(t, " SELECT", rest)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/turtacn/guocedb/compute/executor"
 	"github.com/turtacn/guocedb/compute/optimizer"
 	"github.com/turtacn/guocedb/maintenance/metrics"
+	"github.com/turtacn/guocedb/network/gateway"
 	"github.com/turtacn/guocedb/network/server"
 	"github.com/turtacn/guocedb/compute/auth"
 	mysql "github.com/turtacn/guocedb/compute/server"
@@ -47,6 +48,8 @@ func main() {
 
 	// Compute Layer
 	catalog := sql.NewCatalog()
+	catalog.AddDatabase(sql.NewInformationSchemaDatabase(catalog))
+	sal.RegisterEngines(catalog.EngineRegistry)
 	analyzer := analyzer.NewAnalyzer(catalog)
 	optimizer := optimizer.NewOptimizer()
 	engine := executor.NewEngine(analyzer, optimizer, catalog)
@@ -68,12 +71,25 @@ func main() {
 
 	// Maintenance Layer
 	metricsRegistry := metrics.NewRegistry()
+	metricsRegistry.MustRegister(metrics.NewConnectionCollector(func() metrics.ConnectionStats {
+		stats := mysqlServer.Handler.Stats()
+		return metrics.ConnectionStats{
+			ActiveConnections: stats.ActiveConnections,
+			Queries:           stats.Queries,
+		}
+	}))
 
 	// Network Server Manager
 	serverMgr := server.NewManager()
 	serverMgr.Register(mysqlServer)
 	// serverMgr.Register(grpcServer)
-	// serverMgr.Register(httpGateway)
+	if cfg.Server.HTTPPort != 0 {
+		httpGateway, err := gateway.NewGateway(fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.HTTPPort), engine)
+		if err != nil {
+			logger.Fatalf("Failed to initialize HTTP query gateway: %v", err)
+		}
+		serverMgr.Register(httpGateway)
+	}
 
 	// 5. Start servers
 	serverMgr.StartAll()
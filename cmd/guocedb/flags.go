@@ -9,6 +9,7 @@ var (
 	dataDir       string
 	port          int
 	host          string
+	replicaOf     string
 	logLevel      string
 	enableAuth    bool
 	enableMetrics bool
@@ -26,6 +27,7 @@ func initFlags(cmd *cobra.Command) {
 	// Server settings
 	flags.StringVar(&host, "host", "0.0.0.0", "server listen host")
 	flags.IntVarP(&port, "port", "p", 3306, "server listen port")
+	flags.StringVar(&replicaOf, "replica-of", "", "start as a read replica of the primary at this gRPC address")
 
 	// Storage settings
 	flags.StringVarP(&dataDir, "data-dir", "d", "./data", "data directory")
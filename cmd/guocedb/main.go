@@ -8,12 +8,15 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"syscall"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/turtacn/guocedb/config"
 	"github.com/turtacn/guocedb/server"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 var (
@@ -41,7 +44,7 @@ func runServer(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize logging
-	logger, err := initLogging(cfg.Logging)
+	logger, level, err := initLogging(cfg.Logging)
 	if err != nil {
 		return fmt.Errorf("init logging: %w", err)
 	}
@@ -62,6 +65,7 @@ func runServer(cmd *cobra.Command, args []string) error {
 
 	// Set the logger
 	server.WithLogger(logger)(srv)
+	server.WithLogLevel(level)(srv)
 
 	// Register lifecycle hooks
 	srv.Hooks().OnPostStart(func(s *server.Server) {
@@ -83,7 +87,7 @@ func runServer(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go handleSignals(ctx, srv, logger)
+	go handleSignals(ctx, srv, logger, cmd.Flags())
 
 	// Start server (blocks until shutdown)
 	if err := srv.Start(); err != nil {
@@ -93,16 +97,25 @@ func runServer(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// handleSignals handles OS signals for graceful shutdown.
-func handleSignals(ctx context.Context, srv *server.Server, logger *slog.Logger) {
+// handleSignals handles OS signals: SIGHUP reloads the configuration and
+// the others shut the server down gracefully.
+func handleSignals(ctx context.Context, srv *server.Server, logger *slog.Logger, flags *pflag.FlagSet) {
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case sig := <-sigChan:
+			if sig == syscall.SIGHUP {
+				logger.Info("Received reload signal", "signal", sig)
+				if err := reloadConfig(srv, flags); err != nil {
+					logger.Error("Reload error", "error", err)
+				}
+				continue
+			}
+
 			logger.Info("Received shutdown signal", "signal", sig)
 
 			shutdownCtx, cancel := context.WithTimeout(
@@ -119,32 +132,27 @@ func handleSignals(ctx context.Context, srv *server.Server, logger *slog.Logger)
 	}
 }
 
-// initLogging initializes the logging system.
-func initLogging(cfg config.LoggingConfig) (*slog.Logger, error) {
-	// Parse log level
-	var level slog.Level
-	switch cfg.Level {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	default:
-		level = slog.LevelInfo
+// reloadConfig loads the configuration again, from the same file and
+// flags, and applies the settings that can change while the server runs.
+func reloadConfig(srv *server.Server, flags *pflag.FlagSet) error {
+	cfg, err := config.LoadWithFlags(cfgFile, flags)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
 	}
+	return srv.Reload(cfg)
+}
+
+// initLogging initializes the logging system. The level of the logger is
+// the returned LevelVar, which is changed when the configuration is
+// reloaded.
+func initLogging(cfg config.LoggingConfig) (*slog.Logger, *slog.LevelVar, error) {
+	level := new(slog.LevelVar)
+	level.Set(cfg.SlogLevel())
 
 	// Determine output
-	var output io.Writer
-	if cfg.Output == "stdout" || cfg.Output == "" {
-		output = os.Stdout
-	} else {
-		// For file output, we would use lumberjack here
-		// Since it's not in dependencies yet, fall back to stdout
-		fmt.Fprintf(os.Stderr, "Warning: File logging not yet implemented, using stdout\n")
-		output = os.Stdout
+	output, err := logOutput(cfg)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Create handler based on format
@@ -157,7 +165,38 @@ func initLogging(cfg config.LoggingConfig) (*slog.Logger, error) {
 		handler = slog.NewTextHandler(output, opts)
 	}
 
-	return slog.New(handler), nil
+	return slog.New(handler), level, nil
+}
+
+// logOutput returns the writer for the configured log output, which is
+// stdout, stderr or the path of a file rotated once it reaches MaxSize
+// megabytes.
+func logOutput(cfg config.LoggingConfig) (io.Writer, error) {
+	switch cfg.Output {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Output), 0755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+
+	// The rotating writer opens the file on the first write, so check now
+	// that it can be opened rather than losing the logs later.
+	f, err := os.OpenFile(cfg.Output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+	f.Close()
+
+	return &lumberjack.Logger{
+		Filename:   cfg.Output,
+		MaxSize:    cfg.MaxSize,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAge,
+	}, nil
 }
 
 // printVersion prints version information.
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/config"
+	"github.com/turtacn/guocedb/server"
+)
+
+func TestInitLogging_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "guocedb.log")
+
+	logger, _, err := initLogging(config.LoggingConfig{
+		Level:      "info",
+		Format:     "json",
+		Output:     path,
+		MaxSize:    1,
+		MaxBackups: 2,
+		MaxAge:     1,
+	})
+	require.NoError(t, err)
+
+	logger.Info("hello from the log file", "answer", 42)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"msg":"hello from the log file"`)
+	require.Contains(t, string(data), `"answer":42`)
+}
+
+func TestInitLogging_FileError(t *testing.T) {
+	// A regular file can't be used as the log directory.
+	parent := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(parent, nil, 0644))
+
+	_, _, err := initLogging(config.LoggingConfig{
+		Level:  "info",
+		Output: filepath.Join(parent, "guocedb.log"),
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "log directory")
+}
+
+func TestReloadConfig(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "guocedb.log")
+	writeConfig := func(port int, level string) {
+		t.Helper()
+		data := fmt.Sprintf(`server:
+  port: %d
+storage:
+  data_dir: %s
+logging:
+  level: %s
+  format: text
+  output: %s
+`, port, filepath.Join(dir, "data"), level, logPath)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "guocedb.yaml"), []byte(data), 0644))
+	}
+
+	defer func(path string) { cfgFile = path }(cfgFile)
+	cfgFile = filepath.Join(dir, "guocedb.yaml")
+	writeConfig(3306, "info")
+
+	cfg, err := config.LoadWithFlags(cfgFile, nil)
+	require.NoError(t, err)
+	logger, level, err := initLogging(cfg.Logging)
+	require.NoError(t, err)
+	srv, err := server.NewWithOptions(cfg, server.WithLogger(logger), server.WithLogLevel(level))
+	require.NoError(t, err)
+
+	readLog := func() string {
+		data, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	logger.Debug("debug before the reload")
+	logger.Info("info before the reload")
+	require.NotContains(t, readLog(), "debug before the reload")
+
+	writeConfig(3306, "debug")
+	require.NoError(t, reloadConfig(srv, nil))
+	logger.Debug("debug after the reload")
+	require.Contains(t, readLog(), "debug after the reload")
+	require.Equal(t, "debug", srv.Config().Logging.Level)
+
+	// Reloads changing settings that need a restart are rejected whole.
+	writeConfig(3307, "info")
+	err = reloadConfig(srv, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "server.port")
+	logger.Debug("debug after the rejected reload")
+	require.Contains(t, readLog(), "debug after the rejected reload")
+	require.Equal(t, 3306, srv.Config().Server.Port)
+}
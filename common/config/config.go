@@ -23,6 +23,7 @@ type ServerConfig struct {
 	Host          string `mapstructure:"host"`
 	Port          int    `mapstructure:"port"`
 	GRPCPort      int    `mapstructure:"grpcPort"`
+	HTTPPort      int    `mapstructure:"httpPort"`
 	MaxConnections int    `mapstructure:"maxConnections"`
 	Timeout       int    `mapstructure:"timeout"`
 	Version       string `mapstructure:"version"`
@@ -37,8 +38,11 @@ type StorageConfig struct {
 
 // BadgerConfig holds configuration specific to the Badger storage engine.
 type BadgerConfig struct {
-	ValueLogFileSize int `mapstructure:"valueLogFileSize"`
+	ValueLogFileSize int  `mapstructure:"valueLogFileSize"`
 	SyncWrites       bool `mapstructure:"syncWrites"`
+	// RecoverCorruption drops the values of a corrupted value log that
+	// can't be read, so the database can be opened.
+	RecoverCorruption bool `mapstructure:"recoverCorruption"`
 }
 
 // SecurityConfig holds security-related configuration.
@@ -76,6 +80,7 @@ func Load(configPath string) (*Config, error) {
 	v.SetDefault("storage.dataDir", "/var/lib/guocedb")
 	v.SetDefault("storage.badger.valueLogFileSize", 1<<30) // 1GB
 	v.SetDefault("storage.badger.syncWrites", true)
+	v.SetDefault("storage.badger.recoverCorruption", true)
 	v.SetDefault("security.enableTls", false)
 	v.SetDefault("log.level", "info")
 	v.SetDefault("log.format", "text")
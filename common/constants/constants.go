@@ -29,6 +29,7 @@ const (
 	StorageEngineKVD    = "kvd"
 	StorageEngineMDD    = "mdd"
 	StorageEngineMDI    = "mdi"
+	StorageEngineMemory = "memory"
 )
 
 // Error message templates
@@ -48,4 +49,10 @@ const (
 	ErrCodeSyntax  = 1001
 	ErrCodeRuntime = 1002
 	ErrCodeSystem  = 1003
+	// ErrCodeSerialization is the code of the errors encoding or decoding
+	// values.
+	ErrCodeSerialization = 1004
+	// ErrCodeTimeout is the code of the errors of queries interrupted for
+	// running longer than the maximum execution time.
+	ErrCodeTimeout = 1005
 )
@@ -2,10 +2,12 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"net/http"
 
 	"github.com/turtacn/guocedb/common/constants"
+	"github.com/turtacn/guocedb/common/types/enum"
 )
 
 // Error represents a custom error with a code, message, and an underlying error.
@@ -48,6 +50,45 @@ func (e *Error) Unwrap() error {
 	return e.Err
 }
 
+// CodedError is an error along with the ErrorCode of its kind.
+type CodedError struct {
+	Code enum.ErrorCode
+	Err  error
+}
+
+// WithCode returns err along with code. It returns nil if err is nil.
+func WithCode(err error, code enum.ErrorCode) error {
+	if err == nil {
+		return nil
+	}
+	return &CodedError{Code: code, Err: err}
+}
+
+// NewCoded creates a new error with the given code and message.
+func NewCoded(code enum.ErrorCode, message string) error {
+	return &CodedError{Code: code, Err: stderrors.New(message)}
+}
+
+// Error returns the message of the underlying error.
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code of the first CodedError in the chain of err, or
+// enum.UnknownError if there is none.
+func CodeOf(err error) enum.ErrorCode {
+	var coded *CodedError
+	if stderrors.As(err, &coded) {
+		return coded.Code
+	}
+	return enum.UnknownError
+}
+
 // ToHTTPStatusCode maps an error code to an HTTP status code.
 // This is a simplified mapping.
 func ToHTTPStatusCode(code int) int {
@@ -58,6 +99,8 @@ func ToHTTPStatusCode(code int) int {
 		return http.StatusInternalServerError
 	case constants.ErrCodeSystem:
 		return http.StatusServiceUnavailable
+	case constants.ErrCodeTimeout:
+		return http.StatusGatewayTimeout
 	default:
 		return http.StatusInternalServerError
 	}
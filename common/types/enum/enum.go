@@ -132,3 +132,57 @@ func (s SystemStatus) String() string {
 		return "Unknown"
 	}
 }
+
+// ErrorCode classifies an error by its kind, so it can be handled, and
+// reported to clients with the right MySQL error, without looking at its
+// message.
+type ErrorCode int
+
+const (
+	// UnknownError is the code of the errors that were not classified.
+	UnknownError ErrorCode = iota
+	// DuplicateKey is the code of the writes of a key that already exists.
+	DuplicateKey
+	// Deadlock is the code of the transactions rolled back to break a
+	// deadlock.
+	Deadlock
+	// TransactionConflict is the code of the transactions that can't
+	// commit because another one changed the data they read.
+	TransactionConflict
+	// ConstraintViolation is the code of the writes of rows that don't
+	// satisfy a constraint of their table.
+	ConstraintViolation
+	// ReadOnlyTransaction is the code of the writes in a read-only
+	// transaction.
+	ReadOnlyTransaction
+	// LockWaitTimeout is the code of the statements that waited too long
+	// for a lock held by another transaction.
+	LockWaitTimeout
+	// DataCorruption is the code of the reads and writes of tables whose
+	// stored data was found corrupted.
+	DataCorruption
+)
+
+// String returns the string representation of an ErrorCode.
+func (c ErrorCode) String() string {
+	switch c {
+	case UnknownError:
+		return "UnknownError"
+	case DuplicateKey:
+		return "DuplicateKey"
+	case Deadlock:
+		return "Deadlock"
+	case TransactionConflict:
+		return "TransactionConflict"
+	case ConstraintViolation:
+		return "ConstraintViolation"
+	case ReadOnlyTransaction:
+		return "ReadOnlyTransaction"
+	case LockWaitTimeout:
+		return "LockWaitTimeout"
+	case DataCorruption:
+		return "DataCorruption"
+	default:
+		return "Unknown"
+	}
+}
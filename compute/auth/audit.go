@@ -104,6 +104,22 @@ func (a *Audit) Allowed(ctx *sql.Context, permission Permission) error {
 	return err
 }
 
+// AllowedOn implements DatabaseAuth interface.
+func (a *Audit) AllowedOn(ctx *sql.Context, database string, permission Permission) error {
+	err := AllowedOn(a.auth, ctx, database, permission)
+	a.method.Authorization(ctx, permission, err)
+
+	return err
+}
+
+// AllowedOnTable implements TableAuth interface.
+func (a *Audit) AllowedOnTable(ctx *sql.Context, database, table string, columns []string, permission Permission) error {
+	err := AllowedOnTable(a.auth, ctx, database, table, columns, permission)
+	a.method.Authorization(ctx, permission, err)
+
+	return err
+}
+
 // Query implements AuditQuery interface.
 func (a *Audit) Query(ctx *sql.Context, d time.Duration, err error) {
 	if q, ok := a.auth.(*Audit); ok {
@@ -156,6 +172,10 @@ func auditInfo(ctx *sql.Context, err error) logrus.Fields {
 		"success":       true,
 	}
 
+	if attrs := ctx.Client().Attributes; len(attrs) > 0 {
+		fields["connection_attributes"] = attrs
+	}
+
 	if err != nil {
 		fields["success"] = false
 		fields["err"] = err
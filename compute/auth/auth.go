@@ -60,3 +60,42 @@ type Auth interface {
 	// Otherwise is an error using the authentication method.
 	Allowed(ctx *sql.Context, permission Permission) error
 }
+
+// DatabaseAuth is an Auth whose permissions may be granted on some
+// databases only.
+type DatabaseAuth interface {
+	Auth
+	// AllowedOn checks user's permissions on the given database as Allowed
+	// does on the current one.
+	AllowedOn(ctx *sql.Context, database string, permission Permission) error
+}
+
+// AllowedOn checks user's permissions on a database with the given auth.
+// The permissions of an auth that is not a DatabaseAuth are the same on
+// every database.
+func AllowedOn(a Auth, ctx *sql.Context, database string, permission Permission) error {
+	if da, ok := a.(DatabaseAuth); ok {
+		return da.AllowedOn(ctx, database, permission)
+	}
+	return a.Allowed(ctx, permission)
+}
+
+// TableAuth is a DatabaseAuth whose permissions may be granted on some
+// tables, or some columns of them, only.
+type TableAuth interface {
+	DatabaseAuth
+	// AllowedOnTable checks user's permissions on a table of the given
+	// database. If columns are given, the permissions are checked on each
+	// of them instead, as they may be granted on some columns only.
+	AllowedOnTable(ctx *sql.Context, database, table string, columns []string, permission Permission) error
+}
+
+// AllowedOnTable checks user's permissions on a table with the given auth.
+// The permissions of an auth that is not a TableAuth are those it has on
+// the database of the table.
+func AllowedOnTable(a Auth, ctx *sql.Context, database, table string, columns []string, permission Permission) error {
+	if ta, ok := a.(TableAuth); ok {
+		return ta.AllowedOnTable(ctx, database, table, columns, permission)
+	}
+	return AllowedOn(a, ctx, database, permission)
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
@@ -10,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/turtacn/guocedb/compute/sql"
+	secauth "github.com/turtacn/guocedb/security/auth"
 
 	"github.com/dolthub/vitess/go/mysql"
 	"gopkg.in/src-d/go-errors.v1"
@@ -166,3 +168,35 @@ func (s *Native) Allowed(ctx *sql.Context, permission Permission) error {
 
 	return u.Allowed(permission)
 }
+
+var _ secauth.ScrambleProvider = (*Native)(nil)
+
+// Authenticate implements secauth.Provider. The scramble is checked if
+// there is one, and the password otherwise. The user has the privileges of
+// its permissions.
+func (s *Native) Authenticate(ctx context.Context, user string, credential secauth.Credential, clientAddr string) (*secauth.User, error) {
+	u, ok := s.users[user]
+	if !ok {
+		return nil, secauth.ErrAuthenticationFailed
+	}
+
+	if credential.Scramble != nil || credential.Salt != nil {
+		ok = secauth.VerifyMySQLNativeScramble(credential.Salt, credential.Scramble, u.Password)
+	} else {
+		ok = NativePassword(credential.Password) == u.Password
+	}
+	if !ok {
+		return nil, secauth.ErrAuthenticationFailed
+	}
+
+	return &secauth.User{
+		Username:           u.Name,
+		NativePasswordHash: u.Password,
+		Privileges:         privilegesOf(u.Permissions),
+	}, nil
+}
+
+// AcceptsScrambles implements secauth.ScrambleProvider.
+func (s *Native) AcceptsScrambles() bool {
+	return true
+}
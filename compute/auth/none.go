@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"context"
+
 	"github.com/turtacn/guocedb/compute/sql"
+	secauth "github.com/turtacn/guocedb/security/auth"
 
 	"github.com/dolthub/vitess/go/mysql"
 )
@@ -23,3 +26,16 @@ func (n *None) Mysql() mysql.AuthServer {
 func (n *None) Allowed(ctx *sql.Context, permission Permission) error {
 	return nil
 }
+
+var _ secauth.ScrambleProvider = (*None)(nil)
+
+// Authenticate implements secauth.Provider. Everyone is let in, with all
+// the privileges.
+func (n *None) Authenticate(ctx context.Context, user string, credential secauth.Credential, clientAddr string) (*secauth.User, error) {
+	return secauth.NoneProvider{}.Authenticate(ctx, user, credential, clientAddr)
+}
+
+// AcceptsScrambles implements secauth.ScrambleProvider.
+func (n *None) AcceptsScrambles() bool {
+	return true
+}
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/security"
+	secauth "github.com/turtacn/guocedb/security/auth"
+	"github.com/turtacn/guocedb/security/authz"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/vt/proto/query"
+)
+
+// Security authenticates the users of a security manager with
+// mysql_native_password, checking their passwords against the hashes it
+// stores, and grants them the permissions of their privileges.
+type Security struct {
+	sm *security.SecurityManager
+}
+
+// NewSecurity creates a Security auth for the users of the manager.
+func NewSecurity(sm *security.SecurityManager) *Security {
+	return &Security{sm}
+}
+
+// Mysql implements Auth interface. The clients log in with
+// mysql_native_password, unless the authentication provider of the manager
+// needs their passwords, which they then send with mysql_clear_password.
+// The listener refuses to receive them without TLS unless it's configured
+// to allow it.
+func (s *Security) Mysql() mysql.AuthServer {
+	a := &securityAuthServer{sm: s.sm}
+	if s.sm.AcceptsScrambles() {
+		a.methods = []mysql.AuthMethod{mysql.NewMysqlNativeAuthMethod(a, a)}
+	} else {
+		a.methods = []mysql.AuthMethod{mysql.NewMysqlClearAuthMethod(a, a)}
+	}
+	return a
+}
+
+// Allowed implements Auth interface. Reading needs the SELECT privilege on
+// the current database, and writing the INSERT, UPDATE and DELETE ones.
+func (s *Security) Allowed(ctx *sql.Context, permission Permission) error {
+	return s.AllowedOn(ctx, ctx.GetCurrentDatabase(), permission)
+}
+
+// AllowedOn implements DatabaseAuth interface. The privileges are those
+// the user has on the given database.
+func (s *Security) AllowedOn(ctx *sql.Context, database string, permission Permission) error {
+	user, err := s.sm.GetUser(ctx, ctx.Client().User)
+	if err != nil || user == nil {
+		return ErrNotAuthorized.Wrap(ErrNoPermission.New(permission))
+	}
+
+	if err := s.sm.CheckPrivilege(ctx, user, database, "", privilegesOf(permission)); err != nil {
+		return ErrNotAuthorized.Wrap(ErrNoPermission.New(permission))
+	}
+	return nil
+}
+
+// AllowedOnTable implements TableAuth interface. The privileges granted on
+// the table or its columns win over those the user has on the database.
+func (s *Security) AllowedOnTable(ctx *sql.Context, database, table string, columns []string, permission Permission) error {
+	user, err := s.sm.GetUser(ctx, ctx.Client().User)
+	if err != nil || user == nil {
+		return ErrNotAuthorized.Wrap(ErrNoPermission.New(permission))
+	}
+
+	privileges := privilegesOf(permission)
+	if len(columns) == 0 {
+		err = s.sm.CheckPrivilege(ctx, user, database, table, privileges)
+	}
+	for _, column := range columns {
+		if err = s.sm.CheckColumnPrivilege(ctx, user, database, table, column, privileges); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		return ErrNotAuthorized.Wrap(ErrNoPermission.New(permission))
+	}
+	return nil
+}
+
+// privilegesOf returns the privileges needed to have the permissions.
+func privilegesOf(permission Permission) authz.Privilege {
+	var privileges authz.Privilege
+	if permission&ReadPerm != 0 {
+		privileges |= authz.PrivilegeSelect
+	}
+	if permission&WritePerm != 0 {
+		privileges |= authz.PrivilegeInsert | authz.PrivilegeUpdate | authz.PrivilegeDelete
+	}
+	return privileges
+}
+
+// securityAuthServer is the mysql.AuthServer of a Security auth.
+type securityAuthServer struct {
+	sm      *security.SecurityManager
+	methods []mysql.AuthMethod
+}
+
+// AuthMethods implements mysql.AuthServer.
+func (a *securityAuthServer) AuthMethods() []mysql.AuthMethod {
+	return a.methods
+}
+
+// DefaultAuthMethodDescription implements mysql.AuthServer.
+func (a *securityAuthServer) DefaultAuthMethodDescription() mysql.AuthMethodDescription {
+	return mysql.MysqlNativePassword
+}
+
+// HandleUser implements mysql.UserValidator. Unknown users are handled too,
+// so they fail like the ones giving a wrong password.
+func (a *securityAuthServer) HandleUser(user string, remoteAddr net.Addr) bool {
+	return true
+}
+
+// UserEntryWithHash implements mysql.HashStorage.
+func (a *securityAuthServer) UserEntryWithHash(userCerts []*x509.Certificate, salt []byte, user string, authResponse []byte, remoteAddr net.Addr) (mysql.Getter, error) {
+	_, err := a.sm.AuthenticateNative(context.Background(), user, salt, authResponse, clientIP(remoteAddr))
+	return authenticated(user, err)
+}
+
+// UserEntryWithPassword implements mysql.PlainTextStorage.
+func (a *securityAuthServer) UserEntryWithPassword(userCerts []*x509.Certificate, user string, password string, remoteAddr net.Addr) (mysql.Getter, error) {
+	_, err := a.sm.Authenticate(context.Background(), user, password, clientIP(remoteAddr))
+	return authenticated(user, err)
+}
+
+// authenticated returns the data of the user if it was authenticated, and
+// the MySQL error for the reason it wasn't otherwise.
+func authenticated(user string, err error) (mysql.Getter, error) {
+	if err == secauth.ErrAuthenticationFailed {
+		return nil, mysql.NewSQLError(mysql.ERAccessDeniedError, mysql.SSAccessDeniedError,
+			"Access denied for user '%v'", user)
+	}
+	if ae, ok := err.(*secauth.AuthError); ok {
+		// The account is locked or its password expired.
+		return nil, mysql.NewSQLError(mysql.ERAccessDeniedError, mysql.SSAccessDeniedError,
+			"Access denied for user '%v': %s", user, ae.Message)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return securityUserData{user}, nil
+}
+
+// clientIP returns the IP address of a client, or "" if it's unknown.
+func clientIP(remoteAddr net.Addr) string {
+	if remoteAddr == nil {
+		return ""
+	}
+	ip, _, _ := net.SplitHostPort(remoteAddr.String())
+	return ip
+}
+
+// securityUserData is the data of a user authenticated by a Security auth.
+type securityUserData struct {
+	username string
+}
+
+// Get implements mysql.Getter.
+func (d securityUserData) Get() *query.VTGateCallerID {
+	return &query.VTGateCallerID{Username: d.username}
+}
@@ -2,11 +2,17 @@
 package executor
 
 import (
+	"context"
+	"io"
+	"sync/atomic"
+
 	"github.com/turtacn/guocedb/compute/sql"
 	"github.com/turtacn/guocedb/common/errors"
 	"github.com/turtacn/guocedb/compute/analyzer"
 	"github.com/turtacn/guocedb/compute/optimizer"
 	"github.com/turtacn/guocedb/compute/parser"
+	"github.com/turtacn/guocedb/compute/sql/expression/function"
+	"github.com/turtacn/guocedb/compute/sql/plan"
 	"github.com/turtacn/guocedb/compute/auth"
 	"github.com/turtacn/guocedb/common/constants"
 )
@@ -19,45 +25,141 @@ type Engine struct {
 	optimizer optimizer.Optimizer
 	Catalog   *sql.Catalog
 	Auth      auth.Auth
+	parses    atomic.Uint64 // Queries parsed
 }
 
-// NewEngine creates a new query execution engine.
+// NewEngine creates a new query execution engine. The default functions are
+// registered in the catalog.
 func NewEngine(a *analyzer.Analyzer, o optimizer.Optimizer, c *sql.Catalog) *Engine {
+	c.RegisterFunctions(function.Defaults)
 	return &Engine{
 		parser:    parser.NewParser(),
 		analyzer:  a,
 		optimizer: o,
 		Catalog:   c,
-		Auth:      auth.NewNativeSingle("root", "", auth.AllPermissions), // Default auth
+		// Queries run without a server are not checked; the server sets
+		// the auth of its users.
+		Auth: auth.NewNone(),
 	}
 }
 
 // Query executes a SQL query and returns the resulting rows and schema.
+// The query is interrupted once the context is done, because it timed out or
+// was killed.
 func (e *Engine) Query(ctx *sql.Context, query string) (sql.Schema, sql.RowIter, error) {
-	// 1. Parse the query to get the AST
-	parsedNode, err := e.parser.Parse(ctx, query)
+	optimizedNode, err := e.Analyze(ctx, query)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// 2. Analyze the AST to create a logical plan
-	analyzedNode, err := e.analyzer.Analyze(ctx, parsedNode)
-	if err != nil {
-		return nil, nil, err
-	}
+	return e.execute(ctx, optimizedNode)
+}
 
-	// 3. Optimize the logical plan to create a physical plan
-	optimizedNode, err := e.optimizer.Optimize(ctx, analyzedNode)
+// QueryPrepared binds values to the parameters of a query parsed by
+// Prepare, and executes it as Query does. The parsed query is kept as it
+// is, so it can be executed again with other values.
+func (e *Engine) QueryPrepared(ctx *sql.Context, parsed sql.Node, bindings map[string]sql.Expression) (sql.Schema, sql.RowIter, error) {
+	optimizedNode, err := e.AnalyzePrepared(ctx, parsed, bindings)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	return e.execute(ctx, optimizedNode)
+}
+
+func (e *Engine) execute(ctx *sql.Context, optimizedNode sql.Node) (sql.Schema, sql.RowIter, error) {
 	// 4. Execute the physical plan
 	// The GMS plan nodes have an Execute method that returns a RowIter.
 	rowIter, err := optimizedNode.RowIter(ctx)
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, interrupted(ctxErr)
+		}
 		return nil, nil, errors.Wrapf(err, constants.ErrCodeRuntime, "failed to execute query")
 	}
 
-	return optimizedNode.Schema(), rowIter, nil
+	return optimizedNode.Schema(), &contextRowIter{ctx: ctx, RowIter: rowIter}, nil
+}
+
+// interrupted returns the error of a query interrupted because its context
+// is done with the given error.
+func interrupted(err error) error {
+	if err == context.DeadlineExceeded {
+		return errors.Wrapf(err, constants.ErrCodeTimeout, "query execution was interrupted, maximum statement execution time exceeded")
+	}
+	return errors.Wrapf(err, constants.ErrCodeRuntime, "query execution was interrupted")
+}
+
+// contextRowIter stops returning rows once its context is done.
+type contextRowIter struct {
+	ctx *sql.Context
+	sql.RowIter
+}
+
+func (i *contextRowIter) Next() (sql.Row, error) {
+	if err := i.ctx.Err(); err != nil {
+		return nil, interrupted(err)
+	}
+
+	row, err := i.RowIter.Next()
+	if err != nil && err != io.EOF {
+		if ctxErr := i.ctx.Err(); ctxErr != nil {
+			return nil, interrupted(ctxErr)
+		}
+	}
+	return row, err
+}
+
+// Analyze returns the physical plan of a SQL query without executing it.
+func (e *Engine) Analyze(ctx *sql.Context, query string) (sql.Node, error) {
+	// 1. Parse the query to get the AST
+	parsedNode, err := e.parse(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.analyze(ctx, parsedNode)
+}
+
+// Prepare parses a query whose parameters, written as ?, are bound each
+// time it's executed with QueryPrepared, so it's only parsed once.
+func (e *Engine) Prepare(ctx *sql.Context, query string) (sql.Node, error) {
+	return e.parse(ctx, query)
+}
+
+// Parses returns the number of queries the engine has parsed, which the
+// queries whose parsed plans are reused don't add to.
+func (e *Engine) Parses() uint64 {
+	return e.parses.Load()
+}
+
+func (e *Engine) parse(ctx *sql.Context, query string) (sql.Node, error) {
+	e.parses.Add(1)
+	return e.parser.Parse(ctx, query)
+}
+
+// AnalyzePrepared returns the physical plan of a query parsed by Prepare
+// with the given values bound to its parameters.
+func (e *Engine) AnalyzePrepared(ctx *sql.Context, parsed sql.Node, bindings map[string]sql.Expression) (sql.Node, error) {
+	bound, err := plan.BindVars(parsed, bindings)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.analyze(ctx, bound)
+}
+
+func (e *Engine) analyze(ctx *sql.Context, parsedNode sql.Node) (sql.Node, error) {
+	if err := e.checkPrivileges(ctx, parsedNode); err != nil {
+		return nil, err
+	}
+
+	// 2. Analyze the AST to create a logical plan
+	analyzedNode, err := e.analyzer.Analyze(ctx, parsedNode)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Optimize the logical plan to create a physical plan
+	return e.optimizer.Optimize(ctx, analyzedNode)
 }
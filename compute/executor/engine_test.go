@@ -2,13 +2,24 @@ package executor
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
 	"io"
+	"strings"
 	"testing"
+	"time"
 
+	badgerdb "github.com/dgraph-io/badger/v3"
+	"github.com/turtacn/guocedb/common/constants"
+	cerrors "github.com/turtacn/guocedb/common/errors"
 	"github.com/turtacn/guocedb/compute/sql"
 	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
 	"github.com/turtacn/guocedb/compute/optimizer"
 	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/storage/engines/badger"
+	"github.com/turtacn/guocedb/storage/engines/memory"
+	"github.com/turtacn/guocedb/storage/sal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -55,3 +66,1214 @@ func TestEngine_Execute_Simple(t *testing.T) {
 	// 1 is parsed as IntVal, which `convertVal` in parser converts to int64.
 	assert.Equal(t, int64(1), rows[0][0])
 }
+
+func TestEngine_Query_Returning(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	db := mem.NewDatabase("test_db")
+	db.AddTable("t", mem.NewTable("t", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "t"},
+		{Name: "name", Type: sql.Text, Source: "t", Nullable: true},
+	}))
+	c.AddDatabase(db)
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) (sql.Schema, []sql.Row) {
+		schema, iter, err := e.Query(ctx, q)
+		require.NoError(err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, q)
+		return schema, rows
+	}
+
+	schema, rows := query("INSERT INTO t (id, name) VALUES (1, 'a'), (2, 'b') RETURNING id")
+	require.Len(schema, 1)
+	require.Equal("id", schema[0].Name)
+	require.Equal([]sql.Row{{int64(1)}, {int64(2)}}, rows)
+
+	_, rows = query("UPDATE t SET name = 'c' WHERE id = 2 RETURNING *")
+	require.Equal([]sql.Row{{int64(2), "c"}}, rows)
+
+	_, rows = query("DELETE FROM t WHERE id = 1 RETURNING name")
+	require.Equal([]sql.Row{{"a"}}, rows)
+
+	_, rows = query("SELECT id, name FROM t")
+	require.Equal([]sql.Row{{int64(2), "c"}}, rows)
+
+	_, rows = query("UPDATE t SET name = 'd'")
+	require.Equal([]sql.Row{{int64(1)}}, rows)
+}
+
+func TestEngine_Query_InformationSchemaProcessList(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	c.AddDatabase(mem.NewDatabase("test_db"))
+	c.AddDatabase(sql.NewInformationSchemaDatabase(c))
+	c.SetCurrentDatabase("test_db")
+
+	for i, user := range []string{"foo", "bar", "foo"} {
+		sess := sql.NewSession("localhost:3306", "127.0.0.1:3456"+fmt.Sprint(i), user, uint32(i+1))
+		ctx := sql.NewContext(context.Background(), sql.WithPid(uint64(i+1)), sql.WithSession(sess))
+		_, err := c.AddProcess(ctx, sql.QueryProcess, fmt.Sprintf("SELECT %d", i))
+		require.NoError(err)
+	}
+	c.Done(3)
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	_, iter, err := e.Query(ctx, `
+		SELECT p.id, p.user, p.host, s.schema_name, s.default_character_set_name, p.info
+		FROM information_schema.processlist p
+		INNER JOIN information_schema.schemata s ON p.db = s.schema_name
+		WHERE p.user = 'foo'`)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+
+	require.Equal([]sql.Row{
+		{int64(1), "foo", "127.0.0.1:34560", "test_db", "utf8mb4", "SELECT 0"},
+	}, rows)
+}
+
+func TestEngine_Query_DropProtectedDatabase(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	c.AddDatabase(mem.NewDatabase("prod"))
+	c.ProtectDatabases("prod")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	exec := func(q string) error {
+		_, iter, err := e.Query(ctx, q)
+		if err != nil {
+			return err
+		}
+		_, err = sql.RowIterToRows(iter)
+		return err
+	}
+
+	require.ErrorContains(exec("DROP DATABASE prod"), "database prod is protected")
+	require.ErrorContains(exec("DROP DATABASE IF EXISTS prod"), "database prod is protected")
+
+	_, err := c.Database("prod")
+	require.NoError(err)
+
+	require.NoError(exec("SET drop_database_override = 'prod'"))
+	require.NoError(exec("DROP DATABASE prod"))
+
+	_, err = c.Database("prod")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+}
+
+func TestEngine_Query_ShowCreateTableRoundTrip(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	db := mem.NewDatabase("test_db")
+	c.AddDatabase(db)
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, q)
+		return rows
+	}
+
+	query(`CREATE TABLE t1 (a INT NOT NULL, b BIGINT, c TEXT, d DOUBLE)
+		ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=latin1 COLLATE=latin1_bin
+		ROW_FORMAT=compressed COMMENT='it''s a \\ test' KEY_BLOCK_SIZE=8`)
+
+	warnings := ctx.Warnings()
+	require.Len(warnings, 1)
+	require.Equal(1478, warnings[0].Code)
+	require.Contains(warnings[0].Message, "KEY_BLOCK_SIZE")
+
+	rows := query("SHOW CREATE TABLE t1")
+	require.Len(rows, 1)
+	stmt := rows[0][1].(string)
+	require.Equal("CREATE TABLE `t1` (`a` INT NOT NULL,\n"+
+		"`b` BIGINT,\n"+
+		"`c` TEXT,\n"+
+		"`d` DOUBLE) ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=latin1 "+
+		"COLLATE=latin1_bin ROW_FORMAT=COMPRESSED COMMENT='it''s a \\\\ test'", stmt)
+
+	query(strings.Replace(stmt, "`t1`", "`t2`", 1))
+
+	table, ok := db.Tables()["t2"]
+	require.True(ok)
+	require.Equal(sql.TableOptions{
+		sql.TableOptionEngine:        "InnoDB",
+		sql.TableOptionAutoIncrement: "42",
+		sql.TableOptionCharset:       "latin1",
+		sql.TableOptionCollate:       "latin1_bin",
+		sql.TableOptionRowFormat:     "COMPRESSED",
+		sql.TableOptionComment:       `it's a \ test`,
+	}, table.(sql.TableOptioner).TableOptions())
+
+	require.Equal(sql.Schema{
+		{Name: "a", Type: sql.Int32, Source: "t2"},
+		{Name: "b", Type: sql.Int64, Source: "t2", Nullable: true},
+		{Name: "c", Type: sql.Text, Source: "t2", Nullable: true},
+		{Name: "d", Type: sql.Float64, Source: "t2", Nullable: true},
+	}, table.Schema())
+
+	rows = query("SHOW CREATE TABLE t2")
+	require.Equal(strings.Replace(stmt, "`t1`", "`t2`", 1), rows[0][1])
+}
+
+func TestEngine_Query_HashJoin(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	db := mem.NewDatabase("test_db")
+	for _, name := range []string{"a", "b"} {
+		table := mem.NewPartitionedTable(name, sql.Schema{
+			{Name: "id", Type: sql.Int64, Source: name},
+			{Name: "name", Type: sql.Text, Source: name},
+		}, 2)
+		for i := 0; i < 300; i++ {
+			row := sql.NewRow(int64(i), fmt.Sprintf("%s%d", name, i))
+			require.NoError(table.Insert(sql.NewEmptyContext(), row))
+		}
+		db.AddTable(name, table)
+	}
+	c.AddDatabase(db)
+	c.SetCurrentDatabase("test_db")
+
+	o := optimizer.NewOptimizer()
+	o.JoinMemoryBudget = 1 << 10
+	o.TempDir = t.TempDir()
+
+	e := NewEngine(analyzer.NewAnalyzer(c), o, c)
+	ctx := sql.NewContext(context.Background())
+
+	_, iter, err := e.Query(ctx, "SELECT a.name, b.name FROM a INNER JOIN b ON a.id = b.id WHERE a.id < 100")
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+
+	require.Len(rows, 100)
+	for _, row := range rows {
+		require.Equal(row[0].(string)[1:], row[1].(string)[1:])
+	}
+}
+
+func TestEngine_Query_ShowEngines(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	c.AddDatabase(mem.NewDatabase("test_db"))
+	c.SetCurrentDatabase("test_db")
+	sal.RegisterEngines(c.EngineRegistry)
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	schema, iter, err := e.Query(sql.NewContext(context.Background()), "SHOW ENGINES")
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+
+	var columns []string
+	for _, col := range schema {
+		columns = append(columns, col.Name)
+	}
+	require.Equal([]string{"Engine", "Support", "Comment", "Transactions", "XA", "Savepoints"}, columns)
+
+	engines := make(map[string]sql.Row)
+	for _, row := range rows {
+		engines[row[0].(string)] = row
+	}
+
+	require.Contains(engines, "badger")
+	require.Equal("DEFAULT", engines["badger"][1])
+	require.Equal("YES", engines["badger"][3])
+
+	require.Contains(engines, "memory")
+	require.Equal("YES", engines["memory"][1])
+}
+
+func TestEngine_Query_JSON(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) ([]sql.Row, error) {
+		_, iter, err := e.Query(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		return sql.RowIterToRows(iter)
+	}
+
+	_, err = query("CREATE TABLE t (id BIGINT, doc JSON)")
+	require.NoError(err)
+	_, err = query(`INSERT INTO t VALUES (1, '{"a":{"b":[1,2,3]}}')`)
+	require.NoError(err)
+
+	_, err = query(`INSERT INTO t VALUES (2, '{"a":')`)
+	require.ErrorContains(err, "invalid JSON text")
+
+	rows, err := query(`SELECT doc, JSON_EXTRACT(doc, '$.a.b[1]'), doc->'$.a.b[*]', doc->'$.x' FROM t`)
+	require.NoError(err)
+	require.Equal([]sql.Row{{
+		[]byte(`{"a":{"b":[1,2,3]}}`),
+		float64(2),
+		[]interface{}{1., 2., 3.},
+		nil,
+	}}, rows)
+	require.Equal("2", sql.JSON.SQL(rows[0][1]).ToString())
+}
+
+func TestEngine_Query_AlterTable(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) ([]sql.Row, error) {
+		_, iter, err := e.Query(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		return sql.RowIterToRows(iter)
+	}
+
+	_, err = query("CREATE TABLE t (id BIGINT, name TEXT)")
+	require.NoError(err)
+	_, err = query("INSERT INTO t VALUES (1, 'a'), (2, 'b')")
+	require.NoError(err)
+
+	_, err = query("ALTER TABLE t ADD COLUMN age INT")
+	require.NoError(err)
+	_, err = query("ALTER TABLE t ADD COLUMN n INT NOT NULL DEFAULT 7")
+	require.NoError(err)
+	_, err = query("ALTER TABLE t DROP COLUMN name")
+	require.NoError(err)
+
+	rows, err := query("SELECT * FROM t")
+	require.NoError(err)
+	require.Equal([]sql.Row{
+		{int64(1), nil, int32(7)},
+		{int64(2), nil, int32(7)},
+	}, rows)
+}
+
+func TestEngine_Query_Explain(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	database := badger.NewDatabase("test_db", kv)
+	c := sql.NewCatalog()
+	c.AddDatabase(database)
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) (sql.Schema, []sql.Row) {
+		schema, iter, err := e.Query(ctx, q)
+		require.NoError(err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+		return schema, rows
+	}
+
+	query("CREATE TABLE t (id BIGINT, email TEXT)")
+	query("INSERT INTO t VALUES (1, 'a@x'), (2, 'b@x')")
+
+	schema, rows := query("EXPLAIN SELECT id FROM t WHERE email = 'a@x'")
+	var names []string
+	for _, col := range schema {
+		names = append(names, col.Name)
+	}
+	require.Equal([]string{"id", "select_type", "table", "access_type", "key", "rows", "extra"}, names)
+	require.Equal([]sql.Row{{int64(1), "SIMPLE", "t", "ALL", nil, nil, "Using where"}}, rows)
+
+	require.NoError(database.CreateIndex("t", "idx_email", []string{"email"}))
+
+	_, rows = query("EXPLAIN SELECT id FROM t WHERE email = 'a@x'")
+	require.Equal([]sql.Row{{int64(1), "SIMPLE", "t", "ref", "idx_email", nil, "Using where"}}, rows)
+
+	_, rows = query("EXPLAIN SELECT id FROM t WHERE email > 'a@x'")
+	require.Equal("range", rows[0][3])
+
+	// The query described still runs the same.
+	_, rows = query("SELECT id FROM t WHERE email = 'a@x'")
+	require.Equal([]sql.Row{{int64(1)}}, rows)
+
+	_, rows = query("EXPLAIN SELECT 1")
+	require.Equal([]sql.Row{{int64(1), "SIMPLE", nil, nil, nil, nil, "No tables used"}}, rows)
+}
+
+func TestEngine_Query_OrderByIndex(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	database := badger.NewDatabase("test_db", kv)
+	c := sql.NewCatalog()
+	c.AddDatabase(database)
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+		return rows
+	}
+
+	query("CREATE TABLE t (id BIGINT NOT NULL, score BIGINT, name TEXT)")
+	query("INSERT INTO t VALUES (1, 30, 'a'), (2, NULL, 'b'), (3, 10, 'c'), (4, 20, 'd')")
+
+	const byScore = "SELECT id FROM t ORDER BY score LIMIT 2"
+	require.Equal([]sql.Row{{int64(1), "SIMPLE", "t", "ALL", nil, nil, "Using filesort"}}, query("EXPLAIN "+byScore))
+	require.Equal([]sql.Row{{int64(2)}, {int64(3)}}, query(byScore))
+
+	require.NoError(database.CreateIndex("t", "idx_score", []string{"score"}))
+	require.NoError(database.CreateIndex("t", "idx_id", []string{"id"}))
+
+	// The rows are read sorted from the index, so there's no sort and the
+	// limit is right above the scan of the table.
+	node, err := e.Analyze(ctx, byScore)
+	require.NoError(err)
+	require.NotContains(node.String(), "Sort")
+	require.Contains(node.String(), "Limit")
+	require.Equal([]sql.Row{{int64(1), "SIMPLE", "t", "index", "idx_score", nil, nil}}, query("EXPLAIN "+byScore))
+	require.Equal([]sql.Row{{int64(2)}, {int64(3)}}, query(byScore))
+
+	const byID = "SELECT name, id AS k FROM t WHERE id > 1 ORDER BY k DESC"
+	require.Equal([]sql.Row{{int64(1), "SIMPLE", "t", "range", "idx_id", nil, "Using where"}}, query("EXPLAIN "+byID))
+	require.Equal([]sql.Row{{"d", int64(4)}, {"c", int64(3)}, {"b", int64(2)}}, query(byID))
+
+	// NULL values are sorted first in descending order too, which the
+	// index can't do, and expressions are not in any index.
+	require.Equal([]sql.Row{{int64(2)}, {int64(1)}, {int64(4)}, {int64(3)}}, query("SELECT id FROM t ORDER BY score DESC"))
+	require.Equal("Using filesort", query("EXPLAIN SELECT id FROM t ORDER BY score DESC")[0][6])
+	require.Equal("Using filesort", query("EXPLAIN SELECT id FROM t ORDER BY id + 1")[0][6])
+}
+
+func TestEngine_Query_CheckConstraint(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	exec := func(q string) error {
+		_, iter, err := e.Query(ctx, q)
+		if err != nil {
+			return err
+		}
+		_, err = sql.RowIterToRows(iter)
+		return err
+	}
+
+	require.NoError(exec(`CREATE TABLE t (
+		id BIGINT PRIMARY KEY,
+		age BIGINT CHECK (age >= 0),
+		lo BIGINT,
+		hi BIGINT,
+		CONSTRAINT lo_below_hi CHECK (lo + 1 <= hi)
+	)`))
+
+	require.NoError(exec("INSERT INTO t VALUES (1, 30, 1, 5)"))
+	require.ErrorContains(exec("INSERT INTO t VALUES (2, -1, 1, 5)"), "Check constraint 't_chk_1' is violated.")
+	require.ErrorContains(exec("INSERT INTO t VALUES (3, 1, 5, 5)"), "Check constraint 'lo_below_hi' is violated.")
+
+	// Checks whose expression is NULL pass.
+	require.NoError(exec("INSERT INTO t VALUES (4, NULL, NULL, 5)"))
+
+	require.ErrorContains(exec("UPDATE t SET age = age - 31 WHERE id = 1"), "Check constraint 't_chk_1' is violated.")
+	require.NoError(exec("UPDATE t SET age = age - 30 WHERE id = 1"))
+
+	_, iter, err := e.Query(ctx, "SELECT id, age FROM t ORDER BY id")
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(1), int64(0)}, {int64(4), nil}}, rows)
+
+	// The checks are kept along with the table.
+	c = sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.SetCurrentDatabase("test_db")
+	e = NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	require.ErrorContains(exec("INSERT INTO t VALUES (5, -1, 1, 5)"), "Check constraint 't_chk_1' is violated.")
+
+	require.ErrorContains(exec("CREATE TABLE u (a BIGINT CHECK (b > 0))"), "non-existing column 'b'")
+}
+
+func TestEngine_Query_OrderByLimitDML(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, q)
+		return rows
+	}
+
+	query("CREATE TABLE events (id BIGINT PRIMARY KEY, created TIMESTAMP, done BIGINT)")
+	query(`INSERT INTO events VALUES
+		(1, '2024-03-01 00:00:00', 0),
+		(2, '2024-01-01 00:00:00', 0),
+		(3, '2024-04-01 00:00:00', 0),
+		(4, '2024-02-01 00:00:00', 0),
+		(5, '2023-12-01 00:00:00', 1)`)
+
+	// Only the 2 oldest rows that match are deleted.
+	rows := query("DELETE FROM events WHERE done = 0 ORDER BY created LIMIT 2")
+	require.Equal([]sql.Row{{int64(2)}}, rows)
+	require.Equal([]sql.Row{{int64(1)}, {int64(3)}, {int64(5)}}, query("SELECT id FROM events ORDER BY id"))
+
+	rows = query("UPDATE events SET done = 1 ORDER BY created DESC LIMIT 1")
+	require.Equal([]sql.Row{{int64(1)}}, rows)
+	require.Equal([]sql.Row{{int64(3)}, {int64(5)}}, query("SELECT id FROM events WHERE done = 1 ORDER BY id"))
+
+	// Without ORDER BY, any rows up to the limit are modified.
+	rows = query("DELETE FROM events LIMIT 2")
+	require.Equal([]sql.Row{{int64(2)}}, rows)
+	require.Len(query("SELECT id FROM events"), 1)
+}
+
+func TestEngine_Query_InformationSchema(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.AddDatabase(sql.NewInformationSchemaDatabase(c))
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+		return rows
+	}
+
+	query("CREATE TABLE orders (region TEXT, id BIGINT, note TEXT, PRIMARY KEY (region, id))")
+	query("CREATE TABLE other (x INT)")
+
+	rows := query(`SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = 'test_db' ORDER BY TABLE_NAME`)
+	require.Equal([]sql.Row{{"orders"}, {"other"}}, rows)
+
+	rows = query(`SELECT COLUMN_NAME, ORDINAL_POSITION, DATA_TYPE, COLUMN_TYPE, IS_NULLABLE, COLUMN_KEY
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = 'test_db' AND TABLE_NAME = 'orders'
+		ORDER BY ORDINAL_POSITION`)
+	require.Equal([]sql.Row{
+		{"region", uint64(1), "text", "text", "NO", "PRI"},
+		{"id", uint64(2), "bigint", "bigint", "NO", "PRI"},
+		{"note", uint64(3), "text", "text", "YES", ""},
+	}, rows)
+
+	rows = query(`SELECT CONSTRAINT_NAME, TABLE_NAME, COLUMN_NAME, ORDINAL_POSITION
+		FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = 'test_db'`)
+	require.Equal([]sql.Row{
+		{"PRIMARY", "orders", "region", uint64(1)},
+		{"PRIMARY", "orders", "id", uint64(2)},
+	}, rows)
+
+	// The tables are read live, so later changes show up.
+	query("CREATE TABLE later (k BIGINT PRIMARY KEY)")
+	rows = query(`SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = 'test_db' AND TABLE_NAME = 'later'`)
+	require.Equal([]sql.Row{{"k"}}, rows)
+}
+
+func TestEngine_Query_Timeout(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	_, iter, err := e.Query(ctx, "CREATE TABLE t (id BIGINT PRIMARY KEY)")
+	require.NoError(err)
+	_, err = sql.RowIterToRows(iter)
+	require.NoError(err)
+
+	values := make([]string, 200)
+	for i := range values {
+		values[i] = fmt.Sprintf("(%d)", i)
+	}
+	_, iter, err = e.Query(ctx, "INSERT INTO t VALUES "+strings.Join(values, ", "))
+	require.NoError(err)
+	_, err = sql.RowIterToRows(iter)
+	require.NoError(err)
+
+	// Counting the 8 million rows of the join takes much longer than the
+	// query may run.
+	timeout, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ctx = sql.NewContext(timeout)
+
+	start := time.Now()
+	_, iter, err = e.Query(ctx, "SELECT COUNT(*) FROM t a, t b, t c")
+	if err == nil {
+		_, err = sql.RowIterToRows(iter)
+	}
+	require.Error(err)
+	require.Less(time.Since(start), 2*time.Second)
+	require.True(stderrors.Is(err, context.DeadlineExceeded), err)
+
+	var cerr *cerrors.Error
+	require.True(stderrors.As(err, &cerr))
+	require.Equal(constants.ErrCodeTimeout, cerr.Code)
+}
+
+func TestEngine_Query_OnDuplicateKeyUpdate(t *testing.T) {
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer kv.Close()
+
+	// Badger tables update the duplicates in the transaction of the
+	// statement, and the others by looking them up among their rows.
+	databases := map[string]sql.Database{
+		"badger": badger.NewDatabase("test_db", kv),
+		"memory": memory.NewDatabase("test_db"),
+	}
+
+	for name, db := range databases {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+
+			c := sql.NewCatalog()
+			c.AddDatabase(db)
+			c.SetCurrentDatabase("test_db")
+
+			e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+			ctx := sql.NewContext(context.Background())
+
+			query := func(q string) []sql.Row {
+				_, iter, err := e.Query(ctx, q)
+				require.NoError(err, q)
+				rows, err := sql.RowIterToRows(iter)
+				require.NoError(err, q)
+				return rows
+			}
+
+			query("CREATE TABLE counters (name TEXT PRIMARY KEY, hits BIGINT, note TEXT)")
+
+			// A fresh insert counts as 1 row affected.
+			rows := query(`INSERT INTO counters VALUES ('a', 1, 'first')
+				ON DUPLICATE KEY UPDATE hits = hits + VALUES(hits)`)
+			require.Equal([]sql.Row{{int64(1)}}, rows)
+
+			// A conflicting insert updates the existing row, which counts
+			// as 2.
+			rows = query(`INSERT INTO counters VALUES ('a', 5, 'second')
+				ON DUPLICATE KEY UPDATE hits = hits + VALUES(hits), note = VALUES(note)`)
+			require.Equal([]sql.Row{{int64(2)}}, rows)
+			require.Equal([]sql.Row{{"a", int64(6), "second"}}, query("SELECT * FROM counters"))
+
+			// Leaving the row as it was counts as 0.
+			rows = query(`INSERT INTO counters VALUES ('a', 0, 'third')
+				ON DUPLICATE KEY UPDATE hits = hits`)
+			require.Equal([]sql.Row{{int64(0)}}, rows)
+
+			// A batch inserts some rows and updates others, including the
+			// ones it inserted itself.
+			rows = query(`INSERT INTO counters VALUES ('b', 1, 'b'), ('a', 1, 'a'), ('c', 1, 'c'), ('b', 1, 'b')
+				ON DUPLICATE KEY UPDATE hits = hits + 1`)
+			require.Equal([]sql.Row{{int64(1 + 2 + 1 + 2)}}, rows)
+			require.Equal([]sql.Row{
+				{"a", int64(7), "second"},
+				{"b", int64(2), "b"},
+				{"c", int64(1), "c"},
+			}, query("SELECT * FROM counters ORDER BY name"))
+
+			// Without the clause, a duplicate is still an error.
+			_, _, err := e.Query(ctx, "INSERT INTO counters VALUES ('a', 1, NULL)")
+			require.ErrorContains(err, "Duplicate entry 'a'")
+		})
+	}
+}
+
+func TestEngine_Query_DateArithmetic(t *testing.T) {
+	require := require.New(t)
+
+	db := memory.NewDatabase("test_db")
+	c := sql.NewCatalog()
+	c.AddDatabase(db)
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, q)
+		return rows
+	}
+
+	query("CREATE TABLE events (id BIGINT PRIMARY KEY, at DATETIME)")
+	query(`INSERT INTO events VALUES
+		(1, '2024-01-15 10:30:00'), (2, '2024-01-05 08:00:00'), (3, '2023-12-31 23:59:59')`)
+
+	// Dates compare with strings as instants, not as text.
+	rows := query("SELECT id FROM events WHERE at > '2024-1-5' ORDER BY id")
+	require.Equal([]sql.Row{{int64(1)}, {int64(2)}}, rows)
+
+	rows = query(`SELECT DATE_ADD(at, INTERVAL 1 DAY), at - INTERVAL 2 HOUR,
+		DATE_SUB(at, INTERVAL 1 MONTH), DATEDIFF(at, '2024-01-01')
+		FROM events WHERE id = 1`)
+	require.Equal([]sql.Row{{
+		time.Date(2024, 1, 16, 10, 30, 0, 0, time.UTC),
+		time.Date(2024, 1, 15, 8, 30, 0, 0, time.UTC),
+		time.Date(2023, 12, 15, 10, 30, 0, 0, time.UTC),
+		int64(14),
+	}}, rows)
+
+	rows = query("SELECT id FROM events WHERE at + INTERVAL 1 SECOND >= '2024-01-01' ORDER BY id")
+	require.Equal([]sql.Row{{int64(1)}, {int64(2)}, {int64(3)}}, rows)
+}
+
+func TestEngine_Query_ConditionalExpressions(t *testing.T) {
+	require := require.New(t)
+
+	db := memory.NewDatabase("test_db")
+	c := sql.NewCatalog()
+	c.AddDatabase(db)
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, q)
+		return rows
+	}
+
+	query("CREATE TABLE t (id BIGINT PRIMARY KEY, a BIGINT, b BIGINT)")
+	query("INSERT INTO t VALUES (1, NULL, 10), (2, 5, NULL), (3, NULL, NULL), (4, 7, 7)")
+
+	rows := query(`SELECT id, COALESCE(a, b, -1), IFNULL(a, b), NULLIF(a, b),
+		CASE WHEN a > 6 THEN 'big' WHEN a > 0 THEN 'small' ELSE 'none' END,
+		CASE id WHEN 1 THEN 'one' WHEN 2 THEN 'two' END
+		FROM t ORDER BY id`)
+	require.Equal([]sql.Row{
+		{int64(1), int64(10), int64(10), nil, "none", "one"},
+		{int64(2), int64(5), int64(5), int64(5), "small", "two"},
+		{int64(3), int64(-1), nil, nil, "none", nil},
+		{int64(4), int64(7), int64(7), nil, "big", nil},
+	}, rows)
+
+	rows = query("SELECT id FROM t WHERE COALESCE(a, b) > 6 ORDER BY id")
+	require.Equal([]sql.Row{{int64(1)}, {int64(4)}}, rows)
+
+	rows = query("SELECT id FROM t WHERE CASE WHEN a IS NULL THEN b IS NULL ELSE a = b END ORDER BY id")
+	require.Equal([]sql.Row{{int64(3)}, {int64(4)}}, rows)
+}
+
+func TestEngine_Query_ScalarFunctions(t *testing.T) {
+	require := require.New(t)
+
+	db := memory.NewDatabase("test_db")
+	c := sql.NewCatalog()
+	c.AddDatabase(db)
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, q)
+		return rows
+	}
+
+	query("CREATE TABLE t (id BIGINT PRIMARY KEY, name TEXT, price DOUBLE)")
+	query("INSERT INTO t VALUES (1, 'café', 3.14159), (2, '日本語😀', -2.5)")
+
+	rows := query(`SELECT CONCAT(name, '-', id), ROUND(price, 2), ABS(price),
+		LENGTH(name), CHAR_LENGTH(name) FROM t ORDER BY id`)
+	require.Equal([]sql.Row{
+		{"café-1", 3.14, 3.14159, int32(5), int32(4)},
+		{"日本語😀-2", -2.5, 2.5, int32(13), int32(4)},
+	}, rows)
+
+	// The arity and the types of the arguments are checked when the query
+	// is planned.
+	planErr := func(q string) error {
+		_, _, err := e.Query(ctx, q)
+		var cerr *cerrors.Error
+		require.True(stderrors.As(err, &cerr), q)
+		return cerr.Err
+	}
+	require.True(sql.ErrInvalidArgumentNumber.Is(planErr("SELECT ROUND(price, 2, 3) FROM t")))
+	require.True(sql.ErrInvalidArgumentNumber.Is(planErr("SELECT CHAR_LENGTH() FROM t")))
+	require.True(sql.ErrInvalidArgumentType.Is(planErr("SELECT ABS(JSON_EXTRACT(name, '$')) FROM t")))
+}
+
+func TestEngine_Query_Collation(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, q)
+		return rows
+	}
+
+	query(`CREATE TABLE t (
+		id BIGINT PRIMARY KEY,
+		ci VARCHAR(10) COLLATE utf8mb4_general_ci,
+		bin VARCHAR(10) COLLATE utf8mb4_bin
+	)`)
+	query("INSERT INTO t VALUES (1, 'abc', 'abc'), (2, 'B', 'B'), (3, 'ABC', 'ABC'), (4, 'a', 'a')")
+
+	require.Equal([]sql.Row{{int64(1)}, {int64(3)}}, query("SELECT id FROM t WHERE ci = 'ABC' ORDER BY id"))
+	require.Equal([]sql.Row{{int64(3)}}, query("SELECT id FROM t WHERE bin = 'ABC' ORDER BY id"))
+	require.Equal([]sql.Row{{int64(1)}, {int64(3)}}, query("SELECT id FROM t WHERE ci LIKE 'ab%' ORDER BY id"))
+	require.Equal([]sql.Row{{int64(1)}}, query("SELECT id FROM t WHERE bin LIKE 'ab%' ORDER BY id"))
+
+	// Strings differing only in case sort together, not apart as their
+	// bytes do.
+	require.Equal([]sql.Row{{int64(4)}, {int64(1)}, {int64(3)}, {int64(2)}}, query("SELECT id FROM t ORDER BY ci, id"))
+	require.Equal([]sql.Row{{int64(3)}, {int64(2)}, {int64(4)}, {int64(1)}}, query("SELECT id FROM t ORDER BY bin, id"))
+
+	// Primary keys that are equal regardless of case are duplicates.
+	query("CREATE TABLE tags (name VARCHAR(20) COLLATE utf8mb4_general_ci PRIMARY KEY)")
+	query("INSERT INTO tags VALUES ('Go')")
+	_, _, err = e.Query(ctx, "INSERT INTO tags VALUES ('GO')")
+	require.ErrorContains(err, "Duplicate entry 'GO'")
+
+	// Columns without a collation get the one of the table, or else the one
+	// of the database.
+	query("CREATE TABLE u (name TEXT) COLLATE=utf8mb4_general_ci")
+	c.SetDatabaseCollation("test_db", sql.Utf8mb4GeneralCI)
+	query("CREATE TABLE v (name TEXT, code VARCHAR(5) COLLATE utf8mb4_bin)")
+	query("INSERT INTO u VALUES ('X')")
+	query("INSERT INTO v VALUES ('X', 'X')")
+	require.Equal([]sql.Row{{"X"}}, query("SELECT name FROM u WHERE name = 'x'"))
+	require.Equal([]sql.Row{{"X"}}, query("SELECT name FROM v WHERE name = 'x'"))
+	require.Empty(query("SELECT name FROM v WHERE code = 'x'"))
+
+	_, _, err = e.Query(ctx, "CREATE TABLE w (name TEXT COLLATE latin1_swedish_ci)")
+	require.ErrorContains(err, "Unknown collation: 'latin1_swedish_ci'")
+
+	// The collations are kept along with the tables.
+	c = sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.SetCurrentDatabase("test_db")
+	e = NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	require.Equal([]sql.Row{{int64(1)}, {int64(3)}}, query("SELECT id FROM t WHERE ci = 'ABC' ORDER BY id"))
+
+	create := query("SHOW CREATE TABLE t")[0][1].(string)
+	require.Contains(create, "`ci` VARCHAR(10) COLLATE utf8mb4_general_ci")
+	require.NotContains(create, "`bin` VARCHAR(10) COLLATE")
+}
+
+func TestEngine_Query_AnalyzeTable(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, q)
+		return rows
+	}
+
+	// Most users are in one country, and the rest in one of 100 others.
+	// One in ten has no age.
+	query("CREATE TABLE users (id BIGINT PRIMARY KEY, country TEXT, age BIGINT)")
+	var values []string
+	for i := 0; i < 2000; i++ {
+		country := "us"
+		if i%10 == 0 {
+			country = fmt.Sprintf("c%02d", i/10%100)
+		}
+		age := fmt.Sprint(18 + i%50)
+		if i%10 == 5 {
+			age = "NULL"
+		}
+		values = append(values, fmt.Sprintf("(%d, '%s', %s)", i, country, age))
+	}
+	query("INSERT INTO users VALUES " + strings.Join(values, ", "))
+
+	query("CREATE TABLE orders (id BIGINT PRIMARY KEY, user_id BIGINT)")
+	values = values[:0]
+	for i := 0; i < 300; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d)", i, i*7%2000))
+	}
+	query("INSERT INTO orders VALUES " + strings.Join(values, ", "))
+
+	const join = "SELECT orders.id FROM orders JOIN users ON orders.user_id = users.id WHERE users.country = 'c07'"
+	plan := func() string {
+		node, err := e.Analyze(ctx, join)
+		require.NoError(err)
+		return node.String()
+	}
+
+	// Without statistics the size of the tables is unknown, so the join is
+	// left in the order of the query.
+	require.Contains(plan(), "├─ orders")
+
+	require.Equal([]sql.Row{
+		{"test_db.users", "analyze", "status", "OK"},
+		{"test_db.orders", "analyze", "status", "OK"},
+		{"test_db.missing", "analyze", "Error", "table not found: missing"},
+	}, query("ANALYZE TABLE users, orders, missing"))
+
+	stats := c.TableStatistics("test_db", "users")
+	require.NotNil(stats)
+	require.Equal(uint64(2000), stats.RowCount)
+
+	id := stats.Column("id")
+	require.InDelta(2000, float64(id.NDV), 2000*0.03)
+	require.Equal(int64(0), id.Min)
+	require.Equal(int64(1999), id.Max)
+	require.Zero(id.NullFraction)
+
+	country := stats.Column("country")
+	require.InDelta(101, float64(country.NDV), 101*0.03)
+	require.Equal("c00", country.Min)
+	require.Equal("us", country.Max)
+
+	age := stats.Column("age")
+	// The ages of the users without one are never seen.
+	require.InDelta(45, float64(age.NDV), 45*0.03)
+	require.Equal(int64(18), age.Min)
+	require.Equal(int64(67), age.Max)
+	require.InDelta(0.1, age.NullFraction, 0.001)
+
+	// Few users are expected in a country other than the common one, so
+	// they drive the join.
+	require.Contains(plan(), "├─ users")
+	require.Equal(query("SELECT orders.id FROM users JOIN orders ON orders.user_id = users.id WHERE users.country = 'c07' ORDER BY orders.id"),
+		query(join+" ORDER BY orders.id"))
+}
+
+func TestEngine_Query_OuterJoin(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, q)
+		return rows
+	}
+
+	query("CREATE TABLE users (id BIGINT PRIMARY KEY, name TEXT)")
+	query("CREATE TABLE orders (id BIGINT PRIMARY KEY, user_id BIGINT, amount BIGINT)")
+	query("INSERT INTO users VALUES (1, 'alice'), (2, 'bob'), (3, 'carol')")
+	query("INSERT INTO orders VALUES (10, 1, 100), (11, 1, 5), (12, 3, 50), (13, 9, 70)")
+
+	require.Equal([]sql.Row{
+		{"alice", int64(10), int64(100)},
+		{"alice", int64(11), int64(5)},
+		{"bob", nil, nil},
+		{"carol", int64(12), int64(50)},
+	}, query("SELECT u.name, o.id, o.amount FROM users u LEFT JOIN orders o ON u.id = o.user_id ORDER BY u.name, o.id"))
+
+	require.Equal([]sql.Row{
+		{"alice", int64(10)},
+		{"alice", int64(11)},
+		{"carol", int64(12)},
+		{nil, int64(13)},
+	}, query("SELECT u.name, o.id FROM users u RIGHT JOIN orders o ON u.id = o.user_id ORDER BY o.id"))
+
+	require.Equal([]sql.Row{
+		{int64(1), "alice", int64(10), int64(1), int64(100)},
+		{int64(2), "bob", nil, nil, nil},
+		{int64(3), "carol", nil, nil, nil},
+		{nil, nil, int64(11), int64(1), int64(5)},
+		{nil, nil, int64(12), int64(3), int64(50)},
+		{nil, nil, int64(13), int64(9), int64(70)},
+	}, query("SELECT * FROM users u FULL OUTER JOIN orders o ON u.id = o.user_id AND o.amount > 60 ORDER BY u.id IS NULL, u.id, o.id"))
+
+	// The conditions on the padded side in the WHERE clause see the
+	// padding, unlike the ones in the ON clause.
+	require.Equal([]sql.Row{{"bob"}},
+		query("SELECT u.name FROM users u LEFT JOIN orders o ON u.id = o.user_id WHERE o.id IS NULL"))
+	require.Equal([]sql.Row{{"alice", int64(10)}, {"bob", nil}, {"carol", nil}},
+		query("SELECT u.name, o.id FROM users u LEFT JOIN orders o ON u.id = o.user_id AND o.amount > 60 ORDER BY u.name"))
+	require.Equal([]sql.Row{{"alice", int64(10)}},
+		query("SELECT u.name, o.id FROM users u LEFT JOIN orders o ON u.id = o.user_id WHERE o.amount > 60"))
+	require.Equal([]sql.Row{{"alice", float64(105)}, {"bob", float64(0)}, {"carol", float64(50)}},
+		query("SELECT u.name, COALESCE(SUM(o.amount), 0) FROM users u LEFT JOIN orders o ON u.id = o.user_id GROUP BY u.name ORDER BY u.name"))
+}
+
+func TestEngine_Query_RollupAndHaving(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, q)
+		return rows
+	}
+
+	query("CREATE TABLE sales (id BIGINT PRIMARY KEY, region TEXT, product TEXT, amount BIGINT)")
+	query(`INSERT INTO sales VALUES
+		(1, 'east', 'apple', 50), (2, 'east', 'pear', 70), (3, 'east', 'apple', 10),
+		(4, 'west', 'apple', 30), (5, 'west', 'pear', 20), (6, 'north', 'pear', 200)`)
+
+	require.Equal([]sql.Row{
+		{"east", float64(130)},
+		{"west", float64(50)},
+		{"north", float64(200)},
+		{nil, float64(380)},
+	}, query("SELECT region, SUM(amount) AS total FROM sales GROUP BY region WITH ROLLUP"))
+
+	require.Equal([]sql.Row{
+		{"east", "apple", float64(60)},
+		{"east", "pear", float64(70)},
+		{"east", nil, float64(130)},
+		{"west", "apple", float64(30)},
+		{"west", "pear", float64(20)},
+		{"west", nil, float64(50)},
+		{nil, nil, float64(180)},
+	}, query("SELECT region, product, SUM(amount) FROM sales WHERE region <> 'north' GROUP BY region, product WITH ROLLUP"))
+
+	require.Equal([]sql.Row{
+		{"east", float64(130)},
+		{"north", float64(200)},
+	}, query("SELECT region, SUM(amount) AS total FROM sales GROUP BY region HAVING total > 100 ORDER BY region"))
+
+	require.Equal([]sql.Row{{"east"}, {"west"}},
+		query("SELECT region FROM sales GROUP BY region HAVING COUNT(*) > 1 ORDER BY region"))
+
+	require.Equal([]sql.Row{{"north", float64(200)}},
+		query("SELECT region, SUM(amount) AS total FROM sales GROUP BY region HAVING SUM(amount) > 100 AND COUNT(*) = 1"))
+
+	require.Equal([]sql.Row{
+		{"north", float64(200)},
+		{nil, float64(380)},
+	}, query("SELECT region, SUM(amount) AS total FROM sales GROUP BY region WITH ROLLUP HAVING total > 150"))
+}
+
+func TestEngine_Query_TruncateTable(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	database := badger.NewDatabase("test_db", kv)
+	c := sql.NewCatalog()
+	c.AddDatabase(database)
+	c.SetCurrentDatabase("test_db")
+
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	query := func(q string) []sql.Row {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, q)
+		return rows
+	}
+
+	query("CREATE TABLE items (id BIGINT AUTO_INCREMENT PRIMARY KEY, name TEXT)")
+	require.NoError(database.CreateIndex("items", "items_name", []string{"name"}))
+	query("CREATE TABLE other (id BIGINT PRIMARY KEY)")
+	query("INSERT INTO other VALUES (1)")
+
+	const rows = 5000
+	for i := 0; i < rows; i += 500 {
+		values := make([]string, 500)
+		for j := range values {
+			values[j] = fmt.Sprintf("('item %d')", i+j)
+		}
+		query("INSERT INTO items (name) VALUES " + strings.Join(values, ", "))
+	}
+	require.Equal([]sql.Row{{int32(rows)}}, query("SELECT COUNT(*) FROM items"))
+
+	query("TRUNCATE TABLE items")
+
+	require.Equal([]sql.Row{{int32(0)}}, query("SELECT COUNT(*) FROM items"))
+	require.Empty(query("SELECT id FROM items WHERE name = 'item 10'"))
+	require.Equal([]sql.Row{{int32(1)}}, query("SELECT COUNT(*) FROM other"))
+
+	// The schema is kept, and the AUTO_INCREMENT counter starts again.
+	query("INSERT INTO items (name) VALUES ('first')")
+	require.Equal([]sql.Row{{int64(1), "first"}}, query("SELECT id, name FROM items"))
+	require.Equal([]sql.Row{{int64(1)}}, query("SELECT id FROM items WHERE name = 'first'"))
+
+	_, _, err = e.Query(ctx, "TRUNCATE TABLE missing")
+	require.Error(err)
+}
+
+// databaseAuth grants each database the permissions it's mapped to.
+type databaseAuth struct {
+	*auth.None
+	perms map[string]auth.Permission
+}
+
+func (a *databaseAuth) AllowedOn(ctx *sql.Context, database string, permission auth.Permission) error {
+	if a.perms[database]&permission != permission {
+		return auth.ErrNotAuthorized.Wrap(auth.ErrNoPermission.New(permission))
+	}
+	return nil
+}
+
+func TestEngine_Query_CrossDatabasePrivileges(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	for _, name := range []string{"db1", "db2"} {
+		db := mem.NewDatabase(name)
+		db.AddTable("t", mem.NewTable("t", sql.Schema{
+			{Name: "id", Type: sql.Int64, Source: "t"},
+		}))
+		c.AddDatabase(db)
+	}
+	c.SetCurrentDatabase("db1")
+
+	a := &databaseAuth{None: auth.NewNone(), perms: map[string]auth.Permission{
+		"db1": auth.ReadPerm,
+		"db2": auth.AllPermissions,
+	}}
+	e := NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	e.Auth = a
+
+	query := func(q string) error {
+		_, iter, err := e.Query(sql.NewContext(context.Background()), q)
+		if err != nil {
+			return err
+		}
+		_, err = sql.RowIterToRows(iter)
+		return err
+	}
+
+	require.NoError(query("SELECT * FROM db1.t JOIN db2.t ON db1.t.id = db2.t.id"))
+	require.NoError(query("INSERT INTO db2.t SELECT id FROM db1.t"))
+	// Unqualified tables are those of the current database.
+	require.True(auth.ErrNotAuthorized.Is(query("INSERT INTO t VALUES (1)")))
+
+	// Every database of a query must be allowed.
+	delete(a.perms, "db2")
+	require.True(auth.ErrNotAuthorized.Is(query("SELECT * FROM db1.t JOIN db2.t ON db1.t.id = db2.t.id")))
+	require.NoError(query("SELECT * FROM t"))
+}
@@ -0,0 +1,255 @@
+package executor
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+// tableRef is a table referenced by a query.
+type tableRef struct {
+	database string
+	table    string
+}
+
+// checkPrivileges checks that the user of the context has the permissions
+// the query needs on each database whose tables it references, so a query
+// joining tables of several databases needs to be allowed on all of them.
+// Reading a table needs the read permission on its database, and writing
+// it the write permission too. Unqualified tables are those of the current
+// database.
+//
+// An auth whose permissions may be granted on some tables or columns only
+// is asked about each table instead, and about each column read of them.
+func (e *Engine) checkPrivileges(ctx *sql.Context, n sql.Node) error {
+	if e.Auth == nil {
+		return nil
+	}
+
+	current := ctx.GetCurrentDatabase()
+	if current == "" {
+		current = e.Catalog.CurrentDatabase()
+	}
+
+	perms := make(map[tableRef]auth.Permission)
+	add := func(node sql.Node, perm auth.Permission) {
+		plan.Inspect(node, func(node sql.Node) bool {
+			if t, ok := node.(*plan.UnresolvedTable); ok {
+				perms[refOf(t, current)] |= perm
+			}
+			return true
+		})
+	}
+
+	plan.Inspect(n, func(node sql.Node) bool {
+		switch node := node.(type) {
+		case *plan.InsertInto:
+			add(node.Left, auth.ReadPerm|auth.WritePerm)
+		case *plan.Update, *plan.DeleteFrom:
+			add(node, auth.ReadPerm|auth.WritePerm)
+		case *plan.UnresolvedTable:
+			add(node, auth.ReadPerm)
+		}
+		return true
+	})
+
+	// The tables are checked in order, so the error of a query denied on
+	// several of them is always the same.
+	refs := make([]tableRef, 0, len(perms))
+	for ref := range perms {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].database != refs[j].database {
+			return refs[i].database < refs[j].database
+		}
+		return refs[i].table < refs[j].table
+	})
+
+	if _, ok := e.Auth.(auth.TableAuth); !ok {
+		dbPerms := make(map[string]auth.Permission)
+		var dbs []string
+		for _, ref := range refs {
+			if _, ok := dbPerms[ref.database]; !ok {
+				dbs = append(dbs, ref.database)
+			}
+			dbPerms[ref.database] |= perms[ref]
+		}
+
+		for _, db := range dbs {
+			if err := auth.AllowedOn(e.Auth, ctx, db, dbPerms[db]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, ref := range refs {
+		if err := auth.AllowedOnTable(e.Auth, ctx, ref.database, ref.table, nil, perms[ref]); err != nil {
+			return err
+		}
+	}
+
+	columns := e.readColumns(n, current)
+	for _, ref := range refs {
+		if len(columns[ref]) == 0 {
+			continue
+		}
+		if err := auth.AllowedOnTable(e.Auth, ctx, ref.database, ref.table, columns[ref], auth.ReadPerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refOf returns the table an unresolved table refers to.
+func refOf(t *plan.UnresolvedTable, current string) tableRef {
+	db := t.Database
+	if db == "" {
+		db = current
+	}
+	return tableRef{database: db, table: t.Name()}
+}
+
+// readColumns returns the columns of each table the query reads, in order.
+// Columns that are not qualified are read of every table having them, and
+// stars of the projections read every column. The columns assigned by an
+// UPDATE are written, not read.
+func (e *Engine) readColumns(n sql.Node, current string) map[tableRef][]string {
+	names := make(map[string][]tableRef)
+	var refs []tableRef
+	schemas := make(map[tableRef]sql.Schema)
+	var register func(node sql.Node) bool
+	register = func(node sql.Node) bool {
+		var ref tableRef
+		var name string
+		switch node := node.(type) {
+		case *plan.InsertInto:
+			// The table inserted into is written, not read.
+			plan.Inspect(node.Right, register)
+			return false
+		case *plan.TableAlias:
+			t, ok := node.Child.(*plan.UnresolvedTable)
+			if !ok {
+				return true
+			}
+			ref, name = refOf(t, current), node.Name()
+		case *plan.UnresolvedTable:
+			ref, name = refOf(node, current), node.Name()
+		default:
+			return true
+		}
+
+		name = strings.ToLower(name)
+		names[name] = append(names[name], ref)
+		if _, ok := schemas[ref]; !ok {
+			// Missing tables fail the analysis of the query later.
+			table, err := e.Catalog.Table(ref.database, ref.table)
+			if err == nil {
+				schemas[ref] = table.Schema()
+				refs = append(refs, ref)
+			}
+		}
+		return false
+	}
+	plan.Inspect(n, register)
+
+	read := make(map[tableRef]map[string]bool)
+	readColumn := func(ref tableRef, column string) {
+		if read[ref] == nil {
+			read[ref] = make(map[string]bool)
+		}
+		read[ref][strings.ToLower(column)] = true
+	}
+	readAll := func(ref tableRef) {
+		for _, col := range schemas[ref] {
+			readColumn(ref, col.Name)
+		}
+	}
+
+	var exprs []sql.Expression
+	plan.Inspect(n, func(node sql.Node) bool {
+		switch node := node.(type) {
+		case *plan.Update:
+			exprs = append(exprs, node.Values...)
+			return true
+		case *plan.Project:
+			exprs = append(exprs, node.Projections...)
+			stars(node.Projections, names, refs, readAll)
+			return true
+		case *plan.GroupBy:
+			stars(node.Aggregate, names, refs, readAll)
+		}
+		if node, ok := node.(sql.Expressioner); ok {
+			exprs = append(exprs, node.Expressions()...)
+		}
+		return true
+	})
+
+	for _, expr := range exprs {
+		expression.Inspect(expr, func(e sql.Expression) bool {
+			col, ok := e.(*expression.UnresolvedColumn)
+			if !ok {
+				return true
+			}
+
+			if col.Table() != "" {
+				for _, ref := range names[strings.ToLower(col.Table())] {
+					readColumn(ref, col.Name())
+				}
+				return true
+			}
+
+			for _, ref := range refs {
+				if hasColumn(schemas[ref], col.Name()) {
+					readColumn(ref, col.Name())
+				}
+			}
+			return true
+		})
+	}
+
+	columns := make(map[tableRef][]string, len(read))
+	for ref, cols := range read {
+		for col := range cols {
+			columns[ref] = append(columns[ref], col)
+		}
+		sort.Strings(columns[ref])
+	}
+	return columns
+}
+
+// stars reads all the columns of the tables the stars of the expressions
+// select.
+func stars(exprs []sql.Expression, names map[string][]tableRef, refs []tableRef, readAll func(tableRef)) {
+	for _, e := range exprs {
+		star, ok := e.(*expression.Star)
+		if !ok {
+			continue
+		}
+
+		if star.Table == "" {
+			for _, ref := range refs {
+				readAll(ref)
+			}
+			continue
+		}
+		for _, ref := range names[strings.ToLower(star.Table)] {
+			readAll(ref)
+		}
+	}
+}
+
+// hasColumn returns whether the schema has a column with the given name.
+func hasColumn(schema sql.Schema, name string) bool {
+	for _, col := range schema {
+		if strings.EqualFold(col.Name, name) {
+			return true
+		}
+	}
+	return false
+}
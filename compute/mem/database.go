@@ -4,6 +4,12 @@ import (
 	"github.com/turtacn/guocedb/compute/sql"
 )
 
+// Engine describes the in-memory storage engine for SHOW ENGINES.
+var Engine = sql.StorageEngine{
+	Name:    "memory",
+	Comment: "In-memory tables, their contents are lost on restart",
+}
+
 // Database is an in-memory database.
 type Database struct {
 	name   string
@@ -35,11 +41,19 @@ func (d *Database) AddTable(name string, t sql.Table) {
 
 // Create creates a table with the given name and schema
 func (d *Database) Create(name string, schema sql.Schema) error {
+	return d.CreateWithOptions(name, schema, nil)
+}
+
+// CreateWithOptions creates a table with the given name, schema and table
+// options.
+func (d *Database) CreateWithOptions(name string, schema sql.Schema, options sql.TableOptions) error {
 	_, ok := d.tables[name]
 	if ok {
 		return sql.ErrTableAlreadyExists.New(name)
 	}
 
-	d.tables[name] = NewTable(name, schema)
+	table := NewTable(name, schema)
+	table.options = options
+	d.tables[name] = table
 	return nil
 }
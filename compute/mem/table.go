@@ -19,7 +19,8 @@ type Table struct {
 	partitions map[string][]sql.Row
 	keys       [][]byte
 
-	insert int
+	insert  int
+	options sql.TableOptions
 
 	filters    []sql.Expression
 	projection []string
@@ -29,9 +30,13 @@ type Table struct {
 
 var _ sql.Table = (*Table)(nil)
 var _ sql.Inserter = (*Table)(nil)
+var _ sql.Updater = (*Table)(nil)
+var _ sql.Deleter = (*Table)(nil)
 var _ sql.FilteredTable = (*Table)(nil)
 var _ sql.ProjectedTable = (*Table)(nil)
 var _ sql.IndexableTable = (*Table)(nil)
+var _ sql.TableOptioner = (*Table)(nil)
+var _ sql.RowCounter = (*Table)(nil)
 
 // NewTable creates a new Table with the given name and schema.
 func NewTable(name string, schema sql.Schema) *Table {
@@ -71,6 +76,20 @@ func (t *Table) Schema() sql.Schema {
 	return t.schema
 }
 
+// TableOptions implements the sql.TableOptioner interface.
+func (t *Table) TableOptions() sql.TableOptions {
+	return t.options
+}
+
+// RowCount implements the sql.RowCounter interface.
+func (t *Table) RowCount(*sql.Context) (uint64, error) {
+	var count uint64
+	for _, rows := range t.partitions {
+		count += uint64(len(rows))
+	}
+	return count, nil
+}
+
 // Partitions implements the sql.Table interface.
 func (t *Table) Partitions(ctx *sql.Context) (sql.PartitionIter, error) {
 	var keys [][]byte
@@ -258,6 +277,57 @@ func (t *Table) Insert(ctx *sql.Context, row sql.Row) error {
 	return nil
 }
 
+// ErrRowNotFound is returned when the row to update or delete is not in the
+// table.
+var ErrRowNotFound = errors.NewKind("row not found in table %s")
+
+// Update replaces the given old row with the new one.
+func (t *Table) Update(ctx *sql.Context, old sql.Row, new sql.Row) error {
+	if err := checkRow(t.schema, new); err != nil {
+		return err
+	}
+
+	key, pos, err := t.find(old)
+	if err != nil {
+		return err
+	}
+
+	t.partitions[key][pos] = new
+	return nil
+}
+
+// Delete removes the given row from the table.
+func (t *Table) Delete(ctx *sql.Context, row sql.Row) error {
+	key, pos, err := t.find(row)
+	if err != nil {
+		return err
+	}
+
+	rows := t.partitions[key]
+	t.partitions[key] = append(rows[:pos:pos], rows[pos+1:]...)
+	return nil
+}
+
+// find returns the partition and position of the first row equal to the
+// given one.
+func (t *Table) find(row sql.Row) (string, int, error) {
+	for _, k := range t.keys {
+		key := string(k)
+		for i, r := range t.partitions[key] {
+			ok, err := r.Equals(row, t.schema)
+			if err != nil {
+				return "", 0, err
+			}
+
+			if ok {
+				return key, i, nil
+			}
+		}
+	}
+
+	return "", 0, ErrRowNotFound.New(t.name)
+}
+
 func checkRow(schema sql.Schema, row sql.Row) error {
 	if len(row) != len(schema) {
 		return sql.ErrUnexpectedRowLength.New(len(schema), len(row))
@@ -0,0 +1,99 @@
+package optimizer
+
+import (
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	gmsplan "github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+// sortByIndex removes the sorts of the rows of a table that can return them
+// already sorted, usually because it has an index on the column they are
+// sorted by. The rows are not kept in memory to be sorted then, and a limit
+// above the sort stops reading the table as soon as it has enough rows.
+// Sorts by more than one column, or by anything but a column, are kept.
+func (o *GMSOptimizer) sortByIndex(n sql.Node) (sql.Node, error) {
+	return n.TransformUp(func(n sql.Node) (sql.Node, error) {
+		s, ok := n.(*gmsplan.Sort)
+		if !ok || len(s.SortFields) != 1 {
+			return n, nil
+		}
+
+		field, ok := s.SortFields[0].Column.(*expression.GetField)
+		if !ok {
+			return n, nil
+		}
+
+		if child, ok := withTableOrder(s.Child, field.Index(), s.SortFields[0]); ok {
+			return child, nil
+		}
+		return n, nil
+	})
+}
+
+// withTableOrder returns the node with the rows of its table sorted as the
+// sort field says, where idx is the position in the schema of the node of
+// the column they are sorted by. It returns false if the node changes the
+// order of the rows or its table can't sort them.
+func withTableOrder(n sql.Node, idx int, sf gmsplan.SortField) (sql.Node, bool) {
+	switch n := n.(type) {
+	case *gmsplan.ResolvedTable:
+		t, ok := n.Table.(sql.OrderedTable)
+		if !ok || idx >= len(n.Schema()) {
+			return nil, false
+		}
+
+		// The tables put the NULL values first only in ascending order.
+		col := n.Schema()[idx]
+		desc := sf.Order == gmsplan.Descending
+		if col.Nullable && (sf.NullOrdering == gmsplan.NullsFirst) == desc {
+			return nil, false
+		}
+
+		ordered, ok := t.WithOrder(col.Name, desc)
+		if !ok {
+			return nil, false
+		}
+		return gmsplan.NewResolvedTable(ordered), true
+	case *gmsplan.Filter:
+		child, ok := withTableOrder(n.Child, idx, sf)
+		if !ok {
+			return nil, false
+		}
+		return gmsplan.NewFilter(n.Expression, child), true
+	case *gmsplan.TableAlias:
+		child, ok := withTableOrder(n.Child, idx, sf)
+		if !ok {
+			return nil, false
+		}
+		return gmsplan.NewTableAlias(n.Name(), child), true
+	case *gmsplan.Exchange:
+		// The sorted rows are a single partition, which the exchange
+		// returns in order.
+		child, ok := withTableOrder(n.Child, idx, sf)
+		if !ok {
+			return nil, false
+		}
+		return gmsplan.NewExchange(n.Parallelism, child), true
+	case *gmsplan.Project:
+		if idx >= len(n.Projections) {
+			return nil, false
+		}
+
+		e := n.Projections[idx]
+		if a, ok := e.(*expression.Alias); ok {
+			e = a.Child
+		}
+		field, ok := e.(*expression.GetField)
+		if !ok {
+			return nil, false
+		}
+
+		child, ok := withTableOrder(n.Child, field.Index(), sf)
+		if !ok {
+			return nil, false
+		}
+		return gmsplan.NewProject(n.Projections, child), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,350 @@
+package optimizer
+
+import (
+	"math"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	gmsplan "github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+// joinLeaf is one of the nodes joined by a tree of inner joins.
+type joinLeaf struct {
+	node sql.Node
+	// offset is the index of the first column of the leaf in the schema of
+	// the whole join.
+	offset int
+	width  int
+	rows   uint64
+}
+
+// joinCond is a conjunct of the conditions of a tree of inner joins, with
+// its columns indexed in the schema of the whole join.
+type joinCond struct {
+	expr sql.Expression
+	// leaves are the indexes of the leaves whose columns it uses.
+	leaves map[int]bool
+}
+
+// reorderJoins reorders the trees of inner joins of the node so that the
+// estimated number of rows they produce at each step is as small as
+// possible. Only the order of the tables changes: a project on top of the
+// reordered join returns the columns in their original order, so the nodes
+// above it are left as they are. Joins of tables whose sizes are not known
+// are not reordered, and neither are the sides of other kinds of joins than
+// inner joins, whose semantics depend on their order.
+func (o *GMSOptimizer) reorderJoins(ctx *sql.Context, n sql.Node) (sql.Node, error) {
+	// Joins are transformed bottom up, so the ones nested in a bigger tree
+	// are reordered first. The projects made for them are seen through
+	// when the whole tree is, so it's reordered as a whole.
+	reordered := make(map[*gmsplan.Project]bool)
+	return n.TransformUp(func(n sql.Node) (sql.Node, error) {
+		j, ok := n.(*gmsplan.InnerJoin)
+		if !ok {
+			return n, nil
+		}
+
+		node, err := reorderJoin(ctx, j, reordered)
+		if p, ok := node.(*gmsplan.Project); ok {
+			reordered[p] = true
+		}
+		return node, err
+	})
+}
+
+// reorderJoin reorders the tree of inner joins with the given root.
+func reorderJoin(ctx *sql.Context, j *gmsplan.InnerJoin, reordered map[*gmsplan.Project]bool) (sql.Node, error) {
+	var leaves []joinLeaf
+	var conds []sql.Expression
+	columns, err := flattenJoin(j, reordered, &leaves, &conds)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range leaves {
+		rows, ok := estimateRows(ctx, leaves[i].node)
+		if !ok {
+			return j, nil
+		}
+		leaves[i].rows = rows
+	}
+
+	joinConds := make([]joinCond, len(conds))
+	for i, c := range conds {
+		joinConds[i] = joinCond{expr: c, leaves: condLeaves(c, leaves)}
+	}
+
+	order := chooseJoinOrder(leaves, joinConds)
+	changed := false
+	for i, l := range order {
+		if i != l {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return j, nil
+	}
+
+	return buildJoin(j.Schema(), columns, leaves, joinConds, order)
+}
+
+// flattenJoin appends the leaves of the tree of joins to leaves and the
+// conjuncts of their conditions to conds, with the columns of both indexed
+// in the schema made of all the leaves in order. It returns the function
+// that maps the index of a column in the schema of the node to that one.
+// Cross joins and the projects in reordered are part of the tree.
+func flattenJoin(
+	n sql.Node,
+	reordered map[*gmsplan.Project]bool,
+	leaves *[]joinLeaf,
+	conds *[]sql.Expression,
+) (func(int) int, error) {
+	var left, right sql.Node
+	var cond sql.Expression
+	switch n := n.(type) {
+	case *gmsplan.InnerJoin:
+		left, right, cond = n.Left, n.Right, n.Cond
+	case *gmsplan.CrossJoin:
+		left, right = n.Left, n.Right
+	case *gmsplan.Project:
+		if !reordered[n] {
+			break
+		}
+
+		childMap, err := flattenJoin(n.Child, reordered, leaves, conds)
+		if err != nil {
+			return nil, err
+		}
+		return func(idx int) int {
+			return childMap(n.Projections[idx].(*expression.GetField).Index())
+		}, nil
+	}
+
+	if left == nil {
+		offset := 0
+		if len(*leaves) > 0 {
+			last := (*leaves)[len(*leaves)-1]
+			offset = last.offset + last.width
+		}
+		*leaves = append(*leaves, joinLeaf{node: n, offset: offset, width: len(n.Schema())})
+		return func(idx int) int { return idx + offset }, nil
+	}
+
+	leftMap, err := flattenJoin(left, reordered, leaves, conds)
+	if err != nil {
+		return nil, err
+	}
+	rightMap, err := flattenJoin(right, reordered, leaves, conds)
+	if err != nil {
+		return nil, err
+	}
+
+	leftWidth := len(left.Schema())
+	mapping := func(idx int) int {
+		if idx < leftWidth {
+			return leftMap(idx)
+		}
+		return rightMap(idx - leftWidth)
+	}
+
+	for _, c := range splitConjunction(cond) {
+		mapped, err := remapFields(c, mapping)
+		if err != nil {
+			return nil, err
+		}
+		*conds = append(*conds, mapped)
+	}
+	return mapping, nil
+}
+
+func splitConjunction(e sql.Expression) []sql.Expression {
+	if e == nil {
+		return nil
+	}
+
+	and, ok := e.(*expression.And)
+	if !ok {
+		return []sql.Expression{e}
+	}
+
+	return append(splitConjunction(and.Left), splitConjunction(and.Right)...)
+}
+
+// remapFields returns the expression with the index of each of its fields
+// replaced by the one f returns.
+func remapFields(e sql.Expression, f func(int) int) (sql.Expression, error) {
+	return e.TransformUp(func(e sql.Expression) (sql.Expression, error) {
+		gf, ok := e.(*expression.GetField)
+		if !ok {
+			return e, nil
+		}
+		return gf.WithIndex(f(gf.Index())), nil
+	})
+}
+
+// condLeaves returns the indexes of the leaves whose columns the condition
+// uses.
+func condLeaves(c sql.Expression, leaves []joinLeaf) map[int]bool {
+	used := make(map[int]bool)
+	expression.Inspect(c, func(e sql.Expression) bool {
+		if gf, ok := e.(*expression.GetField); ok {
+			if l := leafOf(gf.Index(), leaves); l >= 0 {
+				used[l] = true
+			}
+		}
+		return true
+	})
+	return used
+}
+
+// leafOf returns the index of the leaf with the column at idx of the
+// schema of the whole join.
+func leafOf(idx int, leaves []joinLeaf) int {
+	for i, l := range leaves {
+		if idx >= l.offset && idx < l.offset+l.width {
+			return i
+		}
+	}
+	return -1
+}
+
+// chooseJoinOrder returns the order in which the leaves are joined. It
+// starts with the smallest leaf, which drives the join, and greedily joins
+// next the leaf that keeps the estimated number of rows lowest. Joining a
+// leaf related to the ones already joined by a condition is estimated to
+// produce as many rows as the bigger of both sides, as joins on keys do,
+// and joining an unrelated one the product of both. Ties are broken by the
+// size of the leaf and then by the order in the query.
+func chooseJoinOrder(leaves []joinLeaf, conds []joinCond) []int {
+	joined := make(map[int]bool, len(leaves))
+	order := make([]int, 0, len(leaves))
+
+	first := 0
+	for i, l := range leaves {
+		if l.rows < leaves[first].rows {
+			first = i
+		}
+	}
+	order = append(order, first)
+	joined[first] = true
+	rows := leaves[first].rows
+
+	for len(order) < len(leaves) {
+		next := -1
+		var nextRows uint64
+		for i, l := range leaves {
+			if joined[i] {
+				continue
+			}
+
+			var est uint64
+			if isConnected(i, joined, conds) {
+				est = rows
+				if l.rows > est {
+					est = l.rows
+				}
+			} else {
+				est = mulSaturating(rows, l.rows)
+			}
+
+			if next < 0 || est < nextRows || (est == nextRows && l.rows < leaves[next].rows) {
+				next, nextRows = i, est
+			}
+		}
+
+		order = append(order, next)
+		joined[next] = true
+		rows = nextRows
+	}
+
+	return order
+}
+
+// isConnected returns whether a condition relates the leaf to the ones
+// already joined.
+func isConnected(leaf int, joined map[int]bool, conds []joinCond) bool {
+	for _, c := range conds {
+		if !c.leaves[leaf] {
+			continue
+		}
+		for l := range c.leaves {
+			if joined[l] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func mulSaturating(a, b uint64) uint64 {
+	if a != 0 && b > math.MaxUint64/a {
+		return math.MaxUint64
+	}
+	return a * b
+}
+
+// buildJoin builds a left-deep tree of joins of the leaves in the given
+// order. Each condition is checked by the first join that has all the
+// columns it uses, and joins without conditions are cross joins. The result
+// has the columns of the original schema in their order; columns maps their
+// indexes to the ones in the schema made of all the leaves in their
+// original order.
+func buildJoin(schema sql.Schema, columns func(int) int, leaves []joinLeaf, conds []joinCond, order []int) (sql.Node, error) {
+	newOffsets := make([]int, len(leaves))
+	offset := 0
+	for _, l := range order {
+		newOffsets[l] = offset
+		offset += leaves[l].width
+	}
+
+	remap := func(idx int) int {
+		l := leafOf(idx, leaves)
+		if l < 0 {
+			return idx
+		}
+		return idx - leaves[l].offset + newOffsets[l]
+	}
+
+	node := leaves[order[0]].node
+	joined := map[int]bool{order[0]: true}
+	used := make([]bool, len(conds))
+	for _, l := range order[1:] {
+		joined[l] = true
+
+		var exprs []sql.Expression
+		for i, c := range conds {
+			if used[i] || !containsAll(joined, c.leaves) {
+				continue
+			}
+			used[i] = true
+
+			e, err := remapFields(c.expr, remap)
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, e)
+		}
+
+		if len(exprs) == 0 {
+			node = gmsplan.NewCrossJoin(node, leaves[l].node)
+		} else {
+			node = gmsplan.NewInnerJoin(node, leaves[l].node, expression.JoinAnd(exprs...))
+		}
+	}
+
+	projections := make([]sql.Expression, len(schema))
+	for i, col := range schema {
+		projections[i] = expression.NewGetFieldWithTable(remap(columns(i)), col.Type, col.Source, col.Name, col.Nullable)
+	}
+	return gmsplan.NewProject(projections, node), nil
+}
+
+func containsAll(set, subset map[int]bool) bool {
+	for k := range subset {
+		if !set[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -4,8 +4,14 @@ import (
 	"context"
 
 	"github.com/turtacn/guocedb/compute/plan"
+	"github.com/turtacn/guocedb/compute/sql"
+	gmsplan "github.com/turtacn/guocedb/compute/sql/plan"
 )
 
+// nestedLoopMaxRows is the maximum number of row pairs an inner join can
+// compare to be executed as a nested loop. Bigger joins use a hash join.
+const nestedLoopMaxRows = 1 << 14
+
 // Optimizer optimizes the plan.
 // In GMS, the analyzer performs both analysis and optimization (rule-based).
 // This interface might be redundant if we just use Analyzer, but we keep it for architecture separation.
@@ -15,17 +21,104 @@ type Optimizer interface {
 
 // GMSOptimizer is a wrapper. Since GMS Analyzer does optimization, this might just be a pass-through
 // or handle specific optimization stages if we separated them.
+// On top of that, it chooses how joins are executed.
 type GMSOptimizer struct {
+	// JoinMemoryBudget is the number of bytes a hash join can keep in
+	// memory before spilling its inputs to disk.
+	JoinMemoryBudget int64
+	// TempDir is the directory where joins spill to disk. The default
+	// directory for temporary files is used if it's empty.
+	TempDir string
 }
 
 func NewOptimizer() *GMSOptimizer {
-	return &GMSOptimizer{}
+	return &GMSOptimizer{JoinMemoryBudget: gmsplan.DefaultJoinMemoryBudget}
 }
 
 func (o *GMSOptimizer) Optimize(ctx context.Context, node plan.Node) (plan.Node, error) {
 	// In GMS, optimization happens during analysis (Analyzer.Analyze).
 	// So if the node is already analyzed, it might be already optimized.
-	// However, if we want to add extra optimization steps, we can do it here.
-	// For now, we return the node as is.
-	return node, nil
+	// The only extra steps are choosing the join order and algorithm, and
+	// reading sorted rows from indexes.
+	sqlCtx, ok := ctx.(*sql.Context)
+	if !ok {
+		sqlCtx = sql.NewContext(ctx)
+	}
+
+	node, err := o.reorderJoins(sqlCtx, node)
+	if err != nil {
+		return nil, err
+	}
+
+	if node, err = o.sortByIndex(node); err != nil {
+		return nil, err
+	}
+
+	return node.TransformUp(func(n sql.Node) (sql.Node, error) {
+		j, ok := n.(*gmsplan.InnerJoin)
+		if !ok || !o.useHashJoin(sqlCtx, j) {
+			return n, nil
+		}
+
+		return gmsplan.NewHashJoin(j.Left, j.Right, j.Cond, o.JoinMemoryBudget, o.TempDir)
+	})
+}
+
+// useHashJoin returns whether the join should be executed as a hash join,
+// which is the case for joins on equalities unless the tables are known to
+// be small enough for a nested loop to be cheaper.
+func (o *GMSOptimizer) useHashJoin(ctx *sql.Context, j *gmsplan.InnerJoin) bool {
+	if !gmsplan.IsEquiJoin(j.Cond, len(j.Left.Schema())) {
+		return false
+	}
+
+	left, lok := estimateRows(ctx, j.Left)
+	right, rok := estimateRows(ctx, j.Right)
+	if lok && rok && (left == 0 || right <= nestedLoopMaxRows/left) {
+		return false
+	}
+
+	return true
+}
+
+// estimateRows returns an estimate of the number of rows the node returns,
+// if the tables it reads can tell how many rows they have. Tables that count
+// their rows give an upper bound, and analyzed tables the number of rows
+// their statistics expect to match their filters.
+func estimateRows(ctx *sql.Context, n sql.Node) (uint64, bool) {
+	switch n := n.(type) {
+	case *gmsplan.ResolvedTable:
+		var table sql.Table = n.Table
+		for {
+			if c, ok := table.(sql.RowCounter); ok {
+				count, err := c.RowCount(ctx)
+				return count, err == nil
+			}
+
+			if st, ok := table.(sql.StatisticsTable); ok && st.Statistics() != nil {
+				var filters []sql.Expression
+				if ft, ok := table.(sql.FilteredTable); ok {
+					filters = ft.Filters()
+				}
+				stats := st.Statistics()
+				return applySelectivity(stats.RowCount, selectivity(stats, filters...)), true
+			}
+
+			w, ok := table.(sql.TableWrapper)
+			if !ok {
+				return 0, false
+			}
+			table = w.Underlying()
+		}
+	case *gmsplan.Filter:
+		rows, ok := estimateRows(ctx, n.Child)
+		if !ok {
+			return 0, false
+		}
+		return applySelectivity(rows, selectivity(statisticsOf(n.Child), n.Expression)), true
+	case *gmsplan.TableAlias, *gmsplan.Project, *gmsplan.Exchange:
+		return estimateRows(ctx, n.Children()[0])
+	default:
+		return 0, false
+	}
 }
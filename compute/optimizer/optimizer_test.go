@@ -0,0 +1,149 @@
+package optimizer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	gmsplan "github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+func TestOptimize_JoinAlgorithm(t *testing.T) {
+	table := func(name string, rows int) sql.Node {
+		tbl := mem.NewTable(name, sql.Schema{
+			{Name: "id", Type: sql.Int64, Source: name},
+		})
+		for i := 0; i < rows; i++ {
+			require.NoError(t, tbl.Insert(sql.NewEmptyContext(), sql.NewRow(int64(i))))
+		}
+		return gmsplan.NewResolvedTable(tbl)
+	}
+
+	equals := expression.NewEquals(
+		expression.NewGetFieldWithTable(0, sql.Int64, "a", "id", false),
+		expression.NewGetFieldWithTable(1, sql.Int64, "b", "id", false),
+	)
+	lessThan := expression.NewLessThan(
+		expression.NewGetFieldWithTable(0, sql.Int64, "a", "id", false),
+		expression.NewGetFieldWithTable(1, sql.Int64, "b", "id", false),
+	)
+
+	testCases := []struct {
+		name      string
+		left      sql.Node
+		right     sql.Node
+		cond      sql.Expression
+		hashJoins bool
+	}{
+		{"small tables", table("a", 10), table("b", 10), equals, false},
+		{"large tables", table("a", 200), table("b", 200), equals, true},
+		{"large tables without equality", table("a", 200), table("b", 200), lessThan, false},
+		{"unknown size", gmsplan.NewTableAlias("a", table("a", 10)), gmsplan.NewSubqueryAlias("b", table("b", 10)), equals, true},
+	}
+
+	o := NewOptimizer()
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			node, err := o.Optimize(sql.NewEmptyContext(), gmsplan.NewInnerJoin(tt.left, tt.right, tt.cond))
+			require.NoError(err)
+
+			_, ok := node.(*gmsplan.HashJoin)
+			require.Equal(tt.hashJoins, ok, node.String())
+
+			rows, err := sql.NodeToRows(sql.NewEmptyContext(), node)
+			require.NoError(err)
+			if tt.cond == equals {
+				require.Len(rows, len(rowsOf(t, tt.left)))
+			}
+		})
+	}
+}
+
+func rowsOf(t *testing.T, n sql.Node) []sql.Row {
+	rows, err := sql.NodeToRows(sql.NewEmptyContext(), n)
+	require.NoError(t, err)
+	return rows
+}
+
+func TestOptimize_JoinOrder(t *testing.T) {
+	require := require.New(t)
+
+	table := func(name string, rows int) *gmsplan.ResolvedTable {
+		tbl := mem.NewTable(name, sql.Schema{
+			{Name: "id", Type: sql.Int64, Source: name},
+			{Name: "name", Type: sql.Text, Source: name},
+		})
+		for i := 0; i < rows; i++ {
+			require.NoError(tbl.Insert(sql.NewEmptyContext(), sql.NewRow(int64(i), fmt.Sprintf("%s%d", name, i))))
+		}
+		return gmsplan.NewResolvedTable(tbl)
+	}
+	field := func(idx int, table, name string) sql.Expression {
+		return expression.NewGetFieldWithTable(idx, sql.Int64, table, name, false)
+	}
+
+	big, medium, small := table("big", 300), table("medium", 30), table("small", 3)
+
+	// big JOIN medium ON big.id = medium.id JOIN small ON medium.id = small.id
+	join := gmsplan.NewInnerJoin(
+		gmsplan.NewInnerJoin(big, medium, expression.NewEquals(field(0, "big", "id"), field(2, "medium", "id"))),
+		small,
+		expression.NewEquals(field(2, "medium", "id"), field(4, "small", "id")),
+	)
+
+	node, err := NewOptimizer().Optimize(sql.NewEmptyContext(), join)
+	require.NoError(err)
+
+	// The smallest table drives the join.
+	var leaves []string
+	gmsplan.Inspect(node, func(n sql.Node) bool {
+		if t, ok := n.(*gmsplan.ResolvedTable); ok {
+			leaves = append(leaves, t.Name())
+		}
+		return true
+	})
+	require.Equal([]string{"small", "medium", "big"}, leaves, node.String())
+
+	// The columns and rows are the same as in the order of the query.
+	require.Equal(join.Schema(), node.Schema())
+	require.ElementsMatch(rowsOf(t, join), rowsOf(t, node))
+	require.Len(rowsOf(t, node), 3)
+
+	// The order chosen is shown when the query is described.
+	described, err := NewOptimizer().Optimize(sql.NewEmptyContext(), gmsplan.NewDescribeQuery("tree", join))
+	require.NoError(err)
+	plan := described.String()
+	require.True(strings.Index(plan, "Table(small)") < strings.Index(plan, "Table(medium)"), plan)
+	require.True(strings.Index(plan, "Table(medium)") < strings.Index(plan, "Table(big)"), plan)
+}
+
+func TestOptimize_JoinOrderKept(t *testing.T) {
+	table := func(name string, rows int) sql.Node {
+		tbl := mem.NewTable(name, sql.Schema{{Name: "id", Type: sql.Int64, Source: name}})
+		for i := 0; i < rows; i++ {
+			require.NoError(t, tbl.Insert(sql.NewEmptyContext(), sql.NewRow(int64(i))))
+		}
+		return gmsplan.NewResolvedTable(tbl)
+	}
+	cond := expression.NewEquals(
+		expression.NewGetFieldWithTable(0, sql.Int64, "a", "id", false),
+		expression.NewGetFieldWithTable(1, sql.Int64, "b", "id", false),
+	)
+
+	// Joins already in the best order, or of tables of unknown size, are
+	// left as they are.
+	for _, join := range []sql.Node{
+		gmsplan.NewInnerJoin(table("a", 3), table("b", 30), cond),
+		gmsplan.NewInnerJoin(gmsplan.NewSubqueryAlias("a", table("a", 30)), table("b", 3), cond),
+	} {
+		node, err := NewOptimizer().Optimize(sql.NewEmptyContext(), join)
+		require.NoError(t, err)
+		require.Equal(t, join.Children(), node.Children())
+	}
+}
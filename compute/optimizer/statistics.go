@@ -0,0 +1,107 @@
+package optimizer
+
+import (
+	"math"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	gmsplan "github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+// statisticsOf returns the statistics of the table the node reads, or nil if
+// it reads none or the table hasn't been analyzed.
+func statisticsOf(n sql.Node) *sql.TableStatistics {
+	switch n := n.(type) {
+	case *gmsplan.ResolvedTable:
+		return sql.StatisticsOf(n.Table)
+	case *gmsplan.TableAlias, *gmsplan.Filter, *gmsplan.Project, *gmsplan.Exchange:
+		return statisticsOf(n.Children()[0])
+	default:
+		return nil
+	}
+}
+
+// selectivity returns the estimated fraction of the rows of a table with the
+// given statistics that match all the filters. Filters the statistics can't
+// tell about are assumed to match all the rows.
+func selectivity(stats *sql.TableStatistics, filters ...sql.Expression) float64 {
+	if stats == nil {
+		return 1
+	}
+
+	s := 1.0
+	for _, f := range filters {
+		s *= filterSelectivity(stats, f)
+	}
+	return s
+}
+
+func filterSelectivity(stats *sql.TableStatistics, f sql.Expression) float64 {
+	switch f := f.(type) {
+	case *expression.And:
+		return filterSelectivity(stats, f.Left) * filterSelectivity(stats, f.Right)
+	case *expression.IsNull:
+		if cs := columnStatistics(stats, f.Child); cs != nil {
+			return cs.NullFraction
+		}
+	case *expression.Equals:
+		field, lit := f.Left(), f.Right()
+		if _, ok := field.(*expression.GetField); !ok {
+			field, lit = lit, field
+		}
+		cs := columnStatistics(stats, field)
+		l, ok := lit.(*expression.Literal)
+		if cs == nil || !ok {
+			return 1
+		}
+		if l.Value() == nil || cs.NDV == 0 || outOfRange(field.Type(), cs, l.Value()) {
+			return 0
+		}
+		return (1 - cs.NullFraction) / float64(cs.NDV)
+	}
+	return 1
+}
+
+// columnStatistics returns the statistics of the column the expression is,
+// or nil if it isn't a column or there are none.
+func columnStatistics(stats *sql.TableStatistics, e sql.Expression) *sql.ColumnStatistics {
+	field, ok := e.(*expression.GetField)
+	if !ok {
+		return nil
+	}
+	return stats.Column(field.Name())
+}
+
+// outOfRange returns whether the value is out of the range of the values of
+// the column, so no row is equal to it.
+func outOfRange(typ sql.Type, cs *sql.ColumnStatistics, v interface{}) bool {
+	if cs.Min == nil {
+		return true
+	}
+
+	v, err := typ.Convert(v)
+	if err != nil {
+		return false
+	}
+	if cmp, err := typ.Compare(v, cs.Min); err == nil && cmp < 0 {
+		return true
+	}
+	if cmp, err := typ.Compare(v, cs.Max); err == nil && cmp > 0 {
+		return true
+	}
+	return false
+}
+
+// applySelectivity returns the number of the rows expected to be left after
+// keeping the given fraction of them. At least one row is expected if any
+// may be, so that empty estimates are only trusted when they are certain.
+func applySelectivity(rows uint64, s float64) uint64 {
+	if rows == 0 || s <= 0 {
+		return 0
+	}
+	est := uint64(math.Ceil(float64(rows) * s))
+	if est > rows {
+		return rows
+	}
+	return est
+}
@@ -39,7 +39,9 @@ func Decompose(n Node) ([]Node, error) {
 // IsDDL returns true if the plan node is a DDL statement.
 func IsDDL(n Node) bool {
 	switch n.(type) {
-	case *gmsplan.CreateTable, *gmsplan.CreateIndex, *gmsplan.DropIndex:
+	case *gmsplan.CreateTable, *gmsplan.CreateIndex, *gmsplan.DropIndex,
+		*gmsplan.AddColumn, *gmsplan.DropColumn, *gmsplan.AlterAutoIncrement,
+		*gmsplan.TruncateTable:
 		return true
 	default:
 		return false
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/security"
+	"github.com/turtacn/guocedb/security/audit"
+	secauth "github.com/turtacn/guocedb/security/auth"
+	"github.com/turtacn/guocedb/security/authz"
+)
+
+// directoryProvider stands for a provider checking the passwords against
+// an external directory, such as a LDAP one.
+type directoryProvider struct {
+	passwords map[string]string
+	attempts  []string
+}
+
+func (p *directoryProvider) Authenticate(ctx context.Context, user string, credential secauth.Credential, clientAddr string) (*secauth.User, error) {
+	p.attempts = append(p.attempts, user+"@"+clientAddr)
+	if password, ok := p.passwords[user]; !ok || password != credential.Password {
+		return nil, secauth.ErrAuthenticationFailed
+	}
+	return &secauth.User{Username: user, Privileges: authz.PrivilegeReadOnly}, nil
+}
+
+func TestServer_AuthProvider(t *testing.T) {
+	provider := &directoryProvider{passwords: map[string]string{"alice": "from-directory"}}
+	secauth.RegisterProvider("directory-test", func(config secauth.ProviderConfig) (secauth.Provider, error) {
+		require.Equal(t, "ldap://directory", config.Options["url"])
+		return provider, nil
+	})
+
+	sm, err := security.NewSecurityManager(security.SecurityConfig{
+		Enabled:             true,
+		AuditConfig:         audit.AuditConfig{FilePath: filepath.Join(t.TempDir(), "audit.log")},
+		AuthProvider:        "directory-test",
+		AuthProviderOptions: map[string]string{"url": "ldap://directory"},
+	})
+	require.NoError(t, err)
+	defer sm.Close()
+	require.False(t, sm.AcceptsScrambles())
+
+	catalog := sqlengine.NewCatalog()
+	catalog.AddDatabase(newMockDatabase("testdb"))
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "127.0.0.1:0",
+		Auth:     auth.NewSecurity(sm),
+	}, engine)
+	require.NoError(t, err)
+	// The provider needs the passwords in clear text, which the test
+	// sends without TLS.
+	s.Listener.AllowClearTextWithoutTLS.Set(true)
+	s.Start()
+	defer s.Close()
+
+	connect := func(user, password string) error {
+		dsn := fmt.Sprintf("%s:%s@tcp(%s)/testdb?allowCleartextPasswords=true", user, password, s.Addr())
+		db, err := sql.Open("mysql", dsn)
+		require.NoError(t, err)
+		defer db.Close()
+
+		var one int64
+		return db.QueryRow("SELECT 1").Scan(&one)
+	}
+
+	requireAccessDenied := func(err error, user string) {
+		t.Helper()
+		var mysqlErr *mysqldriver.MySQLError
+		require.ErrorAs(t, err, &mysqlErr)
+		require.Equal(t, uint16(1045), mysqlErr.Number)
+		require.Contains(t, mysqlErr.Message, "Access denied for user '"+user+"'")
+	}
+
+	// The user approved by the provider logs in and gets its privileges,
+	// though it's not a user of the server.
+	require.NoError(t, connect("alice", "from-directory"))
+	user, err := sm.GetUser(context.Background(), "alice")
+	require.NoError(t, err)
+	require.Equal(t, authz.PrivilegeReadOnly, user.Privileges)
+
+	requireAccessDenied(connect("alice", "wrong"), "alice")
+	requireAccessDenied(connect("bob", "from-directory"), "bob")
+
+	require.Len(t, provider.attempts, 3)
+	require.Equal(t, "alice@127.0.0.1", provider.attempts[0])
+}
+
+func TestNewSecurityManager_UnknownAuthProvider(t *testing.T) {
+	_, err := security.NewSecurityManager(security.SecurityConfig{
+		Enabled:      true,
+		AuditConfig:  audit.AuditConfig{FilePath: filepath.Join(t.TempDir(), "audit.log")},
+		AuthProvider: "missing",
+	})
+	require.Error(t, err)
+}
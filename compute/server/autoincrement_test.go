@@ -0,0 +1,146 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/transaction"
+	"github.com/turtacn/guocedb/storage/engines/badger"
+)
+
+// startBadgerTestServer starts a server whose testdb database is stored
+// in badger, with transactions, and returns a client connected to it.
+func startBadgerTestServer(t *testing.T) *sql.DB {
+	_, db := startBadgerTestHandler(t)
+	return db
+}
+
+// startBadgerTestHandler is startBadgerTestServer, which also returns the
+// handler of the server.
+func startBadgerTestHandler(t *testing.T) (*Handler, *sql.DB) {
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	t.Cleanup(func() { kv.Close() })
+
+	catalog := sqlengine.NewCatalog()
+	catalog.AddDatabase(badger.NewDatabase("testdb", kv))
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+	handler := NewHandlerWithTxnManager(engine, NewSessionManager(DefaultSessionBuilder, nil, "localhost:0"),
+		transaction.NewManagerWithDB(kv))
+
+	authServer := auth.NewNativeSingle("root", "", auth.AllPermissions)
+	l, err := mysql.NewListener("tcp", "127.0.0.1:0", authServer.Mysql(), handler, 0, 0)
+	require.NoError(t, err)
+	go l.Accept()
+	t.Cleanup(l.Close)
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", l.Addr()))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return handler, db
+}
+
+func TestE2E_AutoIncrement(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	_, err := db.Exec("CREATE TABLE t (id BIGINT AUTO_INCREMENT PRIMARY KEY, name TEXT)")
+	require.NoError(err)
+
+	lastInsertID := func(query string, args ...interface{}) int64 {
+		t.Helper()
+		res, err := db.Exec(query, args...)
+		require.NoError(err)
+		id, err := res.LastInsertId()
+		require.NoError(err)
+		return id
+	}
+
+	require.Equal(int64(1), lastInsertID("INSERT INTO t (name) VALUES ('a')"))
+	require.Equal(int64(2), lastInsertID("INSERT INTO t (id, name) VALUES (NULL, 'b')"))
+	require.Equal(int64(3), lastInsertID("INSERT INTO t (id, name) VALUES (0, 'c')"))
+	// With arguments, the driver uses a prepared statement.
+	require.Equal(int64(4), lastInsertID("INSERT INTO t (name) VALUES (?)", "d"))
+	// Only the first value generated by a statement is reported.
+	require.Equal(int64(5), lastInsertID("INSERT INTO t (name) VALUES ('e'), ('f')"))
+
+	// An explicit value moves the counter past it.
+	require.Equal(int64(0), lastInsertID("INSERT INTO t (id, name) VALUES (10, 'g')"))
+	require.Equal(int64(11), lastInsertID("INSERT INTO t (name) VALUES ('h')"))
+
+	_, err = db.Exec("ALTER TABLE t AUTO_INCREMENT = 100")
+	require.NoError(err)
+	require.Equal(int64(100), lastInsertID("INSERT INTO t (name) VALUES ('i')"))
+
+	// The counter is never set below the values the table has.
+	_, err = db.Exec("ALTER TABLE t AUTO_INCREMENT = 50")
+	require.NoError(err)
+	require.Equal(int64(101), lastInsertID("INSERT INTO t (name) VALUES ('j')"))
+
+	rows, err := db.Query("SELECT id FROM t ORDER BY id")
+	require.NoError(err)
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		require.NoError(rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(rows.Err())
+	require.NoError(rows.Close())
+	require.Equal([]int64{1, 2, 3, 4, 5, 6, 10, 11, 100, 101}, ids)
+}
+
+func TestE2E_AutoIncrementConcurrent(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	_, err := db.Exec("CREATE TABLE t (id BIGINT AUTO_INCREMENT PRIMARY KEY, name TEXT)")
+	require.NoError(err)
+
+	const workers, inserts = 8, 10
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < inserts; i++ {
+				res, err := db.Exec("INSERT INTO t (name) VALUES ('x')")
+				if !assert.NoError(t, err) {
+					return
+				}
+				id, err := res.LastInsertId()
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("id %d given to several rows", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(seen, workers*inserts)
+
+	var count int64
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM t").Scan(&count))
+	require.Equal(int64(workers*inserts), count)
+}
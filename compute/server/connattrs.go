@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/dolthub/vitess/go/mysql"
+)
+
+// parseConnAttributes returns the connection attributes of the answer of a
+// client to the handshake, such as program_name and _client_version, which
+// the listener reads but doesn't keep. It returns false if the client sent
+// none, or if the packet can't be read.
+func parseConnAttributes(data []byte) (map[string]string, bool) {
+	r := handshakeReader{data: data, ok: true}
+	flags := r.uint32()
+	if flags&mysql.CapabilityClientProtocol41 == 0 || flags&mysql.CapabilityClientConnAttr == 0 {
+		return nil, false
+	}
+
+	// Max packet size, character set and filler.
+	r.skip(4 + 1 + 23)
+	r.nullString() // User
+
+	switch {
+	case flags&mysql.CapabilityClientPluginAuthLenencClientData != 0:
+		r.lenEncString()
+	case flags&mysql.CapabilityClientSecureConnection != 0:
+		r.skip(int(r.byte()))
+	default:
+		r.nullString()
+	}
+	if flags&mysql.CapabilityClientConnectWithDB != 0 {
+		r.nullString()
+	}
+	if flags&mysql.CapabilityClientPluginAuth != 0 {
+		r.nullString()
+	}
+
+	n, ok := r.lenEncInt()
+	r.ok = r.ok && ok
+	attrs := handshakeReader{data: r.bytes(int(n)), ok: true}
+	if !r.ok {
+		return nil, false
+	}
+
+	m := make(map[string]string)
+	for attrs.ok && len(attrs.data) > 0 {
+		k, v := attrs.lenEncString(), attrs.lenEncString()
+		m[string(k)] = string(v)
+	}
+	return m, attrs.ok
+}
+
+// handshakeReader reads the fields of a handshake packet. Once a read
+// goes past the end of the packet, ok is false and the reads return
+// nothing.
+type handshakeReader struct {
+	data []byte
+	ok   bool
+}
+
+func (r *handshakeReader) bytes(n int) []byte {
+	if !r.ok || n < 0 || n > len(r.data) {
+		r.ok = false
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *handshakeReader) skip(n int) { r.bytes(n) }
+
+func (r *handshakeReader) byte() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *handshakeReader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *handshakeReader) nullString() []byte {
+	i := bytes.IndexByte(r.data, 0)
+	if i < 0 {
+		r.ok = false
+		return nil
+	}
+	s := r.bytes(i)
+	r.skip(1)
+	return s
+}
+
+func (r *handshakeReader) lenEncInt() (uint64, bool) {
+	switch b := r.byte(); b {
+	case 0xfc:
+		b := r.bytes(2)
+		if b == nil {
+			return 0, false
+		}
+		return uint64(binary.LittleEndian.Uint16(b)), true
+	case 0xfd:
+		b := r.bytes(3)
+		if b == nil {
+			return 0, false
+		}
+		return uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16, true
+	case 0xfe:
+		b := r.bytes(8)
+		if b == nil {
+			return 0, false
+		}
+		return binary.LittleEndian.Uint64(b), true
+	default:
+		return uint64(b), r.ok && b < 0xfb
+	}
+}
+
+func (r *handshakeReader) lenEncString() []byte {
+	n, ok := r.lenEncInt()
+	if !ok {
+		r.ok = false
+		return nil
+	}
+	return r.bytes(int(n))
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+)
+
+func TestServer_ConnectionAttributes(t *testing.T) {
+	catalog := sqlengine.NewCatalog()
+	catalog.AddDatabase(newMockDatabase("testdb"))
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+
+	logger, hook := test.NewNullLogger()
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "127.0.0.1:0",
+		Auth:     auth.NewAudit(auth.NewNativeSingle("root", "", auth.AllPermissions), auth.NewAuditLog(logger)),
+	}, engine)
+	require.NoError(t, err)
+	s.Start()
+	defer s.Close()
+
+	db, err := sql.Open("mysql", fmt.Sprintf(
+		"root@tcp(%s)/testdb?connectionAttributes=program_name:billing,_client_version:1.2.3", s.Addr()))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var id uint32
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id))
+
+	// The session keeps the attributes the driver sends, and the ones of
+	// the DSN.
+	attrs := s.Handler.sessionMgr.GetSession(id).Attributes()
+	require.Equal(t, "billing", attrs["program_name"])
+	require.Equal(t, "1.2.3", attrs["_client_version"])
+	require.Equal(t, "Go-MySQL-Driver", attrs["_client_name"])
+
+	rows, err := conn.QueryContext(ctx, "SHOW FULL PROCESSLIST")
+	require.NoError(t, err)
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+	require.Equal(t, []string{"Id", "User", "Host", "db", "Command", "Time", "State", "Info", "Program", "Client"}, columns)
+
+	require.True(t, rows.Next())
+	var (
+		procID             uint32
+		user, host, dbName string
+		command, state     string
+		secs               int64
+		info               sql.NullString
+		program, client    sql.NullString
+	)
+	require.NoError(t, rows.Scan(&procID, &user, &host, &dbName, &command, &secs, &state, &info, &program, &client))
+	require.False(t, rows.Next())
+	require.NoError(t, rows.Close())
+
+	require.Equal(t, id, procID)
+	require.Equal(t, sql.NullString{String: "billing", Valid: true}, program)
+	require.Equal(t, sql.NullString{String: "Go-MySQL-Driver 1.2.3", Valid: true}, client)
+
+	// The queries logged in the audit trail have them too.
+	var logged []logrus.Fields
+	for _, entry := range hook.AllEntries() {
+		if entry.Data["action"] == "query" {
+			logged = append(logged, entry.Data)
+		}
+	}
+	require.NotEmpty(t, logged)
+	for _, fields := range logged {
+		require.Equal(t, attrs, fields["connection_attributes"])
+	}
+}
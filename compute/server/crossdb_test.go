@@ -0,0 +1,97 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/transaction"
+	"github.com/turtacn/guocedb/storage/engines/badger"
+)
+
+// startMultiDatabaseTestServer starts a server with the given databases
+// stored in badger, and returns a client connected to the first one.
+func startMultiDatabaseTestServer(t *testing.T, names ...string) *sql.DB {
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	t.Cleanup(func() { kv.Close() })
+
+	catalog := sqlengine.NewCatalog()
+	for _, name := range names {
+		catalog.AddDatabase(badger.NewDatabase(name, kv))
+	}
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+	handler := NewHandlerWithTxnManager(engine, NewSessionManager(DefaultSessionBuilder, nil, "localhost:0"),
+		transaction.NewManagerWithDB(kv))
+
+	authServer := auth.NewNativeSingle("root", "", auth.AllPermissions)
+	l, err := mysql.NewListener("tcp", "127.0.0.1:0", authServer.Mysql(), handler, 0, 0)
+	require.NoError(t, err)
+	go l.Accept()
+	t.Cleanup(l.Close)
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/%s", l.Addr(), names[0]))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestE2E_CrossDatabaseJoin(t *testing.T) {
+	require := require.New(t)
+	db := startMultiDatabaseTestServer(t, "testdb", "db1", "db2")
+
+	for _, query := range []string{
+		"CREATE TABLE db1.users (id BIGINT PRIMARY KEY, name TEXT)",
+		"CREATE TABLE db2.orders (id BIGINT PRIMARY KEY, user_id BIGINT, total BIGINT)",
+		"INSERT INTO db1.users VALUES (1, 'alice'), (2, 'bob')",
+		"INSERT INTO db2.orders VALUES (10, 1, 30), (11, 1, 12), (12, 2, 5)",
+	} {
+		_, err := db.Exec(query)
+		require.NoError(err, query)
+	}
+
+	// The tables were created in their databases, not in the current one.
+	var n int
+	require.Error(db.QueryRow("SELECT COUNT(*) FROM orders").Scan(&n))
+
+	rows, err := db.Query(`SELECT u.name, o.total
+		FROM db1.users u JOIN db2.orders o ON u.id = o.user_id
+		ORDER BY o.id`)
+	require.NoError(err)
+	defer rows.Close()
+
+	type result struct {
+		name  string
+		total int64
+	}
+	var results []result
+	for rows.Next() {
+		var r result
+		require.NoError(rows.Scan(&r.name, &r.total))
+		results = append(results, r)
+	}
+	require.NoError(rows.Err())
+	require.Equal([]result{{"alice", 30}, {"alice", 12}, {"bob", 5}}, results)
+
+	// A table of the current database joins those of the others too.
+	_, err = db.Exec("CREATE TABLE discounts (user_id BIGINT PRIMARY KEY, percent BIGINT)")
+	require.NoError(err)
+	_, err = db.Exec("INSERT INTO discounts VALUES (2, 50)")
+	require.NoError(err)
+
+	var name string
+	var percent int64
+	require.NoError(db.QueryRow(`SELECT u.name, d.percent
+		FROM db1.users u JOIN discounts d ON u.id = d.user_id`).Scan(&name, &percent))
+	require.Equal("bob", name)
+	require.Equal(int64(50), percent)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	gosql "database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_DescribeTable(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	_, err := db.Exec(`CREATE TABLE orders (
+		id BIGINT NOT NULL AUTO_INCREMENT,
+		ref VARCHAR(16) NOT NULL UNIQUE,
+		customer INT,
+		status VARCHAR(10) DEFAULT 'new',
+		total DOUBLE DEFAULT 0,
+		PRIMARY KEY (id),
+		KEY idx_customer (customer, status)
+	)`)
+	require.NoError(err)
+
+	describe := func(query string) [][]string {
+		rows, err := db.Query(query)
+		require.NoError(err)
+		defer rows.Close()
+
+		var result [][]string
+		for rows.Next() {
+			var field, typ, null, key, extra string
+			var def gosql.NullString
+			require.NoError(rows.Scan(&field, &typ, &null, &key, &def, &extra))
+
+			d := "NULL"
+			if def.Valid {
+				d = def.String
+			}
+			result = append(result, []string{field, typ, null, key, d, extra})
+		}
+		require.NoError(rows.Err())
+		return result
+	}
+
+	expected := [][]string{
+		{"id", "bigint", "NO", "PRI", "NULL", "auto_increment"},
+		{"ref", "varchar(16)", "NO", "UNI", "NULL", ""},
+		{"customer", "int", "YES", "MUL", "NULL", ""},
+		{"status", "varchar(10)", "YES", "", "new", ""},
+		{"total", "double", "YES", "", "0", ""},
+	}
+	for _, query := range []string{
+		"DESCRIBE orders",
+		"DESC orders",
+		"SHOW COLUMNS FROM orders",
+		"SHOW COLUMNS FROM orders FROM testdb",
+	} {
+		require.Equal(expected, describe(query), query)
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+)
+
+// blockingTable is a table whose rows are returned once release is closed.
+type blockingTable struct {
+	*mockTable
+	started chan struct{}
+	release chan struct{}
+}
+
+func (t *blockingTable) PartitionRows(ctx *sqlengine.Context, p sqlengine.Partition) (sqlengine.RowIter, error) {
+	close(t.started)
+	<-t.release
+	return t.mockTable.PartitionRows(ctx, p)
+}
+
+func TestHandler_Drain(t *testing.T) {
+	handler, addr := startHandlerListener(t, 0)
+
+	table := &blockingTable{
+		mockTable: newMockTable("slow", sqlengine.Schema{
+			{Name: "n", Type: sqlengine.Int64, Source: "slow"},
+		}, []sqlengine.Row{{int64(1)}}),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	db, err := handler.e.Catalog.Database("testdb")
+	require.NoError(t, err)
+	db.(*mockDatabase).tables["slow"] = table
+
+	client, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", addr))
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	slowConn, err := client.Conn(ctx)
+	require.NoError(t, err)
+	defer slowConn.Close()
+	conn, err := client.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		var n int64
+		result <- slowConn.QueryRowContext(ctx, "SELECT n FROM slow").Scan(&n)
+	}()
+	<-table.started
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- handler.Drain(ctx)
+	}()
+	require.Eventually(t, handler.shuttingDown.Load, time.Second, time.Millisecond)
+
+	// New queries are refused while the running one goes on.
+	var one int64
+	err = conn.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(t, err, &mysqlErr)
+	require.Equal(t, uint16(ERServerShutdown), mysqlErr.Number)
+	require.Contains(t, mysqlErr.Message, "server shutting down")
+
+	select {
+	case err := <-drained:
+		t.Fatalf("drain returned with a query running: %v", err)
+	default:
+	}
+
+	close(table.release)
+	require.NoError(t, <-result)
+	require.NoError(t, <-drained)
+	require.Zero(t, handler.Stats().ActiveQueries)
+}
+
+func TestHandler_DrainTimeout(t *testing.T) {
+	handler, _ := startHandlerListener(t, 0)
+	handler.activeQueries.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := handler.Drain(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Contains(t, err.Error(), "1 queries still running")
+}
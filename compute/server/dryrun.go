@@ -0,0 +1,45 @@
+package server
+
+import (
+	"regexp"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+// DryRunVariable is the session variable that, when it's on, makes the
+// statements of the session be parsed, analyzed and optimized without
+// executing them.
+const DryRunVariable = "dry_run"
+
+// dryRunHintRegex matches the optimizer hint that dry-runs a single
+// statement, such as SELECT /*+ DRYRUN */ * FROM t.
+var dryRunHintRegex = regexp.MustCompile(`(?i)/\*\+[^*]*\bDRYRUN\b[^*]*\*/`)
+
+// isDryRun returns whether the query must only be planned.
+func isDryRun(sess *Session, query string) bool {
+	return dryRunHintRegex.MatchString(query) || (sess != nil && sess.GetDryRun())
+}
+
+// dryRun plans the query without executing it, so nothing is read from or
+// written to storage. The result has the columns the query would return,
+// and no rows. SET statements are still run, so the dry run mode of the
+// session can be turned off. It returns false if the query was not handled.
+func (h *Handler) dryRun(ctx *sql.Context, query string, callback mysql.ResultSpoolFn) (bool, error) {
+	node, err := h.e.Analyze(ctx, query)
+	if err != nil {
+		return true, ConvertToMySQLError(err)
+	}
+
+	stmt := node
+	if qp, ok := stmt.(*plan.QueryProcess); ok {
+		stmt = qp.Child
+	}
+	if _, ok := stmt.(*plan.Set); ok {
+		return false, nil
+	}
+
+	return true, callback(&sqltypes.Result{Fields: schemaToFields(node.Schema())}, false)
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_DryRun(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+	ctx := context.Background()
+
+	_, err := db.Exec("CREATE TABLE t (id BIGINT PRIMARY KEY, name TEXT)")
+	require.NoError(err)
+	_, err = db.Exec("INSERT INTO t VALUES (1, 'a')")
+	require.NoError(err)
+
+	count := func() int64 {
+		t.Helper()
+		var n int64
+		require.NoError(db.QueryRow("SELECT COUNT(*) FROM t").Scan(&n))
+		return n
+	}
+
+	// A valid query returns its columns and no rows.
+	rows, err := db.Query("SELECT /*+ DRYRUN */ id, name AS label FROM t")
+	require.NoError(err)
+	columns, err := rows.Columns()
+	require.NoError(err)
+	require.Equal([]string{"id", "label"}, columns)
+	require.False(rows.Next())
+	require.NoError(rows.Close())
+
+	// An invalid one returns the analysis error.
+	_, err = db.Query("SELECT /*+ DRYRUN */ missing FROM t")
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(err, &mysqlErr)
+	require.Equal(uint16(ERBadField), mysqlErr.Number, mysqlErr.Message)
+
+	// Statements that write are only planned.
+	_, err = db.Exec("INSERT /*+ DRYRUN */ INTO t VALUES (2, 'b')")
+	require.NoError(err)
+	require.Equal(int64(1), count())
+
+	conn, err := db.Conn(ctx)
+	require.NoError(err)
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "SET dry_run = 1")
+	require.NoError(err)
+	_, err = conn.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(err)
+	_, err = conn.ExecContext(ctx, "DELETE FROM missing")
+	require.Error(err)
+	require.Equal(int64(1), count())
+
+	_, err = conn.ExecContext(ctx, "SET dry_run = 0")
+	require.NoError(err)
+	_, err = conn.ExecContext(ctx, "DELETE FROM t")
+	require.NoError(err)
+	require.Equal(int64(0), count())
+}
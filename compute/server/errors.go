@@ -1,11 +1,19 @@
 package server
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
 	"strings"
 
 	"github.com/dolthub/vitess/go/mysql"
+	cerrors "github.com/turtacn/guocedb/common/errors"
+	"github.com/turtacn/guocedb/common/types/enum"
+	"github.com/turtacn/guocedb/compute/auth"
 	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/analyzer"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+	"github.com/turtacn/guocedb/compute/transaction"
 	"gopkg.in/src-d/go-errors.v1"
 )
 
@@ -32,14 +40,70 @@ const (
 	ERWrongValueCountOnRow = 1136
 	// ERLockDeadlock - Deadlock found when trying to get lock
 	ERLockDeadlock = 1213
+	// ERLockWaitTimeout - Lock wait timeout exceeded
+	ERLockWaitTimeout = 1205
 	// ERUnknownError - Unknown error
 	ERUnknownError = 1105
 	// ERUnknownComError - Unknown command
 	ERUnknownComError = 1047
+	// ERConCountError - Too many connections
+	ERConCountError = 1040
+	// ERWrongValueForVar - Variable can't be set to the value
+	ERWrongValueForVar = 1231
+	// ERNetPacketTooLarge - Got a packet bigger than max_allowed_packet
+	ERNetPacketTooLarge = 1153
+	// ERTooBigSelect - The SELECT would return too many rows
+	ERTooBigSelect = 1104
+	// ERNoSuchThread - Unknown thread id
+	ERNoSuchThread = 1094
+	// ERServerShutdown - Server shutdown in progress
+	ERServerShutdown = 1053
 	// ERAlreadyExists - Can't create database; database exists
 	ERAlreadyExists = 1007
 	// ERTableExistsError - Table already exists
 	ERTableExistsError = 1050
+	// ERUserLimitReached - User has exceeded a resource limit
+	ERUserLimitReached = 1226
+	// ERUnknownStmtHandler - Unknown prepared statement handler
+	ERUnknownStmtHandler = 1243
+	// ERXAERNota - Unknown XID
+	ERXAERNota = 1397
+	// ERXAERRmfail - The command cannot be executed in the current XA state
+	ERXAERRmfail = 1399
+	// ERXAERDupid - The XID already exists
+	ERXAERDupid = 1440
+	// ERQueryInterrupted - Query execution was interrupted
+	ERQueryInterrupted = 1317
+	// ERCantExecuteInReadOnlyTransaction - Write in a read-only transaction
+	ERCantExecuteInReadOnlyTransaction = 1792
+	// ERQueryTimeout - Maximum statement execution time exceeded
+	ERQueryTimeout = 3024
+	// ERCheckConstraintViolated - Check constraint is violated
+	ERCheckConstraintViolated = 3819
+	// ERCrashedOnUsage - Table is marked as crashed and should be repaired
+	ERCrashedOnUsage = 1194
+	// ERWarnDataOutOfRange - Out of range value for column
+	ERWarnDataOutOfRange = 1264
+	// ERCantChangeTxCharacteristics - Transaction characteristics can't be
+	// changed while a transaction is in progress
+	ERCantChangeTxCharacteristics = 1568
+	// ERDBAccessDenied - Access denied for user to database
+	ERDBAccessDenied = 1044
+	// ERWrongArguments - Incorrect arguments to a function
+	ERWrongArguments = 1210
+	// ERWrongValueForType - Incorrect value for the type
+	ERWrongValueForType = 1411
+	// ERWrongParamcountToNativeFct - Incorrect parameter count in the call to
+	// a native function
+	ERWrongParamcountToNativeFct = 1582
+	// ERPasswordNoMatch - Can't find any matching row in the user table
+	ERPasswordNoMatch = 1133
+	// ERTableAccessDenied - Command denied to user for table
+	ERTableAccessDenied = 1142
+	// ERIllegalGrantForTable - Illegal GRANT/REVOKE command
+	ERIllegalGrantForTable = 1144
+	// ERNotSupportedYet - This version doesn't yet support the feature
+	ERNotSupportedYet = 1235
 )
 
 // SQL State constants
@@ -60,6 +124,24 @@ const (
 	SSDeadlock = "40001"
 	// SSAccessDenied - Access denied
 	SSAccessDenied = "28000"
+	// SSNetError - Communication error
+	SSNetError = "08S01"
+	// SSConCount - Too many connections
+	SSConCount = "08004"
+	// SSReadOnlyTransaction - Write in a read-only transaction
+	SSReadOnlyTransaction = "25006"
+	// SSQueryInterrupted - Query execution was interrupted
+	SSQueryInterrupted = "70100"
+	// SSXAERNota - Unknown XID
+	SSXAERNota = "XAE04"
+	// SSXAERRmfail - Wrong XA state
+	SSXAERRmfail = "XAE07"
+	// SSXAERDupid - XID already exists
+	SSXAERDupid = "XAE08"
+	// SSOutOfRange - Numeric value out of range
+	SSOutOfRange = "22003"
+	// SSActiveTransaction - A transaction is in progress
+	SSActiveTransaction = "25001"
 )
 
 // ConvertToMySQLError converts internal errors to MySQL protocol errors
@@ -68,6 +150,21 @@ func ConvertToMySQLError(err error) error {
 		return nil
 	}
 
+	// The engine wraps the errors of the analyzer and the executor, which
+	// would hide what kind of error they are.
+	for {
+		wrapped, ok := err.(*cerrors.Error)
+		if !ok || wrapped.Err == nil {
+			break
+		}
+		err = wrapped.Err
+	}
+
+	// Errors that carry their code are mapped by it.
+	if mysqlErr := codedToMySQLError(err); mysqlErr != nil {
+		return mysqlErr
+	}
+
 	// Check for specific error types
 	switch {
 	case sql.ErrDatabaseNotFound.Is(err):
@@ -86,14 +183,55 @@ func ConvertToMySQLError(err error) error {
 		msg := extractErrorMessage(err, "Database already exists")
 		return mysql.NewSQLError(ERAlreadyExists, SSClientError, "%s", msg)
 	
+	case analyzer.ErrColumnNotFound.Is(err), analyzer.ErrColumnTableNotFound.Is(err):
+		return mysql.NewSQLError(ERBadField, SSBadField, "%s", err.Error())
+
 	case sql.ErrInvalidType.Is(err):
 		msg := extractErrorMessage(err, "Invalid type")
 		return mysql.NewSQLError(ERBadField, SSBadField, "%s", msg)
 	
-	case sql.ErrUnexpectedRowLength.Is(err):
+	case sql.ErrUnexpectedRowLength.Is(err), plan.ErrInsertIntoMismatchValueCount.Is(err):
 		msg := extractErrorMessage(err, "Column count doesn't match")
 		return mysql.NewSQLError(ERWrongValueCountOnRow, SSClientError, "%s", msg)
 	
+	case sql.ErrDuplicateKey.Is(err):
+		return mysql.NewSQLError(ERDupEntry, SSDupEntry, "%s", err.Error())
+
+	case sql.ErrCheckConstraintViolated.Is(err):
+		return mysql.NewSQLError(ERCheckConstraintViolated, SSUnknownSQLState, "%s", err.Error())
+
+	case plan.ErrOutOfRangeValue.Is(err), sql.ErrValueOutOfRange.Is(err):
+		return mysql.NewSQLError(ERWarnDataOutOfRange, SSOutOfRange, "%s", err.Error())
+
+	case sql.ErrInvalidUUID.Is(err):
+		return mysql.NewSQLError(ERWrongValueForType, SSUnknownSQLState, "%s", err.Error())
+
+	case auth.ErrNotAuthorized.Is(err):
+		return mysql.NewSQLError(ERDBAccessDenied, SSClientError, "%s", err.Error())
+
+	case sql.ErrInvalidArgumentType.Is(err):
+		return mysql.NewSQLError(ERWrongArguments, SSUnknownSQLState, "%s", err.Error())
+
+	case sql.ErrInvalidArgumentNumber.Is(err):
+		return mysql.NewSQLError(ERWrongParamcountToNativeFct, SSClientError, "%s", err.Error())
+
+	case err == transaction.ErrXANotFound:
+		return mysql.NewSQLError(ERXAERNota, SSXAERNota, "XAER_NOTA: %s", err)
+
+	case err == transaction.ErrXAState:
+		return mysql.NewSQLError(ERXAERRmfail, SSXAERRmfail, "XAER_RMFAIL: %s", err)
+
+	case err == transaction.ErrXAExists:
+		return mysql.NewSQLError(ERXAERDupid, SSXAERDupid, "XAER_DUPID: %s", err)
+
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return mysql.NewSQLError(ERQueryTimeout, SSUnknownSQLState, "Query execution was interrupted, maximum statement execution time exceeded")
+
+	case stderrors.Is(err, context.Canceled):
+		return mysql.NewSQLError(ERQueryInterrupted, SSQueryInterrupted, "Query execution was interrupted")
+
+	// The errors without a code or a kind are recognized by their message
+	// as a last resort.
 	case isParseError(err):
 		msg := extractErrorMessage(err, "SQL syntax error")
 		return mysql.NewSQLError(ERParseError, SSClientError, "%s", msg)
@@ -120,6 +258,28 @@ func ConvertToMySQLError(err error) error {
 	return mysql.NewSQLError(ERUnknownError, SSUnknownSQLState, "%s", err.Error())
 }
 
+// codedToMySQLError converts an error with an ErrorCode to the MySQL error
+// of its code. It returns nil if the error has no code.
+func codedToMySQLError(err error) error {
+	switch cerrors.CodeOf(err) {
+	case enum.DuplicateKey:
+		return mysql.NewSQLError(ERDupEntry, SSDupEntry, "%s", err.Error())
+	case enum.Deadlock:
+		return mysql.NewSQLError(ERLockDeadlock, SSDeadlock, "Deadlock found when trying to get lock; try restarting transaction")
+	case enum.LockWaitTimeout:
+		return mysql.NewSQLError(ERLockWaitTimeout, SSUnknownSQLState, "Lock wait timeout exceeded; try restarting transaction")
+	case enum.TransactionConflict:
+		return mysql.NewSQLError(ERLockDeadlock, SSDeadlock, "%s; try restarting transaction", err.Error())
+	case enum.ConstraintViolation:
+		return mysql.NewSQLError(ERCheckConstraintViolated, SSUnknownSQLState, "%s", err.Error())
+	case enum.ReadOnlyTransaction:
+		return mysql.NewSQLError(ERCantExecuteInReadOnlyTransaction, SSReadOnlyTransaction, "Cannot execute statement in a READ ONLY transaction.")
+	case enum.DataCorruption:
+		return mysql.NewSQLError(ERCrashedOnUsage, SSUnknownSQLState, "%s", err.Error())
+	}
+	return nil
+}
+
 // extractErrorMessage extracts the message from an error or returns a default
 func extractErrorMessage(err error, defaultMsg string) string {
 	if err == nil {
@@ -1,13 +1,20 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/dolthub/vitess/go/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/common/constants"
+	cerrors "github.com/turtacn/guocedb/common/errors"
+	"github.com/turtacn/guocedb/common/types/enum"
+	"github.com/turtacn/guocedb/compute/auth"
 	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+	"github.com/turtacn/guocedb/compute/transaction"
 )
 
 func TestConvertToMySQLError_DatabaseNotFound(t *testing.T) {
@@ -56,6 +63,32 @@ func TestConvertToMySQLError_DatabaseExists(t *testing.T) {
 	assert.Equal(t, SSClientError, sqlErr.State)
 }
 
+func TestConvertToMySQLError_CheckConstraintViolated(t *testing.T) {
+	err := sql.ErrCheckConstraintViolated.New("t_chk_1")
+	mysqlErr := ConvertToMySQLError(err)
+
+	require.NotNil(t, mysqlErr)
+	sqlErr, ok := mysqlErr.(*mysql.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, ERCheckConstraintViolated, sqlErr.Num)
+	assert.Equal(t, SSUnknownSQLState, sqlErr.State)
+	assert.Contains(t, sqlErr.Message, "t_chk_1")
+}
+
+func TestConvertToMySQLError_QueryInterrupted(t *testing.T) {
+	// The engine wraps the error of the context of the query.
+	err := cerrors.Wrapf(context.DeadlineExceeded, constants.ErrCodeTimeout, "query execution was interrupted")
+	sqlErr, ok := ConvertToMySQLError(err).(*mysql.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, ERQueryTimeout, sqlErr.Num)
+	assert.Equal(t, SSUnknownSQLState, sqlErr.State)
+
+	sqlErr, ok = ConvertToMySQLError(context.Canceled).(*mysql.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, ERQueryInterrupted, sqlErr.Num)
+	assert.Equal(t, SSQueryInterrupted, sqlErr.State)
+}
+
 func TestConvertToMySQLError_ParseError(t *testing.T) {
 	err := errors.New("syntax error near 'SELEC'")
 	mysqlErr := ConvertToMySQLError(err)
@@ -89,6 +122,63 @@ func TestConvertToMySQLError_Deadlock(t *testing.T) {
 	assert.Equal(t, SSDeadlock, sqlErr.State)
 }
 
+func TestConvertToMySQLError_DeadlockVictim(t *testing.T) {
+	mysqlErr := ConvertToMySQLError(transaction.ErrDeadlock)
+
+	sqlErr, ok := mysqlErr.(*mysql.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, ERLockDeadlock, sqlErr.Num)
+	assert.Equal(t, SSDeadlock, sqlErr.State)
+	assert.Equal(t, "Deadlock found when trying to get lock; try restarting transaction", sqlErr.Message)
+}
+
+func TestConvertToMySQLError_Coded(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		num   int
+		state string
+	}{
+		// The messages don't say what kind of error they are, so only the
+		// codes do.
+		{"duplicate key", cerrors.NewCoded(enum.DuplicateKey, "key 7 is taken"), ERDupEntry, SSDupEntry},
+		{"deadlock", cerrors.NewCoded(enum.Deadlock, "rolled back"), ERLockDeadlock, SSDeadlock},
+		{"conflict", transaction.ErrTransactionConflict, ERLockDeadlock, SSDeadlock},
+		{"read only", transaction.ErrReadOnlyTransaction, ERCantExecuteInReadOnlyTransaction, SSReadOnlyTransaction},
+		{"corrupted", cerrors.NewCoded(enum.DataCorruption, "table t lost rows"), ERCrashedOnUsage, SSUnknownSQLState},
+		{
+			"wrapped by the engine",
+			cerrors.Wrapf(cerrors.NewCoded(enum.DuplicateKey, "key 7 is taken"), constants.ErrCodeRuntime, "failed to execute query"),
+			ERDupEntry, SSDupEntry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlErr, ok := ConvertToMySQLError(tt.err).(*mysql.SQLError)
+			require.True(t, ok)
+			assert.Equal(t, tt.num, sqlErr.Num)
+			assert.Equal(t, tt.state, sqlErr.State)
+		})
+	}
+
+	// A code takes precedence over the message.
+	sqlErr, ok := ConvertToMySQLError(cerrors.NewCoded(enum.DuplicateKey, "deadlock")).(*mysql.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, ERDupEntry, sqlErr.Num)
+	assert.Equal(t, "deadlock", sqlErr.Message)
+}
+
+func TestConvertToMySQLError_DuplicatePrimaryKey(t *testing.T) {
+	mysqlErr := ConvertToMySQLError(sql.ErrDuplicateKey.New("eu-2"))
+
+	sqlErr, ok := mysqlErr.(*mysql.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, ERDupEntry, sqlErr.Num)
+	assert.Equal(t, SSDupEntry, sqlErr.State)
+	assert.Equal(t, "Duplicate entry 'eu-2' for key 'PRIMARY'", sqlErr.Message)
+}
+
 func TestConvertToMySQLError_AccessDenied(t *testing.T) {
 	err := errors.New("access denied for user")
 	mysqlErr := ConvertToMySQLError(err)
@@ -306,3 +396,30 @@ func TestIsAccessDeniedError(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertToMySQLError_OutOfRange(t *testing.T) {
+	sqlErr, ok := ConvertToMySQLError(plan.ErrOutOfRangeValue.New("tiny", 1)).(*mysql.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, ERWarnDataOutOfRange, sqlErr.Num)
+	assert.Equal(t, SSOutOfRange, sqlErr.State)
+	assert.Equal(t, "Out of range value for column 'tiny' at row 1", sqlErr.Message)
+}
+
+func TestConvertToMySQLError_NotAuthorized(t *testing.T) {
+	err := auth.ErrNotAuthorized.Wrap(auth.ErrNoPermission.New(auth.WritePerm))
+	sqlErr, ok := ConvertToMySQLError(err).(*mysql.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, ERDBAccessDenied, sqlErr.Num)
+	assert.Equal(t, SSClientError, sqlErr.State)
+}
+
+func TestConvertToMySQLError_FunctionArguments(t *testing.T) {
+	sqlErr, ok := ConvertToMySQLError(sql.ErrInvalidArgumentType.New("abs", sql.NumberArgument, 1, sql.JSON)).(*mysql.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, ERWrongArguments, sqlErr.Num)
+
+	sqlErr, ok = ConvertToMySQLError(sql.ErrInvalidArgumentNumber.New(1, 2)).(*mysql.SQLError)
+	require.True(t, ok)
+	assert.Equal(t, ERWrongParamcountToNativeFct, sqlErr.Num)
+	assert.Equal(t, SSClientError, sqlErr.State)
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/security"
+	"github.com/turtacn/guocedb/security/authz"
+)
+
+// Grantor keeps the privileges granted to the users on tables and their
+// columns, for GRANT and REVOKE.
+type Grantor interface {
+	// CheckGrant returns an error unless the user may grant the privileges
+	// on the table to others, or revoke them.
+	CheckGrant(ctx context.Context, user, database, table string, privileges authz.Privilege) error
+	// GrantPrivileges grants the privileges on the table to the user, or
+	// on the given columns of it only.
+	GrantPrivileges(ctx context.Context, user, database, table string, columns []string, privileges authz.Privilege) error
+	// RevokePrivileges revokes the privileges on the table from the user,
+	// or on the given columns of it only.
+	RevokePrivileges(ctx context.Context, user, database, table string, columns []string, privileges authz.Privilege) error
+}
+
+// objectGrant is a GRANT or REVOKE statement on a table.
+type objectGrant struct {
+	revoke     bool
+	privileges []sqlparser.Privilege
+	level      sqlparser.PrivilegeLevel
+	users      []sqlparser.AccountName
+	grantOpt   bool
+}
+
+// handleGrant handles the GRANT and REVOKE statements of privileges on a
+// table or some of its columns. Privileges on databases or on every
+// database are not supported.
+func (h *Handler) handleGrant(ctx *sql.Context, user string, stmt sqlparser.Statement, callback mysql.ResultSpoolFn) (bool, error) {
+	var g objectGrant
+	switch stmt := stmt.(type) {
+	case *sqlparser.GrantPrivilege:
+		g = objectGrant{
+			privileges: stmt.Privileges,
+			level:      stmt.PrivilegeLevel,
+			users:      stmt.To,
+			grantOpt:   stmt.WithGrantOption,
+		}
+	case *sqlparser.RevokePrivilege:
+		g = objectGrant{
+			revoke:     true,
+			privileges: stmt.Privileges,
+			level:      stmt.PrivilegeLevel,
+			users:      stmt.From,
+		}
+	default:
+		return false, nil
+	}
+
+	if h.grants == nil {
+		return true, mysql.NewSQLError(ERNotSupportedYet, SSClientError,
+			"This version of MySQL doesn't yet support 'GRANT and REVOKE without security'")
+	}
+
+	database, table := g.level.Database, g.level.TableRoutine
+	if database == "*" || table == "*" {
+		return true, mysql.NewSQLError(ERNotSupportedYet, SSClientError,
+			"This version of MySQL doesn't yet support 'privileges on %s'", g.level.String())
+	}
+	if database == "" {
+		database = ctx.GetCurrentDatabase()
+		if database == "" {
+			return true, mysql.NewSQLError(ERNoDB, SSNoDatabase, "No database selected")
+		}
+	}
+
+	t, err := h.e.Catalog.Table(database, table)
+	if err != nil {
+		return true, h.convertError(err)
+	}
+
+	privileges, columns, err := grantedPrivileges(g.privileges, t.Schema())
+	if err != nil {
+		return true, err
+	}
+	if g.grantOpt {
+		privileges |= authz.PrivilegeGrant
+	}
+
+	command := "GRANT"
+	if g.revoke {
+		command = "REVOKE"
+	}
+	if err := h.grants.CheckGrant(ctx, user, database, table, privileges); err != nil {
+		return true, mysql.NewSQLError(ERTableAccessDenied, SSClientError,
+			"%s command denied to user '%s' for table '%s'", command, user, table)
+	}
+
+	for _, account := range g.users {
+		if g.revoke {
+			err = h.grants.RevokePrivileges(ctx, account.Name, database, table, columns, privileges)
+		} else {
+			err = h.grants.GrantPrivileges(ctx, account.Name, database, table, columns, privileges)
+		}
+		if err == security.ErrUserNotFound {
+			return true, mysql.NewSQLError(ERPasswordNoMatch, SSClientError,
+				"Can't find any matching row in the user table")
+		}
+		if err != nil {
+			return true, h.convertError(err)
+		}
+	}
+
+	return true, callback(&sqltypes.Result{}, false)
+}
+
+// grantedPrivileges returns the privileges of a GRANT or REVOKE statement,
+// and the columns of the table they are limited to, if they are. All the
+// privileges must be on the same columns, which must be in the schema of
+// the table.
+func grantedPrivileges(privs []sqlparser.Privilege, schema sql.Schema) (authz.Privilege, []string, error) {
+	var privileges authz.Privilege
+	var columns []string
+	for i, p := range privs {
+		priv, ok := privilegeTypes[p.Type]
+		if !ok {
+			return 0, nil, mysql.NewSQLError(ERNotSupportedYet, SSClientError,
+				"This version of MySQL doesn't yet support 'the %s privilege'", strings.ToUpper(p.String()))
+		}
+		privileges |= priv
+
+		if len(p.Columns) > 0 && priv&^columnPrivileges != 0 {
+			return 0, nil, mysql.NewSQLError(ERIllegalGrantForTable, SSClientError,
+				"Illegal GRANT/REVOKE command; please consult the manual to see which privileges can be used")
+		}
+		if i > 0 && !sameColumns(columns, p.Columns) {
+			return 0, nil, mysql.NewSQLError(ERNotSupportedYet, SSClientError,
+				"This version of MySQL doesn't yet support 'privileges on different columns'")
+		}
+		columns = p.Columns
+	}
+
+	for _, column := range columns {
+		if !hasColumn(schema, column) {
+			return 0, nil, mysql.NewSQLError(ERBadField, SSBadField,
+				"Unknown column '%s' in 'column list'", column)
+		}
+	}
+	return privileges, columns, nil
+}
+
+// privilegeTypes are the privileges of GRANT and REVOKE statements that
+// may be granted on tables.
+var privilegeTypes = map[sqlparser.PrivilegeType]authz.Privilege{
+	sqlparser.PrivilegeType_All:    authz.PrivilegeReadWrite | authz.PrivilegeDDL,
+	sqlparser.PrivilegeType_Select: authz.PrivilegeSelect,
+	sqlparser.PrivilegeType_Insert: authz.PrivilegeInsert,
+	sqlparser.PrivilegeType_Update: authz.PrivilegeUpdate,
+	sqlparser.PrivilegeType_Delete: authz.PrivilegeDelete,
+	sqlparser.PrivilegeType_Create: authz.PrivilegeCreate,
+	sqlparser.PrivilegeType_Drop:   authz.PrivilegeDrop,
+	sqlparser.PrivilegeType_Alter:  authz.PrivilegeAlter,
+	sqlparser.PrivilegeType_Index:  authz.PrivilegeIndex,
+}
+
+// columnPrivileges are the privileges that may be granted on columns.
+const columnPrivileges = authz.PrivilegeSelect | authz.PrivilegeInsert | authz.PrivilegeUpdate
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasColumn(schema sql.Schema, name string) bool {
+	for _, col := range schema {
+		if strings.EqualFold(col.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/security"
+	"github.com/turtacn/guocedb/security/audit"
+)
+
+func TestE2E_ObjectGrants(t *testing.T) {
+	require := require.New(t)
+
+	sm, err := security.NewSecurityManager(security.SecurityConfig{
+		Enabled:     true,
+		AuditConfig: audit.AuditConfig{FilePath: filepath.Join(t.TempDir(), "audit.log")},
+	})
+	require.NoError(err)
+	defer sm.Close()
+	require.NoError(sm.CreateUser(context.Background(), "app", "secret", nil))
+
+	db := mem.NewDatabase("testdb")
+	db.AddTable("orders", mem.NewTable("orders", sqlengine.Schema{
+		{Name: "id", Type: sqlengine.Int64, Source: "orders"},
+		{Name: "total", Type: sqlengine.Int64, Source: "orders"},
+	}))
+	db.AddTable("users", mem.NewTable("users", sqlengine.Schema{
+		{Name: "id", Type: sqlengine.Int64, Source: "users"},
+		{Name: "name", Type: sqlengine.Text, Source: "users"},
+		{Name: "password", Type: sqlengine.Text, Source: "users"},
+	}))
+	catalog := sqlengine.NewCatalog()
+	catalog.AddDatabase(db)
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "127.0.0.1:0",
+		Auth:     auth.NewSecurity(sm),
+		Grants:   sm,
+	}, engine)
+	require.NoError(err)
+	s.Start()
+	defer s.Close()
+
+	open := func(user, password string) *sql.DB {
+		conn, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s)/testdb", user, password, s.Addr()))
+		require.NoError(err)
+		conn.SetMaxOpenConns(1)
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+	root := open("root", "")
+	app := open("app", "secret")
+
+	_, err = root.Exec("INSERT INTO orders (id, total) VALUES (1, 100)")
+	require.NoError(err)
+	_, err = root.Exec("INSERT INTO users (id, name, password) VALUES (1, 'ann', 'hunter2')")
+	require.NoError(err)
+
+	requireError := func(err error, code uint16) {
+		t.Helper()
+		var mysqlErr *mysqldriver.MySQLError
+		require.ErrorAs(err, &mysqlErr)
+		require.Equal(code, mysqlErr.Number, mysqlErr.Message)
+	}
+	count := func(query string) (int64, error) {
+		var n int64
+		err := app.QueryRow(query).Scan(&n)
+		return n, err
+	}
+
+	_, err = count("SELECT COUNT(*) FROM orders")
+	requireError(err, ERDBAccessDenied)
+
+	// Only root may grant, and only to existing users on existing tables.
+	_, err = app.Exec("GRANT SELECT ON testdb.orders TO app")
+	requireError(err, ERTableAccessDenied)
+	_, err = root.Exec("GRANT SELECT ON testdb.orders TO nobody")
+	requireError(err, ERPasswordNoMatch)
+	_, err = root.Exec("GRANT SELECT ON testdb.missing TO app")
+	requireError(err, ERNoSuchTable)
+	_, err = root.Exec("GRANT SELECT ON testdb.* TO app")
+	requireError(err, ERNotSupportedYet)
+
+	_, err = root.Exec("GRANT SELECT ON testdb.orders TO app")
+	require.NoError(err)
+
+	var total int64
+	require.NoError(app.QueryRow("SELECT total FROM orders WHERE id = 1").Scan(&total))
+	require.Equal(int64(100), total)
+	_, err = app.Exec("INSERT INTO orders (id, total) VALUES (2, 200)")
+	requireError(err, ERDBAccessDenied)
+	_, err = count("SELECT COUNT(*) FROM users")
+	requireError(err, ERDBAccessDenied)
+
+	// A column grant only makes those columns readable.
+	_, err = root.Exec("GRANT SELECT (id, name) ON users TO app")
+	require.NoError(err)
+	_, err = root.Exec("GRANT DELETE (name) ON users TO app")
+	requireError(err, ERIllegalGrantForTable)
+	_, err = root.Exec("GRANT SELECT (email) ON users TO app")
+	requireError(err, ERBadField)
+
+	var name string
+	require.NoError(app.QueryRow("SELECT name FROM users WHERE id = 1").Scan(&name))
+	require.Equal("ann", name)
+	require.NoError(app.QueryRow("SELECT u.name FROM users u JOIN orders o ON u.id = o.id").Scan(&name))
+	n, err := count("SELECT COUNT(*) FROM users")
+	require.NoError(err)
+	require.Equal(int64(1), n)
+
+	_, err = count("SELECT password FROM users")
+	requireError(err, ERDBAccessDenied)
+	_, err = app.Query("SELECT * FROM users")
+	requireError(err, ERDBAccessDenied)
+	_, err = count("SELECT id FROM users WHERE password = 'hunter2'")
+	requireError(err, ERDBAccessDenied)
+
+	// Revoking the grants takes the access away again.
+	_, err = root.Exec("REVOKE SELECT (name) ON users FROM app")
+	require.NoError(err)
+	_, err = count("SELECT name FROM users")
+	requireError(err, ERDBAccessDenied)
+	require.NoError(app.QueryRow("SELECT id FROM users").Scan(&n))
+
+	_, err = root.Exec("REVOKE SELECT ON testdb.orders FROM app")
+	require.NoError(err)
+	_, err = count("SELECT total FROM orders")
+	requireError(err, ERDBAccessDenied)
+}
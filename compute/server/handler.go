@@ -2,18 +2,21 @@ package server
 
 import (
 	"context"
-	"io"
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	errors "gopkg.in/src-d/go-errors.v1"
+	cerrors "github.com/turtacn/guocedb/common/errors"
 	"github.com/turtacn/guocedb/compute/executor"
 	"github.com/turtacn/guocedb/compute/auth"
 	"github.com/turtacn/guocedb/compute/sql"
 	"github.com/turtacn/guocedb/compute/transaction"
+	"github.com/turtacn/guocedb/maintenance/metrics"
+	"github.com/turtacn/guocedb/maintenance/slowlog"
 
 	"github.com/sirupsen/logrus"
 	"github.com/dolthub/vitess/go/mysql"
@@ -24,11 +27,6 @@ import (
 
 var regKillCmd = regexp.MustCompile(`^kill (?:(query|connection) )?(\d+)$`)
 
-var errConnectionNotFound = errors.NewKind("Connection not found: %c")
-
-// TODO parametrize
-const rowsBatch = 100
-
 // Handler is a connection handler for a SQLe engine.
 type Handler struct {
 	mu              sync.Mutex
@@ -37,38 +35,139 @@ type Handler struct {
 	sessionMgr      *EnhancedSessionManager // New session manager for enhanced functionality
 	txnManager      *transaction.Manager    // Transaction manager
 	c               map[uint32]*mysql.Conn
+	prepared        map[uint32]map[uint32]*preparedStatement // Prepared statements by connection
+	multiStmts      map[uint32]*multiStatement               // Multi-statement queries being executed by connection
 	disableMultiStmts bool
+	batchSize       int // Rows sent to the client at a time
+	queryTimeout    time.Duration // Longest a query may run, if not zero
+	maxResultRows   int64         // Most rows of a result set, if not zero
+	truncateResults bool          // Whether result sets over maxResultRows are cut short rather than failing
+	activeConns     atomic.Int64  // Connections established and not closed yet
+	maxConns        atomic.Int64  // Most connections open at once, if not zero
+	maxPacket       atomic.Int64  // Largest packet the clients may send
+	queries         atomic.Uint64 // Queries received
+	activeQueries   atomic.Int64  // Queries being executed
+	shuttingDown    atomic.Bool   // Set once new queries are refused
+	quotas          QuotaChecker      // Limits of the users, if any
+	quotaConns      map[uint32]string // Users of the connections acquired from quotas
+	grants          Grantor           // Privileges granted on tables, if GRANT is supported
+	stopReaper      context.CancelFunc // Stops closing idle sessions, if they are
+	slowLog         *slowlog.Logger    // Log of the slow statements, if any
+	cache           *QueryCache        // Results of the SELECT queries, if they're cached
+	statements      *metrics.StatementCollector // Latencies and errors of the statements, if they're recorded
+	stmtCacheSize   int                         // Plans of statements kept by each session, none if not positive
+}
+
+// Stats are the figures of the connections served by a Handler.
+type Stats struct {
+	// ActiveConnections is the number of connections currently open.
+	ActiveConnections int64
+	// Queries is the number of queries received since the handler was
+	// created, counting each statement of a multi-statement query.
+	Queries uint64
+	// ActiveQueries is the number of queries being executed, including
+	// the ones whose results are still being sent.
+	ActiveQueries int64
+}
+
+// Stats returns the current figures of the handler.
+func (h *Handler) Stats() Stats {
+	return Stats{
+		ActiveConnections: h.activeConns.Load(),
+		Queries:           h.queries.Load(),
+		ActiveQueries:     h.activeQueries.Load(),
+	}
+}
+
+// Drain makes the handler refuse the queries it receives from now on and
+// waits for the ones being executed to finish, or for the context to be
+// done. The connections are left open, so the clients running queries get
+// their results.
+func (h *Handler) Drain(ctx context.Context) error {
+	h.shuttingDown.Store(true)
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		active := h.activeQueries.Load()
+		if active == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d queries still running: %w", active, ctx.Err())
+		case <-ticker.C:
+		}
+	}
 }
 
 // NewHandler creates a new Handler given a SQLe engine.
 func NewHandler(e *executor.Engine, sm *SessionManager) *Handler {
-	return &Handler{
+	h := &Handler{
 		e:          e,
 		sm:         sm,
 		sessionMgr: NewEnhancedSessionManager(),
 		txnManager: transaction.NewManager(nil), // Will be updated when storage is available
 		c:          make(map[uint32]*mysql.Conn),
+		prepared:   make(map[uint32]map[uint32]*preparedStatement),
+		multiStmts: make(map[uint32]*multiStatement),
+		quotaConns: make(map[uint32]string),
+		batchSize:  DefaultResultBatchSize,
+
+		stmtCacheSize: DefaultStatementCacheSize,
 	}
+	e.Catalog.SetConnectionLister(h.connections)
+	h.defineVariables()
+	return h
 }
 
 // NewHandlerWithTxnManager creates a new Handler with a specific transaction manager.
 func NewHandlerWithTxnManager(e *executor.Engine, sm *SessionManager, txnMgr *transaction.Manager) *Handler {
-	return &Handler{
+	h := &Handler{
 		e:          e,
 		sm:         sm,
 		sessionMgr: NewEnhancedSessionManager(),
 		txnManager: txnMgr,
 		c:          make(map[uint32]*mysql.Conn),
+		prepared:   make(map[uint32]map[uint32]*preparedStatement),
+		multiStmts: make(map[uint32]*multiStatement),
+		quotaConns: make(map[uint32]string),
+		batchSize:  DefaultResultBatchSize,
+
+		stmtCacheSize: DefaultStatementCacheSize,
 	}
+	e.Catalog.SetConnectionLister(h.connections)
+	h.defineVariables()
+	return h
+}
+
+// connections returns the connections of the sessions, for SHOW
+// PROCESSLIST.
+func (h *Handler) connections() []sql.Connection {
+	sessions := h.sessionMgr.Sessions()
+	conns := make([]sql.Connection, len(sessions))
+	for i, sess := range sessions {
+		since, _ := sess.IdleSince()
+		conns[i] = sql.Connection{
+			ID:         sess.ID(),
+			User:       sess.User(),
+			Host:       sess.Client(),
+			Database:   sess.GetCurrentDB(),
+			Since:      since,
+			Attributes: sess.Attributes(),
+		}
+	}
+	return conns
 }
 
 // NewConnection reports that a new connection has been established.
 func (h *Handler) NewConnection(c *mysql.Conn) {
-	h.mu.Lock()
-	if _, ok := h.c[c.ConnectionID]; !ok {
-		h.c[c.ConnectionID] = c
+	if n := h.activeConns.Add(1); h.tooManyConnections(n) {
+		h.rejectConnection(c)
+		return
 	}
-	h.mu.Unlock()
 
 	// Create a new session for this connection
 	user := c.User
@@ -87,17 +186,52 @@ func (h *Handler) NewConnection(c *mysql.Conn) {
 	}()
 	sess := h.sessionMgr.NewSession(user, client)
 	c.ConnectionID = sess.ID()
+	if h.stmtCacheSize > 0 {
+		sess.SetStatementCache(NewStatementCache(h.stmtCacheSize))
+	}
+	sess.SetVar(MaxAllowedPacketVariable, h.maxPacket.Load())
+
+	// The connection attributes are read along with the answer of the
+	// client to the handshake, which comes next.
+	if pc, ok := c.Conn.(*packetLimitConn); ok {
+		pc.onHandshake = func(attrs map[string]string) {
+			sess.SetAttributes(attrs)
+			logrus.Infof("Connection attributes: client %v, %v", sess.ID(), attrs)
+		}
+	}
+
+	// The connection is kept by the ID of its session, which is the one
+	// KILL and the idle session reaper use.
+	h.mu.Lock()
+	if _, ok := h.c[c.ConnectionID]; !ok {
+		h.c[c.ConnectionID] = c
+	}
+	h.mu.Unlock()
 
 	logrus.Infof("NewConnection: client %v, user %s", c.ConnectionID, user)
 }
 
 // ConnectionClosed reports that a connection has been closed.
 func (h *Handler) ConnectionClosed(c *mysql.Conn) {
+	defer h.activeConns.Add(-1)
+
+	// Rejected connections never had a session.
+	if c.ConnectionID == rejectedConnectionID {
+		return
+	}
+
+	if h.quotas != nil {
+		h.releaseConnection(c)
+	}
+
 	h.sm.CloseConn(c)
 	h.sessionMgr.RemoveSession(c.ConnectionID)
+	h.releaseWrittenTables(c.ConnectionID)
 
 	h.mu.Lock()
 	delete(h.c, c.ConnectionID)
+	delete(h.prepared, c.ConnectionID)
+	delete(h.multiStmts, c.ConnectionID)
 	h.mu.Unlock()
 
 	if err := h.e.Catalog.UnlockTables(nil, c.ConnectionID); err != nil {
@@ -107,45 +241,261 @@ func (h *Handler) ConnectionClosed(c *mysql.Conn) {
 	logrus.Infof("ConnectionClosed: client %v", c.ConnectionID)
 }
 
+// StartReaper closes the sessions that have been idle for longer than
+// timeout, rolling back their transactions and closing their connections,
+// which may have been left half-open by a client that crashed. It replaces
+// the reaper started before, if any.
+func (h *Handler) StartReaper(timeout time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h.mu.Lock()
+	if h.stopReaper != nil {
+		h.stopReaper()
+	}
+	h.stopReaper = cancel
+	h.mu.Unlock()
+
+	go h.sessionMgr.ReapIdle(ctx, timeout, h.reapSession)
+}
+
+// StopReaper stops closing idle sessions.
+func (h *Handler) StopReaper() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopReaper != nil {
+		h.stopReaper()
+		h.stopReaper = nil
+	}
+}
+
+// reapSession rolls back the transaction of an idle session that was
+// removed, and closes its connection.
+func (h *Handler) reapSession(sess *Session) {
+	if err := h.rollbackSession(sess); err != nil {
+		logrus.Errorf("unable to roll back the transaction of idle session %d: %s", sess.ID(), err)
+	}
+
+	h.mu.Lock()
+	c := h.c[sess.ID()]
+	h.mu.Unlock()
+	if c != nil {
+		c.Close()
+	}
+
+	logrus.Infof("Closed idle session %d", sess.ID())
+}
+
+// rollbackSession rolls back the transaction of the session, if it has
+// one.
+func (h *Handler) rollbackSession(sess *Session) error {
+	txn := sess.GetTransaction()
+	if txn == nil {
+		return nil
+	}
+
+	var err error
+	if t, ok := txn.(*transaction.Transaction); ok {
+		err = h.txnManager.Rollback(t)
+	} else {
+		err = txn.Rollback()
+	}
+	sess.SetTransaction(nil)
+	return err
+}
+
+// beginCommand marks the session of the connection as running a command
+// until the returned function is called, so it's not closed as idle.
+func (h *Handler) beginCommand(c *mysql.Conn) func() {
+	sess := h.sessionMgr.GetSession(c.ConnectionID)
+	if sess == nil {
+		return func() {}
+	}
+	sess.BeginCommand()
+	return sess.EndCommand
+}
+
 // ComQuery executes a SQL query on the SQLe engine.
 func (h *Handler) ComQuery(
 	ctx context.Context,
 	c *mysql.Conn,
 	query string,
 	callback mysql.ResultSpoolFn,
+) error {
+	if err := h.checkQuerySize(query); err != nil {
+		return err
+	}
+	return h.query(ctx, c, query, nil, callback)
+}
+
+// query executes a SQL query, or a prepared statement if bound isn't nil.
+// The query of a prepared statement is the text of the statement with its
+// parameters bound, which is only parsed if the statement is run dry.
+func (h *Handler) query(
+	ctx context.Context,
+	c *mysql.Conn,
+	query string,
+	bound *boundStatement,
+	callback mysql.ResultSpoolFn,
 ) (err error) {
+	h.queries.Add(1)
+	defer h.beginCommand(c)()
+
+	// The query is counted before the handler is checked, so Drain either
+	// waits for it or it's refused.
+	h.activeQueries.Add(1)
+	defer h.activeQueries.Add(-1)
+	if h.shuttingDown.Load() {
+		return mysql.NewSQLError(ERServerShutdown, SSNetError, "server shutting down")
+	}
+
+	if h.quotas != nil {
+		if err := h.quotas.AllowQuery(c.User); err != nil {
+			return userLimitError(err)
+		}
+	}
+
 	// Get the session and create context with current database
 	sess := h.sessionMgr.GetSession(c.ConnectionID)
 	var sqlCtx *sql.Context
 	if sess != nil {
-		sqlCtx = sess.Context(ctx)
-		sqlCtx = sql.NewContext(ctx, sql.WithSession(sqlCtx.Session), sql.WithQuery(query))
+		sess.SetUser(c.User)
+		sqlCtx = sess.Context(ctx, sql.WithPid(h.sm.nextPid()), sql.WithQuery(query))
 	} else {
 		// Fall back to old session manager
 		sqlCtx = h.sm.NewContextWithQuery(c, query)
 	}
 
-	handled, err := h.handleKill(c, query)
-	if err != nil {
+	// Prepared statements are never KILL, XA or transaction statements.
+	if bound == nil {
+		handled, err := h.handleKill(c, query, callback)
+		if err != nil {
+			return err
+		}
+
+		if handled {
+			return nil
+		}
+
+		handled, err = h.handleXA(sess, query, callback)
+		if err != nil {
+			return err
+		}
+
+		if handled {
+			return nil
+		}
+
+		// Handle transaction statements
+		handled, err = h.handleTransactionStatements(sess, query, callback)
+		if err != nil {
+			return err
+		}
+
+		if handled {
+			return nil
+		}
+	}
+
+	var stmt sqlparser.Statement
+	if bound != nil {
+		stmt = bound.stmt
+	} else {
+		stmt, _ = sqlparser.Parse(query)
+	}
+
+	// The MAX_EXECUTION_TIME hint of a SELECT overrides the longest time
+	// queries may run.
+	timeout := h.queryTimeout
+	if d, ok := maxExecutionTime(stmt); ok {
+		timeout = d
+	}
+	if timeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(sqlCtx, timeout)
+		defer cancel()
+		sqlCtx = sqlCtx.WithContext(timeoutCtx)
+	}
+
+	if isDryRun(sess, query) {
+		handled, err := h.dryRun(sqlCtx, query, callback)
+		if handled {
+			return err
+		}
+	}
+
+	if handled, err := h.handleGrant(sqlCtx, c.User, stmt, callback); handled {
 		return err
 	}
 
-	if handled {
-		return nil
+	var cached *cachedQuery
+	if h.cache != nil {
+		if cached = h.cacheableQuery(c.User, sess, stmt, bound != nil, query); cached != nil {
+			if r, ok := h.cache.Get(cached.key); ok {
+				return h.sendCachedResult(r, callback)
+			}
+			callback = cached.record(callback)
+		}
+		defer h.holdWrittenTables(c.ConnectionID, sess, stmt)()
+	}
+
+	dml := isDML(stmt)
+	autoCommit := sess == nil || sess.GetAutoCommit()
+	if !autoCommit && (dml || isLockingRead(stmt)) {
+		if err := h.beginImplicitTransaction(sess, sqlCtx); err != nil {
+			return err
+		}
+	}
+	if sess != nil {
+		if t, ok := sess.GetTransaction().(*transaction.Transaction); ok {
+			if err := t.BeginStatement(); err != nil {
+				return h.convertError(err)
+			}
+		}
 	}
 
-	// Handle transaction statements
-	handled, err = h.handleTransactionStatements(sess, query, callback)
+	sqlCtx, err = h.e.Catalog.AddProcess(sqlCtx, sql.QueryProcess, query)
 	if err != nil {
 		return err
 	}
 
-	if handled {
-		return nil
-	}
+	// The process is marked as done when the rows are closed, unless the
+	// query fails before that.
+	pid := sqlCtx.Pid()
+	defer func() {
+		if err != nil {
+			h.e.Catalog.Done(pid)
+		}
+	}()
 
+	var rowsSent uint64
 	start := time.Now()
-	schema, rows, err := h.e.Query(sqlCtx, query)
+	if h.statements != nil {
+		kind := statementKind(stmt)
+		defer func() {
+			h.statements.Observe(kind, time.Since(start), errorCode(err))
+		}()
+	}
+	if h.slowLog != nil {
+		defer func() {
+			h.logSlowQuery(sqlCtx, sess, query, time.Since(start), rowsSent)
+		}()
+	}
+
+	// Queries that only differ in their literals reuse the plan parsed for
+	// the first of them.
+	if bound == nil {
+		bound = h.cachedStatement(sqlCtx, sess, query)
+	}
+	if stmt != nil {
+		defer h.invalidateStatements(stmt)
+	}
+
+	var schema sql.Schema
+	var rows sql.RowIter
+	if bound != nil {
+		schema, rows, err = h.e.QueryPrepared(sqlCtx, bound.parsed, bound.bindings)
+	} else {
+		schema, rows, err = h.e.Query(sqlCtx, query)
+	}
 	defer func() {
 		if q, ok := h.e.Auth.(*auth.Audit); ok {
 			q.Query(sqlCtx, time.Since(start), err)
@@ -156,54 +506,153 @@ func (h *Handler) ComQuery(
 		return ConvertToMySQLError(err)
 	}
 
-	var r *sqltypes.Result
-	var proccesedAtLeastOneBatch bool
-	for {
-		if r == nil {
-			r = &sqltypes.Result{Fields: schemaToFields(schema)}
+	// Turning autocommit back on commits the open transaction, as MySQL
+	// does.
+	if !autoCommit && sess.GetAutoCommit() {
+		if err := h.commitTransaction(sess); err != nil {
+			return err
 		}
+	}
 
-		if r.RowsAffected == rowsBatch {
-			if err := callback(r, true); err != nil {
-				return ConvertToMySQLError(err)
-			}
-
-			r = nil
-			proccesedAtLeastOneBatch = true
+	if dml {
+		return h.sendDMLResult(sqlCtx, schema, rows, callback)
+	}
 
-			continue
+	if h.quotas != nil {
+		if limit := h.quotas.MaxRowsPerQuery(c.User); limit > 0 {
+			rows = &rowLimitIter{RowIter: rows, user: c.User, limit: limit}
 		}
+	}
 
-		row, err := rows.Next()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
+	if h.maxResultRows > 0 {
+		rows = &resultLimitIter{RowIter: rows, ctx: sqlCtx, limit: h.maxResultRows, truncate: h.truncateResults}
+	}
 
-			return ConvertToMySQLError(err)
-		}
+	rowsSent, err = StreamResult(schema, rows, h.batchSize, callback)
+	if err != nil {
+		return ConvertToMySQLError(err)
+	}
+	if cached != nil {
+		cached.store(h.cache)
+	}
+	return nil
+}
 
-		r.Rows = append(r.Rows, rowToSQL(schema, row))
-		r.RowsAffected++
+// logSlowQuery writes a statement to the slow query log if it took longer
+// than its threshold.
+func (h *Handler) logSlowQuery(ctx *sql.Context, sess *Session, query string, d time.Duration, rowsSent uint64) {
+	e := slowlog.Entry{
+		Time:         time.Now(),
+		Query:        query,
+		Duration:     d,
+		RowsSent:     rowsSent,
+		RowsExamined: ctx.RowsExamined(),
+	}
+	if sess != nil {
+		e.User, e.Host, e.Database = sess.User(), sess.Client(), sess.GetCurrentDB()
+	}
+
+	if err := h.slowLog.Log(e); err != nil {
+		logrus.Errorf("unable to write to the slow query log: %s", err)
+	}
+}
+
+// sendDMLResult sends the result of an INSERT, UPDATE or DELETE as an OK
+// packet with the number of rows changed.
+func (h *Handler) sendDMLResult(ctx *sql.Context, schema sql.Schema, rows sql.RowIter, callback mysql.ResultSpoolFn) error {
+	r, err := BuildResult(schema, rows)
+	if err != nil {
+		return ConvertToMySQLError(err)
 	}
 
 	if err := rows.Close(); err != nil {
 		return ConvertToMySQLError(err)
 	}
 
-	// Even if r.RowsAffected = 0, the callback must be
-	// called to update the state in the go-vitess' listener
-	// and avoid returning errors when the query doesn't
-	// produce any results.
-	if r != nil && (r.RowsAffected == 0 && proccesedAtLeastOneBatch) {
-		return nil
+	if ok, err := toOKResult(r, ctx.InsertID()); err != nil {
+		return ConvertToMySQLError(err)
+	} else if ok != nil {
+		r = ok
 	}
 
 	return callback(r, false)
 }
 
+// isDML returns whether the statement is an INSERT, UPDATE or DELETE, whose
+// results are sent as OK packets. It's false for a nil statement, which is
+// what a query that can't be parsed gives.
+func isDML(stmt sqlparser.Statement) bool {
+	switch stmt.(type) {
+	case *sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isLockingRead returns whether the statement is a SELECT ... FOR UPDATE or
+// LOCK IN SHARE MODE, whose locks are kept by the transaction it runs in.
+func isLockingRead(stmt sqlparser.Statement) bool {
+	s, ok := stmt.(*sqlparser.Select)
+	return ok && s.Lock != ""
+}
+
+// statementKind returns the kind of statement the latency of stmt is
+// recorded under.
+func statementKind(stmt sqlparser.Statement) string {
+	switch stmt.(type) {
+	case *sqlparser.Select, *sqlparser.SetOp, *sqlparser.ParenSelect:
+		return metrics.StatementSelect
+	case *sqlparser.Insert:
+		return metrics.StatementInsert
+	case *sqlparser.Update:
+		return metrics.StatementUpdate
+	case *sqlparser.Delete:
+		return metrics.StatementDelete
+	case *sqlparser.DDL, *sqlparser.DBDDL, *sqlparser.AlterTable:
+		return metrics.StatementDDL
+	default:
+		return metrics.StatementOther
+	}
+}
+
+// errorCode returns the MySQL error code of err, or zero if it's nil.
+func errorCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if sqlErr, ok := err.(*mysql.SQLError); ok {
+		return sqlErr.Num
+	}
+	return ERUnknownError
+}
+
+// toOKResult converts the result of an INSERT, UPDATE or DELETE, which
+// is the number of rows changed, to a result without fields, which is sent
+// as an OK packet along with the first AUTO_INCREMENT value generated. It
+// returns nil if the result has rows of its own, as the ones returned with
+// RETURNING.
+func toOKResult(r *sqltypes.Result, insertID uint64) (*sqltypes.Result, error) {
+	if len(r.Fields) != 1 || r.Fields[0].Name != "updated" || len(r.Rows) > 1 {
+		return nil, nil
+	}
+
+	var affected uint64
+	if len(r.Rows) == 1 {
+		var err error
+		affected, err = strconv.ParseUint(r.Rows[0][0].ToString(), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number of rows changed: %s", r.Rows[0][0].ToString())
+		}
+	}
+
+	return BuildOKResult(affected, insertID), nil
+}
+
 // ComInitDB changes the database for the current connection.
 func (h *Handler) ComInitDB(c *mysql.Conn, schemaName string) error {
+	defer h.beginCommand(c)()
+
 	// Get the session for this connection
 	sess := h.sessionMgr.GetSession(c.ConnectionID)
 	if sess == nil {
@@ -239,6 +688,12 @@ func (h *Handler) ComInitDB(c *mysql.Conn, schemaName string) error {
 }
 
 // ComMultiQuery executes multiple SQL queries on the SQLe engine.
+//
+// The listener sends the results of each statement before it executes the
+// next one, so it calls ComMultiQuery again with the remainder that is
+// returned. The query is only split the first time; the remainder is a
+// suffix of it that is recognized when it's given back, and the statements
+// that were already split are used instead of splitting it again.
 func (h *Handler) ComMultiQuery(
 	ctx context.Context,
 	c *mysql.Conn,
@@ -251,36 +706,35 @@ func (h *Handler) ComMultiQuery(
 		return "", err
 	}
 
-	// Split the query into individual statements
-	statements := h.splitStatements(query)
-	if len(statements) == 0 {
-		return "", nil
+	h.mu.Lock()
+	m, ok := h.multiStmts[c.ConnectionID]
+	if !ok || m.remainder() != query {
+		m = newMultiStatement(query)
 	}
+	delete(h.multiStmts, c.ConnectionID)
+	h.mu.Unlock()
 
-	// Execute the first statement
-	firstStmt := statements[0]
-	err := h.ComQuery(ctx, c, firstStmt, callback)
-	if err != nil {
-		return "", err
+	stmt, ok := m.next()
+	if !ok {
+		return "", nil
 	}
 
-	// Return the remainder of the query (all statements after the first)
-	if len(statements) > 1 {
-		remainder := strings.Join(statements[1:], ";")
-		return remainder, nil
+	more := m.remainder() != ""
+	err := h.ComQuery(ctx, c, stmt, func(r *sqltypes.Result, hasMore bool) error {
+		// The last result of the statement tells the client there are
+		// results of other statements after it.
+		return callback(r, hasMore || more)
+	})
+	if err != nil || !more {
+		// The statements after a failed one are not executed.
+		return "", err
 	}
 
-	return "", nil
-}
+	h.mu.Lock()
+	h.multiStmts[c.ConnectionID] = m
+	h.mu.Unlock()
 
-func (h *Handler) ComPrepare(ctx context.Context, c *mysql.Conn, query string, prepare *mysql.PrepareData) ([]*query.Field, error) {
-	// Not implemented
-	return nil, nil
-}
-
-func (h *Handler) ComStmtExecute(ctx context.Context, c *mysql.Conn, prepare *mysql.PrepareData, callback func(*sqltypes.Result) error) error {
-	// Not implemented
-	return nil
+	return m.remainder(), nil
 }
 
 func (h *Handler) ConnectionAborted(c *mysql.Conn, reason string) error {
@@ -288,8 +742,37 @@ func (h *Handler) ConnectionAborted(c *mysql.Conn, reason string) error {
 	return nil
 }
 
+// ComResetConnection resets the session of the connection to the state of
+// a new connection of the same user, without authenticating it again. The
+// transaction is rolled back, the tables locked are unlocked, and the
+// current database, session variables, prepared statements and warnings
+// are cleared.
 func (h *Handler) ComResetConnection(c *mysql.Conn) error {
-	// Not implemented
+	defer h.beginCommand(c)()
+
+	sess := h.sessionMgr.GetSession(c.ConnectionID)
+	if sess == nil {
+		return mysql.NewSQLError(mysql.ERUnknownComError, mysql.SSUnknownSQLState, "session not found")
+	}
+
+	if err := h.rollbackSession(sess); err != nil {
+		logrus.Errorf("unable to roll back the transaction of session %d on reset: %s", sess.ID(), err)
+	}
+	if err := h.e.Catalog.UnlockTables(nil, c.ConnectionID); err != nil {
+		logrus.Errorf("unable to unlock tables on session reset: %s", err)
+	}
+
+	h.mu.Lock()
+	delete(h.prepared, c.ConnectionID)
+	delete(h.multiStmts, c.ConnectionID)
+	h.mu.Unlock()
+	c.PrepareData = make(map[uint32]*mysql.PrepareData)
+
+	sess.Reset()
+	sess.SetVar(MaxAllowedPacketVariable, h.maxPacket.Load())
+	h.sm.CloseConn(c)
+
+	logrus.Infof("ComResetConnection: client %v", c.ConnectionID)
 	return nil
 }
 
@@ -303,7 +786,15 @@ func (h *Handler) ParserOptionsForConnection(c *mysql.Conn) (sqlparser.ParserOpt
 // ComQuery callback if the result does not contain any fields,
 // or after the last ComQuery call completes.
 func (h *Handler) WarningCount(c *mysql.Conn) uint16 {
+	// Queries warn in the session of the connection, unless it has none
+	// and they fall back to the old session manager.
+	if sess := h.sessionMgr.GetSession(c.ConnectionID); sess != nil {
+		return sess.base.WarningCount()
+	}
+
+	h.sm.mu.Lock()
 	sess, ok := h.sm.sessions[c.ConnectionID]
+	h.sm.mu.Unlock()
 	if !ok {
 		return 0
 	}
@@ -311,7 +802,7 @@ func (h *Handler) WarningCount(c *mysql.Conn) uint16 {
 	return sess.WarningCount()
 }
 
-func (h *Handler) handleKill(conn *mysql.Conn, query string) (bool, error) {
+func (h *Handler) handleKill(conn *mysql.Conn, query string, callback mysql.ResultSpoolFn) (bool, error) {
 	q := strings.ToLower(query)
 	s := regKillCmd.FindStringSubmatch(q)
 	if s == nil {
@@ -324,35 +815,42 @@ func (h *Handler) handleKill(conn *mysql.Conn, query string) (bool, error) {
 	}
 
 	// KILL CONNECTION and KILL should close the connection. KILL QUERY only
-	// cancels the query.
+	// cancels the query the connection with the given id is running.
 	//
 	// https://dev.mysql.com/doc/refman/5.7/en/kill.html
 
+	h.mu.Lock()
+	c, ok := h.c[uint32(id)]
+	h.mu.Unlock()
+	if !ok {
+		return false, mysql.NewSQLError(ERNoSuchThread, SSUnknownSQLState, "Unknown thread id: %d", id)
+	}
+
 	if s[1] == "query" {
 		logrus.Infof("kill query: id %v", id)
-		h.e.Catalog.Kill(id)
-	} else {
-		logrus.Infof("kill connection: id %v, pid: %v", conn.ConnectionID, id)
-		h.mu.Lock()
-		c, ok := h.c[conn.ConnectionID]
-		delete(h.c, conn.ConnectionID)
-		h.mu.Unlock()
-
-		if !ok {
-			return false, errConnectionNotFound.New(conn.ConnectionID)
-		}
-
-		h.e.Catalog.KillConnection(id)
-		h.sm.CloseConn(c)
-		c.Close()
+		h.e.Catalog.KillQuery(uint32(id))
+		return true, callback(&sqltypes.Result{}, false)
 	}
 
-	return true, nil
+	// The running query is cancelled, and the rest of the connection is
+	// cleaned up by ConnectionClosed once the listener sees it's closed.
+	logrus.Infof("kill connection: id %v, by client %v", id, conn.ConnectionID)
+	h.e.Catalog.KillConnection(uint32(id))
+	c.Close()
+
+	if c == conn {
+		return false, mysql.NewSQLError(ERQueryInterrupted, SSQueryInterrupted, "Query execution was interrupted")
+	}
+	return true, callback(&sqltypes.Result{}, false)
 }
 
 func rowToSQL(s sql.Schema, row sql.Row) []sqltypes.Value {
 	o := make([]sqltypes.Value, len(row))
 	for i, v := range row {
+		if v == nil {
+			o[i] = sqltypes.NULL
+			continue
+		}
 		o[i] = s[i].Type.SQL(v)
 	}
 
@@ -373,37 +871,63 @@ func schemaToFields(s sql.Schema) []*query.Field {
 
 // splitStatements splits a multi-statement query into individual statements
 func (h *Handler) splitStatements(query string) []string {
+	return newMultiStatement(query).statements
+}
+
+// multiStatement is a multi-statement query split into its statements.
+type multiStatement struct {
+	query string
+	// statements are the statements that were not executed yet, and starts
+	// the positions in query where each of them begins.
+	statements []string
+	starts     []int
+}
+
+func newMultiStatement(query string) *multiStatement {
 	// Use vitess sqlparser to split statements properly
-	statements, err := sqlparser.SplitStatementToPieces(query)
+	pieces, err := sqlparser.SplitStatementToPieces(query)
 	if err != nil {
 		// Fall back to simple semicolon splitting if parsing fails
-		return h.simpleSplitStatements(query)
+		pieces = strings.Split(query, ";")
 	}
 
-	result := make([]string, 0)
-	for _, stmt := range statements {
-		trimmed := strings.TrimSpace(stmt)
-		if trimmed != "" {
-			result = append(result, trimmed)
+	// Pieces are the text between semicolons, so each one starts right
+	// after the semicolon that ends the one before it.
+	m := &multiStatement{query: query, statements: make([]string, 0, len(pieces))}
+	start := 0
+	for _, piece := range pieces {
+		if trimmed := strings.TrimSpace(piece); trimmed != "" {
+			m.statements = append(m.statements, trimmed)
+			m.starts = append(m.starts, start)
 		}
+		start += len(piece) + 1
 	}
-	return result
+	return m
 }
 
-// simpleSplitStatements provides a fallback for simple semicolon splitting
-func (h *Handler) simpleSplitStatements(query string) []string {
-	parts := strings.Split(query, ";")
-	result := make([]string, 0)
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
+// next returns the next statement to execute, or false if there are none.
+func (m *multiStatement) next() (string, bool) {
+	if len(m.statements) == 0 {
+		return "", false
+	}
+
+	stmt := m.statements[0]
+	m.statements, m.starts = m.statements[1:], m.starts[1:]
+	return stmt, true
+}
+
+// remainder returns the part of the query with the statements that were
+// not executed yet. It shares the memory of the query, so it's cheap to
+// compare it with the remainder the listener gives back.
+func (m *multiStatement) remainder() string {
+	if len(m.starts) == 0 {
+		return ""
 	}
-	return result
+	return m.query[m.starts[0]:]
 }
 
-// handleTransactionStatements handles BEGIN, COMMIT, and ROLLBACK statements
+// handleTransactionStatements handles BEGIN, COMMIT, ROLLBACK and SET
+// TRANSACTION statements
 func (h *Handler) handleTransactionStatements(sess *Session, query string, callback mysql.ResultSpoolFn) (bool, error) {
 	if sess == nil {
 		return false, nil
@@ -415,7 +939,9 @@ func (h *Handler) handleTransactionStatements(sess *Session, query string, callb
 		return false, nil // Not a transaction statement, let normal processing handle it
 	}
 
-	switch stmt.(type) {
+	switch stmt := stmt.(type) {
+	case *sqlparser.Set:
+		return h.handleSetTransaction(sess, stmt, callback)
 	case *sqlparser.Begin:
 		return true, h.handleBegin(sess, callback)
 	case *sqlparser.Commit:
@@ -433,7 +959,7 @@ func (h *Handler) handleBegin(sess *Session, callback mysql.ResultSpoolFn) error
 		return mysql.NewSQLError(1400, "HY000", "Transaction already started")
 	}
 
-	txn, err := h.txnManager.Begin(nil)
+	txn, err := h.txnManager.Begin(transactionOptions(sess))
 	if err != nil {
 		return h.convertError(err)
 	}
@@ -444,25 +970,55 @@ func (h *Handler) handleBegin(sess *Session, callback mysql.ResultSpoolFn) error
 	return callback(result, false)
 }
 
+// beginImplicitTransaction starts the transaction a DML statement run with
+// autocommit off is part of, unless one is open already. It lasts until
+// COMMIT or ROLLBACK, as if BEGIN had been run before the statement. If the
+// storage has no transactions, the statement runs without one.
+func (h *Handler) beginImplicitTransaction(sess *Session, ctx *sql.Context) error {
+	if sess.GetTransaction() != nil {
+		return nil
+	}
+
+	txn, err := h.txnManager.Begin(transactionOptions(sess))
+	if err == cerrors.ErrNotImplemented {
+		return nil
+	}
+	if err != nil {
+		return h.convertError(err)
+	}
+
+	sess.SetTransaction(txn)
+	ctx.SetTransaction(txn)
+	return nil
+}
+
 // handleCommit handles COMMIT statements
 func (h *Handler) handleCommit(sess *Session, callback mysql.ResultSpoolFn) error {
+	if err := h.commitTransaction(sess); err != nil {
+		return err
+	}
+
+	result := &sqltypes.Result{}
+	return callback(result, false)
+}
+
+// commitTransaction commits the open transaction of the session, if any.
+func (h *Handler) commitTransaction(sess *Session) error {
 	txn := sess.GetTransaction()
 	if txn == nil {
 		// No active transaction, silently succeed
-		result := &sqltypes.Result{}
-		return callback(result, false)
+		return nil
 	}
 
 	if t, ok := txn.(*transaction.Transaction); ok {
 		err := h.txnManager.Commit(t)
 		sess.SetTransaction(nil)
+		h.releaseWrittenTables(sess.ID())
 		if err != nil {
 			return h.convertError(err)
 		}
 	}
-
-	result := &sqltypes.Result{}
-	return callback(result, false)
+	return nil
 }
 
 // handleRollback handles ROLLBACK statements
@@ -477,6 +1033,7 @@ func (h *Handler) handleRollback(sess *Session, callback mysql.ResultSpoolFn) er
 	if t, ok := txn.(*transaction.Transaction); ok {
 		err := h.txnManager.Rollback(t)
 		sess.SetTransaction(nil)
+		h.releaseWrittenTables(sess.ID())
 		if err != nil {
 			return h.convertError(err)
 		}
@@ -157,10 +157,85 @@ func TestHandler_ComMultiQuery_TwoStatements(t *testing.T) {
 	})
 
 	assert.NoError(t, err)
-	assert.Equal(t, "SELECT 2", remainder)
+	assert.Equal(t, " SELECT 2", remainder)
 	assert.Len(t, results, 1) // Only first statement executed
 }
 
+// multiQueryResult is the result of a statement of a multi-statement query,
+// and whether the client was told more results follow it.
+type multiQueryResult struct {
+	result *sqltypes.Result
+	more   bool
+}
+
+// runMultiQuery executes a multi-statement query the same way the listener
+// does, calling ComMultiQuery with the remainder until there is none.
+func runMultiQuery(h *Handler, c *mysql.Conn, q string) ([]multiQueryResult, error) {
+	var results []multiQueryResult
+	for q != "" {
+		var r multiQueryResult
+		remainder, err := h.ComMultiQuery(context.Background(), c, q, func(res *sqltypes.Result, more bool) error {
+			if r.result == nil {
+				r.result = &sqltypes.Result{Fields: res.Fields}
+			}
+			r.result.Rows = append(r.result.Rows, res.Rows...)
+			r.more = more
+			return nil
+		})
+		if err != nil {
+			return results, err
+		}
+
+		results = append(results, r)
+		q = remainder
+	}
+	return results, nil
+}
+
+func TestHandler_ComMultiQuery_Batch(t *testing.T) {
+	require := require.New(t)
+	h, conn := setupPreparedHandler(t)
+
+	results, err := runMultiQuery(h, conn,
+		"INSERT INTO t (id, name) VALUES (1, 'a;b'); INSERT INTO t (id, name) VALUES (2, 'c');  SELECT id, name FROM t ORDER BY id; ;  ")
+	require.NoError(err)
+	require.Len(results, 3)
+
+	require.True(results[0].more)
+	require.True(results[1].more)
+	require.False(results[2].more)
+
+	require.Equal([][]sqltypes.Value{
+		{sqltypes.NewInt32(1), sqltypes.MakeTrusted(sqltypes.Text, []byte("a;b"))},
+		{sqltypes.NewInt32(2), sqltypes.MakeTrusted(sqltypes.Text, []byte("c"))},
+	}, results[2].result.Rows)
+
+	h.mu.Lock()
+	require.Empty(h.multiStmts)
+	h.mu.Unlock()
+}
+
+func TestHandler_ComMultiQuery_ErrorStopsBatch(t *testing.T) {
+	require := require.New(t)
+	h, conn := setupPreparedHandler(t)
+
+	results, err := runMultiQuery(h, conn,
+		"INSERT INTO t (id) VALUES (1); SELECT * FROM missing; INSERT INTO t (id) VALUES (2)")
+	require.Len(results, 1)
+
+	sqlErr, ok := err.(*mysql.SQLError)
+	require.True(ok, "%T", err)
+	require.Equal(ERNoSuchTable, sqlErr.Num, sqlErr.Message)
+
+	h.mu.Lock()
+	require.Empty(h.multiStmts)
+	h.mu.Unlock()
+
+	results, err = runMultiQuery(h, conn, "SELECT id FROM t")
+	require.NoError(err)
+	require.Equal([][]sqltypes.Value{{sqltypes.NewInt32(1)}}, results[0].result.Rows)
+}
+
 func TestHandler_SplitStatements(t *testing.T) {
 	h, _ := setupTestHandler()
 
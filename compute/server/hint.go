@@ -0,0 +1,58 @@
+package server
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+var maxExecutionTimeHint = regexp.MustCompile(`(?i)\bMAX_EXECUTION_TIME\s*\(\s*(\d+)\s*\)`)
+
+// maxExecutionTime returns the time the MAX_EXECUTION_TIME optimizer hint of
+// a statement allows it to run, and whether it has one. As in MySQL, the
+// hint is only taken from the first SELECT of a read statement, which it
+// applies to as a whole, and zero means the statement has no limit. Other
+// statements, such as INSERT ... SELECT, ignore it.
+func maxExecutionTime(stmt sqlparser.Statement) (time.Duration, bool) {
+	sel := firstSelect(stmt)
+	if sel == nil {
+		return 0, false
+	}
+
+	for _, comment := range sel.Comments {
+		c := string(comment)
+		if !strings.HasPrefix(c, "/*+") {
+			continue
+		}
+		m := maxExecutionTimeHint.FindStringSubmatch(c)
+		if m == nil {
+			continue
+		}
+		ms, err := strconv.ParseUint(m[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		return time.Duration(ms) * time.Millisecond, true
+	}
+	return 0, false
+}
+
+// firstSelect returns the first SELECT of a read statement, nil if it's not
+// one.
+func firstSelect(stmt sqlparser.Statement) *sqlparser.Select {
+	for {
+		switch s := stmt.(type) {
+		case *sqlparser.Select:
+			return s
+		case *sqlparser.SetOp:
+			stmt = s.Left
+		case *sqlparser.ParenSelect:
+			stmt = s.Select
+		default:
+			return nil
+		}
+	}
+}
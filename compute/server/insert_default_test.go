@@ -0,0 +1,164 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+)
+
+// requireRecent checks that a timestamp read from the server is the time
+// of the test.
+func requireRecent(t *testing.T, timestamp string) {
+	t.Helper()
+	ts, err := time.Parse(sqlengine.TimestampLayout, timestamp)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), ts, time.Minute)
+}
+
+func TestE2E_InsertDefaults(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	for _, q := range []string{
+		`CREATE TABLE accounts (
+			id BIGINT PRIMARY KEY,
+			owner VARCHAR(20) DEFAULT 'nobody',
+			balance DOUBLE DEFAULT 0.00,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT NOW()
+		)`,
+		"INSERT INTO accounts (id) VALUES (1)",
+		"INSERT INTO accounts (id, owner, balance) VALUES (2, 'ann', 12.5)",
+	} {
+		_, err := db.Exec(q)
+		require.NoError(err, q)
+	}
+
+	type account struct {
+		owner     string
+		balance   float64
+		createdAt string
+		updatedAt string
+	}
+	get := func(id int64) account {
+		var a account
+		require.NoError(db.QueryRow(
+			"SELECT owner, balance, created_at, updated_at FROM accounts WHERE id = ?", id,
+		).Scan(&a.owner, &a.balance, &a.createdAt, &a.updatedAt))
+		return a
+	}
+
+	first := get(1)
+	require.Equal("nobody", first.owner)
+	require.Equal(0.0, first.balance)
+	requireRecent(t, first.createdAt)
+	require.Equal(first.createdAt, first.updatedAt)
+
+	second := get(2)
+	require.Equal("ann", second.owner)
+	require.Equal(12.5, second.balance)
+	requireRecent(t, second.createdAt)
+
+	// CURRENT_TIMESTAMP is the time the statement started, so the rows
+	// inserted by a statement all take the same.
+	_, err := db.Exec("INSERT INTO accounts (id) VALUES (3), (4)")
+	require.NoError(err)
+	require.Equal(get(3).createdAt, get(4).createdAt)
+
+	// The defaults are kept with the table.
+	var table, create string
+	require.NoError(db.QueryRow("SHOW CREATE TABLE accounts").Scan(&table, &create))
+	require.Contains(create, "DEFAULT 'nobody'")
+	require.Contains(create, "`balance` DOUBLE DEFAULT 0")
+	require.Contains(create, "`created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP")
+}
+
+func TestE2E_InsertDefaults_AddColumn(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	for _, q := range []string{
+		"CREATE TABLE t (id BIGINT PRIMARY KEY)",
+		"INSERT INTO t VALUES (1)",
+		"ALTER TABLE t ADD COLUMN added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP",
+		"INSERT INTO t (id) VALUES (2)",
+	} {
+		_, err := db.Exec(q)
+		require.NoError(err, q)
+	}
+
+	rows, err := db.Query("SELECT added_at FROM t ORDER BY id")
+	require.NoError(err)
+	defer rows.Close()
+	for rows.Next() {
+		var addedAt string
+		require.NoError(rows.Scan(&addedAt))
+		requireRecent(t, addedAt)
+	}
+	require.NoError(rows.Err())
+
+	// Only the time columns default to CURRENT_TIMESTAMP.
+	_, err = db.Exec("CREATE TABLE u (id BIGINT PRIMARY KEY, name VARCHAR(20) DEFAULT CURRENT_TIMESTAMP)")
+	require.Error(err)
+}
+
+func TestE2E_OnUpdateCurrentTimestamp(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	for _, q := range []string{
+		`CREATE TABLE items (
+			id BIGINT PRIMARY KEY,
+			name VARCHAR(20),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)`,
+		"INSERT INTO items (id, name) VALUES (1, 'a'), (2, 'b')",
+	} {
+		_, err := db.Exec(q)
+		require.NoError(err, q)
+	}
+
+	times := func(id int64) (created, updated time.Time) {
+		var c, u string
+		require.NoError(db.QueryRow("SELECT created_at, updated_at FROM items WHERE id = ?", id).Scan(&c, &u))
+		created, err := time.Parse(sqlengine.TimestampLayout, c)
+		require.NoError(err)
+		updated, err = time.Parse(sqlengine.TimestampLayout, u)
+		require.NoError(err)
+		return created, updated
+	}
+	created, updated := times(1)
+	require.Equal(created, updated)
+
+	// The timestamps have a precision of a second.
+	time.Sleep(1100 * time.Millisecond)
+
+	_, err := db.Exec("UPDATE items SET name = 'c' WHERE id = 1")
+	require.NoError(err)
+	newCreated, newUpdated := times(1)
+	require.Equal(created, newCreated)
+	require.True(newUpdated.After(updated), "%s is not after %s", newUpdated, updated)
+
+	// Rows left as they were, or whose column is assigned, keep the value.
+	_, err = db.Exec("UPDATE items SET name = 'b' WHERE id = 2")
+	require.NoError(err)
+	_, untouched := times(2)
+	require.Equal(updated, untouched)
+
+	_, err = db.Exec("UPDATE items SET name = 'd', updated_at = '2020-01-02 03:04:05' WHERE id = 1")
+	require.NoError(err)
+	_, assigned := times(1)
+	require.Equal("2020-01-02 03:04:05", assigned.Format(sqlengine.TimestampLayout))
+
+	var table, create string
+	require.NoError(db.QueryRow("SHOW CREATE TABLE items").Scan(&table, &create))
+	require.Contains(create, "`created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,")
+	require.Contains(create, "`updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP")
+
+	// Only the time columns are updated with the time.
+	_, err = db.Exec("CREATE TABLE u (id BIGINT PRIMARY KEY, n BIGINT ON UPDATE CURRENT_TIMESTAMP)")
+	require.Error(err)
+}
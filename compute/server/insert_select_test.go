@@ -0,0 +1,130 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_InsertSelect(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	for _, q := range []string{
+		"CREATE TABLE players (id BIGINT PRIMARY KEY, name VARCHAR(20), score BIGINT)",
+		"CREATE TABLE winners (id BIGINT PRIMARY KEY AUTO_INCREMENT, name VARCHAR(20), score INT)",
+		"INSERT INTO players VALUES (1, 'ann', 10), (2, 'bob', 20), (3, 'cid', 30), (4, 'dan', 5)",
+	} {
+		_, err := db.Exec(q)
+		require.NoError(err, q)
+	}
+
+	// The rows are given the ids of the auto-increment column and their
+	// scores are converted to the type of the destination column.
+	res, err := db.Exec("INSERT INTO winners (name, score) SELECT name, score FROM players WHERE score >= 20 ORDER BY id")
+	require.NoError(err)
+	n, err := res.RowsAffected()
+	require.NoError(err)
+	require.Equal(int64(2), n)
+
+	type winner struct {
+		id    int64
+		name  string
+		score int64
+	}
+	winners := func() []winner {
+		rows, err := db.Query("SELECT id, name, score FROM winners ORDER BY id")
+		require.NoError(err)
+		defer rows.Close()
+
+		var ws []winner
+		for rows.Next() {
+			var w winner
+			require.NoError(rows.Scan(&w.id, &w.name, &w.score))
+			ws = append(ws, w)
+		}
+		require.NoError(rows.Err())
+		return ws
+	}
+	require.Equal([]winner{{1, "bob", 20}, {2, "cid", 30}}, winners())
+
+	// Without columns, the rows of the query fill all the columns.
+	_, err = db.Exec("INSERT INTO winners SELECT id + 10, name, score * 2 FROM players WHERE id = 4")
+	require.NoError(err)
+	require.Equal([]winner{{1, "bob", 20}, {2, "cid", 30}, {14, "dan", 10}}, winners())
+
+	// A table can be copied into itself.
+	_, err = db.Exec("INSERT INTO players SELECT id + 100, name, score FROM players WHERE id < 3")
+	require.NoError(err)
+	var count int64
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM players").Scan(&count))
+	require.Equal(int64(6), count)
+}
+
+func TestE2E_InsertSelect_ColumnCountMismatch(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	for _, q := range []string{
+		"CREATE TABLE src (id BIGINT PRIMARY KEY, name VARCHAR(20))",
+		"CREATE TABLE dst (id BIGINT PRIMARY KEY, name VARCHAR(20))",
+		"INSERT INTO src VALUES (1, 'a'), (2, 'b')",
+	} {
+		_, err := db.Exec(q)
+		require.NoError(err, q)
+	}
+
+	for _, q := range []string{
+		"INSERT INTO dst (id, name) SELECT id FROM src",
+		"INSERT INTO dst (id) SELECT id, name FROM src",
+		"INSERT INTO dst SELECT id FROM src",
+		// The counts don't match even if there are no rows to insert.
+		"INSERT INTO dst (id, name) SELECT id FROM src WHERE id > 10",
+		"INSERT INTO dst (id, name) VALUES (1)",
+	} {
+		_, err := db.Exec(q)
+		var mysqlErr *mysqldriver.MySQLError
+		require.True(errors.As(err, &mysqlErr), "%s: unexpected error: %v", q, err)
+		require.Equal(uint16(ERWrongValueCountOnRow), mysqlErr.Number, q)
+	}
+
+	var count int64
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM dst").Scan(&count))
+	require.Equal(int64(0), count)
+}
+
+// The rows copied by a statement that fails are all discarded.
+func TestE2E_InsertSelect_Atomic(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	for _, q := range []string{
+		"CREATE TABLE src (id BIGINT PRIMARY KEY, name VARCHAR(20))",
+		"CREATE TABLE dst (id BIGINT PRIMARY KEY, name VARCHAR(20))",
+		"INSERT INTO src VALUES (1, 'a'), (2, 'b'), (3, 'c')",
+		"INSERT INTO dst VALUES (2, 'taken')",
+	} {
+		_, err := db.Exec(q)
+		require.NoError(err, q)
+	}
+
+	_, err := db.Exec("INSERT INTO dst SELECT id, name FROM src ORDER BY id")
+	var mysqlErr *mysqldriver.MySQLError
+	require.True(errors.As(err, &mysqlErr), "unexpected error: %v", err)
+	require.Equal(uint16(ERDupEntry), mysqlErr.Number)
+
+	rows, err := db.Query("SELECT id, name FROM dst")
+	require.NoError(err)
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var name string
+		require.NoError(rows.Scan(&id, &name))
+		ids = append(ids, id)
+	}
+	require.NoError(rows.Err())
+	require.Equal([]int64{2}, ids)
+}
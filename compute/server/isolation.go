@@ -0,0 +1,85 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/turtacn/guocedb/compute/transaction"
+)
+
+const (
+	// isolationVariable is the session variable with the isolation level
+	// of the transactions of the session.
+	isolationVariable = "transaction_isolation"
+	// legacyIsolationVariable is the name isolationVariable had before
+	// MySQL 8.0, which clients still read.
+	legacyIsolationVariable = "tx_isolation"
+	isolationLevelPrefix    = "isolation level "
+)
+
+// handleSetTransaction handles SET [SESSION] TRANSACTION ISOLATION LEVEL,
+// and returns whether the statement was one. Without SESSION, the level is
+// only that of the next transaction of the session, as in MySQL, so it
+// can't be set while one is in progress. With SESSION, it's the level of
+// every transaction of the session from then on. SET GLOBAL TRANSACTION,
+// and the other characteristics of transactions, are left to the engine.
+func (h *Handler) handleSetTransaction(sess *Session, set *sqlparser.Set, callback mysql.ResultSpoolFn) (bool, error) {
+	levels := make([]transaction.IsolationLevel, len(set.Exprs))
+	for i, e := range set.Exprs {
+		if !e.Name.EqualString(sqlparser.TransactionStr) {
+			return false, nil
+		}
+		if e.Scope != sqlparser.SetScope_None && e.Scope != sqlparser.SetScope_Session {
+			return false, nil
+		}
+		val, ok := e.Expr.(*sqlparser.SQLVal)
+		if !ok || !strings.HasPrefix(string(val.Val), isolationLevelPrefix) {
+			return false, nil
+		}
+
+		level, err := transaction.ParseIsolationLevel(strings.TrimPrefix(string(val.Val), isolationLevelPrefix))
+		if err != nil {
+			return true, mysql.NewSQLError(ERWrongValueForVar, SSClientError, "%s", err.Error())
+		}
+		levels[i] = level
+	}
+
+	for i, e := range set.Exprs {
+		if e.Scope == sqlparser.SetScope_Session {
+			value := strings.ReplaceAll(levels[i].String(), " ", "-")
+			sess.SetVar(isolationVariable, value)
+			sess.SetVar(legacyIsolationVariable, value)
+			continue
+		}
+
+		if sess.GetTransaction() != nil {
+			return true, mysql.NewSQLError(ERCantChangeTxCharacteristics, SSActiveTransaction,
+				"Transaction characteristics can't be changed while a transaction is in progress")
+		}
+		sess.SetNextIsolation(levels[i])
+	}
+	return true, callback(&sqltypes.Result{}, false)
+}
+
+// transactionOptions returns the options of the next transaction of the
+// session. Its isolation level is the one given to SET TRANSACTION, which
+// only lasts for one transaction, or else that of the transaction_isolation
+// variable. It returns nil, so the default level of the manager is used, if
+// neither is set.
+func transactionOptions(sess *Session) *transaction.TransactionOptions {
+	if level, ok := sess.TakeNextIsolation(); ok {
+		return &transaction.TransactionOptions{IsolationLevel: level}
+	}
+
+	value, ok := sess.GetVar(isolationVariable).(string)
+	if !ok {
+		return nil
+	}
+	level, err := transaction.ParseIsolationLevel(value)
+	if err != nil {
+		return nil
+	}
+	return &transaction.TransactionOptions{IsolationLevel: level}
+}
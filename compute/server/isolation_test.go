@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_IsolationLevels(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	_, err := db.Exec("CREATE TABLE counters (id BIGINT PRIMARY KEY, value BIGINT)")
+	require.NoError(err)
+	_, err = db.Exec("INSERT INTO counters VALUES (1, 1)")
+	require.NoError(err)
+
+	ctx := context.Background()
+	reader, err := db.Conn(ctx)
+	require.NoError(err)
+	defer reader.Close()
+
+	value := func(conn *sql.Conn) int64 {
+		t.Helper()
+		var value int64
+		require.NoError(conn.QueryRowContext(ctx, "SELECT value FROM counters WHERE id = 1").Scan(&value))
+		return value
+	}
+	exec := func(conn *sql.Conn, query string) {
+		t.Helper()
+		_, err := conn.ExecContext(ctx, query)
+		require.NoError(err)
+	}
+	update := func(v int) {
+		t.Helper()
+		_, err := db.Exec("UPDATE counters SET value = ? WHERE id = 1", v)
+		require.NoError(err)
+	}
+
+	// REPEATABLE READ reads the same value in the whole transaction.
+	exec(reader, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ")
+	exec(reader, "BEGIN")
+	require.Equal(int64(1), value(reader))
+	update(2)
+	require.Equal(int64(1), value(reader))
+
+	// The level can't change while a transaction is in progress.
+	_, err = reader.ExecContext(ctx, "SET TRANSACTION ISOLATION LEVEL READ COMMITTED")
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(err, &mysqlErr)
+	require.Equal(uint16(ERCantChangeTxCharacteristics), mysqlErr.Number)
+
+	exec(reader, "COMMIT")
+	require.Equal(int64(2), value(reader))
+
+	// SET TRANSACTION only sets the level of the next transaction, so the
+	// session goes back to READ COMMITTED, which sees the new value.
+	exec(reader, "BEGIN")
+	require.Equal(int64(2), value(reader))
+	update(3)
+	require.Equal(int64(3), value(reader))
+	exec(reader, "COMMIT")
+
+	// SET SESSION sets the level of every transaction.
+	exec(reader, "SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ")
+	var level string
+	require.NoError(reader.QueryRowContext(ctx, "SELECT @@transaction_isolation").Scan(&level))
+	require.Equal("REPEATABLE-READ", level)
+	for i := 0; i < 2; i++ {
+		exec(reader, "BEGIN")
+		before := value(reader)
+		update(int(before) + 1)
+		require.Equal(before, value(reader))
+		exec(reader, "COMMIT")
+	}
+	require.Equal(int64(5), value(reader))
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_SelectForUpdate(t *testing.T) {
+	require := require.New(t)
+	_, db := startBadgerTestHandler(t)
+
+	_, err := db.Exec("CREATE TABLE accounts (id BIGINT PRIMARY KEY, balance BIGINT)")
+	require.NoError(err)
+	_, err = db.Exec("INSERT INTO accounts VALUES (1, 100), (2, 100)")
+	require.NoError(err)
+
+	ctx := context.Background()
+	a, err := db.Conn(ctx)
+	require.NoError(err)
+	defer a.Close()
+	b, err := db.Conn(ctx)
+	require.NoError(err)
+	defer b.Close()
+
+	_, err = a.ExecContext(ctx, "BEGIN")
+	require.NoError(err)
+	var balance int64
+	require.NoError(a.QueryRowContext(ctx, "SELECT balance FROM accounts WHERE id = 1 FOR UPDATE").Scan(&balance))
+	require.Equal(int64(100), balance)
+
+	_, err = b.ExecContext(ctx, "BEGIN")
+	require.NoError(err)
+
+	// The row A locked is read by B once A commits, with the balance A
+	// left.
+	locked := make(chan int64, 1)
+	errs := make(chan error, 1)
+	go func() {
+		var balance int64
+		err := b.QueryRowContext(ctx, "SELECT balance FROM accounts WHERE id = 1 FOR UPDATE").Scan(&balance)
+		if err != nil {
+			errs <- err
+			return
+		}
+		locked <- balance
+	}()
+
+	select {
+	case balance := <-locked:
+		t.Fatalf("B read the row locked by A: %d", balance)
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_, err = a.ExecContext(ctx, "UPDATE accounts SET balance = 50 WHERE id = 1")
+	require.NoError(err)
+	_, err = a.ExecContext(ctx, "COMMIT")
+	require.NoError(err)
+
+	select {
+	case balance := <-locked:
+		require.Equal(int64(50), balance)
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("B still blocked after A committed")
+	}
+	_, err = b.ExecContext(ctx, "COMMIT")
+	require.NoError(err)
+}
+
+func TestE2E_SelectForUpdate_LockWaitTimeout(t *testing.T) {
+	require := require.New(t)
+	handler, db := startBadgerTestHandler(t)
+	handler.txnManager.SetLockWaitTimeout(100 * time.Millisecond)
+
+	_, err := db.Exec("CREATE TABLE accounts (id BIGINT PRIMARY KEY, balance BIGINT)")
+	require.NoError(err)
+	_, err = db.Exec("INSERT INTO accounts VALUES (1, 100)")
+	require.NoError(err)
+
+	ctx := context.Background()
+	a, err := db.Conn(ctx)
+	require.NoError(err)
+	defer a.Close()
+	b, err := db.Conn(ctx)
+	require.NoError(err)
+	defer b.Close()
+
+	_, err = a.ExecContext(ctx, "BEGIN")
+	require.NoError(err)
+	var balance int64
+	require.NoError(a.QueryRowContext(ctx, "SELECT balance FROM accounts WHERE id = 1 FOR UPDATE").Scan(&balance))
+
+	// A shared lock conflicts with the exclusive one too.
+	_, err = b.ExecContext(ctx, "BEGIN")
+	require.NoError(err)
+	err = b.QueryRowContext(ctx, "SELECT balance FROM accounts WHERE id = 1 LOCK IN SHARE MODE").Scan(&balance)
+	var mysqlErr *mysqldriver.MySQLError
+	require.True(errors.As(err, &mysqlErr), "unexpected error: %v", err)
+	require.Equal(uint16(ERLockWaitTimeout), mysqlErr.Number)
+
+	// B can still lock the row once A releases it.
+	_, err = a.ExecContext(ctx, "ROLLBACK")
+	require.NoError(err)
+	require.NoError(b.QueryRowContext(ctx, "SELECT balance FROM accounts WHERE id = 1 LOCK IN SHARE MODE").Scan(&balance))
+	require.Equal(int64(100), balance)
+	_, err = b.ExecContext(ctx, "COMMIT")
+	require.NoError(err)
+}
+
+// Rows read without a lock are not blocked by the ones locked.
+func TestE2E_SelectForUpdate_PlainReads(t *testing.T) {
+	require := require.New(t)
+	_, db := startBadgerTestHandler(t)
+
+	_, err := db.Exec("CREATE TABLE accounts (id BIGINT PRIMARY KEY, balance BIGINT)")
+	require.NoError(err)
+	_, err = db.Exec("INSERT INTO accounts VALUES (1, 100)")
+	require.NoError(err)
+
+	ctx := context.Background()
+	a, err := db.Conn(ctx)
+	require.NoError(err)
+	defer a.Close()
+
+	_, err = a.ExecContext(ctx, "BEGIN")
+	require.NoError(err)
+	var balance int64
+	require.NoError(a.QueryRowContext(ctx, "SELECT balance FROM accounts WHERE id = 1 FOR UPDATE").Scan(&balance))
+
+	done := make(chan error, 1)
+	go func() {
+		var balance int64
+		done <- db.QueryRowContext(ctx, "SELECT balance FROM accounts WHERE id = 1").Scan(&balance)
+	}()
+	select {
+	case err := <-done:
+		require.NoError(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("plain read blocked by FOR UPDATE")
+	}
+
+	_, err = a.ExecContext(ctx, "COMMIT")
+	require.NoError(err)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/sirupsen/logrus"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// MaxConnectionsVariable is the global variable with the most connections
+// the server keeps open at once. It's changed with SET GLOBAL.
+const MaxConnectionsVariable = "max_connections"
+
+// rejectedConnectionID is the ID of the connections rejected because there
+// were too many, which never had a session. Sessions never have it, as
+// their IDs start at 1.
+const rejectedConnectionID = 0
+
+// defineVariables defines the global variables of the handler in the
+// catalog of its engine.
+func (h *Handler) defineVariables() {
+	h.e.Catalog.DefineGlobalVariable(MaxConnectionsVariable, sql.Int64, int64(0), func(v interface{}) error {
+		n := v.(int64)
+		if n < 1 {
+			return mysql.NewSQLError(ERWrongValueForVar, SSClientError,
+				"Variable '%s' can't be set to the value of '%d'", MaxConnectionsVariable, n)
+		}
+		h.maxConns.Store(n)
+		return nil
+	})
+
+	h.maxPacket.Store(DefaultMaxAllowedPacket)
+	h.e.Catalog.DefineGlobalVariable(MaxAllowedPacketVariable, sql.Int64, int64(DefaultMaxAllowedPacket), func(v interface{}) error {
+		n := v.(int64)
+		if err := checkMaxAllowedPacket(n); err != nil {
+			return err
+		}
+		h.maxPacket.Store(n)
+		return nil
+	})
+}
+
+// SetMaxConnections sets the most connections the handler keeps open at
+// once. Connections beyond the limit are rejected with ER_CON_COUNT_ERROR.
+// It must be positive.
+func (h *Handler) SetMaxConnections(n int) error {
+	return h.e.Catalog.SetGlobalVariable(MaxConnectionsVariable, int64(n))
+}
+
+// MaxConnections returns the most connections the handler keeps open at
+// once, or zero if there is no limit.
+func (h *Handler) MaxConnections() int {
+	return int(h.maxConns.Load())
+}
+
+// tooManyConnections returns whether n connections are over the limit.
+func (h *Handler) tooManyConnections(n int64) bool {
+	max := h.maxConns.Load()
+	return max > 0 && n > max
+}
+
+// rejectConnection sends ER_CON_COUNT_ERROR to a new connection in place of
+// the handshake, as MySQL does, and closes it.
+func (h *Handler) rejectConnection(c *mysql.Conn) {
+	c.ConnectionID = rejectedConnectionID
+	logrus.Warnf("NewConnection: rejecting client %v, too many connections", c.RemoteAddr())
+
+	if err := writeHandshakeError(c.Conn, ERConCountError, SSConCount, "Too many connections"); err != nil {
+		logrus.Debugf("unable to send connection error: %s", err)
+	}
+	c.Close()
+}
+
+// writeHandshakeError writes an error packet to a connection that hasn't
+// received the handshake yet.
+func writeHandshakeError(conn net.Conn, code uint16, state, message string) error {
+	// The sequence number is 0 as this is the first packet of the
+	// connection.
+	return writeErrorPacket(conn, 0, code, state, message)
+}
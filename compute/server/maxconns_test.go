@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_MaxConnections(t *testing.T) {
+	handler, addr := startHandlerListener(t, time.Hour)
+	require.NoError(t, handler.SetMaxConnections(3))
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", addr))
+	require.NoError(t, err)
+	defer db.Close()
+	// Closed connections are closed for real, not kept for reuse.
+	db.SetMaxIdleConns(0)
+
+	ctx := context.Background()
+	var conns []*sql.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := db.Conn(ctx)
+		require.NoError(t, err)
+		defer conn.Close()
+		require.NoError(t, conn.PingContext(ctx))
+		conns = append(conns, conn)
+	}
+
+	_, err = db.Conn(ctx)
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(t, err, &mysqlErr)
+	require.Equal(t, uint16(ERConCountError), mysqlErr.Number)
+	require.Equal(t, "Too many connections", mysqlErr.Message)
+
+	// The rejected connection didn't take a session from the others.
+	require.Equal(t, 3, handler.openConns())
+	for _, conn := range conns {
+		require.NoError(t, conn.PingContext(ctx))
+	}
+
+	// The limit can be changed while the server runs.
+	var max int64
+	require.NoError(t, conns[0].QueryRowContext(ctx, "SELECT @@global.max_connections").Scan(&max))
+	require.Equal(t, int64(3), max)
+
+	_, err = conns[0].ExecContext(ctx, "SET GLOBAL max_connections = 4")
+	require.NoError(t, err)
+	require.Equal(t, 4, handler.MaxConnections())
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.PingContext(ctx))
+
+	_, err = conns[0].ExecContext(ctx, "SET GLOBAL max_connections = 0")
+	require.ErrorAs(t, err, &mysqlErr)
+	require.Equal(t, uint16(ERWrongValueForVar), mysqlErr.Number)
+
+	_, err = conns[0].ExecContext(ctx, "SET max_connections = 10")
+	require.Error(t, err)
+	require.Equal(t, 4, handler.MaxConnections())
+
+	// Once a connection is closed, a new one can take its place.
+	_, err = db.Conn(ctx)
+	require.ErrorAs(t, err, &mysqlErr)
+	require.NoError(t, conns[1].Close())
+	require.Eventually(t, func() bool {
+		c, err := db.Conn(ctx)
+		if err != nil {
+			return false
+		}
+		c.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_NumericOutOfRange(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	conn, err := db.Conn(context.Background())
+	require.NoError(err)
+	defer conn.Close()
+
+	_, err = conn.ExecContext(context.Background(),
+		"CREATE TABLE t (id BIGINT PRIMARY KEY, tiny TINYINT, small SMALLINT UNSIGNED)")
+	require.NoError(err)
+
+	_, err = conn.ExecContext(context.Background(), "INSERT INTO t (id, tiny, small) VALUES (1, 127, 65535)")
+	require.NoError(err)
+
+	_, err = conn.ExecContext(context.Background(), "INSERT INTO t (id, tiny) VALUES (2, 1), (3, 300)")
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(err, &mysqlErr)
+	require.Equal(uint16(ERWarnDataOutOfRange), mysqlErr.Number)
+	require.Equal("Out of range value for column 'tiny' at row 2", mysqlErr.Message)
+
+	_, err = conn.ExecContext(context.Background(), "UPDATE t SET small = -1 WHERE id = 1")
+	require.ErrorAs(err, &mysqlErr)
+	require.Equal(uint16(ERWarnDataOutOfRange), mysqlErr.Number)
+
+	// The values that don't fit are never stored.
+	var tiny, small int
+	require.NoError(conn.QueryRowContext(context.Background(), "SELECT tiny, small FROM t WHERE id = 1").Scan(&tiny, &small))
+	require.Equal(127, tiny)
+	require.Equal(65535, small)
+
+	// The fraction of floats stored in integer columns is truncated.
+	_, err = conn.ExecContext(context.Background(), "INSERT INTO t (id, tiny) VALUES (4, 12.7)")
+	require.NoError(err)
+	var level, message string
+	var code int
+	require.NoError(conn.QueryRowContext(context.Background(), "SHOW WARNINGS").Scan(&level, &code, &message))
+	require.Equal(1265, code)
+	require.Equal("Data truncated for column 'tiny' at row 1", message)
+	require.NoError(conn.QueryRowContext(context.Background(), "SELECT tiny FROM t WHERE id = 4").Scan(&tiny))
+	require.Equal(12, tiny)
+}
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxAllowedPacketVariable is the global variable with the largest packet,
+// or packets continuing one another, a client may send, which bounds the
+// size of its queries and of the values they have. It's changed with SET
+// GLOBAL and applies to the packets read from then on.
+const MaxAllowedPacketVariable = "max_allowed_packet"
+
+// Bounds of max_allowed_packet, which are those of MySQL.
+const (
+	DefaultMaxAllowedPacket = 64 << 20
+	MinMaxAllowedPacket     = 1 << 10
+	MaxMaxAllowedPacket     = 1 << 30
+)
+
+// packetTooLargeMessage is the message of ER_NET_PACKET_TOO_LARGE.
+const packetTooLargeMessage = "Got a packet bigger than 'max_allowed_packet' bytes"
+
+// errPacketTooLarge is returned by the reads of a connection once its
+// client sent a packet over the limit.
+var errPacketTooLarge = mysql.NewSQLError(ERNetPacketTooLarge, SSNetError, packetTooLargeMessage)
+
+// SetMaxAllowedPacket sets the largest packet the clients of the handler
+// may send. Clients sending larger ones get ER_NET_PACKET_TOO_LARGE and are
+// disconnected. It must be between MinMaxAllowedPacket and
+// MaxMaxAllowedPacket.
+func (h *Handler) SetMaxAllowedPacket(n int) error {
+	return h.e.Catalog.SetGlobalVariable(MaxAllowedPacketVariable, int64(n))
+}
+
+// MaxAllowedPacket returns the largest packet the clients of the handler
+// may send.
+func (h *Handler) MaxAllowedPacket() int {
+	return int(h.maxPacket.Load())
+}
+
+// checkMaxAllowedPacket validates a new value of max_allowed_packet.
+func checkMaxAllowedPacket(n int64) error {
+	if n < MinMaxAllowedPacket || n > MaxMaxAllowedPacket {
+		return mysql.NewSQLError(ERWrongValueForVar, SSClientError,
+			"Variable '%s' can't be set to the value of '%d'", MaxAllowedPacketVariable, n)
+	}
+	return nil
+}
+
+// packetLimitListener accepts connections whose packets are checked
+// against the max_allowed_packet of a handler as they're read.
+type packetLimitListener struct {
+	net.Listener
+	limit *atomic.Int64
+}
+
+// Accept implements the net.Listener interface.
+func (l *packetLimitListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &packetLimitConn{Conn: conn, limit: l.limit}, nil
+}
+
+// packetLimitConn follows the headers of the packets a client sends, so a
+// packet over the limit is refused from its header, before its payload is
+// read and buffered. The payload is discarded, so the client gets
+// ER_NET_PACKET_TOO_LARGE once it has sent it, and the reads fail from
+// then on, which closes the connection.
+//
+// The packets of a connection switching to TLS can't be followed past the
+// SSL request, so those connections are only limited by the check of the
+// queries the handler gets.
+//
+// The first packet is also kept, so the connection attributes the client
+// sent with it are handed to onHandshake, which the connections switching
+// to TLS don't get either.
+type packetLimitConn struct {
+	net.Conn
+	limit *atomic.Int64
+
+	header    [4]byte
+	headerLen int   // Bytes of the header of the next packet read so far
+	remaining int   // Bytes of the payload of the current packet not read yet
+	size      int64 // Bytes of the payload of the current packet and the ones it continues
+	last      bool  // Whether the current packet isn't continued by the next one
+	packets   int   // Packets read
+	tls       bool  // Whether the connection switched to TLS
+	err       error // Error of all the reads once the limit was exceeded
+
+	handshake   []byte // Payload of the first packet read so far
+	onHandshake func(attrs map[string]string)
+}
+
+// Read implements the net.Conn interface.
+func (c *packetLimitConn) Read(b []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n, err := c.Conn.Read(b)
+	if c.tls {
+		return n, err
+	}
+
+	for i := 0; i < n && !c.tls; {
+		if c.remaining > 0 {
+			skip := min(c.remaining, n-i)
+			if c.packets == 0 && c.onHandshake != nil {
+				c.handshake = append(c.handshake, b[i:i+skip]...)
+			}
+			c.remaining -= skip
+			i += skip
+			c.packetRead()
+			continue
+		}
+
+		c.header[c.headerLen] = b[i]
+		c.headerLen++
+		i++
+		if c.headerLen < len(c.header) {
+			continue
+		}
+
+		c.headerLen = 0
+		length := int(c.header[0]) | int(c.header[1])<<8 | int(c.header[2])<<16
+		if c.last {
+			c.size = 0
+		}
+		c.size += int64(length)
+		c.last = length < mysql.MaxPacketSize
+		c.remaining = length
+
+		if c.size > c.limit.Load() {
+			c.err = c.refuse(b[i:n])
+			return 0, c.err
+		}
+		c.packetRead()
+	}
+	return n, err
+}
+
+// packetRead counts the packets whose payload has been read. The first one
+// is the answer of the client to the handshake, which is an SSL request of
+// 32 bytes when it switches to TLS.
+func (c *packetLimitConn) packetRead() {
+	if c.remaining > 0 {
+		return
+	}
+	c.packets++
+	if c.packets != 1 {
+		return
+	}
+	if c.size == 32 {
+		c.tls = true
+	} else if c.onHandshake != nil {
+		if attrs, ok := parseConnAttributes(c.handshake); ok {
+			c.onHandshake(attrs)
+		}
+	}
+	c.handshake = nil
+}
+
+// refuse discards what's left of the packet over the limit, of which
+// buffered are the bytes already read, along with the packets continuing
+// it, and sends ER_NET_PACKET_TOO_LARGE.
+func (c *packetLimitConn) refuse(buffered []byte) error {
+	seq := c.header[3]
+	logrus.Warnf("refusing a packet of at least %d bytes from client %v, over max_allowed_packet",
+		c.size, c.RemoteAddr())
+
+	skip := min(c.remaining, len(buffered))
+	c.remaining -= skip
+	buffered = buffered[skip:]
+
+	for {
+		if c.remaining > 0 {
+			if _, err := io.CopyN(io.Discard, c.Conn, int64(c.remaining)); err != nil {
+				return err
+			}
+			c.remaining = 0
+		}
+		if c.last {
+			break
+		}
+
+		// The header of the next packet may have been read along with
+		// the end of the previous one.
+		var header [4]byte
+		n := copy(header[:], buffered)
+		buffered = buffered[n:]
+		if _, err := io.ReadFull(c.Conn, header[n:]); err != nil {
+			return err
+		}
+		length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+		seq = header[3]
+		c.last = length < mysql.MaxPacketSize
+
+		skip := min(length, len(buffered))
+		buffered = buffered[skip:]
+		c.remaining = length - skip
+	}
+
+	if err := writeErrorPacket(c.Conn, seq+1, ERNetPacketTooLarge, SSNetError, packetTooLargeMessage); err != nil {
+		return err
+	}
+	return errPacketTooLarge
+}
+
+// checkQuerySize returns ER_NET_PACKET_TOO_LARGE if a query is longer than
+// max_allowed_packet, which is how the queries of the connections whose
+// packets aren't followed are limited.
+func (h *Handler) checkQuerySize(query string) error {
+	if int64(len(query)) > h.maxPacket.Load() {
+		return errPacketTooLarge
+	}
+	return nil
+}
+
+// writeErrorPacket writes an error packet with the given sequence number
+// to a connection.
+func writeErrorPacket(conn net.Conn, seq byte, code uint16, state, message string) error {
+	if len(state) != 5 {
+		return fmt.Errorf("invalid SQL state %q", state)
+	}
+
+	payload := make([]byte, 0, 9+len(message))
+	payload = append(payload, 0xff, byte(code), byte(code>>8), '#')
+	payload = append(payload, state...)
+	payload = append(payload, message...)
+
+	n := len(payload)
+	packet := append([]byte{byte(n), byte(n >> 8), byte(n >> 16), seq}, payload...)
+	_, err := conn.Write(packet)
+	return err
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dolthub/vitess/go/mysql"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+)
+
+func TestServer_MaxAllowedPacket(t *testing.T) {
+	require := require.New(t)
+
+	memdb := mem.NewDatabase("testdb")
+	require.NoError(memdb.Create("files", sqlengine.Schema{
+		{Name: "id", Type: sqlengine.Int64, Source: "files", PrimaryKey: true},
+		{Name: "data", Type: sqlengine.Blob, Source: "files"},
+	}))
+	catalog := sqlengine.NewCatalog()
+	catalog.AddDatabase(memdb)
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+
+	s, err := NewDefaultServer(Config{
+		Protocol:         "tcp",
+		Address:          "127.0.0.1:0",
+		Auth:             auth.NewNativeSingle("root", "", auth.AllPermissions),
+		MaxAllowedPacket: 64 << 10,
+	}, engine)
+	require.NoError(err)
+	s.Start()
+	defer s.Close()
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", s.Addr()))
+	require.NoError(err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(err)
+	defer conn.Close()
+
+	var max int64
+	require.NoError(conn.QueryRowContext(ctx, "SELECT @@max_allowed_packet").Scan(&max))
+	require.Equal(int64(64<<10), max)
+
+	insert := func(conn *sql.Conn, id, size int) error {
+		_, err := conn.ExecContext(ctx, fmt.Sprintf("INSERT INTO files VALUES (%d, '%s')", id, strings.Repeat("x", size)))
+		return err
+	}
+	require.NoError(insert(conn, 1, 32<<10))
+
+	err = insert(conn, 2, 100<<10)
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(err, &mysqlErr)
+	require.Equal(uint16(ERNetPacketTooLarge), mysqlErr.Number)
+	require.Equal(packetTooLargeMessage, mysqlErr.Message)
+
+	// The limit can be raised for the new connections.
+	other, err := db.Conn(ctx)
+	require.NoError(err)
+	defer other.Close()
+	_, err = other.ExecContext(ctx, "SET GLOBAL max_allowed_packet = 1048576")
+	require.NoError(err)
+	_, err = other.ExecContext(ctx, "SET GLOBAL max_allowed_packet = 10")
+	require.ErrorAs(err, &mysqlErr)
+	require.Equal(uint16(ERWrongValueForVar), mysqlErr.Number)
+
+	raised, err := db.Conn(ctx)
+	require.NoError(err)
+	defer raised.Close()
+	require.NoError(raised.QueryRowContext(ctx, "SELECT @@max_allowed_packet").Scan(&max))
+	require.Equal(int64(1<<20), max)
+	require.NoError(insert(raised, 2, 100<<10))
+
+	var n int
+	require.NoError(raised.QueryRowContext(ctx, "SELECT COUNT(*) FROM files").Scan(&n))
+	require.Equal(2, n)
+}
+
+func TestPacketLimitConn_Continued(t *testing.T) {
+	require := require.New(t)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	var limit atomic.Int64
+	limit.Store(MaxMaxAllowedPacket)
+	conn := &packetLimitConn{Conn: server, limit: &limit}
+
+	packet := func(seq byte, payload []byte) []byte {
+		n := len(payload)
+		return append([]byte{byte(n), byte(n >> 8), byte(n >> 16), seq}, payload...)
+	}
+
+	// A packet under the limit goes through.
+	go client.Write(packet(0, make([]byte, 100)))
+	_, err := io.ReadFull(conn, make([]byte, 104))
+	require.NoError(err)
+
+	// A payload of the largest packet size is continued by the next packet,
+	// which makes them go over the limit together.
+	limit.Store(mysql.MaxPacketSize + 10)
+	go func() {
+		client.Write(packet(0, make([]byte, mysql.MaxPacketSize)))
+		client.Write(packet(1, make([]byte, 20)))
+	}()
+	errPacket := make(chan []byte)
+	go func() {
+		b := make([]byte, 1024)
+		n, _ := client.Read(b)
+		errPacket <- b[:n]
+	}()
+
+	_, err = io.ReadAll(conn)
+	require.Equal(errPacketTooLarge, err)
+
+	b := <-errPacket
+	require.Equal(byte(2), b[3])
+	require.Equal(byte(0xff), b[4])
+	require.Equal(uint16(ERNetPacketTooLarge), uint16(b[5])|uint16(b[6])<<8)
+	require.True(bytes.HasSuffix(b, []byte(packetTooLargeMessage)))
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/dolthub/vitess/go/vt/proto/query"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/parse"
+)
+
+// preparedStatement is a statement prepared with COM_STMT_PREPARE. It's
+// parsed once, and each execution only binds the parameters to it.
+type preparedStatement struct {
+	query  *sqlparser.ParsedQuery
+	params uint16
+	fields []*query.Field
+	// stmt is the statement parsed, with its parameters.
+	stmt sqlparser.Statement
+	// parsed is the plan of the statement before it's analyzed, which is
+	// bound and analyzed on each execution. It's nil for the statements
+	// that are executed as queries with their parameters written in, such
+	// as the ones with LIMIT ?, which is given as a number to the plan.
+	parsed sql.Node
+	// selectLimit is the sql_select_limit of the session when the plan was
+	// parsed, which gives a limit to the plans of SELECT statements. The
+	// plan is parsed again if it changes.
+	selectLimit interface{}
+}
+
+// boundStatement is a prepared statement with the values of an execution
+// bound to its parameters.
+type boundStatement struct {
+	stmt     sqlparser.Statement
+	parsed   sql.Node
+	bindings map[string]sql.Expression
+}
+
+// ComPrepare parses a statement to be executed later with
+// ComStmtExecute, and returns the fields of its results.
+func (h *Handler) ComPrepare(ctx context.Context, c *mysql.Conn, q string, prepare *mysql.PrepareData) ([]*query.Field, error) {
+	defer h.beginCommand(c)()
+
+	stmt, err := h.prepare(ctx, c, q)
+	if err != nil {
+		return nil, ConvertToMySQLError(err)
+	}
+
+	prepare.ParamsCount = stmt.params
+	if len(prepare.ParamsType) != int(stmt.params) {
+		prepare.ParamsType = make([]int32, stmt.params)
+	}
+	if prepare.BindVars == nil && stmt.params > 0 {
+		prepare.BindVars = make(map[string]*query.BindVariable, stmt.params)
+	}
+
+	h.mu.Lock()
+	h.forgetClosedStatements(c)
+	if h.prepared[c.ConnectionID] == nil {
+		h.prepared[c.ConnectionID] = make(map[uint32]*preparedStatement)
+	}
+	h.prepared[c.ConnectionID][prepare.StatementID] = stmt
+	h.mu.Unlock()
+
+	return stmt.fields, nil
+}
+
+// ComStmtExecute binds the parameters to a prepared statement and
+// executes it.
+func (h *Handler) ComStmtExecute(ctx context.Context, c *mysql.Conn, prepare *mysql.PrepareData, callback func(*sqltypes.Result) error) error {
+	h.mu.Lock()
+	h.forgetClosedStatements(c)
+	stmt, ok := h.prepared[c.ConnectionID][prepare.StatementID]
+	h.mu.Unlock()
+
+	if !ok {
+		return mysql.NewSQLError(ERUnknownStmtHandler, SSUnknownSQLState,
+			"unknown prepared statement handler (%d) given to mysqld_stmt_execute", prepare.StatementID)
+	}
+
+	for i := 1; i <= int(stmt.params); i++ {
+		if _, ok := prepare.BindVars["v"+strconv.Itoa(i)]; !ok {
+			return mysql.NewSQLError(mysql.ERWrongArguments, SSUnknownSQLState,
+				"incorrect arguments to mysqld_stmt_execute: missing parameter %d", i)
+		}
+	}
+
+	// The query with the values written in is what the process list and
+	// the logs show.
+	q, err := stmt.query.GenerateQuery(prepare.BindVars, nil)
+	if err != nil {
+		return mysql.NewSQLError(mysql.ERWrongArguments, SSUnknownSQLState,
+			"incorrect arguments to mysqld_stmt_execute: %s", err)
+	}
+
+	spool := func(r *sqltypes.Result, more bool) error {
+		return callback(r)
+	}
+	if stmt.parsed == nil {
+		return h.ComQuery(ctx, c, q, spool)
+	}
+
+	parsed := stmt.parsed
+	if sess := h.sessionMgr.GetSession(c.ConnectionID); sess != nil {
+		sqlCtx := sess.Context(ctx, sql.WithQuery(prepare.PrepareStmt))
+		if limit := selectLimit(sqlCtx); limit != stmt.selectLimit {
+			if parsed, err = h.e.Prepare(sqlCtx, prepare.PrepareStmt); err != nil {
+				return ConvertToMySQLError(err)
+			}
+			// Statements are only executed by their connection, one at
+			// a time.
+			stmt.parsed, stmt.selectLimit = parsed, limit
+		}
+	}
+
+	bindings := make(map[string]sql.Expression, stmt.params)
+	for i := 1; i <= int(stmt.params); i++ {
+		name := "v" + strconv.Itoa(i)
+		if bindings[name], err = parse.BindVarExpression(prepare.BindVars[name]); err != nil {
+			return mysql.NewSQLError(mysql.ERWrongArguments, SSUnknownSQLState,
+				"incorrect arguments to mysqld_stmt_execute: %s", err)
+		}
+	}
+
+	return h.query(ctx, c, q, &boundStatement{stmt: stmt.stmt, parsed: parsed, bindings: bindings}, spool)
+}
+
+// selectLimit returns the sql_select_limit of the session of ctx.
+func selectLimit(ctx *sql.Context) interface{} {
+	_, limit := ctx.Get("sql_select_limit")
+	return limit
+}
+
+// prepare parses the query and works out the fields of its results by
+// analyzing it with NULL as the value of all parameters.
+func (h *Handler) prepare(ctx context.Context, c *mysql.Conn, q string) (*preparedStatement, error) {
+	parsed, err := sqlparser.Parse(q)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &preparedStatement{query: sqlparser.NewParsedQuery(parsed), stmt: parsed}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if v, ok := node.(*sqlparser.SQLVal); ok && v.Type == sqlparser.ValArg {
+			stmt.params++
+		}
+		return true, nil
+	}, parsed)
+
+	// SELECT, INSERT, UPDATE and DELETE statements keep their plan, unless
+	// it can't be parsed with parameters, and are executed by binding it.
+	// The others are executed as queries with their parameters written in.
+	reusable := false
+	switch parsed.(type) {
+	case *sqlparser.Select, *sqlparser.SetOp, *sqlparser.ParenSelect:
+		reusable = true
+	case *sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete:
+		reusable = true
+	case *sqlparser.Show:
+	default:
+		return stmt, nil
+	}
+
+	var sqlCtx *sql.Context
+	sess := h.sessionMgr.GetSession(c.ConnectionID)
+	if sess != nil {
+		sqlCtx = sess.Context(ctx, sql.WithQuery(q))
+	} else {
+		sqlCtx = h.sm.NewContextWithQuery(c, q)
+	}
+
+	// The plan is shared with the statements prepared with the same text,
+	// and with the queries with the same literals in place of parameters.
+	if reusable {
+		stmt.parsed, _ = h.parsePlan(sqlCtx, sess, parsed, sqlparser.String(parsed))
+		stmt.selectLimit = selectLimit(sqlCtx)
+	}
+	if stmt.fields, err = h.resultFields(sqlCtx, stmt); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// resultFields returns the fields of the results of a prepared statement,
+// which it works out by analyzing it with NULL as the value of all
+// parameters. Only SELECT and SHOW statements have results.
+func (h *Handler) resultFields(ctx *sql.Context, stmt *preparedStatement) ([]*query.Field, error) {
+	switch stmt.stmt.(type) {
+	case *sqlparser.Select, *sqlparser.SetOp, *sqlparser.ParenSelect, *sqlparser.Show:
+	default:
+		return nil, nil
+	}
+
+	nulls := make(map[string]*query.BindVariable, stmt.params)
+	for i := 1; i <= int(stmt.params); i++ {
+		nulls["v"+strconv.Itoa(i)] = sqltypes.ValueBindVariable(sqltypes.NULL)
+	}
+
+	var node sql.Node
+	var err error
+	if stmt.parsed != nil {
+		bindings := make(map[string]sql.Expression, len(nulls))
+		for name, null := range nulls {
+			if bindings[name], err = parse.BindVarExpression(null); err != nil {
+				return nil, err
+			}
+		}
+		node, err = h.e.AnalyzePrepared(ctx, stmt.parsed, bindings)
+	} else {
+		var analyzed string
+		if analyzed, err = stmt.query.GenerateQuery(nulls, nil); err != nil {
+			return nil, err
+		}
+		node, err = h.e.Analyze(ctx, analyzed)
+	}
+
+	// Some queries are not valid with NULL parameters, such as the ones
+	// with LIMIT ?. The fields of their results are only sent when they are
+	// executed, and any other error is also reported then.
+	if err != nil {
+		return nil, nil
+	}
+	return schemaToFields(node.Schema()), nil
+}
+
+// forgetClosedStatements removes the statements of the connection that the
+// client closed. Closing a statement is handled by the connection without
+// telling the handler, which only sees it's no longer in the connection.
+// It must be called with h.mu held.
+func (h *Handler) forgetClosedStatements(c *mysql.Conn) {
+	for id := range h.prepared[c.ConnectionID] {
+		if _, ok := c.PrepareData[id]; !ok {
+			delete(h.prepared[c.ConnectionID], id)
+		}
+	}
+}
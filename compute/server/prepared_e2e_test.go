@@ -0,0 +1,54 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The driver prepares statements with arguments on the server, and sends
+// only their arguments on each execution.
+func TestE2E_PreparedStatements(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	_, err := db.Exec("CREATE TABLE t (id BIGINT PRIMARY KEY, name VARCHAR(20))")
+	require.NoError(err)
+
+	insert, err := db.Prepare("INSERT INTO t (id, name) VALUES (?, ?)")
+	require.NoError(err)
+	defer insert.Close()
+	for i := 1; i <= 1000; i++ {
+		_, err := insert.Exec(i, "name")
+		require.NoError(err, "row %d", i)
+	}
+
+	var count int64
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM t").Scan(&count))
+	require.Equal(int64(1000), count)
+
+	sel, err := db.Prepare("SELECT id FROM t WHERE id IN (?, ?, ?) ORDER BY id")
+	require.NoError(err)
+	defer sel.Close()
+
+	for _, tt := range []struct {
+		args []interface{}
+		want []int64
+	}{
+		{[]interface{}{10, 500, 1000}, []int64{10, 500, 1000}},
+		{[]interface{}{2, 1001, 1}, []int64{1, 2}},
+	} {
+		rows, err := sel.Query(tt.args...)
+		require.NoError(err)
+
+		var got []int64
+		for rows.Next() {
+			var id int64
+			require.NoError(rows.Scan(&id))
+			got = append(got, id)
+		}
+		require.NoError(rows.Err())
+		rows.Close()
+		require.Equal(tt.want, got)
+	}
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/dolthub/vitess/go/vt/proto/query"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+func setupPreparedHandler(t *testing.T) (*Handler, *mysql.Conn) {
+	db := mem.NewDatabase("testdb")
+	db.AddTable("t", mem.NewTable("t", sql.Schema{
+		{Name: "id", Type: sql.Int32, Source: "t"},
+		{Name: "name", Type: sql.Text, Source: "t", Nullable: true},
+		{Name: "age", Type: sql.Int32, Source: "t", Nullable: true},
+	}))
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	catalog.SetCurrentDatabase("testdb")
+
+	e := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+	h := NewHandler(e, NewSessionManager(DefaultSessionBuilder, nil, "localhost:3306"))
+
+	conn := &mysql.Conn{ConnectionID: 1, PrepareData: make(map[uint32]*mysql.PrepareData)}
+	h.NewConnection(conn)
+	require.NoError(t, h.ComInitDB(conn, "testdb"))
+	return h, conn
+}
+
+// prepareStmt prepares the query the same way the connection does when it
+// gets COM_STMT_PREPARE.
+func prepareStmt(t *testing.T, h *Handler, c *mysql.Conn, q string) (*mysql.PrepareData, []*query.Field) {
+	c.StatementID++
+	prepare := &mysql.PrepareData{StatementID: c.StatementID, PrepareStmt: q}
+	c.PrepareData[prepare.StatementID] = prepare
+
+	fields, err := h.ComPrepare(context.Background(), c, q, prepare)
+	require.NoError(t, err)
+	return prepare, fields
+}
+
+func execStmt(h *Handler, c *mysql.Conn, prepare *mysql.PrepareData, args ...*query.BindVariable) (*sqltypes.Result, error) {
+	prepare.BindVars = make(map[string]*query.BindVariable, len(args))
+	for i, arg := range args {
+		prepare.BindVars["v"+string(rune('1'+i))] = arg
+	}
+
+	result := &sqltypes.Result{}
+	err := h.ComStmtExecute(context.Background(), c, prepare, func(r *sqltypes.Result) error {
+		result.Fields = r.Fields
+		result.Rows = append(result.Rows, r.Rows...)
+		result.RowsAffected += r.RowsAffected
+		return nil
+	})
+	return result, err
+}
+
+func TestHandler_PreparedStatements(t *testing.T) {
+	require := require.New(t)
+	h, conn := setupPreparedHandler(t)
+
+	insert, fields := prepareStmt(t, h, conn, "INSERT INTO t (id, name, age) VALUES (?, ?, ?)")
+	require.Equal(uint16(3), insert.ParamsCount)
+	require.Empty(fields)
+
+	for _, args := range [][]*query.BindVariable{
+		{sqltypes.Int64BindVariable(1), sqltypes.StringBindVariable("Alice"), sqltypes.Int64BindVariable(25)},
+		{sqltypes.Int64BindVariable(2), sqltypes.StringBindVariable("Bob"), sqltypes.Int64BindVariable(30)},
+		{sqltypes.Int64BindVariable(3), sqltypes.ValueBindVariable(sqltypes.NULL), sqltypes.ValueBindVariable(sqltypes.NULL)},
+	} {
+		r, err := execStmt(h, conn, insert, args...)
+		require.NoError(err)
+		require.Empty(r.Fields)
+		require.Equal(uint64(1), r.RowsAffected)
+	}
+
+	sel, fields := prepareStmt(t, h, conn, "SELECT name, age FROM t WHERE id = ?")
+	require.Equal(uint16(1), sel.ParamsCount)
+	require.Len(fields, 2)
+	require.Equal("name", fields[0].Name)
+	require.Equal("age", fields[1].Name)
+
+	r, err := execStmt(h, conn, sel, sqltypes.Int64BindVariable(1))
+	require.NoError(err)
+	require.Equal([][]sqltypes.Value{{sqltypes.MakeTrusted(sqltypes.Text, []byte("Alice")), sqltypes.NewInt32(25)}}, r.Rows)
+
+	r, err = execStmt(h, conn, sel, sqltypes.Int64BindVariable(3))
+	require.NoError(err)
+	require.Equal([][]sqltypes.Value{{sqltypes.NULL, sqltypes.NULL}}, r.Rows)
+
+	update, _ := prepareStmt(t, h, conn, "UPDATE t SET age = ? WHERE id = ?")
+	r, err = execStmt(h, conn, update, sqltypes.Int64BindVariable(26), sqltypes.Int64BindVariable(1))
+	require.NoError(err)
+	require.Equal(uint64(1), r.RowsAffected)
+
+	r, err = execStmt(h, conn, sel, sqltypes.Int64BindVariable(1))
+	require.NoError(err)
+	require.Equal([][]sqltypes.Value{{sqltypes.MakeTrusted(sqltypes.Text, []byte("Alice")), sqltypes.NewInt32(26)}}, r.Rows)
+
+	_, err = execStmt(h, conn, sel)
+	require.Error(err)
+}
+
+func TestHandler_PreparedStatementClose(t *testing.T) {
+	require := require.New(t)
+	h, conn := setupPreparedHandler(t)
+
+	stmt, _ := prepareStmt(t, h, conn, "SELECT ? + 1")
+	other, _ := prepareStmt(t, h, conn, "SELECT 1")
+	require.Len(h.prepared[conn.ConnectionID], 2)
+
+	// COM_STMT_CLOSE only removes the statement from the connection.
+	delete(conn.PrepareData, stmt.StatementID)
+
+	_, err := execStmt(h, conn, stmt, sqltypes.Int64BindVariable(1))
+	require.Error(err)
+	sqlErr, ok := err.(*mysql.SQLError)
+	require.True(ok)
+	require.Equal(ERUnknownStmtHandler, sqlErr.Num)
+	require.Len(h.prepared[conn.ConnectionID], 1)
+
+	r, err := execStmt(h, conn, other)
+	require.NoError(err)
+	require.Len(r.Rows, 1)
+
+	h.ConnectionClosed(conn)
+	require.Empty(h.prepared)
+}
+
+func TestHandler_PreparedStatementIn(t *testing.T) {
+	require := require.New(t)
+	h, conn := setupPreparedHandler(t)
+
+	insert, _ := prepareStmt(t, h, conn, "INSERT INTO t (id, name) VALUES (?, ?)")
+	for i, name := range []string{"a", "b", "c", "d", "e"} {
+		_, err := execStmt(h, conn, insert, sqltypes.Int64BindVariable(int64(i+1)), sqltypes.StringBindVariable(name))
+		require.NoError(err)
+	}
+
+	sel, fields := prepareStmt(t, h, conn, "SELECT name FROM t WHERE id IN (?, ?, ?) ORDER BY id")
+	require.Equal(uint16(3), sel.ParamsCount)
+	require.Len(fields, 1)
+
+	// The statement is parsed once, and each execution binds its plan.
+	require.NotNil(h.prepared[conn.ConnectionID][sel.StatementID].parsed)
+
+	names := func(r *sqltypes.Result) []string {
+		var names []string
+		for _, row := range r.Rows {
+			names = append(names, row[0].ToString())
+		}
+		return names
+	}
+
+	r, err := execStmt(h, conn, sel, sqltypes.Int64BindVariable(1), sqltypes.Int64BindVariable(3), sqltypes.Int64BindVariable(5))
+	require.NoError(err)
+	require.Equal([]string{"a", "c", "e"}, names(r))
+
+	r, err = execStmt(h, conn, sel, sqltypes.Int64BindVariable(2), sqltypes.Int64BindVariable(9), sqltypes.Int64BindVariable(2))
+	require.NoError(err)
+	require.Equal([]string{"b"}, names(r))
+}
+
+// The plans of SELECT statements have the sql_select_limit of the session,
+// so they are parsed again when it changes.
+func TestHandler_PreparedStatementSelectLimit(t *testing.T) {
+	require := require.New(t)
+	h, conn := setupPreparedHandler(t)
+
+	insert, _ := prepareStmt(t, h, conn, "INSERT INTO t (id) VALUES (?)")
+	for i := 1; i <= 3; i++ {
+		_, err := execStmt(h, conn, insert, sqltypes.Int64BindVariable(int64(i)))
+		require.NoError(err)
+	}
+
+	sel, _ := prepareStmt(t, h, conn, "SELECT id FROM t WHERE id > ?")
+	r, err := execStmt(h, conn, sel, sqltypes.Int64BindVariable(0))
+	require.NoError(err)
+	require.Len(r.Rows, 3)
+
+	require.NoError(h.ComQuery(context.Background(), conn, "SET sql_select_limit = 1", func(*sqltypes.Result, bool) error { return nil }))
+	r, err = execStmt(h, conn, sel, sqltypes.Int64BindVariable(0))
+	require.NoError(err)
+	require.Len(r.Rows, 1)
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+type processListRow struct {
+	id      int64
+	user    string
+	db      string
+	command string
+	info    sql.NullString
+}
+
+func showProcessList(t *testing.T, conn *sql.Conn) map[int64]processListRow {
+	rows, err := conn.QueryContext(context.Background(), "SHOW PROCESSLIST")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	procs := make(map[int64]processListRow)
+	for rows.Next() {
+		var (
+			r           processListRow
+			host, state string
+			secs        int64
+		)
+		require.NoError(t, rows.Scan(&r.id, &r.user, &host, &r.db, &r.command, &secs, &state, &r.info))
+		procs[r.id] = r
+	}
+	require.NoError(t, rows.Err())
+	return procs
+}
+
+func TestServer_ProcessListAndKill(t *testing.T) {
+	handler, addr := startHandlerListener(t, time.Hour)
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", addr))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn1, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn1.Close()
+	conn2, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn2.Close()
+
+	var id1, id2 int64
+	require.NoError(t, conn1.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id1))
+	require.NoError(t, conn2.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id2))
+
+	procs := showProcessList(t, conn1)
+	require.Len(t, procs, 2)
+	require.Equal(t, processListRow{
+		id: id1, user: "root", db: "testdb", command: "Query",
+		info: sql.NullString{String: "SHOW PROCESSLIST", Valid: true},
+	}, procs[id1])
+	require.Equal(t, processListRow{id: id2, user: "root", db: "testdb", command: "Sleep"}, procs[id2])
+
+	_, err = conn1.ExecContext(ctx, fmt.Sprintf("KILL %d", id2))
+	require.NoError(t, err)
+
+	require.Error(t, conn2.PingContext(ctx))
+	require.Eventually(t, func() bool {
+		return handler.openConns() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	procs = showProcessList(t, conn1)
+	require.Len(t, procs, 1)
+	require.Contains(t, procs, id1)
+
+	// The connection is gone, so it can't be killed again.
+	_, err = conn1.ExecContext(ctx, fmt.Sprintf("KILL CONNECTION %d", id2))
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(t, err, &mysqlErr)
+	require.Equal(t, uint16(ERNoSuchThread), mysqlErr.Number)
+}
@@ -0,0 +1,461 @@
+package server
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// maxCachedRows is the most rows a result may have to be cached, so a
+// single large result doesn't take the memory of the whole cache.
+const maxCachedRows = 10000
+
+// allTables stands for every table when tables are invalidated or held,
+// as it's not a valid qualified table name.
+const allTables = "*"
+
+// QueryCache is a LRU cache of the results of SELECT queries. Results are
+// kept by the query, the user and the current database, along with the
+// tables they read, and they are dropped when any of those tables is
+// written to.
+//
+// The tables written in a transaction are held by its session until it
+// ends, so the results read while it's open are not cached, as they would
+// be stale once it's committed.
+type QueryCache struct {
+	mu       sync.Mutex
+	capacity int
+	lru      *list.List                     // Entries by last use, the most recent first
+	entries  map[string]*list.Element       // Entries by key
+	tables   map[string]map[string]struct{} // Keys of the entries by the tables they read
+	held     map[string]int                 // Sessions holding each table
+	holders  map[uint32]map[string]struct{} // Tables held by each session
+	// generation changes whenever entries are invalidated, so the results
+	// of queries that were running at the time are not cached.
+	generation uint64
+	hits       uint64
+	misses     uint64
+}
+
+type cacheEntry struct {
+	key    string
+	tables []string
+	result *sqltypes.Result
+}
+
+// QueryCacheStats are the figures of the lookups in a QueryCache.
+type QueryCacheStats struct {
+	// Entries is the number of results cached.
+	Entries int
+	// Hits and Misses are the number of lookups that found a result and
+	// that didn't.
+	Hits, Misses uint64
+}
+
+// NewQueryCache creates a cache that keeps up to capacity results.
+func NewQueryCache(capacity int) *QueryCache {
+	return &QueryCache{
+		capacity: capacity,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		tables:   make(map[string]map[string]struct{}),
+		held:     make(map[string]int),
+		holders:  make(map[uint32]map[string]struct{}),
+	}
+}
+
+// Get returns the result cached with key, if any.
+func (c *QueryCache) Get(key string) (*sqltypes.Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(e)
+	return e.Value.(*cacheEntry).result, true
+}
+
+// Generation returns the current generation of the cache, which must be
+// taken before a query is executed and given to Put along with its result.
+func (c *QueryCache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// Put caches the result of a query that read the given tables. The result
+// is not cached if entries were invalidated since generation was taken, or
+// if any of the tables is held.
+func (c *QueryCache) Put(key string, tables []string, result *sqltypes.Result, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation || c.held[allTables] > 0 {
+		return
+	}
+	for _, t := range tables {
+		if c.held[t] > 0 {
+			return
+		}
+	}
+
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, tables: tables, result: result})
+	for _, t := range tables {
+		keys, ok := c.tables[t]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tables[t] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	for c.lru.Len() > c.capacity {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Invalidate drops the results that read any of the given tables.
+func (c *QueryCache) Invalidate(tables ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidate(tables)
+}
+
+// Hold invalidates the given tables and keeps the results that read them
+// from being cached until the session releases them.
+func (c *QueryCache) Hold(session uint32, tables ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	held, ok := c.holders[session]
+	if !ok {
+		held = make(map[string]struct{})
+		c.holders[session] = held
+	}
+	for _, t := range tables {
+		if _, ok := held[t]; !ok {
+			held[t] = struct{}{}
+			c.held[t]++
+		}
+	}
+	c.invalidate(tables)
+}
+
+// Release releases the tables held by the session and invalidates them, as
+// the writes made to them become visible to the other sessions.
+func (c *QueryCache) Release(session uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	held, ok := c.holders[session]
+	if !ok {
+		return
+	}
+	delete(c.holders, session)
+
+	tables := make([]string, 0, len(held))
+	for t := range held {
+		tables = append(tables, t)
+		if c.held[t]--; c.held[t] == 0 {
+			delete(c.held, t)
+		}
+	}
+	c.invalidate(tables)
+}
+
+// Stats returns the current figures of the cache.
+func (c *QueryCache) Stats() QueryCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return QueryCacheStats{Entries: c.lru.Len(), Hits: c.hits, Misses: c.misses}
+}
+
+func (c *QueryCache) invalidate(tables []string) {
+	c.generation++
+	for _, t := range tables {
+		if t == allTables {
+			c.lru.Init()
+			c.entries = make(map[string]*list.Element)
+			c.tables = make(map[string]map[string]struct{})
+			return
+		}
+	}
+
+	for _, t := range tables {
+		for key := range c.tables[t] {
+			c.remove(c.entries[key])
+		}
+	}
+}
+
+func (c *QueryCache) remove(e *list.Element) {
+	entry := c.lru.Remove(e).(*cacheEntry)
+	delete(c.entries, entry.key)
+	for _, t := range entry.tables {
+		delete(c.tables[t], entry.key)
+		if len(c.tables[t]) == 0 {
+			delete(c.tables, t)
+		}
+	}
+}
+
+// systemDatabases are the databases whose tables describe the state of the
+// server, which changes without them being written to.
+var systemDatabases = map[string]bool{
+	"information_schema": true,
+	"mysql":              true,
+	"performance_schema": true,
+	"sys":                true,
+}
+
+// volatileFunctions are the functions whose results may differ between
+// executions of the same query on the same data.
+var volatileFunctions = map[string]bool{
+	"connection_id":     true,
+	"current_date":      true,
+	"current_time":      true,
+	"current_timestamp": true,
+	"current_user":      true,
+	"curdate":           true,
+	"curtime":           true,
+	"found_rows":        true,
+	"last_insert_id":    true,
+	"localtime":         true,
+	"localtimestamp":    true,
+	"now":               true,
+	"rand":              true,
+	"row_count":         true,
+	"session_user":      true,
+	"sleep":             true,
+	"sysdate":           true,
+	"system_user":       true,
+	"unix_timestamp":    true,
+	"user":              true,
+	"utc_date":          true,
+	"utc_time":          true,
+	"utc_timestamp":     true,
+	"uuid":              true,
+	"uuid_short":        true,
+}
+
+// tableName returns the qualified name of a table, which is in db unless
+// it's qualified, or false if it has no database.
+func tableName(t sqlparser.TableName, db string) (string, bool) {
+	if q := t.DbQualifier.String(); q != "" {
+		db = q
+	}
+	if db == "" {
+		return "", false
+	}
+	return strings.ToLower(db + "." + t.Name.String()), true
+}
+
+// readTables returns the tables a SELECT reads, or false if its result
+// can't be cached: it locks rows, stores its result, reads system tables
+// or variables, or calls a volatile function.
+func readTables(stmt sqlparser.SelectStatement, db string) ([]string, bool) {
+	var tables []string
+	cacheable := true
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.Select:
+			if n.Lock != "" || n.Into != nil || n.QueryOpts.SQLNoCache {
+				cacheable = false
+			}
+		case *sqlparser.SetOp:
+			if n.Lock != "" || n.Into != nil {
+				cacheable = false
+			}
+		case *sqlparser.AliasedTableExpr:
+			t, ok := n.Expr.(sqlparser.TableName)
+			if !ok || (t.DbQualifier.IsEmpty() && strings.EqualFold(t.Name.String(), "dual")) {
+				break
+			}
+			name, ok := tableName(t, db)
+			if !ok || systemDatabases[strings.SplitN(name, ".", 2)[0]] {
+				cacheable = false
+				break
+			}
+			tables = append(tables, name)
+		case *sqlparser.ColName:
+			if strings.HasPrefix(n.Name.String(), "@") {
+				cacheable = false
+			}
+		case *sqlparser.FuncExpr:
+			if volatileFunctions[n.Name.Lowered()] {
+				cacheable = false
+			}
+		}
+		return cacheable, nil
+	}, stmt)
+	return tables, cacheable
+}
+
+// writtenTables returns the tables a statement may write to, or allTables
+// if it's not known which ones. Statements that don't write return none.
+func writtenTables(stmt sqlparser.Statement, db string) []string {
+	var exprs sqlparser.TableExprs
+	switch n := stmt.(type) {
+	case *sqlparser.Select, *sqlparser.SetOp, *sqlparser.Show, *sqlparser.Explain,
+		*sqlparser.Set, *sqlparser.Use, *sqlparser.Begin, *sqlparser.Commit, *sqlparser.Rollback:
+		return nil
+	case *sqlparser.Insert:
+		name, ok := tableName(n.Table, db)
+		if !ok {
+			return nil
+		}
+		return []string{name}
+	case *sqlparser.Update:
+		exprs = n.TableExprs
+	case *sqlparser.Delete:
+		exprs = n.TableExprs
+	default:
+		return []string{allTables}
+	}
+
+	var tables []string
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if n, ok := node.(*sqlparser.AliasedTableExpr); ok {
+			if t, ok := n.Expr.(sqlparser.TableName); ok {
+				if name, ok := tableName(t, db); ok {
+					tables = append(tables, name)
+				}
+			}
+		}
+		return true, nil
+	}, exprs)
+	return tables
+}
+
+// cachedQuery is a SELECT whose result can be cached.
+type cachedQuery struct {
+	key        string
+	tables     []string
+	generation uint64
+	result     *sqltypes.Result
+	tooLarge   bool // Whether the result has more than maxCachedRows
+}
+
+// cacheableQuery returns the query the result of stmt is cached as, or nil
+// if it can't be cached. Results are not cached inside transactions, which
+// must see their own writes. Unqualified tables are in the current database
+// of the catalog, which is the one the analyzer resolves them in. Prepared
+// statements have their parameters in stmt, so they are cached as their
+// query, which has the values bound to them.
+func (h *Handler) cacheableQuery(user string, sess *Session, stmt sqlparser.Statement, prepared bool, query string) *cachedQuery {
+	if sess == nil || sess.GetTransaction() != nil {
+		return nil
+	}
+
+	sel, ok := stmt.(sqlparser.SelectStatement)
+	if !ok {
+		return nil
+	}
+
+	db := h.e.Catalog.CurrentDatabase()
+	tables, ok := readTables(sel, db)
+	if !ok {
+		return nil
+	}
+
+	if !prepared {
+		query = sqlparser.String(stmt)
+	}
+
+	return &cachedQuery{
+		key:        user + "\x00" + db + "\x00" + query,
+		tables:     tables,
+		generation: h.cache.Generation(),
+	}
+}
+
+// record returns a callback that sends the results to callback and keeps
+// their rows, so they can be cached once they've all been sent.
+func (q *cachedQuery) record(callback mysql.ResultSpoolFn) mysql.ResultSpoolFn {
+	return func(r *sqltypes.Result, more bool) error {
+		if q.result == nil {
+			q.result = &sqltypes.Result{Fields: r.Fields}
+		}
+		if !q.tooLarge && len(q.result.Rows)+len(r.Rows) <= maxCachedRows {
+			q.result.Rows = append(q.result.Rows, r.Rows...)
+		} else {
+			q.tooLarge = true
+			q.result.Rows = nil
+		}
+		return callback(r, more)
+	}
+}
+
+// store caches the result recorded, unless it had too many rows.
+func (q *cachedQuery) store(c *QueryCache) {
+	if q.result == nil || q.tooLarge {
+		return
+	}
+	q.result.RowsAffected = uint64(len(q.result.Rows))
+	c.Put(q.key, q.tables, q.result, q.generation)
+}
+
+// sendCachedResult sends a cached result in batches, the same as
+// StreamResult.
+func (h *Handler) sendCachedResult(result *sqltypes.Result, callback mysql.ResultSpoolFn) error {
+	batchSize := h.batchSize
+	if batchSize <= 0 {
+		batchSize = DefaultResultBatchSize
+	}
+
+	rows := result.Rows
+	for {
+		n := len(rows)
+		if n > batchSize {
+			n = batchSize
+		}
+		more := n < len(rows)
+		r := &sqltypes.Result{Fields: result.Fields, Rows: rows[:n], RowsAffected: uint64(n)}
+		if err := callback(r, more); err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+		rows = rows[n:]
+	}
+}
+
+// holdWrittenTables holds the tables stmt may write to until it's done,
+// or until the transaction it's part of ends. The returned function must
+// be called once it's done.
+func (h *Handler) holdWrittenTables(id uint32, sess *Session, stmt sqlparser.Statement) func() {
+	tables := []string{allTables}
+	if stmt != nil {
+		tables = writtenTables(stmt, h.e.Catalog.CurrentDatabase())
+	}
+	if len(tables) == 0 {
+		return func() {}
+	}
+
+	h.cache.Hold(id, tables...)
+	return func() {
+		if sess == nil || sess.GetTransaction() == nil {
+			h.cache.Release(id)
+		}
+	}
+}
+
+// releaseWrittenTables releases the tables the session held for its
+// transaction, once it has ended.
+func (h *Handler) releaseWrittenTables(id uint32) {
+	if h.cache != nil {
+		h.cache.Release(id)
+	}
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/stretchr/testify/require"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/transaction"
+	badgerengine "github.com/turtacn/guocedb/storage/engines/badger"
+)
+
+func TestQueryCache(t *testing.T) {
+	c := NewQueryCache(2)
+	r := &sqltypes.Result{Rows: [][]sqltypes.Value{{sqltypes.NewInt64(1)}}}
+
+	c.Put("a", []string{"db.t"}, r, c.Generation())
+	c.Put("b", []string{"db.u"}, r, c.Generation())
+	_, ok := c.Get("a")
+	require.True(t, ok)
+
+	// The least recently used result makes room for the new one.
+	c.Put("c", []string{"db.t", "db.u"}, r, c.Generation())
+	_, ok = c.Get("b")
+	require.False(t, ok)
+	require.Equal(t, QueryCacheStats{Entries: 2, Hits: 1, Misses: 1}, c.Stats())
+
+	c.Invalidate("db.u")
+	_, ok = c.Get("a")
+	require.True(t, ok)
+	_, ok = c.Get("c")
+	require.False(t, ok)
+
+	// Results of queries that were running when entries were invalidated
+	// are not cached.
+	gen := c.Generation()
+	c.Invalidate("db.v")
+	c.Put("d", nil, r, gen)
+	_, ok = c.Get("d")
+	require.False(t, ok)
+
+	c.Invalidate(allTables)
+	require.Equal(t, 0, c.Stats().Entries)
+}
+
+func TestQueryCache_Hold(t *testing.T) {
+	c := NewQueryCache(10)
+	r := &sqltypes.Result{}
+
+	c.Put("a", []string{"db.t"}, r, c.Generation())
+	c.Hold(1, "db.t")
+	_, ok := c.Get("a")
+	require.False(t, ok)
+
+	// The results reading held tables are not cached, the others are.
+	c.Put("a", []string{"db.t"}, r, c.Generation())
+	c.Put("b", []string{"db.u"}, r, c.Generation())
+	_, ok = c.Get("a")
+	require.False(t, ok)
+	_, ok = c.Get("b")
+	require.True(t, ok)
+
+	c.Hold(2, "db.t")
+	c.Release(1)
+	c.Put("a", []string{"db.t"}, r, c.Generation())
+	_, ok = c.Get("a")
+	require.False(t, ok)
+
+	c.Release(2)
+	c.Put("a", []string{"db.t"}, r, c.Generation())
+	_, ok = c.Get("a")
+	require.True(t, ok)
+
+	c.Hold(3, allTables)
+	c.Put("b", []string{"db.u"}, r, c.Generation())
+	_, ok = c.Get("b")
+	require.False(t, ok)
+}
+
+func TestReadTables(t *testing.T) {
+	tests := []struct {
+		query  string
+		tables []string
+	}{
+		{"SELECT * FROM t", []string{"db.t"}},
+		{"SELECT * FROM T JOIN other.u ON T.id = u.id", []string{"db.t", "other.u"}},
+		{"SELECT a FROM t WHERE a IN (SELECT b FROM u)", []string{"db.t", "db.u"}},
+		{"SELECT 1", nil},
+		{"SELECT 1 FROM dual", nil},
+		{"SELECT * FROM t FOR UPDATE", nil},
+		{"SELECT SQL_NO_CACHE * FROM t", nil},
+		{"SELECT NOW() FROM t", nil},
+		{"SELECT RAND()", nil},
+		{"SELECT @@max_connections", nil},
+		{"SELECT @a FROM t", nil},
+		{"SELECT * FROM information_schema.tables", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			stmt, err := sqlparser.Parse(tt.query)
+			require.NoError(t, err)
+
+			tables, ok := readTables(stmt.(sqlparser.SelectStatement), "db")
+			if tt.tables == nil && !ok {
+				return
+			}
+			require.True(t, ok)
+			require.ElementsMatch(t, tt.tables, tables)
+		})
+	}
+
+	stmt, err := sqlparser.Parse("SELECT * FROM t")
+	require.NoError(t, err)
+	_, ok := readTables(stmt.(sqlparser.SelectStatement), "")
+	require.False(t, ok)
+}
+
+func TestWrittenTables(t *testing.T) {
+	tests := []struct {
+		query  string
+		tables []string
+	}{
+		{"INSERT INTO t VALUES (1)", []string{"db.t"}},
+		{"INSERT INTO other.t SELECT * FROM u", []string{"other.t"}},
+		{"UPDATE t SET a = 1", []string{"db.t"}},
+		{"DELETE FROM other.t WHERE a = 1", []string{"other.t"}},
+		{"SELECT * FROM t", nil},
+		{"SET autocommit = 0", nil},
+		{"CREATE TABLE t (a INT)", []string{allTables}},
+		{"DROP TABLE t", []string{allTables}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			stmt, err := sqlparser.Parse(tt.query)
+			require.NoError(t, err)
+			require.Equal(t, tt.tables, writtenTables(stmt, "db"))
+		})
+	}
+}
+
+// startQueryCacheListener starts a listener that caches query results,
+// with the tables t, which has 200 rows, and u in the cachedb database.
+func startQueryCacheListener(t *testing.T) (*Handler, *sql.DB, sqlengine.Table) {
+	kv, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	t.Cleanup(func() { kv.Close() })
+
+	handler, addr := startHandlerListener(t, 0)
+	handler.cache = NewQueryCache(16)
+	handler.txnManager = transaction.NewManagerWithDB(kv)
+	database := badgerengine.NewDatabase("cachedb", kv)
+	handler.e.Catalog.AddDatabase(database)
+
+	schema := sqlengine.Schema{{Name: "id", Type: sqlengine.Int64, Source: "t", PrimaryKey: true}}
+	require.NoError(t, database.Create("t", schema))
+	require.NoError(t, database.Create("u", schema))
+	table, _, err := database.GetTableInsensitive(nil, "t")
+	require.NoError(t, err)
+	insertRows(t, table, 0, 200)
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/cachedb", addr))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return handler, db, table
+}
+
+// insertRows inserts the rows with ids from to to directly into the table,
+// without going through the handler.
+func insertRows(t *testing.T, table sqlengine.Table, from, to int64) {
+	ctx := sqlengine.NewEmptyContext()
+	inserter := table.(sqlengine.InsertableTable).Inserter(ctx)
+	inserter.StatementBegin(ctx)
+	for id := from; id < to; id++ {
+		require.NoError(t, inserter.Insert(ctx, sqlengine.NewRow(id)))
+	}
+	require.NoError(t, inserter.StatementComplete(ctx))
+	require.NoError(t, inserter.Close(ctx))
+}
+
+func countRows(t *testing.T, db interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}, query string) int {
+	var n int
+	require.NoError(t, db.QueryRow(query).Scan(&n))
+	return n
+}
+
+func TestServer_QueryCache(t *testing.T) {
+	handler, db, table := startQueryCacheListener(t)
+
+	const query = "SELECT COUNT(*) FROM cachedb.t a, cachedb.t b"
+	start := time.Now()
+	require.Equal(t, 40000, countRows(t, db, query))
+	executed := time.Since(start)
+
+	// The same query, even written differently, is not executed again: the
+	// rows inserted behind the handler's back are not counted.
+	insertRows(t, table, 200, 201)
+	start = time.Now()
+	require.Equal(t, 40000, countRows(t, db, "select COUNT(*)   from cachedb.t a, cachedb.t b"))
+	require.Less(t, time.Since(start), executed)
+	require.Equal(t, uint64(1), handler.cache.Stats().Hits)
+
+	require.Equal(t, 0, countRows(t, db, "SELECT COUNT(*) FROM cachedb.u"))
+	require.Equal(t, 2, handler.cache.Stats().Entries)
+
+	// Writing to a table drops the results that read it, and only those.
+	_, err := db.Exec("INSERT INTO cachedb.t VALUES (1000)")
+	require.NoError(t, err)
+	require.Equal(t, 1, handler.cache.Stats().Entries)
+	require.Equal(t, 202*202, countRows(t, db, query))
+
+	_, err = db.Exec("DELETE FROM cachedb.u")
+	require.NoError(t, err)
+	require.Equal(t, 1, handler.cache.Stats().Entries)
+}
+
+func TestServer_QueryCacheTransaction(t *testing.T) {
+	handler, db, table := startQueryCacheListener(t)
+
+	const query = "SELECT COUNT(*) FROM cachedb.t"
+	require.Equal(t, 200, countRows(t, db, query))
+	insertRows(t, table, 200, 201)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Queries in a transaction are executed, not answered from the cache,
+	// so they see the writes made in it.
+	_, err = conn.ExecContext(ctx, "BEGIN")
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, conn.QueryRowContext(ctx, query).Scan(&n))
+	require.Equal(t, 201, n)
+	require.Equal(t, uint64(0), handler.cache.Stats().Hits)
+
+	_, err = conn.ExecContext(ctx, "INSERT INTO cachedb.t VALUES (1000)")
+	require.NoError(t, err)
+	require.Equal(t, 0, handler.cache.Stats().Entries)
+
+	// The results read while the transaction is open are not cached, as
+	// they'd be stale once it's committed.
+	countRows(t, db, query)
+	require.Equal(t, 0, handler.cache.Stats().Entries)
+
+	_, err = conn.ExecContext(ctx, "COMMIT")
+	require.NoError(t, err)
+	require.Equal(t, 202, countRows(t, db, query))
+	require.Equal(t, 1, handler.cache.Stats().Entries)
+}
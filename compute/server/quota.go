@@ -0,0 +1,117 @@
+package server
+
+import (
+	"net"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// QuotaChecker limits the resources each user may use. The errors it returns
+// are sent to the clients as ER_USER_LIMIT_REACHED errors.
+type QuotaChecker interface {
+	// AcquireConnection accounts for a new connection of the user, or
+	// returns an error if the user may not open more.
+	AcquireConnection(user string) error
+	// ReleaseConnection accounts for a connection of the user being
+	// closed.
+	ReleaseConnection(user string)
+	// AllowQuery accounts for a new query of the user, or returns an error
+	// if the user may not run it.
+	AllowQuery(user string) error
+	// MaxRowsPerQuery returns the number of rows a query of the user may
+	// return, or zero if there is no limit.
+	MaxRowsPerQuery(user string) int64
+}
+
+// userLimitError converts an error of a QuotaChecker to a MySQL error.
+func userLimitError(err error) error {
+	return mysql.NewSQLError(ERUserLimitReached, SSClientError, "%s", err.Error())
+}
+
+// acquireConnection accounts for the connection in the quota of its user.
+// The connection is released when it's closed.
+func (h *Handler) acquireConnection(c *mysql.Conn, user string) error {
+	if err := h.quotas.AcquireConnection(user); err != nil {
+		return userLimitError(err)
+	}
+
+	h.mu.Lock()
+	h.quotaConns[c.ConnectionID] = user
+	h.mu.Unlock()
+	return nil
+}
+
+// releaseConnection releases the connection from the quota of its user, if
+// it was acquired.
+func (h *Handler) releaseConnection(c *mysql.Conn) {
+	h.mu.Lock()
+	user, ok := h.quotaConns[c.ConnectionID]
+	delete(h.quotaConns, c.ConnectionID)
+	h.mu.Unlock()
+
+	if ok {
+		h.quotas.ReleaseConnection(user)
+	}
+}
+
+// quotaAuth wraps an auth server so the connections of each user are
+// limited by the quotas of a handler. The listener reports new connections
+// before the client authenticates, so they are accounted for once the user
+// is known.
+type quotaAuth struct {
+	mysql.AuthServer
+	h *Handler
+}
+
+// AuthMethods implements mysql.AuthServer.
+func (a quotaAuth) AuthMethods() []mysql.AuthMethod {
+	methods := a.AuthServer.AuthMethods()
+	limited := make([]mysql.AuthMethod, len(methods))
+	for i, m := range methods {
+		limited[i] = quotaMethod{m, a.h}
+	}
+	return limited
+}
+
+// quotaMethod is an auth method that refuses the users that have as many
+// connections as their quotas allow.
+type quotaMethod struct {
+	mysql.AuthMethod
+	h *Handler
+}
+
+// HandleAuthPluginData implements mysql.AuthMethod.
+func (m quotaMethod) HandleAuthPluginData(c *mysql.Conn, user string, serverAuthPluginData []byte, clientAuthPluginData []byte, remoteAddr net.Addr) (mysql.Getter, error) {
+	getter, err := m.AuthMethod.HandleAuthPluginData(c, user, serverAuthPluginData, clientAuthPluginData, remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.h.acquireConnection(c, user); err != nil {
+		return nil, err
+	}
+	return getter, nil
+}
+
+// rowLimitIter fails once its iterator returns more rows than allowed.
+type rowLimitIter struct {
+	sql.RowIter
+	user  string
+	limit int64
+	n     int64
+}
+
+func (i *rowLimitIter) Next() (sql.Row, error) {
+	row, err := i.RowIter.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	i.n++
+	if i.n > i.limit {
+		return nil, mysql.NewSQLError(ERUserLimitReached, SSClientError,
+			"User '%s' has exceeded the 'max_rows_per_query' resource (current value: %d)", i.user, i.limit)
+	}
+	return row, nil
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dolthub/vitess/go/mysql"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/security"
+	"github.com/turtacn/guocedb/security/audit"
+)
+
+// startQuotaServer starts a server whose root user has the given quota.
+func startQuotaServer(t *testing.T, q security.Quota) *Server {
+	sm, err := security.NewSecurityManager(security.SecurityConfig{
+		Enabled:     true,
+		AuditConfig: audit.AuditConfig{FilePath: filepath.Join(t.TempDir(), "audit.log")},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { sm.Close() })
+	require.NoError(t, sm.SetQuota(context.Background(), "root", q))
+
+	db := newMockDatabase("testdb")
+	db.tables["nums"] = newMockTable("nums", sqlengine.Schema{
+		{Name: "n", Type: sqlengine.Int64, Source: "nums"},
+	}, []sqlengine.Row{{int64(1)}, {int64(2)}, {int64(3)}})
+	catalog := sqlengine.NewCatalog()
+	catalog.AddDatabase(db)
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "127.0.0.1:0",
+		Auth:     auth.NewNativeSingle("root", "", auth.AllPermissions),
+		Quotas:   sm,
+	}, engine)
+	require.NoError(t, err)
+	s.Start()
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func requireUserLimitReached(t *testing.T, err error, resource string) {
+	t.Helper()
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(t, err, &mysqlErr)
+	require.Equal(t, uint16(ERUserLimitReached), mysqlErr.Number)
+	require.Contains(t, mysqlErr.Message, resource)
+}
+
+func TestServer_QuotaMaxConnections(t *testing.T) {
+	s := startQuotaServer(t, security.Quota{MaxConnections: 2})
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", s.Addr()))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	var conns []*sql.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := db.Conn(ctx)
+		require.NoError(t, err)
+		conns = append(conns, conn)
+	}
+
+	_, err = db.Conn(ctx)
+	requireUserLimitReached(t, err, security.ResourceMaxConnections)
+
+	// Closing a connection makes room for another one.
+	require.NoError(t, conns[0].Close())
+	require.Eventually(t, func() bool {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return false
+		}
+		conns[0] = conn
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	for _, c := range conns {
+		c.Close()
+	}
+}
+
+func TestServer_QuotaMaxQueriesPerMinute(t *testing.T) {
+	s := startQuotaServer(t, security.Quota{MaxQueriesPerMinute: 3})
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", s.Addr()))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var one int64
+	for i := 0; i < 3; i++ {
+		require.NoError(t, conn.QueryRowContext(ctx, "SELECT 1").Scan(&one))
+	}
+
+	err = conn.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+	requireUserLimitReached(t, err, security.ResourceMaxQueriesPerMinute)
+}
+
+func TestServer_QuotaMaxRowsPerQuery(t *testing.T) {
+	s := startQuotaServer(t, security.Quota{MaxRowsPerQuery: 2})
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", s.Addr()))
+	require.NoError(t, err)
+	defer db.Close()
+
+	var n int64
+	require.NoError(t, db.QueryRow("SELECT n FROM nums WHERE n < 3 ORDER BY n").Scan(&n))
+
+	rows, err := db.Query("SELECT n FROM nums")
+	if err == nil {
+		for rows.Next() {
+		}
+		err = rows.Err()
+		rows.Close()
+	}
+	requireUserLimitReached(t, err, security.ResourceMaxRowsPerQuery)
+}
+
+func TestRowLimitIter(t *testing.T) {
+	iter := &rowLimitIter{
+		RowIter: &mockRowIter{rows: []sqlengine.Row{{1}, {2}, {3}}},
+		user:    "root",
+		limit:   2,
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := iter.Next()
+		require.NoError(t, err)
+	}
+
+	_, err := iter.Next()
+	sqlErr, ok := err.(*mysql.SQLError)
+	require.True(t, ok)
+	require.Equal(t, ERUserLimitReached, sqlErr.Number())
+}
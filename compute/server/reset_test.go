@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_ComResetConnection(t *testing.T) {
+	require := require.New(t)
+	h, db := startBadgerTestHandler(t)
+	ctx := context.Background()
+
+	_, err := db.Exec("CREATE TABLE t (id BIGINT PRIMARY KEY, name VARCHAR(3))")
+	require.NoError(err)
+
+	conn, err := db.Conn(ctx)
+	require.NoError(err)
+	defer conn.Close()
+
+	var id uint32
+	require.NoError(conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id))
+
+	stmt, err := conn.PrepareContext(ctx, "SELECT COUNT(*) FROM t")
+	require.NoError(err)
+	defer stmt.Close()
+
+	_, err = conn.ExecContext(ctx, "SET autocommit = 0")
+	require.NoError(err)
+	_, err = conn.ExecContext(ctx, "INSERT INTO t (id, name) VALUES (1, 'truncated')")
+	require.NoError(err)
+
+	h.mu.Lock()
+	c := h.c[id]
+	h.mu.Unlock()
+	require.NotNil(c)
+	require.Equal(uint16(1), h.WarningCount(c))
+	h.mu.Lock()
+	require.NotEmpty(h.prepared[id])
+	h.mu.Unlock()
+
+	// The reset is what a pooled connection gets before it's handed to
+	// another user.
+	require.NoError(h.ComResetConnection(c))
+	require.Equal(uint16(0), h.WarningCount(c))
+
+	var autocommit int64
+	require.NoError(conn.QueryRowContext(ctx, "SELECT @@autocommit").Scan(&autocommit))
+	require.Equal(int64(1), autocommit)
+
+	require.Equal("", h.sessionMgr.GetSession(id).GetCurrentDB())
+
+	// The insert was rolled back rather than committed.
+	var n int64
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM t").Scan(&n))
+	require.Equal(int64(0), n)
+	require.NoError(conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM testdb.t").Scan(&n))
+	require.Equal(int64(0), n)
+
+	// The statement prepared before is gone.
+	h.mu.Lock()
+	require.Empty(h.prepared[id])
+	h.mu.Unlock()
+	err = stmt.QueryRowContext(ctx).Scan(&n)
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(err, &mysqlErr)
+}
@@ -43,6 +43,97 @@ func BuildResult(schema sql.Schema, iter sql.RowIter) (*sqltypes.Result, error)
 	}, nil
 }
 
+// DefaultResultBatchSize is the number of rows sent to the client at a time
+// unless configured otherwise.
+const DefaultResultBatchSize = 100
+
+// StreamResult reads the rows of iter and sends them to callback in results
+// of at most batchSize rows, so the whole result set is never held in
+// memory. The same result is reused for every batch, so callback must not
+// keep it or its rows once it returns. The last batch is sent with more set
+// to false. iter is closed once all the rows are read. It returns the number
+// of rows sent.
+func StreamResult(schema sql.Schema, iter sql.RowIter, batchSize int, callback func(r *sqltypes.Result, more bool) error) (n uint64, err error) {
+	defer func() {
+		if cerr := iter.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	if batchSize <= 0 {
+		batchSize = DefaultResultBatchSize
+	}
+
+	r := &sqltypes.Result{
+		Fields: schemaToFields(schema),
+		Rows:   make([][]sqltypes.Value, 0, batchSize),
+	}
+	var sent bool
+	for {
+		row, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+
+		r.Rows = append(r.Rows, rowToSQL(schema, row))
+		r.RowsAffected++
+		n++
+
+		if len(r.Rows) == batchSize {
+			if err := callback(r, true); err != nil {
+				return n, err
+			}
+			sent = true
+			r.Rows = r.Rows[:0]
+			r.RowsAffected = 0
+		}
+	}
+
+	// Even with no rows, the callback must be called once so the fields
+	// are sent to the client.
+	if sent && len(r.Rows) == 0 {
+		return n, nil
+	}
+	return n, callback(r, false)
+}
+
+// resultLimitIter ends the rows of a result set at limit. The ones beyond
+// it fail the query with ER_TOO_BIG_SELECT, unless truncate is set, in which
+// case the result set is cut short with a warning.
+type resultLimitIter struct {
+	sql.RowIter
+	ctx      *sql.Context
+	limit    int64
+	truncate bool
+	n        int64
+}
+
+func (i *resultLimitIter) Next() (sql.Row, error) {
+	if i.n > i.limit {
+		return nil, io.EOF
+	}
+
+	row, err := i.RowIter.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	i.n++
+	if i.n <= i.limit {
+		return row, nil
+	}
+
+	if !i.truncate {
+		return nil, mysql.NewSQLError(ERTooBigSelect, SSClientError,
+			"The result set has more than %d rows, the most allowed by max_result_rows", i.limit)
+	}
+	i.ctx.Warn(ERTooBigSelect, "Result set truncated to %d rows by max_result_rows", i.limit)
+	return nil, io.EOF
+}
+
 // BuildOKResult creates an OK result for DML operations (INSERT/UPDATE/DELETE)
 func BuildOKResult(affectedRows, lastInsertID uint64) *sqltypes.Result {
 	return &sqltypes.Result{
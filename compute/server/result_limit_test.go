@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+// startResultLimitTest starts a server whose result sets have at most 3
+// rows, with a table of 5 rows, and returns a connection to it.
+func startResultLimitTest(t *testing.T, truncate bool) *sql.Conn {
+	handler, db := startBadgerTestHandler(t)
+	handler.maxResultRows = 3
+	handler.truncateResults = truncate
+
+	// Warnings belong to the session, so everything goes through the same
+	// connection.
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	for _, q := range []string{
+		"CREATE TABLE t (id BIGINT PRIMARY KEY)",
+		"INSERT INTO t VALUES (1), (2), (3), (4), (5)",
+	} {
+		_, err := conn.ExecContext(context.Background(), q)
+		require.NoError(t, err, q)
+	}
+	return conn
+}
+
+func queryIDs(t *testing.T, conn *sql.Conn, q string) ([]int64, error) {
+	rows, err := conn.QueryContext(context.Background(), q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func TestE2E_MaxResultRows(t *testing.T) {
+	require := require.New(t)
+	conn := startResultLimitTest(t, false)
+
+	_, err := queryIDs(t, conn, "SELECT id FROM t ORDER BY id")
+	var mysqlErr *mysqldriver.MySQLError
+	require.True(errors.As(err, &mysqlErr), "unexpected error: %v", err)
+	require.Equal(uint16(ERTooBigSelect), mysqlErr.Number)
+	require.Contains(mysqlErr.Message, "more than 3 rows")
+
+	// Result sets within the limit are sent whole.
+	ids, err := queryIDs(t, conn, "SELECT id FROM t WHERE id > 2 ORDER BY id")
+	require.NoError(err)
+	require.Equal([]int64{3, 4, 5}, ids)
+
+	// The rows read to compute a result are not counted.
+	var count int64
+	require.NoError(conn.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM t").Scan(&count))
+	require.Equal(int64(5), count)
+}
+
+func TestE2E_MaxResultRows_Truncate(t *testing.T) {
+	require := require.New(t)
+	conn := startResultLimitTest(t, true)
+
+	ids, err := queryIDs(t, conn, "SELECT id FROM t ORDER BY id")
+	require.NoError(err)
+	require.Equal([]int64{1, 2, 3}, ids)
+
+	var level, message string
+	var code int
+	require.NoError(conn.QueryRowContext(context.Background(), "SHOW WARNINGS").Scan(&level, &code, &message))
+	require.Equal(ERTooBigSelect, code)
+	require.Equal("Result set truncated to 3 rows by max_result_rows", message)
+
+	// No warning is given for result sets within the limit.
+	ids, err = queryIDs(t, conn, "SELECT id FROM t WHERE id < 4 ORDER BY id")
+	require.NoError(err)
+	require.Equal([]int64{1, 2, 3}, ids)
+	rows, err := conn.QueryContext(context.Background(), "SHOW WARNINGS")
+	require.NoError(err)
+	defer rows.Close()
+	require.False(rows.Next())
+}
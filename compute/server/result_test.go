@@ -1,7 +1,10 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"strconv"
 	"testing"
 	"time"
 
@@ -379,3 +382,98 @@ func (m *mockRowIterWithError) Next() (sql.Row, error) {
 func (m *mockRowIterWithError) Close() error {
 	return nil
 }
+
+// generatedRowIter returns n rows made as they are read, so it holds no
+// more than one at a time.
+type generatedRowIter struct {
+	n, pos int
+	closed bool
+}
+
+func (g *generatedRowIter) Next() (sql.Row, error) {
+	if g.pos >= g.n {
+		return nil, io.EOF
+	}
+	g.pos++
+	return sql.NewRow(int64(g.pos), fmt.Sprintf("row %d", g.pos)), nil
+}
+
+func (g *generatedRowIter) Close() error {
+	g.closed = true
+	return nil
+}
+
+func TestStreamResult(t *testing.T) {
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64},
+		{Name: "name", Type: sql.Text},
+	}
+
+	tests := []struct {
+		rows, batchSize int
+		callbacks       int
+	}{
+		{10000, 100, 100},
+		{10001, 100, 101},
+		{50, 100, 1},
+		{0, 100, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d rows", tt.rows), func(t *testing.T) {
+			iter := &generatedRowIter{n: tt.rows}
+
+			var (
+				callbacks int
+				next      int64 = 1
+				last      *sqltypes.Result
+			)
+			n, err := StreamResult(schema, iter, tt.batchSize, func(r *sqltypes.Result, more bool) error {
+				callbacks++
+				require.Len(t, r.Fields, 2)
+				// Only a batch of rows is held at a time, in the same
+				// result every time.
+				require.LessOrEqual(t, len(r.Rows), tt.batchSize)
+				require.Equal(t, tt.batchSize, cap(r.Rows))
+				if last != nil {
+					require.Same(t, last, r)
+				}
+				last = r
+
+				require.Equal(t, uint64(len(r.Rows)), r.RowsAffected)
+				for _, row := range r.Rows {
+					require.Equal(t, strconv.FormatInt(next, 10), row[0].ToString())
+					next++
+				}
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, uint64(tt.rows), n)
+			assert.Equal(t, int64(tt.rows+1), next)
+			assert.Equal(t, tt.callbacks, callbacks)
+			assert.True(t, iter.closed)
+		})
+	}
+}
+
+func TestStreamResult_Error(t *testing.T) {
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64},
+		{Name: "name", Type: sql.Text},
+	}
+
+	_, err := StreamResult(schema, &mockRowIterWithError{}, 10, func(*sqltypes.Result, bool) error {
+		t.Fatal("no rows must be sent")
+		return nil
+	})
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	stop := errors.New("stop")
+	iter := &generatedRowIter{n: 100}
+	_, err = StreamResult(schema, iter, 10, func(*sqltypes.Result, bool) error {
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 10, iter.pos)
+	assert.True(t, iter.closed)
+}
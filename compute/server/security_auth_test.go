@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/security"
+	"github.com/turtacn/guocedb/security/audit"
+)
+
+func TestServer_SecurityAuth(t *testing.T) {
+	const maxAuthFails = 3
+
+	sm, err := security.NewSecurityManager(security.SecurityConfig{
+		Enabled:      true,
+		AuditConfig:  audit.AuditConfig{FilePath: filepath.Join(t.TempDir(), "audit.log")},
+		MaxAuthFails: maxAuthFails,
+		LockDuration: time.Minute,
+	})
+	require.NoError(t, err)
+	defer sm.Close()
+	require.NoError(t, sm.CreateUser(context.Background(), "app", "secret", []string{"readonly"}))
+
+	catalog := sqlengine.NewCatalog()
+	catalog.AddDatabase(newMockDatabase("testdb"))
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "127.0.0.1:0",
+		Auth:     auth.NewSecurity(sm),
+	}, engine)
+	require.NoError(t, err)
+	s.Start()
+	defer s.Close()
+
+	connect := func(password string) error {
+		db, err := sql.Open("mysql", fmt.Sprintf("app:%s@tcp(%s)/testdb", password, s.Addr()))
+		require.NoError(t, err)
+		defer db.Close()
+
+		var one int64
+		return db.QueryRow("SELECT 1").Scan(&one)
+	}
+
+	requireAccessDenied := func(err error, msg string) {
+		t.Helper()
+		var mysqlErr *mysqldriver.MySQLError
+		require.ErrorAs(t, err, &mysqlErr)
+		require.Equal(t, uint16(1045), mysqlErr.Number)
+		require.Contains(t, mysqlErr.Message, msg)
+	}
+
+	require.NoError(t, connect("secret"))
+
+	for i := 0; i < maxAuthFails; i++ {
+		requireAccessDenied(connect("wrong"), "Access denied for user 'app'")
+	}
+
+	// The user is locked out, even with the right password.
+	requireAccessDenied(connect("secret"), "account is locked")
+}
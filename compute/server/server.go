@@ -1,11 +1,16 @@
 package server // import "github.com/turtacn/guocedb/compute/server"
 
 import (
+	"crypto/tls"
+	"errors"
+	"net"
 	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/turtacn/guocedb/compute/executor"
 	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/maintenance/metrics"
+	"github.com/turtacn/guocedb/maintenance/slowlog"
 
 	"github.com/dolthub/vitess/go/mysql"
 )
@@ -13,6 +18,7 @@ import (
 // Server is a MySQL server for SQLe engines.
 type Server struct {
 	Listener *mysql.Listener
+	Handler  *Handler
 }
 
 // Config for the mysql server.
@@ -27,10 +33,113 @@ type Config struct {
 	// no tracer is provided.
 	Tracer opentracing.Tracer
 
-	ConnReadTimeout  time.Duration
+	// ConnReadTimeout is the longest a connection may wait for the next
+	// packet of the client, so idle connections are closed once it passes.
+	// Zero means no timeout.
+	ConnReadTimeout time.Duration
+	// ConnWriteTimeout is the longest a single write to the client may
+	// take. The deadline is renewed for every write, so it doesn't bound
+	// the time needed to stream a whole result. Zero means no timeout.
 	ConnWriteTimeout time.Duration
+
+	// IdleTimeout is the longest a session may go without running a
+	// command. Idle sessions are closed along with their connections, and
+	// their transactions are rolled back. Zero means sessions are never
+	// closed for being idle.
+	IdleTimeout time.Duration
+
+	// MaxExecutionTime is the longest a query may run. Queries running for
+	// longer are interrupted with an error. Zero means no limit.
+	MaxExecutionTime time.Duration
+
+	// LockWaitTimeout is how long a transaction waits for a row locked by
+	// another one, as with SELECT ... FOR UPDATE, before the statement
+	// fails with ER_LOCK_WAIT_TIMEOUT. Zero means
+	// transaction.DefaultLockWaitTimeout.
+	LockWaitTimeout time.Duration
+
+	// ResultBatchSize is the number of rows of a result set sent to the
+	// client at a time. Zero means DefaultResultBatchSize.
+	ResultBatchSize int
+
+	// MaxResultRows is the most rows a result set may have. Queries
+	// returning more fail with ER_TOO_BIG_SELECT, unless TruncateResults is
+	// set. Zero means no limit.
+	MaxResultRows int64
+	// TruncateResults ends the result sets over MaxResultRows at the limit,
+	// with a warning, instead of failing their queries.
+	TruncateResults bool
+
+	// TLSConfig enables TLS: the server advertises it in the handshake and
+	// clients asking for it switch to TLS before they authenticate. Nil
+	// means connections are always in plaintext.
+	TLSConfig *tls.Config
+	// RequireSecureTransport rejects the logins of clients that don't use
+	// TLS. It needs TLSConfig.
+	RequireSecureTransport bool
+
+	// Quotas limits the connections, queries and rows of each user. Nil
+	// means there are no limits.
+	Quotas QuotaChecker
+
+	// Grants keeps the privileges that GRANT and REVOKE give and take on
+	// tables and columns. Nil means the statements are refused.
+	Grants Grantor
+
+	// MaxConnections is the most connections the server keeps open at once.
+	// Connections beyond it are rejected with ER_CON_COUNT_ERROR. It can be
+	// changed while the server runs with SET GLOBAL max_connections. Zero
+	// means no limit.
+	MaxConnections int
+
+	// ConnectRate is the most new connections the server lets in per
+	// second, so the sessions of many clients connecting at once, as after
+	// a restart, are set up over time rather than in a burst. Connections
+	// over the rate wait their turn, and are rejected with
+	// ER_CON_COUNT_ERROR if it doesn't come within ConnectQueueTimeout.
+	// Zero means no limit.
+	ConnectRate float64
+	// ConnectBurst is the most connections let in at once when none came
+	// for a while. Zero means ConnectRate, rounded up.
+	ConnectBurst int
+	// ConnectQueueTimeout is the longest a connection over ConnectRate
+	// waits its turn. Zero means DefaultConnectQueueTimeout.
+	ConnectQueueTimeout time.Duration
+
+	// SlowLog records the statements that take longer than its threshold.
+	// Nil means they are not recorded.
+	SlowLog *slowlog.Logger
+
+	// QueryCacheSize is the number of results of SELECT queries kept to be
+	// sent again when the same queries are run, until the tables they read
+	// are written to. Zero means results are not cached.
+	QueryCacheSize int
+
+	// StatementCacheSize is the number of plans of statements each session
+	// keeps, so the statements run or prepared again, or that only differ
+	// in the values they compare, insert or assign, are not parsed again.
+	// Zero means DefaultStatementCacheSize, and a negative size keeps plans
+	// from being cached.
+	StatementCacheSize int
+
+	// MaxAllowedPacket is the largest packet a client may send, or packets
+	// continuing one another, which bounds the size of its queries and of
+	// the values they have. Clients sending larger ones get
+	// ER_NET_PACKET_TOO_LARGE and are disconnected. It can be changed while
+	// the server runs with SET GLOBAL max_allowed_packet. Zero means
+	// DefaultMaxAllowedPacket.
+	MaxAllowedPacket int
+
+	// StatementMetrics records the latency of the statements by kind of
+	// statement, and their errors by MySQL error code. Nil means they are
+	// not recorded.
+	StatementMetrics *metrics.StatementCollector
 }
 
+// ErrSecureTransportWithoutTLS is returned when secure transport is required
+// but there is no TLS configuration to provide it.
+var ErrSecureTransportWithoutTLS = errors.New("secure transport can't be required without a TLS configuration")
+
 // NewDefaultServer creates a Server with the default session builder.
 func NewDefaultServer(cfg Config, e *executor.Engine) (*Server, error) {
 	return NewServer(cfg, e, DefaultSessionBuilder)
@@ -46,6 +155,10 @@ func NewServer(cfg Config, e *executor.Engine, sb SessionBuilder) (*Server, erro
 		tracer = opentracing.NoopTracer{}
 	}
 
+	if cfg.RequireSecureTransport && cfg.TLSConfig == nil {
+		return nil, ErrSecureTransportWithoutTLS
+	}
+
 	if cfg.ConnReadTimeout < 0 {
 		cfg.ConnReadTimeout = 0
 	}
@@ -59,15 +172,75 @@ func NewServer(cfg Config, e *executor.Engine, sb SessionBuilder) (*Server, erro
 	if cfg.Auth == nil {
 		cfg.Auth = auth.NewNativeSingle("root", "", auth.AllPermissions)
 	}
+	// The queries are checked against the permissions of the users.
+	e.Auth = cfg.Auth
 
 	handler := NewHandler(e, NewSessionManager(sb, tracer, cfg.Address))
+	if cfg.ResultBatchSize > 0 {
+		handler.batchSize = cfg.ResultBatchSize
+	}
+	if cfg.MaxExecutionTime > 0 {
+		handler.queryTimeout = cfg.MaxExecutionTime
+	}
+	if cfg.MaxResultRows > 0 {
+		handler.maxResultRows = cfg.MaxResultRows
+		handler.truncateResults = cfg.TruncateResults
+	}
+	if cfg.LockWaitTimeout > 0 {
+		handler.txnManager.SetLockWaitTimeout(cfg.LockWaitTimeout)
+	}
+	handler.slowLog = cfg.SlowLog
+	handler.statements = cfg.StatementMetrics
+	if cfg.QueryCacheSize > 0 {
+		handler.cache = NewQueryCache(cfg.QueryCacheSize)
+	}
+	if cfg.StatementCacheSize != 0 {
+		handler.stmtCacheSize = cfg.StatementCacheSize
+	}
+	if cfg.MaxConnections > 0 {
+		if err := handler.SetMaxConnections(cfg.MaxConnections); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.MaxAllowedPacket > 0 {
+		if err := handler.SetMaxAllowedPacket(cfg.MaxAllowedPacket); err != nil {
+			return nil, err
+		}
+	}
+
 	a := cfg.Auth.Mysql()
-	l, err := mysql.NewListener(cfg.Protocol, cfg.Address, a, handler, cfg.ConnReadTimeout, cfg.ConnWriteTimeout)
+	if cfg.RequireSecureTransport {
+		a = secureTransportAuth{a}
+	}
+	handler.grants = cfg.Grants
+	if cfg.Quotas != nil {
+		handler.quotas = cfg.Quotas
+		a = quotaAuth{a, handler}
+	}
+	// The packets the clients send are checked against max_allowed_packet
+	// as they're read.
+	nl, err := net.Listen(cfg.Protocol, cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	// New connections are let in at the connect rate, if there is one.
+	if cfg.ConnectRate > 0 {
+		nl = newThrottleListener(nl, cfg.ConnectRate, cfg.ConnectBurst, cfg.ConnectQueueTimeout)
+	}
+	pl := &packetLimitListener{Listener: nl, limit: &handler.maxPacket}
+	l, err := mysql.NewFromListener(pl, a, handler, cfg.ConnReadTimeout, cfg.ConnWriteTimeout)
 	if err != nil {
+		nl.Close()
 		return nil, err
 	}
+	l.TLSConfig = cfg.TLSConfig
+	l.RequireSecureTransport = cfg.RequireSecureTransport
+
+	if cfg.IdleTimeout > 0 {
+		handler.StartReaper(cfg.IdleTimeout)
+	}
 
-	return &Server{Listener: l}, nil
+	return &Server{Listener: l, Handler: handler}, nil
 }
 
 // Start starts accepting connections on the server.
@@ -77,6 +250,7 @@ func (s *Server) Start() {
 
 // Close closes the server connection.
 func (s *Server) Close() error {
+	s.Handler.StopReaper()
 	s.Listener.Close()
 	return nil
 }
@@ -2,9 +2,12 @@ package server
 
 import (
 	"context"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/transaction"
 )
 
 // Session represents a database session with connection state
@@ -13,20 +16,34 @@ type Session struct {
 	currentDB   string
 	user        string
 	client      string
-	vars        map[string]interface{}
+	attrs       map[string]string // Connection attributes the client sent, if any
 	transaction sql.Transaction
-	autoCommit  bool
-	mu          sync.RWMutex
+	// nextIsolation is the isolation level of the next transaction, set by
+	// SET TRANSACTION, if hasNextIsolation is set.
+	nextIsolation    transaction.IsolationLevel
+	hasNextIsolation bool
+	// statements keeps the plans of the statements parsed, if they're
+	// cached.
+	statements *StatementCache
+	// base holds the session variables, which SET changes and @@name
+	// reads, and the warnings of the session.
+	base sql.Session
+	// lastActive is when the session last started or finished a command,
+	// and running is the number of commands it's running.
+	lastActive time.Time
+	running    int
+	mu         sync.RWMutex
 }
 
 // NewSession creates a new session with the given parameters
 func NewSession(id uint32, user, client string) *Session {
 	return &Session{
-		id:         id,
-		user:       user,
-		client:     client,
-		vars:       make(map[string]interface{}),
-		autoCommit: true, // Default to autocommit mode
+		id:     id,
+		user:   user,
+		client: client,
+		base:   sql.NewSession("", client, user, id),
+
+		lastActive: time.Now(),
 	}
 }
 
@@ -49,12 +66,13 @@ func (s *Session) GetCurrentDB() string {
 	return s.currentDB
 }
 
-// Context creates a new SQL context with session information
-func (s *Session) Context(baseCtx context.Context) *sql.Context {
+// Context creates a new SQL context with session information. The given
+// options are applied after the session ones.
+func (s *Session) Context(baseCtx context.Context, opts ...sql.ContextOption) *sql.Context {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	
-	ctx := sql.NewContext(baseCtx)
+
+	ctx := sql.NewContext(baseCtx, append([]sql.ContextOption{sql.WithSession(s.base)}, opts...)...)
 	if s.currentDB != "" {
 		ctx.SetCurrentDatabase(s.currentDB)
 	}
@@ -64,30 +82,131 @@ func (s *Session) Context(baseCtx context.Context) *sql.Context {
 	return ctx
 }
 
-// SetVar sets a session variable
+// SetVar sets a session variable, as SET @@name = val would.
 func (s *Session) SetVar(name string, val interface{}) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.vars[name] = val
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.base.Set(strings.ToLower(name), varType(val), val)
 }
 
-// GetVar gets a session variable
+// GetVar gets a session variable, or nil if it's not set.
 func (s *Session) GetVar(name string) interface{} {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.vars[name]
+	_, val := s.base.Get(strings.ToLower(name))
+	return val
+}
+
+// varType returns the SQL type of a variable value set with SetVar.
+func varType(val interface{}) sql.Type {
+	switch val.(type) {
+	case nil:
+		return sql.Null
+	case bool:
+		return sql.Boolean
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return sql.Int64
+	case float32, float64:
+		return sql.Float64
+	case []byte:
+		return sql.Blob
+	default:
+		return sql.Text
+	}
 }
 
 // User returns the session user
 func (s *Session) User() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.user
 }
 
+// SetUser sets the user of the session. Sessions are created before the
+// connection is authenticated, so the user is only known afterwards.
+func (s *Session) SetUser(user string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user == user {
+		return
+	}
+
+	s.user = user
+	s.rebuildBase()
+}
+
+// Attributes returns the connection attributes the client sent when it
+// connected, such as program_name and _client_version, or nil if it sent
+// none.
+func (s *Session) Attributes() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.attrs
+}
+
+// SetAttributes sets the connection attributes of the client. Like the
+// user, they're only known once the connection is authenticated.
+func (s *Session) SetAttributes(attrs map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = attrs
+	s.rebuildBase()
+}
+
+// newBase returns a base session of the client of the session, without
+// variables.
+func (s *Session) newBase() sql.Session {
+	return sql.NewClientSession("", sql.Client{
+		User:       s.user,
+		Address:    s.client,
+		Attributes: s.attrs,
+	}, s.id)
+}
+
+// rebuildBase replaces the base session with one of the client of the
+// session as it is now, with the same variables.
+func (s *Session) rebuildBase() {
+	base := s.newBase()
+	for name, v := range s.base.GetAll() {
+		base.Set(name, v.Typ, v.Value)
+	}
+	s.base = base
+}
+
 // Client returns the client address
 func (s *Session) Client() string {
 	return s.client
 }
 
+// StatementCache returns the cache of the plans of the statements parsed
+// by the session, or nil if they are not cached.
+func (s *Session) StatementCache() *StatementCache {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.statements
+}
+
+// SetStatementCache sets the cache of the plans of the statements parsed by
+// the session.
+func (s *Session) SetStatementCache(c *StatementCache) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statements = c
+}
+
+// Reset returns the session to the state of a new one of the same user:
+// no current database or transaction, and the default session variables
+// without warnings. The transaction must have been ended before. The plans
+// of the statements parsed are kept, as they don't depend on the session.
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentDB = ""
+	s.transaction = nil
+	s.hasNextIsolation = false
+	s.base = s.newBase()
+}
+
 // GetTransaction returns the current transaction
 func (s *Session) GetTransaction() sql.Transaction {
 	s.mu.RLock()
@@ -102,18 +221,97 @@ func (s *Session) SetTransaction(txn sql.Transaction) {
 	s.transaction = txn
 }
 
-// GetAutoCommit returns the autocommit setting
+// SetNextIsolation sets the isolation level of the next transaction of the
+// session.
+func (s *Session) SetNextIsolation(level transaction.IsolationLevel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextIsolation = level
+	s.hasNextIsolation = true
+}
+
+// TakeNextIsolation returns the isolation level of the next transaction of
+// the session, and false if none was set. The level is cleared, as it's
+// only used by one transaction.
+func (s *Session) TakeNextIsolation() (transaction.IsolationLevel, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	level, ok := s.nextIsolation, s.hasNextIsolation
+	s.hasNextIsolation = false
+	return level, ok
+}
+
+// GetAutoCommit returns the autocommit setting, which is the value of the
+// autocommit session variable.
 func (s *Session) GetAutoCommit() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.autoCommit
+	_, val := s.base.Get("autocommit")
+	return boolVariable(val, true)
+}
+
+// GetDryRun returns whether the statements of the session are only checked
+// and planned, without executing them.
+func (s *Session) GetDryRun() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, val := s.base.Get(DryRunVariable)
+	return boolVariable(val, false)
+}
+
+// boolVariable returns whether the value of a boolean session variable is
+// on, or def if it's not set. Values that aren't booleans are on unless they
+// are 0, OFF or FALSE.
+func boolVariable(val interface{}, def bool) bool {
+	switch v := val.(type) {
+	case nil:
+		return def
+	case bool:
+		return v
+	case string:
+		switch strings.ToUpper(v) {
+		case "0", "OFF", "FALSE":
+			return false
+		}
+		return true
+	default:
+		n, err := sql.Int64.Convert(v)
+		return err != nil || n.(int64) != 0
+	}
 }
 
 // SetAutoCommit sets the autocommit setting
 func (s *Session) SetAutoCommit(autoCommit bool) {
+	var val int64
+	if autoCommit {
+		val = 1
+	}
+	s.SetVar("autocommit", val)
+}
+
+// BeginCommand marks the session as running a command, so it's not idle
+// until EndCommand is called.
+func (s *Session) BeginCommand() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running++
+	s.lastActive = time.Now()
+}
+
+// EndCommand marks a command started with BeginCommand as finished.
+func (s *Session) EndCommand() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.autoCommit = autoCommit
+	s.running--
+	s.lastActive = time.Now()
+}
+
+// IdleSince returns when the session became idle, or false if it's running
+// a command.
+func (s *Session) IdleSince() (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastActive, s.running == 0
 }
 
 // EnhancedSessionManager manages database sessions with enhanced functionality
@@ -147,6 +345,18 @@ func (m *EnhancedSessionManager) GetSession(id uint32) *Session {
 	return m.sessions[id]
 }
 
+// Sessions returns all the sessions.
+func (m *EnhancedSessionManager) Sessions() []*Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, sess := range m.sessions {
+		sessions = append(sessions, sess)
+	}
+	return sessions
+}
+
 // RemoveSession removes a session by ID
 func (m *EnhancedSessionManager) RemoveSession(id uint32) {
 	m.mu.Lock()
@@ -154,3 +364,46 @@ func (m *EnhancedSessionManager) RemoveSession(id uint32) {
 	delete(m.sessions, id)
 }
 
+// RemoveIdle removes the sessions that have been idle for longer than
+// timeout at the given time, and returns them.
+func (m *EnhancedSessionManager) RemoveIdle(now time.Time, timeout time.Duration) []*Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var idle []*Session
+	for id, sess := range m.sessions {
+		since, ok := sess.IdleSince()
+		if ok && now.Sub(since) > timeout {
+			delete(m.sessions, id)
+			idle = append(idle, sess)
+		}
+	}
+	return idle
+}
+
+// ReapIdle removes the sessions that have been idle for longer than timeout
+// until the context is done, passing each of them to reap once removed.
+// Sessions are checked several times per timeout, so they are removed soon
+// after it passes.
+func (m *EnhancedSessionManager) ReapIdle(ctx context.Context, timeout time.Duration, reap func(*Session)) {
+	interval := timeout / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	} else if interval > time.Minute {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, sess := range m.RemoveIdle(now, timeout) {
+				reap(sess)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_SessionVariables(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(err)
+	defer conn.Close()
+
+	var autoCommit int64
+	require.NoError(conn.QueryRowContext(ctx, "SELECT @@autocommit").Scan(&autoCommit))
+	require.Equal(int64(1), autoCommit)
+
+	_, err = conn.ExecContext(ctx, "SET @@session.my_var = 'hello'")
+	require.NoError(err)
+
+	var val string
+	require.NoError(conn.QueryRowContext(ctx, "SELECT @@my_var").Scan(&val))
+	require.Equal("hello", val)
+
+	// Variables belong to the connection that set them.
+	other, err := db.Conn(ctx)
+	require.NoError(err)
+	defer other.Close()
+
+	var otherVal *string
+	require.NoError(other.QueryRowContext(ctx, "SELECT @@my_var").Scan(&otherVal))
+	require.Nil(otherVal)
+}
+
+func TestE2E_AutoCommitOff(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+	ctx := context.Background()
+
+	_, err := db.Exec("CREATE TABLE t (id BIGINT PRIMARY KEY, name TEXT)")
+	require.NoError(err)
+
+	writer, err := db.Conn(ctx)
+	require.NoError(err)
+	defer writer.Close()
+
+	reader, err := db.Conn(ctx)
+	require.NoError(err)
+	defer reader.Close()
+
+	count := func() int64 {
+		t.Helper()
+		var n int64
+		require.NoError(reader.QueryRowContext(ctx, "SELECT COUNT(*) FROM t").Scan(&n))
+		return n
+	}
+
+	_, err = writer.ExecContext(ctx, "SET autocommit = 0")
+	require.NoError(err)
+
+	var autoCommit int64
+	require.NoError(writer.QueryRowContext(ctx, "SELECT @@autocommit").Scan(&autoCommit))
+	require.Equal(int64(0), autoCommit)
+
+	// The rows are only seen by others once they are committed.
+	_, err = writer.ExecContext(ctx, "INSERT INTO t VALUES (1, 'a')")
+	require.NoError(err)
+	_, err = writer.ExecContext(ctx, "INSERT INTO t VALUES (2, 'b')")
+	require.NoError(err)
+	require.Equal(int64(0), count())
+
+	_, err = writer.ExecContext(ctx, "COMMIT")
+	require.NoError(err)
+	require.Equal(int64(2), count())
+
+	// Rolled back rows are never seen.
+	_, err = writer.ExecContext(ctx, "INSERT INTO t VALUES (3, 'c')")
+	require.NoError(err)
+	_, err = writer.ExecContext(ctx, "ROLLBACK")
+	require.NoError(err)
+	require.Equal(int64(2), count())
+
+	// Turning autocommit back on commits the open transaction.
+	_, err = writer.ExecContext(ctx, "INSERT INTO t VALUES (4, 'd')")
+	require.NoError(err)
+	require.Equal(int64(2), count())
+	_, err = writer.ExecContext(ctx, "SET autocommit = 1")
+	require.NoError(err)
+	require.Equal(int64(3), count())
+
+	_, err = writer.ExecContext(ctx, "INSERT INTO t VALUES (5, 'e')")
+	require.NoError(err)
+	require.Equal(int64(4), count())
+}
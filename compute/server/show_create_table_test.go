@@ -0,0 +1,63 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_ShowCreateTable(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	_, err := db.Exec(`CREATE TABLE accounts (
+		id BIGINT NOT NULL AUTO_INCREMENT COMMENT 'account number',
+		email VARCHAR(64) NOT NULL UNIQUE,
+		owner VARCHAR(20) DEFAULT 'nobody' COMMENT 'who''s paying',
+		balance DOUBLE DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (id),
+		KEY idx_owner (owner, balance)
+	) COMMENT='customer accounts'`)
+	require.NoError(err)
+
+	showCreate := func(table string) string {
+		var name, create string
+		require.NoError(db.QueryRow("SHOW CREATE TABLE "+table).Scan(&name, &create))
+		return create
+	}
+
+	create := showCreate("accounts")
+	require.Equal("CREATE TABLE `accounts` (`id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'account number',\n"+
+		"`email` VARCHAR(64) NOT NULL,\n"+
+		"`owner` VARCHAR(20) DEFAULT 'nobody' COMMENT 'who''s paying',\n"+
+		"`balance` DOUBLE DEFAULT 0,\n"+
+		"`created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,\n"+
+		"PRIMARY KEY (`id`),\n"+
+		"UNIQUE KEY `email` (`email`),\n"+
+		"KEY `idx_owner` (`owner`,`balance`)) "+
+		"ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='customer accounts'", create)
+
+	// The statement creates the same table again.
+	_, err = db.Exec(strings.Replace(create, "`accounts`", "`accounts_copy`", 1))
+	require.NoError(err)
+	require.Equal(
+		strings.Replace(create, "`accounts`", "`accounts_copy`", 1),
+		showCreate("accounts_copy"),
+	)
+
+	_, err = db.Exec("INSERT INTO accounts_copy (email) VALUES ('ann@example.com')")
+	require.NoError(err)
+	_, err = db.Exec("INSERT INTO accounts_copy (email) VALUES ('ann@example.com')")
+	require.Error(err)
+	require.Contains(err.Error(), "Duplicate entry 'ann@example.com' for key 'email'")
+
+	var id int64
+	var owner string
+	require.NoError(db.QueryRow(
+		"SELECT id, owner FROM accounts_copy WHERE email = 'ann@example.com'",
+	).Scan(&id, &owner))
+	require.Equal(int64(1), id)
+	require.Equal("nobody", owner)
+}
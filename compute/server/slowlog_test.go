@@ -0,0 +1,65 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/maintenance/slowlog"
+)
+
+var slowLogHeader = regexp.MustCompile(`# Query_time: ([0-9.]+)  Lock_time: [0-9.]+ Rows_sent: (\d+)  Rows_examined: (\d+)`)
+
+func TestServer_SlowQueryLog(t *testing.T) {
+	handler, db := startSlowQueryListener(t)
+
+	path := filepath.Join(t.TempDir(), "slow.log")
+	slowLog, err := slowlog.New(slowlog.Config{FilePath: path})
+	require.NoError(t, err)
+	defer slowLog.Close()
+	handler.slowLog = slowLog
+
+	const query = "SELECT COUNT(*) FROM slowdb.t"
+	start := time.Now()
+	var n int
+	require.NoError(t, db.QueryRow(query).Scan(&n))
+	elapsed := time.Since(start)
+	require.Equal(t, 200, n)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	log := string(content)
+
+	require.Contains(t, log, "# User@Host: root[root] @ 127.0.0.1\n")
+	require.Contains(t, log, "use testdb;\n")
+	require.Contains(t, log, query+";\n")
+
+	m := slowLogHeader.FindStringSubmatch(log)
+	require.NotNil(t, m, log)
+	secs, err := strconv.ParseFloat(m[1], 64)
+	require.NoError(t, err)
+	require.LessOrEqual(t, secs, elapsed.Seconds())
+	require.Equal(t, "1", m[2])
+	require.Equal(t, "200", m[3])
+}
+
+func TestServer_SlowQueryLogThreshold(t *testing.T) {
+	handler, db := startSlowQueryListener(t)
+
+	path := filepath.Join(t.TempDir(), "slow.log")
+	slowLog, err := slowlog.New(slowlog.Config{FilePath: path, Threshold: time.Hour})
+	require.NoError(t, err)
+	defer slowLog.Close()
+	handler.slowLog = slowLog
+
+	_, err = db.Exec("SELECT COUNT(*) FROM slowdb.t")
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Empty(t, content)
+}
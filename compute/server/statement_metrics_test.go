@@ -0,0 +1,89 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/transaction"
+	"github.com/turtacn/guocedb/maintenance/metrics"
+	badgerengine "github.com/turtacn/guocedb/storage/engines/badger"
+)
+
+func TestE2E_StatementMetrics(t *testing.T) {
+	kv, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	t.Cleanup(func() { kv.Close() })
+
+	handler, addr := startHandlerListener(t, 0)
+	handler.txnManager = transaction.NewManagerWithDB(kv)
+	handler.statements = metrics.NewStatementCollector()
+	handler.e.Catalog.AddDatabase(badgerengine.NewDatabase("metricsdb", kv))
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(handler.statements)
+	srv := httptest.NewServer(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	defer srv.Close()
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/metricsdb", addr))
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	queries := []string{
+		"USE metricsdb",
+		"CREATE TABLE t (id BIGINT PRIMARY KEY, v BIGINT)",
+		"INSERT INTO t VALUES (1, 10)",
+		"INSERT INTO t VALUES (2, 20)",
+		"UPDATE t SET v = 30 WHERE id = 2",
+		"DELETE FROM t WHERE id = 1",
+	}
+	for _, q := range queries {
+		_, err := db.Exec(q)
+		require.NoError(t, err, q)
+	}
+
+	for i := 0; i < 3; i++ {
+		var v int64
+		require.NoError(t, db.QueryRow("SELECT v FROM t WHERE id = 2").Scan(&v))
+		require.Equal(t, int64(30), v)
+	}
+	_, err = db.Exec("SELECT missing FROM t")
+	require.Error(t, err)
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	text := string(body)
+
+	value := func(metric string) float64 {
+		m := regexp.MustCompile(regexp.QuoteMeta(metric) + ` (\S+)`).FindStringSubmatch(text)
+		require.NotNil(t, m, "metric %s not found in:\n%s", metric, text)
+		v, err := strconv.ParseFloat(m[1], 64)
+		require.NoError(t, err)
+		return v
+	}
+
+	require.Equal(t, float64(1), value(`guocedb_statement_duration_seconds_count{type="ddl"}`))
+	require.Equal(t, float64(2), value(`guocedb_statement_duration_seconds_count{type="insert"}`))
+	require.Equal(t, float64(1), value(`guocedb_statement_duration_seconds_count{type="update"}`))
+	require.Equal(t, float64(1), value(`guocedb_statement_duration_seconds_count{type="delete"}`))
+	require.Equal(t, float64(4), value(`guocedb_statement_duration_seconds_count{type="select"}`))
+	require.Equal(t, float64(1), value(`guocedb_statement_duration_seconds_count{type="other"}`))
+	require.Equal(t, float64(1), value(`guocedb_statement_errors_total{code="1054"}`))
+
+	// The histogram has the durations in its buckets, not only the count.
+	require.Equal(t, float64(4), value(`guocedb_statement_duration_seconds_bucket{type="select",le="+Inf"}`))
+	require.Greater(t, value(`guocedb_statement_duration_seconds_sum{type="select"}`), float64(0))
+}
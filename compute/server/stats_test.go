@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/maintenance/metrics"
+)
+
+func TestHandler_Stats(t *testing.T) {
+	const n = 5
+
+	handler, addr := startHandlerListener(t, 0)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(metrics.NewConnectionCollector(func() metrics.ConnectionStats {
+		stats := handler.Stats()
+		return metrics.ConnectionStats{
+			ActiveConnections: stats.ActiveConnections,
+			Queries:           stats.Queries,
+		}
+	}))
+
+	gauge := func(name string) float64 {
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		for _, f := range families {
+			if f.GetName() == name {
+				m := f.GetMetric()[0]
+				if m.GetGauge() != nil {
+					return m.GetGauge().GetValue()
+				}
+				return m.GetCounter().GetValue()
+			}
+		}
+		t.Fatalf("metric %s not found", name)
+		return 0
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", addr))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	var conns []*sql.Conn
+	for i := 0; i < n; i++ {
+		conn, err := db.Conn(ctx)
+		require.NoError(t, err)
+		var one int64
+		require.NoError(t, conn.QueryRowContext(ctx, "SELECT 1").Scan(&one))
+		conns = append(conns, conn)
+	}
+
+	require.Equal(t, float64(n), gauge("guocedb_server_connections_active"))
+	require.GreaterOrEqual(t, gauge("guocedb_server_queries_total"), float64(n))
+
+	// The connections go back to the pool, which closes them.
+	for _, conn := range conns {
+		require.NoError(t, conn.Close())
+	}
+	require.NoError(t, db.Close())
+
+	require.Eventually(t, func() bool {
+		return gauge("guocedb_server_connections_active") == 0
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Zero(t, handler.Stats().ActiveConnections)
+}
@@ -0,0 +1,329 @@
+package server
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/parse"
+)
+
+// DefaultStatementCacheSize is the number of parsed statements each session
+// keeps, unless the server is configured otherwise.
+const DefaultStatementCacheSize = 256
+
+// StatementCache is a LRU cache of the plans of the statements parsed by a
+// session, before they are analyzed, so running or preparing the same
+// statement again doesn't parse it again. Plans are kept by the text of
+// their statements, whose literals are parameters, and the current
+// database, along with the tables they refer to, and they are dropped when
+// the schema of any of those tables changes.
+type StatementCache struct {
+	mu       sync.Mutex
+	capacity int
+	lru      *list.List               // Entries by last use, the most recent first
+	entries  map[string]*list.Element // Entries by key
+	hits     uint64
+	misses   uint64
+}
+
+type statementEntry struct {
+	key    string
+	tables []string
+	parsed sql.Node
+	// selectLimit is the sql_select_limit of the session when the plan was
+	// parsed, which gives a limit to the plans of SELECT statements.
+	selectLimit interface{}
+}
+
+// StatementCacheStats are the figures of the lookups in a StatementCache.
+type StatementCacheStats struct {
+	// Entries is the number of plans cached.
+	Entries int
+	// Hits and Misses are the number of lookups that found a plan and that
+	// didn't.
+	Hits, Misses uint64
+}
+
+// NewStatementCache creates a cache that keeps up to capacity plans.
+func NewStatementCache(capacity int) *StatementCache {
+	return &StatementCache{
+		capacity: capacity,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the plan cached with key, if any, and if it was parsed with
+// the given sql_select_limit.
+func (c *StatementCache) Get(key string, selectLimit interface{}) (sql.Node, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || e.Value.(*statementEntry).selectLimit != selectLimit {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(e)
+	return e.Value.(*statementEntry).parsed, true
+}
+
+// Put caches the plan of a statement that refers to the given tables.
+func (c *StatementCache) Put(key string, tables []string, parsed sql.Node, selectLimit interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.lru.Remove(e)
+	}
+	c.entries[key] = c.lru.PushFront(&statementEntry{
+		key:         key,
+		tables:      tables,
+		parsed:      parsed,
+		selectLimit: selectLimit,
+	})
+
+	for c.lru.Len() > c.capacity {
+		entry := c.lru.Remove(c.lru.Back()).(*statementEntry)
+		delete(c.entries, entry.key)
+	}
+}
+
+// Invalidate drops the plans that refer to any of the given tables.
+func (c *StatementCache) Invalidate(tables ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	invalid := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		if t == allTables {
+			c.lru.Init()
+			c.entries = make(map[string]*list.Element)
+			return
+		}
+		invalid[t] = true
+	}
+
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		entry := e.Value.(*statementEntry)
+		for _, t := range entry.tables {
+			if invalid[t] {
+				c.lru.Remove(e)
+				delete(c.entries, entry.key)
+				break
+			}
+		}
+		e = next
+	}
+}
+
+// Stats returns the current figures of the cache.
+func (c *StatementCache) Stats() StatementCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return StatementCacheStats{Entries: c.lru.Len(), Hits: c.hits, Misses: c.misses}
+}
+
+// cacheableStatement returns whether the plan of stmt is kept by the
+// statement cache, as it is for the statements a prepared statement keeps
+// the plan of.
+func cacheableStatement(stmt sqlparser.Statement) bool {
+	switch stmt.(type) {
+	case *sqlparser.Select, *sqlparser.SetOp, *sqlparser.ParenSelect,
+		*sqlparser.Insert, *sqlparser.Update, *sqlparser.Delete:
+		return true
+	default:
+		return false
+	}
+}
+
+// parsePlan returns the plan of a statement whose text, with the values of
+// its parameters written as :v1, :v2..., is query. The plan is taken from
+// the statement cache of the session if it's there, and cached otherwise.
+func (h *Handler) parsePlan(ctx *sql.Context, sess *Session, stmt sqlparser.Statement, query string) (sql.Node, error) {
+	var cache *StatementCache
+	if sess != nil {
+		cache = sess.StatementCache()
+	}
+	if cache == nil {
+		return h.e.Prepare(ctx, query)
+	}
+
+	db := h.e.Catalog.CurrentDatabase()
+	key := db + "\x00" + query
+	limit := selectLimit(ctx)
+	if parsed, ok := cache.Get(key, limit); ok {
+		return parsed, nil
+	}
+
+	parsed, err := h.e.Prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	cache.Put(key, referencedTables(stmt, db), parsed, limit)
+	return parsed, nil
+}
+
+// cachedStatement returns the plan of a query along with the values of the
+// literals it has as parameters, so the plan parsed for a query is reused
+// by the ones that only differ from it in those values. It returns nil if
+// the session doesn't cache statements, or if the query's plan can't be
+// kept.
+func (h *Handler) cachedStatement(ctx *sql.Context, sess *Session, query string) *boundStatement {
+	if sess == nil || sess.StatementCache() == nil {
+		return nil
+	}
+
+	// The statement is parsed again, as its literals are replaced.
+	stmt, err := sqlparser.Parse(query)
+	if err != nil || !cacheableStatement(stmt) {
+		return nil
+	}
+
+	bindings, ok := parameterizeLiterals(stmt)
+	if !ok {
+		return nil
+	}
+
+	parsed, err := h.parsePlan(ctx, sess, stmt, sqlparser.String(stmt))
+	if err != nil {
+		// The query fails the same when it's parsed as it is, and some
+		// queries can only be parsed with their literals.
+		return nil
+	}
+	return &boundStatement{stmt: stmt, parsed: parsed, bindings: bindings}
+}
+
+// parameterizeLiterals replaces the literals of stmt that are compared,
+// inserted or assigned with parameters, named v1, v2... in the order they
+// are written, and returns their values. It returns false if the statement
+// already has parameters. Literals elsewhere, as those of the selected
+// expressions, ORDER BY or LIMIT, are part of the plan, and subqueries are
+// left as they are.
+func parameterizeLiterals(stmt sqlparser.Statement) (map[string]sql.Expression, bool) {
+	bindings := make(map[string]sql.Expression)
+	ok := true
+
+	replace := func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.Subquery:
+			return false, nil
+		case *sqlparser.SQLVal:
+			if n.Type == sqlparser.ValArg {
+				ok = false
+				return false, nil
+			}
+
+			e, err := parse.LiteralExpression(n)
+			if err != nil {
+				// The literal is left for the parser to report.
+				return false, nil
+			}
+			name := "v" + strconv.Itoa(len(bindings)+1)
+			bindings[name] = e
+			n.Type, n.Val = sqlparser.ValArg, []byte(":"+name)
+		}
+		return ok, nil
+	}
+
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.Subquery:
+			return false, nil
+		case *sqlparser.Where:
+			_ = sqlparser.Walk(replace, n)
+			return false, nil
+		case sqlparser.JoinCondition:
+			if n.On != nil {
+				_ = sqlparser.Walk(replace, n.On)
+			}
+			return false, nil
+		case *sqlparser.AliasedValues:
+			_ = sqlparser.Walk(replace, n.Values)
+			return false, nil
+		case sqlparser.Values, sqlparser.AssignmentExprs:
+			_ = sqlparser.Walk(replace, n)
+			return false, nil
+		case *sqlparser.SQLVal:
+			if n.Type == sqlparser.ValArg {
+				ok = false
+			}
+		}
+		return ok, nil
+	}, stmt)
+
+	return bindings, ok
+}
+
+// referencedTables returns the qualified names of the tables stmt refers
+// to, which are in db unless they're qualified.
+func referencedTables(stmt sqlparser.Statement, db string) []string {
+	var tables []string
+	add := func(t sqlparser.TableName) {
+		if name, ok := tableName(t, db); ok {
+			tables = append(tables, name)
+		}
+	}
+
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.AliasedTableExpr:
+			if t, ok := n.Expr.(sqlparser.TableName); ok {
+				add(t)
+			}
+		case *sqlparser.Insert:
+			add(n.Table)
+		}
+		return true, nil
+	}, stmt)
+	return tables
+}
+
+// schemaChangedTables returns the tables whose schema stmt may change, or
+// allTables if it's not known which ones. Statements that don't change any
+// return none.
+func schemaChangedTables(stmt sqlparser.Statement, db string) []string {
+	var names sqlparser.TableNames
+	switch n := stmt.(type) {
+	case *sqlparser.DDL:
+		if !n.Table.IsEmpty() {
+			names = append(names, n.Table)
+		}
+		names = append(names, n.FromTables...)
+		names = append(names, n.ToTables...)
+	case *sqlparser.AlterTable:
+		names = append(names, n.Table)
+	case *sqlparser.DBDDL:
+		return []string{allTables}
+	default:
+		return nil
+	}
+
+	var tables []string
+	for _, t := range names {
+		if name, ok := tableName(t, db); ok {
+			tables = append(tables, name)
+		}
+	}
+	return tables
+}
+
+// invalidateStatements drops the plans that refer to the tables whose
+// schema stmt may have changed from the statement caches of all sessions.
+func (h *Handler) invalidateStatements(stmt sqlparser.Statement) {
+	tables := schemaChangedTables(stmt, h.e.Catalog.CurrentDatabase())
+	if len(tables) == 0 {
+		return
+	}
+
+	for _, sess := range h.sessionMgr.Sessions() {
+		if cache := sess.StatementCache(); cache != nil {
+			cache.Invalidate(tables...)
+		}
+	}
+}
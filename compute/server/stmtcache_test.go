@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+func TestStatementCache(t *testing.T) {
+	require := require.New(t)
+
+	c := NewStatementCache(2)
+	c.Put("a", []string{"db.t"}, plan.NewUnresolvedTable("t", ""), nil)
+	c.Put("b", []string{"db.u"}, plan.NewUnresolvedTable("u", ""), nil)
+
+	_, ok := c.Get("a", nil)
+	require.True(ok)
+	// The sql_select_limit the plan was parsed with must be the same.
+	_, ok = c.Get("a", int64(10))
+	require.False(ok)
+
+	// b is the least recently used.
+	c.Put("c", []string{"db.t", "db.u"}, plan.NewUnresolvedTable("t", ""), nil)
+	_, ok = c.Get("b", nil)
+	require.False(ok)
+
+	c.Invalidate("db.u")
+	_, ok = c.Get("c", nil)
+	require.False(ok)
+	_, ok = c.Get("a", nil)
+	require.True(ok)
+
+	c.Invalidate(allTables)
+	require.Equal(StatementCacheStats{Entries: 0, Hits: 2, Misses: 3}, c.Stats())
+}
+
+func TestParameterizeLiterals(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+		params   int
+	}{
+		{
+			"SELECT a, 1 FROM t WHERE a = 5 AND b = 'x' ORDER BY 1 LIMIT 10",
+			"select a, 1 from t where a = :v1 and b = :v2 order by 1 asc limit 10",
+			2,
+		},
+		{
+			"SELECT * FROM t JOIN u ON t.a = u.a AND u.b > 2.5 WHERE t.c IN (1, 2)",
+			"select * from t join u on t.a = u.a and u.b > :v1 where t.c in (:v2, :v3)",
+			3,
+		},
+		{
+			"INSERT INTO t VALUES (1, 'a'), (2, 'b')",
+			"insert into t values (:v1, :v2), (:v3, :v4)",
+			4,
+		},
+		{
+			"UPDATE t SET a = 1 WHERE b = 2 LIMIT 3",
+			"update t set a = :v1 where b = :v2 limit 3",
+			2,
+		},
+		{
+			"SELECT * FROM t WHERE a IN (SELECT a FROM u WHERE b = 1 LIMIT 1)",
+			"select * from t where a in (select a from u where b = 1 limit 1)",
+			0,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			stmt, err := sqlparser.Parse(tt.query)
+			require.NoError(t, err)
+
+			bindings, ok := parameterizeLiterals(stmt)
+			require.True(t, ok)
+			require.Equal(t, tt.expected, sqlparser.String(stmt))
+			require.Len(t, bindings, tt.params)
+		})
+	}
+
+	stmt, err := sqlparser.Parse("SELECT * FROM t WHERE a = ?")
+	require.NoError(t, err)
+	_, ok := parameterizeLiterals(stmt)
+	require.False(t, ok)
+}
+
+func TestE2E_StatementCache(t *testing.T) {
+	require := require.New(t)
+	h, db := startBadgerTestHandler(t)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(err)
+	defer conn.Close()
+
+	for _, query := range []string{
+		"CREATE TABLE t (id BIGINT PRIMARY KEY, name TEXT)",
+		"INSERT INTO t VALUES (1, 'a'), (2, 'b'), (3, 'c')",
+	} {
+		_, err := conn.ExecContext(ctx, query)
+		require.NoError(err)
+	}
+
+	name := func(query string, args ...interface{}) string {
+		t.Helper()
+		var name string
+		require.NoError(conn.QueryRowContext(ctx, query, args...).Scan(&name))
+		return name
+	}
+
+	// The queries only differ in their literals, so they're parsed once.
+	parses := h.e.Parses()
+	for i := 0; i < 100; i++ {
+		id := i%3 + 1
+		require.Equal(string(rune('a'+id-1)), name(fmt.Sprintf("SELECT name FROM t WHERE id = %d", id)))
+	}
+	require.Equal(uint64(1), h.e.Parses()-parses)
+
+	// A statement prepared with the same text shares the plan.
+	for i := 0; i < 10; i++ {
+		stmt, err := conn.PrepareContext(ctx, "SELECT name FROM t WHERE id = ?")
+		require.NoError(err)
+		var name string
+		require.NoError(stmt.QueryRowContext(ctx, 2).Scan(&name))
+		require.Equal("b", name)
+		require.NoError(stmt.Close())
+	}
+	require.Equal(uint64(1), h.e.Parses()-parses)
+
+	// Changing the schema of the table drops its plans.
+	_, err = conn.ExecContext(ctx, "ALTER TABLE t ADD COLUMN age BIGINT")
+	require.NoError(err)
+	parses = h.e.Parses()
+	require.Equal("c", name("SELECT name FROM t WHERE id = 3"))
+	require.Equal(uint64(1), h.e.Parses()-parses)
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultConnectQueueTimeout is how long a new connection over the connect
+// rate waits to be accepted by default.
+const DefaultConnectQueueTimeout = time.Second
+
+// tokenBucket limits the rate of events to rate per second, with bursts of
+// up to burst events at once.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full token bucket.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long to wait until it's due, as
+// the tokens are taken in advance by the events waiting for them. It takes
+// nothing and returns false if the token wouldn't be due within maxWait.
+func (b *tokenBucket) reserve(now time.Time, maxWait time.Duration) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+
+	tokens := b.tokens - 1
+	var wait time.Duration
+	if tokens < 0 {
+		wait = time.Duration(-tokens / b.rate * float64(time.Second))
+	}
+	if wait > maxWait {
+		return 0, false
+	}
+	b.tokens = tokens
+	return wait, true
+}
+
+// throttleListener smooths the bursts of new connections, such as the one
+// of the clients reconnecting after a restart, so their sessions are set
+// up at the connect rate of the server rather than all at once. The
+// connections are accepted as soon as they arrive and wait for their turn,
+// and those that would wait longer than the queue timeout are rejected
+// with ER_CON_COUNT_ERROR, which clients retry.
+type throttleListener struct {
+	net.Listener
+	bucket  *tokenBucket
+	timeout time.Duration
+
+	conns     chan net.Conn
+	done      chan struct{}
+	err       error // Error of Accept once done is closed
+	closeOnce sync.Once
+}
+
+// newThrottleListener returns a listener accepting the connections of l at
+// the given rate per second, with bursts of up to burst connections.
+func newThrottleListener(l net.Listener, rate float64, burst int, timeout time.Duration) *throttleListener {
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	if timeout <= 0 {
+		timeout = DefaultConnectQueueTimeout
+	}
+
+	tl := &throttleListener{
+		Listener: l,
+		bucket:   newTokenBucket(rate, burst),
+		timeout:  timeout,
+		conns:    make(chan net.Conn),
+		done:     make(chan struct{}),
+	}
+	go tl.acceptLoop()
+	return tl
+}
+
+// acceptLoop accepts the connections of the listener until it fails, and
+// lets each one in once it's its turn.
+func (l *throttleListener) acceptLoop() {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			l.stop(err)
+			return
+		}
+		go l.admit(conn)
+	}
+}
+
+// admit waits for the turn of a connection and hands it to Accept, or
+// rejects it if it wouldn't get one within the queue timeout.
+func (l *throttleListener) admit(conn net.Conn) {
+	wait, ok := l.bucket.reserve(time.Now(), l.timeout)
+	if !ok {
+		logrus.Warnf("rejecting client %v, over the connect rate", conn.RemoteAddr())
+		if err := writeHandshakeError(conn, ERConCountError, SSConCount, "Too many connections"); err != nil {
+			logrus.Debugf("unable to send connection error: %s", err)
+		}
+		conn.Close()
+		return
+	}
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-l.done:
+			conn.Close()
+			return
+		}
+	}
+
+	select {
+	case l.conns <- conn:
+	case <-l.done:
+		conn.Close()
+	}
+}
+
+// Accept implements the net.Listener interface.
+func (l *throttleListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, l.err
+	}
+}
+
+// Close implements the net.Listener interface.
+func (l *throttleListener) Close() error {
+	l.stop(net.ErrClosed)
+	return l.Listener.Close()
+}
+
+// stop makes Accept fail with err, and the connections waiting their turn
+// be closed.
+func (l *throttleListener) stop(err error) {
+	l.closeOnce.Do(func() {
+		l.err = err
+		close(l.done)
+	})
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+)
+
+func TestServer_ConnectRate(t *testing.T) {
+	start := func(rate float64, burst int, timeout time.Duration) *sql.DB {
+		catalog := sqlengine.NewCatalog()
+		catalog.AddDatabase(newMockDatabase("testdb"))
+		engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+
+		s, err := NewDefaultServer(Config{
+			Protocol:            "tcp",
+			Address:             "127.0.0.1:0",
+			Auth:                auth.NewNativeSingle("root", "", auth.AllPermissions),
+			ConnectRate:         rate,
+			ConnectBurst:        burst,
+			ConnectQueueTimeout: timeout,
+		}, engine)
+		require.NoError(t, err)
+		s.Start()
+		t.Cleanup(func() { s.Close() })
+
+		db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", s.Addr()))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	// connectAll connects 200 clients at once, and returns the number of
+	// them that got in and the time the last one took.
+	connectAll := func(db *sql.DB) (int, time.Duration) {
+		ctx := context.Background()
+		begin := time.Now()
+
+		var (
+			mu       sync.Mutex
+			wg       sync.WaitGroup
+			conns    []*sql.Conn
+			rejected int
+			last     time.Duration
+		)
+		for i := 0; i < 200; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				conn, err := db.Conn(ctx)
+				if err == nil {
+					err = conn.PingContext(ctx)
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					var mysqlErr *mysqldriver.MySQLError
+					if !errors.As(err, &mysqlErr) || mysqlErr.Number != ERConCountError {
+						t.Errorf("unexpected error connecting: %v", err)
+					}
+					rejected++
+					return
+				}
+				conns = append(conns, conn)
+				last = time.Since(begin)
+			}()
+		}
+		wg.Wait()
+
+		for _, conn := range conns {
+			require.NoError(t, conn.PingContext(ctx))
+			conn.Close()
+		}
+		require.Equal(t, 200, len(conns)+rejected)
+		return len(conns), last
+	}
+
+	// The connections all get in at the rate, after the first burst.
+	db := start(200, 10, 5*time.Second)
+	accepted, took := connectAll(db)
+	require.Equal(t, 200, accepted)
+	require.Greater(t, took, 800*time.Millisecond)
+
+	// Those that wouldn't get in within the queue timeout are rejected,
+	// and the server keeps accepting connections.
+	db = start(50, 10, 200*time.Millisecond)
+	accepted, _ = connectAll(db)
+	require.Less(t, accepted, 200)
+	require.GreaterOrEqual(t, accepted, 10)
+
+	require.Eventually(t, func() bool {
+		return db.PingContext(context.Background()) == nil
+	}, 5*time.Second, 50*time.Millisecond)
+}
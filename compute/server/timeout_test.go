@@ -0,0 +1,285 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/transaction"
+	badgerengine "github.com/turtacn/guocedb/storage/engines/badger"
+)
+
+func (h *Handler) openConns() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.c)
+}
+
+// startHandlerListener starts a listener on a random port that serves the
+// returned handler, closing connections idle for longer than timeout.
+func startHandlerListener(t *testing.T, timeout time.Duration) (*Handler, string) {
+	catalog := sqlengine.NewCatalog()
+	catalog.AddDatabase(newMockDatabase("testdb"))
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+	handler := NewHandler(engine, NewSessionManager(DefaultSessionBuilder, nil, "localhost:0"))
+
+	a := auth.NewNativeSingle("root", "", auth.AllPermissions)
+	l, err := mysql.NewListener("tcp", "127.0.0.1:0", a.Mysql(), handler, timeout, timeout)
+	require.NoError(t, err)
+	go l.Accept()
+	t.Cleanup(l.Close)
+
+	return handler, l.Addr().String()
+}
+
+func TestServer_IdleConnectionTimeout(t *testing.T) {
+	const timeout = 300 * time.Millisecond
+
+	handler, addr := startHandlerListener(t, timeout)
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", addr))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// A connection in use outlives the timeout.
+	for i := 0; i < 5; i++ {
+		require.NoError(t, conn.PingContext(ctx))
+		time.Sleep(timeout / 3)
+	}
+	require.Equal(t, 1, handler.openConns())
+
+	// An idle one is dropped and its session cleaned up.
+	time.Sleep(2 * timeout)
+	require.Eventually(t, func() bool {
+		return handler.openConns() == 0
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Error(t, conn.PingContext(ctx))
+}
+
+func TestSessionManager_RemoveIdle(t *testing.T) {
+	sm := NewEnhancedSessionManager()
+	idle := sm.NewSession("root", "client1")
+	busy := sm.NewSession("root", "client2")
+	active := sm.NewSession("root", "client3")
+
+	now := time.Now()
+	idle.lastActive = now.Add(-time.Hour)
+	busy.lastActive = now.Add(-time.Hour)
+	busy.BeginCommand()
+
+	// Sessions running a command are never idle, however long it takes.
+	require.Equal(t, []*Session{idle}, sm.RemoveIdle(now, time.Minute))
+	require.Nil(t, sm.GetSession(idle.ID()))
+	require.NotNil(t, sm.GetSession(busy.ID()))
+	require.NotNil(t, sm.GetSession(active.ID()))
+
+	busy.EndCommand()
+	since, ok := busy.IdleSince()
+	require.True(t, ok)
+	require.True(t, since.After(now))
+	require.Empty(t, sm.RemoveIdle(now, time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sm.ReapIdle(ctx, time.Minute, func(*Session) {})
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reaper didn't stop when its context was canceled")
+	}
+}
+
+func TestHandler_ReapIdleSessions(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+
+	bdb, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer bdb.Close()
+
+	handler, addr := startHandlerListener(t, 0)
+	handler.txnManager = transaction.NewManagerWithDB(bdb)
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", addr))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "BEGIN")
+	require.NoError(t, err)
+	require.Equal(t, 1, handler.txnManager.ActiveCount())
+	require.Equal(t, 1, handler.openConns())
+
+	handler.StartReaper(timeout)
+	defer handler.StopReaper()
+
+	// The session of the idle connection is removed, its transaction rolled
+	// back and the connection closed.
+	require.Eventually(t, func() bool {
+		return handler.openConns() == 0 && handler.txnManager.ActiveCount() == 0
+	}, 2*time.Second, 10*time.Millisecond)
+	handler.sessionMgr.mu.RLock()
+	sessions := len(handler.sessionMgr.sessions)
+	handler.sessionMgr.mu.RUnlock()
+	require.Zero(t, sessions)
+	require.Error(t, conn.PingContext(ctx))
+}
+
+// startSlowQueryListener starts a handler listener with a database whose
+// table makes "SELECT COUNT(*) FROM slowdb.t a, slowdb.t b, slowdb.t c" run for a long time.
+func startSlowQueryListener(t *testing.T) (*Handler, *sql.DB) {
+	kv, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	t.Cleanup(func() { kv.Close() })
+
+	handler, addr := startHandlerListener(t, 0)
+	database := badgerengine.NewDatabase("slowdb", kv)
+	handler.e.Catalog.AddDatabase(database)
+
+	schema := sqlengine.Schema{{Name: "id", Type: sqlengine.Int64, Source: "t", PrimaryKey: true}}
+	require.NoError(t, database.Create("t", schema))
+	table, _, err := database.GetTableInsensitive(nil, "t")
+	require.NoError(t, err)
+
+	ctx := sqlengine.NewEmptyContext()
+	inserter := table.(sqlengine.InsertableTable).Inserter(ctx)
+	inserter.StatementBegin(ctx)
+	for i := 0; i < 200; i++ {
+		require.NoError(t, inserter.Insert(ctx, sqlengine.NewRow(int64(i))))
+	}
+	require.NoError(t, inserter.StatementComplete(ctx))
+	require.NoError(t, inserter.Close(ctx))
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", addr))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return handler, db
+}
+
+func TestServer_QueryTimeout(t *testing.T) {
+	handler, db := startSlowQueryListener(t)
+	handler.queryTimeout = 200 * time.Millisecond
+
+	start := time.Now()
+	_, err := db.Exec("SELECT COUNT(*) FROM slowdb.t a, slowdb.t b, slowdb.t c")
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 2*time.Second)
+
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(t, err, &mysqlErr)
+	require.Equal(t, uint16(ERQueryTimeout), mysqlErr.Number)
+
+	// Quick queries are unaffected.
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM slowdb.t").Scan(&n))
+	require.Equal(t, 200, n)
+}
+
+func TestServer_MaxExecutionTimeHint(t *testing.T) {
+	handler, db := startSlowQueryListener(t)
+
+	start := time.Now()
+	_, err := db.Exec("SELECT /*+ MAX_EXECUTION_TIME(200) */ COUNT(*) FROM slowdb.t a, slowdb.t b, slowdb.t c")
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 2*time.Second)
+
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(t, err, &mysqlErr)
+	require.Equal(t, uint16(ERQueryTimeout), mysqlErr.Number)
+
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM slowdb.t").Scan(&n))
+	require.Equal(t, 200, n)
+
+	// The hint overrides the longest time queries may run, even when it's
+	// longer.
+	handler.queryTimeout = time.Nanosecond
+	require.Error(t, db.QueryRow("SELECT COUNT(*) FROM slowdb.t").Scan(&n))
+	require.NoError(t, db.QueryRow("SELECT /*+ MAX_EXECUTION_TIME(10000) */ COUNT(*) FROM slowdb.t").Scan(&n))
+	require.NoError(t, db.QueryRow("SELECT /*+ max_execution_time(0) */ COUNT(*) FROM slowdb.t").Scan(&n))
+	require.Equal(t, 200, n)
+}
+
+func TestMaxExecutionTime(t *testing.T) {
+	testCases := []struct {
+		query string
+		d     time.Duration
+		ok    bool
+	}{
+		{"SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM t", time.Second, true},
+		{"SELECT /*+ BKA(t) MAX_EXECUTION_TIME(5) */ 1 UNION SELECT 2", 5 * time.Millisecond, true},
+		{"SELECT 1 UNION SELECT /*+ MAX_EXECUTION_TIME(5) */ 2", 0, false},
+		{"SELECT /* MAX_EXECUTION_TIME(5) */ 1", 0, false},
+		{"SELECT * FROM t", 0, false},
+		{"INSERT INTO t SELECT /*+ MAX_EXECUTION_TIME(5) */ * FROM u", 0, false},
+		{"DELETE FROM t", 0, false},
+	}
+
+	for _, tc := range testCases {
+		stmt, err := sqlparser.Parse(tc.query)
+		require.NoError(t, err, tc.query)
+		d, ok := maxExecutionTime(stmt)
+		require.Equal(t, tc.ok, ok, tc.query)
+		require.Equal(t, tc.d, d, tc.query)
+	}
+}
+
+func TestServer_KillQuery(t *testing.T) {
+	_, db := startSlowQueryListener(t)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var id int64
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.ExecContext(ctx, "SELECT COUNT(*) FROM slowdb.t a, slowdb.t b, slowdb.t c")
+		done <- err
+	}()
+
+	// Wait for the query to start before killing it.
+	time.Sleep(100 * time.Millisecond)
+	_, err = db.Exec(fmt.Sprintf("KILL QUERY %d", id))
+	require.NoError(t, err)
+
+	select {
+	case err := <-done:
+		var mysqlErr *mysqldriver.MySQLError
+		require.ErrorAs(t, err, &mysqlErr)
+		require.Equal(t, uint16(ERQueryInterrupted), mysqlErr.Number)
+	case <-time.After(5 * time.Second):
+		t.Fatal("query wasn't killed")
+	}
+
+	// Only the query is killed, not the connection.
+	require.NoError(t, conn.PingContext(ctx))
+}
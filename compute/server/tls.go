@@ -0,0 +1,60 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"github.com/dolthub/vitess/go/mysql"
+)
+
+// LoadTLSConfig returns the server TLS configuration with the certificate
+// and private key in the given PEM files.
+func LoadTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate %s and key %s: %v", certFile, keyFile, err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// secureTransportAuth wraps an auth server so it rejects the clients that
+// didn't switch to TLS. The listener reports the error to them, but it goes
+// on with the handshake, so a client ignoring it must still be kept out.
+type secureTransportAuth struct {
+	mysql.AuthServer
+}
+
+// AuthMethods implements mysql.AuthServer.
+func (a secureTransportAuth) AuthMethods() []mysql.AuthMethod {
+	methods := a.AuthServer.AuthMethods()
+	secure := make([]mysql.AuthMethod, len(methods))
+	for i, m := range methods {
+		secure[i] = secureTransportMethod{m}
+	}
+	return secure
+}
+
+// secureTransportMethod is an auth method that only authenticates TLS
+// connections.
+type secureTransportMethod struct {
+	mysql.AuthMethod
+}
+
+// HandleUser implements mysql.AuthMethod.
+func (m secureTransportMethod) HandleUser(c *mysql.Conn, user string) bool {
+	return c.TLSEnabled() && m.AuthMethod.HandleUser(c, user)
+}
+
+// HandleAuthPluginData implements mysql.AuthMethod.
+func (m secureTransportMethod) HandleAuthPluginData(c *mysql.Conn, user string, serverAuthPluginData []byte, clientAuthPluginData []byte, remoteAddr net.Addr) (mysql.Getter, error) {
+	if !c.TLSEnabled() {
+		return nil, mysql.NewSQLError(mysql.ERAccessDeniedError, mysql.SSAccessDeniedError,
+			"Access denied for user '%s': connections must use SSL/TLS", user)
+	}
+	return m.AuthMethod.HandleAuthPluginData(c, user, serverAuthPluginData, clientAuthPluginData, remoteAddr)
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dolthub/vitess/go/mysql"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	sqlengine "github.com/turtacn/guocedb/compute/sql"
+)
+
+// writeSelfSignedCert writes a self-signed certificate for 127.0.0.1 and its
+// key to dir, and returns their paths along with the certificate.
+func writeSelfSignedCert(t *testing.T, dir string) (string, string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "guocedb test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile, cert
+}
+
+// startTLSServer starts a server with TLS enabled and returns its address
+// and handler, along with the name of a client TLS configuration that
+// trusts its certificate.
+func startTLSServer(t *testing.T, requireTLS bool) (string, *Handler, string) {
+	certFile, keyFile, cert := writeSelfSignedCert(t, t.TempDir())
+	tlsConfig, err := LoadTLSConfig(certFile, keyFile)
+	require.NoError(t, err)
+
+	catalog := sqlengine.NewCatalog()
+	catalog.AddDatabase(newMockDatabase("testdb"))
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+
+	s, err := NewDefaultServer(Config{
+		Protocol:               "tcp",
+		Address:                "127.0.0.1:0",
+		TLSConfig:              tlsConfig,
+		RequireSecureTransport: requireTLS,
+	}, engine)
+	require.NoError(t, err)
+	s.Start()
+	t.Cleanup(func() { s.Close() })
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	name := t.Name()
+	require.NoError(t, mysqldriver.RegisterTLSConfig(name, &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}))
+	t.Cleanup(func() { mysqldriver.DeregisterTLSConfig(name) })
+
+	return s.Addr(), s.Handler, name
+}
+
+func TestServer_TLS(t *testing.T) {
+	require := require.New(t)
+	addr, handler, tlsName := startTLSServer(t, false)
+
+	for _, dsn := range []string{
+		fmt.Sprintf("root@tcp(%s)/testdb?tls=%s", addr, tlsName),
+		// Clients not asking for TLS still get in.
+		fmt.Sprintf("root@tcp(%s)/testdb", addr),
+	} {
+		db, err := sql.Open("mysql", dsn)
+		require.NoError(err)
+
+		var n int
+		require.NoError(db.QueryRow("SELECT 1").Scan(&n))
+		require.Equal(1, n)
+		require.NoError(db.Close())
+	}
+
+	// Sessions of TLS connections are cleaned up when they are closed.
+	require.Eventually(func() bool {
+		return handler.openConns() == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestServer_RequireSecureTransport(t *testing.T) {
+	require := require.New(t)
+	addr, _, tlsName := startTLSServer(t, true)
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", addr))
+	require.NoError(err)
+	defer db.Close()
+	err = db.Ping()
+	require.Error(err)
+	require.Contains(err.Error(), "insecure connections")
+
+	db, err = sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb?tls=%s", addr, tlsName))
+	require.NoError(err)
+	defer db.Close()
+	var n int
+	require.NoError(db.QueryRow("SELECT 1").Scan(&n))
+	require.Equal(1, n)
+}
+
+func TestNewServer_RequireSecureTransportWithoutTLS(t *testing.T) {
+	_, err := NewDefaultServer(Config{
+		Protocol:               "tcp",
+		Address:                "127.0.0.1:0",
+		RequireSecureTransport: true,
+	}, nil)
+	require.Equal(t, ErrSecureTransportWithoutTLS, err)
+}
+
+func TestSecureTransportAuth_RejectsPlaintext(t *testing.T) {
+	require := require.New(t)
+	a := secureTransportAuth{auth.NewNativeSingle("root", "", auth.AllPermissions).Mysql()}
+	methods := a.AuthMethods()
+	require.NotEmpty(methods)
+
+	plain := &mysql.Conn{}
+	for _, m := range methods {
+		require.False(m.HandleUser(plain, "root"))
+
+		_, err := m.HandleAuthPluginData(plain, "root", nil, nil, nil)
+		require.Error(err)
+		require.Contains(err.Error(), "connections must use SSL/TLS")
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_RowConstructorIn(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	for _, q := range []string{
+		"CREATE TABLE t (id BIGINT PRIMARY KEY, a BIGINT, b BIGINT, c TEXT)",
+		"INSERT INTO t VALUES (1, 1, 2, 'x'), (2, 3, 4, 'y'), (3, 1, 4, 'z'), (4, 5, NULL, 'n')",
+	} {
+		_, err := db.Exec(q)
+		require.NoError(err)
+	}
+
+	query := func(q string, args ...interface{}) []string {
+		t.Helper()
+		rows, err := db.Query(q, args...)
+		require.NoError(err)
+		defer rows.Close()
+
+		var values []string
+		for rows.Next() {
+			var c string
+			require.NoError(rows.Scan(&c))
+			values = append(values, c)
+		}
+		require.NoError(rows.Err())
+		return values
+	}
+
+	require.Equal([]string{"x", "y"}, query("SELECT c FROM t WHERE (a, b) IN ((1, 2), (3, 4)) ORDER BY id"))
+	require.Equal([]string{"x", "y"}, query("SELECT c FROM t WHERE (a, b) IN ((?, ?), (?, ?)) ORDER BY id", 1, 2, 3, 4))
+	require.Equal([]string{"z", "n"}, query("SELECT c FROM t WHERE (a, b) NOT IN ((1, 2), (3, 4)) ORDER BY id"))
+	require.Equal([]string{"x"}, query("SELECT c FROM t WHERE (b, a) IN ((2, 1)) ORDER BY id"))
+
+	// A tuple with NULL only matches if it's told apart by its other values.
+	require.Empty(query("SELECT c FROM t WHERE (a, b) IN ((5, NULL))"))
+	require.Equal([]string{"y"}, query("SELECT c FROM t WHERE (a, b) IN ((1, NULL), (3, 4)) ORDER BY id"))
+	require.Equal([]string{"y", "n"}, query("SELECT c FROM t WHERE (a, b) NOT IN ((1, NULL)) ORDER BY id"))
+
+	var result *bool
+	require.NoError(db.QueryRow("SELECT (1, NULL) IN ((1, 2))").Scan(&result))
+	require.Nil(result)
+	require.NoError(db.QueryRow("SELECT (1, NULL) IN ((2, 2))").Scan(&result))
+	require.False(*result)
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2E_UUID(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	_, err := db.Exec("CREATE TABLE t (id BINARY(16) PRIMARY KEY, n BIGINT)")
+	require.NoError(err)
+
+	for i := 0; i < 10; i++ {
+		_, err = db.Exec("INSERT INTO t VALUES (UUID(), ?)", i)
+		require.NoError(err)
+	}
+	// The same UUID can be written in other forms.
+	const known = "6ccd780c-baba-1026-9564-5b8c656024db"
+	_, err = db.Exec("INSERT INTO t VALUES ('{6CCD780C-BABA-1026-9564-5B8C656024DB}', 10)")
+	require.NoError(err)
+
+	rows, err := db.Query("SELECT id FROM t ORDER BY id")
+	require.NoError(err)
+	var ids []string
+	for rows.Next() {
+		var id string
+		require.NoError(rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(rows.Err())
+	require.NoError(rows.Close())
+	require.Len(ids, 11)
+	require.Contains(ids, known)
+
+	// The UUIDs come back in their canonical form, sorted by their bytes.
+	require.True(sort.SliceIsSorted(ids, func(i, j int) bool {
+		a, b := uuid.MustParse(ids[i]), uuid.MustParse(ids[j])
+		return bytes.Compare(a[:], b[:]) < 0
+	}))
+	for _, id := range ids {
+		u, err := uuid.Parse(id)
+		require.NoError(err)
+		require.Equal(u.String(), id)
+		if id != known {
+			require.Equal(uuid.Version(4), u.Version())
+		}
+	}
+
+	var n int64
+	require.NoError(db.QueryRow("SELECT n FROM t WHERE id = '" + strings.ToUpper(known) + "'").Scan(&n))
+	require.Equal(int64(10), n)
+
+	var s string
+	require.NoError(db.QueryRow("SHOW CREATE TABLE t").Scan(&s, &s))
+	require.Contains(s, "`id` BINARY(16)")
+
+	_, err = db.Exec("INSERT INTO t VALUES ('not-a-uuid', 11)")
+	var mysqlErr *mysqldriver.MySQLError
+	require.ErrorAs(err, &mysqlErr)
+	require.Equal(uint16(ERWrongValueForType), mysqlErr.Number)
+	require.Contains(mysqlErr.Message, "not-a-uuid")
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+func TestHandler_WarningCount(t *testing.T) {
+	require := require.New(t)
+
+	db := mem.NewDatabase("testdb")
+	db.AddTable("t", mem.NewTable("t", sql.Schema{
+		{Name: "id", Type: sql.Int32, Source: "t"},
+		{Name: "code", Type: sql.VarChar(2), Source: "t", Nullable: true},
+	}))
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	e := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+	h := NewHandler(e, NewSessionManager(DefaultSessionBuilder, nil, "localhost:3306"))
+
+	conn := &mysql.Conn{ConnectionID: 1}
+	h.NewConnection(conn)
+	require.NoError(h.ComInitDB(conn, "testdb"))
+
+	query := func(q string) {
+		t.Helper()
+		require.NoError(h.ComQuery(context.Background(), conn, q, func(*sqltypes.Result, bool) error { return nil }))
+	}
+
+	query("INSERT INTO t (id, code) VALUES (1, 'abc'), (2, 'de'), (3, 'fgh')")
+	require.Equal(uint16(2), h.WarningCount(conn))
+
+	query("SELECT * FROM t")
+	require.Equal(uint16(0), h.WarningCount(conn))
+}
+
+func TestE2E_TruncationWarning(t *testing.T) {
+	require := require.New(t)
+	db := startBadgerTestServer(t)
+
+	// Warnings belong to the session, so everything goes through the same
+	// connection.
+	conn, err := db.Conn(context.Background())
+	require.NoError(err)
+	defer conn.Close()
+
+	_, err = conn.ExecContext(context.Background(), "CREATE TABLE t (id BIGINT PRIMARY KEY, name VARCHAR(5))")
+	require.NoError(err)
+
+	_, err = conn.ExecContext(context.Background(), "INSERT INTO t (id, name) VALUES (1, 'abcdefgh')")
+	require.NoError(err)
+
+	showWarnings := func() [][]interface{} {
+		t.Helper()
+		rows, err := conn.QueryContext(context.Background(), "SHOW WARNINGS")
+		require.NoError(err)
+		defer rows.Close()
+
+		var warnings [][]interface{}
+		for rows.Next() {
+			var level, message string
+			var code int
+			require.NoError(rows.Scan(&level, &code, &message))
+			warnings = append(warnings, []interface{}{level, code, message})
+		}
+		require.NoError(rows.Err())
+		return warnings
+	}
+
+	require.Equal([][]interface{}{
+		{"Warning", 1265, "Data truncated for column 'name' at row 1"},
+	}, showWarnings())
+
+	var name string
+	require.NoError(conn.QueryRowContext(context.Background(), "SELECT name FROM t WHERE id = 1").Scan(&name))
+	require.Equal("abcde", name)
+
+	// The next statement starts without warnings.
+	require.Empty(showWarnings())
+
+	_, err = conn.ExecContext(context.Background(), "UPDATE t SET name = 'ñandúes' WHERE id = 1")
+	require.NoError(err)
+	require.Len(showWarnings(), 1)
+	require.NoError(conn.QueryRowContext(context.Background(), "SELECT name FROM t WHERE id = 1").Scan(&name))
+	require.Equal("ñandú", name)
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/dolthub/vitess/go/vt/proto/query"
+	"github.com/turtacn/guocedb/compute/transaction"
+)
+
+// XA statements are not supported by the SQL parser, so they are handled
+// before queries reach the engine, the same as KILL.
+var regXACmd = regexp.MustCompile(`(?is)^xa\s+(start|begin|end|prepare|commit|rollback|recover)\b\s*(.*?)\s*;?\s*$`)
+
+// handleXA handles the XA statements, which drive transactions that are
+// committed in two phases by an external transaction coordinator.
+func (h *Handler) handleXA(sess *Session, query string, callback mysql.ResultSpoolFn) (bool, error) {
+	s := regXACmd.FindStringSubmatch(strings.TrimSpace(query))
+	if s == nil || sess == nil {
+		return false, nil
+	}
+
+	cmd := strings.ToLower(s[1])
+	if cmd == "recover" {
+		if s[2] != "" {
+			return true, NewParseError("unsupported XA RECOVER option: %s", s[2])
+		}
+		return true, h.handleXARecover(callback)
+	}
+
+	xid, rest, err := parseXID(s[2])
+	if err != nil {
+		return true, NewParseError("%s", err)
+	}
+
+	onePhase := false
+	if rest != "" {
+		if cmd != "commit" || !strings.EqualFold(strings.Join(strings.Fields(rest), " "), "one phase") {
+			return true, NewParseError("unsupported XA %s option: %s", strings.ToUpper(cmd), rest)
+		}
+		onePhase = true
+	}
+
+	switch cmd {
+	case "start", "begin":
+		if sess.GetTransaction() != nil {
+			return true, mysql.NewSQLError(1400, "HY000", "Transaction already started")
+		}
+
+		txn, err := h.txnManager.XAStart(xid, transactionOptions(sess))
+		if err != nil {
+			return true, h.convertError(err)
+		}
+		sess.SetTransaction(txn)
+	case "end":
+		// Only the session that started the transaction can end it.
+		txn, ok := sess.GetTransaction().(*transaction.Transaction)
+		if !ok {
+			return true, h.convertError(transaction.ErrXANotFound)
+		}
+		if id, ok := txn.XID(); !ok || id != xid {
+			return true, h.convertError(transaction.ErrXANotFound)
+		}
+
+		if err := h.txnManager.XAEnd(xid); err != nil {
+			return true, h.convertError(err)
+		}
+		sess.SetTransaction(nil)
+	case "prepare":
+		err = h.txnManager.XAPrepare(xid)
+	case "commit":
+		err = h.txnManager.XACommit(xid, onePhase)
+	case "rollback":
+		err = h.txnManager.XARollback(xid)
+	}
+
+	if err != nil {
+		return true, h.convertError(err)
+	}
+
+	// The transaction may have been started by another session, so it's
+	// not known which tables it wrote to.
+	if cmd == "commit" && h.cache != nil {
+		h.cache.Invalidate(allTables)
+	}
+
+	return true, callback(&sqltypes.Result{}, false)
+}
+
+func (h *Handler) handleXARecover(callback mysql.ResultSpoolFn) error {
+	xids, err := h.txnManager.XARecover()
+	if err != nil {
+		return h.convertError(err)
+	}
+
+	r := &sqltypes.Result{Fields: []*query.Field{
+		{Name: "formatID", Type: sqltypes.Int64},
+		{Name: "gtrid_length", Type: sqltypes.Int64},
+		{Name: "bqual_length", Type: sqltypes.Int64},
+		{Name: "data", Type: sqltypes.VarChar},
+	}}
+	for _, xid := range xids {
+		r.Rows = append(r.Rows, []sqltypes.Value{
+			sqltypes.NewInt64(xid.FormatID),
+			sqltypes.NewInt64(int64(len(xid.GTRID))),
+			sqltypes.NewInt64(int64(len(xid.BQUAL))),
+			sqltypes.NewVarChar(xid.GTRID + xid.BQUAL),
+		})
+	}
+	r.RowsAffected = uint64(len(r.Rows))
+
+	return callback(r, false)
+}
+
+// parseXID parses a XID written as gtrid[, bqual[, formatID]] at the start
+// of s, and returns what comes after it.
+func parseXID(s string) (transaction.XID, string, error) {
+	xid := transaction.XID{FormatID: 1}
+
+	gtrid, rest, err := parseXIDString(s)
+	if err != nil {
+		return xid, "", err
+	}
+	if gtrid == "" {
+		return xid, "", fmt.Errorf("XID gtrid can't be empty")
+	}
+	xid.GTRID = gtrid
+
+	if rest, ok := cutComma(rest); ok {
+		if xid.BQUAL, rest, err = parseXIDString(rest); err != nil {
+			return xid, "", err
+		}
+
+		if rest, ok := cutComma(rest); ok {
+			end := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+			if end < 0 {
+				end = len(rest)
+			}
+
+			if xid.FormatID, err = strconv.ParseInt(rest[:end], 10, 64); err != nil {
+				return xid, "", fmt.Errorf("invalid XID format id: %q", rest)
+			}
+			return xid, strings.TrimSpace(rest[end:]), nil
+		}
+		return xid, rest, nil
+	}
+
+	return xid, rest, nil
+}
+
+// parseXIDString parses a quoted string at the start of s and returns its
+// value and what comes after it.
+func parseXIDString(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '\'' && s[0] != '"') {
+		return "", "", fmt.Errorf("expecting a quoted XID string, got %q", s)
+	}
+
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case c == quote && i+1 < len(s) && s[i+1] == quote:
+			i++
+			b.WriteByte(quote)
+		case c == quote:
+			return b.String(), strings.TrimSpace(s[i+1:]), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated XID string: %s", s)
+}
+
+func cutComma(s string) (string, bool) {
+	if strings.HasPrefix(s, ",") {
+		return strings.TrimSpace(s[1:]), true
+	}
+	return s, false
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/transaction"
+)
+
+func TestParseXID(t *testing.T) {
+	testCases := []struct {
+		in   string
+		xid  transaction.XID
+		rest string
+		err  bool
+	}{
+		{`'trx1'`, transaction.XID{GTRID: "trx1", FormatID: 1}, "", false},
+		{`'trx1', "b1"`, transaction.XID{GTRID: "trx1", BQUAL: "b1", FormatID: 1}, "", false},
+		{`'trx1','b1',42 ONE PHASE`, transaction.XID{GTRID: "trx1", BQUAL: "b1", FormatID: 42}, "ONE PHASE", false},
+		{`'it''s\'' one phase`, transaction.XID{GTRID: "it's'", FormatID: 1}, "one phase", false},
+		{`trx1`, transaction.XID{}, "", true},
+		{`''`, transaction.XID{}, "", true},
+		{`'trx1`, transaction.XID{}, "", true},
+		{`'trx1','b1',x`, transaction.XID{}, "", true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.in, func(t *testing.T) {
+			require := require.New(t)
+			xid, rest, err := parseXID(tt.in)
+			if tt.err {
+				require.Error(err)
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tt.xid, xid)
+			require.Equal(tt.rest, rest)
+		})
+	}
+}
+
+func TestHandler_XA(t *testing.T) {
+	require := require.New(t)
+
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer db.Close()
+
+	h, conn := setupTestHandler()
+	h.txnManager = transaction.NewManagerWithDB(db)
+
+	query := func(q string) (*sqltypes.Result, error) {
+		var result *sqltypes.Result
+		err := h.ComQuery(context.Background(), conn, q, func(r *sqltypes.Result, more bool) error {
+			result = r
+			return nil
+		})
+		return result, err
+	}
+	requireXAErr := func(code int, err error) {
+		require.Error(err)
+		sqlErr, ok := err.(*mysql.SQLError)
+		require.True(ok, err.Error())
+		require.Equal(code, sqlErr.Num)
+	}
+
+	_, err = query("XA START 'trx1', 'b1'")
+	require.NoError(err)
+	sess := h.sessionMgr.GetSession(conn.ConnectionID)
+	txn, ok := sess.GetTransaction().(*transaction.Transaction)
+	require.True(ok)
+	require.NoError(txn.Set([]byte("key"), []byte("value")))
+
+	_, err = query("XA PREPARE 'trx1', 'b1'")
+	requireXAErr(ERXAERRmfail, err)
+	_, err = query("XA END 'trx2'")
+	requireXAErr(ERXAERNota, err)
+
+	_, err = query("xa end 'trx1','b1'")
+	require.NoError(err)
+	require.Nil(sess.GetTransaction())
+
+	_, err = query("XA PREPARE 'trx1','b1'")
+	require.NoError(err)
+
+	r, err := query("XA RECOVER")
+	require.NoError(err)
+	require.Len(r.Fields, 4)
+	require.Equal([][]sqltypes.Value{{
+		sqltypes.NewInt64(1),
+		sqltypes.NewInt64(4),
+		sqltypes.NewInt64(2),
+		sqltypes.NewVarChar("trx1b1"),
+	}}, r.Rows)
+
+	_, err = query("XA COMMIT 'trx1','b1' ONE PHASE")
+	requireXAErr(ERXAERRmfail, err)
+	_, err = query("XA COMMIT 'trx1','b1'")
+	require.NoError(err)
+	_, err = query("XA COMMIT 'trx1','b1'")
+	requireXAErr(ERXAERNota, err)
+
+	r, err = query("XA RECOVER")
+	require.NoError(err)
+	require.Empty(r.Rows)
+
+	_, err = query("XA START 'trx1' JOIN")
+	requireXAErr(ERParseError, err)
+}
@@ -25,14 +25,15 @@ func reorderAggregations(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, e
 
 			a.Log("fixing aggregations of node of type: %T", n)
 
-			return fixAggregations(n.Aggregate, n.Grouping, n.Child)
+			return fixAggregations(n)
 		default:
 			return n, nil
 		}
 	})
 }
 
-func fixAggregations(projection, grouping []sql.Expression, child sql.Node) (sql.Node, error) {
+func fixAggregations(g *plan.GroupBy) (sql.Node, error) {
+	projection := g.Aggregate
 	var aggregate = make([]sql.Expression, 0, len(projection))
 	var newProjection = make([]sql.Expression, len(projection))
 
@@ -67,7 +68,7 @@ func fixAggregations(projection, grouping []sql.Expression, child sql.Node) (sql
 
 	return plan.NewProject(
 		newProjection,
-		plan.NewGroupBy(aggregate, grouping, child),
+		g.WithAggregate(aggregate, g.Child),
 	), nil
 }
 
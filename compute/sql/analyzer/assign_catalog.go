@@ -42,6 +42,10 @@ func assignCatalog(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 			nc := *node
 			nc.Catalog = a.Catalog
 			return &nc, nil
+		case *plan.ShowEngines:
+			nc := *node
+			nc.Registry = a.Catalog.EngineRegistry
+			return &nc, nil
 		case *plan.ShowCreateTable:
 			nc := *node
 			nc.Catalog = a.Catalog
@@ -60,6 +64,11 @@ func assignCatalog(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 			nc := *node
 			nc.Catalog = a.Catalog
 			return &nc, nil
+		case *plan.AnalyzeTable:
+			nc := *node
+			nc.Catalog = a.Catalog
+			nc.CurrentDatabase = a.Catalog.CurrentDatabase()
+			return &nc, nil
 		case *plan.LockTables:
 			nc := *node
 			nc.Catalog = a.Catalog
@@ -68,6 +77,10 @@ func assignCatalog(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 			nc := *node
 			nc.Catalog = a.Catalog
 			return &nc, nil
+		case *plan.Set:
+			nc := *node
+			nc.Catalog = a.Catalog
+			return &nc, nil
 		default:
 			return n, nil
 		}
@@ -62,6 +62,12 @@ func TestAssignCatalog(t *testing.T) {
 	require.True(ok)
 	require.Equal(c, sd.Catalog)
 
+	node, err = f.Apply(sql.NewEmptyContext(), a, plan.NewShowEngines())
+	require.NoError(err)
+	se, ok := node.(*plan.ShowEngines)
+	require.True(ok)
+	require.Equal(c.EngineRegistry, se.Registry)
+
 	node, err = f.Apply(sql.NewEmptyContext(), a, plan.NewLockTables(nil))
 	require.NoError(err)
 	lt, ok := node.(*plan.LockTables)
@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/parse"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+// loadChecks sets the CHECK constraints of the table rows are inserted into
+// or updated in, parsed against the schema of the table, in the nodes
+// writing them.
+func loadChecks(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, ctx := ctx.Span("load_checks")
+	defer span.Finish()
+
+	return n.TransformUp(func(n sql.Node) (sql.Node, error) {
+		if !n.Resolved() {
+			return n, nil
+		}
+
+		switch node := n.(type) {
+		case *plan.InsertInto:
+			checks, err := tableChecks(node.Left)
+			if err != nil || len(checks) == 0 {
+				return n, err
+			}
+
+			nc := *node
+			nc.Checks = checks
+			return &nc, nil
+		case *plan.Update:
+			checks, err := tableChecks(node.Child)
+			if err != nil || len(checks) == 0 {
+				return n, err
+			}
+
+			nc := *node
+			nc.Checks = checks
+			return &nc, nil
+		default:
+			return n, nil
+		}
+	})
+}
+
+// tableChecks returns the CHECK constraints of the first table of the node,
+// which is the one rows are written to.
+func tableChecks(n sql.Node) ([]plan.Check, error) {
+	var table sql.Table
+	plan.Inspect(n, func(n sql.Node) bool {
+		if rt, ok := n.(*plan.ResolvedTable); ok && table == nil {
+			table = rt.Table
+		}
+		return table == nil
+	})
+
+	for table != nil {
+		if ct, ok := table.(sql.CheckTable); ok {
+			var checks []plan.Check
+			for _, c := range ct.Checks() {
+				expr, err := parse.ParseCheck(c, table.Schema())
+				if err != nil {
+					return nil, err
+				}
+				checks = append(checks, plan.Check{Name: c.Name, Expr: expr})
+			}
+			return checks, nil
+		}
+
+		w, ok := table.(sql.TableWrapper)
+		if !ok {
+			break
+		}
+		table = w.Underlying()
+	}
+
+	return nil, nil
+}
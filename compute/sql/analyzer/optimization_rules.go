@@ -366,6 +366,10 @@ func evalFilter(ctx *sql.Context, a *Analyzer, node sql.Node) (sql.Node, error)
 					return e.Left, nil
 				}
 
+				return e, nil
+			case *expression.Interval:
+				// It's only evaluated as part of the date arithmetic
+				// around it.
 				return e, nil
 			default:
 				if !isEvaluable(e) {
@@ -5,14 +5,20 @@ import (
 	"github.com/turtacn/guocedb/compute/sql/plan"
 )
 
+// shouldParallelize reports whether the tables of the node may be read in
+// parallel. Neither index operations nor the statements writing or locking
+// rows are, since they need the table itself and not an Exchange.
 func shouldParallelize(node sql.Node) bool {
-	// Do not try to parallelize index operations.
-	switch node.(type) {
-	case *plan.CreateIndex, *plan.DropIndex, *plan.Describe:
-		return false
-	default:
-		return true
-	}
+	ok := true
+	plan.Inspect(node, func(node sql.Node) bool {
+		switch node.(type) {
+		case *plan.CreateIndex, *plan.DropIndex, *plan.Describe,
+			*plan.InsertInto, *plan.Update, *plan.DeleteFrom, *plan.LockRows:
+			ok = false
+		}
+		return ok
+	})
+	return ok
 }
 
 func parallelize(ctx *sql.Context, a *Analyzer, node sql.Node) (sql.Node, error) {
@@ -20,8 +26,7 @@ func parallelize(ctx *sql.Context, a *Analyzer, node sql.Node) (sql.Node, error)
 		return node, nil
 	}
 
-	proc, ok := node.(*plan.QueryProcess)
-	if (ok && !shouldParallelize(proc.Child)) || !shouldParallelize(node) {
+	if !shouldParallelize(node) {
 		return node, nil
 	}
 
@@ -72,6 +72,24 @@ func TestParallelizeCreateIndex(t *testing.T) {
 	require.Equal(node, result)
 }
 
+func TestParallelizeWrites(t *testing.T) {
+	table := mem.NewTable("t", nil)
+	rule := getRuleFrom(OnceAfterAll, "parallelize")
+	filter := plan.NewFilter(expression.NewLiteral(true, sql.Boolean), plan.NewResolvedTable(table))
+
+	nodes := []sql.Node{
+		plan.NewInsertInto(plan.NewResolvedTable(table), filter, nil),
+		plan.NewUpdate(filter, nil, nil),
+		plan.NewDeleteFrom(filter),
+		plan.NewLimit(1, plan.NewLockRows(sql.RowLockExclusive, filter)),
+	}
+	for _, node := range nodes {
+		result, err := rule.Apply(sql.NewEmptyContext(), &Analyzer{Parallelism: 2}, node)
+		require.NoError(t, err)
+		require.Equal(t, node, result)
+	}
+}
+
 func TestIsParallelizable(t *testing.T) {
 	table := mem.NewTable("t", nil)
 
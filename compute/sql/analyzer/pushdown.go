@@ -20,7 +20,7 @@ func pushdown(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 
 	// don't do pushdown on certain queries
 	switch n.(type) {
-	case *plan.InsertInto, *plan.CreateIndex:
+	case *plan.InsertInto, *plan.Update, *plan.DeleteFrom, *plan.Returning, *plan.CreateIndex:
 		return n, nil
 	}
 
@@ -71,6 +71,26 @@ func pushdown(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 		return true
 	})
 
+	// The filters of the tables padded with NULL by outer joins must see the
+	// rows after they are padded, so they are left above the joins.
+	plan.Inspect(n, func(node sql.Node) bool {
+		if j, ok := node.(*plan.OuterJoin); ok {
+			var padded []sql.Node
+			if j.Type != plan.LeftJoinType {
+				padded = append(padded, j.Left)
+			}
+			if j.Type != plan.RightJoinType {
+				padded = append(padded, j.Right)
+			}
+			for _, side := range padded {
+				for _, source := range nodeSources(side) {
+					delete(filters, source)
+				}
+			}
+		}
+		return true
+	})
+
 	filterSpan.Finish()
 
 	indexSpan, _ := ctx.Span("assign_indexes")
@@ -187,13 +207,21 @@ func pushdown(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 				return nil, err
 			}
 
-			if ij, ok := n.(*plan.InnerJoin); ok {
-				cond, err := fixFieldIndexes(ij.Schema(), ij.Cond)
+			switch j := n.(type) {
+			case *plan.InnerJoin:
+				cond, err := fixFieldIndexes(j.Schema(), j.Cond)
+				if err != nil {
+					return nil, err
+				}
+
+				n = plan.NewInnerJoin(j.Left, j.Right, cond)
+			case *plan.OuterJoin:
+				cond, err := fixFieldIndexes(j.Schema(), j.Cond)
 				if err != nil {
 					return nil, err
 				}
 
-				n = plan.NewInnerJoin(ij.Left, ij.Right, cond)
+				n = plan.NewOuterJoin(j.Left, j.Right, cond, j.Type)
 			}
 
 			return n, nil
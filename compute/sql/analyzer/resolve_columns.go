@@ -51,10 +51,21 @@ func qualifyColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 		}
 	}
 
+	// The tables rows are inserted into are not in the scope of the
+	// queries of INSERT ... SELECT, so their columns are not indexed until
+	// the inserts are. The destination of an insert is transformed before
+	// its source, which may read the same table, so the first table with
+	// its name is skipped.
+	insertTables := make(map[string]int)
 	var projects, seenProjects int
 	plan.Inspect(n, func(n sql.Node) bool {
-		if _, ok := n.(*plan.Project); ok {
+		switch n := n.(type) {
+		case *plan.Project:
 			projects++
+		case *plan.InsertInto:
+			if rt, ok := n.Left.(*plan.ResolvedTable); ok {
+				insertTables[strings.ToLower(rt.Name())]++
+			}
 		}
 		return true
 	})
@@ -74,6 +85,10 @@ func qualifyColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 		case *plan.ResolvedTable, *plan.SubqueryAlias:
 			name := strings.ToLower(n.(sql.Nameable).Name())
 			tables[name] = n
+			if _, ok := n.(*plan.ResolvedTable); ok && insertTables[name] > 0 {
+				insertTables[name]--
+				break
+			}
 			indexCols(name, n.Schema())
 		}
 
@@ -154,6 +169,14 @@ func qualifyColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 			return nil, err
 		}
 
+		// The columns of the table rows were inserted into are in the scope
+		// of the nodes returning them.
+		if insert, ok := n.(*plan.InsertInto); ok {
+			if rt, ok := insert.Left.(*plan.ResolvedTable); ok {
+				indexCols(rt.Name(), rt.Schema())
+			}
+		}
+
 		// We should ignore the topmost project, because some nodes are
 		// reordered, such as Sort, and they would not be resolved well.
 		if n, ok := result.(*plan.Project); ok && projects-seenProjects > 1 {
@@ -276,13 +299,22 @@ func resolveColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 							return nil, errGlobalVariablesNotSupported.New(uc)
 						}
 
+						var global bool
 						name := strings.TrimLeft(uc.Name(), "@")
 						if strings.HasPrefix(name, sessionPrefix) {
 							name = name[len(sessionPrefix):]
 						} else if strings.HasPrefix(name, globalPrefix) {
 							name = name[len(globalPrefix):]
+							global = true
 						}
 						typ, value := ctx.Get(name)
+						// Variables the session doesn't have are looked up
+						// in the global ones.
+						if (global || typ == sql.Null) && a.Catalog != nil {
+							if v, ok := a.Catalog.GlobalVariable(name); ok {
+								typ, value = v.Typ, v.Value
+							}
+						}
 						return expression.NewGetSessionField(name, typ, value), nil
 					}
 
@@ -498,10 +530,7 @@ func resolveGroupingColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node
 			}
 		}
 
-		return plan.NewGroupBy(
-			newAggregate, g.Grouping,
-			plan.NewProject(projection, g.Child),
-		), nil
+		return g.WithAggregate(newAggregate, plan.NewProject(projection, g.Child)), nil
 	})
 }
 
@@ -52,6 +52,57 @@ func TestQualifyColumnsProject(t *testing.T) {
 	require.Equal(expected, result)
 }
 
+// The columns of the query of INSERT ... SELECT are qualified with the
+// tables it reads, not the one rows are inserted into.
+func TestQualifyColumnsInsertSelect(t *testing.T) {
+	require := require.New(t)
+
+	schema := func(source string) sql.Schema {
+		return sql.Schema{
+			{Name: "a", Type: sql.Int64, Source: source},
+			{Name: "b", Type: sql.Text, Source: source},
+		}
+	}
+	src := mem.NewTable("src", schema("src"))
+	dst := mem.NewTable("dst", schema("dst"))
+
+	insert := func(table sql.Table, a, b sql.Expression) sql.Node {
+		return plan.NewInsertInto(
+			plan.NewResolvedTable(dst),
+			plan.NewProject(
+				[]sql.Expression{a, b},
+				plan.NewResolvedTable(table),
+			),
+			nil,
+		)
+	}
+
+	result, err := qualifyColumns(sql.NewEmptyContext(), NewDefault(nil), insert(
+		src,
+		expression.NewUnresolvedColumn("a"),
+		expression.NewUnresolvedColumn("b"),
+	))
+	require.NoError(err)
+	require.Equal(insert(
+		src,
+		expression.NewUnresolvedQualifiedColumn("src", "a"),
+		expression.NewUnresolvedQualifiedColumn("src", "b"),
+	), result)
+
+	// The table is both the destination and the source of the rows.
+	result, err = qualifyColumns(sql.NewEmptyContext(), NewDefault(nil), insert(
+		dst,
+		expression.NewUnresolvedColumn("a"),
+		expression.NewUnresolvedColumn("b"),
+	))
+	require.NoError(err)
+	require.Equal(insert(
+		dst,
+		expression.NewUnresolvedQualifiedColumn("dst", "a"),
+		expression.NewUnresolvedQualifiedColumn("dst", "b"),
+	), result)
+}
+
 func TestMisusedAlias(t *testing.T) {
 	require := require.New(t)
 	f := getRule("resolve_columns")
@@ -31,7 +31,44 @@ func resolveDatabase(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error
 		nc.Database = db
 		return &nc, nil
 	case *plan.CreateTable:
-		db, err := a.Catalog.Database(a.Catalog.CurrentDatabase())
+		db, err := a.Catalog.Database(databaseOrCurrent(a, v.Database))
+		if err != nil {
+			return nil, err
+		}
+
+		nc := *v
+		nc.Database = db
+		nc.DatabaseCollation = a.Catalog.DatabaseCollation(db.Name())
+		return &nc, nil
+	case *plan.AddColumn:
+		db, err := a.Catalog.Database(databaseOrCurrent(a, v.Database))
+		if err != nil {
+			return nil, err
+		}
+
+		nc := *v
+		nc.Database = db
+		return &nc, nil
+	case *plan.DropColumn:
+		db, err := a.Catalog.Database(databaseOrCurrent(a, v.Database))
+		if err != nil {
+			return nil, err
+		}
+
+		nc := *v
+		nc.Database = db
+		return &nc, nil
+	case *plan.AlterAutoIncrement:
+		db, err := a.Catalog.Database(databaseOrCurrent(a, v.Database))
+		if err != nil {
+			return nil, err
+		}
+
+		nc := *v
+		nc.Database = db
+		return &nc, nil
+	case *plan.TruncateTable:
+		db, err := a.Catalog.Database(databaseOrCurrent(a, v.Database))
 		if err != nil {
 			return nil, err
 		}
@@ -61,3 +98,12 @@ func resolveDatabase(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error
 		return n, nil
 	}
 }
+
+// databaseOrCurrent returns the name of the database, or the current one if
+// the statement didn't name any.
+func databaseOrCurrent(a *Analyzer, db sql.Database) string {
+	if db.Name() == "" {
+		return a.Catalog.CurrentDatabase()
+	}
+	return db.Name()
+}
@@ -33,6 +33,20 @@ func resolveFunctions(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, erro
 				return nil, err
 			}
 
+			// The types of the arguments of a typed function are checked, so
+			// it waits until they're resolved.
+			if tf, ok := f.(sql.TypedFunction); ok {
+				for _, arg := range uf.Arguments {
+					if !arg.Resolved() {
+						return e, nil
+					}
+				}
+
+				if err := tf.CheckArguments(n, uf.Arguments...); err != nil {
+					return nil, err
+				}
+			}
+
 			rf, err := f.Call(uf.Arguments...)
 			if err != nil {
 				return nil, err
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+// resolveOnDuplicate resolves the assignments of INSERT ... ON DUPLICATE
+// KEY UPDATE against the schema of the table rows are inserted into. Columns
+// are the fields of the conflicting row, and VALUES(col) the fields of the
+// row being inserted, which follows it.
+func resolveOnDuplicate(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, ctx := ctx.Span("resolve_on_duplicate")
+	defer span.Finish()
+
+	return n.TransformUp(func(n sql.Node) (sql.Node, error) {
+		insert, ok := n.(*plan.InsertInto)
+		if !ok || insert.Resolved() || !insert.Left.Resolved() {
+			return n, nil
+		}
+
+		schema := insert.Left.Schema()
+		field := func(name string, offset int) (sql.Expression, error) {
+			for i, col := range schema {
+				if strings.EqualFold(col.Name, name) {
+					return expression.NewGetFieldWithTable(offset+i, col.Type, col.Source, col.Name, col.Nullable), nil
+				}
+			}
+			return nil, ErrColumnNotFound.New(name)
+		}
+
+		resolve := func(e sql.Expression) (sql.Expression, error) {
+			switch e := e.(type) {
+			case *expression.InsertValue:
+				return field(e.Name(), len(schema))
+			case column:
+				return field(e.Name(), 0)
+			default:
+				return e, nil
+			}
+		}
+
+		columns := make([]sql.Expression, len(insert.OnDupColumns))
+		for i, e := range insert.OnDupColumns {
+			c, err := e.TransformUp(resolve)
+			if err != nil {
+				return nil, err
+			}
+			columns[i] = c
+		}
+
+		values := make([]sql.Expression, len(insert.OnDupValues))
+		for i, e := range insert.OnDupValues {
+			v, err := e.TransformUp(resolve)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+
+		a.Log("resolved the ON DUPLICATE KEY UPDATE assignments of the insert into %s", insert.Left)
+		return insert.WithOnDuplicate(columns, values), nil
+	})
+}
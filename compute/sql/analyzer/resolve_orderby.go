@@ -118,7 +118,7 @@ func fixSortDependencies(sort *plan.Sort, missingCols []string) (sql.Node, error
 			expressions,
 			plan.NewSort(
 				sort.SortFields,
-				plan.NewGroupBy(newExpressions, child.Grouping, child.Child),
+				child.WithAggregate(newExpressions, child.Child),
 			),
 		), nil
 	default:
@@ -158,11 +158,7 @@ func pushSortDown(sort *plan.Sort) (sql.Node, error) {
 			plan.NewSort(sort.SortFields, child.Child),
 		), nil
 	case *plan.GroupBy:
-		return plan.NewGroupBy(
-			child.Aggregate,
-			child.Grouping,
-			plan.NewSort(sort.SortFields, child.Child),
-		), nil
+		return child.WithChild(plan.NewSort(sort.SortFields, child.Child)), nil
 	default:
 		// Can't do anything here, there should be either a project or a groupby
 		// below an order by.
@@ -39,7 +39,21 @@ func resolveStar(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 				return nil, err
 			}
 
-			return plan.NewGroupBy(aggregate, n.Grouping, n.Child), nil
+			return n.WithAggregate(aggregate, n.Child), nil
+		case *plan.Returning:
+			if !n.Child.Resolved() {
+				return n, nil
+			}
+
+			expressions, err := expandStars(n.Projections, n.Child.Schema())
+			if err != nil {
+				return nil, err
+			}
+
+			return &plan.Returning{
+				UnaryNode:   plan.UnaryNode{Child: n.Child},
+				Projections: expressions,
+			}, nil
 		default:
 			return n, nil
 		}
@@ -48,6 +48,14 @@ func resolveTables(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 			}
 		}
 
+		// Tables choose how they are read by the statistics collected by
+		// ANALYZE TABLE, if they can.
+		if st, ok := rt.(sql.StatisticsTable); ok {
+			if stats := a.Catalog.TableStatistics(db, name); stats != nil {
+				rt = st.WithStatistics(stats)
+			}
+		}
+
 		a.Log("table resolved: %q", t.Name())
 
 		return plan.NewResolvedTable(rt), nil
@@ -12,6 +12,7 @@ var DefaultRules = []Rule{
 	{"resolve_grouping_columns", resolveGroupingColumns},
 	{"qualify_columns", qualifyColumns},
 	{"resolve_columns", resolveColumns},
+	{"resolve_on_duplicate", resolveOnDuplicate},
 	{"resolve_database", resolveDatabase},
 	{"resolve_star", resolveStar},
 	{"resolve_functions", resolveFunctions},
@@ -36,6 +37,7 @@ var OnceAfterDefault = []Rule{
 	{"assign_catalog", assignCatalog},
 	{"pushdown", pushdown},
 	{"erase_projection", eraseProjection},
+	{"load_checks", loadChecks},
 }
 
 // OnceAfterAll contains the rules to be applied just once after all other
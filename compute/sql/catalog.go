@@ -12,15 +12,29 @@ import (
 // ErrDatabaseNotFound is thrown when a database is not found
 var ErrDatabaseNotFound = errors.NewKind("database not found: %s")
 
+// ErrDatabaseProtected is thrown when dropping a protected database without
+// confirming it first.
+var ErrDatabaseProtected = errors.NewKind("database %s is protected; run SET " + DropDatabaseOverride + " = '%[1]s' first to drop it")
+
+// DropDatabaseOverride is the session variable that must be set to the name
+// of a protected database before dropping it. It is cleared after the drop,
+// so every drop of a protected database has to be confirmed.
+const DropDatabaseOverride = "drop_database_override"
+
 // Catalog holds databases, tables and functions.
 type Catalog struct {
 	FunctionRegistry
 	*IndexRegistry
 	*ProcessList
+	*EngineRegistry
+	*GlobalVariables
 
 	mu              sync.RWMutex
 	currentDatabase string
 	dbs             Databases
+	protected       map[string]struct{}
+	collations      map[string]Collation
+	statistics      map[string]*TableStatistics
 	locks           sessionLocks
 }
 
@@ -36,6 +50,11 @@ func NewCatalog() *Catalog {
 		FunctionRegistry: NewFunctionRegistry(),
 		IndexRegistry:    NewIndexRegistry(),
 		ProcessList:      NewProcessList(),
+		EngineRegistry:   NewEngineRegistry(),
+		GlobalVariables:  NewGlobalVariables(),
+		protected:        make(map[string]struct{}),
+		collations:       make(map[string]Collation),
+		statistics:       make(map[string]*TableStatistics),
 		locks:            make(sessionLocks),
 	}
 }
@@ -83,7 +102,7 @@ func (c *Catalog) CreateDatabase(ctx *Context, name string) error {
 	// Check if database already exists
 	for _, db := range c.dbs {
 		if strings.ToLower(db.Name()) == strings.ToLower(name) {
-			return fmt.Errorf("database %s already exists", name)
+			return ErrDatabaseExists.New(name)
 		}
 	}
 
@@ -101,8 +120,72 @@ func (c *Catalog) CreateDatabase(ctx *Context, name string) error {
 	return nil
 }
 
+// SetDatabaseCollation sets the default collation of the text columns of
+// the tables created in the database with the given name.
+func (c *Catalog) SetDatabaseCollation(name string, collation Collation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.collations[strings.ToLower(name)] = collation
+}
+
+// DatabaseCollation returns the default collation of the text columns of
+// the tables created in the database with the given name.
+func (c *Catalog) DatabaseCollation(name string) Collation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if collation, ok := c.collations[strings.ToLower(name)]; ok {
+		return collation
+	}
+	return DefaultCollation
+}
+
+// SetTableStatistics sets the statistics of the table with the given name
+// in the given database.
+func (c *Catalog) SetTableStatistics(db, table string, stats *TableStatistics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statistics[statisticsKeyOf(db, table)] = stats
+}
+
+// TableStatistics returns the statistics of the table with the given name in
+// the given database, or nil if it hasn't been analyzed.
+func (c *Catalog) TableStatistics(db, table string) *TableStatistics {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.statistics[statisticsKeyOf(db, table)]
+}
+
+func statisticsKeyOf(db, table string) string {
+	return strings.ToLower(db) + "." + strings.ToLower(table)
+}
+
+// ProtectDatabases marks the given databases as protected. Protected
+// databases can only be dropped if the DropDatabaseOverride session variable
+// is set to their name.
+func (c *Catalog) ProtectDatabases(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range names {
+		c.protected[strings.ToLower(name)] = struct{}{}
+	}
+}
+
+// IsDatabaseProtected returns whether the database with the given name is
+// protected.
+func (c *Catalog) IsDatabaseProtected(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.protected[strings.ToLower(name)]
+	return ok
+}
+
 // DropDatabase removes a database from the catalog.
 func (c *Catalog) DropDatabase(ctx *Context, name string) error {
+	protected := c.IsDatabaseProtected(name)
+	if protected && !dropConfirmed(ctx, name) {
+		return ErrDatabaseProtected.New(name)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -122,15 +205,35 @@ func (c *Catalog) DropDatabase(ctx *Context, name string) error {
 	}
 	
 	c.dbs = newDbs
+	delete(c.collations, strings.ToLower(name))
+	for key := range c.statistics {
+		if strings.HasPrefix(key, strings.ToLower(name)+".") {
+			delete(c.statistics, key)
+		}
+	}
 	
 	// If the current database was dropped, clear it
 	if strings.ToLower(c.currentDatabase) == strings.ToLower(name) {
 		c.currentDatabase = ""
 	}
+
+	if protected {
+		ctx.Set(DropDatabaseOverride, Text, nil)
+	}
 	
 	return nil
 }
 
+func dropConfirmed(ctx *Context, name string) bool {
+	if ctx == nil || ctx.Session == nil {
+		return false
+	}
+
+	_, v := ctx.Get(DropDatabaseOverride)
+	confirmed, ok := v.(string)
+	return ok && strings.EqualFold(confirmed, name)
+}
+
 // Database returns the database with the given name.
 func (c *Catalog) Database(db string) (Database, error) {
 	c.mu.RLock()
@@ -54,6 +54,38 @@ func TestCatalogDatabase(t *testing.T) {
 	require.Equal(mydb, db)
 }
 
+func TestCatalogDropProtectedDatabase(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	c.AddDatabase(mem.NewDatabase("foo"))
+	c.AddDatabase(mem.NewDatabase("bar"))
+	c.ProtectDatabases("FOO")
+	require.True(c.IsDatabaseProtected("foo"))
+	require.False(c.IsDatabaseProtected("bar"))
+
+	ctx := sql.NewEmptyContext()
+	err := c.DropDatabase(ctx, "foo")
+	require.Error(err)
+	require.True(sql.ErrDatabaseProtected.Is(err))
+
+	// The override must name the database being dropped.
+	ctx.Set(sql.DropDatabaseOverride, sql.Text, "bar")
+	err = c.DropDatabase(ctx, "foo")
+	require.True(sql.ErrDatabaseProtected.Is(err))
+
+	ctx.Set(sql.DropDatabaseOverride, sql.Text, "foo")
+	require.NoError(c.DropDatabase(ctx, "foo"))
+	_, err = c.Database("foo")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+
+	// The override is only valid for a single drop.
+	_, v := ctx.Get(sql.DropDatabaseOverride)
+	require.Nil(v)
+
+	require.NoError(c.DropDatabase(ctx, "bar"))
+}
+
 func TestCatalogTable(t *testing.T) {
 	require := require.New(t)
 
@@ -0,0 +1,165 @@
+package sql
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// Collation is the set of rules the values of a string type are compared
+// with, which decides which values are equal and how they are sorted.
+type Collation string
+
+const (
+	// Utf8mb4Bin compares strings by their bytes, which sorts them by the
+	// code points of their characters.
+	Utf8mb4Bin Collation = "utf8mb4_bin"
+	// Utf8mb4GeneralCI compares strings character by character regardless
+	// of their case, so 'ABC' and 'abc' are equal.
+	Utf8mb4GeneralCI Collation = "utf8mb4_general_ci"
+
+	// DefaultCollation is the collation of the string types unless another
+	// one is chosen for them.
+	DefaultCollation = Utf8mb4Bin
+)
+
+var (
+	// ErrUnknownCollation is returned when a collation isn't supported.
+	ErrUnknownCollation = errors.NewKind("Unknown collation: '%s'")
+
+	// ErrCollationNotSupported is returned when a collation is chosen for
+	// a type whose values aren't compared as strings.
+	ErrCollationNotSupported = errors.NewKind("collation %s can't be used with type %s")
+)
+
+// collationAliases are the names of the supported collations, along with
+// the ones of the utf8 character set, which is a subset of utf8mb4.
+var collationAliases = map[string]Collation{
+	"utf8mb4_bin":        Utf8mb4Bin,
+	"utf8mb3_bin":        Utf8mb4Bin,
+	"utf8_bin":           Utf8mb4Bin,
+	"utf8mb4_general_ci": Utf8mb4GeneralCI,
+	"utf8mb3_general_ci": Utf8mb4GeneralCI,
+	"utf8_general_ci":    Utf8mb4GeneralCI,
+}
+
+// ParseCollation returns the collation with the given name, which is case
+// insensitive.
+func ParseCollation(name string) (Collation, error) {
+	c, ok := collationAliases[strings.ToLower(name)]
+	if !ok {
+		return "", ErrUnknownCollation.New(name)
+	}
+	return c, nil
+}
+
+// Name returns the name of the collation.
+func (c Collation) Name() string {
+	if c == "" {
+		return string(DefaultCollation)
+	}
+	return string(c)
+}
+
+// CaseSensitive returns whether strings that only differ in the case of
+// their characters are different.
+func (c Collation) CaseSensitive() bool {
+	return c != Utf8mb4GeneralCI
+}
+
+// Compare compares two strings. The result is 0 if a == b, -1 if a < b, and
+// +1 if a > b.
+func (c Collation) Compare(a, b string) int {
+	if c.CaseSensitive() {
+		return strings.Compare(a, b)
+	}
+
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra, rb = unicode.ToUpper(ra), unicode.ToUpper(rb); ra != rb {
+			if ra < rb {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[na:], b[nb:]
+	}
+
+	switch {
+	case a != "":
+		return 1
+	case b != "":
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Key returns the string the bytes of which compare as s does with the
+// collation, so equal strings have the same key. Keys are used to store
+// strings in indexes.
+func (c Collation) Key(s string) string {
+	if c.CaseSensitive() {
+		return s
+	}
+	return strings.Map(unicode.ToUpper, s)
+}
+
+// HasCollation returns whether t is a text type with a collation, which
+// BLOB and JSON types don't have.
+func HasCollation(t Type) bool {
+	switch t.(type) {
+	case textT, varCharT:
+		return true
+	default:
+		return false
+	}
+}
+
+// CollationOf returns the collation the values of the type are compared
+// with. Types that aren't text have the default collation.
+func CollationOf(t Type) Collation {
+	switch t := t.(type) {
+	case textT:
+		return Collation(t.collation.Name())
+	case varCharT:
+		return Collation(t.collation.Name())
+	default:
+		return DefaultCollation
+	}
+}
+
+// WithCollation returns the text type t with the given collation. Only TEXT
+// and VARCHAR types have collations.
+func WithCollation(t Type, c Collation) (Type, error) {
+	// The types with the default collation are kept as they are created,
+	// so they are equal to Text and the types returned by VarChar.
+	if c == DefaultCollation {
+		c = ""
+	}
+
+	switch t := t.(type) {
+	case textT:
+		return textT{collation: c}, nil
+	case varCharT:
+		return varCharT{length: t.length, collation: c}, nil
+	default:
+		return nil, ErrCollationNotSupported.New(c.Name(), t)
+	}
+}
+
+// ComparisonCollation returns the collation values of the given types are
+// compared with: the first one that isn't the default, as strings of a
+// column with an explicit collation are compared with it even against
+// literals.
+func ComparisonCollation(types ...Type) Collation {
+	for _, t := range types {
+		if c := CollationOf(t); c != DefaultCollation {
+			return c
+		}
+	}
+	return DefaultCollation
+}
@@ -0,0 +1,63 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollation_Compare(t *testing.T) {
+	testCases := []struct {
+		collation Collation
+		a, b      string
+		cmp       int
+	}{
+		{Utf8mb4Bin, "ABC", "abc", -1},
+		{Utf8mb4Bin, "abc", "abc", 0},
+		{Utf8mb4GeneralCI, "ABC", "abc", 0},
+		{Utf8mb4GeneralCI, "abc", "ABD", -1},
+		{Utf8mb4GeneralCI, "B", "abc", 1},
+		{Utf8mb4GeneralCI, "ab", "ABC", -1},
+		{Utf8mb4GeneralCI, "ÉTÉ", "été", 0},
+	}
+
+	for _, tt := range testCases {
+		t.Run(string(tt.collation)+" "+tt.a+" "+tt.b, func(t *testing.T) {
+			require.Equal(t, tt.cmp, tt.collation.Compare(tt.a, tt.b))
+			require.Equal(t, tt.cmp == 0, tt.collation.Key(tt.a) == tt.collation.Key(tt.b))
+		})
+	}
+}
+
+func TestParseCollation(t *testing.T) {
+	c, err := ParseCollation("UTF8MB4_GENERAL_CI")
+	require.NoError(t, err)
+	require.Equal(t, Utf8mb4GeneralCI, c)
+
+	c, err = ParseCollation("utf8_bin")
+	require.NoError(t, err)
+	require.Equal(t, Utf8mb4Bin, c)
+
+	_, err = ParseCollation("latin1_swedish_ci")
+	require.True(t, ErrUnknownCollation.Is(err))
+}
+
+func TestWithCollation(t *testing.T) {
+	typ, err := WithCollation(VarChar(10), Utf8mb4GeneralCI)
+	require.NoError(t, err)
+	require.Equal(t, Utf8mb4GeneralCI, CollationOf(typ))
+	require.Equal(t, 10, MaxLength(typ))
+
+	cmp, err := typ.Compare("abc", "ABC")
+	require.NoError(t, err)
+	require.Equal(t, 0, cmp)
+
+	// The default collation leaves the types as they are.
+	typ, err = WithCollation(Text, Utf8mb4Bin)
+	require.NoError(t, err)
+	require.Equal(t, Text, typ)
+	require.Equal(t, DefaultCollation, CollationOf(Int64))
+
+	_, err = WithCollation(Int64, Utf8mb4GeneralCI)
+	require.True(t, ErrCollationNotSupported.Is(err))
+}
@@ -1,6 +1,7 @@
 package sql // import "github.com/turtacn/guocedb/compute/sql"
 
 import (
+	stderrors "errors"
 	"fmt"
 	"io"
 
@@ -29,8 +30,32 @@ var (
 
 	// ErrInvalidChildrenNumber is returned when a node is given an invalid number of children
 	ErrInvalidChildrenNumber = errors.NewKind("invalid children number for node %T: %d (expected %d)")
+
+	// ErrDuplicateKey is returned when a row is inserted with the primary key
+	// of a row the table already has.
+	ErrDuplicateKey = errors.NewKind("Duplicate entry '%s' for key 'PRIMARY'")
+
+	// ErrDuplicateUniqueKey is returned when a row is written with the
+	// values a row the table already has in the columns of a unique index.
+	ErrDuplicateUniqueKey = errors.NewKind("Duplicate entry '%s' for key '%s'")
+
+	// ErrCheckConstraintViolated is returned when a row makes the expression
+	// of a CHECK constraint of its table false.
+	ErrCheckConstraintViolated = errors.NewKind("Check constraint '%s' is violated.")
 )
 
+// IsKind returns whether err, or any error it wraps, is of the kind k. It's
+// needed for the errors that were wrapped by other packages, as Kind.Is only
+// follows the causes of the errors of its own package.
+func IsKind(k *errors.Kind, err error) bool {
+	for ; err != nil; err = stderrors.Unwrap(err) {
+		if k.Is(err) {
+			return true
+		}
+	}
+	return false
+}
+
 // Nameable is something that has a name.
 type Nameable interface {
 	// Name returns the name.
@@ -157,6 +182,34 @@ type PartitionCounter interface {
 	PartitionCount(*Context) (int64, error)
 }
 
+// RowCounter is a table that knows how many rows it has without reading
+// them.
+type RowCounter interface {
+	// RowCount returns the number of rows of the table.
+	RowCount(*Context) (uint64, error)
+}
+
+// AccessDescriber is a table that can tell how its rows are going to be
+// read, which EXPLAIN shows.
+type AccessDescriber interface {
+	// Access returns the EXPLAIN access type of the table, such as ALL for a
+	// full scan, ref for a lookup of a value in an index or range for a
+	// range of an index, along with the index used, if any.
+	Access() (accessType string, index string)
+}
+
+// OrderedTable is a table that can return its rows sorted by one of its
+// columns without sorting them in memory, usually by reading an index.
+// NULL values come first in ascending order and last in descending order.
+// The sorted rows are a single partition, so they keep their order when the
+// partitions are read in parallel.
+type OrderedTable interface {
+	Table
+	// WithOrder returns the table with its rows sorted by the column, or
+	// false if it can't read them in that order.
+	WithOrder(column string, desc bool) (Table, bool)
+}
+
 // FilteredTable is a table that can produce a specific RowIter
 // that's more optimized given the filters.
 type FilteredTable interface {
@@ -189,6 +242,54 @@ type Inserter interface {
 	Insert(*Context, Row) error
 }
 
+// Updater allows rows to be updated in them.
+type Updater interface {
+	// Update replaces the old row with the new one.
+	Update(ctx *Context, old Row, new Row) error
+}
+
+// Deleter allows rows to be deleted from them.
+type Deleter interface {
+	// Delete the given row.
+	Delete(*Context, Row) error
+}
+
+// RowInserter inserts the rows of a single statement.
+type RowInserter interface {
+	// StatementBegin is called before the first row of the statement is
+	// inserted.
+	StatementBegin(*Context)
+	// Insert the given row.
+	Insert(*Context, Row) error
+	// StatementComplete is called once all the rows of the statement are
+	// inserted, and makes them visible.
+	StatementComplete(*Context) error
+	// DiscardChanges discards the rows inserted by the statement, which
+	// failed with the given error.
+	DiscardChanges(*Context, error) error
+	// Close releases the resources of the inserter.
+	Close(*Context) error
+}
+
+// InsertableTable should be implemented by tables that insert the rows of a
+// statement all at once, instead of one at a time as Inserter does.
+type InsertableTable interface {
+	// Inserter returns an inserter for the rows of a statement.
+	Inserter(*Context) RowInserter
+}
+
+// DuplicateKeyUpdater should be implemented by row inserters that can
+// update the rows conflicting with the ones they insert, as INSERT ... ON
+// DUPLICATE KEY UPDATE does.
+type DuplicateKeyUpdater interface {
+	// Duplicate returns the row whose primary key is the same as the one of
+	// the given row, including the rows inserted by the statement.
+	Duplicate(*Context, Row) (Row, error)
+	// Update replaces the old row with the new one as part of the
+	// statement.
+	Update(ctx *Context, old Row, new Row) error
+}
+
 // Database represents the database.
 type Database interface {
 	Nameable
@@ -201,6 +302,129 @@ type Alterable interface {
 	Create(name string, schema Schema) error
 }
 
+// AlterableTable should be implemented by databases that can add, drop and
+// modify the columns of their tables, migrating the rows they already have.
+type AlterableTable interface {
+	// AddColumn adds the column at the end of the schema of the table. The
+	// existing rows get the default value of the column.
+	AddColumn(ctx *Context, table string, column *Column) error
+	// DropColumn drops the column with the given name from the table.
+	DropColumn(ctx *Context, table string, column string) error
+	// ModifyColumn replaces the definition of the column of the table with
+	// the same name. The existing values are converted to its type.
+	ModifyColumn(ctx *Context, table string, column *Column) error
+}
+
+// AutoIncrementAlterable should be implemented by databases whose tables
+// can have an AUTO_INCREMENT column.
+type AutoIncrementAlterable interface {
+	// SetAutoIncrement sets the value the next row inserted in the table
+	// gets for its AUTO_INCREMENT column.
+	SetAutoIncrement(ctx *Context, table string, next uint64) error
+}
+
+// TruncateAlterable should be implemented by databases that can remove all
+// the rows of a table at once, faster than deleting them one by one.
+type TruncateAlterable interface {
+	// Truncate removes all the rows of the table and resets its
+	// AUTO_INCREMENT counter, keeping its schema.
+	Truncate(ctx *Context, table string) error
+}
+
+// StorageDatabase should be implemented by databases whose data is kept in
+// storage that can be flushed and compacted.
+type StorageDatabase interface {
+	Database
+	// StorageSize returns the estimated bytes taken by the data of the
+	// database.
+	StorageSize(ctx *Context) (int64, error)
+	// Compact compacts the storage of the database and reclaims the space
+	// taken by the rows deleted or overwritten.
+	Compact(ctx *Context) error
+	// Flush writes the changes of the database to disk.
+	Flush(ctx *Context) error
+}
+
+// TableOptions are the options a table was created with, such as ENGINE or
+// ROW_FORMAT, keyed by their upper-case name.
+type TableOptions map[string]string
+
+// Names of the table options that are kept along with a table.
+const (
+	TableOptionEngine        = "ENGINE"
+	TableOptionAutoIncrement = "AUTO_INCREMENT"
+	TableOptionCharset       = "CHARACTER SET"
+	TableOptionCollate       = "COLLATE"
+	TableOptionRowFormat     = "ROW_FORMAT"
+	TableOptionComment       = "COMMENT"
+	// TableOptionCompression is the name of the codec the rows of the
+	// table are stored with, for the engines that have several.
+	TableOptionCompression = "COMPRESSION"
+)
+
+// OptionsAlterable should be implemented by databases that can keep the
+// options given to CREATE TABLE along with the table.
+type OptionsAlterable interface {
+	Alterable
+	// CreateWithOptions creates a table with the given name, schema and
+	// options.
+	CreateWithOptions(name string, schema Schema, options TableOptions) error
+}
+
+// TableOptioner should be implemented by tables that know the options they
+// were created with.
+type TableOptioner interface {
+	// TableOptions returns the options of the table, which may be nil.
+	TableOptions() TableOptions
+}
+
+// CheckConstraint is a CHECK constraint of a table. The rows of the table
+// can't make its expression false, but they can make it NULL.
+type CheckConstraint struct {
+	Name string
+	// Expr is the SQL of the expression, which may only use the columns of
+	// the table.
+	Expr string
+}
+
+// CheckTable should be implemented by tables that have CHECK constraints.
+type CheckTable interface {
+	// Checks returns the CHECK constraints of the table.
+	Checks() []CheckConstraint
+}
+
+// CheckAlterable should be implemented by databases that can keep the CHECK
+// constraints given to CREATE TABLE along with the table.
+type CheckAlterable interface {
+	// CreateWithChecks creates a table with the given name, schema, options
+	// and CHECK constraints.
+	CreateWithChecks(name string, schema Schema, options TableOptions, checks []CheckConstraint) error
+}
+
+// IndexDef is the definition of a secondary index of a table, as given to
+// CREATE TABLE.
+type IndexDef struct {
+	Name    string
+	Columns []string
+	// Unique is true if no two rows can have the same values in the columns
+	// of the index, unless one of them is NULL.
+	Unique bool `json:",omitempty"`
+}
+
+// IndexTable should be implemented by tables that have secondary indexes.
+type IndexTable interface {
+	// Indexes returns the definitions of the indexes of the table.
+	Indexes() []IndexDef
+}
+
+// IndexAlterable should be implemented by databases that can add secondary
+// indexes to their tables.
+type IndexAlterable interface {
+	// AddIndex adds the index to the table, indexing the rows it already
+	// has.
+	AddIndex(table string, index IndexDef) error
+}
+
 // Lockable should be implemented by tables that can be locked and unlocked.
 type Lockable interface {
 	Nameable
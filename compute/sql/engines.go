@@ -0,0 +1,108 @@
+package sql
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrStorageEngineNotFound is returned when a storage engine is not in the
+// registry.
+var ErrStorageEngineNotFound = errors.NewKind("unknown storage engine: %s")
+
+// StorageEngine describes a storage engine tables can be stored with.
+type StorageEngine struct {
+	// Name of the engine, as used in ENGINE=name.
+	Name string
+	// Comment is a short description of the engine.
+	Comment string
+	// Disabled engines are known but can't be used.
+	Disabled bool
+	// Transactions tells whether the engine supports transactions.
+	Transactions bool
+	// XA tells whether the engine supports distributed transactions.
+	XA bool
+	// Savepoints tells whether the engine supports savepoints.
+	Savepoints bool
+}
+
+// EngineRegistry keeps track of the storage engines available.
+type EngineRegistry struct {
+	mu       sync.RWMutex
+	engines  map[string]StorageEngine
+	fallback string
+}
+
+// NewEngineRegistry creates a new empty engine registry.
+func NewEngineRegistry() *EngineRegistry {
+	return &EngineRegistry{engines: make(map[string]StorageEngine)}
+}
+
+// RegisterEngine adds a storage engine to the registry, replacing any
+// engine with the same name. The first engine registered is the default
+// one unless another one is set as default.
+func (r *EngineRegistry) RegisterEngine(engine StorageEngine) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := strings.ToLower(engine.Name)
+	r.engines[name] = engine
+	if r.fallback == "" && !engine.Disabled {
+		r.fallback = name
+	}
+}
+
+// SetDefaultEngine sets the storage engine used when none is given.
+func (r *EngineRegistry) SetDefaultEngine(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name = strings.ToLower(name)
+	if e, ok := r.engines[name]; !ok || e.Disabled {
+		return ErrStorageEngineNotFound.New(name)
+	}
+
+	r.fallback = name
+	return nil
+}
+
+// DefaultEngine returns the storage engine used when none is given, and
+// false if there are no engines.
+func (r *EngineRegistry) DefaultEngine() (StorageEngine, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.engines[r.fallback]
+	return e, ok
+}
+
+// StorageEngine returns the engine with the given name, which is not case
+// sensitive.
+func (r *EngineRegistry) StorageEngine(name string) (StorageEngine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.engines[strings.ToLower(name)]
+	if !ok {
+		return StorageEngine{}, ErrStorageEngineNotFound.New(name)
+	}
+	return e, nil
+}
+
+// StorageEngines returns all the engines in the registry sorted by name.
+func (r *EngineRegistry) StorageEngines() []StorageEngine {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	engines := make([]StorageEngine, 0, len(r.engines))
+	for _, e := range r.engines {
+		engines = append(engines, e)
+	}
+
+	sort.Slice(engines, func(i, j int) bool {
+		return strings.ToLower(engines[i].Name) < strings.ToLower(engines[j].Name)
+	})
+	return engines
+}
@@ -0,0 +1,43 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineRegistry(t *testing.T) {
+	require := require.New(t)
+
+	r := NewEngineRegistry()
+	_, ok := r.DefaultEngine()
+	require.False(ok)
+
+	r.RegisterEngine(StorageEngine{Name: "old", Disabled: true})
+	r.RegisterEngine(StorageEngine{Name: "Foo", Transactions: true})
+	r.RegisterEngine(StorageEngine{Name: "bar"})
+
+	def, ok := r.DefaultEngine()
+	require.True(ok)
+	require.Equal("Foo", def.Name)
+
+	e, err := r.StorageEngine("FOO")
+	require.NoError(err)
+	require.True(e.Transactions)
+
+	_, err = r.StorageEngine("baz")
+	require.True(ErrStorageEngineNotFound.Is(err))
+
+	require.True(ErrStorageEngineNotFound.Is(r.SetDefaultEngine("baz")))
+	require.True(ErrStorageEngineNotFound.Is(r.SetDefaultEngine("old")))
+	require.NoError(r.SetDefaultEngine("BAR"))
+
+	def, _ = r.DefaultEngine()
+	require.Equal("bar", def.Name)
+
+	var names []string
+	for _, e := range r.StorageEngines() {
+		names = append(names, e.Name)
+	}
+	require.Equal([]string{"bar", "Foo", "old"}, names)
+}
@@ -88,8 +88,31 @@ func (a *Arithmetic) String() string {
 	return fmt.Sprintf("%s %s %s", a.Left, a.op, a.Right)
 }
 
+// dateArithmetic returns the date and the interval it's moved by if the
+// operation adds an interval to a date or subtracts one from it.
+func (a *Arithmetic) dateArithmetic() (sql.Expression, *Interval, bool) {
+	switch a.op {
+	case sqlparser.PlusStr:
+		if i, ok := a.Right.(*Interval); ok {
+			return a.Left, i, true
+		}
+		if i, ok := a.Left.(*Interval); ok {
+			return a.Right, i, true
+		}
+	case sqlparser.MinusStr:
+		if i, ok := a.Right.(*Interval); ok {
+			return a.Left, i, true
+		}
+	}
+	return nil, nil, false
+}
+
 // Type returns the greatest type for given operation.
 func (a *Arithmetic) Type() sql.Type {
+	if date, interval, ok := a.dateArithmetic(); ok {
+		return interval.DateType(date.Type())
+	}
+
 	switch a.op {
 	case sqlparser.PlusStr, sqlparser.MinusStr, sqlparser.MultStr, sqlparser.DivStr:
 		if sql.IsInteger(a.Left.Type()) && sql.IsInteger(a.Right.Type()) {
@@ -131,11 +154,20 @@ func (a *Arithmetic) TransformUp(f sql.TransformExprFunc) (sql.Expression, error
 
 // Eval implements the Expression interface.
 func (a *Arithmetic) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	if date, interval, ok := a.dateArithmetic(); ok {
+		return interval.Add(ctx, row, date, a.op == sqlparser.MinusStr)
+	}
+
 	lval, rval, err := a.evalLeftRight(ctx, row)
 	if err != nil {
 		return nil, err
 	}
 
+	// Any operation with NULL is NULL.
+	if lval == nil || rval == nil {
+		return nil, nil
+	}
+
 	lval, rval, err = a.convertLeftRight(lval, rval)
 	if err != nil {
 		return nil, err
@@ -388,3 +388,17 @@ func TestUnaryMinus(t *testing.T) {
 		})
 	}
 }
+
+func TestArithmeticNull(t *testing.T) {
+	require := require.New(t)
+
+	for _, e := range []sql.Expression{
+		NewPlus(NewLiteral(nil, sql.Null), NewLiteral(int64(1), sql.Int64)),
+		NewMinus(NewLiteral(int64(1), sql.Int64), NewLiteral(nil, sql.Null)),
+		NewMult(NewLiteral(nil, sql.Null), NewLiteral(nil, sql.Null)),
+	} {
+		result, err := e.Eval(sql.NewEmptyContext(), sql.NewRow())
+		require.NoError(err)
+		require.Nil(result, e.String())
+	}
+}
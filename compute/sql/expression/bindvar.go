@@ -0,0 +1,59 @@
+package expression
+
+import (
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// BindVar is a parameter of a prepared statement, which is replaced with
+// the value bound to it before the statement is analyzed.
+type BindVar struct {
+	name string
+}
+
+// NewBindVar creates a new BindVar expression.
+func NewBindVar(name string) *BindVar {
+	return &BindVar{name: name}
+}
+
+// Children implements the sql.Expression interface.
+func (*BindVar) Children() []sql.Expression {
+	return nil
+}
+
+// Resolved implements the sql.Expression interface. Parameters are never
+// resolved, so statements with unbound parameters fail to be analyzed.
+func (*BindVar) Resolved() bool {
+	return false
+}
+
+// IsNullable implements the sql.Expression interface.
+// The function always panics!
+func (*BindVar) IsNullable() bool {
+	panic("bind variable is a placeholder node, but IsNullable was called")
+}
+
+// Type implements the sql.Expression interface.
+// The function always panics!
+func (*BindVar) Type() sql.Type {
+	panic("bind variable is a placeholder node, but Type was called")
+}
+
+// Name implements the sql.Nameable interface. It's the name of the bind
+// variable, without the colon.
+func (v *BindVar) Name() string { return v.name }
+
+func (v *BindVar) String() string {
+	return ":" + v.name
+}
+
+// Eval implements the sql.Expression interface.
+// The function always panics!
+func (*BindVar) Eval(ctx *sql.Context, r sql.Row) (interface{}, error) {
+	panic("bind variable is a placeholder node, but Eval was called")
+}
+
+// TransformUp implements the sql.Expression interface.
+func (v *BindVar) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
+	n := *v
+	return f(&n)
+}
@@ -0,0 +1,178 @@
+package expression
+
+import (
+	"bytes"
+
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// CaseBranch is a WHEN cond THEN value branch of a CASE expression.
+type CaseBranch struct {
+	Cond  sql.Expression
+	Value sql.Expression
+}
+
+// Case is a CASE expression. A simple CASE, as in CASE x WHEN 1 THEN 'a'
+// END, compares Expr to the condition of each branch, and a searched CASE,
+// as in CASE WHEN x > 1 THEN 'a' END, which has no Expr, evaluates them. The
+// value of the first branch that matches is returned, or the value of Else
+// if none does, which is NULL if there is no ELSE.
+type Case struct {
+	Expr     sql.Expression
+	Branches []CaseBranch
+	Else     sql.Expression
+}
+
+// NewCase creates a new Case expression. expr and elseExpr may be nil.
+func NewCase(expr sql.Expression, branches []CaseBranch, elseExpr sql.Expression) *Case {
+	return &Case{expr, branches, elseExpr}
+}
+
+// Type implements the Expression interface. It's the aggregated type of the
+// values of all the branches.
+func (c *Case) Type() sql.Type {
+	types := make([]sql.Type, 0, len(c.Branches)+1)
+	for _, b := range c.Branches {
+		types = append(types, b.Value.Type())
+	}
+	if c.Else != nil {
+		types = append(types, c.Else.Type())
+	}
+	return sql.AggregateTypes(types...)
+}
+
+// IsNullable implements the Expression interface.
+func (c *Case) IsNullable() bool {
+	if c.Else == nil || c.Else.IsNullable() {
+		return true
+	}
+	for _, b := range c.Branches {
+		if b.Value.IsNullable() {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolved implements the Expression interface.
+func (c *Case) Resolved() bool {
+	for _, e := range c.Children() {
+		if !e.Resolved() {
+			return false
+		}
+	}
+	return true
+}
+
+// Children implements the Expression interface.
+func (c *Case) Children() []sql.Expression {
+	var children []sql.Expression
+	if c.Expr != nil {
+		children = append(children, c.Expr)
+	}
+	for _, b := range c.Branches {
+		children = append(children, b.Cond, b.Value)
+	}
+	if c.Else != nil {
+		children = append(children, c.Else)
+	}
+	return children
+}
+
+// Eval implements the Expression interface.
+func (c *Case) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	for _, b := range c.Branches {
+		ok, err := c.matches(ctx, row, b.Cond)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return c.eval(ctx, row, b.Value)
+		}
+	}
+
+	if c.Else == nil {
+		return nil, nil
+	}
+	return c.eval(ctx, row, c.Else)
+}
+
+// matches returns whether the branch with the given condition is taken.
+// Conditions that are NULL are never taken, and neither are the branches
+// of a simple CASE whose expression is NULL, as NULL is not equal to
+// anything.
+func (c *Case) matches(ctx *sql.Context, row sql.Row, cond sql.Expression) (bool, error) {
+	if c.Expr != nil {
+		cond = NewEquals(c.Expr, cond)
+	}
+
+	v, err := cond.Eval(ctx, row)
+	if err != nil || v == nil {
+		return false, err
+	}
+
+	b, err := sql.Boolean.Convert(v)
+	if err != nil {
+		return false, nil
+	}
+	return b == true, nil
+}
+
+// eval evaluates the value of a branch, converted to the type of the CASE
+// if it has another type.
+func (c *Case) eval(ctx *sql.Context, row sql.Row, e sql.Expression) (interface{}, error) {
+	v, err := e.Eval(ctx, row)
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	if t := c.Type(); e.Type().String() != t.String() {
+		return t.Convert(v)
+	}
+	return v, nil
+}
+
+// TransformUp implements the Expression interface.
+func (c *Case) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
+	var expr, elseExpr sql.Expression
+	var err error
+	if c.Expr != nil {
+		if expr, err = c.Expr.TransformUp(f); err != nil {
+			return nil, err
+		}
+	}
+
+	branches := make([]CaseBranch, len(c.Branches))
+	for i, b := range c.Branches {
+		if branches[i].Cond, err = b.Cond.TransformUp(f); err != nil {
+			return nil, err
+		}
+		if branches[i].Value, err = b.Value.TransformUp(f); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Else != nil {
+		if elseExpr, err = c.Else.TransformUp(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return f(NewCase(expr, branches, elseExpr))
+}
+
+func (c *Case) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("CASE ")
+	if c.Expr != nil {
+		buf.WriteString(c.Expr.String() + " ")
+	}
+	for _, b := range c.Branches {
+		buf.WriteString("WHEN " + b.Cond.String() + " THEN " + b.Value.String() + " ")
+	}
+	if c.Else != nil {
+		buf.WriteString("ELSE " + c.Else.String() + " ")
+	}
+	buf.WriteString("END")
+	return buf.String()
+}
@@ -0,0 +1,69 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+func TestCase(t *testing.T) {
+	// CASE WHEN x > 10 THEN 'big' WHEN x > 5 THEN 'medium' WHEN x > 0
+	// THEN 'small' ELSE 'none' END
+	searched := NewCase(nil, []CaseBranch{
+		{NewGreaterThan(NewGetField(0, sql.Int64, "x", true), NewLiteral(int64(10), sql.Int64)), NewLiteral("big", sql.Text)},
+		{NewGreaterThan(NewGetField(0, sql.Int64, "x", true), NewLiteral(int64(5), sql.Int64)), NewLiteral("medium", sql.Text)},
+		{NewGreaterThan(NewGetField(0, sql.Int64, "x", true), NewLiteral(int64(0), sql.Int64)), NewLiteral("small", sql.Text)},
+	}, NewLiteral("none", sql.Text))
+
+	// CASE x WHEN 1 THEN 'one' WHEN 2 THEN 'two' END
+	simple := NewCase(NewGetField(0, sql.Int64, "x", true), []CaseBranch{
+		{NewLiteral(int64(1), sql.Int64), NewLiteral("one", sql.Text)},
+		{NewLiteral(int64(2), sql.Int64), NewLiteral("two", sql.Text)},
+	}, nil)
+
+	testCases := []struct {
+		name     string
+		expr     *Case
+		row      sql.Row
+		expected interface{}
+	}{
+		{"searched first branch", searched, sql.NewRow(int64(20)), "big"},
+		{"searched second branch", searched, sql.NewRow(int64(7)), "medium"},
+		{"searched third branch", searched, sql.NewRow(int64(1)), "small"},
+		{"searched else", searched, sql.NewRow(int64(-1)), "none"},
+		{"searched null condition", searched, sql.NewRow(nil), "none"},
+		{"simple match", simple, sql.NewRow(int64(2)), "two"},
+		{"simple no match and no else", simple, sql.NewRow(int64(3)), nil},
+		{"simple null", simple, sql.NewRow(nil), nil},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := tt.expr.Eval(sql.NewEmptyContext(), tt.row)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, v)
+		})
+	}
+
+	require.False(t, searched.IsNullable())
+	require.True(t, simple.IsNullable())
+	require.Equal(t, "CASE x WHEN 1 THEN \"one\" WHEN 2 THEN \"two\" END", simple.String())
+}
+
+func TestCaseType(t *testing.T) {
+	// CASE WHEN x THEN 1 ELSE 2.5 END
+	c := NewCase(nil, []CaseBranch{
+		{NewGetField(0, sql.Boolean, "x", false), NewLiteral(int64(1), sql.Int64)},
+	}, NewLiteral(2.5, sql.Float64))
+
+	require.Equal(t, sql.Float64, c.Type())
+
+	v, err := c.Eval(sql.NewEmptyContext(), sql.NewRow(true))
+	require.NoError(t, err)
+	require.Equal(t, float64(1), v)
+
+	v, err = c.Eval(sql.NewEmptyContext(), sql.NewRow(false))
+	require.NoError(t, err)
+	require.Equal(t, 2.5, v)
+}
@@ -68,6 +68,33 @@ func (c *comparison) evalLeftAndRight(ctx *sql.Context, row sql.Row) (interface{
 }
 
 func (c *comparison) castLeftAndRight(left, right interface{}) (interface{}, interface{}, error) {
+	// Dates are compared with strings as instants, so '2024-1-5' comes
+	// before '2024-01-15', which isn't their order as text.
+	lt, rt := c.Left().Type(), c.Right().Type()
+	if (sql.IsTime(lt) || sql.IsTime(rt)) && !sql.IsNumber(lt) && !sql.IsNumber(rt) {
+		l, lerr := sql.Timestamp.Convert(left)
+		r, rerr := sql.Timestamp.Convert(right)
+		if lerr == nil && rerr == nil {
+			c.compareType = sql.Timestamp
+			return l, r, nil
+		}
+	}
+
+	// UUIDs are compared with their text as 16 bytes, which can be
+	// written in more than one way.
+	if lt == sql.UUID || rt == sql.UUID {
+		l, err := sql.UUID.Convert(left)
+		if err != nil {
+			return nil, nil, err
+		}
+		r, err := sql.UUID.Convert(right)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.compareType = sql.UUID
+		return l, r, nil
+	}
+
 	if sql.IsNumber(c.Left().Type()) || sql.IsNumber(c.Right().Type()) {
 		if sql.IsDecimal(c.Left().Type()) || sql.IsDecimal(c.Right().Type()) {
 			left, right, err := convertLeftAndRight(left, right, ConvertToDecimal)
@@ -103,7 +130,14 @@ func (c *comparison) castLeftAndRight(left, right interface{}) (interface{}, int
 		return nil, nil, err
 	}
 
-	c.compareType = sql.Text
+	// Strings are compared with the collation of the column they come
+	// from, if any, so a literal equals any case of it in a _ci column.
+	compareType, err := sql.WithCollation(sql.Text, sql.ComparisonCollation(lt, rt))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.compareType = compareType
 	return left, right, nil
 }
 
@@ -224,14 +258,31 @@ func (re *Regexp) compareRegexp(ctx *sql.Context, row sql.Row) (interface{}, err
 		return nil, err
 	}
 
-	if re.r == nil {
-		re.r, err = regex.New(regex.Default(), right.(string))
+	r := re.r
+	if r == nil {
+		r, err = regex.New(regex.Default(), right.(string))
 		if err != nil {
 			return false, err
 		}
+
+		// Patterns read from the row may change from one row to the next.
+		if re.canCacheRegex() {
+			re.r = r
+		}
 	}
 
-	return re.r.Match(left.(string)), nil
+	return r.Match(left.(string)), nil
+}
+
+func (re *Regexp) canCacheRegex() bool {
+	canCache := true
+	Inspect(re.Right(), func(e sql.Expression) bool {
+		if _, ok := e.(*GetField); ok {
+			canCache = false
+		}
+		return true
+	})
+	return canCache
 }
 
 // TransformUp implements the Expression interface.
@@ -446,11 +497,20 @@ func NewIn(left sql.Expression, right sql.Expression) *In {
 	return &In{newComparison(left, right)}
 }
 
-// Eval implements the Expression interface.
+// Eval implements the Expression interface. It's true if the left operand
+// equals any of the values in the list, NULL if it doesn't but it may equal
+// one compared with NULL, and false otherwise.
 func (in *In) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
-	typ := in.Left().Type()
+	return evalIn(ctx, in.Left(), in.Right(), row)
+}
+
+// evalIn evaluates left IN right. Tuples are equal if all their columns are,
+// and not equal if any of them isn't, so (1, NULL) IN ((1, 2)) is NULL but
+// (1, NULL) IN ((2, 2)) is false.
+func evalIn(ctx *sql.Context, leftExpr, rightExpr sql.Expression, row sql.Row) (interface{}, error) {
+	typ := leftExpr.Type()
 	leftElems := sql.NumColumns(typ)
-	left, err := in.Left().Eval(ctx, row)
+	left, err := leftExpr.Eval(ctx, row)
 	if err != nil {
 		return nil, err
 	}
@@ -459,45 +519,88 @@ func (in *In) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, err
 	}
 
-	left, err = typ.Convert(left)
-	if err != nil {
-		return nil, err
+	// TODO: support subqueries
+	right, ok := rightExpr.(Tuple)
+	if !ok {
+		return nil, ErrUnsupportedInOperand.New(rightExpr)
 	}
 
-	// TODO: support subqueries
-	switch right := in.Right().(type) {
-	case Tuple:
-		for _, el := range right {
-			if sql.NumColumns(el.Type()) != leftElems {
-				return nil, ErrInvalidOperandColumns.New(leftElems, sql.NumColumns(el.Type()))
-			}
+	for _, el := range right {
+		if sql.NumColumns(el.Type()) != leftElems {
+			return nil, ErrInvalidOperandColumns.New(leftElems, sql.NumColumns(el.Type()))
 		}
+	}
 
-		for _, el := range right {
-			right, err := el.Eval(ctx, row)
-			if err != nil {
-				return nil, err
-			}
+	var unknown bool
+	for _, el := range right {
+		right, err := el.Eval(ctx, row)
+		if err != nil {
+			return nil, err
+		}
 
-			right, err = typ.Convert(right)
-			if err != nil {
-				return nil, err
-			}
+		equal, err := tupleEquals(typ, left, right)
+		if err != nil {
+			return nil, err
+		}
 
-			cmp, err := typ.Compare(left, right)
-			if err != nil {
-				return nil, err
-			}
+		switch equal {
+		case true:
+			return true, nil
+		case nil:
+			unknown = true
+		}
+	}
 
-			if cmp == 0 {
-				return true, nil
-			}
+	if unknown {
+		return nil, nil
+	}
+	return false, nil
+}
+
+// tupleEquals compares two values of the given type, which may be a tuple,
+// column by column. The result is NULL if no columns differ but some are
+// NULL.
+func tupleEquals(typ sql.Type, left, right interface{}) (interface{}, error) {
+	types := sql.ColumnTypes(typ)
+	if len(types) == 1 {
+		left, right = []interface{}{left}, []interface{}{right}
+	}
+
+	lvals, lok := left.([]interface{})
+	rvals, rok := right.([]interface{})
+	if !lok || !rok {
+		return nil, sql.ErrNotTuple.New(right)
+	}
+
+	var unknown bool
+	for i, t := range types {
+		if lvals[i] == nil || rvals[i] == nil {
+			unknown = true
+			continue
+		}
+
+		l, err := t.Convert(lvals[i])
+		if err != nil {
+			return nil, err
+		}
+		r, err := t.Convert(rvals[i])
+		if err != nil {
+			return nil, err
 		}
 
-		return false, nil
-	default:
-		return nil, ErrUnsupportedInOperand.New(right)
+		cmp, err := t.Compare(l, r)
+		if err != nil {
+			return nil, err
+		}
+		if cmp != 0 {
+			return false, nil
+		}
+	}
+
+	if unknown {
+		return nil, nil
 	}
+	return true, nil
 }
 
 // TransformUp implements the Expression interface.
@@ -534,58 +637,13 @@ func NewNotIn(left sql.Expression, right sql.Expression) *NotIn {
 	return &NotIn{newComparison(left, right)}
 }
 
-// Eval implements the Expression interface.
+// Eval implements the Expression interface. It's NULL when IN would be.
 func (in *NotIn) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
-	typ := in.Left().Type()
-	leftElems := sql.NumColumns(typ)
-	left, err := in.Left().Eval(ctx, row)
-	if err != nil {
-		return nil, err
-	}
-
-	if left == nil {
+	result, err := evalIn(ctx, in.Left(), in.Right(), row)
+	if result == nil || err != nil {
 		return nil, err
 	}
-
-	left, err = typ.Convert(left)
-	if err != nil {
-		return nil, err
-	}
-
-	// TODO: support subqueries
-	switch right := in.Right().(type) {
-	case Tuple:
-		for _, el := range right {
-			if sql.NumColumns(el.Type()) != leftElems {
-				return nil, ErrInvalidOperandColumns.New(leftElems, sql.NumColumns(el.Type()))
-			}
-		}
-
-		for _, el := range right {
-			right, err := el.Eval(ctx, row)
-			if err != nil {
-				return nil, err
-			}
-
-			right, err = typ.Convert(right)
-			if err != nil {
-				return nil, err
-			}
-
-			cmp, err := typ.Compare(left, right)
-			if err != nil {
-				return nil, err
-			}
-
-			if cmp == 0 {
-				return false, nil
-			}
-		}
-
-		return true, nil
-	default:
-		return nil, ErrUnsupportedInOperand.New(right)
-	}
+	return !result.(bool), nil
 }
 
 // TransformUp implements the Expression interface.
@@ -119,6 +119,20 @@ func TestEquals(t *testing.T) {
 	}
 }
 
+func TestEquals_Collation(t *testing.T) {
+	require := require.New(t)
+
+	ci, err := sql.WithCollation(sql.VarChar(10), sql.Utf8mb4GeneralCI)
+	require.NoError(err)
+
+	// Strings of a column are compared with its collation, even against a
+	// literal.
+	row := sql.NewRow("ABC")
+	require.Equal(true, eval(t, NewEquals(NewGetField(0, ci, "col1", true), NewLiteral("abc", sql.Text)), row))
+	require.Equal(true, eval(t, NewEquals(NewLiteral("abc", sql.Text), NewGetField(0, ci, "col1", true)), row))
+	require.Equal(false, eval(t, NewEquals(NewGetField(0, sql.VarChar(10), "col1", true), NewLiteral("abc", sql.Text)), row))
+}
+
 func TestLessThan(t *testing.T) {
 	require := require.New(t)
 	for resultType, cmpCase := range comparisonCases {
@@ -210,6 +224,19 @@ func testRegexpCases(t *testing.T) {
 	}
 }
 
+func TestRegexp_PatternFromRow(t *testing.T) {
+	require := require.New(t)
+	re := NewRegexp(
+		NewGetField(0, sql.Text, "col1", true),
+		NewGetField(1, sql.Text, "col2", true),
+	)
+
+	// Each row is matched against its own pattern.
+	require.Equal(true, eval(t, re, sql.NewRow("foobar", "^foo")))
+	require.Equal(false, eval(t, re, sql.NewRow("foobar", "^bar")))
+	require.Equal(true, eval(t, re, sql.NewRow("foobar", "bar$")))
+}
+
 func TestIn(t *testing.T) {
 	testCases := []struct {
 		name   string
@@ -274,6 +301,97 @@ func TestIn(t *testing.T) {
 			false,
 			nil,
 		},
+		{
+			"tuple is in right",
+			NewTuple(
+				NewGetField(0, sql.Int64, "a", true),
+				NewGetField(1, sql.Int64, "b", true),
+			),
+			NewTuple(
+				NewTuple(
+					NewLiteral(int64(1), sql.Int64),
+					NewLiteral(int64(2), sql.Int64),
+				),
+				NewTuple(
+					NewLiteral(int64(3), sql.Int64),
+					NewLiteral(int64(4), sql.Int64),
+				),
+			),
+			sql.NewRow(int64(3), int64(4)),
+			true,
+			nil,
+		},
+		{
+			"tuple is not in right",
+			NewTuple(
+				NewGetField(0, sql.Int64, "a", true),
+				NewGetField(1, sql.Int64, "b", true),
+			),
+			NewTuple(
+				NewTuple(
+					NewLiteral(int64(1), sql.Int64),
+					NewLiteral(int64(2), sql.Int64),
+				),
+				NewTuple(
+					NewLiteral(int64(3), sql.Int64),
+					NewLiteral(int64(4), sql.Int64),
+				),
+			),
+			sql.NewRow(int64(1), int64(4)),
+			false,
+			nil,
+		},
+		{
+			"tuple with NULL may be in right",
+			NewTuple(
+				NewGetField(0, sql.Int64, "a", true),
+				NewGetField(1, sql.Int64, "b", true),
+			),
+			NewTuple(
+				NewTuple(
+					NewLiteral(int64(1), sql.Int64),
+					NewLiteral(int64(2), sql.Int64),
+				),
+				NewTuple(
+					NewLiteral(int64(3), sql.Int64),
+					NewLiteral(int64(4), sql.Int64),
+				),
+			),
+			sql.NewRow(int64(1), nil),
+			nil,
+			nil,
+		},
+		{
+			"tuple with NULL is not in right",
+			NewTuple(
+				NewGetField(0, sql.Int64, "a", true),
+				NewGetField(1, sql.Int64, "b", true),
+			),
+			NewTuple(
+				NewTuple(
+					NewLiteral(int64(1), sql.Int64),
+					NewLiteral(int64(2), sql.Int64),
+				),
+				NewTuple(
+					NewLiteral(int64(3), sql.Int64),
+					NewLiteral(int64(4), sql.Int64),
+				),
+			),
+			sql.NewRow(int64(5), nil),
+			false,
+			nil,
+		},
+		{
+			"right has NULL",
+			NewLiteral(int64(1), sql.Int64),
+			NewTuple(
+				NewLiteral(int64(2), sql.Int64),
+				NewLiteral(nil, sql.Null),
+			),
+			nil,
+			nil,
+			nil,
+		},
 	}
 
 	for _, tt := range testCases {
@@ -356,6 +474,66 @@ func TestNotIn(t *testing.T) {
 			true,
 			nil,
 		},
+		{
+			"tuple is in right",
+			NewTuple(
+				NewGetField(0, sql.Int64, "a", true),
+				NewGetField(1, sql.Int64, "b", true),
+			),
+			NewTuple(
+				NewTuple(
+					NewLiteral(int64(1), sql.Int64),
+					NewLiteral(int64(2), sql.Int64),
+				),
+				NewTuple(
+					NewLiteral(int64(3), sql.Int64),
+					NewLiteral(int64(4), sql.Int64),
+				),
+			),
+			sql.NewRow(int64(3), int64(4)),
+			false,
+			nil,
+		},
+		{
+			"tuple is not in right",
+			NewTuple(
+				NewGetField(0, sql.Int64, "a", true),
+				NewGetField(1, sql.Int64, "b", true),
+			),
+			NewTuple(
+				NewTuple(
+					NewLiteral(int64(1), sql.Int64),
+					NewLiteral(int64(2), sql.Int64),
+				),
+				NewTuple(
+					NewLiteral(int64(3), sql.Int64),
+					NewLiteral(int64(4), sql.Int64),
+				),
+			),
+			sql.NewRow(int64(1), int64(4)),
+			true,
+			nil,
+		},
+		{
+			"tuple with NULL may be in right",
+			NewTuple(
+				NewGetField(0, sql.Int64, "a", true),
+				NewGetField(1, sql.Int64, "b", true),
+			),
+			NewTuple(
+				NewTuple(
+					NewLiteral(int64(1), sql.Int64),
+					NewLiteral(int64(2), sql.Int64),
+				),
+				NewTuple(
+					NewLiteral(int64(3), sql.Int64),
+					NewLiteral(int64(4), sql.Int64),
+				),
+			),
+			sql.NewRow(int64(1), nil),
+			nil,
+			nil,
+		},
 	}
 
 	for _, tt := range testCases {
@@ -184,6 +184,15 @@ func convertValue(val interface{}, castTo string) (interface{}, error) {
 }
 
 func handleUnsignedErrors(err error, val interface{}) uint64 {
+	// Negative numbers wrap around, as they do in MySQL.
+	if sql.ErrValueOutOfRange.Is(err) {
+		signed, err := sql.Int64.Convert(val)
+		if err != nil {
+			return uint64(0)
+		}
+		return uint64(signed.(int64))
+	}
+
 	if err.Error() == "unable to cast negative value" {
 		return castSignedToUnsigned(val)
 	}
@@ -0,0 +1,92 @@
+package function
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+// Abs returns the absolute value of a number.
+type Abs struct {
+	expression.UnaryExpression
+}
+
+// NewAbs creates a new Abs expression.
+func NewAbs(num sql.Expression) sql.Expression {
+	return &Abs{expression.UnaryExpression{Child: num}}
+}
+
+// Type implements the Expression interface.
+func (a *Abs) Type() sql.Type {
+	childType := a.Child.Type()
+	if sql.IsNumber(childType) {
+		return childType
+	}
+	return sql.Float64
+}
+
+func (a *Abs) String() string {
+	return fmt.Sprintf("ABS(%s)", a.Child)
+}
+
+// TransformUp implements the Expression interface.
+func (a *Abs) TransformUp(fn sql.TransformExprFunc) (sql.Expression, error) {
+	child, err := a.Child.TransformUp(fn)
+	if err != nil {
+		return nil, err
+	}
+	return fn(NewAbs(child))
+}
+
+// Eval implements the Expression interface.
+func (a *Abs) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	child, err := a.Child.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if child == nil {
+		return nil, nil
+	}
+
+	if !sql.IsNumber(a.Child.Type()) {
+		child, err = sql.Float64.Convert(child)
+		if err != nil {
+			return float64(0), nil
+		}
+	}
+
+	switch num := child.(type) {
+	case float64:
+		if num < 0 {
+			return -num, nil
+		}
+		return num, nil
+	case float32:
+		if num < 0 {
+			return -num, nil
+		}
+		return num, nil
+	case int64:
+		if num < 0 {
+			return -num, nil
+		}
+		return num, nil
+	case int32:
+		if num < 0 {
+			return -num, nil
+		}
+		return num, nil
+	case int:
+		if num < 0 {
+			return -num, nil
+		}
+		return num, nil
+	case uint64, uint32, uint:
+		return num, nil
+	default:
+		return nil, sql.ErrInvalidType.New(reflect.TypeOf(num))
+	}
+}
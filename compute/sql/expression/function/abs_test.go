@@ -0,0 +1,40 @@
+package function
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+func TestAbs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rowType  sql.Type
+		row      sql.Row
+		expected interface{}
+	}{
+		{"int64 is nil", sql.Int64, sql.NewRow(nil), nil},
+		{"int64 is negative", sql.Int64, sql.NewRow(int64(-5)), int64(5)},
+		{"int32 is positive", sql.Int32, sql.NewRow(int32(5)), int32(5)},
+		{"uint64 is ok", sql.Uint64, sql.NewRow(uint64(5)), uint64(5)},
+		{"float64 is negative", sql.Float64, sql.NewRow(-1.5), 1.5},
+		{"float32 is negative", sql.Float32, sql.NewRow(float32(-1.5)), float32(1.5)},
+		{"string is negative", sql.Text, sql.NewRow("-2.5"), 2.5},
+	}
+
+	for _, tt := range testCases {
+		f := NewAbs(expression.NewGetField(0, tt.rowType, "", true))
+
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, eval(t, f, tt.row))
+		})
+
+		if sql.IsNumber(tt.rowType) {
+			require.Equal(t, tt.rowType, f.Type())
+		} else {
+			require.Equal(t, sql.Float64, f.Type())
+		}
+	}
+}
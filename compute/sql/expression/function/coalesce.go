@@ -22,20 +22,21 @@ func NewCoalesce(args ...sql.Expression) (sql.Expression, error) {
 }
 
 // Type implements the sql.Expression interface.
-// The return type of Type() is the aggregated type of the argument types.
+// The return type of Type() is the aggregated type of the argument types, or
+// nil if none of them has a type.
 func (c *Coalesce) Type() sql.Type {
+	var types []sql.Type
 	for _, arg := range c.args {
-		if arg == nil {
-			continue
-		}
-		t := arg.Type()
-		if t == nil {
+		if arg == nil || arg.Type() == nil {
 			continue
 		}
-		return t
+		types = append(types, arg.Type())
 	}
 
-	return nil
+	if len(types) == 0 {
+		return nil
+	}
+	return sql.AggregateTypes(types...)
 }
 
 // IsNullable implements the sql.Expression interface.
@@ -119,6 +120,11 @@ func (c *Coalesce) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 			continue
 		}
 
+		// Values of other types than the aggregated one are converted,
+		// so all the rows have values of the same type.
+		if t := c.Type(); arg.Type() != nil && arg.Type().String() != t.String() {
+			return t.Convert(val)
+		}
 		return val, nil
 	}
 
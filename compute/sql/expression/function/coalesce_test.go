@@ -26,6 +26,7 @@ func TestCoalesce(t *testing.T) {
 		{"coalesce(NULL, NULL, '3')", []sql.Expression{nil, nil, expression.NewLiteral("3", sql.Text)}, "3", sql.Text, false},
 		{"coalesce(NULL, '2', 3)", []sql.Expression{nil, expression.NewLiteral("2", sql.Text), expression.NewLiteral(3, sql.Int32)}, "2", sql.Text, false},
 		{"coalesce(NULL, NULL, NULL)", []sql.Expression{nil, nil, nil}, nil, nil, true},
+		{"coalesce(NULL, 2, 3.5)", []sql.Expression{nil, expression.NewLiteral(int32(2), sql.Int32), expression.NewLiteral(3.5, sql.Float64)}, float64(2), sql.Float64, false},
 	}
 
 	for _, tt := range testCases {
@@ -0,0 +1,208 @@
+package function
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	"github.com/turtacn/guocedb/compute/types"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrIntervalExpected is returned when the second argument of DATE_ADD or
+// DATE_SUB is not an INTERVAL.
+var ErrIntervalExpected = errors.NewKind("%s expects an INTERVAL as its second argument, got %s")
+
+func dateAndInterval(name string, args []sql.Expression) (sql.Expression, *expression.Interval, error) {
+	if len(args) != 2 {
+		return nil, nil, sql.ErrInvalidArgumentNumber.New(2, len(args))
+	}
+
+	interval, ok := args[1].(*expression.Interval)
+	if !ok {
+		return nil, nil, ErrIntervalExpected.New(name, args[1])
+	}
+
+	return args[0], interval, nil
+}
+
+// DateAdd moves a date forward by an interval, as in
+// DATE_ADD('2024-01-15', INTERVAL 1 DAY).
+type DateAdd struct {
+	Date     sql.Expression
+	Interval *expression.Interval
+}
+
+// NewDateAdd creates a new DATE_ADD function.
+func NewDateAdd(args ...sql.Expression) (sql.Expression, error) {
+	date, interval, err := dateAndInterval("DATE_ADD", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DateAdd{date, interval}, nil
+}
+
+// Children implements the Expression interface.
+func (d *DateAdd) Children() []sql.Expression {
+	return []sql.Expression{d.Date, d.Interval}
+}
+
+// Resolved implements the Expression interface.
+func (d *DateAdd) Resolved() bool {
+	return d.Date.Resolved() && d.Interval.Resolved()
+}
+
+// IsNullable implements the Expression interface.
+func (d *DateAdd) IsNullable() bool { return true }
+
+// Type implements the Expression interface.
+func (d *DateAdd) Type() sql.Type { return d.Interval.DateType(d.Date.Type()) }
+
+// TransformUp implements the Expression interface.
+func (d *DateAdd) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
+	date, err := d.Date.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	interval, err := d.Interval.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := NewDateAdd(date, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return f(fn)
+}
+
+// Eval implements the Expression interface.
+func (d *DateAdd) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	return d.Interval.Add(ctx, row, d.Date, false)
+}
+
+func (d *DateAdd) String() string {
+	return fmt.Sprintf("DATE_ADD(%s, %s)", d.Date, d.Interval)
+}
+
+// DateSub moves a date backward by an interval, as in
+// DATE_SUB('2024-01-15', INTERVAL 1 DAY).
+type DateSub struct {
+	Date     sql.Expression
+	Interval *expression.Interval
+}
+
+// NewDateSub creates a new DATE_SUB function.
+func NewDateSub(args ...sql.Expression) (sql.Expression, error) {
+	date, interval, err := dateAndInterval("DATE_SUB", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DateSub{date, interval}, nil
+}
+
+// Children implements the Expression interface.
+func (d *DateSub) Children() []sql.Expression {
+	return []sql.Expression{d.Date, d.Interval}
+}
+
+// Resolved implements the Expression interface.
+func (d *DateSub) Resolved() bool {
+	return d.Date.Resolved() && d.Interval.Resolved()
+}
+
+// IsNullable implements the Expression interface.
+func (d *DateSub) IsNullable() bool { return true }
+
+// Type implements the Expression interface.
+func (d *DateSub) Type() sql.Type { return d.Interval.DateType(d.Date.Type()) }
+
+// TransformUp implements the Expression interface.
+func (d *DateSub) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
+	date, err := d.Date.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	interval, err := d.Interval.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := NewDateSub(date, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return f(fn)
+}
+
+// Eval implements the Expression interface.
+func (d *DateSub) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	return d.Interval.Add(ctx, row, d.Date, true)
+}
+
+func (d *DateSub) String() string {
+	return fmt.Sprintf("DATE_SUB(%s, %s)", d.Date, d.Interval)
+}
+
+// DateDiff returns the number of days from the second date to the first,
+// ignoring the time of day.
+type DateDiff struct {
+	expression.BinaryExpression
+}
+
+// NewDateDiff creates a new DATEDIFF function.
+func NewDateDiff(left, right sql.Expression) sql.Expression {
+	return &DateDiff{expression.BinaryExpression{Left: left, Right: right}}
+}
+
+// Type implements the Expression interface.
+func (d *DateDiff) Type() sql.Type { return sql.Int64 }
+
+// IsNullable implements the Expression interface.
+func (d *DateDiff) IsNullable() bool { return true }
+
+// TransformUp implements the Expression interface.
+func (d *DateDiff) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
+	left, err := d.Left.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := d.Right.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return f(NewDateDiff(left, right))
+}
+
+// Eval implements the Expression interface. The result is NULL if any of
+// the dates is NULL or not a valid date.
+func (d *DateDiff) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	var dates [2]time.Time
+	for i, e := range []sql.Expression{d.Left, d.Right} {
+		v, err := e.Eval(ctx, row)
+		if err != nil || v == nil {
+			return nil, err
+		}
+
+		t, err := sql.Timestamp.Convert(v)
+		if err != nil {
+			return nil, nil
+		}
+		dates[i] = t.(time.Time)
+	}
+
+	return types.DateDiff(dates[0], dates[1]), nil
+}
+
+func (d *DateDiff) String() string {
+	return fmt.Sprintf("DATEDIFF(%s, %s)", d.Left, d.Right)
+}
@@ -0,0 +1,124 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+func TestDateAdd(t *testing.T) {
+	ctx := sql.NewEmptyContext()
+
+	testCases := []struct {
+		name     string
+		date     sql.Expression
+		n        interface{}
+		unit     string
+		typ      sql.Type
+		expected interface{}
+	}{
+		{
+			"datetime plus days",
+			expression.NewLiteral("2024-01-15 10:30:00", sql.Text),
+			int64(20), "DAY", sql.Timestamp,
+			time.Date(2024, 2, 4, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			"datetime plus hours",
+			expression.NewLiteral("2024-01-15 23:30:00", sql.Text),
+			int64(1), "hour", sql.Timestamp,
+			time.Date(2024, 1, 16, 0, 30, 0, 0, time.UTC),
+		},
+		{
+			"date plus month stays a date",
+			expression.NewLiteral(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), sql.Date),
+			int64(1), "MONTH", sql.Date,
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			"date plus seconds becomes a timestamp",
+			expression.NewLiteral(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), sql.Date),
+			int64(90), "SECOND", sql.Timestamp,
+			time.Date(2024, 1, 15, 0, 1, 30, 0, time.UTC),
+		},
+		{
+			"null date",
+			expression.NewLiteral(nil, sql.Null),
+			int64(1), "DAY", sql.Timestamp,
+			nil,
+		},
+		{
+			"null interval",
+			expression.NewLiteral("2024-01-15", sql.Text),
+			nil, "DAY", sql.Timestamp,
+			nil,
+		},
+		{
+			"invalid date",
+			expression.NewLiteral("not a date", sql.Text),
+			int64(1), "DAY", sql.Timestamp,
+			nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			interval := expression.NewInterval(expression.NewLiteral(tt.n, sql.Int64), tt.unit)
+
+			f, err := NewDateAdd(tt.date, interval)
+			require.NoError(err)
+			require.Equal(tt.typ, f.Type())
+
+			val, err := f.Eval(ctx, nil)
+			require.NoError(err)
+			require.Equal(tt.expected, val)
+		})
+	}
+
+	_, err := NewDateAdd(expression.NewLiteral("2024-01-15", sql.Text), expression.NewLiteral(int64(1), sql.Int64))
+	require.True(t, ErrIntervalExpected.Is(err))
+}
+
+func TestDateSub(t *testing.T) {
+	f, err := NewDateSub(
+		expression.NewLiteral("2024-03-01 00:00:00", sql.Text),
+		expression.NewInterval(expression.NewLiteral(int64(1), sql.Int64), "DAY"),
+	)
+	require.NoError(t, err)
+
+	val, err := f.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), val)
+}
+
+func TestDateDiff(t *testing.T) {
+	f := NewDateDiff(
+		expression.NewGetField(0, sql.Text, "a", true),
+		expression.NewGetField(1, sql.Text, "b", true),
+	)
+	ctx := sql.NewEmptyContext()
+
+	testCases := []struct {
+		name     string
+		row      sql.Row
+		expected interface{}
+	}{
+		{"days between dates", sql.NewRow("2024-01-15", "2024-01-01"), int64(14)},
+		{"time of day is ignored", sql.NewRow("2024-01-15 00:00:01", "2024-01-14 23:59:59"), int64(1)},
+		{"negative", sql.NewRow("2023-12-31", "2024-03-01 10:00:00"), int64(-61)},
+		{"null", sql.NewRow(nil, "2024-01-01"), nil},
+		{"invalid date", sql.NewRow("2024-01-15", "yesterday"), nil},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := f.Eval(ctx, tt.row)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, val)
+		})
+	}
+}
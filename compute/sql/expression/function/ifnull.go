@@ -0,0 +1,122 @@
+package function
+
+import (
+	"fmt"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+// IfNull returns the first argument unless it's NULL, in which case it
+// returns the second one.
+type IfNull struct {
+	expression.BinaryExpression
+}
+
+// NewIfNull creates a new IFNULL function.
+func NewIfNull(left, right sql.Expression) sql.Expression {
+	return &IfNull{expression.BinaryExpression{Left: left, Right: right}}
+}
+
+// Type implements the Expression interface. It's the aggregated type of
+// both arguments.
+func (f *IfNull) Type() sql.Type {
+	return sql.AggregateTypes(f.Left.Type(), f.Right.Type())
+}
+
+// IsNullable implements the Expression interface.
+func (f *IfNull) IsNullable() bool {
+	return f.Left.IsNullable() && f.Right.IsNullable()
+}
+
+// Eval implements the Expression interface.
+func (f *IfNull) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	arg := f.Left
+	val, err := arg.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if val == nil {
+		arg = f.Right
+		val, err = arg.Eval(ctx, row)
+		if err != nil || val == nil {
+			return nil, err
+		}
+	}
+
+	if t := f.Type(); arg.Type().String() != t.String() {
+		return t.Convert(val)
+	}
+	return val, nil
+}
+
+// TransformUp implements the Expression interface.
+func (f *IfNull) TransformUp(fn sql.TransformExprFunc) (sql.Expression, error) {
+	left, err := f.Left.TransformUp(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := f.Right.TransformUp(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	return fn(NewIfNull(left, right))
+}
+
+func (f *IfNull) String() string {
+	return fmt.Sprintf("ifnull(%s, %s)", f.Left, f.Right)
+}
+
+// NullIf returns NULL if both arguments are equal, and the first one
+// otherwise.
+type NullIf struct {
+	expression.BinaryExpression
+}
+
+// NewNullIf creates a new NULLIF function.
+func NewNullIf(left, right sql.Expression) sql.Expression {
+	return &NullIf{expression.BinaryExpression{Left: left, Right: right}}
+}
+
+// Type implements the Expression interface.
+func (f *NullIf) Type() sql.Type { return f.Left.Type() }
+
+// IsNullable implements the Expression interface.
+func (f *NullIf) IsNullable() bool { return true }
+
+// Eval implements the Expression interface. Arguments are compared as they
+// are by the = operator, so the first one is returned if any of them is
+// NULL.
+func (f *NullIf) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	equal, err := expression.NewEquals(f.Left, f.Right).Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if equal == true {
+		return nil, nil
+	}
+	return f.Left.Eval(ctx, row)
+}
+
+// TransformUp implements the Expression interface.
+func (f *NullIf) TransformUp(fn sql.TransformExprFunc) (sql.Expression, error) {
+	left, err := f.Left.TransformUp(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := f.Right.TransformUp(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	return fn(NewNullIf(left, right))
+}
+
+func (f *NullIf) String() string {
+	return fmt.Sprintf("nullif(%s, %s)", f.Left, f.Right)
+}
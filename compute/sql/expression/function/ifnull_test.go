@@ -0,0 +1,67 @@
+package function
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+func TestIfNull(t *testing.T) {
+	testCases := []struct {
+		name     string
+		left     interface{}
+		right    interface{}
+		expected interface{}
+	}{
+		{"ifnull(1, 2)", int64(1), int64(2), int64(1)},
+		{"ifnull(NULL, 2)", nil, int64(2), int64(2)},
+		{"ifnull(NULL, NULL)", nil, nil, nil},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewIfNull(
+				expression.NewLiteral(tt.left, sql.Int64),
+				expression.NewLiteral(tt.right, sql.Int64),
+			)
+			v, err := f.Eval(sql.NewEmptyContext(), nil)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, v)
+		})
+	}
+
+	f := NewIfNull(expression.NewLiteral(nil, sql.Null), expression.NewLiteral("foo", sql.Text))
+	require.Equal(t, sql.Text, f.Type())
+	v, err := f.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "foo", v)
+}
+
+func TestNullIf(t *testing.T) {
+	testCases := []struct {
+		name     string
+		left     interface{}
+		right    interface{}
+		expected interface{}
+	}{
+		{"nullif(1, 1)", int64(1), int64(1), nil},
+		{"nullif(1, 2)", int64(1), int64(2), int64(1)},
+		{"nullif(1, NULL)", int64(1), nil, int64(1)},
+		{"nullif(NULL, 1)", nil, int64(1), nil},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewNullIf(
+				expression.NewLiteral(tt.left, sql.Int64),
+				expression.NewLiteral(tt.right, sql.Int64),
+			)
+			require.True(t, f.IsNullable())
+			v, err := f.Eval(sql.NewEmptyContext(), nil)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, v)
+		})
+	}
+}
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/oliveagle/jsonpath"
 	"github.com/turtacn/guocedb/compute/sql"
 )
 
-// JSONExtract extracts data from a json document using json paths.
+// JSONExtract extracts data from a json document using json paths. A path
+// that matches nothing gives NULL.
 type JSONExtract struct {
 	JSON  sql.Expression
 	Paths []sql.Expression
@@ -47,6 +47,10 @@ func (j *JSONExtract) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, err
 	}
 
+	if js == nil {
+		return nil, nil
+	}
+
 	js, err = sql.JSON.Convert(js)
 	if err != nil {
 		return nil, err
@@ -57,39 +61,46 @@ func (j *JSONExtract) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, err
 	}
 
-	var result = make([]interface{}, len(j.Paths))
-	for i, p := range j.Paths {
+	var result = make([]interface{}, 0, len(j.Paths))
+	for _, p := range j.Paths {
 		path, err := p.Eval(ctx, row)
 		if err != nil {
 			return nil, err
 		}
 
+		if path == nil {
+			return nil, nil
+		}
+
 		path, err = sql.Text.Convert(path)
 		if err != nil {
 			return nil, err
 		}
 
-		result[i], err = jsonpath.JsonPathLookup(doc, path.(string))
+		jp, err := parseJSONPath(path.(string))
 		if err != nil {
 			return nil, err
 		}
+
+		if v := jp.Lookup(doc); v != nil {
+			result = append(result, v)
+		}
 	}
 
-	if len(result) == 1 {
+	// With several paths, the values found are returned in an array.
+	switch {
+	case len(result) == 0:
+		return nil, nil
+	case len(j.Paths) == 1:
 		return result[0], nil
+	default:
+		return result, nil
 	}
-
-	return result, nil
 }
 
 // IsNullable implements the sql.Expression interface.
 func (j *JSONExtract) IsNullable() bool {
-	for _, p := range j.Paths {
-		if p.IsNullable() {
-			return true
-		}
-	}
-	return j.JSON.IsNullable()
+	return true
 }
 
 // Children implements the sql.Expression interface.
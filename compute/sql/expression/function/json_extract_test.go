@@ -66,3 +66,73 @@ func TestJSONExtract(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONExtractPaths(t *testing.T) {
+	doc := `{"a":{"b":[1,2,3]},"c":"foo","d":[{"e":1},{"e":2}],"some key":true}`
+
+	testCases := []struct {
+		path     string
+		expected interface{}
+	}{
+		{"$", map[string]interface{}{
+			"a":        map[string]interface{}{"b": []interface{}{1., 2., 3.}},
+			"c":        "foo",
+			"d":        []interface{}{map[string]interface{}{"e": 1.}, map[string]interface{}{"e": 2.}},
+			"some key": true,
+		}},
+		{"$.a.b[1]", 2.},
+		{"$.a.b", []interface{}{1., 2., 3.}},
+		{`$."some key"`, true},
+		{"$.c[0]", "foo"},
+		{"$.a.b[*]", []interface{}{1., 2., 3.}},
+		{"$.d[*].e", []interface{}{1., 2.}},
+		{"$.a.*", []interface{}{[]interface{}{1., 2., 3.}}},
+		{"$.a.b[3]", nil},
+		{"$.x", nil},
+		{"$.c.x", nil},
+		{"$.x[*]", nil},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.path, func(t *testing.T) {
+			f, err := NewJSONExtract(
+				expression.NewLiteral(doc, sql.Text),
+				expression.NewLiteral(tt.path, sql.Text),
+			)
+			require.NoError(t, err)
+
+			result, err := f.Eval(sql.NewEmptyContext(), nil)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+
+	f, err := NewJSONExtract(
+		expression.NewLiteral(doc, sql.Text),
+		expression.NewLiteral("$.x", sql.Text),
+		expression.NewLiteral("$.c", sql.Text),
+	)
+	require.NoError(t, err)
+	result, err := f.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"foo"}, result)
+
+	for _, path := range []string{"a.b", "$.", "$[x]", "$[1", `$."a`, "$a"} {
+		f, err := NewJSONExtract(
+			expression.NewLiteral(doc, sql.Text),
+			expression.NewLiteral(path, sql.Text),
+		)
+		require.NoError(t, err)
+		_, err = f.Eval(sql.NewEmptyContext(), nil)
+		require.True(t, ErrInvalidJSONPath.Is(err), path)
+	}
+
+	f, err = NewJSONExtract(
+		expression.NewLiteral(nil, sql.Null),
+		expression.NewLiteral("$.a", sql.Text),
+	)
+	require.NoError(t, err)
+	result, err = f.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(t, err)
+	require.Nil(t, result)
+}
@@ -0,0 +1,162 @@
+package function
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrInvalidJSONPath is returned when a JSON path expression can't be parsed.
+var ErrInvalidJSONPath = errors.NewKind("invalid JSON path expression %q: %s")
+
+// jsonPathLeg is a step of a JSON path. It selects either a member of an
+// object or an element of an array, and it may select all of them.
+type jsonPathLeg struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// jsonPath is a parsed JSON path expression, such as `$.a.b[1]` or
+// `$.a[*]."some key"`.
+type jsonPath struct {
+	legs     []jsonPathLeg
+	wildcard bool
+}
+
+func parseJSONPath(path string) (*jsonPath, error) {
+	s := strings.TrimSpace(path)
+	if !strings.HasPrefix(s, "$") {
+		return nil, ErrInvalidJSONPath.New(path, "it must start with $")
+	}
+	s = s[1:]
+
+	p := new(jsonPath)
+	for len(s) > 0 {
+		var leg jsonPathLeg
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			switch {
+			case strings.HasPrefix(s, "*"):
+				leg.wildcard = true
+				s = s[1:]
+			case strings.HasPrefix(s, `"`):
+				end := strings.IndexByte(s[1:], '"')
+				if end < 0 {
+					return nil, ErrInvalidJSONPath.New(path, "unterminated quoted key")
+				}
+				leg.key = s[1 : end+1]
+				s = s[end+2:]
+			default:
+				end := strings.IndexAny(s, ".[")
+				if end < 0 {
+					end = len(s)
+				}
+				leg.key = s[:end]
+				if leg.key == "" {
+					return nil, ErrInvalidJSONPath.New(path, "empty key")
+				}
+				s = s[end:]
+			}
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, ErrInvalidJSONPath.New(path, "unterminated array index")
+			}
+			idx := strings.TrimSpace(s[1:end])
+			leg.isIndex = true
+			if idx == "*" {
+				leg.wildcard = true
+			} else {
+				n, err := strconv.Atoi(idx)
+				if err != nil || n < 0 {
+					return nil, ErrInvalidJSONPath.New(path, "invalid array index "+idx)
+				}
+				leg.index = n
+			}
+			s = s[end+1:]
+		default:
+			return nil, ErrInvalidJSONPath.New(path, "unexpected "+strconv.Quote(s[:1]))
+		}
+
+		p.legs = append(p.legs, leg)
+		p.wildcard = p.wildcard || leg.wildcard
+	}
+
+	return p, nil
+}
+
+// Lookup returns the value in the document at the path, or nil if there is
+// none. If the path has wildcards, all values matched are returned in a
+// slice, which is nil if nothing matched.
+func (p *jsonPath) Lookup(doc interface{}) interface{} {
+	values := []interface{}{doc}
+	for _, leg := range p.legs {
+		var next []interface{}
+		for _, v := range values {
+			next = leg.apply(v, next)
+		}
+		values = next
+	}
+
+	if p.wildcard {
+		if len(values) == 0 {
+			return nil
+		}
+		return values
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+// apply appends to matches the values selected by the leg in v.
+func (l jsonPathLeg) apply(v interface{}, matches []interface{}) []interface{} {
+	if l.isIndex {
+		arr, ok := v.([]interface{})
+		if !ok {
+			// A value that is not an array is taken as an array with only
+			// that value.
+			if l.wildcard || l.index == 0 {
+				matches = append(matches, v)
+			}
+			return matches
+		}
+
+		if l.wildcard {
+			return append(matches, arr...)
+		}
+		if l.index < len(arr) {
+			matches = append(matches, arr[l.index])
+		}
+		return matches
+	}
+
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return matches
+	}
+
+	if l.wildcard {
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			matches = append(matches, obj[k])
+		}
+		return matches
+	}
+
+	if member, ok := obj[l.key]; ok {
+		matches = append(matches, member)
+	}
+	return matches
+}
@@ -0,0 +1,89 @@
+package function
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+// Length returns the length of a string in bytes, as MySQL's LENGTH does.
+type Length struct {
+	expression.UnaryExpression
+}
+
+// NewLength creates a new Length expression.
+func NewLength(e sql.Expression) sql.Expression {
+	return &Length{expression.UnaryExpression{Child: e}}
+}
+
+// Type implements the Expression interface.
+func (*Length) Type() sql.Type { return sql.Int32 }
+
+func (l *Length) String() string {
+	return fmt.Sprintf("LENGTH(%s)", l.Child)
+}
+
+// TransformUp implements the Expression interface.
+func (l *Length) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
+	child, err := l.Child.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+	return f(NewLength(child))
+}
+
+// Eval implements the Expression interface.
+func (l *Length) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	s, err := evalText(ctx, l.Child, row)
+	if s == nil || err != nil {
+		return nil, err
+	}
+	return int32(len(s.(string))), nil
+}
+
+// CharLength returns the length of a string in characters. Strings are
+// utf8mb4, so a character may take up to four bytes.
+type CharLength struct {
+	expression.UnaryExpression
+}
+
+// NewCharLength creates a new CharLength expression.
+func NewCharLength(e sql.Expression) sql.Expression {
+	return &CharLength{expression.UnaryExpression{Child: e}}
+}
+
+// Type implements the Expression interface.
+func (*CharLength) Type() sql.Type { return sql.Int32 }
+
+func (l *CharLength) String() string {
+	return fmt.Sprintf("CHAR_LENGTH(%s)", l.Child)
+}
+
+// TransformUp implements the Expression interface.
+func (l *CharLength) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
+	child, err := l.Child.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+	return f(NewCharLength(child))
+}
+
+// Eval implements the Expression interface.
+func (l *CharLength) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	s, err := evalText(ctx, l.Child, row)
+	if s == nil || err != nil {
+		return nil, err
+	}
+	return int32(utf8.RuneCountInString(s.(string))), nil
+}
+
+// evalText evaluates e and converts its value to text, unless it's nil.
+func evalText(ctx *sql.Context, e sql.Expression, row sql.Row) (interface{}, error) {
+	v, err := e.Eval(ctx, row)
+	if v == nil || err != nil {
+		return nil, err
+	}
+	return sql.Text.Convert(v)
+}
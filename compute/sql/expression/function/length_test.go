@@ -0,0 +1,33 @@
+package function
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+func TestLength(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rowType sql.Type
+		row     sql.Row
+		bytes   interface{}
+		chars   interface{}
+	}{
+		{"text nil", sql.Text, sql.NewRow(nil), nil, nil},
+		{"ascii", sql.Text, sql.NewRow("hello"), int32(5), int32(5)},
+		{"utf8mb4", sql.Text, sql.NewRow("héllo 世界 😀"), int32(18), int32(10)},
+		{"blob", sql.Blob, sql.NewRow([]byte("abc")), int32(3), int32(3)},
+		{"number", sql.Int64, sql.NewRow(int64(-12)), int32(3), int32(3)},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			field := expression.NewGetField(0, tt.rowType, "", true)
+			require.Equal(t, tt.bytes, eval(t, NewLength(field), tt.row))
+			require.Equal(t, tt.chars, eval(t, NewCharLength(field), tt.row))
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package function
+
+import "github.com/turtacn/guocedb/compute/sql"
+
+// Now returns the time the current query started at, so all the rows of a
+// query get the same one.
+type Now struct{}
+
+// NewNow creates a new Now UDF node.
+func NewNow() sql.Expression {
+	return Now{}
+}
+
+// Children implements the sql.Expression interface.
+func (Now) Children() []sql.Expression { return nil }
+
+// Type implements the sql.Expression interface.
+func (Now) Type() sql.Type { return sql.Timestamp }
+
+// Resolved implements the sql.Expression interface.
+func (Now) Resolved() bool { return true }
+
+// TransformUp implements the sql.Expression interface.
+func (Now) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
+	return f(Now{})
+}
+
+// IsNullable implements the sql.Expression interface.
+func (Now) IsNullable() bool { return false }
+
+// String implements the fmt.Stringer interface.
+func (Now) String() string { return "now()" }
+
+// Eval implements the sql.Expression interface.
+func (Now) Eval(ctx *sql.Context, _ sql.Row) (interface{}, error) {
+	return ctx.QueryTime(), nil
+}
@@ -24,44 +24,56 @@ var Defaults = sql.Functions{
 	"sum": sql.Function1(func(e sql.Expression) sql.Expression {
 		return aggregation.NewSum(e)
 	}),
-	"is_binary":     sql.Function1(NewIsBinary),
-	"substring":     sql.FunctionN(NewSubstring),
-	"year":          sql.Function1(NewYear),
-	"month":         sql.Function1(NewMonth),
-	"day":           sql.Function1(NewDay),
-	"weekday":       sql.Function1(NewWeekday),
-	"hour":          sql.Function1(NewHour),
-	"minute":        sql.Function1(NewMinute),
-	"second":        sql.Function1(NewSecond),
-	"dayofweek":     sql.Function1(NewDayOfWeek),
-	"dayofyear":     sql.Function1(NewDayOfYear),
-	"array_length":  sql.Function1(NewArrayLength),
-	"split":         sql.Function2(NewSplit),
-	"concat":        sql.FunctionN(NewConcat),
-	"concat_ws":     sql.FunctionN(NewConcatWithSeparator),
-	"lower":         sql.Function1(NewLower),
-	"upper":         sql.Function1(NewUpper),
-	"ceiling":       sql.Function1(NewCeil),
-	"ceil":          sql.Function1(NewCeil),
-	"floor":         sql.Function1(NewFloor),
-	"round":         sql.FunctionN(NewRound),
-	"coalesce":      sql.FunctionN(NewCoalesce),
-	"json_extract":  sql.FunctionN(NewJSONExtract),
-	"connection_id": sql.Function0(NewConnectionID),
-	"soundex":       sql.Function1(NewSoundex),
-	"ln":            sql.Function1(NewLogBaseFunc(float64(math.E))),
-	"log2":          sql.Function1(NewLogBaseFunc(float64(2))),
-	"log10":         sql.Function1(NewLogBaseFunc(float64(10))),
-	"log":           sql.FunctionN(NewLog),
-	"rpad":          sql.FunctionN(NewPadFunc(rPadType)),
-	"lpad":          sql.FunctionN(NewPadFunc(lPadType)),
-	"sqrt":          sql.Function1(NewSqrt),
-	"pow":           sql.Function2(NewPower),
-	"power":         sql.Function2(NewPower),
-	"ltrim":         sql.Function1(NewTrimFunc(lTrimType)),
-	"rtrim":         sql.Function1(NewTrimFunc(rTrimType)),
-	"trim":          sql.Function1(NewTrimFunc(bTrimType)),
-	"reverse":       sql.Function1(NewReverse),
-	"repeat":        sql.Function2(NewRepeat),
-	"replace":       sql.Function3(NewReplace),
+	"is_binary":         sql.Function1(NewIsBinary),
+	"substring":         sql.Typed(sql.FunctionN(NewSubstring), sql.TextArgument, sql.NumberArgument),
+	"year":              sql.Typed(sql.Function1(NewYear), sql.TimeArgument),
+	"month":             sql.Typed(sql.Function1(NewMonth), sql.TimeArgument),
+	"day":               sql.Typed(sql.Function1(NewDay), sql.TimeArgument),
+	"weekday":           sql.Typed(sql.Function1(NewWeekday), sql.TimeArgument),
+	"hour":              sql.Typed(sql.Function1(NewHour), sql.TimeArgument),
+	"minute":            sql.Typed(sql.Function1(NewMinute), sql.TimeArgument),
+	"second":            sql.Typed(sql.Function1(NewSecond), sql.TimeArgument),
+	"dayofweek":         sql.Typed(sql.Function1(NewDayOfWeek), sql.TimeArgument),
+	"dayofyear":         sql.Typed(sql.Function1(NewDayOfYear), sql.TimeArgument),
+	"date_add":          sql.FunctionN(NewDateAdd),
+	"date_sub":          sql.FunctionN(NewDateSub),
+	"datediff":          sql.Typed(sql.Function2(NewDateDiff), sql.TimeArgument),
+	"array_length":      sql.Function1(NewArrayLength),
+	"split":             sql.Typed(sql.Function2(NewSplit), sql.TextArgument),
+	"concat":            sql.Typed(sql.FunctionN(NewConcat), sql.TextArgument),
+	"concat_ws":         sql.Typed(sql.FunctionN(NewConcatWithSeparator), sql.TextArgument),
+	"lower":             sql.Typed(sql.Function1(NewLower), sql.TextArgument),
+	"upper":             sql.Typed(sql.Function1(NewUpper), sql.TextArgument),
+	"ceiling":           sql.Typed(sql.Function1(NewCeil), sql.NumberArgument),
+	"ceil":              sql.Typed(sql.Function1(NewCeil), sql.NumberArgument),
+	"floor":             sql.Typed(sql.Function1(NewFloor), sql.NumberArgument),
+	"round":             sql.Typed(sql.FunctionN(NewRound), sql.NumberArgument),
+	"coalesce":          sql.FunctionN(NewCoalesce),
+	"ifnull":            sql.Function2(NewIfNull),
+	"nullif":            sql.Function2(NewNullIf),
+	"json_extract":      sql.FunctionN(NewJSONExtract),
+	"connection_id":     sql.Function0(NewConnectionID),
+	"soundex":           sql.Typed(sql.Function1(NewSoundex), sql.TextArgument),
+	"ln":                sql.Typed(sql.Function1(NewLogBaseFunc(float64(math.E))), sql.NumberArgument),
+	"log2":              sql.Typed(sql.Function1(NewLogBaseFunc(float64(2))), sql.NumberArgument),
+	"log10":             sql.Typed(sql.Function1(NewLogBaseFunc(float64(10))), sql.NumberArgument),
+	"log":               sql.Typed(sql.FunctionN(NewLog), sql.NumberArgument),
+	"rpad":              sql.Typed(sql.FunctionN(NewPadFunc(rPadType)), sql.TextArgument, sql.NumberArgument, sql.TextArgument),
+	"lpad":              sql.Typed(sql.FunctionN(NewPadFunc(lPadType)), sql.TextArgument, sql.NumberArgument, sql.TextArgument),
+	"sqrt":              sql.Typed(sql.Function1(NewSqrt), sql.NumberArgument),
+	"pow":               sql.Typed(sql.Function2(NewPower), sql.NumberArgument),
+	"power":             sql.Typed(sql.Function2(NewPower), sql.NumberArgument),
+	"ltrim":             sql.Typed(sql.Function1(NewTrimFunc(lTrimType)), sql.TextArgument),
+	"rtrim":             sql.Typed(sql.Function1(NewTrimFunc(rTrimType)), sql.TextArgument),
+	"trim":              sql.Typed(sql.Function1(NewTrimFunc(bTrimType)), sql.TextArgument),
+	"reverse":           sql.Typed(sql.Function1(NewReverse), sql.TextArgument),
+	"repeat":            sql.Typed(sql.Function2(NewRepeat), sql.TextArgument, sql.NumberArgument),
+	"replace":           sql.Typed(sql.Function3(NewReplace), sql.TextArgument),
+	"abs":               sql.Typed(sql.Function1(NewAbs), sql.NumberArgument),
+	"length":            sql.Typed(sql.Function1(NewLength), sql.TextArgument),
+	"char_length":       sql.Typed(sql.Function1(NewCharLength), sql.TextArgument),
+	"character_length":  sql.Typed(sql.Function1(NewCharLength), sql.TextArgument),
+	"now":               sql.Function0(NewNow),
+	"current_timestamp": sql.Function0(NewNow),
+	"uuid":              sql.Function0(NewUUID),
 }
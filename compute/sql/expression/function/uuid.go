@@ -0,0 +1,44 @@
+package function
+
+import (
+	"github.com/google/uuid"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// UUID returns a random, version 4, UUID in its canonical text form. Every
+// call, and so every row, gets a different one.
+type UUID struct{}
+
+// NewUUID creates a new UUID UDF node.
+func NewUUID() sql.Expression {
+	return UUID{}
+}
+
+// Children implements the sql.Expression interface.
+func (UUID) Children() []sql.Expression { return nil }
+
+// Type implements the sql.Expression interface.
+func (UUID) Type() sql.Type { return sql.Text }
+
+// Resolved implements the sql.Expression interface.
+func (UUID) Resolved() bool { return true }
+
+// TransformUp implements the sql.Expression interface.
+func (UUID) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
+	return f(UUID{})
+}
+
+// IsNullable implements the sql.Expression interface.
+func (UUID) IsNullable() bool { return false }
+
+// String implements the fmt.Stringer interface.
+func (UUID) String() string { return "uuid()" }
+
+// Eval implements the sql.Expression interface.
+func (UUID) Eval(ctx *sql.Context, _ sql.Row) (interface{}, error) {
+	u, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	return u.String(), nil
+}
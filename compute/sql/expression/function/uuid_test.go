@@ -0,0 +1,28 @@
+package function
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+func TestUUID(t *testing.T) {
+	require := require.New(t)
+
+	f := NewUUID()
+	first, err := f.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(err)
+	second, err := f.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(err)
+	require.NotEqual(first, second)
+
+	s := first.(string)
+	require.Len(s, 36)
+	require.Equal(byte('4'), s[14])
+
+	b, err := sql.UUID.Convert(s)
+	require.NoError(err)
+	require.Len(b, 16)
+	require.Equal(s, sql.FormatUUID(b.([]byte)))
+}
@@ -0,0 +1,62 @@
+package expression
+
+import (
+	"fmt"
+
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// InsertValue is the VALUES(col) function of an ON DUPLICATE KEY UPDATE
+// clause, which is the value the statement tried to insert in the column. It
+// is a placeholder that is resolved to the field of the inserted row.
+type InsertValue struct {
+	name string
+}
+
+// NewInsertValue creates a new InsertValue expression.
+func NewInsertValue(name string) *InsertValue {
+	return &InsertValue{name: name}
+}
+
+// Children implements the sql.Expression interface.
+// The function returns always nil
+func (*InsertValue) Children() []sql.Expression {
+	return nil
+}
+
+// Resolved implements the sql.Expression interface.
+// The function returns always false
+func (*InsertValue) Resolved() bool {
+	return false
+}
+
+// IsNullable implements the sql.Expression interface.
+// The function always panics!
+func (*InsertValue) IsNullable() bool {
+	panic("insert value is a placeholder node, but IsNullable was called")
+}
+
+// Type implements the sql.Expression interface.
+// The function always panics!
+func (*InsertValue) Type() sql.Type {
+	panic("insert value is a placeholder node, but Type was called")
+}
+
+// Name implements the sql.Nameable interface.
+func (v *InsertValue) Name() string { return v.name }
+
+func (v *InsertValue) String() string {
+	return fmt.Sprintf("VALUES(%s)", v.name)
+}
+
+// Eval implements the sql.Expression interface.
+// The function always panics!
+func (*InsertValue) Eval(ctx *sql.Context, r sql.Row) (interface{}, error) {
+	panic("insert value is a placeholder node, but Eval was called")
+}
+
+// TransformUp implements the sql.Expression interface.
+func (v *InsertValue) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
+	n := *v
+	return f(&n)
+}
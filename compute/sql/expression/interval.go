@@ -0,0 +1,102 @@
+package expression
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/types"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrIntervalNotInDateArithmetic is returned when an INTERVAL is used
+// anywhere but to move a date.
+var ErrIntervalNotInDateArithmetic = errors.NewKind("INTERVAL %s can only be added to or subtracted from a date")
+
+// Interval is the INTERVAL expr unit of date arithmetic, as in
+// DATE_ADD(d, INTERVAL 1 DAY) or d - INTERVAL 2 HOUR. It can't be evaluated
+// by itself, only added to a date with Add.
+type Interval struct {
+	UnaryExpression
+	Unit string
+}
+
+// NewInterval creates a new Interval of the given number of units.
+func NewInterval(child sql.Expression, unit string) *Interval {
+	return &Interval{UnaryExpression{Child: child}, strings.ToUpper(unit)}
+}
+
+// Type implements the Expression interface.
+func (i *Interval) Type() sql.Type { return i.Child.Type() }
+
+// IsNullable implements the Expression interface.
+func (i *Interval) IsNullable() bool { return i.Child.IsNullable() }
+
+// Eval implements the Expression interface.
+func (i *Interval) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	return nil, ErrIntervalNotInDateArithmetic.New(i.Child.String() + " " + i.Unit)
+}
+
+// String implements the Expression interface.
+func (i *Interval) String() string {
+	return fmt.Sprintf("INTERVAL %s %s", i.Child, i.Unit)
+}
+
+// TransformUp implements the Expression interface.
+func (i *Interval) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
+	child, err := i.Child.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return f(NewInterval(child, i.Unit))
+}
+
+// DateType returns the type of the result of moving a value of the given
+// type by the interval. Dates stay dates when they are moved by whole days,
+// and become timestamps otherwise.
+func (i *Interval) DateType(t sql.Type) sql.Type {
+	if t == sql.Date {
+		switch i.Unit {
+		case "DAY", "WEEK", "MONTH", "QUARTER", "YEAR":
+			return sql.Date
+		}
+	}
+	return sql.Timestamp
+}
+
+// Add evaluates the interval and moves the date by it, backwards if sub is
+// true. The result is NULL if the date or the interval are NULL, or if the
+// date is not a valid date.
+func (i *Interval) Add(ctx *sql.Context, row sql.Row, date sql.Expression, sub bool) (interface{}, error) {
+	d, err := date.Eval(ctx, row)
+	if err != nil || d == nil {
+		return nil, err
+	}
+
+	t, err := sql.Timestamp.Convert(d)
+	if err != nil {
+		return nil, nil
+	}
+
+	n, err := i.Child.Eval(ctx, row)
+	if err != nil || n == nil {
+		return nil, err
+	}
+
+	delta, err := sql.Int64.Convert(n)
+	if err != nil {
+		return nil, err
+	}
+	if sub {
+		delta = -delta.(int64)
+	}
+
+	moved, err := types.AddInterval(t.(time.Time), delta.(int64), i.Unit)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.DateType(date.Type()).Convert(moved)
+}
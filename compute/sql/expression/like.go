@@ -45,12 +45,22 @@ func (l *Like) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 			return nil, err
 		}
 
+		if v == nil {
+			return nil, nil
+		}
+
 		v, err = sql.Text.Convert(v)
 		if err != nil {
 			return nil, err
 		}
 
-		re, err = regex.New(regex.Default(), patternToRegex(v.(string)))
+		// Wildcards match any character, new lines included. Letters match
+		// either case if the strings are compared regardless of it.
+		flags := "(?s)"
+		if !sql.ComparisonCollation(l.Left.Type(), l.Right.Type()).CaseSensitive() {
+			flags = "(?is)"
+		}
+		re, err = regex.New(regex.Default(), flags+patternToRegex(v.(string)))
 		if err != nil {
 			return nil, err
 		}
@@ -67,6 +77,10 @@ func (l *Like) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, err
 	}
 
+	if value == nil {
+		return nil, nil
+	}
+
 	value, err = sql.Text.Convert(value)
 	if err != nil {
 		return nil, err
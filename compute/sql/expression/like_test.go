@@ -50,6 +50,12 @@ func TestLike(t *testing.T) {
 		{"a%b", "ab", true},
 		{"a%b", "a", false},
 		{"a_b", "ab", false},
+		{"A%", "Abc", true},
+		{"A%", "bAc", false},
+		{`a\_b`, "a_b", true},
+		{`a\_b`, "acb", false},
+		{"a%b", "a\nb", true},
+		{"a_b", "a\nb", true},
 	}
 
 	for _, tt := range testCases {
@@ -63,3 +69,36 @@ func TestLike(t *testing.T) {
 		})
 	}
 }
+
+func TestLike_Null(t *testing.T) {
+	f := NewLike(
+		NewGetField(0, sql.Text, "", true),
+		NewGetField(1, sql.Text, "", true),
+	)
+
+	for _, row := range []sql.Row{
+		sql.NewRow(nil, "a%"),
+		sql.NewRow("abc", nil),
+		sql.NewRow(nil, nil),
+	} {
+		value, err := f.Eval(sql.NewEmptyContext(), row)
+		require.NoError(t, err)
+		require.Nil(t, value)
+	}
+}
+
+func TestLike_Collation(t *testing.T) {
+	ci, err := sql.WithCollation(sql.Text, sql.Utf8mb4GeneralCI)
+	require.NoError(t, err)
+
+	row := sql.NewRow("ABC", "ab%")
+	value, err := NewLike(NewGetField(0, ci, "", false), NewGetField(1, sql.Text, "", false)).
+		Eval(sql.NewEmptyContext(), row)
+	require.NoError(t, err)
+	require.Equal(t, true, value)
+
+	value, err = NewLike(NewGetField(0, sql.Text, "", false), NewGetField(1, sql.Text, "", false)).
+		Eval(sql.NewEmptyContext(), row)
+	require.NoError(t, err)
+	require.Equal(t, false, value)
+}
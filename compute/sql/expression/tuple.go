@@ -82,7 +82,7 @@ func (t Tuple) TransformUp(f sql.TransformExprFunc) (sql.Expression, error) {
 	var exprs = make([]sql.Expression, len(t))
 	for i, e := range t {
 		var err error
-		exprs[i], err = f(e)
+		exprs[i], err = e.TransformUp(f)
 		if err != nil {
 			return nil, err
 		}
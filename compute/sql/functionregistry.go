@@ -11,6 +11,10 @@ var ErrFunctionNotFound = errors.NewKind("function not found: %s")
 // function is different from the function arity.
 var ErrInvalidArgumentNumber = errors.NewKind("expecting %v arguments for calling this function, %d received")
 
+// ErrInvalidArgumentType is returned when an argument of a function is not of
+// a type the function takes.
+var ErrInvalidArgumentType = errors.NewKind("function %s takes %s as argument %d, not %s")
+
 // Function is a function defined by the user that can be applied in a SQL
 // query.
 type Function interface {
@@ -130,6 +134,92 @@ func (Function6) isFunction() {}
 func (Function7) isFunction() {}
 func (FunctionN) isFunction() {}
 
+// ArgumentType is the kind of values a function takes as an argument. NULL
+// is a value of every kind, and tuples are not values of any.
+type ArgumentType byte
+
+const (
+	// AnyArgument takes values of any type.
+	AnyArgument ArgumentType = iota
+	// NumberArgument takes numbers, and the values that are converted to
+	// them: text, booleans and times.
+	NumberArgument
+	// TextArgument takes text, and the values that are converted to it,
+	// which are all but arrays.
+	TextArgument
+	// TimeArgument takes dates and timestamps, and the text and numbers
+	// that are parsed as them.
+	TimeArgument
+)
+
+// Accepts returns whether values of type t are of the kind.
+func (a ArgumentType) Accepts(t Type) bool {
+	if t == Null {
+		return true
+	}
+	if IsTuple(t) {
+		return false
+	}
+
+	switch a {
+	case NumberArgument:
+		return t != JSON && !IsArray(t)
+	case TextArgument:
+		return !IsArray(t)
+	case TimeArgument:
+		return IsTime(t) || IsNumber(t) || (IsText(t) && t != JSON)
+	default:
+		return true
+	}
+}
+
+func (a ArgumentType) String() string {
+	switch a {
+	case NumberArgument:
+		return "a number"
+	case TextArgument:
+		return "text"
+	case TimeArgument:
+		return "a date or timestamp"
+	default:
+		return "a value"
+	}
+}
+
+// TypedFunction is a Function that takes arguments of the given types. The
+// arguments after the last type are of that type too, so a function with a
+// variable number of arguments gives the type of them last.
+type TypedFunction struct {
+	Function
+	Arguments []ArgumentType
+}
+
+// Typed returns a function whose arguments are of the given types.
+func Typed(f Function, args ...ArgumentType) TypedFunction {
+	return TypedFunction{Function: f, Arguments: args}
+}
+
+// CheckArguments returns ErrInvalidArgumentType if any of the arguments the
+// function named name is called with is not of the type it takes. The
+// arguments must be resolved.
+func (f TypedFunction) CheckArguments(name string, args ...Expression) error {
+	if len(f.Arguments) == 0 {
+		return nil
+	}
+
+	for i, arg := range args {
+		typ := f.Arguments[len(f.Arguments)-1]
+		if i < len(f.Arguments) {
+			typ = f.Arguments[i]
+		}
+
+		if t := arg.Type(); !typ.Accepts(t) {
+			return ErrInvalidArgumentType.New(name, typ, i+1, t)
+		}
+	}
+	return nil
+}
+
 // FunctionRegistry is used to register functions. It is used both for builtin
 // and User-Defined Functions.
 type FunctionRegistry map[string]Function
@@ -42,3 +42,34 @@ func TestFunctionRegistryMissingFunction(t *testing.T) {
 	require.Error(err)
 	require.Nil(f)
 }
+
+func TestTypedFunction(t *testing.T) {
+	require := require.New(t)
+
+	f := sql.Typed(sql.FunctionN(func(args ...sql.Expression) (sql.Expression, error) {
+		return args[0], nil
+	}), sql.TextArgument, sql.NumberArgument)
+
+	text := expression.NewLiteral("a", sql.Text)
+	num := expression.NewLiteral(int64(1), sql.Int64)
+	json := expression.NewLiteral([]byte("{}"), sql.JSON)
+	null := expression.NewLiteral(nil, sql.Null)
+
+	require.NoError(f.CheckArguments("f", text, num))
+	require.NoError(f.CheckArguments("f", num, null))
+	// The arguments after the last type are of that type.
+	require.NoError(f.CheckArguments("f", text, num, num))
+
+	err := f.CheckArguments("f", text, num, json)
+	require.True(sql.ErrInvalidArgumentType.Is(err))
+	require.Equal("function f takes a number as argument 3, not JSON", err.Error())
+
+	err = f.CheckArguments("f", expression.NewTuple(text, text))
+	require.True(sql.ErrInvalidArgumentType.Is(err))
+
+	require.True(sql.TimeArgument.Accepts(sql.Date))
+	require.True(sql.TimeArgument.Accepts(sql.Text))
+	require.False(sql.TimeArgument.Accepts(sql.JSON))
+	require.False(sql.TextArgument.Accepts(sql.Array(sql.Text)))
+	require.True(sql.AnyArgument.Accepts(sql.Array(sql.Text)))
+}
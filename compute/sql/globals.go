@@ -0,0 +1,95 @@
+package sql
+
+import (
+	"strings"
+	"sync"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrUnknownSystemVariable is returned when setting a global variable that
+// was not defined.
+var ErrUnknownSystemVariable = errors.NewKind("unknown system variable '%s'")
+
+// ErrGlobalVariable is returned when setting a global variable without the
+// GLOBAL scope.
+var ErrGlobalVariable = errors.NewKind("variable '%s' is a GLOBAL variable and should be set with SET GLOBAL")
+
+// GlobalVariables holds the system variables shared by every session, such
+// as max_connections. A variable may have a function that applies its new
+// value, which is called before the value is stored and may reject it.
+type GlobalVariables struct {
+	mu   sync.RWMutex
+	vars map[string]*globalVariable
+}
+
+type globalVariable struct {
+	TypedValue
+	onSet func(value interface{}) error
+}
+
+// NewGlobalVariables returns a new empty set of global variables.
+func NewGlobalVariables() *GlobalVariables {
+	return &GlobalVariables{vars: make(map[string]*globalVariable)}
+}
+
+// DefineGlobalVariable adds the global variable with the given name, type
+// and value, replacing the one with the same name if any. onSet, which may
+// be nil, is called with the value given to the variable by SET GLOBAL,
+// already converted to typ.
+func (g *GlobalVariables) DefineGlobalVariable(name string, typ Type, value interface{}, onSet func(value interface{}) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.vars[strings.ToLower(name)] = &globalVariable{TypedValue{typ, value}, onSet}
+}
+
+// SetGlobalVariable changes the value of a global variable.
+func (g *GlobalVariables) SetGlobalVariable(name string, value interface{}) error {
+	name = strings.ToLower(name)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	v, ok := g.vars[name]
+	if !ok {
+		return ErrUnknownSystemVariable.New(name)
+	}
+
+	value, err := v.Typ.Convert(value)
+	if err != nil {
+		return err
+	}
+
+	if v.onSet != nil {
+		if err := v.onSet(value); err != nil {
+			return err
+		}
+	}
+	v.Value = value
+	return nil
+}
+
+// GlobalVariable returns the value of a global variable, and whether it's
+// defined.
+func (g *GlobalVariables) GlobalVariable(name string) (TypedValue, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	v, ok := g.vars[strings.ToLower(name)]
+	if !ok {
+		return TypedValue{}, false
+	}
+	return v.TypedValue, true
+}
+
+// AllGlobalVariables returns the values of all the global variables.
+func (g *GlobalVariables) AllGlobalVariables() map[string]TypedValue {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	m := make(map[string]TypedValue, len(g.vars))
+	for name, v := range g.vars {
+		m[name] = v.TypedValue
+	}
+	return m
+}
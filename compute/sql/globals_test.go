@@ -0,0 +1,41 @@
+package sql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalVariables(t *testing.T) {
+	g := NewGlobalVariables()
+
+	var applied interface{}
+	g.DefineGlobalVariable("Max_Things", Int64, int64(1), func(v interface{}) error {
+		if v.(int64) < 0 {
+			return errors.New("negative")
+		}
+		applied = v
+		return nil
+	})
+
+	v, ok := g.GlobalVariable("max_things")
+	require.True(t, ok)
+	require.Equal(t, TypedValue{Int64, int64(1)}, v)
+
+	// Values are converted to the type of the variable.
+	require.NoError(t, g.SetGlobalVariable("MAX_THINGS", "5"))
+	require.Equal(t, int64(5), applied)
+	v, _ = g.GlobalVariable("max_things")
+	require.Equal(t, int64(5), v.Value)
+
+	// A rejected value is not stored.
+	require.Error(t, g.SetGlobalVariable("max_things", -1))
+	v, _ = g.GlobalVariable("max_things")
+	require.Equal(t, int64(5), v.Value)
+
+	err := g.SetGlobalVariable("unknown", 1)
+	require.True(t, ErrUnknownSystemVariable.Is(err))
+
+	require.Equal(t, map[string]TypedValue{"max_things": {Int64, int64(5)}}, g.AllGlobalVariables())
+}
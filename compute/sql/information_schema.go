@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 )
 
 const (
@@ -19,8 +21,15 @@ const (
 	ColumnsTableName = "columns"
 	// SchemataTableName is the name of the schemata table.
 	SchemataTableName = "schemata"
+	// ProcessListTableName is the name of the processlist table.
+	ProcessListTableName = "processlist"
+	// KeyColumnUsageTableName is the name of the key_column_usage table.
+	KeyColumnUsageTableName = "key_column_usage"
 )
 
+// PrimaryKeyConstraintName is the name of the constraint of primary keys.
+const PrimaryKeyConstraintName = "PRIMARY"
+
 type informationSchemaDatabase struct {
 	name   string
 	tables map[string]Table
@@ -153,6 +162,94 @@ var schemataSchema = Schema{
 	{Name: "sql_path", Type: Text, Default: nil, Nullable: true, Source: SchemataTableName},
 }
 
+var processListSchema = Schema{
+	{Name: "id", Type: Int64, Default: nil, Nullable: false, Source: ProcessListTableName},
+	{Name: "user", Type: Text, Default: nil, Nullable: false, Source: ProcessListTableName},
+	{Name: "host", Type: Text, Default: nil, Nullable: false, Source: ProcessListTableName},
+	{Name: "db", Type: Text, Default: nil, Nullable: true, Source: ProcessListTableName},
+	{Name: "command", Type: Text, Default: nil, Nullable: false, Source: ProcessListTableName},
+	{Name: "time", Type: Int64, Default: nil, Nullable: false, Source: ProcessListTableName},
+	{Name: "state", Type: Text, Default: nil, Nullable: true, Source: ProcessListTableName},
+	{Name: "info", Type: Text, Default: nil, Nullable: true, Source: ProcessListTableName},
+}
+
+var keyColumnUsageSchema = Schema{
+	{Name: "constraint_catalog", Type: Text, Default: "", Nullable: false, Source: KeyColumnUsageTableName},
+	{Name: "constraint_schema", Type: Text, Default: "", Nullable: false, Source: KeyColumnUsageTableName},
+	{Name: "constraint_name", Type: Text, Default: "", Nullable: false, Source: KeyColumnUsageTableName},
+	{Name: "table_catalog", Type: Text, Default: "", Nullable: false, Source: KeyColumnUsageTableName},
+	{Name: "table_schema", Type: Text, Default: "", Nullable: false, Source: KeyColumnUsageTableName},
+	{Name: "table_name", Type: Text, Default: "", Nullable: false, Source: KeyColumnUsageTableName},
+	{Name: "column_name", Type: Text, Default: "", Nullable: false, Source: KeyColumnUsageTableName},
+	{Name: "ordinal_position", Type: Uint64, Default: 0, Nullable: false, Source: KeyColumnUsageTableName},
+	{Name: "position_in_unique_constraint", Type: Uint64, Default: nil, Nullable: true, Source: KeyColumnUsageTableName},
+	{Name: "referenced_table_schema", Type: Text, Default: nil, Nullable: true, Source: KeyColumnUsageTableName},
+	{Name: "referenced_table_name", Type: Text, Default: nil, Nullable: true, Source: KeyColumnUsageTableName},
+	{Name: "referenced_column_name", Type: Text, Default: nil, Nullable: true, Source: KeyColumnUsageTableName},
+}
+
+// sortedTables returns the tables of the database sorted by name.
+func sortedTables(db Database) []Table {
+	tables := db.Tables()
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := make([]Table, len(names))
+	for i, name := range names {
+		sorted[i] = tables[name]
+	}
+	return sorted
+}
+
+// mysqlColumnType returns the type of a column as MySQL shows it in the
+// column_type column of the columns table.
+func mysqlColumnType(t Type) string {
+	switch t {
+	case Int8:
+		return "tinyint"
+	case Int16:
+		return "smallint"
+	case Int24:
+		return "mediumint"
+	case Int32:
+		return "int"
+	case Int64:
+		return "bigint"
+	case Uint8:
+		return "tinyint unsigned"
+	case Uint16:
+		return "smallint unsigned"
+	case Uint24:
+		return "mediumint unsigned"
+	case Uint32:
+		return "int unsigned"
+	case Uint64:
+		return "bigint unsigned"
+	case Float32:
+		return "float"
+	case Float64:
+		return "double"
+	case Boolean:
+		return "tinyint(1)"
+	default:
+		return strings.ToLower(t.String())
+	}
+}
+
+// mysqlDataType returns the type of a column as MySQL shows it in the
+// data_type column of the columns table, which is the column type without
+// its length or attributes.
+func mysqlDataType(t Type) string {
+	dataType := mysqlColumnType(t)
+	if i := strings.IndexAny(dataType, "( "); i >= 0 {
+		dataType = dataType[:i]
+	}
+	return dataType
+}
+
 func tablesRowIter(cat *Catalog) RowIter {
 	var rows []Row
 	for _, db := range cat.AllDatabases() {
@@ -164,7 +261,7 @@ func tablesRowIter(cat *Catalog) RowIter {
 			engine = "MEMORY"
 			rowFormat = "Fixed"
 		}
-		for _, t := range db.Tables() {
+		for _, t := range sortedTables(db) {
 			rows = append(rows, Row{
 				"def",      //table_catalog
 				db.Name(),  // table_schema
@@ -197,12 +294,15 @@ func tablesRowIter(cat *Catalog) RowIter {
 func columnsRowIter(cat *Catalog) RowIter {
 	var rows []Row
 	for _, db := range cat.AllDatabases() {
-		for _, t := range db.Tables() {
+		for _, t := range sortedTables(db) {
 			for i, c := range t.Schema() {
 				var (
 					nullable string
 					charName interface{}
 					collName interface{}
+					colDef   interface{}
+					colKey   string
+					extra    string
 				)
 				if c.Nullable {
 					nullable = "YES"
@@ -213,28 +313,74 @@ func columnsRowIter(cat *Catalog) RowIter {
 					charName = "utf8mb4"
 					collName = "utf8_bin"
 				}
+				if collation := CollationOf(c.Type); collation != DefaultCollation {
+					collName = collation.Name()
+				}
+				if c.Default != nil {
+					colDef = fmt.Sprint(c.Default)
+				}
+				if c.PrimaryKey {
+					colKey = "PRI"
+				}
+				if c.AutoIncrement {
+					extra = "auto_increment"
+				}
+				if c.OnUpdateCurrentTimestamp {
+					extra = "on update CURRENT_TIMESTAMP"
+				}
 				rows = append(rows, Row{
-					"def",           // table_catalog
-					db.Name(),       // table_schema
-					t.Name(),        // table_name
-					c.Name,          // column_name
-					uint64(i),       // ordinal_position
-					c.Default,       // column_default
-					nullable,        // is_nullable
-					c.Type.String(), // data_type
-					nil,             // character_maximum_length
-					nil,             // character_octet_length
-					nil,             // numeric_precision
-					nil,             // numeric_scale
-					nil,             // datetime_precision
-					charName,        // character_set_name
-					collName,        // collation_name
-					c.Type.String(), // column_type
-					"",              // column_key
-					"",              // extra
-					"select",        // privileges
-					"",              // column_comment
-					"",              // generation_expression
+					"def",                   // table_catalog
+					db.Name(),               // table_schema
+					t.Name(),                // table_name
+					c.Name,                  // column_name
+					uint64(i + 1),           // ordinal_position
+					colDef,                  // column_default
+					nullable,                // is_nullable
+					mysqlDataType(c.Type),   // data_type
+					nil,                     // character_maximum_length
+					nil,                     // character_octet_length
+					nil,                     // numeric_precision
+					nil,                     // numeric_scale
+					nil,                     // datetime_precision
+					charName,                // character_set_name
+					collName,                // collation_name
+					mysqlColumnType(c.Type), // column_type
+					colKey,                  // column_key
+					extra,                   // extra
+					"select",                // privileges
+					"",                      // column_comment
+					"",                      // generation_expression
+				})
+			}
+		}
+	}
+	return RowsToRowIter(rows...)
+}
+
+func keyColumnUsageRowIter(cat *Catalog) RowIter {
+	var rows []Row
+	for _, db := range cat.AllDatabases() {
+		for _, t := range sortedTables(db) {
+			var position uint64
+			for _, c := range t.Schema() {
+				if !c.PrimaryKey {
+					continue
+				}
+
+				position++
+				rows = append(rows, Row{
+					"def",                    // constraint_catalog
+					db.Name(),                // constraint_schema
+					PrimaryKeyConstraintName, // constraint_name
+					"def",                    // table_catalog
+					db.Name(),                // table_schema
+					t.Name(),                 // table_name
+					c.Name,                   // column_name
+					position,                 // ordinal_position
+					nil,                      // position_in_unique_constraint
+					nil,                      // referenced_table_schema
+					nil,                      // referenced_table_name
+					nil,                      // referenced_column_name
 				})
 			}
 		}
@@ -263,6 +409,34 @@ func schemataRowIter(c *Catalog) RowIter {
 	return RowsToRowIter(rows...)
 }
 
+func processListRowIter(c *Catalog) RowIter {
+	var db interface{}
+	if name := c.CurrentDatabase(); name != "" {
+		db = name
+	}
+
+	processes := c.Processes()
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].Pid < processes[j].Pid
+	})
+
+	var rows = make([]Row, len(processes))
+	for i, proc := range processes {
+		rows[i] = Row{
+			int64(proc.Pid),       // id
+			proc.User,             // user
+			proc.Host,             // host
+			db,                    // db
+			proc.Type.String(),    // command
+			int64(proc.Seconds()), // time
+			proc.State(),          // state
+			proc.Query,            // info
+		}
+	}
+
+	return RowsToRowIter(rows...)
+}
+
 // NewInformationSchemaDatabase creates a new INFORMATION_SCHEMA Database.
 func NewInformationSchemaDatabase(cat *Catalog) Database {
 	return &informationSchemaDatabase{
@@ -296,6 +470,18 @@ func NewInformationSchemaDatabase(cat *Catalog) Database {
 				catalog: cat,
 				rowIter: schemataRowIter,
 			},
+			ProcessListTableName: &informationSchemaTable{
+				name:    ProcessListTableName,
+				schema:  processListSchema,
+				catalog: cat,
+				rowIter: processListRowIter,
+			},
+			KeyColumnUsageTableName: &informationSchemaTable{
+				name:    KeyColumnUsageTableName,
+				schema:  keyColumnUsageSchema,
+				catalog: cat,
+				rowIter: keyColumnUsageRowIter,
+			},
 		},
 	}
 }
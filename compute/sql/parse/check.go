@@ -0,0 +1,89 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrCheckColumnNotFound is returned when a CHECK constraint uses a column
+// its table doesn't have.
+var ErrCheckColumnNotFound = errors.NewKind("Check constraint '%s' refers to non-existing column '%s'.")
+
+// checkConstraints returns the CHECK constraints of a CREATE TABLE
+// statement, which include the ones given in the definitions of its columns.
+// Those without a name are named after the table, as MySQL does. The ones
+// that are NOT ENFORCED are left out, since they would never be evaluated.
+func checkConstraints(table string, spec *sqlparser.TableSpec, schema sql.Schema) ([]sql.CheckConstraint, error) {
+	var checks []sql.CheckConstraint
+	unnamed := 0
+	for _, def := range spec.Constraints {
+		check, ok := def.Details.(*sqlparser.CheckConstraintDefinition)
+		if !ok {
+			continue
+		}
+
+		name := def.Name
+		if name == "" {
+			unnamed++
+			name = fmt.Sprintf("%s_chk_%d", table, unnamed)
+		}
+		if !check.Enforced {
+			continue
+		}
+
+		c := sql.CheckConstraint{Name: name, Expr: sqlparser.String(check.Expr)}
+		if _, err := ParseCheck(c, schema); err != nil {
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+
+	return checks, nil
+}
+
+// ParseCheck parses the expression of a CHECK constraint of a table with the
+// given schema. The columns it uses are the fields of the rows of the table.
+func ParseCheck(check sql.CheckConstraint, schema sql.Schema) (sql.Expression, error) {
+	stmt, err := sqlparser.Parse("SELECT " + check.Expr)
+	if err != nil {
+		return nil, err
+	}
+
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok || len(sel.SelectExprs) != 1 {
+		return nil, ErrUnsupportedSyntax.New(check.Expr)
+	}
+
+	ae, ok := sel.SelectExprs[0].(*sqlparser.AliasedExpr)
+	if !ok {
+		return nil, ErrUnsupportedSyntax.New(check.Expr)
+	}
+
+	e, err := exprToExpression(ae.Expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.TransformUp(func(e sql.Expression) (sql.Expression, error) {
+		if col, ok := e.(*expression.UnresolvedColumn); ok {
+			for i, c := range schema {
+				if strings.EqualFold(c.Name, col.Name()) {
+					return expression.NewGetFieldWithTable(i, c.Type, c.Source, c.Name, c.Nullable), nil
+				}
+			}
+			return nil, ErrCheckColumnNotFound.New(check.Name, col.Name())
+		}
+
+		// Only columns, literals and operators can be used, so anything
+		// else, such as functions or subqueries, is left unresolved.
+		if !e.Resolved() {
+			return nil, ErrUnsupportedFeature.New(fmt.Sprintf("%s in CHECK constraint %s", e, check.Name))
+		}
+		return e, nil
+	})
+}
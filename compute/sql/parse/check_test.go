@@ -0,0 +1,58 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+func TestParseCreateTableChecks(t *testing.T) {
+	require := require.New(t)
+
+	node, err := Parse(sql.NewEmptyContext(), `CREATE TABLE t (
+		a INTEGER CHECK (a > 0),
+		b INTEGER,
+		CONSTRAINT b_small CHECK (b * 2 < 100),
+		CHECK (a <> b) NOT ENFORCED,
+		CHECK (a + b >= 0)
+	)`)
+	require.NoError(err)
+	require.Equal([]sql.CheckConstraint{
+		{Name: "t_chk_1", Expr: "a > 0"},
+		{Name: "b_small", Expr: "b * 2 < 100"},
+		{Name: "t_chk_3", Expr: "a + b >= 0"},
+	}, node.(*plan.CreateTable).Checks())
+
+	_, err = Parse(sql.NewEmptyContext(), "CREATE TABLE t (a INTEGER CHECK (b > 0))")
+	require.True(ErrCheckColumnNotFound.Is(err))
+
+	_, err = Parse(sql.NewEmptyContext(), "CREATE TABLE t (a INTEGER CHECK (abs(a) > 0))")
+	require.True(ErrUnsupportedFeature.Is(err))
+}
+
+func TestParseCheck(t *testing.T) {
+	require := require.New(t)
+
+	schema := sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t", Nullable: true},
+		{Name: "b", Type: sql.Int64, Source: "t", Nullable: true},
+	}
+	expr, err := ParseCheck(sql.CheckConstraint{Name: "c", Expr: "A + 1 <= b"}, schema)
+	require.NoError(err)
+
+	ctx := sql.NewEmptyContext()
+	for _, tt := range []struct {
+		row      sql.Row
+		expected interface{}
+	}{
+		{sql.NewRow(int64(1), int64(2)), true},
+		{sql.NewRow(int64(2), int64(2)), false},
+		{sql.NewRow(nil, int64(2)), nil},
+	} {
+		v, err := expr.Eval(ctx, tt.row)
+		require.NoError(err)
+		require.Equal(tt.expected, v, "%v", tt.row)
+	}
+}
@@ -11,22 +11,19 @@ import (
 
 var (
 	errInvalidDescribeFormat = errors.NewKind("invalid format %q for DESCRIBE, supported formats: %s")
-	describeSupportedFormats = []string{"tree"}
+	describeSupportedFormats = []string{plan.DescribeFormatTraditional, plan.DescribeFormatTree}
 )
 
+// parseDescribeQuery parses DESCRIBE, DESC and EXPLAIN of a query. Without
+// a FORMAT they use the traditional one, which describes the tables read
+// as MySQL does.
 func parseDescribeQuery(ctx *sql.Context, s string) (sql.Node, error) {
 	r := bufio.NewReader(strings.NewReader(s))
 
-	var format, query string
+	var query string
 	err := parseFuncs{
 		oneOf("describe", "desc", "explain"),
 		skipSpaces,
-		expect("format"),
-		skipSpaces,
-		expectRune('='),
-		skipSpaces,
-		readIdent(&format),
-		skipSpaces,
 		readRemaining(&query),
 	}.exec(r)
 
@@ -34,7 +31,33 @@ func parseDescribeQuery(ctx *sql.Context, s string) (sql.Node, error) {
 		return nil, err
 	}
 
-	if format != "tree" {
+	format := plan.DescribeFormatTraditional
+	var first string
+	if err := readIdent(&first)(bufio.NewReader(strings.NewReader(query))); err != nil {
+		return nil, err
+	}
+
+	switch first {
+	case "format":
+		err = parseFuncs{
+			expect("format"),
+			skipSpaces,
+			expectRune('='),
+			skipSpaces,
+			readIdent(&format),
+			skipSpaces,
+			readRemaining(&query),
+		}.exec(bufio.NewReader(strings.NewReader(query)))
+
+		if err != nil {
+			return nil, err
+		}
+	case "select":
+	default:
+		return nil, errUnexpectedSyntax.New("select", first)
+	}
+
+	if format != plan.DescribeFormatTree && format != plan.DescribeFormatTraditional {
 		return nil, errInvalidDescribeFormat.New(
 			format,
 			strings.Join(describeSupportedFormats, ", "),
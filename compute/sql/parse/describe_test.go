@@ -47,6 +47,22 @@ func TestParseDescribeQuery(t *testing.T) {
 			),
 			nil,
 		},
+		{
+			"EXPLAIN SELECT * FROM foo",
+			plan.NewDescribeQuery("traditional", plan.NewProject(
+				[]sql.Expression{expression.NewStar()},
+				plan.NewUnresolvedTable("foo", "")),
+			),
+			nil,
+		},
+		{
+			"EXPLAIN FORMAT=traditional SELECT * FROM foo",
+			plan.NewDescribeQuery("traditional", plan.NewProject(
+				[]sql.Expression{expression.NewStar()},
+				plan.NewUnresolvedTable("foo", "")),
+			),
+			nil,
+		},
 		{
 			"EXPLAIN FORMAT=tree SELECT * FROM foo",
 			plan.NewDescribeQuery("tree", plan.NewProject(
@@ -15,6 +15,8 @@ import (
 	"github.com/turtacn/guocedb/compute/sql/expression"
 	"github.com/turtacn/guocedb/compute/sql/expression/function"
 	"github.com/turtacn/guocedb/compute/sql/plan"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/dolthub/vitess/go/vt/proto/query"
 	"github.com/dolthub/vitess/go/vt/sqlparser"
 )
 
@@ -82,7 +84,9 @@ func Parse(ctx *sql.Context, query string) (sql.Node, error) {
 	case describeRegex.MatchString(lowerQuery):
 		return parseDescribeQuery(ctx, s)
 	case fullProcessListRegex.MatchString(lowerQuery):
-		return plan.NewShowProcessList(), nil
+		p := plan.NewShowProcessList()
+		p.Full = fullProcessListRegex.FindStringSubmatch(lowerQuery)[1] != ""
+		return p, nil
 	case unlockTablesRegex.MatchString(lowerQuery):
 		return plan.NewUnlockTables(), nil
 	case lockTablesRegex.MatchString(lowerQuery):
@@ -91,12 +95,35 @@ func Parse(ctx *sql.Context, query string) (sql.Node, error) {
 		s = fixSetQuery(s)
 	}
 
+	var returning string
+	if returningStmtRegex.MatchString(lowerQuery) {
+		s, returning = splitReturning(s)
+	}
+
+	var rollup bool
+	if selectStmtRegex.MatchString(lowerQuery) {
+		s, rollup = splitRollup(s)
+	}
+
 	stmt, err := sqlparser.Parse(s)
 	if err != nil {
 		return nil, err
 	}
 
-	return convertStatement(ctx, stmt, s)
+	node, err := convertStatement(ctx, stmt, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if rollup {
+		return withRollup(node)
+	}
+
+	if returning == "" {
+		return node, nil
+	}
+
+	return withReturning(node, returning)
 }
 
 func parseDescribeTables(s string) (sql.Node, error) {
@@ -133,14 +160,22 @@ func convertStatement(ctx *sql.Context, stmt sqlparser.Statement, query string)
 		return convertSelect(ctx, n)
 	case *sqlparser.Insert:
 		return convertInsert(ctx, n)
+	case *sqlparser.Update:
+		return convertUpdate(ctx, n)
+	case *sqlparser.Delete:
+		return convertDelete(ctx, n)
 	case *sqlparser.DDL:
 		return convertDDL(n)
 	case *sqlparser.DBDDL:
 		return convertDBDDL(n)
+	case *sqlparser.AlterTable:
+		return convertAlterTable(n)
 	case *sqlparser.Set:
 		return convertSet(ctx, n)
 	case *sqlparser.Use:
 		return convertUse(n)
+	case *sqlparser.Analyze:
+		return convertAnalyze(n)
 	case *sqlparser.SetOp: // Replaces Union
 		return convertSetOp(ctx, n)
 	case *sqlparser.ParenSelect:
@@ -211,14 +246,22 @@ func convertUse(n *sqlparser.Use) (sql.Node, error) {
 	return plan.NewUse(sql.UnresolvedDatabase(name)), nil
 }
 
+func convertAnalyze(n *sqlparser.Analyze) (sql.Node, error) {
+	if n.Action != "" {
+		return nil, ErrUnsupportedFeature.New("ANALYZE TABLE " + n.Action + " HISTOGRAM")
+	}
+
+	tables := make([]*plan.UnresolvedTable, len(n.Tables))
+	for i, t := range n.Tables {
+		tables[i] = plan.NewUnresolvedTable(t.Name.String(), t.DbQualifier.String())
+	}
+	return plan.NewAnalyzeTable(tables...), nil
+}
+
 func convertSet(ctx *sql.Context, n *sqlparser.Set) (sql.Node, error) {
 	var variables = make([]plan.SetVariable, len(n.Exprs))
 	for i, e := range n.Exprs {
 		// e is *sqlparser.SetVarExpr
-		if e.Scope == sqlparser.SetScope_Global {
-			return nil, ErrUnsupportedFeature.New("SET global variables")
-		}
-
 		expr, err := exprToExpression(e.Expr)
 		if err != nil {
 			return nil, err
@@ -285,9 +328,15 @@ func convertShow(s *sqlparser.Show, query string) (sql.Node, error) {
 		return plan.NewShowTables(sql.UnresolvedDatabase("")), nil
 	case "DATABASES":
 		return plan.NewShowDatabases(), nil
+	case "ENGINES":
+		return plan.NewShowEngines(), nil
 	case "FIELDS", "COLUMNS":
-		// s.Table (not s.OnTable)
-		table := plan.NewUnresolvedTable(s.Table.Name.String(), s.Table.DbQualifier.String())
+		// s.Table (not s.OnTable), in the database of FROM db if it has one.
+		db := s.Table.DbQualifier.String()
+		if s.ShowTablesOpt != nil && s.ShowTablesOpt.DbName != "" {
+			db = s.ShowTablesOpt.DbName
+		}
+		table := plan.NewUnresolvedTable(s.Table.Name.String(), db)
 		// s.Full (boolean on struct)
 		full := s.Full
 
@@ -338,10 +387,6 @@ func convertSelect(ctx *sql.Context, s *sqlparser.Select) (sql.Node, error) {
 		}
 	}
 
-	if s.Having != nil {
-		return nil, ErrUnsupportedFeature.New("HAVING")
-	}
-
 	if s.Where != nil {
 		node, err = whereToFilter(s.Where, node)
 		if err != nil {
@@ -354,6 +399,13 @@ func convertSelect(ctx *sql.Context, s *sqlparser.Select) (sql.Node, error) {
 		return nil, err
 	}
 
+	if s.Having != nil {
+		node, err = havingToHaving(s.Having, node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Distinct is in QueryOpts
 	if s.QueryOpts.Distinct {
 		node = plan.NewDistinct(node)
@@ -383,6 +435,16 @@ func convertSelect(ctx *sql.Context, s *sqlparser.Select) (sql.Node, error) {
 		}
 	}
 
+	switch s.Lock {
+	case "":
+	case sqlparser.ForUpdateStr:
+		node = plan.NewLockRows(sql.RowLockExclusive, node)
+	case sqlparser.ShareModeStr:
+		node = plan.NewLockRows(sql.RowLockShared, node)
+	default:
+		return nil, ErrUnsupportedFeature.New(strings.TrimSpace(s.Lock))
+	}
+
 	return node, nil
 }
 
@@ -392,15 +454,100 @@ func convertDDL(c *sqlparser.DDL) (sql.Node, error) {
 		return convertCreateTable(c)
 	case sqlparser.DropStr:
 		return convertDropTable(c)
+	case sqlparser.TruncateStr:
+		return convertTruncateTable(c)
 	default:
 		return nil, ErrUnsupportedSyntax.New(c)
 	}
 }
 
+// convertAlterTable converts an ALTER TABLE statement that adds, drops or
+// modifies a single column. Other alterations are not supported yet.
+func convertAlterTable(c *sqlparser.AlterTable) (sql.Node, error) {
+	if len(c.Statements) != 1 {
+		return nil, ErrUnsupportedFeature.New("ALTER TABLE with several alterations")
+	}
+
+	ddl := c.Statements[0]
+	db := sql.UnresolvedDatabase(c.Table.DbQualifier.String())
+	table := c.Table.Name.String()
+
+	if ddl.AutoIncSpec != nil {
+		return convertAlterAutoIncrement(db, table, ddl.AutoIncSpec)
+	}
+
+	switch strings.ToLower(ddl.ColumnAction) {
+	case sqlparser.AddStr:
+		if ddl.ColumnOrder != nil {
+			return nil, ErrUnsupportedFeature.New("FIRST and AFTER in ADD COLUMN")
+		}
+
+		column, err := alterColumnDefinition(ddl.TableSpec)
+		if err != nil {
+			return nil, err
+		}
+		return plan.NewAddColumn(db, table, column), nil
+	case sqlparser.DropStr:
+		return plan.NewDropColumn(db, table, ddl.Column.String()), nil
+	case sqlparser.ModifyStr:
+		if ddl.ColumnOrder != nil {
+			return nil, ErrUnsupportedFeature.New("FIRST and AFTER in MODIFY COLUMN")
+		}
+
+		column, err := alterColumnDefinition(ddl.TableSpec)
+		if err != nil {
+			return nil, err
+		}
+		return plan.NewModifyColumn(db, table, column), nil
+	default:
+		return nil, ErrUnsupportedSyntax.New(c)
+	}
+}
+
+// convertAlterAutoIncrement converts ALTER TABLE ... AUTO_INCREMENT = n.
+func convertAlterAutoIncrement(db sql.Database, table string, spec *sqlparser.AutoIncSpec) (sql.Node, error) {
+	val, ok := spec.Value.(*sqlparser.SQLVal)
+	if !ok || val.Type != sqlparser.IntVal {
+		return nil, ErrUnsupportedSyntax.New(spec)
+	}
+
+	next, err := strconv.ParseUint(string(val.Val), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return plan.NewAlterAutoIncrement(db, table, next), nil
+}
+
+// alterColumnDefinition returns the column added by ALTER TABLE, whose
+// default is given to the rows the table already has.
+func alterColumnDefinition(spec *sqlparser.TableSpec) (*sql.Column, error) {
+	if spec == nil || len(spec.Columns) != 1 {
+		return nil, ErrUnsupportedFeature.New("ALTER TABLE of several columns")
+	}
+
+	schema, err := columnDefinitionToSchema(spec.Columns)
+	if err != nil {
+		return nil, err
+	}
+	return schema[0], nil
+}
+
 func convertDBDDL(c *sqlparser.DBDDL) (sql.Node, error) {
 	switch c.Action {
 	case sqlparser.CreateStr:
-		return plan.NewCreateDatabase(c.DBName, c.IfNotExists), nil
+		create := plan.NewCreateDatabase(c.DBName, c.IfNotExists)
+		for _, cc := range c.CharsetCollate {
+			if !strings.EqualFold(cc.Type, "collate") {
+				continue
+			}
+
+			collation, err := sql.ParseCollation(cc.Value)
+			if err != nil {
+				return nil, err
+			}
+			create = create.WithCollation(collation)
+		}
+		return create, nil
 	case sqlparser.DropStr:
 		return plan.NewDropDatabase(c.DBName, c.IfExists), nil
 	default:
@@ -408,6 +555,11 @@ func convertDBDDL(c *sqlparser.DBDDL) (sql.Node, error) {
 	}
 }
 
+func convertTruncateTable(c *sqlparser.DDL) (sql.Node, error) {
+	db := sql.UnresolvedDatabase(c.Table.DbQualifier.String())
+	return plan.NewTruncateTable(db, c.Table.Name.String()), nil
+}
+
 func convertDropTable(c *sqlparser.DDL) (sql.Node, error) {
 	tableName := c.Table.Name.String()
 	dbName := c.Table.DbQualifier.String()
@@ -421,15 +573,124 @@ func convertCreateTable(c *sqlparser.DDL) (sql.Node, error) {
 		return nil, err
 	}
 
-	return plan.NewCreateTable(
-		sql.UnresolvedDatabase(""), c.Table.Name.String(), schema), nil
+	for _, idx := range c.TableSpec.Indexes {
+		if idx.Info == nil || !idx.Info.Primary {
+			continue
+		}
+
+		for _, ic := range idx.Columns {
+			i := schema.IndexOf(ic.Column.String(), "")
+			if i < 0 {
+				return nil, ErrUnsupportedSyntax.New(fmt.Sprintf("unknown column %s in PRIMARY KEY", ic.Column))
+			}
+			schema[i].PrimaryKey = true
+			schema[i].Nullable = false
+		}
+	}
+
+	checks, err := checkConstraints(c.Table.Name.String(), c.TableSpec, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes, err := tableIndexes(c.TableSpec, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var collated []string
+	for _, cd := range c.TableSpec.Columns {
+		if cd.Type.Collate != "" {
+			collated = append(collated, cd.Name.String())
+		}
+	}
+
+	return plan.NewCreateTableWithOptions(
+		sql.UnresolvedDatabase(c.Table.DbQualifier.String()),
+		c.Table.Name.String(),
+		schema,
+		tableOptions(c.TableSpec.TableOpts),
+	).WithChecks(checks).WithIndexes(indexes).WithCollatedColumns(collated...), nil
 }
 
-func convertInsert(ctx *sql.Context, i *sqlparser.Insert) (sql.Node, error) {
-	if len(i.OnDup) > 0 {
-		return nil, ErrUnsupportedFeature.New("ON DUPLICATE KEY")
+// tableIndexes returns the secondary indexes of a CREATE TABLE statement,
+// which include the UNIQUE columns. Those without a name are named after
+// their first column, as MySQL does. FULLTEXT and SPATIAL indexes are not
+// supported, so they are left out.
+func tableIndexes(spec *sqlparser.TableSpec, schema sql.Schema) ([]sql.IndexDef, error) {
+	var indexes []sql.IndexDef
+	names := make(map[string]bool)
+	add := func(name string, columns []string, unique bool) error {
+		for i, col := range columns {
+			idx := schema.IndexOf(col, "")
+			if idx < 0 {
+				return ErrUnsupportedSyntax.New(fmt.Sprintf("unknown column %s in index", col))
+			}
+			columns[i] = schema[idx].Name
+		}
+
+		if name == "" {
+			name = columns[0]
+			for n := 2; names[strings.ToLower(name)]; n++ {
+				name = fmt.Sprintf("%s_%d", columns[0], n)
+			}
+		} else if names[strings.ToLower(name)] {
+			return ErrUnsupportedSyntax.New(fmt.Sprintf("duplicate key name %s", name))
+		}
+		names[strings.ToLower(name)] = true
+
+		indexes = append(indexes, sql.IndexDef{Name: name, Columns: columns, Unique: unique})
+		return nil
+	}
+
+	for _, cd := range spec.Columns {
+		if cd.Type.KeyOpt == colKeyUnique || cd.Type.KeyOpt == colKeyUniqueKey {
+			if err := add("", []string{cd.Name.String()}, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, idx := range spec.Indexes {
+		info := idx.Info
+		if info == nil || info.Primary || info.Fulltext || info.Spatial || info.Vector {
+			continue
+		}
+
+		columns := make([]string, len(idx.Columns))
+		for i, ic := range idx.Columns {
+			columns[i] = ic.Column.String()
+		}
+		if err := add(info.Name.String(), columns, info.Unique); err != nil {
+			return nil, err
+		}
+	}
+
+	return indexes, nil
+}
+
+// tableOptions returns the options of a CREATE TABLE statement keyed by their
+// upper-case name. Whether an option is supported is decided when the table
+// is created.
+func tableOptions(opts []*sqlparser.TableOption) sql.TableOptions {
+	if len(opts) == 0 {
+		return nil
 	}
 
+	options := make(sql.TableOptions, len(opts))
+	for _, opt := range opts {
+		name := strings.ToUpper(strings.Join(strings.Fields(opt.Name), " "))
+		value := opt.Value
+		if name == sql.TableOptionRowFormat {
+			value = strings.ToUpper(value)
+		}
+		options[name] = value
+	}
+
+	return options
+}
+
+func convertInsert(ctx *sql.Context, i *sqlparser.Insert) (sql.Node, error) {
 	if len(i.Ignore) > 0 {
 		return nil, ErrUnsupportedSyntax.New(i)
 	}
@@ -440,34 +701,270 @@ func convertInsert(ctx *sql.Context, i *sqlparser.Insert) (sql.Node, error) {
 	}
 
 	// Qualifier -> DbQualifier
-	return plan.NewInsertInto(
+	insert := plan.NewInsertInto(
 		plan.NewUnresolvedTable(i.Table.Name.String(), i.Table.DbQualifier.String()),
 		src,
 		columnsToStrings(i.Columns),
-	), nil
+	)
+
+	if len(i.OnDup) == 0 {
+		return insert, nil
+	}
+
+	columns := make([]sql.Expression, len(i.OnDup))
+	values := make([]sql.Expression, len(i.OnDup))
+	for j, e := range i.OnDup {
+		columns[j], err = exprToExpression(e.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		values[j], err = exprToExpression(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return insert.WithOnDuplicate(columns, values), nil
 }
 
+func convertUpdate(ctx *sql.Context, u *sqlparser.Update) (sql.Node, error) {
+	if len(u.Ignore) > 0 {
+		return nil, ErrUnsupportedSyntax.New(u)
+	}
+
+	node, err := dmlTableExprsToTable(ctx, u.TableExprs)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Where != nil {
+		node, err = whereToFilter(u.Where, node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	node, err = dmlOrderByLimit(ctx, u.OrderBy, u.Limit, node)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]sql.Expression, len(u.Exprs))
+	values := make([]sql.Expression, len(u.Exprs))
+	for i, e := range u.Exprs {
+		columns[i], err = exprToExpression(e.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		values[i], err = exprToExpression(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return plan.NewUpdate(node, columns, values), nil
+}
+
+func convertDelete(ctx *sql.Context, d *sqlparser.Delete) (sql.Node, error) {
+	if len(d.Targets) > 0 {
+		return nil, ErrUnsupportedFeature.New("multi-table DELETE")
+	}
+
+	node, err := dmlTableExprsToTable(ctx, d.TableExprs)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Where != nil {
+		node, err = whereToFilter(d.Where, node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	node, err = dmlOrderByLimit(ctx, d.OrderBy, d.Limit, node)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewDeleteFrom(node), nil
+}
+
+// dmlOrderByLimit sorts and limits the rows an UPDATE or DELETE statement
+// modifies, so only the first rows in the given order are modified. As in
+// MySQL, the limit can't have an offset.
+func dmlOrderByLimit(ctx *sql.Context, ob sqlparser.OrderBy, limit *sqlparser.Limit, node sql.Node) (sql.Node, error) {
+	var err error
+	if len(ob) != 0 {
+		node, err = orderByToSort(ob, node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if limit != nil {
+		if limit.Offset != nil {
+			return nil, ErrUnsupportedSyntax.New(sqlparser.String(limit))
+		}
+
+		node, err = limitToLimit(ctx, limit.Rowcount, node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+// dmlTableExprsToTable converts the table of an UPDATE or DELETE statement,
+// which can only modify a single table.
+func dmlTableExprsToTable(ctx *sql.Context, te sqlparser.TableExprs) (sql.Node, error) {
+	if len(te) != 1 {
+		return nil, ErrUnsupportedFeature.New("multi-table UPDATE and DELETE")
+	}
+
+	if _, ok := te[0].(*sqlparser.AliasedTableExpr); !ok {
+		return nil, ErrUnsupportedFeature.New("multi-table UPDATE and DELETE")
+	}
+
+	return tableExprToTable(ctx, te[0])
+}
+
+// Key options of the columns declared PRIMARY KEY and UNIQUE [KEY], which
+// vitess doesn't export.
+const (
+	colKeyPrimary   sqlparser.ColumnKeyOption = 1
+	colKeyUnique    sqlparser.ColumnKeyOption = 3
+	colKeyUniqueKey sqlparser.ColumnKeyOption = 4
+)
+
 func columnDefinitionToSchema(colDef []*sqlparser.ColumnDefinition) (sql.Schema, error) {
 	var schema sql.Schema
 	for _, cd := range colDef {
 		typ := cd.Type
-		internalTyp, err := sql.MysqlTypeToType(typ.SQLType())
+		internalTyp, err := columnType(typ)
 		if err != nil {
 			return nil, err
 		}
 
+		def, err := columnDefault(typ.Default, internalTyp)
+		if err != nil {
+			return nil, err
+		}
+
+		onUpdate := typ.OnUpdate != nil
+		if onUpdate && (!isCurrentTimestamp(typ.OnUpdate) || !sql.IsTime(internalTyp)) {
+			return nil, ErrUnsupportedFeature.New(fmt.Sprintf("ON UPDATE %s for a column of type %s",
+				sqlparser.String(typ.OnUpdate), internalTyp))
+		}
+
+		var comment string
+		if typ.Comment != nil {
+			comment = string(typ.Comment.Val)
+		}
+
+		primaryKey := typ.KeyOpt == colKeyPrimary
 		schema = append(schema, &sql.Column{
-			Nullable: !bool(typ.NotNull),
-			Type:     internalTyp,
-			Name:     cd.Name.String(),
-			// TODO
-			Default: nil,
+			Nullable:      !bool(typ.NotNull) && !primaryKey,
+			Type:          internalTyp,
+			Name:          cd.Name.String(),
+			AutoIncrement: bool(typ.Autoincrement),
+			PrimaryKey:    primaryKey,
+			Default:       def,
+			Comment:       comment,
+
+			OnUpdateCurrentTimestamp: onUpdate,
 		})
 	}
 
 	return schema, nil
 }
 
+// columnDefault returns the default of a column of the given type, which
+// is either a literal, converted to the type, or CURRENT_TIMESTAMP for the
+// time columns.
+func columnDefault(def sqlparser.Expr, typ sql.Type) (interface{}, error) {
+	if def == nil {
+		return nil, nil
+	}
+
+	if isCurrentTimestamp(def) {
+		if !sql.IsTime(typ) {
+			return nil, ErrUnsupportedFeature.New(fmt.Sprintf("DEFAULT %s for a column of type %s", sqlparser.String(def), typ))
+		}
+		return sql.CurrentTimestamp, nil
+	}
+
+	e, err := exprToExpression(def)
+	if err != nil {
+		return nil, err
+	}
+
+	lit, ok := e.(*expression.Literal)
+	if !ok {
+		return nil, ErrUnsupportedFeature.New("non-literal DEFAULT")
+	}
+
+	v, err := lit.Eval(nil, nil)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return typ.Convert(v)
+}
+
+// isCurrentTimestamp returns whether e is CURRENT_TIMESTAMP or one of its
+// synonyms.
+func isCurrentTimestamp(e sqlparser.Expr) bool {
+	f, ok := e.(*sqlparser.FuncExpr)
+	if !ok {
+		return false
+	}
+	switch f.Name.Lowered() {
+	case "current_timestamp", "now", "localtime", "localtimestamp":
+		return true
+	default:
+		return false
+	}
+}
+
+// columnType returns the type of a column definition. VARCHAR columns keep
+// their length, so longer values can be truncated, and text columns keep
+// their collation.
+func columnType(typ sqlparser.ColumnType) (sql.Type, error) {
+	var t sql.Type
+	if typ.SQLType() == sqltypes.VarChar && typ.Length != nil {
+		length, err := strconv.Atoi(string(typ.Length.Val))
+		if err != nil {
+			return nil, ErrUnsupportedSyntax.New(typ.Length)
+		}
+		t = sql.VarChar(length)
+	} else if typ.SQLType() == sqltypes.Binary {
+		// BINARY(16) is how UUIDs are kept, and the only binary string
+		// of a fixed length there is.
+		if typ.Length == nil || string(typ.Length.Val) != "16" {
+			return nil, sql.ErrTypeNotSupported.New(typ.Type)
+		}
+		t = sql.UUID
+	} else {
+		var err error
+		if t, err = sql.MysqlTypeToType(typ.SQLType()); err != nil {
+			return nil, err
+		}
+	}
+
+	if typ.Collate == "" {
+		return t, nil
+	}
+
+	collation, err := sql.ParseCollation(typ.Collate)
+	if err != nil {
+		return nil, err
+	}
+	return sql.WithCollation(t, collation)
+}
+
 func columnsToStrings(cols sqlparser.Columns) []string {
 	res := make([]string, len(cols))
 	for i, c := range cols {
@@ -573,7 +1070,10 @@ func tableExprToTable(
 		}
 	case *sqlparser.JoinTableExpr:
 		// TODO: add support for the rest of joins
-		if t.Join != sqlparser.JoinStr && t.Join != sqlparser.NaturalJoinStr {
+		switch t.Join {
+		case sqlparser.JoinStr, sqlparser.NaturalJoinStr, sqlparser.LeftJoinStr,
+			sqlparser.RightJoinStr, sqlparser.FullOuterJoinStr:
+		default:
 			return nil, ErrUnsupportedFeature.New(t.Join)
 		}
 
@@ -602,7 +1102,16 @@ func tableExprToTable(
 			return nil, err
 		}
 
-		return plan.NewInnerJoin(left, right, cond), nil
+		switch t.Join {
+		case sqlparser.LeftJoinStr:
+			return plan.NewLeftJoin(left, right, cond), nil
+		case sqlparser.RightJoinStr:
+			return plan.NewRightJoin(left, right, cond), nil
+		case sqlparser.FullOuterJoinStr:
+			return plan.NewFullOuterJoin(left, right, cond), nil
+		default:
+			return plan.NewInnerJoin(left, right, cond), nil
+		}
 	}
 }
 
@@ -759,6 +1268,8 @@ func exprToExpression(e sqlparser.Expr) (sql.Expression, error) {
 		return nil, ErrUnsupportedSyntax.New(e)
 	case *sqlparser.Default:
 		return expression.NewDefaultColumn(v.ColName), nil
+	case *sqlparser.ValuesFuncExpr:
+		return expression.NewInsertValue(v.Name.Name.String()), nil
 	case *sqlparser.SubstrExpr:
 		var (
 			name sql.Expression
@@ -887,6 +1398,17 @@ func exprToExpression(e sqlparser.Expr) (sql.Expression, error) {
 		}
 		return expression.NewTuple(exprs...), nil
 
+	case *sqlparser.IntervalExpr:
+		expr, err := exprToExpression(v.Expr)
+		if err != nil {
+			return nil, err
+		}
+
+		return expression.NewInterval(expr, v.Unit), nil
+
+	case *sqlparser.CaseExpr:
+		return caseExprToExpression(v)
+
 	case *sqlparser.BinaryExpr:
 		return binaryExprToExpression(v)
 	case *sqlparser.UnaryExpr:
@@ -894,6 +1416,56 @@ func exprToExpression(e sqlparser.Expr) (sql.Expression, error) {
 	}
 }
 
+func caseExprToExpression(c *sqlparser.CaseExpr) (sql.Expression, error) {
+	var expr, elseExpr sql.Expression
+	var err error
+	if c.Expr != nil {
+		if expr, err = exprToExpression(c.Expr); err != nil {
+			return nil, err
+		}
+	}
+
+	branches := make([]expression.CaseBranch, len(c.Whens))
+	for i, w := range c.Whens {
+		if branches[i].Cond, err = exprToExpression(w.Cond); err != nil {
+			return nil, err
+		}
+		if branches[i].Value, err = exprToExpression(w.Val); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Else != nil {
+		if elseExpr, err = exprToExpression(c.Else); err != nil {
+			return nil, err
+		}
+	}
+
+	return expression.NewCase(expr, branches, elseExpr), nil
+}
+
+// LiteralExpression returns the expression of a literal value, the same
+// as it's given in the plans of the queries where it's written.
+func LiteralExpression(v *sqlparser.SQLVal) (sql.Expression, error) {
+	return convertVal(v)
+}
+
+// BindVarExpression returns the expression of the value bound to a
+// parameter of a prepared statement, which is the literal the value is
+// parsed as when it's written in the query.
+func BindVarExpression(bv *query.BindVariable) (sql.Expression, error) {
+	v, err := sqltypes.BindVariableToValue(bv)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := sqlparser.ExprFromValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return exprToExpression(e)
+}
+
 func convertVal(v *sqlparser.SQLVal) (sql.Expression, error) {
 	switch v.Type {
 	case sqlparser.StrVal:
@@ -931,7 +1503,7 @@ func convertVal(v *sqlparser.SQLVal) (sql.Expression, error) {
 		}
 		return expression.NewLiteral(val, sql.Blob), nil
 	case sqlparser.ValArg:
-		return expression.NewLiteral(string(v.Val), sql.Text), nil
+		return expression.NewBindVar(strings.TrimPrefix(string(v.Val), ":")), nil
 	case sqlparser.BitVal:
 		return expression.NewLiteral(v.Val[0] == '1', sql.Boolean), nil
 	}
@@ -1078,6 +1650,19 @@ func binaryExprToExpression(be *sqlparser.BinaryExpr) (sql.Expression, error) {
 
 		return expression.NewArithmetic(l, r, be.Operator), nil
 
+	case sqlparser.JSONExtractOp:
+		l, err := exprToExpression(be.Left)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := exprToExpression(be.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		return expression.NewUnresolvedFunction("json_extract", false, l, r), nil
+
 	default:
 		return nil, ErrUnsupportedFeature.New(be.Operator)
 	}
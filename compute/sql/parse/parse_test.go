@@ -33,7 +33,7 @@ var fixtures = map[string]sql.Node{
 			Nullable: true,
 		}, {
 			Name:     "e",
-			Type:     sql.Text,
+			Type:     sql.VarChar(20),
 			Nullable: true,
 		}, {
 			Name:     "f",
@@ -41,6 +41,130 @@ var fixtures = map[string]sql.Node{
 			Nullable: false,
 		}},
 	),
+	`CREATE TABLE t1(a INTEGER) engine=InnoDB DEFAULT CHARSET utf8mb4 row_format=dynamic COMMENT 'foo' KEY_BLOCK_SIZE=8`: plan.NewCreateTableWithOptions(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		sql.Schema{{
+			Name:     "a",
+			Type:     sql.Int32,
+			Nullable: true,
+		}},
+		sql.TableOptions{
+			sql.TableOptionEngine:    "InnoDB",
+			sql.TableOptionCharset:   "utf8mb4",
+			sql.TableOptionRowFormat: "DYNAMIC",
+			sql.TableOptionComment:   "foo",
+			"KEY_BLOCK_SIZE":         "8",
+		},
+	),
+	`CREATE TABLE t1(a INTEGER PRIMARY KEY, b TEXT)`: plan.NewCreateTable(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		sql.Schema{{
+			Name:       "a",
+			Type:       sql.Int32,
+			PrimaryKey: true,
+		}, {
+			Name:     "b",
+			Type:     sql.Text,
+			Nullable: true,
+		}},
+	),
+	`CREATE TABLE t1(region TEXT, id INTEGER, PRIMARY KEY (region, id))`: plan.NewCreateTable(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		sql.Schema{{
+			Name:       "region",
+			Type:       sql.Text,
+			PrimaryKey: true,
+		}, {
+			Name:       "id",
+			Type:       sql.Int32,
+			PrimaryKey: true,
+		}},
+	),
+	"CREATE TABLE t1(a INTEGER UNIQUE COMMENT 'the a', b TEXT, KEY (b), KEY (b, a), UNIQUE KEY ab (a, b))": plan.NewCreateTable(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		sql.Schema{{
+			Name:     "a",
+			Type:     sql.Int32,
+			Nullable: true,
+			Comment:  "the a",
+		}, {
+			Name:     "b",
+			Type:     sql.Text,
+			Nullable: true,
+		}},
+	).WithIndexes([]sql.IndexDef{
+		{Name: "a", Columns: []string{"a"}, Unique: true},
+		{Name: "b", Columns: []string{"b"}},
+		{Name: "b_2", Columns: []string{"b", "a"}},
+		{Name: "ab", Columns: []string{"a", "b"}, Unique: true},
+	}),
+	`ALTER TABLE t1 ADD COLUMN age INT`: plan.NewAddColumn(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		&sql.Column{Name: "age", Type: sql.Int32, Nullable: true},
+	),
+	`ALTER TABLE t1 ADD COLUMN n INT NOT NULL DEFAULT 5`: plan.NewAddColumn(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		&sql.Column{Name: "n", Type: sql.Int32, Default: int32(5)},
+	),
+	`ALTER TABLE t1 ADD COLUMN at TIMESTAMP DEFAULT CURRENT_TIMESTAMP`: plan.NewAddColumn(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		&sql.Column{Name: "at", Type: sql.Timestamp, Nullable: true, Default: sql.CurrentTimestamp},
+	),
+	`ALTER TABLE t1 ADD COLUMN at TIMESTAMP ON UPDATE NOW()`: plan.NewAddColumn(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		&sql.Column{Name: "at", Type: sql.Timestamp, Nullable: true, OnUpdateCurrentTimestamp: true},
+	),
+	`CREATE TABLE t1(a DOUBLE DEFAULT 0.00, b VARCHAR(20) DEFAULT 'x', c DATETIME DEFAULT NOW())`: plan.NewCreateTable(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		sql.Schema{{
+			Name:     "a",
+			Type:     sql.Float64,
+			Nullable: true,
+			Default:  float64(0),
+		}, {
+			Name:     "b",
+			Type:     sql.VarChar(20),
+			Nullable: true,
+			Default:  "x",
+		}, {
+			Name:     "c",
+			Type:     sql.Timestamp,
+			Nullable: true,
+			Default:  sql.CurrentTimestamp,
+		}},
+	),
+	`ALTER TABLE mydb.t1 DROP COLUMN age`: plan.NewDropColumn(
+		sql.UnresolvedDatabase("mydb"),
+		"t1",
+		"age",
+	),
+	`ALTER TABLE t1 MODIFY COLUMN age BIGINT NOT NULL`: plan.NewModifyColumn(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		&sql.Column{Name: "age", Type: sql.Int64},
+	),
+	`ALTER TABLE t1 AUTO_INCREMENT = 100`: plan.NewAlterAutoIncrement(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		100,
+	),
+	`TRUNCATE TABLE t1`: plan.NewTruncateTable(
+		sql.UnresolvedDatabase(""),
+		"t1",
+	),
+	`TRUNCATE mydb.t1`: plan.NewTruncateTable(
+		sql.UnresolvedDatabase("mydb"),
+		"t1",
+	),
 	`DESCRIBE TABLE foo;`: plan.NewDescribe(
 		plan.NewUnresolvedTable("foo", ""),
 	),
@@ -117,6 +241,26 @@ var fixtures = map[string]sql.Node{
 			plan.NewUnresolvedTable("foo", ""),
 		),
 	),
+	`SELECT foo FROM foo WHERE foo = 1 FOR UPDATE`: plan.NewLockRows(sql.RowLockExclusive,
+		plan.NewProject(
+			[]sql.Expression{expression.NewUnresolvedColumn("foo")},
+			plan.NewFilter(
+				expression.NewEquals(
+					expression.NewUnresolvedColumn("foo"),
+					expression.NewLiteral(int64(1), sql.Int64),
+				),
+				plan.NewUnresolvedTable("foo", ""),
+			),
+		),
+	),
+	`SELECT foo FROM foo LIMIT 1 LOCK IN SHARE MODE`: plan.NewLockRows(sql.RowLockShared,
+		plan.NewLimit(1,
+			plan.NewProject(
+				[]sql.Expression{expression.NewUnresolvedColumn("foo")},
+				plan.NewUnresolvedTable("foo", ""),
+			),
+		),
+	),
 	`SELECT foo, bar FROM foo ORDER BY baz DESC;`: plan.NewSort(
 		[]plan.SortField{{Column: expression.NewUnresolvedColumn("baz"), Order: plan.Descending, NullOrdering: plan.NullsFirst}},
 		plan.NewProject(
@@ -246,6 +390,20 @@ var fixtures = map[string]sql.Node{
 		}}),
 		[]string{"col1", "col2"},
 	),
+	`INSERT INTO t1 (col1, col2) VALUES ('a', 1) ON DUPLICATE KEY UPDATE col2 = col2 + VALUES(col2)`: plan.NewInsertInto(
+		plan.NewUnresolvedTable("t1", ""),
+		plan.NewValues([][]sql.Expression{{
+			expression.NewLiteral("a", sql.Text),
+			expression.NewLiteral(int64(1), sql.Int64),
+		}}),
+		[]string{"col1", "col2"},
+	).WithOnDuplicate(
+		[]sql.Expression{expression.NewUnresolvedColumn("col2")},
+		[]sql.Expression{expression.NewPlus(
+			expression.NewUnresolvedColumn("col2"),
+			expression.NewInsertValue("col2"),
+		)},
+	),
 	`SHOW TABLES`: plan.NewShowTables(sql.UnresolvedDatabase("")),
 	`SELECT DISTINCT foo, bar FROM foo;`: plan.NewDistinct(
 		plan.NewProject(
@@ -403,7 +561,7 @@ var fixtures = map[string]sql.Node{
 		[]sql.Expression{expression.NewStar()},
 		plan.NewFilter(
 			expression.NewEquals(
-				expression.NewLiteral(":foo_id", sql.Text),
+				expression.NewBindVar("foo_id"),
 				expression.NewLiteral(int64(2), sql.Int64),
 			),
 			plan.NewUnresolvedTable("foo", ""),
@@ -420,6 +578,50 @@ var fixtures = map[string]sql.Node{
 			),
 		),
 	),
+	`SELECT * FROM foo LEFT JOIN bar ON a = b`: plan.NewProject(
+		[]sql.Expression{expression.NewStar()},
+		plan.NewLeftJoin(
+			plan.NewUnresolvedTable("foo", ""),
+			plan.NewUnresolvedTable("bar", ""),
+			expression.NewEquals(
+				expression.NewUnresolvedColumn("a"),
+				expression.NewUnresolvedColumn("b"),
+			),
+		),
+	),
+	`SELECT * FROM foo LEFT OUTER JOIN bar ON a = b`: plan.NewProject(
+		[]sql.Expression{expression.NewStar()},
+		plan.NewLeftJoin(
+			plan.NewUnresolvedTable("foo", ""),
+			plan.NewUnresolvedTable("bar", ""),
+			expression.NewEquals(
+				expression.NewUnresolvedColumn("a"),
+				expression.NewUnresolvedColumn("b"),
+			),
+		),
+	),
+	`SELECT * FROM foo RIGHT JOIN bar ON a = b`: plan.NewProject(
+		[]sql.Expression{expression.NewStar()},
+		plan.NewRightJoin(
+			plan.NewUnresolvedTable("foo", ""),
+			plan.NewUnresolvedTable("bar", ""),
+			expression.NewEquals(
+				expression.NewUnresolvedColumn("a"),
+				expression.NewUnresolvedColumn("b"),
+			),
+		),
+	),
+	`SELECT * FROM foo FULL OUTER JOIN bar ON a = b`: plan.NewProject(
+		[]sql.Expression{expression.NewStar()},
+		plan.NewFullOuterJoin(
+			plan.NewUnresolvedTable("foo", ""),
+			plan.NewUnresolvedTable("bar", ""),
+			expression.NewEquals(
+				expression.NewUnresolvedColumn("a"),
+				expression.NewUnresolvedColumn("b"),
+			),
+		),
+	),
 	`SELECT foo.a FROM foo`: plan.NewProject(
 		[]sql.Expression{
 			expression.NewUnresolvedQualifiedColumn("foo", "a"),
@@ -639,6 +841,16 @@ var fixtures = map[string]sql.Node{
 		[]sql.Expression{},
 		plan.NewUnresolvedTable("foo", ""),
 	),
+	`SELECT doc->'$.a' FROM foo`: plan.NewProject(
+		[]sql.Expression{
+			expression.NewUnresolvedFunction(
+				"json_extract", false,
+				expression.NewUnresolvedColumn("doc"),
+				expression.NewLiteral("$.a", sql.Text),
+			),
+		},
+		plan.NewUnresolvedTable("foo", ""),
+	),
 	`SHOW INDEXES FROM foo`: plan.NewShowIndexes(sql.UnresolvedDatabase(""), "foo", nil),
 	`SHOW INDEX FROM foo`:   plan.NewShowIndexes(sql.UnresolvedDatabase(""), "foo", nil),
 	`SHOW KEYS FROM foo`:    plan.NewShowIndexes(sql.UnresolvedDatabase(""), "foo", nil),
@@ -652,7 +864,7 @@ var fixtures = map[string]sql.Node{
 		"qux",
 		make(map[string]string),
 	),
-	`SHOW FULL PROCESSLIST`: plan.NewShowProcessList(),
+	`SHOW FULL PROCESSLIST`: &plan.ShowProcessList{Full: true},
 	`SHOW PROCESSLIST`:      plan.NewShowProcessList(),
 	`SELECT @@allowed_max_packet`: plan.NewProject([]sql.Expression{
 		expression.NewUnresolvedColumn("@@allowed_max_packet"),
@@ -763,6 +975,7 @@ var fixtures = map[string]sql.Node{
 		plan.NewUnresolvedTable("foo", ""),
 	),
 	`SHOW DATABASES`: plan.NewShowDatabases(),
+	`SHOW ENGINES`:   plan.NewShowEngines(),
 	`SELECT * FROM foo WHERE i LIKE 'foo'`: plan.NewProject(
 		[]sql.Expression{expression.NewStar()},
 		plan.NewFilter(
@@ -785,6 +998,7 @@ var fixtures = map[string]sql.Node{
 	),
 	`SHOW FIELDS FROM foo`:       plan.NewShowColumns(false, plan.NewUnresolvedTable("foo", "")),
 	`SHOW FULL COLUMNS FROM foo`: plan.NewShowColumns(true, plan.NewUnresolvedTable("foo", "")),
+	`SHOW COLUMNS FROM foo FROM bar`: plan.NewShowColumns(false, plan.NewUnresolvedTable("foo", "bar")),
 	`SHOW FIELDS FROM foo WHERE Field = 'bar'`: plan.NewFilter(
 		expression.NewEquals(
 			expression.NewUnresolvedColumn("Field"),
@@ -835,6 +1049,10 @@ var fixtures = map[string]sql.Node{
 	`SHOW VARIABLES LIKE 'gtid_mode'`:          plan.NewShowVariables(sql.NewEmptyContext().GetAll(), "gtid_mode"),
 	`SHOW SESSION VARIABLES LIKE 'autocommit'`: plan.NewShowVariables(sql.NewEmptyContext().GetAll(), "autocommit"),
 	`UNLOCK TABLES`:                            plan.NewUnlockTables(),
+	`ANALYZE TABLE foo, bar.baz`: plan.NewAnalyzeTable(
+		plan.NewUnresolvedTable("foo", ""),
+		plan.NewUnresolvedTable("baz", "bar"),
+	),
 	`LOCK TABLES foo READ`: plan.NewLockTables([]*plan.TableLock{
 		{Table: plan.NewUnresolvedTable("foo", "")},
 	}),
@@ -888,6 +1106,41 @@ var fixtures = map[string]sql.Node{
 	"SHOW CREATE SCHEMA `foo`":                 plan.NewShowCreateDatabase(sql.UnresolvedDatabase("foo"), false),
 	"SHOW CREATE DATABASE IF NOT EXISTS `foo`": plan.NewShowCreateDatabase(sql.UnresolvedDatabase("foo"), true),
 	"SHOW CREATE SCHEMA IF NOT EXISTS `foo`":   plan.NewShowCreateDatabase(sql.UnresolvedDatabase("foo"), true),
+	`UPDATE foo SET a = 1, b = a WHERE c = 2`: plan.NewUpdate(
+		plan.NewFilter(
+			expression.NewEquals(
+				expression.NewUnresolvedColumn("c"),
+				expression.NewLiteral(int64(2), sql.Int64),
+			),
+			plan.NewUnresolvedTable("foo", ""),
+		),
+		[]sql.Expression{
+			expression.NewUnresolvedColumn("a"),
+			expression.NewUnresolvedColumn("b"),
+		},
+		[]sql.Expression{
+			expression.NewLiteral(int64(1), sql.Int64),
+			expression.NewUnresolvedColumn("a"),
+		},
+	),
+	`DELETE FROM foo`: plan.NewDeleteFrom(plan.NewUnresolvedTable("foo", "")),
+	`DELETE FROM foo WHERE a > 1 ORDER BY b DESC LIMIT 2`: plan.NewDeleteFrom(
+		plan.NewLimit(2, plan.NewSort(
+			[]plan.SortField{{Column: expression.NewUnresolvedColumn("b"), Order: plan.Descending}},
+			plan.NewFilter(
+				expression.NewGreaterThan(
+					expression.NewUnresolvedColumn("a"),
+					expression.NewLiteral(int64(1), sql.Int64),
+				),
+				plan.NewUnresolvedTable("foo", ""),
+			),
+		)),
+	),
+	`UPDATE foo SET a = 1 LIMIT 3`: plan.NewUpdate(
+		plan.NewLimit(3, plan.NewUnresolvedTable("foo", "")),
+		[]sql.Expression{expression.NewUnresolvedColumn("a")},
+		[]sql.Expression{expression.NewLiteral(int64(1), sql.Int64)},
+	),
 }
 
 func TestParse(t *testing.T) {
@@ -908,6 +1161,10 @@ var fixturesErrors = map[string]*errors.Kind{
 	// `SHOW METHEMONEY`:                   ErrUnsupportedFeature, // Disabled because sqlparser might fail earlier
 	`LOCK TABLES foo AS READ`:           errUnexpectedSyntax,
 	`LOCK TABLES foo LOW_PRIORITY READ`: errUnexpectedSyntax,
+	`ALTER TABLE t1 ADD COLUMN b INT AFTER a`: ErrUnsupportedFeature,
+	`ALTER TABLE t1 MODIFY b BIGINT FIRST`:    ErrUnsupportedFeature,
+	`DELETE FROM foo LIMIT 1, 2`:              ErrUnsupportedSyntax,
+	`SELECT foo FROM foo FOR UPDATE SKIP LOCKED`: ErrUnsupportedFeature,
 }
 
 func TestParseErrors(t *testing.T) {
@@ -0,0 +1,81 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+var returningStmtRegex = regexp.MustCompile(`^(insert|update|delete)\s`)
+
+const returningKeyword = "returning"
+
+// splitReturning separates a trailing RETURNING clause from a data
+// modification statement, because the MySQL grammar does not support it.
+// It returns the statement without the clause and the list of expressions to
+// return, which is empty if the statement has no RETURNING clause.
+func splitReturning(query string) (string, string) {
+	var (
+		quote rune
+		depth int
+		pos   = -1
+	)
+
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == '\\' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case depth == 0 && i > 0 && unicode.IsSpace(runes[i-1]):
+			end := i + len(returningKeyword)
+			if end < len(runes) &&
+				unicode.IsSpace(runes[end]) &&
+				strings.EqualFold(string(runes[i:end]), returningKeyword) {
+				pos = i
+			}
+		}
+	}
+
+	if pos < 0 {
+		return query, ""
+	}
+
+	exprs := strings.TrimSpace(string(runes[pos+len(returningKeyword):]))
+	return strings.TrimSpace(string(runes[:pos])), exprs
+}
+
+// withReturning wraps the given data modification node so it returns the
+// given expressions evaluated on the rows it affected.
+func withReturning(node sql.Node, returning string) (sql.Node, error) {
+	stmt, err := sqlparser.Parse("SELECT " + returning)
+	if err != nil {
+		return nil, err
+	}
+
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok || len(sel.From) != 0 {
+		return nil, ErrUnsupportedSyntax.New(returning)
+	}
+
+	exprs, err := selectExprsToExpressions(sel.SelectExprs)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewReturning(exprs, node)
+}
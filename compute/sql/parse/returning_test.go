@@ -0,0 +1,100 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+func TestSplitReturning(t *testing.T) {
+	testCases := []struct {
+		input string
+		stmt  string
+		exprs string
+	}{
+		{
+			"INSERT INTO t VALUES (1)",
+			"INSERT INTO t VALUES (1)",
+			"",
+		},
+		{
+			"INSERT INTO t VALUES (1) RETURNING id",
+			"INSERT INTO t VALUES (1)",
+			"id",
+		},
+		{
+			"insert into t values (1)\nreturning id, name",
+			"insert into t values (1)",
+			"id, name",
+		},
+		{
+			"INSERT INTO t VALUES ('a returning b')",
+			"INSERT INTO t VALUES ('a returning b')",
+			"",
+		},
+		{
+			"UPDATE t SET returning = 1 RETURNING returning",
+			"UPDATE t SET returning = 1",
+			"returning",
+		},
+		{
+			"DELETE FROM t WHERE a IN (SELECT returning FROM u) RETURNING *",
+			"DELETE FROM t WHERE a IN (SELECT returning FROM u)",
+			"*",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.input, func(t *testing.T) {
+			require := require.New(t)
+			stmt, exprs := splitReturning(tt.input)
+			require.Equal(tt.stmt, stmt)
+			require.Equal(tt.exprs, exprs)
+		})
+	}
+}
+
+func TestParseReturning(t *testing.T) {
+	require := require.New(t)
+
+	expected, err := plan.NewReturning(
+		[]sql.Expression{expression.NewUnresolvedColumn("id")},
+		plan.NewInsertInto(
+			plan.NewUnresolvedTable("t", ""),
+			plan.NewValues([][]sql.Expression{{
+				expression.NewLiteral("a", sql.Text),
+			}}),
+			[]string{"name"},
+		),
+	)
+	require.NoError(err)
+
+	node, err := Parse(sql.NewEmptyContext(), `INSERT INTO t (name) VALUES ('a') RETURNING id`)
+	require.NoError(err)
+	require.Equal(expected, node)
+
+	expected, err = plan.NewReturning(
+		[]sql.Expression{expression.NewStar()},
+		plan.NewDeleteFrom(plan.NewFilter(
+			expression.NewEquals(
+				expression.NewUnresolvedColumn("id"),
+				expression.NewLiteral(int64(1), sql.Int64),
+			),
+			plan.NewUnresolvedTable("t", ""),
+		)),
+	)
+	require.NoError(err)
+
+	node, err = Parse(sql.NewEmptyContext(), `DELETE FROM t WHERE id = 1 RETURNING *`)
+	require.NoError(err)
+	require.Equal(expected, node)
+
+	_, err = Parse(sql.NewEmptyContext(), `SELECT 1 RETURNING id`)
+	require.Error(err)
+
+	_, err = Parse(sql.NewEmptyContext(), `INSERT INTO t VALUES (1) RETURNING id FROM t`)
+	require.Error(err)
+}
@@ -0,0 +1,119 @@
+package parse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+var selectStmtRegex = regexp.MustCompile(`^select\s`)
+
+var rollupRegex = regexp.MustCompile(`(?i)^with\s+rollup\b`)
+
+// splitRollup removes the WITH ROLLUP modifier of the GROUP BY clause of a
+// SELECT statement, because the MySQL grammar does not support it. It
+// returns the statement without the modifier and whether it had one.
+func splitRollup(query string) (string, bool) {
+	var (
+		quote rune
+		depth int
+	)
+
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == '\\' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case depth == 0 && i > 0 && unicode.IsSpace(runes[i-1]):
+			rest := string(runes[i:])
+			if loc := rollupRegex.FindStringIndex(rest); loc != nil {
+				return strings.TrimSpace(string(runes[:i]) + rest[loc[1]:]), true
+			}
+		}
+	}
+
+	return query, false
+}
+
+// withRollup makes the GROUP BY of the given SELECT node return the
+// super-aggregate rows of WITH ROLLUP.
+func withRollup(node sql.Node) (sql.Node, error) {
+	for n := node; ; {
+		if g, ok := n.(*plan.GroupBy); ok && len(g.Grouping) > 0 {
+			g.Rollup = true
+			return node, nil
+		}
+
+		children := n.Children()
+		if len(children) != 1 {
+			return nil, ErrUnsupportedSyntax.New("WITH ROLLUP without GROUP BY")
+		}
+		n = children[0]
+	}
+}
+
+// havingToHaving converts the HAVING clause of a SELECT statement whose
+// select expressions are converted to the given node. The aggregations of
+// the condition that aren't in the select expressions are computed by the
+// GroupBy in hidden columns.
+func havingToHaving(h *sqlparser.Where, node sql.Node) (*plan.Having, error) {
+	cond, err := exprToExpression(h.Expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAggregate(cond) {
+		return plan.NewHaving(cond, 0, node), nil
+	}
+
+	var g *plan.GroupBy
+	switch n := node.(type) {
+	case *plan.GroupBy:
+		g = n
+	case *plan.Project:
+		g = plan.NewGroupBy(n.Projections, nil, n.Child)
+	default:
+		return nil, ErrUnsupportedSyntax.New(h.Expr)
+	}
+
+	var hidden int
+	cond, err = cond.TransformUp(func(e sql.Expression) (sql.Expression, error) {
+		fn, ok := e.(*expression.UnresolvedFunction)
+		if !ok || !fn.IsAggregate {
+			return e, nil
+		}
+
+		for _, agg := range g.Aggregate {
+			if alias, ok := agg.(*expression.Alias); ok && alias.Child.String() == fn.String() {
+				return expression.NewUnresolvedColumn(alias.Name()), nil
+			}
+		}
+
+		hidden++
+		name := fmt.Sprintf("__having_%d", hidden)
+		g.Aggregate = append(g.Aggregate, expression.NewAlias(fn, name))
+		return expression.NewUnresolvedColumn(name), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewHaving(cond, hidden, g), nil
+}
@@ -0,0 +1,94 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+func TestSplitRollup(t *testing.T) {
+	testCases := []struct {
+		input  string
+		stmt   string
+		rollup bool
+	}{
+		{
+			"SELECT a FROM t GROUP BY a",
+			"SELECT a FROM t GROUP BY a",
+			false,
+		},
+		{
+			"SELECT a FROM t GROUP BY a WITH ROLLUP",
+			"SELECT a FROM t GROUP BY a",
+			true,
+		},
+		{
+			"select a from t group by a with\n rollup having a > 1",
+			"select a from t group by a  having a > 1",
+			true,
+		},
+		{
+			"SELECT 'with rollup' FROM t GROUP BY a",
+			"SELECT 'with rollup' FROM t GROUP BY a",
+			false,
+		},
+		{
+			"SELECT a FROM t WHERE a IN (SELECT b FROM u GROUP BY b WITH ROLLUP)",
+			"SELECT a FROM t WHERE a IN (SELECT b FROM u GROUP BY b WITH ROLLUP)",
+			false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.input, func(t *testing.T) {
+			require := require.New(t)
+			stmt, rollup := splitRollup(tt.input)
+			require.Equal(tt.stmt, stmt)
+			require.Equal(tt.rollup, rollup)
+		})
+	}
+}
+
+func TestParseRollupAndHaving(t *testing.T) {
+	require := require.New(t)
+
+	sum := expression.NewUnresolvedFunction("sum", true, expression.NewUnresolvedColumn("b"))
+	groupBy := plan.NewGroupBy(
+		[]sql.Expression{
+			expression.NewUnresolvedColumn("a"),
+			expression.NewAlias(sum, "total"),
+			expression.NewAlias(
+				expression.NewUnresolvedFunction("count", true, expression.NewStar()),
+				"__having_1",
+			),
+		},
+		[]sql.Expression{expression.NewUnresolvedColumn("a")},
+		plan.NewUnresolvedTable("t", ""),
+	)
+	groupBy.Rollup = true
+	expected := plan.NewHaving(
+		expression.NewAnd(
+			expression.NewGreaterThan(
+				expression.NewUnresolvedColumn("total"),
+				expression.NewLiteral(int64(100), sql.Int64),
+			),
+			expression.NewGreaterThan(
+				expression.NewUnresolvedColumn("__having_1"),
+				expression.NewLiteral(int64(1), sql.Int64),
+			),
+		),
+		1,
+		groupBy,
+	)
+
+	node, err := Parse(sql.NewEmptyContext(),
+		`SELECT a, SUM(b) AS total FROM t GROUP BY a WITH ROLLUP HAVING SUM(b) > 100 AND COUNT(*) > 1`)
+	require.NoError(err)
+	require.Equal(expected, node)
+
+	_, err = Parse(sql.NewEmptyContext(), `SELECT a FROM t WITH ROLLUP`)
+	require.Error(err)
+}
@@ -0,0 +1,222 @@
+package plan
+
+import (
+	"fmt"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrAlterTable is thrown when the database doesn't support altering tables.
+var ErrAlterTable = errors.NewKind("tables cannot be altered on database %s")
+
+// AddColumn is a node describing the addition of a column to a table.
+type AddColumn struct {
+	Database sql.Database
+	table    string
+	column   *sql.Column
+}
+
+// NewAddColumn creates a new AddColumn node.
+func NewAddColumn(db sql.Database, table string, column *sql.Column) *AddColumn {
+	column.Source = table
+	return &AddColumn{
+		Database: db,
+		table:    table,
+		column:   column,
+	}
+}
+
+// Resolved implements the Resolvable interface.
+func (a *AddColumn) Resolved() bool {
+	_, ok := a.Database.(sql.UnresolvedDatabase)
+	return !ok
+}
+
+// RowIter implements the Node interface.
+func (a *AddColumn) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	d, ok := a.Database.(sql.AlterableTable)
+	if !ok {
+		return nil, ErrAlterTable.New(a.Database.Name())
+	}
+
+	return sql.RowsToRowIter(), d.AddColumn(ctx, a.table, a.column)
+}
+
+// Schema implements the Node interface.
+func (a *AddColumn) Schema() sql.Schema { return nil }
+
+// Children implements the Node interface.
+func (a *AddColumn) Children() []sql.Node { return nil }
+
+// TransformUp implements the Transformable interface.
+func (a *AddColumn) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	return f(NewAddColumn(a.Database, a.table, a.column))
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (a *AddColumn) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	return a, nil
+}
+
+func (a *AddColumn) String() string {
+	return fmt.Sprintf("AddColumn(%s.%s)", a.table, a.column.Name)
+}
+
+// DropColumn is a node describing the removal of a column from a table.
+type DropColumn struct {
+	Database sql.Database
+	table    string
+	column   string
+}
+
+// NewDropColumn creates a new DropColumn node.
+func NewDropColumn(db sql.Database, table, column string) *DropColumn {
+	return &DropColumn{
+		Database: db,
+		table:    table,
+		column:   column,
+	}
+}
+
+// Resolved implements the Resolvable interface.
+func (d *DropColumn) Resolved() bool {
+	_, ok := d.Database.(sql.UnresolvedDatabase)
+	return !ok
+}
+
+// RowIter implements the Node interface.
+func (d *DropColumn) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	db, ok := d.Database.(sql.AlterableTable)
+	if !ok {
+		return nil, ErrAlterTable.New(d.Database.Name())
+	}
+
+	return sql.RowsToRowIter(), db.DropColumn(ctx, d.table, d.column)
+}
+
+// Schema implements the Node interface.
+func (d *DropColumn) Schema() sql.Schema { return nil }
+
+// Children implements the Node interface.
+func (d *DropColumn) Children() []sql.Node { return nil }
+
+// TransformUp implements the Transformable interface.
+func (d *DropColumn) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	return f(NewDropColumn(d.Database, d.table, d.column))
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (d *DropColumn) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	return d, nil
+}
+
+func (d *DropColumn) String() string {
+	return fmt.Sprintf("DropColumn(%s.%s)", d.table, d.column)
+}
+
+// ModifyColumn is a node describing the change of the definition of a
+// column of a table.
+type ModifyColumn struct {
+	Database sql.Database
+	table    string
+	column   *sql.Column
+}
+
+// NewModifyColumn creates a new ModifyColumn node.
+func NewModifyColumn(db sql.Database, table string, column *sql.Column) *ModifyColumn {
+	return &ModifyColumn{
+		Database: db,
+		table:    table,
+		column:   column,
+	}
+}
+
+// Resolved implements the Resolvable interface.
+func (m *ModifyColumn) Resolved() bool {
+	_, ok := m.Database.(sql.UnresolvedDatabase)
+	return !ok
+}
+
+// RowIter implements the Node interface.
+func (m *ModifyColumn) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	db, ok := m.Database.(sql.AlterableTable)
+	if !ok {
+		return nil, ErrAlterTable.New(m.Database.Name())
+	}
+
+	return sql.RowsToRowIter(), db.ModifyColumn(ctx, m.table, m.column)
+}
+
+// Schema implements the Node interface.
+func (m *ModifyColumn) Schema() sql.Schema { return nil }
+
+// Children implements the Node interface.
+func (m *ModifyColumn) Children() []sql.Node { return nil }
+
+// TransformUp implements the Transformable interface.
+func (m *ModifyColumn) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	return f(NewModifyColumn(m.Database, m.table, m.column))
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (m *ModifyColumn) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	return m, nil
+}
+
+func (m *ModifyColumn) String() string {
+	return fmt.Sprintf("ModifyColumn(%s.%s)", m.table, m.column.Name)
+}
+
+// AlterAutoIncrement is a node describing a change of the value the next
+// row inserted in a table gets for its AUTO_INCREMENT column.
+type AlterAutoIncrement struct {
+	Database sql.Database
+	table    string
+	next     uint64
+}
+
+// NewAlterAutoIncrement creates a new AlterAutoIncrement node.
+func NewAlterAutoIncrement(db sql.Database, table string, next uint64) *AlterAutoIncrement {
+	return &AlterAutoIncrement{
+		Database: db,
+		table:    table,
+		next:     next,
+	}
+}
+
+// Resolved implements the Resolvable interface.
+func (a *AlterAutoIncrement) Resolved() bool {
+	_, ok := a.Database.(sql.UnresolvedDatabase)
+	return !ok
+}
+
+// RowIter implements the Node interface.
+func (a *AlterAutoIncrement) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	d, ok := a.Database.(sql.AutoIncrementAlterable)
+	if !ok {
+		return nil, ErrAlterTable.New(a.Database.Name())
+	}
+
+	return sql.RowsToRowIter(), d.SetAutoIncrement(ctx, a.table, a.next)
+}
+
+// Schema implements the Node interface.
+func (a *AlterAutoIncrement) Schema() sql.Schema { return nil }
+
+// Children implements the Node interface.
+func (a *AlterAutoIncrement) Children() []sql.Node { return nil }
+
+// TransformUp implements the Transformable interface.
+func (a *AlterAutoIncrement) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	return f(NewAlterAutoIncrement(a.Database, a.table, a.next))
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (a *AlterAutoIncrement) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	return a, nil
+}
+
+func (a *AlterAutoIncrement) String() string {
+	return fmt.Sprintf("AlterAutoIncrement(%s, %d)", a.table, a.next)
+}
@@ -0,0 +1,99 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// AnalyzeTable is a node that collects the statistics of tables and stores
+// them in the catalog, where the optimizer finds them.
+type AnalyzeTable struct {
+	Catalog         *sql.Catalog
+	CurrentDatabase string
+	Tables          []*UnresolvedTable
+}
+
+// NewAnalyzeTable creates a new AnalyzeTable node.
+func NewAnalyzeTable(tables ...*UnresolvedTable) *AnalyzeTable {
+	return &AnalyzeTable{Tables: tables}
+}
+
+var analyzeTableSchema = sql.Schema{
+	{Name: "Table", Type: sql.Text},
+	{Name: "Op", Type: sql.Text},
+	{Name: "Msg_type", Type: sql.Text},
+	{Name: "Msg_text", Type: sql.Text},
+}
+
+// Resolved implements the Resolvable interface.
+func (n *AnalyzeTable) Resolved() bool { return true }
+
+// Children implements the Node interface.
+func (n *AnalyzeTable) Children() []sql.Node { return nil }
+
+// Schema implements the Node interface.
+func (n *AnalyzeTable) Schema() sql.Schema { return analyzeTableSchema }
+
+// RowIter implements the Node interface. Like MySQL, it returns a row with
+// the outcome of each table, so a missing table doesn't stop the others
+// from being analyzed.
+func (n *AnalyzeTable) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.AnalyzeTable")
+	defer span.Finish()
+
+	rows := make([]sql.Row, len(n.Tables))
+	for i, t := range n.Tables {
+		db := t.Database
+		if db == "" {
+			db = n.CurrentDatabase
+		}
+		name := fmt.Sprintf("%s.%s", db, t.Name())
+
+		msgType, msgText := "status", "OK"
+		if err := n.analyze(ctx, db, t.Name()); err != nil {
+			msgType, msgText = "Error", err.Error()
+		}
+		rows[i] = sql.NewRow(name, "analyze", msgType, msgText)
+	}
+
+	return sql.RowsToRowIter(rows...), nil
+}
+
+func (n *AnalyzeTable) analyze(ctx *sql.Context, db, name string) error {
+	table, err := n.Catalog.Table(db, name)
+	if err != nil {
+		return err
+	}
+
+	stats, err := sql.CollectStatistics(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	n.Catalog.SetTableStatistics(db, table.Name(), stats)
+	return nil
+}
+
+// TransformUp implements the Transformable interface.
+func (n *AnalyzeTable) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	nn := *n
+	return f(&nn)
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (n *AnalyzeTable) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	return n, nil
+}
+
+func (n *AnalyzeTable) String() string {
+	names := make([]string, len(n.Tables))
+	for i, t := range n.Tables {
+		names[i] = t.Name()
+		if t.Database != "" {
+			names[i] = t.Database + "." + names[i]
+		}
+	}
+	return fmt.Sprintf("AnalyzeTable(%s)", strings.Join(names, ", "))
+}
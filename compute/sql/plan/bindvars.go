@@ -0,0 +1,29 @@
+package plan
+
+import (
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrUnboundVariable is returned when a parameter of a prepared statement
+// has no value bound to it.
+var ErrUnboundVariable = errors.NewKind("no value bound to parameter %s")
+
+// BindVars replaces the parameters of a prepared statement with the values
+// bound to them, by their name. The node given is not changed, so it can
+// be bound again with other values.
+func BindVars(node sql.Node, bindings map[string]sql.Expression) (sql.Node, error) {
+	return node.TransformExpressionsUp(func(e sql.Expression) (sql.Expression, error) {
+		v, ok := e.(*expression.BindVar)
+		if !ok {
+			return e, nil
+		}
+
+		bound, ok := bindings[v.Name()]
+		if !ok {
+			return nil, ErrUnboundVariable.New(v)
+		}
+		return bound, nil
+	})
+}
@@ -0,0 +1,35 @@
+package plan
+
+import (
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// Check is a CHECK constraint of the table rows are written to, with its
+// expression resolved against the schema of the table.
+type Check struct {
+	Name string
+	Expr sql.Expression
+}
+
+// evalChecks returns an error if the row makes the expression of any of the
+// checks false. Checks whose expression is NULL pass, as in standard SQL.
+func evalChecks(ctx *sql.Context, checks []Check, row sql.Row) error {
+	for _, c := range checks {
+		v, err := c.Expr.Eval(ctx, row)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			continue
+		}
+
+		ok, err := sql.Boolean.Convert(v)
+		if err != nil {
+			return err
+		}
+		if ok == false {
+			return sql.ErrCheckConstraintViolated.New(c.Name)
+		}
+	}
+	return nil
+}
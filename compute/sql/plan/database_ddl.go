@@ -8,6 +8,7 @@ import (
 type CreateDatabase struct {
 	name        string
 	ifNotExists bool
+	collation   sql.Collation
 	catalog     *sql.Catalog
 }
 
@@ -19,6 +20,14 @@ func NewCreateDatabase(name string, ifNotExists bool) *CreateDatabase {
 	}
 }
 
+// WithCollation returns the node creating the database with the given
+// default collation for the text columns of its tables.
+func (c *CreateDatabase) WithCollation(collation sql.Collation) *CreateDatabase {
+	nc := *c
+	nc.collation = collation
+	return &nc
+}
+
 // SetCatalog sets the catalog for this node
 func (c *CreateDatabase) SetCatalog(cat *sql.Catalog) {
 	c.catalog = cat
@@ -39,6 +48,10 @@ func (c *CreateDatabase) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 	if err != nil && !c.ifNotExists {
 		return nil, err
 	}
+
+	if err == nil && c.collation != "" {
+		c.catalog.SetDatabaseCollation(c.name, c.collation)
+	}
 	
 	return sql.RowsToRowIter(), nil
 }
@@ -51,7 +64,7 @@ func (c *CreateDatabase) Children() []sql.Node { return nil }
 
 // TransformUp implements the Transformable interface
 func (c *CreateDatabase) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
-	node := NewCreateDatabase(c.name, c.ifNotExists)
+	node := NewCreateDatabase(c.name, c.ifNotExists).WithCollation(c.collation)
 	node.catalog = c.catalog
 	return f(node)
 }
@@ -107,7 +120,7 @@ func (d *DropDatabase) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 	}
 
 	err := d.catalog.DropDatabase(ctx, d.name)
-	if err != nil && !d.ifExists {
+	if err != nil && !(d.ifExists && sql.ErrDatabaseNotFound.Is(err)) {
 		return nil, err
 	}
 	
@@ -1,6 +1,9 @@
 package plan
 
 import (
+	"sort"
+	"strings"
+
 	"gopkg.in/src-d/go-errors.v1"
 	"github.com/turtacn/guocedb/compute/sql"
 )
@@ -11,12 +14,30 @@ var ErrCreateTable = errors.NewKind("tables cannot be created on database %s")
 // CreateTable is a node describing the creation of some table.
 type CreateTable struct {
 	Database sql.Database
-	name     string
-	schema   sql.Schema
+	// DatabaseCollation is the default collation of the database, which the
+	// text columns get unless the table or the column has another one.
+	DatabaseCollation sql.Collation
+	name              string
+	schema            sql.Schema
+	options           sql.TableOptions
+	checks            []sql.CheckConstraint
+	indexes           []sql.IndexDef
+	collated          map[string]bool
 }
 
 // NewCreateTable creates a new CreateTable node
 func NewCreateTable(db sql.Database, name string, schema sql.Schema) *CreateTable {
+	return NewCreateTableWithOptions(db, name, schema, nil)
+}
+
+// NewCreateTableWithOptions creates a new CreateTable node for a table with
+// the given table options.
+func NewCreateTableWithOptions(
+	db sql.Database,
+	name string,
+	schema sql.Schema,
+	options sql.TableOptions,
+) *CreateTable {
 	for _, s := range schema {
 		s.Source = name
 	}
@@ -25,7 +46,78 @@ func NewCreateTable(db sql.Database, name string, schema sql.Schema) *CreateTabl
 		Database: db,
 		name:     name,
 		schema:   schema,
+		options:  options,
+	}
+}
+
+// WithChecks returns the node creating the table with the given CHECK
+// constraints.
+func (c *CreateTable) WithChecks(checks []sql.CheckConstraint) *CreateTable {
+	nc := *c
+	nc.checks = checks
+	return &nc
+}
+
+// Checks returns the CHECK constraints of the table.
+func (c *CreateTable) Checks() []sql.CheckConstraint {
+	return c.checks
+}
+
+// WithIndexes returns the node creating the table with the given secondary
+// indexes.
+func (c *CreateTable) WithIndexes(indexes []sql.IndexDef) *CreateTable {
+	nc := *c
+	nc.indexes = indexes
+	return &nc
+}
+
+// Indexes returns the secondary indexes of the table.
+func (c *CreateTable) Indexes() []sql.IndexDef {
+	return c.indexes
+}
+
+// WithCollatedColumns returns the node creating the table where the columns
+// with the given names have their own COLLATE, so they don't get the default
+// collation of the table or the database.
+func (c *CreateTable) WithCollatedColumns(names ...string) *CreateTable {
+	nc := *c
+	nc.collated = nil
+	for _, name := range names {
+		if nc.collated == nil {
+			nc.collated = make(map[string]bool, len(names))
+		}
+		nc.collated[strings.ToLower(name)] = true
+	}
+	return &nc
+}
+
+// collatedSchema returns the schema of the table with the text columns that
+// don't have their own COLLATE given the default collation of the table, or
+// the one of the database if the table has none. Tables with a collation
+// that isn't supported keep it as an option, but their columns get none.
+func (c *CreateTable) collatedSchema() sql.Schema {
+	collation := c.DatabaseCollation
+	if name, ok := c.options[sql.TableOptionCollate]; ok {
+		collation, _ = sql.ParseCollation(name)
+	}
+
+	if collation == "" || collation == sql.DefaultCollation {
+		return c.schema
+	}
+
+	schema := make(sql.Schema, len(c.schema))
+	for i, col := range c.schema {
+		nc := *col
+		if !c.collated[strings.ToLower(col.Name)] {
+			// BLOB and JSON columns are text but they have no collation.
+			if typ, err := sql.WithCollation(col.Type, collation); err == nil {
+				nc.Type = typ
+			}
+		}
+		schema[i] = &nc
 	}
+
+	return schema
 }
 
 // Resolved implements the Resolvable interface.
@@ -36,12 +128,80 @@ func (c *CreateTable) Resolved() bool {
 
 // RowIter implements the Node interface.
 func (c *CreateTable) RowIter(s *sql.Context) (sql.RowIter, error) {
+	if err := c.create(s); err != nil {
+		return nil, err
+	}
+
+	if len(c.indexes) > 0 {
+		d, ok := c.Database.(sql.IndexAlterable)
+		if !ok {
+			s.Warn(1235, "indexes are not supported by database %s and will be ignored", c.Database.Name())
+			return sql.RowsToRowIter(), nil
+		}
+
+		for _, index := range c.indexes {
+			if err := d.AddIndex(c.name, index); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return sql.RowsToRowIter(), nil
+}
+
+func (c *CreateTable) create(s *sql.Context) error {
+	schema := c.collatedSchema()
+	options := supportedTableOptions(s, c.options)
+	if len(c.checks) > 0 {
+		if d, ok := c.Database.(sql.CheckAlterable); ok {
+			return d.CreateWithChecks(c.name, schema, options, c.checks)
+		}
+		s.Warn(1235, "CHECK constraints are not supported by database %s and will be ignored", c.Database.Name())
+	}
+
+	if len(options) > 0 {
+		if d, ok := c.Database.(sql.OptionsAlterable); ok {
+			return d.CreateWithOptions(c.name, schema, options)
+		}
+	}
+
 	d, ok := c.Database.(sql.Alterable)
 	if !ok {
-		return nil, ErrCreateTable.New(c.Database.Name())
+		return ErrCreateTable.New(c.Database.Name())
+	}
+
+	return d.Create(c.name, schema)
+}
+
+// supportedTableOptions returns the options that are kept along with the
+// table. The rest are ignored with a warning, as MySQL does with the options a
+// storage engine does not support.
+func supportedTableOptions(ctx *sql.Context, options sql.TableOptions) sql.TableOptions {
+	var supported sql.TableOptions
+	for _, name := range sortedOptionNames(options) {
+		switch name {
+		case sql.TableOptionEngine, sql.TableOptionAutoIncrement, sql.TableOptionCharset,
+			sql.TableOptionCollate, sql.TableOptionRowFormat, sql.TableOptionComment,
+			sql.TableOptionCompression:
+			if supported == nil {
+				supported = make(sql.TableOptions)
+			}
+			supported[name] = options[name]
+		default:
+			ctx.Warn(1478, "table option %s is not supported and will be ignored", name)
+		}
 	}
 
-	return sql.RowsToRowIter(), d.Create(c.name, c.schema)
+	return supported
+}
+
+func sortedOptionNames(options sql.TableOptions) []string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // Schema implements the Node interface.
@@ -52,7 +212,8 @@ func (c *CreateTable) Children() []sql.Node { return nil }
 
 // TransformUp implements the Transformable interface.
 func (c *CreateTable) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
-	return f(NewCreateTable(c.Database, c.name, c.schema))
+	nc := *c
+	return f(&nc)
 }
 
 // TransformExpressionsUp implements the Transformable interface.
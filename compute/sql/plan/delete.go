@@ -0,0 +1,141 @@
+package plan
+
+import (
+	"gopkg.in/src-d/go-errors.v1"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// ErrDeleteFromNotSupported is thrown when a table doesn't support deletes.
+var ErrDeleteFromNotSupported = errors.NewKind("table doesn't support DELETE FROM")
+
+// DeleteFrom is a node describing the deletion of rows from a table. Its
+// child produces the rows to delete, which must have the schema of the table.
+type DeleteFrom struct {
+	UnaryNode
+	returning bool
+}
+
+// NewDeleteFrom creates a DeleteFrom node.
+func NewDeleteFrom(child sql.Node) *DeleteFrom {
+	return &DeleteFrom{UnaryNode: UnaryNode{Child: child}}
+}
+
+// Schema implements the Node interface.
+func (p *DeleteFrom) Schema() sql.Schema {
+	if p.returning {
+		return p.Child.Schema()
+	}
+
+	return sql.Schema{{
+		Name:     "updated",
+		Type:     sql.Int64,
+		Default:  int64(0),
+		Nullable: false,
+	}}
+}
+
+// WithReturning implements the RowReturner interface.
+func (p *DeleteFrom) WithReturning() sql.Node {
+	np := *p
+	np.returning = true
+	return &np
+}
+
+func getDeletable(node sql.Node) (sql.Deleter, error) {
+	table, ok := targetTable(node)
+	if !ok {
+		return nil, ErrDeleteFromNotSupported.New()
+	}
+	return getDeletableTable(table)
+}
+
+func getDeletableTable(t sql.Table) (sql.Deleter, error) {
+	switch t := t.(type) {
+	case sql.Deleter:
+		return t, nil
+	case sql.TableWrapper:
+		return getDeletableTable(t.Underlying())
+	default:
+		return nil, ErrDeleteFromNotSupported.New()
+	}
+}
+
+// Execute deletes the rows from the database.
+func (p *DeleteFrom) Execute(ctx *sql.Context) (int, error) {
+	n, _, err := p.execute(ctx)
+	return n, err
+}
+
+func (p *DeleteFrom) execute(ctx *sql.Context) (int, []sql.Row, error) {
+	deletable, err := getDeletable(p.Child)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Rows are read before deleting anything, so the table is not modified
+	// while it's being iterated.
+	iter, err := p.Child.RowIter(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rows, err := sql.RowIterToRows(iter)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for i, row := range rows {
+		if err := deletable.Delete(ctx, row); err != nil {
+			return i, rows[:i], err
+		}
+	}
+
+	return len(rows), rows, nil
+}
+
+// RowIter implements the Node interface.
+func (p *DeleteFrom) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	n, rows, err := p.execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.returning {
+		return sql.RowsToRowIter(rows...), nil
+	}
+
+	return sql.RowsToRowIter(sql.NewRow(int64(n))), nil
+}
+
+// TransformUp implements the Transformable interface.
+func (p *DeleteFrom) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	child, err := p.Child.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return f(p.withChild(child))
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (p *DeleteFrom) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	child, err := p.Child.TransformExpressionsUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.withChild(child), nil
+}
+
+func (p *DeleteFrom) withChild(child sql.Node) *DeleteFrom {
+	np := NewDeleteFrom(child)
+	np.returning = p.returning
+	return np
+}
+
+func (p *DeleteFrom) String() string {
+	pr := sql.NewTreePrinter()
+	_ = pr.WriteNode("Delete")
+	_ = pr.WriteChildren(p.Child.String())
+	return pr.String()
+}
@@ -0,0 +1,67 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+func TestDeleteFrom(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	table := newDMLTestTable(t)
+
+	del := NewDeleteFrom(NewFilter(
+		expression.NewLessThan(
+			expression.NewGetFieldWithTable(0, sql.Int64, "test", "id", false),
+			expression.NewLiteral(int64(3), sql.Int64),
+		),
+		NewResolvedTable(table),
+	))
+
+	iter, err := del.RowIter(ctx)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(2)}}, rows)
+
+	iter, err = NewResolvedTable(table).RowIter(ctx)
+	require.NoError(err)
+	rows, err = sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(3), "c"}}, rows)
+}
+
+func TestDeleteFromReturning(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	table := newDMLTestTable(t)
+
+	node, err := NewReturning(
+		[]sql.Expression{expression.NewGetFieldWithTable(0, sql.Int64, "test", "id", false)},
+		NewDeleteFrom(NewFilter(
+			expression.NewEquals(
+				expression.NewGetFieldWithTable(1, sql.Text, "test", "name", true),
+				expression.NewLiteral("b", sql.Text),
+			),
+			NewResolvedTable(table),
+		)),
+	)
+	require.NoError(err)
+
+	iter, err := node.RowIter(ctx)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(2)}}, rows)
+}
+
+func TestReturningNotSupported(t *testing.T) {
+	require := require.New(t)
+
+	_, err := NewReturning(nil, NewResolvedTable(newDMLTestTable(t)))
+	require.Error(err)
+	require.True(ErrReturningNotSupported.Is(err))
+}
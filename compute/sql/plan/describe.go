@@ -77,6 +77,14 @@ func (i *describeIter) Close() error {
 	return nil
 }
 
+const (
+	// DescribeFormatTree describes a query with its plan tree.
+	DescribeFormatTree = "tree"
+	// DescribeFormatTraditional describes a query with a row for each table
+	// it reads, as MySQL's EXPLAIN does.
+	DescribeFormatTraditional = "traditional"
+)
+
 // DescribeQuery returns the description of the query plan.
 type DescribeQuery struct {
 	UnaryNode
@@ -95,11 +103,18 @@ func NewDescribeQuery(format string, child sql.Node) *DescribeQuery {
 
 // Schema implements the Node interface.
 func (d *DescribeQuery) Schema() sql.Schema {
+	if d.Format == DescribeFormatTraditional {
+		return ExplainSchema
+	}
 	return DescribeSchema
 }
 
 // RowIter implements the Node interface.
 func (d *DescribeQuery) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	if d.Format == DescribeFormatTraditional {
+		return sql.RowsToRowIter(explainRows(ctx, d.Child)...), nil
+	}
+
 	var rows []sql.Row
 	for _, l := range strings.Split(d.Child.String(), "\n") {
 		if strings.TrimSpace(l) != "" {
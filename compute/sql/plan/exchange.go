@@ -91,6 +91,7 @@ type exchangeRowIter struct {
 	rows        chan sql.Row
 	err         chan error
 	quit        chan struct{}
+	closed      bool
 }
 
 func newExchangeRowIter(
@@ -179,7 +180,7 @@ func (it *exchangeRowIter) iterPartitions(ch chan<- sql.Partition) {
 		close(ch)
 
 		if err := it.partitions.Close(); err != nil {
-			it.err <- err
+			it.sendErr(err)
 		}
 	}()
 
@@ -195,7 +196,7 @@ func (it *exchangeRowIter) iterPartitions(ch chan<- sql.Partition) {
 		p, err := it.partitions.Next()
 		if err != nil {
 			if err != io.EOF {
-				it.err <- err
+				it.sendErr(err)
 			}
 			return
 		}
@@ -213,19 +214,19 @@ func (it *exchangeRowIter) iterPartition(p sql.Partition) {
 		return n, nil
 	})
 	if err != nil {
-		it.err <- err
+		it.sendErr(err)
 		return
 	}
 
 	rows, err := node.RowIter(it.ctx)
 	if err != nil {
-		it.err <- err
+		it.sendErr(err)
 		return
 	}
 
 	defer func() {
 		if err := rows.Close(); err != nil {
-			it.err <- err
+			it.sendErr(err)
 		}
 	}()
 
@@ -244,11 +245,28 @@ func (it *exchangeRowIter) iterPartition(p sql.Partition) {
 				break
 			}
 
-			it.err <- err
+			it.sendErr(err)
 			return
 		}
 
-		it.rows <- row
+		// The rows aren't read anymore once the iterator is closed, as
+		// it is after a LIMIT is reached.
+		select {
+		case it.rows <- row:
+		case <-it.ctx.Done():
+			return
+		case <-it.quit:
+			return
+		}
+	}
+}
+
+// sendErr hands an error to Next, unless the iterator is done.
+func (it *exchangeRowIter) sendErr(err error) {
+	select {
+	case it.err <- err:
+	case <-it.ctx.Done():
+	case <-it.quit:
 	}
 }
 
@@ -267,15 +285,24 @@ func (it *exchangeRowIter) Next() (sql.Row, error) {
 	case err := <-it.err:
 		_ = it.Close()
 		return nil, err
+	case <-it.ctx.Done():
+		// The partitions stop being read once the query is killed or
+		// times out, so no more rows would come.
+		_ = it.Close()
+		return nil, it.ctx.Err()
 	}
 }
 
+// Close stops reading the partitions. It may be called more than once, as
+// Next closes the iterator on errors.
 func (it *exchangeRowIter) Close() error {
-	if it.quit == nil {
-		return nil
-	}
+	it.mut.Lock()
+	defer it.mut.Unlock()
 
-	close(it.quit)
+	if !it.closed {
+		it.closed = true
+		close(it.quit)
+	}
 	return nil
 }
 
@@ -0,0 +1,148 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// ExplainSchema is the schema returned by a DescribeQuery node with the
+// traditional format, which has a row for each table the query reads.
+var ExplainSchema = sql.Schema{
+	{Name: "id", Type: sql.Int64},
+	{Name: "select_type", Type: sql.Text},
+	{Name: "table", Type: sql.Text, Nullable: true},
+	{Name: "access_type", Type: sql.Text, Nullable: true},
+	{Name: "key", Type: sql.Text, Nullable: true},
+	{Name: "rows", Type: sql.Int64, Nullable: true},
+	{Name: "extra", Type: sql.Text, Nullable: true},
+}
+
+// explainRows returns the rows of the traditional description of the plan.
+func explainRows(ctx *sql.Context, n sql.Node) []sql.Row {
+	e := &explainer{ctx: ctx, lastID: 1}
+	e.explain(n, explainScope{id: 1, selectType: "SIMPLE"})
+	if len(e.rows) == 0 {
+		return []sql.Row{{int64(1), "SIMPLE", nil, nil, nil, nil, "No tables used"}}
+	}
+
+	for _, r := range e.rows {
+		if extra := r[6].([]string); len(extra) > 0 {
+			r[6] = strings.Join(extra, "; ")
+		} else {
+			r[6] = nil
+		}
+	}
+	return e.rows
+}
+
+// explainer walks a plan to describe the tables it reads.
+type explainer struct {
+	ctx  *sql.Context
+	rows []sql.Row
+	// lastID is the id given to the last select found.
+	lastID int64
+}
+
+// explainScope is what the nodes above a table tell about the way it's read.
+type explainScope struct {
+	id         int64
+	selectType string
+	// alias is the name the table is given in the query.
+	alias string
+	// filtered is true if a filter is applied to the rows of the table.
+	filtered bool
+	// joinBuffer is true if the table is the build side of a hash join.
+	joinBuffer bool
+}
+
+func (e *explainer) explain(n sql.Node, scope explainScope) {
+	switch n := n.(type) {
+	case *ResolvedTable:
+		e.explainTable(n.Table, scope)
+	case *TableAlias:
+		scope.alias = n.Name()
+		e.explain(n.Child, scope)
+	case *SubqueryAlias:
+		e.lastID++
+		id := e.lastID
+		e.rows = append(e.rows, sql.Row{
+			scope.id, scope.selectType, fmt.Sprintf("<derived%d>", id), "ALL", nil, nil, explainExtra(scope),
+		})
+		e.explain(n.Child, explainScope{id: id, selectType: "DERIVED"})
+	case *Filter:
+		scope.filtered = true
+		e.explain(n.Child, scope)
+	case *HashJoin:
+		e.explain(n.Left, scope)
+		scope.joinBuffer = true
+		e.explain(n.Right, scope)
+	case *Sort:
+		start := len(e.rows)
+		e.explain(n.Child, scope)
+		if start < len(e.rows) {
+			e.rows[start][6] = append(e.rows[start][6].([]string), "Using filesort")
+		}
+	default:
+		for _, c := range n.Children() {
+			e.explain(c, scope)
+		}
+	}
+}
+
+func (e *explainer) explainTable(t sql.Table, scope explainScope) {
+	// Queries without FROM read the dual table, which MySQL doesn't show.
+	if strings.EqualFold(t.Name(), "dual") {
+		return
+	}
+
+	name := t.Name()
+	if scope.alias != "" {
+		name = scope.alias
+	}
+
+	accessType, key := "ALL", interface{}(nil)
+	var rows interface{}
+	described := false
+	for {
+		if a, ok := t.(sql.AccessDescriber); ok && !described {
+			var index string
+			if accessType, index = a.Access(); index != "" {
+				key = index
+			}
+			described = true
+		}
+		if ft, ok := t.(sql.FilteredTable); ok && len(ft.Filters()) > 0 {
+			scope.filtered = true
+		}
+		if c, ok := t.(sql.RowCounter); ok && rows == nil {
+			if n, err := c.RowCount(e.ctx); err == nil {
+				rows = int64(n)
+			}
+		}
+
+		w, ok := t.(sql.TableWrapper)
+		if !ok {
+			break
+		}
+		t = w.Underlying()
+	}
+
+	e.rows = append(e.rows, sql.Row{
+		scope.id, scope.selectType, name, accessType, key, rows, explainExtra(scope),
+	})
+}
+
+// explainExtra returns the notes of the extra column for a table read in
+// the scope. They are joined once the plan has been walked.
+func explainExtra(scope explainScope) []string {
+	var extra []string
+	if scope.filtered {
+		extra = append(extra, "Using where")
+	}
+	if scope.joinBuffer {
+		extra = append(extra, "Using join buffer (hash join)")
+	}
+	return extra
+}
@@ -20,6 +20,10 @@ type GroupBy struct {
 	UnaryNode
 	Aggregate []sql.Expression
 	Grouping  []sql.Expression
+	// Rollup is true if the node also returns the super-aggregate rows of
+	// WITH ROLLUP, which aggregate the groups of each prefix of the grouping
+	// with NULL in the rest of the grouping columns.
+	Rollup bool
 }
 
 // NewGroupBy creates a new GroupBy node.
@@ -62,7 +66,7 @@ func (p *GroupBy) Schema() sql.Schema {
 		s[i] = &sql.Column{
 			Name:     name,
 			Type:     e.Type(),
-			Nullable: e.IsNullable(),
+			Nullable: e.IsNullable() || (p.Rollup && p.rolledUp(e) >= 0),
 			Source:   table,
 		}
 	}
@@ -84,9 +88,12 @@ func (p *GroupBy) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 	}
 
 	var iter sql.RowIter
-	if len(p.Grouping) == 0 {
+	switch {
+	case len(p.Grouping) == 0:
 		iter = newGroupByIter(ctx, p.Aggregate, i)
-	} else {
+	case p.Rollup:
+		iter = newGroupByRollupIter(ctx, p, i)
+	default:
 		iter = newGroupByGroupingIter(ctx, p.Aggregate, p.Grouping, i)
 	}
 
@@ -99,7 +106,26 @@ func (p *GroupBy) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	return f(NewGroupBy(p.Aggregate, p.Grouping, child))
+	return f(p.with(p.Aggregate, p.Grouping, child))
+}
+
+// with returns a GroupBy like this one with the given expressions and child.
+func (p *GroupBy) with(aggregate, grouping []sql.Expression, child sql.Node) *GroupBy {
+	g := NewGroupBy(aggregate, grouping, child)
+	g.Rollup = p.Rollup
+	return g
+}
+
+// WithChild returns a GroupBy like this one reading the rows of the given
+// child.
+func (p *GroupBy) WithChild(child sql.Node) *GroupBy {
+	return p.with(p.Aggregate, p.Grouping, child)
+}
+
+// WithAggregate returns a GroupBy like this one with the given aggregate
+// expressions and child.
+func (p *GroupBy) WithAggregate(aggregate []sql.Expression, child sql.Node) *GroupBy {
+	return p.with(aggregate, p.Grouping, child)
 }
 
 // TransformExpressionsUp implements the Transformable interface.
@@ -119,12 +145,16 @@ func (p *GroupBy) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, err
 		return nil, err
 	}
 
-	return NewGroupBy(aggregate, grouping, child), nil
+	return p.with(aggregate, grouping, child), nil
 }
 
 func (p *GroupBy) String() string {
 	pr := sql.NewTreePrinter()
-	_ = pr.WriteNode("GroupBy")
+	if p.Rollup {
+		_ = pr.WriteNode("GroupBy(WITH ROLLUP)")
+	} else {
+		_ = pr.WriteNode("GroupBy")
+	}
 
 	var aggregate = make([]string, len(p.Aggregate))
 	for i, agg := range p.Aggregate {
@@ -164,7 +194,7 @@ func (p *GroupBy) TransformExpressions(f sql.TransformExprFunc) (sql.Node, error
 		return nil, err
 	}
 
-	return NewGroupBy(agg, group, p.Child), nil
+	return p.with(agg, group, p.Child), nil
 }
 
 type groupByIter struct {
@@ -402,3 +432,145 @@ func evalBuffer(
 		return nil, ErrGroupBy.New(n.String())
 	}
 }
+
+// rolledUp returns the position in the grouping of the expression of the
+// aggregate, or -1 if it isn't one of them. The expression is NULL in the
+// super-aggregate rows of the groupings without it.
+func (p *GroupBy) rolledUp(e sql.Expression) int {
+	if a, ok := e.(*expression.Alias); ok {
+		e = a.Child
+	}
+	for i, g := range p.Grouping {
+		if g.String() == e.String() {
+			return i
+		}
+	}
+	return -1
+}
+
+// rollupGroup is the aggregation of the rows of a group of WITH ROLLUP,
+// which has the groups of the next level with its values.
+type rollupGroup struct {
+	buffers []sql.Row
+	// level is the number of grouping expressions of the group.
+	level    int
+	children []*rollupGroup
+}
+
+// groupByRollupIter returns the groups of all the prefixes of the grouping.
+// Each group is followed by its super-aggregate ones, and the grand total is
+// the last row, as in MySQL.
+type groupByRollupIter struct {
+	node  *GroupBy
+	child sql.RowIter
+	ctx   *sql.Context
+	// rolledUp has the position in the grouping of each aggregate
+	// expression, or -1.
+	rolledUp []int
+	groups   []*rollupGroup
+	pos      int
+	computed bool
+}
+
+func newGroupByRollupIter(ctx *sql.Context, node *GroupBy, child sql.RowIter) *groupByRollupIter {
+	rolledUp := make([]int, len(node.Aggregate))
+	for i, e := range node.Aggregate {
+		rolledUp[i] = node.rolledUp(e)
+	}
+
+	return &groupByRollupIter{node: node, child: child, ctx: ctx, rolledUp: rolledUp}
+}
+
+func (i *groupByRollupIter) Next() (sql.Row, error) {
+	if !i.computed {
+		i.computed = true
+		root, err := i.compute()
+		if err != nil {
+			return nil, err
+		}
+		// There are no groups to total without rows.
+		if len(root.children) > 0 {
+			i.groups = appendRollupGroups(i.groups, root)
+		}
+	}
+
+	if i.pos >= len(i.groups) {
+		return nil, io.EOF
+	}
+
+	g := i.groups[i.pos]
+	i.pos++
+	row, err := evalBuffers(i.ctx, g.buffers, i.node.Aggregate)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, pos := range i.rolledUp {
+		if pos >= g.level {
+			row[j] = nil
+		}
+	}
+	return row, nil
+}
+
+func (i *groupByRollupIter) compute() (*rollupGroup, error) {
+	aggregate, grouping := i.node.Aggregate, i.node.Grouping
+	newGroup := func(level int) *rollupGroup {
+		buf := make([]sql.Row, len(aggregate))
+		for j, a := range aggregate {
+			buf[j] = fillBuffer(a)
+		}
+		return &rollupGroup{buffers: buf, level: level}
+	}
+
+	root := newGroup(0)
+	groups := make(map[uint64]*rollupGroup)
+	for {
+		row, err := i.child.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := updateBuffers(i.ctx, root.buffers, aggregate, row); err != nil {
+			return nil, err
+		}
+
+		parent := root
+		for level := 1; level <= len(grouping); level++ {
+			key, err := groupingKey(i.ctx, grouping[:level], row)
+			if err != nil {
+				return nil, err
+			}
+
+			g, ok := groups[key]
+			if !ok {
+				g = newGroup(level)
+				groups[key] = g
+				parent.children = append(parent.children, g)
+			}
+
+			if err := updateBuffers(i.ctx, g.buffers, aggregate, row); err != nil {
+				return nil, err
+			}
+			parent = g
+		}
+	}
+
+	return root, nil
+}
+
+// appendRollupGroups appends the groups of each group before itself.
+func appendRollupGroups(groups []*rollupGroup, g *rollupGroup) []*rollupGroup {
+	for _, c := range g.children {
+		groups = appendRollupGroups(groups, c)
+	}
+	return append(groups, g)
+}
+
+func (i *groupByRollupIter) Close() error {
+	i.groups = nil
+	return i.child.Close()
+}
@@ -0,0 +1,616 @@
+package plan
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	errors "gopkg.in/src-d/go-errors.v1"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+// DefaultJoinMemoryBudget is the number of bytes the hash table of a hash
+// join may take before the join spills its inputs to disk.
+const DefaultJoinMemoryBudget int64 = 64 << 20
+
+const (
+	// joinPartitions is the number of partitions each side of a hash join is
+	// split into every time the build side doesn't fit in memory.
+	joinPartitions = 16
+	// maxSpillDepth is the number of times a partition can be split again.
+	// Past that depth the partition is joined in memory no matter its size,
+	// because all its rows probably have the same key.
+	maxSpillDepth = 4
+)
+
+// ErrNotEquiJoin is returned when a hash join is created with a condition
+// that does not compare columns of both sides for equality.
+var ErrNotEquiJoin = errors.NewKind("join condition %s is not an equality between both sides")
+
+// HashJoin is an inner join that builds a hash table with the rows of the
+// right side keyed by the columns the condition compares for equality, and
+// probes it with the rows of the left side.
+//
+// When the hash table doesn't fit in the memory budget, both sides are
+// partitioned to disk by the hash of their keys and every pair of
+// partitions is joined separately, so the memory used by the join stays
+// bounded no matter the size of its inputs (grace hash join).
+type HashJoin struct {
+	BinaryNode
+	Cond sql.Expression
+	// MemoryBudget is the number of bytes the hash table can take.
+	MemoryBudget int64
+	// TempDir is the directory where the partitions are written. The default
+	// directory for temporary files is used if it's empty.
+	TempDir string
+
+	leftKeys  []sql.Expression
+	rightKeys []sql.Expression
+}
+
+// NewHashJoin creates a new hash join node between two nodes. The condition
+// must have at least an equality between expressions of both sides.
+func NewHashJoin(
+	left, right sql.Node,
+	cond sql.Expression,
+	memoryBudget int64,
+	tempDir string,
+) (*HashJoin, error) {
+	leftKeys, rightKeys := equiJoinKeys(cond, len(left.Schema()))
+	if len(leftKeys) == 0 {
+		return nil, ErrNotEquiJoin.New(cond)
+	}
+
+	return &HashJoin{
+		BinaryNode:   BinaryNode{Left: left, Right: right},
+		Cond:         cond,
+		MemoryBudget: memoryBudget,
+		TempDir:      tempDir,
+		leftKeys:     leftKeys,
+		rightKeys:    rightKeys,
+	}, nil
+}
+
+// IsEquiJoin returns whether the given join condition can be used by a hash
+// join between a left side with the given number of columns and any right
+// side.
+func IsEquiJoin(cond sql.Expression, leftColumns int) bool {
+	keys, _ := equiJoinKeys(cond, leftColumns)
+	return len(keys) > 0
+}
+
+// equiJoinKeys returns the pairs of expressions the condition compares for
+// equality, the first one evaluated on the left rows and the second one on
+// the right rows. Only pairs of the same type are returned, so equal keys
+// are also equal values once converted to that type.
+func equiJoinKeys(cond sql.Expression, leftColumns int) (left, right []sql.Expression) {
+	for _, e := range splitConjunction(cond) {
+		eq, ok := e.(*expression.Equals)
+		if !ok || eq.Left().Type() != eq.Right().Type() {
+			continue
+		}
+
+		l, r := eq.Left(), eq.Right()
+		ls, rs := sideOf(l, leftColumns), sideOf(r, leftColumns)
+		if ls == rightJoinSide && rs == leftJoinSide {
+			l, r = r, l
+		} else if ls != leftJoinSide || rs != rightJoinSide {
+			continue
+		}
+
+		r, err := r.TransformUp(func(e sql.Expression) (sql.Expression, error) {
+			if gf, ok := e.(*expression.GetField); ok {
+				return gf.WithIndex(gf.Index() - leftColumns), nil
+			}
+			return e, nil
+		})
+		if err != nil {
+			continue
+		}
+
+		left = append(left, l)
+		right = append(right, r)
+	}
+
+	return left, right
+}
+
+func splitConjunction(e sql.Expression) []sql.Expression {
+	and, ok := e.(*expression.And)
+	if !ok {
+		return []sql.Expression{e}
+	}
+
+	return append(splitConjunction(and.Left), splitConjunction(and.Right)...)
+}
+
+type joinSide byte
+
+const (
+	noJoinSide joinSide = iota
+	leftJoinSide
+	rightJoinSide
+	bothJoinSides
+)
+
+// sideOf returns the side of the join the columns used by the expression
+// belong to.
+func sideOf(e sql.Expression, leftColumns int) joinSide {
+	side := noJoinSide
+	expression.Inspect(e, func(e sql.Expression) bool {
+		gf, ok := e.(*expression.GetField)
+		if !ok {
+			return true
+		}
+
+		s := leftJoinSide
+		if gf.Index() >= leftColumns {
+			s = rightJoinSide
+		}
+
+		if side == noJoinSide {
+			side = s
+		} else if side != s {
+			side = bothJoinSides
+		}
+		return true
+	})
+	return side
+}
+
+// Schema implements the Node interface.
+func (j *HashJoin) Schema() sql.Schema {
+	return append(j.Left.Schema(), j.Right.Schema()...)
+}
+
+// Resolved implements the Resolvable interface.
+func (j *HashJoin) Resolved() bool {
+	return j.Left.Resolved() && j.Right.Resolved() && j.Cond.Resolved()
+}
+
+// RowIter implements the Node interface.
+func (j *HashJoin) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	var left, right string
+	if leftTable, ok := j.Left.(sql.Nameable); ok {
+		left = leftTable.Name()
+	} else {
+		left = reflect.TypeOf(j.Left).String()
+	}
+
+	if rightTable, ok := j.Right.(sql.Nameable); ok {
+		right = rightTable.Name()
+	} else {
+		right = reflect.TypeOf(j.Right).String()
+	}
+
+	span, ctx := ctx.Span("plan.HashJoin", opentracing.Tags{
+		"left":  left,
+		"right": right,
+	})
+
+	l, err := j.Left.RowIter(ctx)
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	r, err := j.Right.RowIter(ctx)
+	if err != nil {
+		span.Finish()
+		_ = l.Close()
+		return nil, err
+	}
+
+	return sql.NewSpanIter(span, &hashJoinIter{
+		ctx:   ctx,
+		join:  j,
+		tasks: []joinTask{{build: r, probe: l}},
+	}), nil
+}
+
+// TransformUp implements the Transformable interface.
+func (j *HashJoin) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	left, err := j.Left.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := j.Right.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	nj := *j
+	nj.BinaryNode = BinaryNode{Left: left, Right: right}
+	return f(&nj)
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (j *HashJoin) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	left, err := j.Left.TransformExpressionsUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := j.Right.TransformExpressionsUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	cond, err := j.Cond.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHashJoin(left, right, cond, j.MemoryBudget, j.TempDir)
+}
+
+func (j *HashJoin) String() string {
+	pr := sql.NewTreePrinter()
+	_ = pr.WriteNode("HashJoin(%s)", j.Cond)
+	_ = pr.WriteChildren(j.Left.String(), j.Right.String())
+	return pr.String()
+}
+
+// Expressions implements the Expressioner interface.
+func (j *HashJoin) Expressions() []sql.Expression {
+	return []sql.Expression{j.Cond}
+}
+
+// TransformExpressions implements the Expressioner interface.
+func (j *HashJoin) TransformExpressions(f sql.TransformExprFunc) (sql.Node, error) {
+	cond, err := j.Cond.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHashJoin(j.Left, j.Right, cond, j.MemoryBudget, j.TempDir)
+}
+
+// joinTask is a pair of inputs to join. The build rows are put in the hash
+// table, which is probed with the probe rows.
+type joinTask struct {
+	build sql.RowIter
+	probe sql.RowIter
+	depth int
+}
+
+type hashJoinIter struct {
+	ctx  *sql.Context
+	join *HashJoin
+
+	// tasks are the inputs that are left to join, the first of them being
+	// the ones of the whole join until they are partitioned.
+	tasks []joinTask
+	table map[uint64][]sql.Row
+	probe sql.RowIter
+
+	leftRow sql.Row
+	matches []sql.Row
+
+	dir    string
+	spills int
+}
+
+func (i *hashJoinIter) Next() (sql.Row, error) {
+	for {
+		if len(i.matches) > 0 {
+			right := i.matches[0]
+			i.matches = i.matches[1:]
+
+			row := make(sql.Row, 0, len(i.leftRow)+len(right))
+			row = append(row, i.leftRow...)
+			row = append(row, right...)
+
+			ok, err := i.join.Cond.Eval(i.ctx, row)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok == true {
+				return row, nil
+			}
+			continue
+		}
+
+		if i.probe != nil {
+			row, err := i.probe.Next()
+			if err == io.EOF {
+				err = i.probe.Close()
+				i.probe = nil
+				i.table = nil
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			h, ok, err := hashJoinKeys(i.ctx, i.join.leftKeys, row)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				i.leftRow = row
+				i.matches = i.table[h]
+			}
+			continue
+		}
+
+		if len(i.tasks) == 0 {
+			return nil, io.EOF
+		}
+
+		task := i.tasks[0]
+		i.tasks = i.tasks[1:]
+		if err := i.start(task); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// start builds the hash table with the build side of the task and starts
+// probing it. If the build side doesn't fit in memory, both sides are
+// partitioned instead and the partitions are added to the pending tasks.
+func (i *hashJoinIter) start(task joinTask) error {
+	fits, err := i.build(task)
+	if err != nil {
+		_ = task.build.Close()
+		_ = task.probe.Close()
+		return err
+	}
+
+	if fits {
+		if err := task.build.Close(); err != nil {
+			_ = task.probe.Close()
+			return err
+		}
+
+		i.probe = task.probe
+		return nil
+	}
+
+	var buffered []sql.Row
+	for _, rows := range i.table {
+		buffered = append(buffered, rows...)
+	}
+	i.table = nil
+
+	tasks, err := i.partition(task, buffered)
+	if err != nil {
+		return err
+	}
+
+	i.tasks = append(tasks, i.tasks...)
+	return nil
+}
+
+// build reads the build side of the task into the hash table and returns
+// whether it fits in the memory budget. If it does not, the rest of the
+// build side is left unread.
+func (i *hashJoinIter) build(task joinTask) (bool, error) {
+	i.table = make(map[uint64][]sql.Row)
+
+	var size int64
+	for {
+		row, err := task.build.Next()
+		if err == io.EOF {
+			return true, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		h, ok, err := hashJoinKeys(i.ctx, i.join.rightKeys, row)
+		if err != nil {
+			return false, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		i.table[h] = append(i.table[h], row)
+		size += estimateRowSize(row)
+		if size > i.join.MemoryBudget && task.depth < maxSpillDepth {
+			return false, nil
+		}
+	}
+}
+
+// partition writes both sides of the task to disk, split by the hash of
+// their keys, and returns a task for each pair of partitions that may have
+// rows in common.
+func (i *hashJoinIter) partition(task joinTask, buffered []sql.Row) ([]joinTask, error) {
+	defer task.build.Close()
+	defer task.probe.Close()
+
+	if i.dir == "" {
+		dir, err := os.MkdirTemp(i.join.TempDir, "guocedb-hashjoin-")
+		if err != nil {
+			return nil, err
+		}
+		i.dir = dir
+	}
+
+	i.spills++
+	build, err := newSpillPartitions(i.dir, fmt.Sprintf("%d-build", i.spills))
+	if err != nil {
+		return nil, err
+	}
+
+	probe, err := newSpillPartitions(i.dir, fmt.Sprintf("%d-probe", i.spills))
+	if err != nil {
+		build.removeAll()
+		return nil, err
+	}
+
+	err = i.spill(build, sql.RowsToRowIter(buffered...), i.join.rightKeys, task.depth)
+	if err == nil {
+		err = i.spill(build, task.build, i.join.rightKeys, task.depth)
+	}
+	if err == nil {
+		err = i.spill(probe, task.probe, i.join.leftKeys, task.depth)
+	}
+	if err == nil {
+		err = build.close()
+	}
+	if err == nil {
+		err = probe.close()
+	}
+	if err != nil {
+		build.removeAll()
+		probe.removeAll()
+		return nil, err
+	}
+
+	var tasks []joinTask
+	for p := 0; p < joinPartitions; p++ {
+		if build.rows[p] == 0 || probe.rows[p] == 0 {
+			build.remove(p)
+			probe.remove(p)
+			continue
+		}
+
+		b, err := build.open(p)
+		if err == nil {
+			var pr sql.RowIter
+			if pr, err = probe.open(p); err == nil {
+				tasks = append(tasks, joinTask{build: b, probe: pr, depth: task.depth + 1})
+				continue
+			}
+			_ = b.Close()
+		}
+
+		// The partitions that are already open are removed once closed.
+		closeJoinTasks(tasks)
+		for ; p < joinPartitions; p++ {
+			build.remove(p)
+			probe.remove(p)
+		}
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (i *hashJoinIter) spill(
+	partitions *spillPartitions,
+	rows sql.RowIter,
+	keys []sql.Expression,
+	depth int,
+) error {
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		h, ok, err := hashJoinKeys(i.ctx, keys, row)
+		if err != nil {
+			return err
+		}
+
+		// Rows with a NULL key can't match any other row.
+		if !ok {
+			continue
+		}
+
+		if err := partitions.write(partitionOf(h, depth), row); err != nil {
+			return err
+		}
+	}
+}
+
+func (i *hashJoinIter) Close() error {
+	var err error
+	if i.probe != nil {
+		err = i.probe.Close()
+	}
+
+	closeJoinTasks(i.tasks)
+	i.tasks = nil
+	i.table = nil
+
+	if i.dir != "" {
+		if e := os.RemoveAll(i.dir); err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+func closeJoinTasks(tasks []joinTask) {
+	for _, t := range tasks {
+		_ = t.build.Close()
+		_ = t.probe.Close()
+	}
+}
+
+// partitionOf returns the partition of a key with the given hash. Each
+// depth uses different bits of the hash, so the rows of a partition are
+// spread again when it's partitioned once more.
+func partitionOf(h uint64, depth int) int {
+	return int((h >> (uint(depth) * 4)) % joinPartitions)
+}
+
+// hashJoinKeys returns the hash of the given keys evaluated on the row, and
+// false if any of them is NULL.
+func hashJoinKeys(ctx *sql.Context, keys []sql.Expression, row sql.Row) (uint64, bool, error) {
+	values := make(sql.Row, len(keys))
+	for i, k := range keys {
+		v, err := k.Eval(ctx, row)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if v == nil {
+			return 0, false, nil
+		}
+
+		v, err = k.Type().Convert(v)
+		if err != nil {
+			return 0, false, err
+		}
+		values[i] = v
+	}
+
+	h, err := hashRow(values)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return h, true, nil
+}
+
+// estimateRowSize returns an approximation of the bytes a row takes in
+// memory.
+func estimateRowSize(row sql.Row) int64 {
+	// slice header and the interface of every value
+	size := int64(24 + 16*len(row))
+	for _, v := range row {
+		switch v := v.(type) {
+		case string:
+			size += int64(len(v))
+		case []byte:
+			size += int64(len(v)) + 24
+		case nil:
+		default:
+			size += 8
+		}
+	}
+	return size
+}
@@ -0,0 +1,214 @@
+package plan
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+func TestHashJoin(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	// The join columns are nullable, as rows with a NULL key never match.
+	lschema := append(sql.Schema{{Name: "lcol1", Type: sql.Text, Nullable: true}}, lSchema[1:]...)
+	rschema := append(sql.Schema{{Name: "rcol1", Type: sql.Text, Nullable: true}}, rSchema[1:]...)
+
+	ltable := mem.NewTable("left", lschema)
+	rtable := mem.NewTable("right", rschema)
+	insertData(t, ltable)
+	insertData(t, rtable)
+	require.NoError(ltable.Insert(ctx, sql.NewRow(nil, "col2_3", int32(5555), int64(6666))))
+	require.NoError(rtable.Insert(ctx, sql.NewRow(nil, "col2_3", int32(5555), int64(6666))))
+	require.NoError(rtable.Insert(ctx, sql.NewRow("col1_1", "col2_4", int32(1111), int64(0))))
+
+	cond := expression.NewAnd(
+		expression.NewEquals(
+			expression.NewGetField(4, sql.Text, "rcol1", true),
+			expression.NewGetField(0, sql.Text, "lcol1", true),
+		),
+		expression.NewGreaterThan(
+			expression.NewGetField(3, sql.Int64, "lcol4", false),
+			expression.NewGetField(7, sql.Int64, "rcol4", false),
+		),
+	)
+
+	dir := t.TempDir()
+	j, err := NewHashJoin(NewResolvedTable(ltable), NewResolvedTable(rtable), cond, DefaultJoinMemoryBudget, dir)
+	require.NoError(err)
+	require.Equal(append(lschema, rschema...), j.Schema())
+
+	iter, err := j.RowIter(ctx)
+	require.NoError(err)
+
+	row, err := iter.Next()
+	require.NoError(err)
+	require.Equal(sql.Row{"col1_1", "col2_1", int32(1111), int64(2222), "col1_1", "col2_4", int32(1111), int64(0)}, row)
+
+	// Small inputs are joined in memory.
+	entries, err := os.ReadDir(dir)
+	require.NoError(err)
+	require.Empty(entries)
+
+	_, err = iter.Next()
+	require.Equal(io.EOF, err)
+	require.NoError(iter.Close())
+}
+
+func TestNewHashJoinNotEquiJoin(t *testing.T) {
+	left := NewResolvedTable(mem.NewTable("left", lSchema))
+	right := NewResolvedTable(mem.NewTable("right", rSchema))
+
+	for _, cond := range []sql.Expression{
+		expression.NewGreaterThan(
+			expression.NewGetField(0, sql.Text, "lcol1", false),
+			expression.NewGetField(4, sql.Text, "rcol1", false),
+		),
+		expression.NewEquals(
+			expression.NewGetField(0, sql.Text, "lcol1", false),
+			expression.NewGetField(1, sql.Text, "lcol2", false),
+		),
+		expression.NewEquals(
+			expression.NewGetField(2, sql.Int32, "lcol3", false),
+			expression.NewGetField(7, sql.Int64, "rcol4", false),
+		),
+	} {
+		require.False(t, IsEquiJoin(cond, len(lSchema)), cond.String())
+		_, err := NewHashJoin(left, right, cond, DefaultJoinMemoryBudget, "")
+		require.True(t, ErrNotEquiJoin.Is(err), cond.String())
+	}
+}
+
+func TestHashJoinSpill(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping hash join spill test in short mode")
+	}
+
+	const rows = 20000
+	run := func(budget int64) (peak uint64, spilled bool) {
+		require := require.New(t)
+		ctx := sql.NewEmptyContext()
+		dir := t.TempDir()
+
+		j, err := NewHashJoin(
+			&generatedRows{name: "left", n: rows},
+			&generatedRows{name: "right", n: rows},
+			expression.NewEquals(
+				expression.NewGetField(0, sql.Int64, "id", false),
+				expression.NewGetField(2, sql.Int64, "id", false),
+			),
+			budget,
+			dir,
+		)
+		require.NoError(err)
+
+		base := liveHeap()
+		iter, err := j.RowIter(ctx)
+		require.NoError(err)
+
+		seen := make([]bool, rows)
+		for n := 0; ; n++ {
+			row, err := iter.Next()
+			if err == io.EOF {
+				require.Equal(rows, n)
+				break
+			}
+			require.NoError(err)
+
+			if n == 0 {
+				entries, err := os.ReadDir(dir)
+				require.NoError(err)
+				spilled = len(entries) > 0
+			}
+
+			id := row[0].(int64)
+			require.Equal(id, row[2])
+			require.Equal(row[1], row[3])
+			require.False(seen[id])
+			seen[id] = true
+
+			if n%2000 == 0 {
+				if h := liveHeap(); h > base && h-base > peak {
+					peak = h - base
+				}
+			}
+		}
+
+		require.NoError(iter.Close())
+		entries, err := os.ReadDir(dir)
+		require.NoError(err)
+		require.Empty(entries)
+		return peak, spilled
+	}
+
+	peak, spilled := run(256 << 10)
+	require.True(t, spilled)
+	require.True(t, peak < 4<<20, "grace hash join used %d bytes", peak)
+
+	peak, spilled = run(DefaultJoinMemoryBudget)
+	require.False(t, spilled)
+	require.True(t, peak > 6<<20, "in-memory hash join used %d bytes", peak)
+}
+
+func liveHeap() uint64 {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// generatedRows is a node with n rows of an id and a payload that are
+// generated while they are read, so its rows only take memory if they are
+// kept by its parent.
+type generatedRows struct {
+	name string
+	n    int
+}
+
+func (g *generatedRows) Schema() sql.Schema {
+	return sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: g.name},
+		{Name: "payload", Type: sql.Text, Source: g.name},
+	}
+}
+
+func (g *generatedRows) Resolved() bool       { return true }
+func (g *generatedRows) Children() []sql.Node { return nil }
+func (g *generatedRows) String() string       { return g.name }
+
+func (g *generatedRows) RowIter(*sql.Context) (sql.RowIter, error) {
+	return &generatedRowsIter{n: g.n}, nil
+}
+
+func (g *generatedRows) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	return f(g)
+}
+
+func (g *generatedRows) TransformExpressionsUp(sql.TransformExprFunc) (sql.Node, error) {
+	return g, nil
+}
+
+type generatedRowsIter struct {
+	n, i int
+}
+
+func (i *generatedRowsIter) Next() (sql.Row, error) {
+	if i.i >= i.n {
+		return nil, io.EOF
+	}
+
+	// Rows are returned in a different order than their ids, so the join
+	// can't rely on both sides being sorted.
+	id := (i.i * 7919) % i.n
+	i.i++
+	return sql.NewRow(int64(id), fmt.Sprintf("%0400d", id)), nil
+}
+
+func (i *generatedRowsIter) Close() error { return nil }
@@ -0,0 +1,110 @@
+package plan
+
+import (
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// Having skips the groups of its child that don't match a condition. Unlike
+// Filter, the condition is on the grouped rows, so it can use the aliases
+// and aggregations of the query.
+type Having struct {
+	UnaryNode
+	Cond sql.Expression
+	// Hidden is the number of the last columns of the child that are only
+	// there to compute the condition, which aren't returned.
+	Hidden int
+}
+
+// NewHaving creates a new Having node.
+func NewHaving(cond sql.Expression, hidden int, child sql.Node) *Having {
+	return &Having{
+		UnaryNode: UnaryNode{Child: child},
+		Cond:      cond,
+		Hidden:    hidden,
+	}
+}
+
+// Resolved implements the Resolvable interface.
+func (h *Having) Resolved() bool {
+	return h.Child.Resolved() && h.Cond.Resolved()
+}
+
+// Schema implements the Node interface.
+func (h *Having) Schema() sql.Schema {
+	schema := h.Child.Schema()
+	return schema[:len(schema)-h.Hidden]
+}
+
+// RowIter implements the Node interface.
+func (h *Having) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.Having")
+
+	i, err := h.Child.RowIter(ctx)
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	return sql.NewSpanIter(span, &havingIter{NewFilterIter(ctx, h.Cond, i), h.Hidden}), nil
+}
+
+// TransformUp implements the Transformable interface.
+func (h *Having) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	child, err := h.Child.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+	return f(NewHaving(h.Cond, h.Hidden, child))
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (h *Having) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	cond, err := h.Cond.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	child, err := h.Child.TransformExpressionsUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHaving(cond, h.Hidden, child), nil
+}
+
+func (h *Having) String() string {
+	pr := sql.NewTreePrinter()
+	_ = pr.WriteNode("Having(%s)", h.Cond)
+	_ = pr.WriteChildren(h.Child.String())
+	return pr.String()
+}
+
+// Expressions implements the Expressioner interface.
+func (h *Having) Expressions() []sql.Expression {
+	return []sql.Expression{h.Cond}
+}
+
+// TransformExpressions implements the Expressioner interface.
+func (h *Having) TransformExpressions(f sql.TransformExprFunc) (sql.Node, error) {
+	cond, err := h.Cond.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHaving(cond, h.Hidden, h.Child), nil
+}
+
+// havingIter returns the rows that match the condition without the hidden
+// columns.
+type havingIter struct {
+	*FilterIter
+	hidden int
+}
+
+func (i *havingIter) Next() (sql.Row, error) {
+	row, err := i.FilterIter.Next()
+	if err != nil {
+		return nil, err
+	}
+	return row[:len(row)-i.hidden], nil
+}
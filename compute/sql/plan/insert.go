@@ -1,21 +1,46 @@
 package plan
 
 import (
+	"fmt"
 	"io"
+	"math"
 	"strings"
+	"unicode/utf8"
 
-	"gopkg.in/src-d/go-errors.v1"
 	"github.com/turtacn/guocedb/compute/sql"
 	"github.com/turtacn/guocedb/compute/sql/expression"
+	"gopkg.in/src-d/go-errors.v1"
 )
 
 // ErrInsertIntoNotSupported is thrown when a table doesn't support inserts
 var ErrInsertIntoNotSupported = errors.NewKind("table doesn't support INSERT INTO")
 
+// ErrOnDuplicateNotSupported is thrown when the rows of a table that
+// conflict with the inserted ones can't be updated.
+var ErrOnDuplicateNotSupported = errors.NewKind("table doesn't support ON DUPLICATE KEY UPDATE")
+
+// ErrInsertIntoMismatchValueCount is thrown when the number of values of a
+// row doesn't match the number of columns it's inserted into.
+var ErrInsertIntoMismatchValueCount = errors.NewKind("column count doesn't match value count at row %d")
+
+// ErrOutOfRangeValue is returned when a number stored in a column doesn't
+// fit in its type.
+var ErrOutOfRangeValue = errors.NewKind("Out of range value for column '%s' at row %d")
+
 // InsertInto is a node describing the insertion into some table.
 type InsertInto struct {
 	BinaryNode
 	Columns []string
+	// OnDupColumns are the columns assigned by ON DUPLICATE KEY UPDATE in
+	// the rows the inserted ones conflict with.
+	OnDupColumns []sql.Expression
+	// OnDupValues are the values assigned to each of the OnDupColumns. They
+	// are evaluated against the conflicting row followed by the row that
+	// was being inserted, which VALUES(col) refers to.
+	OnDupValues []sql.Expression
+	// Checks are the CHECK constraints the inserted rows must pass.
+	Checks    []Check
+	returning bool
 }
 
 // NewInsertInto creates an InsertInto node.
@@ -26,8 +51,28 @@ func NewInsertInto(dst, src sql.Node, cols []string) *InsertInto {
 	}
 }
 
+// WithOnDuplicate returns a copy of the node that updates the rows the
+// inserted ones conflict with, assigning the values to the columns.
+func (p *InsertInto) WithOnDuplicate(columns, values []sql.Expression) *InsertInto {
+	np := *p
+	np.OnDupColumns = columns
+	np.OnDupValues = values
+	return &np
+}
+
+// Resolved implements the Resolvable interface.
+func (p *InsertInto) Resolved() bool {
+	return p.BinaryNode.Resolved() &&
+		expressionsResolved(p.OnDupColumns...) &&
+		expressionsResolved(p.OnDupValues...)
+}
+
 // Schema implements the Node interface.
 func (p *InsertInto) Schema() sql.Schema {
+	if p.returning {
+		return p.Left.Schema()
+	}
+
 	return sql.Schema{{
 		Name:     "updated",
 		Type:     sql.Int64,
@@ -58,18 +103,168 @@ func getInsertableTable(t sql.Table) (sql.Inserter, error) {
 	}
 }
 
+// getRowInserter returns an inserter for the rows of a statement. Tables
+// that don't insert the rows of a statement all at once get them one at a
+// time.
+func getRowInserter(ctx *sql.Context, node sql.Node) (sql.RowInserter, error) {
+	if rt, ok := node.(*ResolvedTable); ok {
+		if t, ok := getInsertableTableByStatement(rt.Table); ok {
+			return t.Inserter(ctx), nil
+		}
+	}
+
+	insertable, err := getInsertable(node)
+	if err != nil {
+		return nil, err
+	}
+
+	inserter := &rowInserter{Inserter: insertable}
+	if rt, ok := node.(*ResolvedTable); ok {
+		inserter.table = rt.Table
+	}
+	return inserter, nil
+}
+
+func getInsertableTableByStatement(t sql.Table) (sql.InsertableTable, bool) {
+	switch t := t.(type) {
+	case sql.InsertableTable:
+		return t, true
+	case sql.TableWrapper:
+		return getInsertableTableByStatement(t.Underlying())
+	default:
+		return nil, false
+	}
+}
+
+// rowInserter inserts the rows of a statement one at a time.
+type rowInserter struct {
+	sql.Inserter
+	// table is the table rows are inserted into, which duplicates are
+	// looked for in, if the inserter is not the node itself.
+	table sql.Table
+}
+
+func (*rowInserter) StatementBegin(*sql.Context)              {}
+func (*rowInserter) StatementComplete(*sql.Context) error     { return nil }
+func (*rowInserter) DiscardChanges(*sql.Context, error) error { return nil }
+func (*rowInserter) Close(*sql.Context) error                 { return nil }
+
+// Duplicate implements the sql.DuplicateKeyUpdater interface. The rows of
+// the table are scanned for the one with the same primary key.
+func (i *rowInserter) Duplicate(ctx *sql.Context, row sql.Row) (sql.Row, error) {
+	if i.table == nil {
+		return nil, ErrOnDuplicateNotSupported.New()
+	}
+
+	schema := i.table.Schema()
+	iter, err := NewResolvedTable(i.table).RowIter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	for {
+		r, err := iter.Next()
+		if err == io.EOF {
+			return nil, ErrOnDuplicateNotSupported.New()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		same := true
+		for j, col := range schema {
+			if !col.PrimaryKey {
+				continue
+			}
+			cmp, err := col.Type.Compare(r[j], row[j])
+			if err != nil {
+				return nil, err
+			}
+			if cmp != 0 {
+				same = false
+				break
+			}
+		}
+
+		if same {
+			return r, nil
+		}
+	}
+}
+
+// Update implements the sql.DuplicateKeyUpdater interface.
+func (i *rowInserter) Update(ctx *sql.Context, old, new sql.Row) error {
+	if i.table == nil {
+		return ErrOnDuplicateNotSupported.New()
+	}
+
+	updatable, err := getUpdatableTable(i.table)
+	if err != nil {
+		return err
+	}
+	return updatable.Update(ctx, old, new)
+}
+
+// WithReturning implements the RowReturner interface.
+func (p *InsertInto) WithReturning() sql.Node {
+	np := *p
+	np.returning = true
+	return &np
+}
+
 // Execute inserts the rows in the database.
 func (p *InsertInto) Execute(ctx *sql.Context) (int, error) {
-	insertable, err := getInsertable(p.Left)
+	n, _, err := p.execute(ctx)
+	return n, err
+}
+
+// execute inserts the rows in the database and, if the node is returning
+// rows, also gives back the rows as they were handed to the table.
+func (p *InsertInto) execute(ctx *sql.Context) (int, []sql.Row, error) {
+	inserter, err := getRowInserter(ctx, p.Left)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	inserter.StatementBegin(ctx)
+	n, inserted, err := p.insertRows(ctx, inserter)
+	if err == nil {
+		err = inserter.StatementComplete(ctx)
+	}
 	if err != nil {
-		return 0, err
+		_ = inserter.DiscardChanges(ctx, err)
+		_ = inserter.Close(ctx)
+		return n, inserted, err
 	}
+	return n, inserted, inserter.Close(ctx)
+}
+
+// insertRows inserts the rows of the statement with the given inserter.
+func (p *InsertInto) insertRows(ctx *sql.Context, inserter sql.RowInserter) (int, []sql.Row, error) {
 
 	dstSchema := p.Left.Schema()
+
+	// If no columns are given, the values are expected to match the full
+	// schema of the table in order.
+	columns := p.Columns
+	if len(columns) == 0 {
+		columns = make([]string, len(dstSchema))
+		for i, f := range dstSchema {
+			columns[i] = f.Name
+		}
+	}
+
+	// Queries have the number of values of their rows in their schema, so
+	// it's checked even if they return no rows.
+	if schema := p.Right.Schema(); schema != nil && len(schema) != len(columns) {
+		return 0, nil, ErrInsertIntoMismatchValueCount.New(1)
+	}
+
 	projExprs := make([]sql.Expression, len(dstSchema))
 	for i, f := range dstSchema {
 		found := false
-		for j, col := range p.Columns {
+		for j, col := range columns {
 			if f.Name == col {
 				projExprs[i] = expression.NewGetField(j, f.Type, f.Name, f.Nullable)
 				found = true
@@ -78,18 +273,23 @@ func (p *InsertInto) Execute(ctx *sql.Context) (int, error) {
 		}
 
 		if !found {
-			def, _ := f.Type.Convert(nil)
+			def, err := f.DefaultValue(ctx)
+			if err != nil {
+				return 0, nil, err
+			}
 			projExprs[i] = expression.NewLiteral(def, f.Type)
 		}
 	}
 
-	proj := NewProject(projExprs, p.Right)
-
-	iter, err := proj.RowIter(ctx)
+	// The rows come from VALUES or from a query, as INSERT ... SELECT
+	// copies the rows of other tables.
+	iter, err := p.Right.RowIter(ctx)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
+	var inserted []sql.Row
+	var affected int
 	i := 0
 	for {
 		row, err := iter.Next()
@@ -99,27 +299,217 @@ func (p *InsertInto) Execute(ctx *sql.Context) (int, error) {
 
 		if err != nil {
 			_ = iter.Close()
-			return i, err
+			return affected, inserted, err
+		}
+
+		if len(row) != len(columns) {
+			_ = iter.Close()
+			return affected, inserted, ErrInsertIntoMismatchValueCount.New(i + 1)
+		}
+
+		row, err = filterRow(ctx, projExprs, row)
+		if err != nil {
+			_ = iter.Close()
+			return affected, inserted, err
+		}
+
+		row, err = convertValues(ctx, dstSchema, row, i+1)
+		if err != nil {
+			_ = iter.Close()
+			return affected, inserted, err
 		}
 
-		if err := insertable.Insert(ctx, row); err != nil {
+		row, err = truncateValues(ctx, dstSchema, row, i+1)
+		if err != nil {
 			_ = iter.Close()
-			return i, err
+			return affected, inserted, err
 		}
 
+		if err := evalChecks(ctx, p.Checks, row); err != nil {
+			_ = iter.Close()
+			return affected, inserted, err
+		}
+
+		n := 1
+		if err := inserter.Insert(ctx, row); err != nil {
+			if len(p.OnDupColumns) == 0 || !sql.IsKind(sql.ErrDuplicateKey, err) {
+				_ = iter.Close()
+				return affected, inserted, err
+			}
+
+			row, n, err = p.updateDuplicate(ctx, inserter, row, i+1)
+			if err != nil {
+				_ = iter.Close()
+				return affected, inserted, err
+			}
+		}
+
+		if p.returning {
+			inserted = append(inserted, row)
+		}
+
+		affected += n
 		i++
 	}
 
-	return i, nil
+	return affected, inserted, iter.Close()
+}
+
+// updateDuplicate applies the ON DUPLICATE KEY UPDATE assignments to the
+// row the given one conflicts with, which is the n-th row of the statement.
+// It returns the row as it's left along with the number of rows affected,
+// which is 2 if the row was changed and 0 otherwise, as MySQL counts them.
+func (p *InsertInto) updateDuplicate(ctx *sql.Context, inserter sql.RowInserter, row sql.Row, n int) (sql.Row, int, error) {
+	updater, ok := inserter.(sql.DuplicateKeyUpdater)
+	if !ok {
+		return nil, 0, ErrOnDuplicateNotSupported.New()
+	}
+
+	fields := make([]*expression.GetField, len(p.OnDupColumns))
+	for i, c := range p.OnDupColumns {
+		gf, ok := c.(*expression.GetField)
+		if !ok {
+			return nil, 0, ErrUpdateUnexpectedSetField.New(c)
+		}
+		fields[i] = gf
+	}
+
+	oldRow, err := updater.Duplicate(ctx, row)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	combined := make(sql.Row, 0, len(oldRow)+len(row))
+	combined = append(combined, oldRow...)
+	combined = append(combined, row...)
+	newRow, err := applyUpdates(ctx, fields, p.OnDupValues, combined, n)
+	if err != nil {
+		return nil, 0, err
+	}
+	newRow = newRow[:len(oldRow)]
+
+	schema := p.Left.Schema()
+	newRow, err = truncateValues(ctx, schema, newRow, n)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	equal, err := oldRow.Equals(newRow, schema)
+	if err != nil || equal {
+		return oldRow, 0, err
+	}
+
+	newRow, err = touchOnUpdate(ctx, schema, fields, newRow)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := evalChecks(ctx, p.Checks, newRow); err != nil {
+		return nil, 0, err
+	}
+
+	if err := updater.Update(ctx, oldRow, newRow); err != nil {
+		return nil, 0, err
+	}
+	return newRow, 2, nil
+}
+
+// convertValues returns the row with its values converted to the types of
+// their columns, as UPDATE does with the values it assigns, so a TIMESTAMP
+// column given a string holds a time, for example. n is the number of the
+// row in the statement, starting from 1.
+func convertValues(ctx *sql.Context, schema sql.Schema, row sql.Row, n int) (sql.Row, error) {
+	converted := make(sql.Row, len(row))
+	for i, v := range row {
+		if v == nil || i >= len(schema) {
+			converted[i] = v
+			continue
+		}
+
+		cv, err := convertValue(ctx, schema[i].Name, schema[i].Type, v, n)
+		if err != nil {
+			return nil, err
+		}
+		converted[i] = cv
+	}
+	return converted, nil
+}
+
+// convertValue converts a value stored in a column to the type of the
+// column. Numbers that don't fit in it fail with ErrOutOfRangeValue, and
+// floats stored in integer columns lose their fraction with a warning.
+func convertValue(ctx *sql.Context, column string, typ sql.Type, v interface{}, n int) (interface{}, error) {
+	cv, err := typ.Convert(v)
+	if err != nil {
+		if sql.ErrValueOutOfRange.Is(err) {
+			return nil, ErrOutOfRangeValue.New(column, n)
+		}
+		return nil, err
+	}
+
+	if sql.IsInteger(typ) && hasFraction(v) {
+		ctx.Warn(1265, "Data truncated for column '%s' at row %d", column, n)
+	}
+	return cv, nil
+}
+
+func hasFraction(v interface{}) bool {
+	switch f := v.(type) {
+	case float32:
+		return float64(f) != math.Trunc(float64(f))
+	case float64:
+		return f != math.Trunc(f)
+	default:
+		return false
+	}
+}
+
+// truncateValues returns the row with the values that are longer than
+// their columns allow cut to fit, with a warning for each of them, as MySQL
+// does when it's not in strict mode. n is the number of the row in the
+// statement, starting from 1.
+func truncateValues(ctx *sql.Context, schema sql.Schema, row sql.Row, n int) (sql.Row, error) {
+	var truncated sql.Row
+	for i, col := range schema {
+		max := sql.MaxLength(col.Type)
+		if max == 0 || i >= len(row) || row[i] == nil {
+			continue
+		}
+
+		v, err := col.Type.Convert(row[i])
+		if err != nil {
+			return nil, err
+		}
+
+		s := v.(string)
+		if utf8.RuneCountInString(s) <= max {
+			continue
+		}
+
+		if truncated == nil {
+			truncated = row.Copy()
+		}
+		truncated[i] = string([]rune(s)[:max])
+		ctx.Warn(1265, "Data truncated for column '%s' at row %d", col.Name, n)
+	}
+
+	if truncated == nil {
+		return row, nil
+	}
+	return truncated, nil
 }
 
 // RowIter implements the Node interface.
 func (p *InsertInto) RowIter(ctx *sql.Context) (sql.RowIter, error) {
-	n, err := p.Execute(ctx)
+	n, rows, err := p.execute(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	if p.returning {
+		return sql.RowsToRowIter(rows...), nil
+	}
+
 	return sql.RowsToRowIter(sql.NewRow(int64(n))), nil
 }
 
@@ -135,7 +525,7 @@ func (p *InsertInto) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
 		return nil, err
 	}
 
-	return f(NewInsertInto(left, right, p.Columns))
+	return f(p.withChildren(left, right))
 }
 
 // TransformExpressionsUp implements the Transformable interface.
@@ -150,12 +540,39 @@ func (p *InsertInto) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node,
 		return nil, err
 	}
 
-	return NewInsertInto(left, right, p.Columns), nil
+	columns, err := transformExpressionsUp(f, p.OnDupColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := transformExpressionsUp(f, p.OnDupValues)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.withChildren(left, right).WithOnDuplicate(columns, values), nil
+}
+
+func (p *InsertInto) withChildren(left, right sql.Node) *InsertInto {
+	np := NewInsertInto(left, right, p.Columns)
+	np.OnDupColumns = p.OnDupColumns
+	np.OnDupValues = p.OnDupValues
+	np.returning = p.returning
+	np.Checks = p.Checks
+	return np
 }
 
 func (p InsertInto) String() string {
 	pr := sql.NewTreePrinter()
-	_ = pr.WriteNode("Insert(%s)", strings.Join(p.Columns, ", "))
+	if len(p.OnDupColumns) > 0 {
+		sets := make([]string, len(p.OnDupColumns))
+		for i := range p.OnDupColumns {
+			sets[i] = fmt.Sprintf("%s = %s", p.OnDupColumns[i], p.OnDupValues[i])
+		}
+		_ = pr.WriteNode("Insert(%s) OnDuplicate(%s)", strings.Join(p.Columns, ", "), strings.Join(sets, ", "))
+	} else {
+		_ = pr.WriteNode("Insert(%s)", strings.Join(p.Columns, ", "))
+	}
 	_ = pr.WriteChildren(p.Left.String(), p.Right.String())
 	return pr.String()
 }
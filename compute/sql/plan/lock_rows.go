@@ -0,0 +1,70 @@
+package plan
+
+import (
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// LockRows locks the rows its child reads from tables with the lock of
+// SELECT ... FOR UPDATE or LOCK IN SHARE MODE. The locks are taken by the
+// transaction the query runs in, which keeps them until it ends. Queries
+// run outside of a transaction read the rows without locking them.
+type LockRows struct {
+	UnaryNode
+	Lock sql.RowLock
+}
+
+// NewLockRows creates a new LockRows node.
+func NewLockRows(lock sql.RowLock, child sql.Node) *LockRows {
+	return &LockRows{
+		UnaryNode: UnaryNode{Child: child},
+		Lock:      lock,
+	}
+}
+
+// Resolved implements the Resolvable interface.
+func (l *LockRows) Resolved() bool {
+	return l.Child.Resolved()
+}
+
+// RowIter implements the Node interface.
+func (l *LockRows) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.LockRows")
+
+	it, err := l.Child.RowIter(ctx.WithRowLock(l.Lock))
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	return sql.NewSpanIter(span, it), nil
+}
+
+// TransformUp implements the Transformable interface.
+func (l *LockRows) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	child, err := l.Child.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+	return f(NewLockRows(l.Lock, child))
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (l *LockRows) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	child, err := l.Child.TransformExpressionsUp(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewLockRows(l.Lock, child), nil
+}
+
+func (l *LockRows) String() string {
+	mode := "FOR UPDATE"
+	if l.Lock == sql.RowLockShared {
+		mode = "LOCK IN SHARE MODE"
+	}
+
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("LockRows(%s)", mode)
+	_ = p.WriteChildren(l.Child.String())
+	return p.String()
+}
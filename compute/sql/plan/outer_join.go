@@ -0,0 +1,369 @@
+package plan
+
+import (
+	"io"
+	"reflect"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// JoinType is the type of an outer join, which tells the rows of which
+// sides are kept when they match no row of the other side.
+type JoinType byte
+
+const (
+	// LeftJoinType keeps the rows of the left side.
+	LeftJoinType JoinType = iota
+	// RightJoinType keeps the rows of the right side.
+	RightJoinType
+	// FullOuterJoinType keeps the rows of both sides.
+	FullOuterJoinType
+)
+
+func (t JoinType) String() string {
+	switch t {
+	case LeftJoinType:
+		return "LeftJoin"
+	case RightJoinType:
+		return "RightJoin"
+	default:
+		return "FullOuterJoin"
+	}
+}
+
+// OuterJoin is an outer join between two tables. The rows of the kept sides
+// that match no row of the other side are returned with NULL in its
+// columns.
+type OuterJoin struct {
+	BinaryNode
+	Cond sql.Expression
+	Type JoinType
+}
+
+// NewLeftJoin creates a new left outer join node from two tables.
+func NewLeftJoin(left, right sql.Node, cond sql.Expression) *OuterJoin {
+	return NewOuterJoin(left, right, cond, LeftJoinType)
+}
+
+// NewRightJoin creates a new right outer join node from two tables.
+func NewRightJoin(left, right sql.Node, cond sql.Expression) *OuterJoin {
+	return NewOuterJoin(left, right, cond, RightJoinType)
+}
+
+// NewFullOuterJoin creates a new full outer join node from two tables.
+func NewFullOuterJoin(left, right sql.Node, cond sql.Expression) *OuterJoin {
+	return NewOuterJoin(left, right, cond, FullOuterJoinType)
+}
+
+// NewOuterJoin creates a new outer join node of the given type.
+func NewOuterJoin(left, right sql.Node, cond sql.Expression, typ JoinType) *OuterJoin {
+	return &OuterJoin{
+		BinaryNode: BinaryNode{
+			Left:  left,
+			Right: right,
+		},
+		Cond: cond,
+		Type: typ,
+	}
+}
+
+// Schema implements the Node interface. The columns of the sides padded
+// with NULL are nullable.
+func (j *OuterJoin) Schema() sql.Schema {
+	left, right := j.Left.Schema(), j.Right.Schema()
+	if j.Type != LeftJoinType {
+		left = nullableSchema(left)
+	}
+	if j.Type != RightJoinType {
+		right = nullableSchema(right)
+	}
+	return append(append(sql.Schema{}, left...), right...)
+}
+
+func nullableSchema(schema sql.Schema) sql.Schema {
+	result := make(sql.Schema, len(schema))
+	for i, col := range schema {
+		c := *col
+		c.Nullable = true
+		result[i] = &c
+	}
+	return result
+}
+
+// Resolved implements the Resolvable interface.
+func (j *OuterJoin) Resolved() bool {
+	return j.Left.Resolved() && j.Right.Resolved() && j.Cond.Resolved()
+}
+
+// RowIter implements the Node interface. Like InnerJoin, it reads the
+// inner side again for each row of the outer one. The right rows of a full
+// outer join that match no left row are found after all the left ones in
+// another pass.
+func (j *OuterJoin) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	var left, right string
+	if leftTable, ok := j.Left.(sql.Nameable); ok {
+		left = leftTable.Name()
+	} else {
+		left = reflect.TypeOf(j.Left).String()
+	}
+
+	if rightTable, ok := j.Right.(sql.Nameable); ok {
+		right = rightTable.Name()
+	} else {
+		right = reflect.TypeOf(j.Right).String()
+	}
+
+	span, ctx := ctx.Span("plan."+j.Type.String(), opentracing.Tags{
+		"left":  left,
+		"right": right,
+	})
+
+	var iter sql.RowIter
+	var err error
+	switch j.Type {
+	case LeftJoinType:
+		iter, err = newOuterJoinIter(ctx, j.Cond, j.Left, j.Right, false, false)
+	case RightJoinType:
+		iter, err = newOuterJoinIter(ctx, j.Cond, j.Right, j.Left, true, false)
+	default:
+		iter, err = newOuterJoinIter(ctx, j.Cond, j.Left, j.Right, false, false)
+		if err == nil {
+			iter = &fullOuterJoinIter{
+				left: iter,
+				right: func() (sql.RowIter, error) {
+					return newOuterJoinIter(ctx, j.Cond, j.Right, j.Left, true, true)
+				},
+			}
+		}
+	}
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	return sql.NewSpanIter(span, iter), nil
+}
+
+// TransformUp implements the Transformable interface.
+func (j *OuterJoin) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	left, err := j.Left.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := j.Right.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return f(NewOuterJoin(left, right, j.Cond, j.Type))
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (j *OuterJoin) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	left, err := j.Left.TransformExpressionsUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := j.Right.TransformExpressionsUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	cond, err := j.Cond.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOuterJoin(left, right, cond, j.Type), nil
+}
+
+func (j *OuterJoin) String() string {
+	pr := sql.NewTreePrinter()
+	_ = pr.WriteNode("%s(%s)", j.Type, j.Cond)
+	_ = pr.WriteChildren(j.Left.String(), j.Right.String())
+	return pr.String()
+}
+
+// Expressions implements the Expressioner interface.
+func (j *OuterJoin) Expressions() []sql.Expression {
+	return []sql.Expression{j.Cond}
+}
+
+// TransformExpressions implements the Expressioner interface.
+func (j *OuterJoin) TransformExpressions(f sql.TransformExprFunc) (sql.Node, error) {
+	cond, err := j.Cond.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOuterJoin(j.Left, j.Right, cond, j.Type), nil
+}
+
+// outerJoinIter returns the rows of the outer side joined with the rows of
+// the inner side that match them, or with NULL values if there are none.
+type outerJoinIter struct {
+	ctx   *sql.Context
+	cond  sql.Expression
+	outer sql.RowIter
+	inner rowIterProvider
+	// innerWidth is the number of columns of the inner side.
+	innerWidth int
+	// innerFirst is true if the columns of the inner side are the first
+	// ones of the joined rows.
+	innerFirst bool
+	// unmatchedOnly is true if only the outer rows that match no inner
+	// row are returned.
+	unmatchedOnly bool
+
+	r        sql.RowIter
+	outerRow sql.Row
+	matched  bool
+}
+
+func newOuterJoinIter(
+	ctx *sql.Context,
+	cond sql.Expression,
+	outer, inner sql.Node,
+	innerFirst, unmatchedOnly bool,
+) (*outerJoinIter, error) {
+	o, err := outer.RowIter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &outerJoinIter{
+		ctx:           ctx,
+		cond:          cond,
+		outer:         o,
+		inner:         inner,
+		innerWidth:    len(inner.Schema()),
+		innerFirst:    innerFirst,
+		unmatchedOnly: unmatchedOnly,
+	}, nil
+}
+
+func (i *outerJoinIter) Next() (sql.Row, error) {
+	for {
+		if i.outerRow == nil {
+			row, err := i.outer.Next()
+			if err != nil {
+				return nil, err
+			}
+
+			i.outerRow, i.matched = row, false
+		}
+
+		if i.r == nil {
+			iter, err := i.inner.RowIter(i.ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			i.r = iter
+		}
+
+		innerRow, err := i.r.Next()
+		if err == io.EOF {
+			if err := i.closeInner(); err != nil {
+				return nil, err
+			}
+
+			outerRow := i.outerRow
+			i.outerRow = nil
+			if !i.matched {
+				return i.join(outerRow, make(sql.Row, i.innerWidth)), nil
+			}
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		row := i.join(i.outerRow, innerRow)
+		result, err := i.cond.Eval(i.ctx, row)
+		if err != nil {
+			return nil, err
+		}
+
+		if result != true {
+			continue
+		}
+
+		i.matched = true
+		if i.unmatchedOnly {
+			// The rest of the inner rows can't change that this one
+			// matches.
+			if err := i.closeInner(); err != nil {
+				return nil, err
+			}
+			i.outerRow = nil
+			continue
+		}
+
+		return row, nil
+	}
+}
+
+func (i *outerJoinIter) join(outer, inner sql.Row) sql.Row {
+	row := make(sql.Row, 0, len(outer)+len(inner))
+	if i.innerFirst {
+		return append(append(row, inner...), outer...)
+	}
+	return append(append(row, outer...), inner...)
+}
+
+func (i *outerJoinIter) closeInner() error {
+	if i.r == nil {
+		return nil
+	}
+
+	err := i.r.Close()
+	i.r = nil
+	return err
+}
+
+func (i *outerJoinIter) Close() error {
+	if err := i.outer.Close(); err != nil {
+		_ = i.closeInner()
+		return err
+	}
+
+	return i.closeInner()
+}
+
+// fullOuterJoinIter returns the rows of the left join of a full outer join,
+// and then the ones of the right side that match no left row.
+type fullOuterJoinIter struct {
+	left  sql.RowIter
+	right func() (sql.RowIter, error)
+	iter  sql.RowIter
+}
+
+func (i *fullOuterJoinIter) Next() (sql.Row, error) {
+	if i.iter == nil {
+		row, err := i.left.Next()
+		if err != io.EOF {
+			return row, err
+		}
+
+		if i.iter, err = i.right(); err != nil {
+			return nil, err
+		}
+	}
+
+	return i.iter.Next()
+}
+
+func (i *fullOuterJoinIter) Close() error {
+	err := i.left.Close()
+	if i.iter != nil {
+		if rerr := i.iter.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+func TestOuterJoin(t *testing.T) {
+	left := mem.NewTable("l", sql.Schema{
+		{Name: "x", Type: sql.Int64, Source: "l"},
+		{Name: "z", Type: sql.Int64, Source: "l"},
+	})
+	right := mem.NewTable("r", sql.Schema{
+		{Name: "y", Type: sql.Int64, Source: "r"},
+		{Name: "w", Type: sql.Int64, Source: "r"},
+	})
+	for _, row := range []sql.Row{{int64(1), int64(10)}, {int64(2), int64(20)}, {int64(3), int64(30)}} {
+		require.NoError(t, left.Insert(sql.NewEmptyContext(), row))
+	}
+	for _, row := range []sql.Row{{int64(1), int64(5)}, {int64(2), int64(25)}, {int64(4), int64(40)}, {int64(1), int64(7)}} {
+		require.NoError(t, right.Insert(sql.NewEmptyContext(), row))
+	}
+
+	// l.x = r.y AND l.z > r.w
+	cond := expression.NewAnd(
+		expression.NewEquals(
+			expression.NewGetFieldWithTable(0, sql.Int64, "l", "x", false),
+			expression.NewGetFieldWithTable(2, sql.Int64, "r", "y", false),
+		),
+		expression.NewGreaterThan(
+			expression.NewGetFieldWithTable(1, sql.Int64, "l", "z", false),
+			expression.NewGetFieldWithTable(3, sql.Int64, "r", "w", false),
+		),
+	)
+
+	testCases := []struct {
+		name     string
+		join     sql.Node
+		nullable []bool
+		expected []sql.Row
+	}{
+		{
+			"left",
+			NewLeftJoin(NewResolvedTable(left), NewResolvedTable(right), cond),
+			[]bool{false, false, true, true},
+			[]sql.Row{
+				{int64(1), int64(10), int64(1), int64(5)},
+				{int64(1), int64(10), int64(1), int64(7)},
+				{int64(2), int64(20), nil, nil},
+				{int64(3), int64(30), nil, nil},
+			},
+		},
+		{
+			"right",
+			NewRightJoin(NewResolvedTable(left), NewResolvedTable(right), cond),
+			[]bool{true, true, false, false},
+			[]sql.Row{
+				{int64(1), int64(10), int64(1), int64(5)},
+				{nil, nil, int64(2), int64(25)},
+				{nil, nil, int64(4), int64(40)},
+				{int64(1), int64(10), int64(1), int64(7)},
+			},
+		},
+		{
+			"full",
+			NewFullOuterJoin(NewResolvedTable(left), NewResolvedTable(right), cond),
+			[]bool{true, true, true, true},
+			[]sql.Row{
+				{int64(1), int64(10), int64(1), int64(5)},
+				{int64(1), int64(10), int64(1), int64(7)},
+				{int64(2), int64(20), nil, nil},
+				{int64(3), int64(30), nil, nil},
+				{nil, nil, int64(2), int64(25)},
+				{nil, nil, int64(4), int64(40)},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			var nullable []bool
+			for _, col := range tt.join.Schema() {
+				nullable = append(nullable, col.Nullable)
+			}
+			require.Equal(tt.nullable, nullable)
+
+			require.Equal(tt.expected, collectRows(t, tt.join))
+		})
+	}
+
+	// The columns of the tables themselves aren't changed.
+	require.False(t, left.Schema()[0].Nullable)
+}
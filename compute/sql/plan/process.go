@@ -96,7 +96,7 @@ func (t *ProcessIndexableTable) PartitionRows(ctx *sql.Context, p sql.Partition)
 		return nil, err
 	}
 
-	return &trackedRowIter{iter, t.Notify}, nil
+	return &examinedRowIter{ctx, &trackedRowIter{iter, t.Notify}}, nil
 }
 
 var _ sql.IndexableTable = (*ProcessIndexableTable)(nil)
@@ -119,6 +119,20 @@ func (t *ProcessTable) Underlying() sql.Table {
 	return t.Table
 }
 
+// WithOrder implements the sql.OrderedTable interface.
+func (t *ProcessTable) WithOrder(column string, desc bool) (sql.Table, bool) {
+	ot, ok := t.Table.(sql.OrderedTable)
+	if !ok {
+		return nil, false
+	}
+
+	ordered, ok := ot.WithOrder(column, desc)
+	if !ok {
+		return nil, false
+	}
+	return NewProcessTable(ordered, t.Notify), true
+}
+
 // PartitionRows implements the sql.Table interface.
 func (t *ProcessTable) PartitionRows(ctx *sql.Context, p sql.Partition) (sql.RowIter, error) {
 	iter, err := t.Table.PartitionRows(ctx, p)
@@ -126,7 +140,7 @@ func (t *ProcessTable) PartitionRows(ctx *sql.Context, p sql.Partition) (sql.Row
 		return nil, err
 	}
 
-	return &trackedRowIter{iter, t.Notify}, nil
+	return &examinedRowIter{ctx, &trackedRowIter{iter, t.Notify}}, nil
 }
 
 type trackedRowIter struct {
@@ -157,6 +171,21 @@ func (i *trackedRowIter) Close() error {
 	return i.iter.Close()
 }
 
+// examinedRowIter counts the rows read from a table as examined by the
+// query.
+type examinedRowIter struct {
+	ctx *sql.Context
+	sql.RowIter
+}
+
+func (i *examinedRowIter) Next() (sql.Row, error) {
+	row, err := i.RowIter.Next()
+	if err == nil {
+		i.ctx.AddRowsExamined(1)
+	}
+	return row, err
+}
+
 type trackedPartitionIndexKeyValueIter struct {
 	sql.PartitionIndexKeyValueIter
 	notify NotifyFunc
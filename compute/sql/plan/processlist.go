@@ -1,9 +1,9 @@
 package plan
 
 import (
-	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/turtacn/guocedb/compute/sql"
 )
@@ -16,11 +16,12 @@ type process struct {
 	command string
 	time    int64
 	state   string
-	info    string
+	info    interface{}
+	attrs   map[string]string
 }
 
-func (p process) toRow() sql.Row {
-	return sql.NewRow(
+func (p process) toRow(full bool) sql.Row {
+	row := sql.NewRow(
 		p.id,
 		p.user,
 		p.host,
@@ -30,6 +31,30 @@ func (p process) toRow() sql.Row {
 		p.state,
 		p.info,
 	)
+	if full {
+		row = append(row, attribute(p.attrs, "program_name"), clientVersion(p.attrs))
+	}
+	return row
+}
+
+// attribute returns a connection attribute, or nil if the client didn't
+// send it.
+func attribute(attrs map[string]string, name string) interface{} {
+	if v, ok := attrs[name]; ok {
+		return v
+	}
+	return nil
+}
+
+// clientVersion returns the name and version of the client library a
+// connection uses, from the _client_name and _client_version attributes it
+// sent, or nil if it sent neither.
+func clientVersion(attrs map[string]string) interface{} {
+	name, version := attrs["_client_name"], attrs["_client_version"]
+	if name == "" && version == "" {
+		return nil
+	}
+	return strings.TrimSpace(name + " " + version)
 }
 
 var processListSchema = sql.Schema{
@@ -40,12 +65,28 @@ var processListSchema = sql.Schema{
 	{Name: "Command", Type: sql.Text},
 	{Name: "Time", Type: sql.Int64},
 	{Name: "State", Type: sql.Text},
-	{Name: "Info", Type: sql.Text},
+	{Name: "Info", Type: sql.Text, Nullable: true},
 }
 
+// fullProcessListSchema is the schema of SHOW FULL PROCESSLIST, which also
+// shows the program of the clients and the client library they use, from
+// the attributes they sent when they connected.
+var fullProcessListSchema = append(processListSchema[:len(processListSchema):len(processListSchema)],
+	&sql.Column{Name: "Program", Type: sql.Text, Nullable: true},
+	&sql.Column{Name: "Client", Type: sql.Text, Nullable: true},
+)
+
+// Commands of the connections shown by SHOW PROCESSLIST.
+const (
+	commandQuery = "Query"
+	commandSleep = "Sleep"
+)
+
 // ShowProcessList shows a list of all current running processes.
 type ShowProcessList struct {
 	Database string
+	// Full is whether it's SHOW FULL PROCESSLIST.
+	Full bool
 	*sql.ProcessList
 }
 
@@ -69,38 +110,75 @@ func (p *ShowProcessList) TransformExpressionsUp(f sql.TransformExprFunc) (sql.N
 }
 
 // Schema implements the Node interface.
-func (p *ShowProcessList) Schema() sql.Schema { return processListSchema }
+func (p *ShowProcessList) Schema() sql.Schema {
+	if p.Full {
+		return fullProcessListSchema
+	}
+	return processListSchema
+}
 
-// RowIter implements the Node interface.
+// RowIter implements the Node interface. If the process list knows the
+// client connections, there is a row for each of them, with the process it
+// is running if any. Otherwise, there is a row for each process.
 func (p *ShowProcessList) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 	processes := p.Processes()
+	if conns, ok := p.Connections(); ok {
+		return sql.RowsToRowIter(connectionRows(conns, processes, p.Full)...), nil
+	}
+
 	var rows = make([]sql.Row, len(processes))
 
 	for i, proc := range processes {
-		var status []string
-		for name, progress := range proc.Progress {
-			status = append(status, fmt.Sprintf("%s(%s)", name, progress))
-		}
-
-		if len(status) == 0 {
-			status = []string{"running"}
-		}
-
-		sort.Strings(status)
-
 		rows[i] = process{
 			id:      int64(proc.Pid),
 			user:    proc.User,
 			time:    int64(proc.Seconds()),
-			state:   strings.Join(status, ", "),
+			state:   proc.State(),
 			command: proc.Type.String(),
-			host:    ctx.Session.Client().Address,
+			host:    proc.Host,
 			info:    proc.Query,
 			db:      p.Database,
-		}.toRow()
+		}.toRow(p.Full)
 	}
 
 	return sql.RowsToRowIter(rows...), nil
 }
 
 func (p *ShowProcessList) String() string { return "ProcessList" }
+
+// connectionRows returns a row for each connection, sorted by id.
+func connectionRows(conns []sql.Connection, processes []sql.Process, full bool) []sql.Row {
+	// A connection runs a process at a time, but a killed one may stay in
+	// the list until it stops, so the latest one is shown.
+	running := make(map[uint32]sql.Process)
+	for _, proc := range processes {
+		if cur, ok := running[proc.Connection]; !ok || proc.StartedAt.After(cur.StartedAt) {
+			running[proc.Connection] = proc
+		}
+	}
+
+	sort.Slice(conns, func(i, j int) bool { return conns[i].ID < conns[j].ID })
+	rows := make([]sql.Row, len(conns))
+	for i, conn := range conns {
+		row := process{
+			id:      int64(conn.ID),
+			user:    conn.User,
+			host:    conn.Host,
+			db:      conn.Database,
+			command: commandSleep,
+			time:    int64(time.Since(conn.Since) / time.Second),
+			attrs:   conn.Attributes,
+		}
+		if proc, ok := running[conn.ID]; ok {
+			row.command = commandQuery
+			if proc.Type != sql.QueryProcess {
+				row.command = proc.Type.String()
+			}
+			row.time = int64(proc.Seconds())
+			row.state = proc.State()
+			row.info = proc.Query
+		}
+		rows[i] = row.toRow(full)
+	}
+	return rows
+}
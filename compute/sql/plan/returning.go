@@ -0,0 +1,118 @@
+package plan
+
+import (
+	"strings"
+
+	"gopkg.in/src-d/go-errors.v1"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// ErrReturningNotSupported is thrown when a RETURNING clause is used on a
+// statement that does not modify rows.
+var ErrReturningNotSupported = errors.NewKind("RETURNING is not supported on %T")
+
+// RowReturner is a data modification node that can produce the final state
+// of the rows it affected instead of the number of affected rows.
+type RowReturner interface {
+	sql.Node
+	// WithReturning returns a copy of the node that produces the affected
+	// rows. The schema of the returned node is the one of the target table.
+	WithReturning() sql.Node
+}
+
+// Returning projects the given expressions over the rows affected by a data
+// modification node, as in INSERT ... RETURNING.
+type Returning struct {
+	UnaryNode
+	Projections []sql.Expression
+}
+
+// NewReturning creates a Returning node that projects the given expressions
+// over the rows affected by the child.
+func NewReturning(expressions []sql.Expression, child sql.Node) (*Returning, error) {
+	rr, ok := child.(RowReturner)
+	if !ok {
+		return nil, ErrReturningNotSupported.New(child)
+	}
+
+	return &Returning{
+		UnaryNode:   UnaryNode{Child: rr.WithReturning()},
+		Projections: expressions,
+	}, nil
+}
+
+// Schema implements the Node interface.
+func (p *Returning) Schema() sql.Schema {
+	return NewProject(p.Projections, p.Child).Schema()
+}
+
+// Resolved implements the Resolvable interface.
+func (p *Returning) Resolved() bool {
+	return p.Child.Resolved() && expressionsResolved(p.Projections...)
+}
+
+// RowIter implements the Node interface.
+func (p *Returning) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.Returning")
+
+	// The child performs all the changes before the first row is returned,
+	// so the statement has the same effect no matter how many rows are read.
+	i, err := p.Child.RowIter(ctx)
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	return sql.NewSpanIter(span, &iter{NewProject(p.Projections, p.Child), i, ctx}), nil
+}
+
+// TransformUp implements the Transformable interface.
+func (p *Returning) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	child, err := p.Child.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return f(&Returning{UnaryNode{child}, p.Projections})
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (p *Returning) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	exprs, err := transformExpressionsUp(f, p.Projections)
+	if err != nil {
+		return nil, err
+	}
+
+	child, err := p.Child.TransformExpressionsUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Returning{UnaryNode{child}, exprs}, nil
+}
+
+// Expressions implements the Expressioner interface.
+func (p *Returning) Expressions() []sql.Expression {
+	return p.Projections
+}
+
+// TransformExpressions implements the Expressioner interface.
+func (p *Returning) TransformExpressions(f sql.TransformExprFunc) (sql.Node, error) {
+	exprs, err := transformExpressionsUp(f, p.Projections)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Returning{UnaryNode{p.Child}, exprs}, nil
+}
+
+func (p *Returning) String() string {
+	pr := sql.NewTreePrinter()
+	var exprs = make([]string, len(p.Projections))
+	for i, expr := range p.Projections {
+		exprs[i] = expr.String()
+	}
+	_ = pr.WriteNode("Returning(%s)", strings.Join(exprs, ", "))
+	_ = pr.WriteChildren(p.Child.String())
+	return pr.String()
+}
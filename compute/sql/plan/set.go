@@ -9,9 +9,11 @@ import (
 	"github.com/dolthub/vitess/go/vt/sqlparser"
 )
 
-// Set configuration variables. Right now, only session variables are supported.
+// Set configuration variables. Variables with the GLOBAL scope are set in
+// the global variables of Catalog, and the others in the session.
 type Set struct {
 	Variables []SetVariable
+	Catalog   *sql.Catalog
 }
 
 // SetVariable is a key-value pair to represent the value that will be set on
@@ -23,7 +25,7 @@ type SetVariable struct {
 
 // NewSet creates a new Set node.
 func NewSet(vars ...SetVariable) *Set {
-	return &Set{vars}
+	return &Set{Variables: vars}
 }
 
 // Resolved implements the sql.Node interface.
@@ -74,7 +76,7 @@ func (s *Set) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error)
 		vars[i].Value = val
 	}
 
-	return NewSet(vars...), nil
+	return &Set{Variables: vars, Catalog: s.Catalog}, nil
 }
 
 // RowIter implements the sql.Node interface.
@@ -93,11 +95,27 @@ func (s *Set) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 			err   error
 		)
 
+		var global bool
 		name := strings.TrimLeft(v.Name, "@")
 		if strings.HasPrefix(name, sessionPrefix) {
 			name = name[len(sessionPrefix):]
 		} else if strings.HasPrefix(name, globalPrefix) {
 			name = name[len(globalPrefix):]
+			global = true
+		}
+
+		if global {
+			if err := s.setGlobal(ctx, name, v.Value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Variables that only exist globally can't be set for a session.
+		if _, ok := sql.DefaultSessionConfig()[name]; !ok && s.Catalog != nil {
+			if _, ok := s.Catalog.GlobalVariable(name); ok {
+				return nil, sql.ErrGlobalVariable.New(name)
+			}
 		}
 
 		if _, ok := v.Value.(*expression.DefaultColumn); ok {
@@ -120,6 +138,22 @@ func (s *Set) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 	return sql.RowsToRowIter(), nil
 }
 
+func (s *Set) setGlobal(ctx *sql.Context, name string, value sql.Expression) error {
+	if s.Catalog == nil {
+		return sql.ErrUnknownSystemVariable.New(name)
+	}
+
+	if _, ok := value.(*expression.DefaultColumn); ok {
+		return fmt.Errorf("variable '%s' can't be set to DEFAULT", name)
+	}
+
+	val, err := value.Eval(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return s.Catalog.SetGlobalVariable(name, val)
+}
+
 // Schema implements the sql.Node interface.
 func (s *Set) Schema() sql.Schema { return nil }
 
@@ -3,6 +3,7 @@ package plan
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"gopkg.in/src-d/go-errors.v1"
@@ -95,33 +96,177 @@ func produceCreateStatement(table sql.Table) string {
 
 	// Statement creation parts for each column
 	for indx, col := range schema {
-		createStmtPart := fmt.Sprintf("`%s` %s", col.Name, col.Type.Type())
+		createStmtPart := fmt.Sprintf("`%s` %s", col.Name, columnTypeSQL(col.Type))
+		if c := sql.CollationOf(col.Type); c != sql.DefaultCollation {
+			createStmtPart = fmt.Sprintf("%s COLLATE %s", createStmtPart, c.Name())
+		}
 
 		if !col.Nullable {
 			createStmtPart = fmt.Sprintf("%s NOT NULL", createStmtPart)
 		}
 
-		switch def := col.Default.(type) {
-		case string:
-			if def != "" {
-				createStmtPart = fmt.Sprintf("%s DEFAULT %s", createStmtPart, def)
-			}
+		switch def := col.Default; {
+		case def == nil:
+		case def == sql.CurrentTimestamp || sql.IsNumber(col.Type):
+			createStmtPart = fmt.Sprintf("%s DEFAULT %v", createStmtPart, def)
 		default:
-			if def != nil {
-				createStmtPart = fmt.Sprintf("%s DEFAULT %v", createStmtPart, col.Default)
-			}
+			createStmtPart = fmt.Sprintf("%s DEFAULT %s", createStmtPart, quoteString(col.Type.SQL(def).ToString()))
+		}
+
+		if col.OnUpdateCurrentTimestamp {
+			createStmtPart = fmt.Sprintf("%s ON UPDATE %v", createStmtPart, sql.CurrentTimestamp)
+		}
+
+		if col.AutoIncrement {
+			createStmtPart = fmt.Sprintf("%s AUTO_INCREMENT", createStmtPart)
+		}
+
+		if col.Comment != "" {
+			createStmtPart = fmt.Sprintf("%s COMMENT %s", createStmtPart, quoteString(col.Comment))
 		}
 
 		colCreateStatements[indx] = createStmtPart
 	}
 
+	colCreateStatements = append(colCreateStatements, keysSQL(table)...)
+
 	prettyColCreateStmts := fmt.Sprintf("%s", strings.Join(colCreateStatements, ",\n"))
 	composedCreateTableStatement :=
-		fmt.Sprintf("CREATE TABLE `%s` (%s) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", table.Name(), prettyColCreateStmts)
+		fmt.Sprintf("CREATE TABLE `%s` (%s) %s", table.Name(), prettyColCreateStmts, tableOptionsSQL(table))
 
 	return composedCreateTableStatement
 }
 
+// keysSQL returns the definitions of the primary key, the secondary indexes
+// and the CHECK constraints of the table, in the order MySQL shows them.
+func keysSQL(table sql.Table) []string {
+	quoteNames := func(names []string) string {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = fmt.Sprintf("`%s`", name)
+		}
+		return strings.Join(quoted, ",")
+	}
+
+	var keys []string
+	var pk []string
+	for _, col := range table.Schema() {
+		if col.PrimaryKey {
+			pk = append(pk, col.Name)
+		}
+	}
+	if len(pk) > 0 {
+		keys = append(keys, fmt.Sprintf("PRIMARY KEY (%s)", quoteNames(pk)))
+	}
+
+	if t, ok := table.(sql.IndexTable); ok {
+		indexes := t.Indexes()
+		// Unique indexes come first.
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return indexes[i].Unique && !indexes[j].Unique
+		})
+		for _, index := range indexes {
+			kind := "KEY"
+			if index.Unique {
+				kind = "UNIQUE KEY"
+			}
+			keys = append(keys, fmt.Sprintf("%s `%s` (%s)", kind, index.Name, quoteNames(index.Columns)))
+		}
+	}
+
+	if t, ok := table.(sql.CheckTable); ok {
+		for _, check := range t.Checks() {
+			keys = append(keys, fmt.Sprintf("CONSTRAINT `%s` CHECK (%s)", check.Name, check.Expr))
+		}
+	}
+
+	return keys
+}
+
+// columnTypeSQL returns the MySQL name of the given type, with the length
+// of VARCHAR and BINARY columns, so the statement can be parsed again.
+func columnTypeSQL(t sql.Type) string {
+	switch t {
+	case sql.Int8:
+		return "TINYINT"
+	case sql.Int16:
+		return "SMALLINT"
+	case sql.Int24:
+		return "MEDIUMINT"
+	case sql.Int32:
+		return "INT"
+	case sql.Int64:
+		return "BIGINT"
+	case sql.Uint8:
+		return "TINYINT UNSIGNED"
+	case sql.Uint16:
+		return "SMALLINT UNSIGNED"
+	case sql.Uint24:
+		return "MEDIUMINT UNSIGNED"
+	case sql.Uint32:
+		return "INT UNSIGNED"
+	case sql.Uint64:
+		return "BIGINT UNSIGNED"
+	case sql.Float32:
+		return "FLOAT"
+	case sql.Float64:
+		return "DOUBLE"
+	case sql.Boolean:
+		return "BIT(1)"
+	case sql.UUID:
+		return "BINARY(16)"
+	}
+
+	// VARCHAR columns keep their length.
+	if sql.MaxLength(t) > 0 {
+		return t.String()
+	}
+	return t.Type().String()
+}
+
+// tableOptionsSQL renders the options of the given table in the order MySQL
+// uses. ENGINE and DEFAULT CHARSET are always present, falling back to the
+// defaults for tables created without them.
+func tableOptionsSQL(table sql.Table) string {
+	var options sql.TableOptions
+	if t, ok := table.(sql.TableOptioner); ok {
+		options = t.TableOptions()
+	}
+
+	option := func(name, def string) string {
+		if v, ok := options[name]; ok {
+			return v
+		}
+		return def
+	}
+
+	parts := []string{"ENGINE=" + option(sql.TableOptionEngine, "InnoDB")}
+	if v := option(sql.TableOptionAutoIncrement, ""); v != "" {
+		parts = append(parts, "AUTO_INCREMENT="+v)
+	}
+	parts = append(parts, "DEFAULT CHARSET="+option(sql.TableOptionCharset, "utf8mb4"))
+	if v := option(sql.TableOptionCollate, ""); v != "" {
+		parts = append(parts, "COLLATE="+v)
+	}
+	if v := option(sql.TableOptionRowFormat, ""); v != "" {
+		parts = append(parts, "ROW_FORMAT="+v)
+	}
+	if v, ok := options[sql.TableOptionComment]; ok {
+		parts = append(parts, "COMMENT="+quoteString(v))
+	}
+	if v, ok := options[sql.TableOptionCompression]; ok {
+		parts = append(parts, "COMPRESSION="+quoteString(v))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+var stringQuoter = strings.NewReplacer(`\`, `\\`, `'`, `''`)
+
+func quoteString(s string) string {
+	return "'" + stringQuoter.Replace(s) + "'"
+}
+
 func (i *showCreateTablesIter) Close() error {
 	return nil
 }
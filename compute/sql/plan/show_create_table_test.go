@@ -16,9 +16,11 @@ func TestShowCreateTable(t *testing.T) {
 	table := mem.NewTable(
 		"test-table",
 		sql.Schema{
-			&sql.Column{Name: "baz", Type: sql.Text, Default: "", Nullable: false},
+			&sql.Column{Name: "id", Type: sql.Int64, AutoIncrement: true, PrimaryKey: true},
+			&sql.Column{Name: "baz", Type: sql.Text, Default: "", Nullable: false, Comment: "it's baz"},
 			&sql.Column{Name: "zab", Type: sql.Int32, Default: int32(0), Nullable: true},
 			&sql.Column{Name: "bza", Type: sql.Int64, Default: int64(0), Nullable: true},
+			&sql.Column{Name: "azb", Type: sql.VarChar(20), Default: "none", Nullable: true},
 		})
 
 	db.AddTable(table.Name(), table)
@@ -37,9 +39,12 @@ func TestShowCreateTable(t *testing.T) {
 
 	expected := sql.NewRow(
 		table.Name(),
-		"CREATE TABLE `test-table` (`baz` TEXT NOT NULL,\n"+
-			"`zab` INT32 DEFAULT 0,\n"+
-			"`bza` INT64 DEFAULT 0) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE `test-table` (`id` BIGINT NOT NULL AUTO_INCREMENT,\n"+
+			"`baz` TEXT NOT NULL DEFAULT '' COMMENT 'it''s baz',\n"+
+			"`zab` INT DEFAULT 0,\n"+
+			"`bza` BIGINT DEFAULT 0,\n"+
+			"`azb` VARCHAR(20) DEFAULT 'none',\n"+
+			"PRIMARY KEY (`id`)) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
 	)
 
 	require.Equal(expected, row)
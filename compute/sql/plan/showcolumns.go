@@ -2,11 +2,13 @@ package plan
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/turtacn/guocedb/compute/sql"
 )
 
-// ShowColumns shows the columns details of a table.
+// ShowColumns shows the columns details of a table, as DESCRIBE and SHOW
+// COLUMNS do in MySQL.
 type ShowColumns struct {
 	UnaryNode
 	Full bool
@@ -57,12 +59,13 @@ func (s *ShowColumns) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 	span, ctx := ctx.Span("plan.ShowColumns")
 
 	schema := s.Child.Schema()
+	keys := columnKeys(s.Child)
 	var rows = make([]sql.Row, len(schema))
 	for i, col := range schema {
 		var row sql.Row
 		var collation interface{}
-		if col.Type == sql.Text {
-			collation = defaultCollation
+		if sql.HasCollation(col.Type) {
+			collation = columnCollation(col.Type)
 		}
 
 		var null = "NO"
@@ -70,31 +73,43 @@ func (s *ShowColumns) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 			null = "YES"
 		}
 
-		var defaultVal string
-		if col.Default != nil {
-			defaultVal = fmt.Sprint(col.Default)
+		var defaultVal interface{}
+		switch def := col.Default; {
+		case def == nil:
+		case def == sql.CurrentTimestamp || sql.IsNumber(col.Type):
+			defaultVal = fmt.Sprint(def)
+		default:
+			defaultVal = col.Type.SQL(def).ToString()
+		}
+
+		var extra []string
+		if col.AutoIncrement {
+			extra = append(extra, "auto_increment")
+		}
+		if col.OnUpdateCurrentTimestamp {
+			extra = append(extra, fmt.Sprintf("on update %v", sql.CurrentTimestamp))
 		}
 
 		if s.Full {
 			row = sql.Row{
 				col.Name,
-				col.Type.String(),
+				strings.ToLower(columnTypeSQL(col.Type)),
 				collation,
 				null,
-				"", // Key
+				keys[strings.ToLower(col.Name)],
 				defaultVal,
-				"", // Extra
+				strings.Join(extra, " "),
 				"", // Privileges
-				"", // Comment
+				col.Comment,
 			}
 		} else {
 			row = sql.Row{
 				col.Name,
-				col.Type.String(),
+				strings.ToLower(columnTypeSQL(col.Type)),
 				null,
-				"", // Key
+				keys[strings.ToLower(col.Name)],
 				defaultVal,
-				"", // Extra
+				strings.Join(extra, " "),
 			}
 		}
 
@@ -134,3 +149,60 @@ func (s *ShowColumns) String() string {
 	_ = tp.WriteChildren(s.Child.String())
 	return tp.String()
 }
+
+// columnKeys returns the Key of the columns of the table shown, by their
+// lowercase name: PRI for the columns of the primary key, UNI for the
+// columns with a unique index of their own, and MUL for the first column of
+// the other indexes, which can have the same value in several rows.
+func columnKeys(node sql.Node) map[string]string {
+	keys := make(map[string]string)
+	for _, col := range node.Schema() {
+		if col.PrimaryKey {
+			keys[strings.ToLower(col.Name)] = "PRI"
+		}
+	}
+
+	rt, ok := node.(*ResolvedTable)
+	if !ok {
+		return keys
+	}
+	t := getIndexTable(rt.Table)
+	if t == nil {
+		return keys
+	}
+
+	for _, index := range t.Indexes() {
+		if len(index.Columns) == 0 {
+			continue
+		}
+
+		name := strings.ToLower(index.Columns[0])
+		switch {
+		case keys[name] == "PRI":
+		case index.Unique && len(index.Columns) == 1:
+			keys[name] = "UNI"
+		case keys[name] == "":
+			keys[name] = "MUL"
+		}
+	}
+	return keys
+}
+
+func getIndexTable(table sql.Table) sql.IndexTable {
+	switch t := table.(type) {
+	case sql.IndexTable:
+		return t
+	case sql.TableWrapper:
+		return getIndexTable(t.Underlying())
+	default:
+		return nil
+	}
+}
+
+// columnCollation returns the name of the collation of a text column.
+func columnCollation(t sql.Type) string {
+	if c := sql.CollationOf(t); c != sql.DefaultCollation {
+		return c.Name()
+	}
+	return defaultCollation
+}
@@ -24,9 +24,9 @@ func TestShowColumns(t *testing.T) {
 	require.NoError(err)
 
 	expected := []sql.Row{
-		sql.Row{"a", "TEXT", "NO", "", "", ""},
-		sql.Row{"b", "INT64", "YES", "", "", ""},
-		sql.Row{"c", "INT64", "NO", "", "1", ""},
+		sql.Row{"a", "text", "NO", "", nil, ""},
+		sql.Row{"b", "bigint", "YES", "", nil, ""},
+		sql.Row{"c", "bigint", "NO", "", "1", ""},
 	}
 
 	require.Equal(expected, rows)
@@ -47,9 +47,9 @@ func TestShowColumnsFull(t *testing.T) {
 	require.NoError(err)
 
 	expected := []sql.Row{
-		sql.Row{"a", "TEXT", "utf8_bin", "NO", "", "", "", "", ""},
-		sql.Row{"b", "INT64", nil, "YES", "", "", "", "", ""},
-		sql.Row{"c", "INT64", nil, "NO", "", "1", "", "", ""},
+		sql.Row{"a", "text", "utf8_bin", "NO", "", nil, "", "", ""},
+		sql.Row{"b", "bigint", nil, "YES", "", nil, "", "", ""},
+		sql.Row{"c", "bigint", nil, "NO", "", "1", "", "", ""},
 	}
 
 	require.Equal(expected, rows)
@@ -0,0 +1,93 @@
+package plan
+
+import (
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+var showEnginesSchema = sql.Schema{
+	{Name: "Engine", Type: sql.Text},
+	{Name: "Support", Type: sql.Text},
+	{Name: "Comment", Type: sql.Text},
+	{Name: "Transactions", Type: sql.Text},
+	{Name: "XA", Type: sql.Text},
+	{Name: "Savepoints", Type: sql.Text},
+}
+
+// ShowEngines is a node that shows the storage engines in the registry and
+// what they support.
+type ShowEngines struct {
+	Registry *sql.EngineRegistry
+}
+
+// NewShowEngines creates a new show engines node.
+func NewShowEngines() *ShowEngines {
+	return new(ShowEngines)
+}
+
+// Resolved implements the Resolvable interface.
+func (p *ShowEngines) Resolved() bool {
+	return true
+}
+
+// Children implements the Node interface.
+func (*ShowEngines) Children() []sql.Node {
+	return nil
+}
+
+// Schema implements the Node interface.
+func (*ShowEngines) Schema() sql.Schema {
+	return showEnginesSchema
+}
+
+// RowIter implements the Node interface.
+func (p *ShowEngines) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	if p.Registry == nil {
+		return sql.RowsToRowIter(), nil
+	}
+
+	def, _ := p.Registry.DefaultEngine()
+	engines := p.Registry.StorageEngines()
+	var rows = make([]sql.Row, len(engines))
+	for i, e := range engines {
+		support := "YES"
+		switch {
+		case e.Disabled:
+			support = "NO"
+		case e.Name == def.Name:
+			support = "DEFAULT"
+		}
+
+		rows[i] = sql.NewRow(
+			e.Name,
+			support,
+			e.Comment,
+			yesNo(e.Transactions),
+			yesNo(e.XA),
+			yesNo(e.Savepoints),
+		)
+	}
+
+	return sql.RowsToRowIter(rows...), nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "YES"
+	}
+	return "NO"
+}
+
+// TransformUp implements the Transformable interface.
+func (p *ShowEngines) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	np := *p
+	return f(&np)
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (p *ShowEngines) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	return p, nil
+}
+
+func (p *ShowEngines) String() string {
+	return "ShowEngines"
+}
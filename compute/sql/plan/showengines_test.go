@@ -0,0 +1,26 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+func TestShowEngines(t *testing.T) {
+	require := require.New(t)
+
+	r := sql.NewEngineRegistry()
+	r.RegisterEngine(sql.StorageEngine{Name: "foo", Comment: "foo engine", Transactions: true, Savepoints: true})
+	r.RegisterEngine(sql.StorageEngine{Name: "bar", Comment: "bar engine", XA: true})
+	r.RegisterEngine(sql.StorageEngine{Name: "baz", Comment: "baz engine", Disabled: true})
+
+	n := NewShowEngines()
+	n.Registry = r
+
+	require.Equal([]sql.Row{
+		{"bar", "YES", "bar engine", "NO", "YES", "NO"},
+		{"baz", "NO", "baz engine", "NO", "NO", "NO"},
+		{"foo", "DEFAULT", "foo engine", "YES", "NO", "YES"},
+	}, collectRows(t, n))
+}
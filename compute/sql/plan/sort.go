@@ -259,6 +259,12 @@ func (s *sorter) Less(i, j int) bool {
 			return false
 		}
 
+		// NULL values are equal to each other, so the rows are sorted by
+		// the next fields.
+		if av == nil && bv == nil {
+			continue
+		}
+
 		if av == nil {
 			return sf.NullOrdering == NullsFirst
 		}
@@ -0,0 +1,126 @@
+package plan
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+func init() {
+	// Timestamps and dates are stored in rows as interfaces, so gob needs
+	// to know the type to decode them.
+	gob.Register(time.Time{})
+}
+
+// spillPartitions are files where rows are written split by partition, to
+// be read back later one partition at a time.
+type spillPartitions struct {
+	files   []*os.File
+	writers []*bufio.Writer
+	encs    []*gob.Encoder
+	rows    []int
+}
+
+func newSpillPartitions(dir, name string) (*spillPartitions, error) {
+	p := &spillPartitions{
+		files:   make([]*os.File, joinPartitions),
+		writers: make([]*bufio.Writer, joinPartitions),
+		encs:    make([]*gob.Encoder, joinPartitions),
+		rows:    make([]int, joinPartitions),
+	}
+
+	for i := range p.files {
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%s-%d", name, i)))
+		if err != nil {
+			p.removeAll()
+			return nil, err
+		}
+
+		p.files[i] = f
+		p.writers[i] = bufio.NewWriter(f)
+		p.encs[i] = gob.NewEncoder(p.writers[i])
+	}
+
+	return p, nil
+}
+
+func (p *spillPartitions) write(partition int, row sql.Row) error {
+	if err := p.encs[partition].Encode(row); err != nil {
+		return err
+	}
+
+	p.rows[partition]++
+	return nil
+}
+
+// close flushes and closes the files of all partitions.
+func (p *spillPartitions) close() error {
+	for i, w := range p.writers {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if err := p.files[i].Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// open returns an iterator over the rows written to the given partition,
+// which deletes the file of the partition once closed.
+func (p *spillPartitions) open(partition int) (sql.RowIter, error) {
+	f, err := os.Open(p.files[partition].Name())
+	if err != nil {
+		return nil, err
+	}
+
+	return &spillIter{f: f, dec: gob.NewDecoder(bufio.NewReader(f))}, nil
+}
+
+// remove deletes the file of the given partition.
+func (p *spillPartitions) remove(partition int) {
+	if f := p.files[partition]; f != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+}
+
+// removeAll deletes the files of all partitions.
+func (p *spillPartitions) removeAll() {
+	for i := range p.files {
+		p.remove(i)
+	}
+}
+
+type spillIter struct {
+	f   *os.File
+	dec *gob.Decoder
+}
+
+func (i *spillIter) Next() (sql.Row, error) {
+	var row sql.Row
+	if err := i.dec.Decode(&row); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("unable to read spilled row: %s", err)
+	}
+
+	return row, nil
+}
+
+func (i *spillIter) Close() error {
+	err := i.f.Close()
+	if e := os.Remove(i.f.Name()); err == nil {
+		err = e
+	}
+	return err
+}
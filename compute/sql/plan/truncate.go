@@ -0,0 +1,68 @@
+package plan
+
+import (
+	"fmt"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrTruncateTable is thrown when the database doesn't support truncating
+// tables.
+var ErrTruncateTable = errors.NewKind("tables cannot be truncated on database %s")
+
+// TruncateTable is a node describing the removal of all the rows of a
+// table, which keeps its schema.
+type TruncateTable struct {
+	Database sql.Database
+	table    string
+}
+
+// NewTruncateTable creates a new TruncateTable node.
+func NewTruncateTable(db sql.Database, table string) *TruncateTable {
+	return &TruncateTable{
+		Database: db,
+		table:    table,
+	}
+}
+
+// Resolved implements the Resolvable interface.
+func (t *TruncateTable) Resolved() bool {
+	_, ok := t.Database.(sql.UnresolvedDatabase)
+	return !ok
+}
+
+// RowIter implements the Node interface.
+func (t *TruncateTable) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	d, ok := t.Database.(sql.TruncateAlterable)
+	if !ok {
+		return nil, ErrTruncateTable.New(t.Database.Name())
+	}
+
+	return sql.RowsToRowIter(), d.Truncate(ctx, t.table)
+}
+
+// Schema implements the Node interface.
+func (t *TruncateTable) Schema() sql.Schema { return nil }
+
+// Children implements the Node interface.
+func (t *TruncateTable) Children() []sql.Node { return nil }
+
+// TransformUp implements the Transformable interface.
+func (t *TruncateTable) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	return f(NewTruncateTable(t.Database, t.table))
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (t *TruncateTable) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	return t, nil
+}
+
+func (t *TruncateTable) String() string {
+	return fmt.Sprintf("TruncateTable(%s)", t.table)
+}
+
+// Table returns the name of the table.
+func (t *TruncateTable) Table() string {
+	return t.table
+}
@@ -0,0 +1,333 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/src-d/go-errors.v1"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+// ErrUpdateNotSupported is thrown when a table doesn't support updates.
+var ErrUpdateNotSupported = errors.NewKind("table doesn't support UPDATE")
+
+// ErrUpdateUnexpectedSetField is thrown when the left side of an assignment
+// is not a column of the table being updated.
+var ErrUpdateUnexpectedSetField = errors.NewKind("invalid assignment target: %s")
+
+// Update is a node describing the update of the rows of a table. Its child
+// produces the rows to update, which must have the schema of the table.
+type Update struct {
+	UnaryNode
+	// Columns are the columns being assigned.
+	Columns []sql.Expression
+	// Values are the values assigned to each of the columns.
+	Values []sql.Expression
+	// Checks are the CHECK constraints the updated rows must pass.
+	Checks    []Check
+	returning bool
+}
+
+// NewUpdate creates an Update node.
+func NewUpdate(child sql.Node, columns, values []sql.Expression) *Update {
+	return &Update{
+		UnaryNode: UnaryNode{Child: child},
+		Columns:   columns,
+		Values:    values,
+	}
+}
+
+// Schema implements the Node interface.
+func (p *Update) Schema() sql.Schema {
+	if p.returning {
+		return p.Child.Schema()
+	}
+
+	return sql.Schema{{
+		Name:     "updated",
+		Type:     sql.Int64,
+		Default:  int64(0),
+		Nullable: false,
+	}}
+}
+
+// Resolved implements the Resolvable interface.
+func (p *Update) Resolved() bool {
+	return p.Child.Resolved() &&
+		expressionsResolved(p.Columns...) &&
+		expressionsResolved(p.Values...)
+}
+
+// WithReturning implements the RowReturner interface.
+func (p *Update) WithReturning() sql.Node {
+	np := *p
+	np.returning = true
+	return &np
+}
+
+func getUpdatable(node sql.Node) (sql.Updater, error) {
+	table, ok := targetTable(node)
+	if !ok {
+		return nil, ErrUpdateNotSupported.New()
+	}
+	return getUpdatableTable(table)
+}
+
+func getUpdatableTable(t sql.Table) (sql.Updater, error) {
+	switch t := t.(type) {
+	case sql.Updater:
+		return t, nil
+	case sql.TableWrapper:
+		return getUpdatableTable(t.Underlying())
+	default:
+		return nil, ErrUpdateNotSupported.New()
+	}
+}
+
+// targetTable returns the first table found in the given node, which is the
+// one data modification statements operate on.
+func targetTable(node sql.Node) (sql.Table, bool) {
+	var table sql.Table
+	Inspect(node, func(n sql.Node) bool {
+		if table != nil {
+			return false
+		}
+
+		if rt, ok := n.(*ResolvedTable); ok {
+			table = rt.Table
+			return false
+		}
+
+		return true
+	})
+	return table, table != nil
+}
+
+// Execute updates the rows in the database.
+func (p *Update) Execute(ctx *sql.Context) (int, error) {
+	n, _, err := p.execute(ctx)
+	return n, err
+}
+
+// execute updates the rows in the database and returns the number of rows
+// that were changed along with the new state of all matched rows if the node
+// is returning rows.
+func (p *Update) execute(ctx *sql.Context) (int, []sql.Row, error) {
+	updatable, err := getUpdatable(p.Child)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	fields := make([]*expression.GetField, len(p.Columns))
+	for i, c := range p.Columns {
+		gf, ok := c.(*expression.GetField)
+		if !ok {
+			return 0, nil, ErrUpdateUnexpectedSetField.New(c)
+		}
+		fields[i] = gf
+	}
+
+	// Rows are read before modifying anything, so the changes made by this
+	// statement are not seen by the iterator of the table.
+	iter, err := p.Child.RowIter(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	oldRows, err := sql.RowIterToRows(iter)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	schema := p.Child.Schema()
+	var updated []sql.Row
+	var changed int
+	for i, oldRow := range oldRows {
+		newRow, err := applyUpdates(ctx, fields, p.Values, oldRow, i+1)
+		if err != nil {
+			return changed, updated, err
+		}
+
+		newRow, err = truncateValues(ctx, schema, newRow, i+1)
+		if err != nil {
+			return changed, updated, err
+		}
+
+		equal, err := oldRow.Equals(newRow, schema)
+		if err != nil {
+			return changed, updated, err
+		}
+
+		if !equal {
+			newRow, err = touchOnUpdate(ctx, schema, fields, newRow)
+			if err != nil {
+				return changed, updated, err
+			}
+		}
+
+		if p.returning {
+			updated = append(updated, newRow)
+		}
+
+		if equal {
+			continue
+		}
+
+		if err := evalChecks(ctx, p.Checks, newRow); err != nil {
+			return changed, updated, err
+		}
+
+		if err := updatable.Update(ctx, oldRow, newRow); err != nil {
+			return changed, updated, err
+		}
+
+		changed++
+	}
+
+	return changed, updated, nil
+}
+
+// applyUpdates returns the row with the values assigned to its fields. n is
+// the number of the row in the statement, starting from 1.
+func applyUpdates(
+	ctx *sql.Context,
+	fields []*expression.GetField,
+	values []sql.Expression,
+	row sql.Row,
+	n int,
+) (sql.Row, error) {
+	newRow := row.Copy()
+	for i, f := range fields {
+		// All values are evaluated against the old row, as in standard SQL.
+		v, err := values[i].Eval(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+
+		if v != nil {
+			v, err = convertValue(ctx, f.Name(), f.Type(), v, n)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		newRow[f.Index()] = v
+	}
+
+	return newRow, nil
+}
+
+// touchOnUpdate returns the changed row with the columns declared ON UPDATE
+// CURRENT_TIMESTAMP set to the time the query started, unless they are
+// among the assigned fields. Rows that are left as they were are not
+// touched, as in MySQL.
+func touchOnUpdate(ctx *sql.Context, schema sql.Schema, fields []*expression.GetField, row sql.Row) (sql.Row, error) {
+	for i, col := range schema {
+		if !col.OnUpdateCurrentTimestamp {
+			continue
+		}
+
+		assigned := false
+		for _, f := range fields {
+			if f.Index() == i {
+				assigned = true
+				break
+			}
+		}
+		if assigned {
+			continue
+		}
+
+		v, err := col.Type.Convert(ctx.QueryTime())
+		if err != nil {
+			return nil, err
+		}
+		row[i] = v
+	}
+	return row, nil
+}
+
+// RowIter implements the Node interface.
+func (p *Update) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	n, rows, err := p.execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.returning {
+		return sql.RowsToRowIter(rows...), nil
+	}
+
+	return sql.RowsToRowIter(sql.NewRow(int64(n))), nil
+}
+
+// TransformUp implements the Transformable interface.
+func (p *Update) TransformUp(f sql.TransformNodeFunc) (sql.Node, error) {
+	child, err := p.Child.TransformUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return f(p.with(child, p.Columns, p.Values))
+}
+
+// TransformExpressionsUp implements the Transformable interface.
+func (p *Update) TransformExpressionsUp(f sql.TransformExprFunc) (sql.Node, error) {
+	columns, err := transformExpressionsUp(f, p.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := transformExpressionsUp(f, p.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	child, err := p.Child.TransformExpressionsUp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.with(child, columns, values), nil
+}
+
+// Expressions implements the Expressioner interface.
+func (p *Update) Expressions() []sql.Expression {
+	exprs := make([]sql.Expression, 0, len(p.Columns)+len(p.Values))
+	exprs = append(exprs, p.Columns...)
+	return append(exprs, p.Values...)
+}
+
+// TransformExpressions implements the Expressioner interface.
+func (p *Update) TransformExpressions(f sql.TransformExprFunc) (sql.Node, error) {
+	columns, err := transformExpressionsUp(f, p.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := transformExpressionsUp(f, p.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.with(p.Child, columns, values), nil
+}
+
+func (p *Update) with(child sql.Node, columns, values []sql.Expression) *Update {
+	np := NewUpdate(child, columns, values)
+	np.returning = p.returning
+	np.Checks = p.Checks
+	return np
+}
+
+func (p *Update) String() string {
+	pr := sql.NewTreePrinter()
+	var sets = make([]string, len(p.Columns))
+	for i := range p.Columns {
+		sets[i] = fmt.Sprintf("%s = %s", p.Columns[i], p.Values[i])
+	}
+	_ = pr.WriteNode("Update(%s)", strings.Join(sets, ", "))
+	_ = pr.WriteChildren(p.Child.String())
+	return pr.String()
+}
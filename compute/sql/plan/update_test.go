@@ -0,0 +1,91 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+func newDMLTestTable(t *testing.T) *mem.Table {
+	t.Helper()
+	table := mem.NewTable("test", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "test"},
+		{Name: "name", Type: sql.Text, Source: "test", Nullable: true},
+	})
+
+	for _, r := range []sql.Row{
+		sql.NewRow(int64(1), "a"),
+		sql.NewRow(int64(2), "b"),
+		sql.NewRow(int64(3), "c"),
+	} {
+		require.NoError(t, table.Insert(sql.NewEmptyContext(), r))
+	}
+
+	return table
+}
+
+func TestUpdate(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	table := newDMLTestTable(t)
+
+	update := NewUpdate(
+		NewFilter(
+			expression.NewGreaterThan(
+				expression.NewGetFieldWithTable(0, sql.Int64, "test", "id", false),
+				expression.NewLiteral(int64(1), sql.Int64),
+			),
+			NewResolvedTable(table),
+		),
+		[]sql.Expression{expression.NewGetFieldWithTable(1, sql.Text, "test", "name", true)},
+		[]sql.Expression{expression.NewLiteral("c", sql.Text)},
+	)
+
+	iter, err := update.RowIter(ctx)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	// Only the row with id 2 is changed, the one with id 3 already had "c".
+	require.Equal([]sql.Row{{int64(1)}}, rows)
+
+	iter, err = NewResolvedTable(table).RowIter(ctx)
+	require.NoError(err)
+	rows, err = sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{
+		{int64(1), "a"},
+		{int64(2), "c"},
+		{int64(3), "c"},
+	}, rows)
+}
+
+func TestUpdateReturning(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	table := newDMLTestTable(t)
+
+	node, err := NewReturning(
+		[]sql.Expression{expression.NewGetFieldWithTable(1, sql.Text, "test", "name", true)},
+		NewUpdate(
+			NewFilter(
+				expression.NewEquals(
+					expression.NewGetFieldWithTable(0, sql.Int64, "test", "id", false),
+					expression.NewLiteral(int64(2), sql.Int64),
+				),
+				NewResolvedTable(table),
+			),
+			[]sql.Expression{expression.NewGetFieldWithTable(1, sql.Text, "test", "name", true)},
+			[]sql.Expression{expression.NewLiteral("z", sql.Text)},
+		),
+	)
+	require.NoError(err)
+
+	iter, err := node.RowIter(ctx)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{"z"}}, rows)
+}
@@ -3,6 +3,8 @@ package sql
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -50,6 +52,7 @@ type Process struct {
 	Pid        uint64
 	Connection uint32
 	User       string
+	Host       string
 	Type       ProcessType
 	Query      string
 	Progress   map[string]Progress
@@ -65,11 +68,44 @@ func (p *Process) Seconds() uint64 {
 	return uint64(time.Since(p.StartedAt) / time.Second)
 }
 
+// State returns a description of the progress of the process, made of the
+// progress of each of its items sorted by name.
+func (p *Process) State() string {
+	var status []string
+	for name, progress := range p.Progress {
+		status = append(status, fmt.Sprintf("%s(%s)", name, progress))
+	}
+
+	if len(status) == 0 {
+		return "running"
+	}
+
+	sort.Strings(status)
+	return strings.Join(status, ", ")
+}
+
+// Connection is a client connection of the server.
+type Connection struct {
+	ID       uint32
+	User     string
+	Host     string
+	Database string
+	// Since is when the connection started running its current command,
+	// or when it became idle if it's not running any.
+	Since time.Time
+	// Attributes are the connection attributes the client sent.
+	Attributes map[string]string
+}
+
+// ConnectionLister returns the client connections open in the server.
+type ConnectionLister func() []Connection
+
 // ProcessList is a structure that keeps track of all the processes and their
 // status.
 type ProcessList struct {
 	mu    sync.RWMutex
 	procs map[uint64]*Process
+	conns ConnectionLister
 }
 
 // NewProcessList creates a new process list.
@@ -79,6 +115,28 @@ func NewProcessList() *ProcessList {
 	}
 }
 
+// SetConnectionLister sets the function that lists the client connections
+// of the server, which are shown by SHOW PROCESSLIST along with the
+// processes they run.
+func (pl *ProcessList) SetConnectionLister(l ConnectionLister) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.conns = l
+}
+
+// Connections returns the client connections open in the server, or false
+// if no connection lister was set.
+func (pl *ProcessList) Connections() ([]Connection, bool) {
+	pl.mu.RLock()
+	l := pl.conns
+	pl.mu.RUnlock()
+
+	if l == nil {
+		return nil, false
+	}
+	return l(), true
+}
+
 // ErrPidAlreadyUsed is returned when the pid is already registered.
 var ErrPidAlreadyUsed = errors.NewKind("pid %d is already in use")
 
@@ -109,6 +167,7 @@ func (pl *ProcessList) AddProcess(
 		Query:      query,
 		Progress:   make(map[string]Progress),
 		User:       ctx.Session.Client().User,
+		Host:       ctx.Session.Client().Address,
 		StartedAt:  time.Now(),
 		Kill:       cancel,
 	}
@@ -160,19 +219,25 @@ func (pl *ProcessList) Kill(pid uint64) {
 	pl.Done(pid)
 }
 
-// KillConnection kills all processes that have the same connection as the one
-// of the process with the given process id. If the process does not exist, it
-// will do nothing.
-func (pl *ProcessList) KillConnection(pid uint64) {
-	pl.mu.Lock()
-	defer pl.mu.Unlock()
+// KillQuery cancels the processes of the connection with the given id. They
+// stay in the list until they are done.
+func (pl *ProcessList) KillQuery(conn uint32) {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
 
-	proc, ok := pl.procs[pid]
-	if !ok {
-		return
+	for _, proc := range pl.procs {
+		if proc.Connection == conn {
+			proc.Kill()
+		}
 	}
+}
+
+// KillConnection kills the processes of the connection with the given id
+// and removes them from the list.
+func (pl *ProcessList) KillConnection(conn uint32) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
 
-	conn := proc.Connection
 	for pid, proc := range pl.procs {
 		if proc.Connection == conn {
 			proc.Kill()
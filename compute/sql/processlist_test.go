@@ -32,6 +32,7 @@ func TestProcessList(t *testing.T) {
 			"b": Progress{0, 6},
 		},
 		User:      "foo",
+		Host:      "127.0.0.1:34567",
 		Query:     "SELECT foo",
 		StartedAt: p.procs[ctx.Pid()].StartedAt,
 	}
@@ -57,6 +58,9 @@ func TestProcessList(t *testing.T) {
 	require.Equal(int64(2), p.procs[1].Progress["b"].Done)
 	require.Equal(int64(1), p.procs[2].Progress["foo"].Done)
 
+	require.Equal("a(4/5), b(2/6)", p.procs[1].State())
+	require.Equal("running", (&Process{}).State())
+
 	var expected []Process
 	for _, p := range p.procs {
 		np := *p
@@ -6,6 +6,7 @@ import (
 	"io"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
@@ -25,6 +26,9 @@ type Client struct {
 	User string
 	// Address of the client.
 	Address string
+	// Attributes are the connection attributes the client sent when it
+	// connected, such as program_name and _client_version, if any.
+	Attributes map[string]string
 }
 
 // Session holds the session data.
@@ -176,6 +180,18 @@ func DefaultSessionConfig() map[string]TypedValue {
 		"collation_database":       TypedValue{Text, "utf8_bin"},
 		"ndbinfo_version":          TypedValue{Text, ""},
 		"sql_select_limit":         TypedValue{Int32, math.MaxInt32},
+		"autocommit":               TypedValue{Int64, int64(1)},
+		"version_comment":          TypedValue{Text, "guocedb"},
+		"character_set_client":     TypedValue{Text, "utf8mb4"},
+		"character_set_connection": TypedValue{Text, "utf8mb4"},
+		"character_set_results":    TypedValue{Text, "utf8mb4"},
+		"collation_connection":     TypedValue{Text, "utf8mb4_bin"},
+		"transaction_isolation":    TypedValue{Text, "READ-COMMITTED"},
+		"tx_isolation":             TypedValue{Text, "READ-COMMITTED"},
+		"wait_timeout":             TypedValue{Int64, int64(28800)},
+		"interactive_timeout":      TypedValue{Int64, int64(28800)},
+		"net_write_timeout":        TypedValue{Int64, int64(60)},
+		"lower_case_table_names":   TypedValue{Int32, int32(0)},
 	}
 }
 
@@ -190,13 +206,15 @@ func HasDefaultValue(s Session, key string) (bool, interface{}) {
 
 // NewSession creates a new session with data.
 func NewSession(server, client, user string, id uint32) Session {
+	return NewClientSession(server, Client{Address: client, User: user}, id)
+}
+
+// NewClientSession creates a new session of the given client.
+func NewClientSession(server string, client Client, id uint32) Session {
 	return &BaseSession{
-		id:   id,
-		addr: server,
-		client: Client{
-			Address: client,
-			User:    user,
-		},
+		id:     id,
+		addr:   server,
+		client: client,
 		config: DefaultSessionConfig(),
 	}
 }
@@ -215,7 +233,34 @@ type Context struct {
 	tracer      opentracing.Tracer
 	transaction Transaction
 	currentDB   string
-}
+	// insertID is shared by the contexts derived from this one, so the id
+	// generated while the query runs can be read from the context it was
+	// started with.
+	insertID *atomic.Uint64
+	// rowsExamined is shared the same way, so rows read anywhere in the
+	// query are counted.
+	rowsExamined *atomic.Uint64
+	// rowLock is the lock taken on the rows read from tables.
+	rowLock RowLock
+	// queryTime is the time the query started, which CURRENT_TIMESTAMP
+	// takes all along the query.
+	queryTime time.Time
+}
+
+// RowLock is the lock a query takes on the rows it reads, which its
+// transaction keeps until it ends.
+type RowLock int
+
+const (
+	// NoRowLock reads the rows without locking them.
+	NoRowLock RowLock = iota
+	// RowLockShared is the lock of SELECT ... LOCK IN SHARE MODE, which
+	// keeps other transactions from changing the rows.
+	RowLockShared
+	// RowLockExclusive is the lock of SELECT ... FOR UPDATE, which keeps
+	// other transactions from locking the rows at all.
+	RowLockExclusive
+)
 
 // ContextOption is a function to configure the context.
 type ContextOption func(*Context)
@@ -256,7 +301,7 @@ func NewContext(
 	ctx context.Context,
 	opts ...ContextOption,
 ) *Context {
-	c := &Context{ctx, NewBaseSession(), 0, "", opentracing.NoopTracer{}, nil, ""}
+	c := &Context{ctx, NewBaseSession(), 0, "", opentracing.NoopTracer{}, nil, "", new(atomic.Uint64), new(atomic.Uint64), NoRowLock, time.Now()}
 	for _, opt := range opts {
 		opt(c)
 	}
@@ -272,6 +317,34 @@ func (c *Context) Pid() uint64 { return c.pid }
 // Query returns the query string associated with this context.
 func (c *Context) Query() string { return c.query }
 
+// QueryTime returns the time the query started.
+func (c *Context) QueryTime() time.Time { return c.queryTime }
+
+// InsertID returns the first value generated for an AUTO_INCREMENT column
+// by the query, or 0 if none was.
+func (c *Context) InsertID() uint64 { return c.insertID.Load() }
+
+// SetInsertID records a value generated for an AUTO_INCREMENT column. Only
+// the first one generated by the query is kept, as MySQL reports.
+func (c *Context) SetInsertID(id uint64) { c.insertID.CompareAndSwap(0, id) }
+
+// RowLock returns the lock taken on the rows read from tables.
+func (c *Context) RowLock() RowLock { return c.rowLock }
+
+// WithRowLock returns a copy of the context whose rows read from tables
+// are locked with the given lock.
+func (c *Context) WithRowLock(lock RowLock) *Context {
+	nc := *c
+	nc.rowLock = lock
+	return &nc
+}
+
+// RowsExamined returns the number of rows the query read from tables.
+func (c *Context) RowsExamined() uint64 { return c.rowsExamined.Load() }
+
+// AddRowsExamined records that the query read n more rows from tables.
+func (c *Context) AddRowsExamined(n uint64) { c.rowsExamined.Add(n) }
+
 // Span creates a new tracing span with the given context.
 // It will return the span and a new context that should be passed to all
 // childrens of this span.
@@ -286,12 +359,12 @@ func (c *Context) Span(
 	span := c.tracer.StartSpan(opName, opts...)
 	ctx := opentracing.ContextWithSpan(c.Context, span)
 
-	return span, &Context{ctx, c.Session, c.Pid(), c.Query(), c.tracer, c.transaction, c.currentDB}
+	return span, &Context{ctx, c.Session, c.Pid(), c.Query(), c.tracer, c.transaction, c.currentDB, c.insertID, c.rowsExamined, c.rowLock, c.queryTime}
 }
 
 // WithContext returns a new context with the given underlying context.
 func (c *Context) WithContext(ctx context.Context) *Context {
-	return &Context{ctx, c.Session, c.Pid(), c.Query(), c.tracer, c.transaction, c.currentDB}
+	return &Context{ctx, c.Session, c.Pid(), c.Query(), c.tracer, c.transaction, c.currentDB, c.insertID, c.rowsExamined, c.rowLock, c.queryTime}
 }
 
 // Error adds an error as warning to the session.
@@ -0,0 +1,259 @@
+package sql
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/bits"
+	"strings"
+	"time"
+)
+
+// TableStatistics are the statistics of the rows of a table collected by
+// ANALYZE TABLE, which the optimizer uses to estimate how many rows reading
+// a table returns.
+type TableStatistics struct {
+	// RowCount is the number of rows of the table.
+	RowCount uint64
+	// Columns are the statistics of each column, keyed by its lowercase
+	// name.
+	Columns map[string]*ColumnStatistics
+}
+
+// Column returns the statistics of the column with the given name, or nil if
+// there are none.
+func (s *TableStatistics) Column(name string) *ColumnStatistics {
+	if s == nil {
+		return nil
+	}
+	return s.Columns[strings.ToLower(name)]
+}
+
+// ColumnStatistics are the statistics of the values of a column.
+type ColumnStatistics struct {
+	// NDV is the estimated number of distinct values that aren't NULL.
+	NDV uint64
+	// Min and Max are the smallest and the largest values that aren't NULL,
+	// or nil if all of them are.
+	Min, Max interface{}
+	// NullFraction is the fraction of the rows where the column is NULL.
+	NullFraction float64
+}
+
+// StatisticsTable is a table that can be given the statistics of its rows,
+// which it uses to choose how they are read.
+type StatisticsTable interface {
+	Table
+	// Statistics returns the statistics of the table, or nil if it has
+	// none.
+	Statistics() *TableStatistics
+	// WithStatistics returns the table with the given statistics.
+	WithStatistics(*TableStatistics) Table
+}
+
+// StatisticsOf returns the statistics of a table, looking through the
+// wrappers around it, or nil if it has none.
+func StatisticsOf(t Table) *TableStatistics {
+	for {
+		if st, ok := t.(StatisticsTable); ok {
+			return st.Statistics()
+		}
+
+		w, ok := t.(TableWrapper)
+		if !ok {
+			return nil
+		}
+		t = w.Underlying()
+	}
+}
+
+// CollectStatistics reads all the rows of the table and returns their
+// statistics. The number of distinct values of each column is estimated
+// with a HyperLogLog sketch, so it takes the same memory however many rows
+// the table has.
+func CollectStatistics(ctx *Context, t Table) (*TableStatistics, error) {
+	schema := t.Schema()
+	sketches := make([]*hyperLogLog, len(schema))
+	columns := make([]*ColumnStatistics, len(schema))
+	nulls := make([]uint64, len(schema))
+	for i := range schema {
+		sketches[i] = newHyperLogLog()
+		columns[i] = new(ColumnStatistics)
+	}
+
+	partitions, err := t.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer partitions.Close()
+
+	var count uint64
+	for {
+		p, err := partitions.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := t.PartitionRows(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+
+		for {
+			row, err := rows.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			count++
+			for i, v := range row {
+				if i >= len(schema) {
+					break
+				}
+				if v == nil {
+					nulls[i]++
+					continue
+				}
+
+				typ, c := schema[i].Type, columns[i]
+				sketches[i].add(statisticsKey(typ, v))
+				if c.Min == nil {
+					c.Min, c.Max = v, v
+					continue
+				}
+				if cmp, err := typ.Compare(v, c.Min); err == nil && cmp < 0 {
+					c.Min = v
+				}
+				if cmp, err := typ.Compare(v, c.Max); err == nil && cmp > 0 {
+					c.Max = v
+				}
+			}
+		}
+
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	stats := &TableStatistics{RowCount: count, Columns: make(map[string]*ColumnStatistics, len(schema))}
+	for i, col := range schema {
+		c := columns[i]
+		if count > 0 {
+			c.NullFraction = float64(nulls[i]) / float64(count)
+		}
+		// The sketch can overestimate, but there can't be more distinct
+		// values than values.
+		c.NDV = sketches[i].estimate()
+		if nonNull := count - nulls[i]; c.NDV > nonNull {
+			c.NDV = nonNull
+		}
+		if c.NDV == 0 && c.Min != nil {
+			c.NDV = 1
+		}
+		stats.Columns[strings.ToLower(col.Name)] = c
+	}
+
+	return stats, nil
+}
+
+// statisticsKey returns the bytes a value is counted as distinct by, which
+// are the same for the values of the type that are equal.
+func statisticsKey(typ Type, v interface{}) []byte {
+	var buf [8]byte
+	switch v := v.(type) {
+	case string:
+		return []byte(CollationOf(typ).Key(v))
+	case []byte:
+		return v
+	case time.Time:
+		binary.BigEndian.PutUint64(buf[:], uint64(v.UnixNano()))
+		return buf[:]
+	case int64:
+		binary.BigEndian.PutUint64(buf[:], uint64(v))
+		return buf[:]
+	case int32:
+		binary.BigEndian.PutUint64(buf[:], uint64(v))
+		return buf[:]
+	case uint64:
+		binary.BigEndian.PutUint64(buf[:], v)
+		return buf[:]
+	case uint32:
+		binary.BigEndian.PutUint64(buf[:], uint64(v))
+		return buf[:]
+	case float64:
+		binary.BigEndian.PutUint64(buf[:], math.Float64bits(v))
+		return buf[:]
+	default:
+		return []byte(fmt.Sprintf("%T:%v", v, v))
+	}
+}
+
+// hyperLogLogPrecision is the number of bits of the hashes that choose a
+// register, so the sketches have 2^14 registers and a standard error of
+// about 0.8%.
+const hyperLogLogPrecision = 14
+
+// hyperLogLog is a HyperLogLog sketch estimating the number of distinct
+// values added to it.
+type hyperLogLog struct {
+	registers []uint8
+}
+
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{registers: make([]uint8, 1<<hyperLogLogPrecision)}
+}
+
+// add adds the value with the given key to the sketch.
+func (h *hyperLogLog) add(key []byte) {
+	f := fnv.New64a()
+	_, _ = f.Write(key)
+	hash := mix64(f.Sum64())
+
+	idx := hash >> (64 - hyperLogLogPrecision)
+	rank := uint8(bits.LeadingZeros64(hash<<hyperLogLogPrecision|1<<(hyperLogLogPrecision-1)) + 1)
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// estimate returns the estimated number of distinct values added to the
+// sketch. Small cardinalities are estimated by linear counting, which is
+// more accurate for them.
+func (h *hyperLogLog) estimate() uint64 {
+	m := float64(len(h.registers))
+	var sum float64
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(e))
+}
+
+// mix64 spreads the bits of a hash, as FNV leaves the high bits of the
+// hashes of similar keys alike, which the sketch relies on.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,30 @@
+package sql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	for _, n := range []int{0, 1, 100, 10000, 200000} {
+		h := newHyperLogLog()
+		for i := 0; i < n; i++ {
+			// Every value is added twice, which doesn't count.
+			h.add(statisticsKey(Int64, int64(i)))
+			h.add(statisticsKey(Int64, int64(i)))
+		}
+		require.InDelta(t, float64(n), float64(h.estimate()), float64(n)*0.03+0.5, "%d values", n)
+	}
+
+	// Strings equal in the collation of the column are the same value.
+	ci, err := WithCollation(Text, Utf8mb4GeneralCI)
+	require.NoError(t, err)
+	h := newHyperLogLog()
+	for i := 0; i < 1000; i++ {
+		h.add(statisticsKey(ci, fmt.Sprintf("value%d", i%100)))
+		h.add(statisticsKey(ci, fmt.Sprintf("VALUE%d", i%100)))
+	}
+	require.InDelta(t, 100, float64(h.estimate()), 3)
+}
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/google/uuid"
 	"github.com/spf13/cast"
 	"gopkg.in/src-d/go-errors.v1"
 	"github.com/dolthub/vitess/go/sqltypes"
@@ -25,6 +28,10 @@ var (
 	// ErrConvertingToTime is thrown when a value cannot be converted to a Time
 	ErrConvertingToTime = errors.NewKind("value %q can't be converted to time.Time")
 
+	// ErrValueOutOfRange is returned when a number doesn't fit in the range
+	// of the numeric type it's converted to.
+	ErrValueOutOfRange = errors.NewKind("value %v is out of range for %s")
+
 	// ErrValueNotNil is thrown when a value that was expected to be nil, is not
 	ErrValueNotNil = errors.NewKind("value not nil: %#v")
 
@@ -37,6 +44,13 @@ var (
 
 	// ErrNotArray is returned when the value is not an array.
 	ErrNotArray = errors.NewKind("value of type %T is not an array")
+
+	// ErrInvalidJSONText is returned when a text is not a valid JSON
+	// document.
+	ErrInvalidJSONText = errors.NewKind("invalid JSON text: %q")
+
+	// ErrInvalidUUID is returned when a value is not a UUID.
+	ErrInvalidUUID = errors.NewKind("invalid UUID: %q")
 )
 
 // Schema is the definition of a table.
@@ -107,12 +121,26 @@ type Column struct {
 	// Type is the data type of the column.
 	Type Type
 	// Default contains the default value of the column or nil if it is NULL.
+	// It is CurrentTimestamp if the column defaults to the time the rows
+	// are inserted.
 	Default interface{}
 	// Nullable is true if the column can contain NULL values, or false
 	// otherwise.
 	Nullable bool
 	// Source is the name of the table this column came from.
 	Source string
+	// AutoIncrement is true if the values of the column are generated when
+	// rows are inserted without one.
+	AutoIncrement bool
+	// PrimaryKey is true if the column is part of the primary key of the
+	// table, which is made of these columns in the order of the schema.
+	PrimaryKey bool
+	// Comment is the COMMENT the column was declared with.
+	Comment string
+	// OnUpdateCurrentTimestamp is true if the column was declared ON UPDATE
+	// CURRENT_TIMESTAMP, so it takes the time the rows are changed at
+	// unless it's assigned.
+	OnUpdateCurrentTimestamp bool
 }
 
 // Check ensures the value is correct for this column.
@@ -120,9 +148,42 @@ func (c *Column) Check(v interface{}) bool {
 	if v == nil {
 		return c.Nullable
 	}
+	return c.ValidateValue(v) == nil
+}
 
+// ValidateValue returns why a value that isn't NULL can't be stored in the
+// column, or nil if it can.
+func (c *Column) ValidateValue(v interface{}) error {
+	if v == nil {
+		return nil
+	}
 	_, err := c.Type.Convert(v)
-	return err == nil
+	return err
+}
+
+// CurrentTimestamp is the default of the columns declared with DEFAULT
+// CURRENT_TIMESTAMP or NOW().
+var CurrentTimestamp interface{} = currentTimestamp{}
+
+type currentTimestamp struct{}
+
+func (currentTimestamp) String() string { return "CURRENT_TIMESTAMP" }
+
+// DefaultValue returns the value of the column in the rows inserted
+// without one. A column defaulting to CURRENT_TIMESTAMP takes the time the
+// query started, so all the rows it inserts take the same.
+func (c *Column) DefaultValue(ctx *Context) (interface{}, error) {
+	switch c.Default {
+	case nil:
+		// Columns without a default take the value their type converts
+		// NULL to, which may not convert it at all.
+		v, _ := c.Type.Convert(nil)
+		return v, nil
+	case CurrentTimestamp:
+		return c.Type.Convert(ctx.QueryTime())
+	default:
+		return c.Type.Convert(c.Default)
+	}
 }
 
 // Equals checks whether two columns are equal.
@@ -130,6 +191,9 @@ func (c *Column) Equals(c2 *Column) bool {
 	return c.Name == c2.Name &&
 		c.Source == c2.Source &&
 		c.Nullable == c2.Nullable &&
+		c.AutoIncrement == c2.AutoIncrement &&
+		c.PrimaryKey == c2.PrimaryKey &&
+		c.OnUpdateCurrentTimestamp == c2.OnUpdateCurrentTimestamp &&
 		reflect.DeepEqual(c.Default, c2.Default) &&
 		reflect.DeepEqual(c.Type, c2.Type)
 }
@@ -152,8 +216,21 @@ var (
 	// Null represents the null type.
 	Null nullT
 
-	// Numeric types
-
+	// Numeric types. The integers narrower than 32 bits hold int32 and
+	// uint32 values in their ranges.
+
+	// Int8 is an integer of 8 bits, a TINYINT.
+	Int8 = numberT{t: sqltypes.Int8}
+	// Int16 is an integer of 16 bits, a SMALLINT.
+	Int16 = numberT{t: sqltypes.Int16}
+	// Int24 is an integer of 24 bits, a MEDIUMINT.
+	Int24 = numberT{t: sqltypes.Int24}
+	// Uint8 is an unsigned integer of 8 bits.
+	Uint8 = numberT{t: sqltypes.Uint8}
+	// Uint16 is an unsigned integer of 16 bits.
+	Uint16 = numberT{t: sqltypes.Uint16}
+	// Uint24 is an unsigned integer of 24 bits.
+	Uint24 = numberT{t: sqltypes.Uint24}
 	// Int32 is an integer of 32 bits.
 	Int32 = numberT{t: sqltypes.Int32}
 	// Int64 is an integer of 64 bytes.
@@ -179,8 +256,17 @@ var (
 	JSON jsonT
 	// Blob is a type that holds a chunk of binary data.
 	Blob blobT
+	// UUID is a type that holds UUIDs as their 16 bytes, which is how the
+	// columns declared BINARY(16) are kept.
+	UUID uuidT
 )
 
+// VarChar returns a string type whose values have at most length
+// characters. Longer values are truncated when they are stored.
+func VarChar(length int) Type {
+	return varCharT{length: length}
+}
+
 // Tuple returns a new tuple type with the given element types.
 func Tuple(types ...Type) Type {
 	return tupleT(types)
@@ -196,6 +282,18 @@ func MysqlTypeToType(sql query.Type) (Type, error) {
 	switch sql {
 	case sqltypes.Null:
 		return Null, nil
+	case sqltypes.Int8:
+		return Int8, nil
+	case sqltypes.Int16:
+		return Int16, nil
+	case sqltypes.Int24:
+		return Int24, nil
+	case sqltypes.Uint8:
+		return Uint8, nil
+	case sqltypes.Uint16:
+		return Uint16, nil
+	case sqltypes.Uint24:
+		return Uint24, nil
 	case sqltypes.Int32:
 		return Int32, nil
 	case sqltypes.Int64:
@@ -208,7 +306,8 @@ func MysqlTypeToType(sql query.Type) (Type, error) {
 		return Float32, nil
 	case sqltypes.Float64:
 		return Float64, nil
-	case sqltypes.Timestamp:
+	case sqltypes.Timestamp, sqltypes.Datetime:
+		// Timestamps are kept in UTC, so they already behave as DATETIME.
 		return Timestamp, nil
 	case sqltypes.Date:
 		return Date, nil
@@ -220,6 +319,8 @@ func MysqlTypeToType(sql query.Type) (Type, error) {
 		return JSON, nil
 	case sqltypes.Blob:
 		return Blob, nil
+	case sqltypes.Binary:
+		return UUID, nil
 	default:
 		return nil, ErrTypeNotSupported.New(sql)
 	}
@@ -265,43 +366,173 @@ func (t numberT) Type() query.Type {
 
 // SQL implements Type interface.
 func (t numberT) SQL(v interface{}) sqltypes.Value {
-	switch t.t {
-	case sqltypes.Int32:
+	switch {
+	case IsSigned(t):
 		return sqltypes.MakeTrusted(t.t, strconv.AppendInt(nil, cast.ToInt64(v), 10))
-	case sqltypes.Int64:
-		return sqltypes.MakeTrusted(t.t, strconv.AppendInt(nil, cast.ToInt64(v), 10))
-	case sqltypes.Uint32:
-		return sqltypes.MakeTrusted(t.t, strconv.AppendUint(nil, cast.ToUint64(v), 10))
-	case sqltypes.Uint64:
+	case IsUnsigned(t):
 		return sqltypes.MakeTrusted(t.t, strconv.AppendUint(nil, cast.ToUint64(v), 10))
-	case sqltypes.Float32:
-		return sqltypes.MakeTrusted(t.t, strconv.AppendFloat(nil, cast.ToFloat64(v), 'f', -1, 64))
-	case sqltypes.Float64:
+	case IsDecimal(t):
 		return sqltypes.MakeTrusted(t.t, strconv.AppendFloat(nil, cast.ToFloat64(v), 'f', -1, 64))
 	default:
 		return sqltypes.MakeTrusted(t.t, []byte{})
 	}
 }
 
-// Convert implements Type interface.
+// Convert implements Type interface. Numbers that don't fit in the range of
+// the type fail with ErrValueOutOfRange instead of wrapping around, and the
+// fraction of the floats converted to integers is dropped.
 func (t numberT) Convert(v interface{}) (interface{}, error) {
 	switch t.t {
+	case sqltypes.Int8:
+		return t.convertSigned(v, math.MinInt8, math.MaxInt8)
+	case sqltypes.Int16:
+		return t.convertSigned(v, math.MinInt16, math.MaxInt16)
+	case sqltypes.Int24:
+		return t.convertSigned(v, -1<<23, 1<<23-1)
 	case sqltypes.Int32:
-		return cast.ToInt32E(v)
+		return t.convertSigned(v, math.MinInt32, math.MaxInt32)
 	case sqltypes.Int64:
-		return cast.ToInt64E(v)
+		i, err := t.toInt64(v)
+		if err != nil {
+			return nil, err
+		}
+		return i, nil
+	case sqltypes.Uint8:
+		return t.convertUnsigned(v, math.MaxUint8)
+	case sqltypes.Uint16:
+		return t.convertUnsigned(v, math.MaxUint16)
+	case sqltypes.Uint24:
+		return t.convertUnsigned(v, 1<<24-1)
 	case sqltypes.Uint32:
-		return cast.ToUint32E(v)
+		return t.convertUnsigned(v, math.MaxUint32)
 	case sqltypes.Uint64:
-		return cast.ToUint64E(v)
+		u, err := t.toUint64(v)
+		if err != nil {
+			return nil, err
+		}
+		return u, nil
 	case sqltypes.Float32:
-		return cast.ToFloat32E(v)
+		f, err := cast.ToFloat64E(v)
+		if err != nil {
+			return nil, err
+		}
+		if math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+			return nil, ErrValueOutOfRange.New(v, t)
+		}
+		return float32(f), nil
 	case sqltypes.Float64:
 		return cast.ToFloat64E(v)
 	default:
 		return nil, ErrInvalidType.New(t.t)
 	}
+}
 
+// convertSigned converts v to an int32 between min and max.
+func (t numberT) convertSigned(v interface{}, min, max int64) (interface{}, error) {
+	i, err := t.toInt64(v)
+	if err != nil {
+		return nil, err
+	}
+	if i < min || i > max {
+		return nil, ErrValueOutOfRange.New(v, t)
+	}
+	return int32(i), nil
+}
+
+// convertUnsigned converts v to a uint32 up to max.
+func (t numberT) convertUnsigned(v interface{}, max uint64) (interface{}, error) {
+	u, err := t.toUint64(v)
+	if err != nil {
+		return nil, err
+	}
+	if u > max {
+		return nil, ErrValueOutOfRange.New(v, t)
+	}
+	return uint32(u), nil
+}
+
+// toInt64 converts v to an int64, failing with ErrValueOutOfRange if it
+// doesn't fit.
+func (t numberT) toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case uint64:
+		if n > math.MaxInt64 {
+			return 0, ErrValueOutOfRange.New(v, t)
+		}
+		return int64(n), nil
+	case uint:
+		if uint64(n) > math.MaxInt64 {
+			return 0, ErrValueOutOfRange.New(v, t)
+		}
+		return int64(n), nil
+	case float32:
+		return t.floatToInt64(v, float64(n))
+	case float64:
+		return t.floatToInt64(v, n)
+	case string:
+		s := strings.TrimSpace(n)
+		i, err := strconv.ParseInt(s, 0, 64)
+		if err == nil {
+			return i, nil
+		}
+		if f, ferr := strconv.ParseFloat(s, 64); ferr == nil {
+			return t.floatToInt64(v, f)
+		}
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			return 0, ErrValueOutOfRange.New(v, t)
+		}
+		return 0, err
+	}
+	return cast.ToInt64E(v)
+}
+
+func (t numberT) floatToInt64(v interface{}, f float64) (int64, error) {
+	// 2^63 is the first float64 bigger than any int64.
+	if math.IsNaN(f) || f < math.MinInt64 || f >= 1<<63 {
+		return 0, ErrValueOutOfRange.New(v, t)
+	}
+	return int64(f), nil
+}
+
+// toUint64 converts v to a uint64, failing with ErrValueOutOfRange if it
+// doesn't fit, as negative numbers don't.
+func (t numberT) toUint64(v interface{}) (uint64, error) {
+	switch n := v.(type) {
+	case uint64, uint32, uint16, uint8, uint:
+		return cast.ToUint64E(v)
+	case int64, int32, int16, int8, int:
+		i := cast.ToInt64(n)
+		if i < 0 {
+			return 0, ErrValueOutOfRange.New(v, t)
+		}
+		return uint64(i), nil
+	case float32:
+		return t.floatToUint64(v, float64(n))
+	case float64:
+		return t.floatToUint64(v, n)
+	case string:
+		s := strings.TrimSpace(n)
+		i, err := strconv.ParseUint(s, 0, 64)
+		if err == nil {
+			return i, nil
+		}
+		if f, ferr := strconv.ParseFloat(s, 64); ferr == nil {
+			return t.floatToUint64(v, f)
+		}
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			return 0, ErrValueOutOfRange.New(v, t)
+		}
+		return 0, err
+	}
+	return cast.ToUint64E(v)
+}
+
+func (t numberT) floatToUint64(v interface{}, f float64) (uint64, error) {
+	// 2^64 is the first float64 bigger than any uint64.
+	if math.IsNaN(f) || f <= -1 || f >= 1<<64 {
+		return 0, ErrValueOutOfRange.New(v, t)
+	}
+	return uint64(f), nil
 }
 
 // Compare implements Type interface.
@@ -377,6 +608,9 @@ const TimestampLayout = "2006-01-02 15:04:05"
 // https://github.com/MariaDB/server/blob/mysql-5.5.36/sql-common/my_time.c#L124
 var TimestampLayouts = []string{
 	"2006-01-02",
+	"2006-1-2 15:04:05",
+	"2006-1-2",
+	"2006-01-02T15:04:05",
 	time.RFC3339,
 	"20060102150405",
 	"20060102",
@@ -490,7 +724,9 @@ func (t dateT) Compare(a, b interface{}) (int, error) {
 	return 0, nil
 }
 
-type textT struct{}
+type textT struct {
+	collation Collation
+}
 
 func (t textT) String() string { return "TEXT" }
 
@@ -511,7 +747,35 @@ func (t textT) Convert(v interface{}) (interface{}, error) {
 
 // Compare implements Type interface.
 func (t textT) Compare(a interface{}, b interface{}) (int, error) {
-	return strings.Compare(a.(string), b.(string)), nil
+	return t.collation.Compare(a.(string), b.(string)), nil
+}
+
+type varCharT struct {
+	length    int
+	collation Collation
+}
+
+func (t varCharT) String() string { return fmt.Sprintf("VARCHAR(%d)", t.length) }
+
+// Type implements Type interface.
+func (t varCharT) Type() query.Type {
+	return sqltypes.VarChar
+}
+
+// SQL implements Type interface.
+func (t varCharT) SQL(v interface{}) sqltypes.Value {
+	return sqltypes.MakeTrusted(sqltypes.VarChar, []byte(MustConvert(t, v).(string)))
+}
+
+// Convert implements Type interface. Values are not truncated, so the
+// caller can tell a value didn't fit, see MaxLength.
+func (t varCharT) Convert(v interface{}) (interface{}, error) {
+	return cast.ToStringE(v)
+}
+
+// Compare implements Type interface.
+func (t varCharT) Compare(a interface{}, b interface{}) (int, error) {
+	return t.collation.Compare(a.(string), b.(string)), nil
 }
 
 type booleanT struct{}
@@ -586,6 +850,73 @@ func (t blobT) Compare(a interface{}, b interface{}) (int, error) {
 	return bytes.Compare(a.([]byte), b.([]byte)), nil
 }
 
+type uuidT struct{}
+
+func (t uuidT) String() string { return "BINARY(16)" }
+
+// Type implements Type interface.
+func (t uuidT) Type() query.Type {
+	return sqltypes.Binary
+}
+
+// SQL implements Type interface. UUIDs are given in their canonical text
+// form.
+func (t uuidT) SQL(v interface{}) sqltypes.Value {
+	return sqltypes.MakeTrusted(sqltypes.Binary, []byte(FormatUUID(MustConvert(t, v).([]byte))))
+}
+
+// Convert implements Type interface. Byte slices of 16 bytes are taken as
+// the bytes of a UUID, and strings and any other byte slices as its text,
+// which is either the canonical form, with or without braces or urn:uuid:,
+// or the 32 hex digits alone. The value is the 16 bytes of the UUID.
+func (t uuidT) Convert(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case nil:
+		return []byte(nil), nil
+	case uuid.UUID:
+		return value[:], nil
+	case []byte:
+		if len(value) == 16 {
+			return value, nil
+		}
+		return ParseUUID(string(value))
+	case string:
+		return ParseUUID(value)
+	default:
+		return nil, ErrInvalidUUID.New(fmt.Sprint(v))
+	}
+}
+
+// Compare implements Type interface. UUIDs are ordered by their bytes.
+func (t uuidT) Compare(a interface{}, b interface{}) (int, error) {
+	a, err := t.Convert(a)
+	if err != nil {
+		return 0, err
+	}
+	b, err = t.Convert(b)
+	if err != nil {
+		return 0, err
+	}
+	return bytes.Compare(a.([]byte), b.([]byte)), nil
+}
+
+// ParseUUID returns the 16 bytes of the UUID with the given text.
+func ParseUUID(s string) ([]byte, error) {
+	u, err := uuid.Parse(s)
+	if err != nil {
+		return nil, ErrInvalidUUID.New(s)
+	}
+	return u[:], nil
+}
+
+// FormatUUID returns the canonical text of the UUID with the given 16
+// bytes, like 6ccd780c-baba-1026-9564-5b8c656024db.
+func FormatUUID(b []byte) string {
+	var u uuid.UUID
+	copy(u[:], b)
+	return u.String()
+}
+
 type jsonT struct{}
 
 func (t jsonT) String() string { return "JSON" }
@@ -595,14 +926,34 @@ func (t jsonT) Type() query.Type {
 	return sqltypes.TypeJSON
 }
 
-// SQL implements Type interface.
+// SQL implements Type interface. Documents are given as is, and any other
+// value, like the ones extracted from a document, is encoded as JSON.
 func (t jsonT) SQL(v interface{}) sqltypes.Value {
-	return sqltypes.MakeTrusted(sqltypes.TypeJSON, MustConvert(t, v).([]byte))
+	doc, ok := v.([]byte)
+	if !ok {
+		var err error
+		if doc, err = json.Marshal(v); err != nil {
+			panic(err)
+		}
+	}
+	return sqltypes.MakeTrusted(sqltypes.TypeJSON, doc)
 }
 
-// Convert implements Type interface.
+// Convert implements Type interface. Strings and byte slices are taken as
+// the text of a document, which must be valid UTF-8 encoded JSON. Any other
+// value is encoded as JSON.
 func (t jsonT) Convert(v interface{}) (interface{}, error) {
-	return json.Marshal(v)
+	switch v := v.(type) {
+	case string:
+		return t.Convert([]byte(v))
+	case []byte:
+		if !utf8.Valid(v) || !json.Valid(v) {
+			return nil, ErrInvalidJSONText.New(v)
+		}
+		return v, nil
+	default:
+		return json.Marshal(v)
+	}
 }
 
 // Compare implements Type interface.
@@ -756,15 +1107,15 @@ func IsNumber(t Type) bool {
 
 // IsSigned checks if t is a signed type.
 func IsSigned(t Type) bool {
-	return t == Int32 || t == Int64
+	return t == Int8 || t == Int16 || t == Int24 || t == Int32 || t == Int64
 }
 
 // IsUnsigned checks if t is an unsigned type.
 func IsUnsigned(t Type) bool {
-	return t == Uint32 || t == Uint64
+	return t == Uint8 || t == Uint16 || t == Uint24 || t == Uint32 || t == Uint64
 }
 
-// IsInteger check if t is a (U)Int32/64 type
+// IsInteger check if t is a (U)Int8/16/24/32/64 type
 func IsInteger(t Type) bool {
 	return IsSigned(t) || IsUnsigned(t)
 }
@@ -774,9 +1125,27 @@ func IsDecimal(t Type) bool {
 	return t == Float32 || t == Float64
 }
 
+// IsTime checks if t is a timestamp or a date.
+func IsTime(t Type) bool {
+	return t == Timestamp || t == Date
+}
+
 // IsText checks if t is a text type.
 func IsText(t Type) bool {
-	return t == Text || t == Blob || t == JSON
+	switch t.(type) {
+	case textT, varCharT:
+		return true
+	}
+	return t == Blob || t == JSON
+}
+
+// MaxLength returns the maximum number of characters of the values of t,
+// or 0 if they can have any length.
+func MaxLength(t Type) int {
+	if v, ok := t.(varCharT); ok {
+		return v.length
+	}
+	return 0
 }
 
 // IsTuple checks if t is a tuple type.
@@ -802,3 +1171,56 @@ func NumColumns(t Type) int {
 	}
 	return len(v)
 }
+
+// ColumnTypes returns the types of the columns of a tuple type, or t alone
+// if it's not a tuple.
+func ColumnTypes(t Type) []Type {
+	if v, ok := t.(tupleT); ok {
+		return v
+	}
+	return []Type{t}
+}
+
+// AggregateTypes returns the type of an expression whose value can come from
+// expressions of any of the given types, as the branches of a CASE or the
+// arguments of COALESCE. NULL types are ignored. Numbers aggregate to the
+// widest number type that holds all of them, dates and timestamps to a
+// timestamp, and any other mix of types to text.
+func AggregateTypes(types ...Type) Type {
+	var result Type
+	for _, t := range types {
+		if t == nil || t == Null {
+			continue
+		}
+
+		switch {
+		case result == nil:
+			result = t
+		case result.String() == t.String():
+			// Tuples can't be compared with ==, so types are compared by
+			// their names.
+		case IsNumber(result) && IsNumber(t):
+			switch {
+			case IsDecimal(result) || IsDecimal(t):
+				result = Float64
+			case IsUnsigned(result) && IsUnsigned(t):
+				result = Uint64
+			default:
+				result = Int64
+			}
+		case IsTime(result) && IsTime(t):
+			result = Timestamp
+		case MaxLength(result) > 0 && MaxLength(t) > 0:
+			if MaxLength(t) > MaxLength(result) {
+				result = t
+			}
+		default:
+			result = Text
+		}
+	}
+
+	if result == nil {
+		return Null
+	}
+	return result
+}
@@ -18,6 +18,21 @@ func TestText(t *testing.T) {
 	gt(t, Text, "b", "a")
 }
 
+func TestVarChar(t *testing.T) {
+	typ := VarChar(3)
+	convert(t, typ, 1, "1")
+	// Values are only truncated when they are stored.
+	convert(t, typ, "abcd", "abcd")
+
+	lt(t, typ, "a", "b")
+	eq(t, typ, "a", "a")
+
+	require.True(t, IsText(typ))
+	require.Equal(t, 3, MaxLength(typ))
+	require.Equal(t, 0, MaxLength(Text))
+	require.Equal(t, "VARCHAR(3)", typ.String())
+}
+
 func TestInt32(t *testing.T) {
 	convert(t, Int32, int32(1), int32(1))
 	convert(t, Int32, 1, int32(1))
@@ -56,6 +71,44 @@ func TestInt64(t *testing.T) {
 	gt(t, Int64, int64(3), int64(2))
 }
 
+func TestNumberOverflow(t *testing.T) {
+	convert(t, Int8, 127, int32(127))
+	convert(t, Int8, -128, int32(-128))
+	convertOutOfRange(t, Int8, 128)
+	convertOutOfRange(t, Int8, -129)
+	convert(t, Int16, int64(32767), int32(32767))
+	convertOutOfRange(t, Int16, int64(32768))
+	convertOutOfRange(t, Int16, int64(-32769))
+	convert(t, Int24, "8388607", int32(8388607))
+	convertOutOfRange(t, Int24, "8388608")
+	convertOutOfRange(t, Int24, int64(-8388609))
+	convertOutOfRange(t, Int32, int64(2147483648))
+	convertOutOfRange(t, Int64, uint64(1<<63))
+	convertOutOfRange(t, Int64, "9223372036854775808")
+
+	convert(t, Uint8, 255, uint32(255))
+	convertOutOfRange(t, Uint8, 256)
+	convertOutOfRange(t, Uint8, -1)
+	convertOutOfRange(t, Uint16, 65536)
+	convertOutOfRange(t, Uint24, 16777216)
+	convertOutOfRange(t, Uint32, int64(4294967296))
+	convertOutOfRange(t, Uint64, int64(-1))
+
+	convertOutOfRange(t, Float32, 1e39)
+	convertOutOfRange(t, Int8, 127.5e3)
+
+	// The fraction of floats is truncated.
+	convert(t, Int32, 3.7, int32(3))
+	convert(t, Uint8, 254.9, uint32(254))
+}
+
+func convertOutOfRange(t *testing.T, typ Type, val interface{}) {
+	t.Helper()
+	_, err := typ.Convert(val)
+	require.Error(t, err)
+	require.True(t, ErrValueOutOfRange.Is(err), "%v: %s", val, err)
+}
+
 func TestFloat64(t *testing.T) {
 	require := require.New(t)
 
@@ -189,10 +242,42 @@ func TestBlob(t *testing.T) {
 	gt(t, Blob, []byte("C"), []byte("B"))
 }
 
+func TestUUID(t *testing.T) {
+	require := require.New(t)
+
+	b := []byte{0x6c, 0xcd, 0x78, 0x0c, 0xba, 0xba, 0x10, 0x26, 0x95, 0x64, 0x5b, 0x8c, 0x65, 0x60, 0x24, 0xdb}
+	convert(t, UUID, "6ccd780c-baba-1026-9564-5b8c656024db", b)
+	convert(t, UUID, "{6CCD780C-BABA-1026-9564-5B8C656024DB}", b)
+	convert(t, UUID, "6ccd780cbaba102695645b8c656024db", b)
+	convert(t, UUID, b, b)
+	require.Equal("6ccd780c-baba-1026-9564-5b8c656024db", UUID.SQL(b).ToString())
+
+	for _, v := range []interface{}{"", "not-a-uuid", "6ccd780c-baba-1026-9564-5b8c656024", []byte{1, 2}, 1} {
+		_, err := UUID.Convert(v)
+		require.True(ErrInvalidUUID.Is(err), "%v", v)
+		c := &Column{Name: "id", Type: UUID}
+		require.Error(c.ValidateValue(v))
+		require.False(c.Check(v))
+	}
+
+	lt(t, UUID, "00000000-0000-0000-0000-000000000001", "10000000-0000-0000-0000-000000000000")
+	eq(t, UUID, "6ccd780c-baba-1026-9564-5b8c656024db", b)
+	gt(t, UUID, "ffffffff-0000-0000-0000-000000000000", b)
+}
+
 func TestJSON(t *testing.T) {
-	convert(t, JSON, "", []byte(`""`))
+	convert(t, JSON, `{"a":{"b":[1,2,3]}}`, []byte(`{"a":{"b":[1,2,3]}}`))
+	convert(t, JSON, []byte(`"foo"`), []byte(`"foo"`))
 	convert(t, JSON, []int{1, 2}, []byte("[1,2]"))
 
+	for _, v := range []interface{}{"", `{"a":`, "foo", []byte("\"\xff\"")} {
+		_, err := JSON.Convert(v)
+		require.True(t, ErrInvalidJSONText.Is(err), "%q", v)
+	}
+
+	require.Equal(t, `"foo"`, JSON.SQL("foo").ToString())
+	require.Equal(t, `{"a":1}`, JSON.SQL([]byte(`{"a":1}`)).ToString())
+
 	lt(t, JSON, []byte("A"), []byte("B"))
 	eq(t, JSON, []byte("A"), []byte("A"))
 	gt(t, JSON, []byte("C"), []byte("B"))
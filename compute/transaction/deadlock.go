@@ -0,0 +1,402 @@
+package transaction
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// WaitForGraph records which transactions are blocked waiting for locks
+// held by others. There is a deadlock when the graph has a cycle: none of
+// the transactions in it can go on until one of them is aborted.
+type WaitForGraph struct {
+	mu sync.Mutex
+	// edges maps the id of each blocked transaction to the ids of the
+	// transactions it waits for.
+	edges map[string]map[string]struct{}
+}
+
+// NewWaitForGraph creates an empty wait-for graph.
+func NewWaitForGraph() *WaitForGraph {
+	return &WaitForGraph{edges: make(map[string]map[string]struct{})}
+}
+
+// AddEdge records that waiter is blocked until holder releases its locks.
+func (g *WaitForGraph) AddEdge(waiter, holder string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.edges[waiter] == nil {
+		g.edges[waiter] = make(map[string]struct{})
+	}
+	g.edges[waiter][holder] = struct{}{}
+}
+
+// RemoveEdge records that waiter is no longer blocked by holder.
+func (g *WaitForGraph) RemoveEdge(waiter, holder string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.edges[waiter], holder)
+	if len(g.edges[waiter]) == 0 {
+		delete(g.edges, waiter)
+	}
+}
+
+// Remove removes the transaction and all the edges from and to it.
+func (g *WaitForGraph) Remove(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.edges, id)
+	for waiter, holders := range g.edges {
+		delete(holders, id)
+		if len(holders) == 0 {
+			delete(g.edges, waiter)
+		}
+	}
+}
+
+// FindCycle returns the ids of the transactions of a cycle in the graph,
+// each one waiting for the next and the last one for the first, or nil if
+// there are no cycles.
+func (g *WaitForGraph) FindCycle() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	const (
+		unvisited = iota
+		inPath
+		done
+	)
+	state := make(map[string]int, len(g.edges))
+	var path []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = inPath
+		path = append(path, id)
+		for _, next := range sortedKeys(g.edges[id]) {
+			switch state[next] {
+			case inPath:
+				for i, p := range path {
+					if p == next {
+						return append([]string(nil), path[i:]...)
+					}
+				}
+			case unvisited:
+				if cycle := visit(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+		return nil
+	}
+
+	for _, id := range sortedKeys(g.edges) {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order, so cycles are always searched
+// for in the same way.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LockMode is the kind of lock a transaction takes on a key.
+type LockMode int
+
+const (
+	// LockShared lets other transactions take shared locks on the key too,
+	// but not exclusive ones.
+	LockShared LockMode = iota + 1
+	// LockExclusive keeps other transactions from taking any lock on the
+	// key.
+	LockExclusive
+)
+
+// DefaultLockWaitTimeout is how long a transaction waits for a lock before
+// giving up, unless the manager is given another timeout. It's the default
+// of innodb_lock_wait_timeout in MySQL.
+const DefaultLockWaitTimeout = 50 * time.Second
+
+// lockTable holds the locks transactions take on keys.
+type lockTable struct {
+	mu sync.Mutex
+	// released is signalled when locks are released, a waiting
+	// transaction is aborted or a wait times out.
+	released *sync.Cond
+	// holders maps each locked key to the transactions holding it.
+	holders map[string]*keyLock
+	// held maps the id of each transaction holding or waiting for locks
+	// to the keys it holds.
+	held map[string][]string
+	// txns are the transactions holding or waiting for locks by id.
+	txns map[string]*Transaction
+	// timeout is how long a transaction waits for a lock. Zero means it
+	// waits for as long as it takes.
+	timeout time.Duration
+}
+
+// keyLock are the transactions holding the lock of a key: either a single
+// one holding it exclusively, or any number sharing it.
+type keyLock struct {
+	exclusive *Transaction
+	shared    map[string]*Transaction
+}
+
+// blockers returns the transactions that keep txn from taking a lock with
+// the given mode on the key.
+func (k *keyLock) blockers(txn *Transaction, mode LockMode) []*Transaction {
+	if k.exclusive != nil {
+		if k.exclusive == txn {
+			return nil
+		}
+		return []*Transaction{k.exclusive}
+	}
+	if mode == LockShared {
+		return nil
+	}
+
+	var blockers []*Transaction
+	for _, id := range sortedKeys(k.shared) {
+		if id != txn.ID() {
+			blockers = append(blockers, k.shared[id])
+		}
+	}
+	return blockers
+}
+
+func newLockTable() *lockTable {
+	l := &lockTable{
+		holders: make(map[string]*keyLock),
+		held:    make(map[string][]string),
+		txns:    make(map[string]*Transaction),
+		timeout: DefaultLockWaitTimeout,
+	}
+	l.released = sync.NewCond(&l.mu)
+	return l
+}
+
+// SetLockWaitTimeout changes how long transactions wait for a lock before
+// LockKey and Lock return ErrLockWaitTimeout. Zero means they wait for as
+// long as it takes.
+func (m *Manager) SetLockWaitTimeout(timeout time.Duration) {
+	m.locks.mu.Lock()
+	defer m.locks.mu.Unlock()
+	if timeout < 0 {
+		timeout = 0
+	}
+	m.locks.timeout = timeout
+}
+
+// LockKey takes an exclusive lock on the key for the transaction, which
+// keeps it until it's committed or rolled back. If another transaction
+// holds it, LockKey blocks until it's released. If waiting would be a
+// deadlock, the youngest transaction involved is rolled back, and if that's
+// this one ErrDeadlock is returned.
+func (m *Manager) LockKey(txn *Transaction, key []byte) error {
+	return m.Lock(txn, key, LockExclusive)
+}
+
+// Lock takes a lock with the given mode on the key for the transaction, as
+// LockKey does. A transaction holding a shared lock can take the exclusive
+// one once no other transaction shares it. If the lock isn't free within
+// the lock wait timeout, ErrLockWaitTimeout is returned and the
+// transaction goes on without it.
+func (m *Manager) Lock(txn *Transaction, key []byte, mode LockMode) error {
+	l := m.locks
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if txn.IsClosed() && txn.aborted == nil {
+		return ErrTransactionClosed
+	}
+
+	k := string(key)
+	l.txns[txn.ID()] = txn
+
+	var deadline time.Time
+	for {
+		if txn.aborted != nil {
+			return txn.aborted
+		}
+
+		holder, ok := l.holders[k]
+		if !ok {
+			break
+		}
+		blockers := holder.blockers(txn, mode)
+		if len(blockers) == 0 {
+			break
+		}
+
+		if l.timeout > 0 {
+			if deadline.IsZero() {
+				deadline = time.Now().Add(l.timeout)
+				// The waits are woken up when the timeout passes, so
+				// they notice it even if no lock is released.
+				timer := time.AfterFunc(l.timeout, func() {
+					l.mu.Lock()
+					l.released.Broadcast()
+					l.mu.Unlock()
+				})
+				defer timer.Stop()
+			} else if !time.Now().Before(deadline) {
+				return ErrLockWaitTimeout
+			}
+		}
+
+		for _, b := range blockers {
+			m.waitFor.AddEdge(txn.ID(), b.ID())
+		}
+		if m.detectDeadlocksLocked() == 0 {
+			l.released.Wait()
+		}
+		for _, b := range blockers {
+			m.waitFor.RemoveEdge(txn.ID(), b.ID())
+		}
+	}
+
+	holder, ok := l.holders[k]
+	if !ok {
+		holder = &keyLock{shared: make(map[string]*Transaction)}
+		l.holders[k] = holder
+		l.held[txn.ID()] = append(l.held[txn.ID()], k)
+	} else if holder.exclusive != txn && holder.shared[txn.ID()] == nil {
+		l.held[txn.ID()] = append(l.held[txn.ID()], k)
+	}
+
+	if mode == LockExclusive {
+		holder.exclusive = txn
+		delete(holder.shared, txn.ID())
+	} else if holder.exclusive != txn {
+		holder.shared[txn.ID()] = txn
+	}
+	return nil
+}
+
+// DetectDeadlocks looks for cycles in the wait-for graph and breaks each one
+// by rolling back its youngest transaction, whose pending LockKey returns
+// ErrDeadlock. It returns the number of transactions rolled back. It's
+// called whenever a transaction starts waiting for a lock, and periodically
+// once StartDeadlockDetection is called.
+func (m *Manager) DetectDeadlocks() int {
+	m.locks.mu.Lock()
+	defer m.locks.mu.Unlock()
+	return m.detectDeadlocksLocked()
+}
+
+// detectDeadlocksLocked is DetectDeadlocks with the lock table mutex held.
+func (m *Manager) detectDeadlocksLocked() int {
+	l := m.locks
+	victims := 0
+	for {
+		cycle := m.waitFor.FindCycle()
+		if cycle == nil {
+			break
+		}
+
+		var victim *Transaction
+		for _, id := range cycle {
+			txn := l.txns[id]
+			if txn == nil {
+				continue
+			}
+			if victim == nil || txn.StartTime().After(victim.StartTime()) ||
+				(txn.StartTime().Equal(victim.StartTime()) && txn.ID() > victim.ID()) {
+				victim = txn
+			}
+		}
+		if victim == nil {
+			// Transactions we don't know of can't be aborted; drop the
+			// cycle so the search ends.
+			m.waitFor.Remove(cycle[0])
+			continue
+		}
+
+		// All the transactions in the cycle are blocked in LockKey, so the
+		// victim can be rolled back here.
+		victim.aborted = ErrDeadlock
+		if !victim.IsClosed() {
+			_ = victim.Rollback()
+		}
+		m.releaseLocksLocked(victim)
+		victims++
+	}
+
+	if victims > 0 {
+		l.released.Broadcast()
+	}
+	return victims
+}
+
+// releaseLocks releases the locks held by the transaction, waking up the
+// transactions waiting for them.
+func (m *Manager) releaseLocks(txn *Transaction) {
+	m.locks.mu.Lock()
+	defer m.locks.mu.Unlock()
+	if _, ok := m.locks.txns[txn.ID()]; !ok {
+		return
+	}
+	m.releaseLocksLocked(txn)
+	m.locks.released.Broadcast()
+}
+
+// releaseLocksLocked is releaseLocks with the lock table mutex held, and
+// without waking up the waiting transactions.
+func (m *Manager) releaseLocksLocked(txn *Transaction) {
+	l := m.locks
+	for _, k := range l.held[txn.ID()] {
+		holder, ok := l.holders[k]
+		if !ok {
+			continue
+		}
+		if holder.exclusive == txn {
+			holder.exclusive = nil
+		}
+		delete(holder.shared, txn.ID())
+		if holder.exclusive == nil && len(holder.shared) == 0 {
+			delete(l.holders, k)
+		}
+	}
+	delete(l.held, txn.ID())
+	delete(l.txns, txn.ID())
+	m.waitFor.Remove(txn.ID())
+}
+
+// StartDeadlockDetection runs DetectDeadlocks every interval until the
+// manager is closed, in addition to the checks made when a transaction
+// starts waiting. Calling it again while it runs does nothing.
+func (m *Manager) StartDeadlockDetection(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopDetection != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	m.stopDetection = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.DetectDeadlocks()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,180 @@
+package transaction
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForGraph_FindCycle(t *testing.T) {
+	g := NewWaitForGraph()
+	assert.Nil(t, g.FindCycle())
+
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	assert.Nil(t, g.FindCycle())
+
+	g.AddEdge("c", "a")
+	assert.Equal(t, []string{"a", "b", "c"}, g.FindCycle())
+
+	g.RemoveEdge("c", "a")
+	assert.Nil(t, g.FindCycle())
+
+	g.AddEdge("c", "b")
+	assert.Equal(t, []string{"b", "c"}, g.FindCycle())
+
+	g.Remove("c")
+	assert.Nil(t, g.FindCycle())
+}
+
+func TestManager_Deadlock(t *testing.T) {
+	db := openTestBadger(t)
+	mgr := NewManagerWithDB(db)
+
+	older, err := mgr.Begin(nil)
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+	younger, err := mgr.Begin(nil)
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.LockKey(older, []byte("a")))
+	require.NoError(t, mgr.LockKey(younger, []byte("b")))
+
+	// Each transaction now needs the key the other one holds.
+	run := func(txn *Transaction, key string) error {
+		if err := mgr.LockKey(txn, []byte(key)); err != nil {
+			return err
+		}
+		if err := txn.Set([]byte(key), []byte(txn.ID())); err != nil {
+			return err
+		}
+		return mgr.Commit(txn)
+	}
+
+	var wg sync.WaitGroup
+	var olderErr, youngerErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		olderErr = run(older, "b")
+	}()
+	go func() {
+		defer wg.Done()
+		youngerErr = run(younger, "a")
+	}()
+	wg.Wait()
+
+	// The youngest transaction is the one aborted.
+	assert.NoError(t, olderErr)
+	assert.Equal(t, ErrDeadlock, youngerErr)
+	assert.Equal(t, ErrDeadlock, younger.Commit())
+	assert.NoError(t, mgr.Rollback(younger))
+	assert.Equal(t, 0, mgr.ActiveCount())
+
+	check, err := mgr.Begin(&TransactionOptions{ReadOnly: true})
+	require.NoError(t, err)
+	defer mgr.Rollback(check)
+	val, err := check.Get([]byte("b"))
+	require.NoError(t, err)
+	assert.Equal(t, older.ID(), string(val))
+
+	// The locks of both transactions were released.
+	next, err := mgr.Begin(nil)
+	require.NoError(t, err)
+	require.NoError(t, mgr.LockKey(next, []byte("a")))
+	require.NoError(t, mgr.LockKey(next, []byte("b")))
+	require.NoError(t, mgr.Rollback(next))
+}
+
+func TestManager_LockKeyWaits(t *testing.T) {
+	db := openTestBadger(t)
+	mgr := NewManagerWithDB(db)
+	mgr.StartDeadlockDetection(10 * time.Millisecond)
+	defer mgr.Close()
+
+	holder, err := mgr.Begin(nil)
+	require.NoError(t, err)
+	waiter, err := mgr.Begin(nil)
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.LockKey(holder, []byte("k")))
+
+	locked := make(chan error)
+	go func() {
+		locked <- mgr.LockKey(waiter, []byte("k"))
+	}()
+
+	select {
+	case err := <-locked:
+		t.Fatalf("lock taken while held by another transaction: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, 0, mgr.DetectDeadlocks())
+
+	require.NoError(t, mgr.Commit(holder))
+	select {
+	case err := <-locked:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("lock not taken once released")
+	}
+	require.NoError(t, mgr.Rollback(waiter))
+}
+
+func TestManager_SharedLocks(t *testing.T) {
+	db := openTestBadger(t)
+	mgr := NewManagerWithDB(db)
+
+	a, err := mgr.Begin(nil)
+	require.NoError(t, err)
+	b, err := mgr.Begin(nil)
+	require.NoError(t, err)
+
+	// Shared locks don't block each other.
+	require.NoError(t, a.Lock([]byte("k"), LockShared))
+	require.NoError(t, b.Lock([]byte("k"), LockShared))
+
+	// An exclusive lock waits for all the others sharing the key.
+	locked := make(chan error, 1)
+	go func() { locked <- a.Lock([]byte("k"), LockExclusive) }()
+
+	select {
+	case err := <-locked:
+		t.Fatalf("exclusive lock taken while shared: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, mgr.Commit(b))
+	select {
+	case err := <-locked:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("exclusive lock not taken after the shared one was released")
+	}
+	require.NoError(t, mgr.Commit(a))
+}
+
+func TestManager_LockWaitTimeout(t *testing.T) {
+	db := openTestBadger(t)
+	mgr := NewManagerWithDB(db)
+	mgr.SetLockWaitTimeout(50 * time.Millisecond)
+
+	holder, err := mgr.Begin(nil)
+	require.NoError(t, err)
+	waiter, err := mgr.Begin(nil)
+	require.NoError(t, err)
+
+	require.NoError(t, holder.Lock([]byte("k"), LockExclusive))
+
+	start := time.Now()
+	require.Equal(t, ErrLockWaitTimeout, waiter.Lock([]byte("k"), LockShared))
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// The waiter goes on and gets the lock once it's released.
+	require.NoError(t, mgr.Commit(holder))
+	require.NoError(t, waiter.Lock([]byte("k"), LockExclusive))
+	require.NoError(t, mgr.Commit(waiter))
+}
@@ -1,6 +1,11 @@
 package transaction
 
-import "errors"
+import (
+	"errors"
+
+	cerrors "github.com/turtacn/guocedb/common/errors"
+	"github.com/turtacn/guocedb/common/types/enum"
+)
 
 var (
 	// ErrTransactionClosed is returned when trying to use a closed transaction
@@ -8,13 +13,25 @@ var (
 	// ErrTransactionNotFound is returned when a transaction is not found
 	ErrTransactionNotFound = errors.New("transaction not found")
 	// ErrReadOnlyTransaction is returned when trying to write in a read-only transaction
-	ErrReadOnlyTransaction = errors.New("cannot write in read-only transaction")
+	ErrReadOnlyTransaction = cerrors.NewCoded(enum.ReadOnlyTransaction, "cannot write in read-only transaction")
 	// ErrNestedTransaction is returned when trying to start a nested transaction
 	ErrNestedTransaction = errors.New("nested transactions not supported")
 	// ErrTransactionConflict is returned when a transaction conflict is detected
-	ErrTransactionConflict = errors.New("transaction conflict detected")
+	ErrTransactionConflict = cerrors.NewCoded(enum.TransactionConflict, "transaction conflict detected")
+	// ErrDeadlock is returned when a transaction is rolled back to break a
+	// deadlock
+	ErrDeadlock = cerrors.NewCoded(enum.Deadlock, "deadlock found when trying to get lock; try restarting transaction")
+	// ErrLockWaitTimeout is returned when a lock is not released by the
+	// transaction holding it within the lock wait timeout
+	ErrLockWaitTimeout = cerrors.NewCoded(enum.LockWaitTimeout, "lock wait timeout exceeded; try restarting transaction")
 	// ErrKeyNotFound is returned when a key is not found
 	ErrKeyNotFound = errors.New("key not found")
 	// ErrNoActiveTransaction is returned when no active transaction exists
 	ErrNoActiveTransaction = errors.New("no active transaction")
+	// ErrXANotFound is returned when there is no XA transaction with the given XID
+	ErrXANotFound = errors.New("unknown XID")
+	// ErrXAExists is returned when starting a XA transaction with an XID already in use
+	ErrXAExists = errors.New("XID already exists")
+	// ErrXAState is returned when a XA command can't be executed in the state of the XA transaction
+	ErrXAState = errors.New("the command cannot be executed in the current state of the XA transaction")
 )
\ No newline at end of file
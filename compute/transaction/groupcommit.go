@@ -0,0 +1,139 @@
+package transaction
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// GroupCommitOptions configures how commits are grouped before they are
+// synced to disk.
+type GroupCommitOptions struct {
+	// Window is how long the first commit of a group waits for others to
+	// join it before the group is synced.
+	Window time.Duration
+	// MaxSize is the number of commits that syncs a group without waiting
+	// for the rest of the window. Zero means no limit.
+	MaxSize int
+}
+
+// EnableGroupCommit makes commits wait for a sync that is shared with the
+// other commits made in the same window, instead of syncing each one on
+// its own. The database should be opened with SyncWrites disabled, as the
+// group sync is what makes commits durable. A commit only returns once the
+// sync of its group has finished, with the error of that sync if it failed.
+func (m *Manager) EnableGroupCommit(opts GroupCommitOptions) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.group = &groupCommit{db: m.db, opts: opts}
+}
+
+// groupCommit batches the syncs of concurrent commits. The first commit of
+// a group leads it: it waits for the window and then syncs once for all
+// the commits that joined.
+type groupCommit struct {
+	db   *badger.DB
+	opts GroupCommitOptions
+
+	mu      sync.Mutex
+	pending *commitGroup
+	syncs   int
+}
+
+type commitGroup struct {
+	size int
+	full chan struct{}
+	done chan struct{}
+	err  error
+}
+
+// sync waits until the changes committed before the call are on disk.
+func (g *groupCommit) sync() error {
+	g.mu.Lock()
+	group := g.pending
+	if group != nil {
+		group.size++
+		if group.size == g.opts.MaxSize {
+			close(group.full)
+		}
+		g.mu.Unlock()
+
+		<-group.done
+		return group.err
+	}
+
+	group = &commitGroup{
+		size: 1,
+		full: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	g.pending = group
+	g.mu.Unlock()
+
+	if g.opts.MaxSize != 1 {
+		timer := time.NewTimer(g.opts.Window)
+		select {
+		case <-timer.C:
+		case <-group.full:
+			timer.Stop()
+		}
+	}
+
+	g.mu.Lock()
+	g.pending = nil
+	g.syncs++
+	g.mu.Unlock()
+
+	group.err = syncWAL(g.db)
+	close(group.done)
+	return group.err
+}
+
+// syncCount returns the number of syncs made so far.
+func (g *groupCommit) syncCount() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.syncs
+}
+
+// syncWAL syncs the changes written to the database to disk. Badger's own
+// Sync only syncs the value log, while small values are only written to
+// the write-ahead logs of the memtables, so those are synced too.
+func syncWAL(db *badger.DB) error {
+	opts := db.Opts()
+	if opts.InMemory {
+		return nil
+	}
+
+	if err := db.Sync(); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(opts.Dir, "*.mem"))
+	if err != nil {
+		return err
+	}
+
+	for _, name := range files {
+		f, err := os.Open(name)
+		if os.IsNotExist(err) {
+			// The memtable was flushed in the meantime.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = f.Sync()
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
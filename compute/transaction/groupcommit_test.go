@@ -0,0 +1,146 @@
+package transaction
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupCommit(t *testing.T) {
+	require := require.New(t)
+
+	dir := t.TempDir()
+	opts := badger.DefaultOptions(dir).WithLogger(nil).WithSyncWrites(false)
+	db, err := badger.Open(opts)
+	require.NoError(err)
+
+	mgr := NewManagerWithDB(db)
+	mgr.EnableGroupCommit(GroupCommitOptions{Window: 20 * time.Millisecond, MaxSize: 8})
+
+	const commits = 32
+	var wg sync.WaitGroup
+	errs := make([]error, commits)
+	for i := 0; i < commits; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			txn, err := mgr.Begin(nil)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			key := []byte(fmt.Sprintf("key%02d", i))
+			if err := txn.Set(key, []byte(fmt.Sprint(i))); err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = mgr.Commit(txn)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(err)
+	}
+	require.Less(mgr.group.syncCount(), commits)
+	require.Equal(0, mgr.ActiveCount())
+
+	// A commit that conflicts fails on its own, without a sync.
+	syncs := mgr.group.syncCount()
+	txn1, err := mgr.Begin(nil)
+	require.NoError(err)
+	txn2, err := mgr.Begin(nil)
+	require.NoError(err)
+	_, err = txn1.Get([]byte("key00"))
+	require.NoError(err)
+	require.NoError(txn1.Set([]byte("key00"), []byte("txn1")))
+	require.NoError(txn2.Set([]byte("key00"), []byte("txn2")))
+	require.NoError(mgr.Commit(txn2))
+	require.Equal(ErrTransactionConflict, mgr.Commit(txn1))
+	require.Equal(syncs+1, mgr.group.syncCount())
+
+	// Read-only transactions don't wait for a sync either.
+	txn, err := mgr.Begin(&TransactionOptions{ReadOnly: true})
+	require.NoError(err)
+	require.NoError(mgr.Commit(txn))
+	require.Equal(syncs+1, mgr.group.syncCount())
+
+	require.NoError(db.Close())
+	db, err = badger.Open(opts)
+	require.NoError(err)
+	defer db.Close()
+
+	err = db.View(func(txn *badger.Txn) error {
+		for i := 0; i < commits; i++ {
+			item, err := txn.Get([]byte(fmt.Sprintf("key%02d", i)))
+			if err != nil {
+				return err
+			}
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			want := fmt.Sprint(i)
+			if i == 0 {
+				want = "txn2"
+			}
+			if string(val) != want {
+				return fmt.Errorf("key%02d: got %q, want %q", i, val, want)
+			}
+		}
+		return nil
+	})
+	require.NoError(err)
+}
+
+func BenchmarkConcurrentCommit(b *testing.B) {
+	run := func(b *testing.B, syncWrites bool, group *GroupCommitOptions) {
+		opts := badger.DefaultOptions(b.TempDir()).WithLogger(nil).WithSyncWrites(syncWrites)
+		db, err := badger.Open(opts)
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer db.Close()
+
+		mgr := NewManagerWithDB(db)
+		if group != nil {
+			mgr.EnableGroupCommit(*group)
+		}
+
+		var n int64
+		var mu sync.Mutex
+		b.SetParallelism(16)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				mu.Lock()
+				n++
+				key := []byte(fmt.Sprintf("key%d", n))
+				mu.Unlock()
+
+				txn, err := mgr.Begin(nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := txn.Set(key, key); err != nil {
+					b.Fatal(err)
+				}
+				if err := mgr.Commit(txn); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	b.Run("sync writes", func(b *testing.B) {
+		run(b, true, nil)
+	})
+	b.Run("group commit", func(b *testing.B) {
+		run(b, false, &GroupCommitOptions{Window: time.Millisecond, MaxSize: 64})
+	})
+}
@@ -35,9 +35,11 @@ func (l IsolationLevel) String() string {
 	}
 }
 
-// ParseIsolationLevel parses a string into an IsolationLevel
+// ParseIsolationLevel parses a string into an IsolationLevel. The words
+// may also be joined by hyphens, as in the values of the
+// transaction_isolation variable.
 func ParseIsolationLevel(s string) (IsolationLevel, error) {
-	switch strings.ToUpper(strings.TrimSpace(s)) {
+	switch strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), "-", " ")) {
 	case "READ UNCOMMITTED":
 		return LevelReadUncommitted, nil
 	case "READ COMMITTED":
@@ -198,6 +198,70 @@ func TestIsolationLevels(t *testing.T) {
 	}
 }
 
+// TestIsolationLevelStatements verifies that REPEATABLE READ transactions
+// read the same data in every statement, while READ COMMITTED ones see the
+// changes committed before each statement.
+func TestIsolationLevelStatements(t *testing.T) {
+	db := openTestBadger(t)
+	mgr := NewManagerWithDB(db)
+
+	set := func(value string) {
+		txn, err := mgr.Begin(nil)
+		require.NoError(t, err)
+		require.NoError(t, txn.Set([]byte("key1"), []byte(value)))
+		require.NoError(t, mgr.Commit(txn))
+	}
+	get := func(txn *Transaction, key string) string {
+		item, err := txn.Snapshot().Get([]byte(key))
+		require.NoError(t, err)
+		val, err := item.ValueCopy(nil)
+		require.NoError(t, err)
+		return string(val)
+	}
+
+	set("1")
+	rr, err := mgr.Begin(&TransactionOptions{IsolationLevel: LevelRepeatableRead})
+	require.NoError(t, err)
+	rc, err := mgr.Begin(&TransactionOptions{IsolationLevel: LevelReadCommitted})
+	require.NoError(t, err)
+	require.NoError(t, rc.Set([]byte("key2"), []byte("mine")))
+
+	for _, txn := range []*Transaction{rr, rc} {
+		require.NoError(t, txn.BeginStatement())
+		assert.Equal(t, "1", get(txn, "key1"))
+	}
+
+	set("2")
+	for _, txn := range []*Transaction{rr, rc} {
+		require.NoError(t, txn.BeginStatement())
+	}
+	assert.Equal(t, "1", get(rr, "key1"))
+	assert.Equal(t, "2", get(rc, "key1"))
+
+	// The changes of the transaction are kept across statements.
+	val, err := rc.Get([]byte("key2"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("mine"), val)
+	val, err = rc.Get([]byte("key1"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("2"), val)
+
+	require.NoError(t, mgr.Commit(rc))
+	require.NoError(t, mgr.Commit(rr))
+	assert.Nil(t, rr.Snapshot())
+
+	check, err := mgr.Begin(nil)
+	require.NoError(t, err)
+	val, err = check.Get([]byte("key2"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("mine"), val)
+	require.NoError(t, mgr.Rollback(check))
+
+	level, err := ParseIsolationLevel("REPEATABLE-READ")
+	require.NoError(t, err)
+	assert.Equal(t, LevelRepeatableRead, level)
+}
+
 // TestKeyNotFound verifies proper error handling for missing keys
 func TestKeyNotFound(t *testing.T) {
 	db := openTestBadger(t)
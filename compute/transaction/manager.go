@@ -15,8 +15,13 @@ type Manager struct {
 	storage           interfaces.Storage
 	db                *badger.DB
 	activeTxns        map[string]*Transaction
+	xaTxns            map[XID]*xaTransaction
 	mu                sync.RWMutex
 	defaultIsolation  IsolationLevel
+	locks             *lockTable
+	waitFor           *WaitForGraph
+	stopDetection     chan struct{}
+	group             *groupCommit
 }
 
 // NewManager creates a new transaction manager.
@@ -24,7 +29,10 @@ func NewManager(storage interfaces.Storage) *Manager {
 	return &Manager{
 		storage:          storage,
 		activeTxns:       make(map[string]*Transaction),
+		xaTxns:           make(map[XID]*xaTransaction),
 		defaultIsolation: LevelReadCommitted,
+		locks:            newLockTable(),
+		waitFor:          NewWaitForGraph(),
 	}
 }
 
@@ -33,7 +41,10 @@ func NewManagerWithDB(db *badger.DB) *Manager {
 	return &Manager{
 		db:               db,
 		activeTxns:       make(map[string]*Transaction),
+		xaTxns:           make(map[XID]*xaTransaction),
 		defaultIsolation: LevelReadCommitted,
+		locks:            newLockTable(),
+		waitFor:          NewWaitForGraph(),
 	}
 }
 
@@ -55,6 +66,7 @@ func (m *Manager) NewTransaction(ctx *sql.Context, readOnly bool) (interfaces.Tr
 	}
 	
 	txn := NewTransaction(m.db, opts)
+	txn.manager = m
 	
 	m.mu.Lock()
 	m.activeTxns[txn.ID()] = txn
@@ -74,6 +86,7 @@ func (m *Manager) Begin(opts *TransactionOptions) (*Transaction, error) {
 	}
 	
 	txn := NewTransaction(m.db, *opts)
+	txn.manager = m
 	
 	m.mu.Lock()
 	m.activeTxns[txn.ID()] = txn
@@ -88,21 +101,36 @@ func (m *Manager) BeginSQL(ctx *sql.Context) (interfaces.Transaction, error) {
 	return m.NewTransaction(ctx, false)
 }
 
-// Commit commits a transaction.
+// Commit commits a transaction. With group commit enabled it returns once
+// the changes are synced to disk.
 func (m *Manager) Commit(txn *Transaction) error {
 	if txn == nil {
 		return nil
 	}
 	
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	
 	if _, exists := m.activeTxns[txn.ID()]; !exists {
+		m.mu.Unlock()
 		return ErrTransactionNotFound
 	}
+	if txn.xid != nil {
+		m.mu.Unlock()
+		return ErrXAState
+	}
 	
 	err := txn.Commit()
+	txn.releaseSnapshot()
 	delete(m.activeTxns, txn.ID())
+	group := m.group
+	m.mu.Unlock()
+	
+	// With group commit the sync is waited for outside the manager lock,
+	// so that other commits can join the group meanwhile. The rows stay
+	// locked until the changes are durable.
+	if err == nil && group != nil && len(txn.writes) > 0 {
+		err = group.sync()
+	}
+	m.releaseLocks(txn)
 	return err
 }
 
@@ -118,9 +146,18 @@ func (m *Manager) Rollback(txn *Transaction) error {
 	if _, exists := m.activeTxns[txn.ID()]; !exists {
 		return ErrTransactionNotFound
 	}
+	if txn.xid != nil {
+		return ErrXAState
+	}
 	
 	err := txn.Rollback()
+	if err == ErrTransactionClosed && txn.aborted != nil {
+		// Deadlock victims are already rolled back.
+		err = nil
+	}
+	txn.releaseSnapshot()
 	delete(m.activeTxns, txn.ID())
+	m.releaseLocks(txn)
 	return err
 }
 
@@ -142,22 +179,17 @@ func (m *Manager) ActiveCount() int {
 func (m *Manager) Close() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.stopDetection != nil {
+		close(m.stopDetection)
+		m.stopDetection = nil
+	}
 	// Roll back all active transactions
 	for _, txn := range m.activeTxns {
 		txn.Rollback()
+		txn.releaseSnapshot()
+		m.releaseLocks(txn)
 	}
 	m.activeTxns = make(map[string]*Transaction)
+	m.xaTxns = make(map[XID]*xaTransaction)
 	return nil
 }
-
-// The following are placeholders for more advanced transaction management features.
-
-// CoordinatedCommit performs a two-phase commit for a distributed transaction.
-func (m *Manager) CoordinatedCommit() error {
-	return errors.ErrNotImplemented
-}
-
-// DetectDeadlocks runs a deadlock detection algorithm.
-func (m *Manager) DetectDeadlocks() {
-	// Placeholder for deadlock detection logic.
-}
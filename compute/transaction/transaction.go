@@ -22,16 +22,40 @@ type Transaction struct {
 	isolationLevel IsolationLevel
 	readOnly       bool
 	badgerTxn      *badger.Txn
-	db             *badger.DB
+	// snapshot is the view of the data the statements of the transaction
+	// read. REPEATABLE READ and SERIALIZABLE transactions read the one
+	// taken when they begin for their whole life, while READ COMMITTED
+	// ones take a new one for each statement.
+	snapshot *badger.Txn
+	db       *badger.DB
 	committed      bool
 	rolledBack     bool
+	writes         []Write
+	xid            *XID
+	// aborted is the error the transaction was rolled back with by the
+	// manager, such as ErrDeadlock.
+	aborted error
+	// manager is the manager that began the transaction, whose locks it
+	// takes, or nil if it was created on its own.
+	manager *Manager
+	// fences are the versions of the keys fenced by the transaction when
+	// it first read them, 0 for those that didn't exist.
+	fences map[string]uint64
+}
+
+// Write is a change made by a transaction. Transactions keep the changes
+// they make so they can be persisted when the transaction is prepared.
+type Write struct {
+	Key    []byte
+	Value  []byte `json:",omitempty"`
+	Delete bool   `json:",omitempty"`
 }
 
 // NewTransaction creates a new transaction with the given options
 func NewTransaction(db *badger.DB, opts TransactionOptions) *Transaction {
 	id := uuid.New().String()
 	badgerTxn := db.NewTransaction(!opts.ReadOnly) // update=true for read-write
-	return &Transaction{
+	t := &Transaction{
 		id:             id,
 		startTime:      time.Now(),
 		isolationLevel: opts.IsolationLevel,
@@ -39,6 +63,10 @@ func NewTransaction(db *badger.DB, opts TransactionOptions) *Transaction {
 		badgerTxn:      badgerTxn,
 		db:             db,
 	}
+	// The snapshot is read-only, so reading it doesn't make the
+	// transaction conflict with the ones changing what it read.
+	t.snapshot = db.NewTransaction(false)
+	return t
 }
 
 // String returns the string representation of the transaction
@@ -53,10 +81,14 @@ func (t *Transaction) IsReadOnly() bool {
 
 // Commit commits the transaction
 func (t *Transaction) Commit() error {
+	if t.aborted != nil {
+		return t.aborted
+	}
 	if t.committed || t.rolledBack {
 		return ErrTransactionClosed
 	}
 	err := t.badgerTxn.Commit()
+	t.releaseSnapshot()
 	if err != nil {
 		// Check for BadgerDB conflict errors
 		if err == badger.ErrConflict {
@@ -77,9 +109,112 @@ func (t *Transaction) Rollback() error {
 	}
 	t.badgerTxn.Discard()
 	t.rolledBack = true
+	// Deadlock victims are rolled back while their statement may still be
+	// reading the snapshot, so the manager releases it once the statement
+	// ends.
+	if t.aborted == nil {
+		t.releaseSnapshot()
+	}
 	return nil
 }
 
+// releaseSnapshot discards the snapshot of the transaction, if it has one.
+func (t *Transaction) releaseSnapshot() {
+	if t.snapshot != nil {
+		t.snapshot.Discard()
+		t.snapshot = nil
+	}
+}
+
+// Snapshot returns the read-only badger transaction with the view of the
+// data the statements of the transaction read, or nil if it's closed.
+func (t *Transaction) Snapshot() *badger.Txn {
+	return t.snapshot
+}
+
+// BeginStatement is called before each statement run in the transaction.
+// READ COMMITTED and READ UNCOMMITTED transactions see the data committed
+// before the statement, so they take a new snapshot, and move their
+// changes to a badger transaction reading it. Badger has no dirty reads,
+// so READ UNCOMMITTED is READ COMMITTED.
+func (t *Transaction) BeginStatement() error {
+	if t.isolationLevel >= LevelRepeatableRead || t.committed || t.rolledBack {
+		return nil
+	}
+
+	badgerTxn := t.db.NewTransaction(!t.readOnly)
+	for _, w := range t.writes {
+		var err error
+		if w.Delete {
+			err = badgerTxn.Delete(w.Key)
+		} else {
+			err = badgerTxn.Set(w.Key, w.Value)
+		}
+		if err != nil {
+			badgerTxn.Discard()
+			return err
+		}
+	}
+
+	// The new badger transaction only conflicts with the changes of the
+	// fenced keys committed after it began, so the earlier ones are
+	// checked here.
+	for key, version := range t.fences {
+		v, err := keyVersion(badgerTxn, []byte(key))
+		if err == nil && v != version {
+			err = ErrTransactionConflict
+		}
+		if err != nil {
+			badgerTxn.Discard()
+			return err
+		}
+	}
+
+	t.badgerTxn.Discard()
+	t.badgerTxn = badgerTxn
+	t.releaseSnapshot()
+	t.snapshot = t.db.NewTransaction(false)
+	return nil
+}
+
+// Fence makes the transaction fail to commit with ErrTransactionConflict
+// if another transaction commits a change of the key after it began.
+// Storage engines fence the metadata of the tables a transaction writes,
+// so its changes are not committed to a table that was replaced meanwhile.
+func (t *Transaction) Fence(key []byte) error {
+	if t.committed || t.rolledBack {
+		return ErrTransactionClosed
+	}
+	if _, ok := t.fences[string(key)]; ok {
+		return nil
+	}
+
+	// Reading the key makes badger check it's not changed by the
+	// transactions committed before this one.
+	version, err := keyVersion(t.badgerTxn, key)
+	if err != nil {
+		return err
+	}
+	if t.fences == nil {
+		t.fences = make(map[string]uint64)
+	}
+	t.fences[string(key)] = version
+	return nil
+}
+
+// keyVersion returns the version of key as seen by txn, 0 if it doesn't
+// exist.
+func keyVersion(txn *badger.Txn, key []byte) (uint64, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return item.Version(), nil
+}
+
 // BadgerTxn returns the underlying Badger transaction for storage layer use
 func (t *Transaction) BadgerTxn() *badger.Txn {
 	return t.badgerTxn
@@ -128,7 +263,14 @@ func (t *Transaction) Set(key, value []byte) error {
 	if t.readOnly {
 		return ErrReadOnlyTransaction
 	}
-	return t.badgerTxn.Set(key, value)
+	if err := t.badgerTxn.Set(key, value); err != nil {
+		return err
+	}
+	t.writes = append(t.writes, Write{
+		Key:   append([]byte(nil), key...),
+		Value: append([]byte(nil), value...),
+	})
+	return nil
 }
 
 // Delete removes a key within the transaction
@@ -139,7 +281,38 @@ func (t *Transaction) Delete(key []byte) error {
 	if t.readOnly {
 		return ErrReadOnlyTransaction
 	}
-	return t.badgerTxn.Delete(key)
+	if err := t.badgerTxn.Delete(key); err != nil {
+		return err
+	}
+	t.writes = append(t.writes, Write{Key: append([]byte(nil), key...), Delete: true})
+	return nil
+}
+
+// Writes returns the changes made by the transaction, in the order they
+// were made.
+func (t *Transaction) Writes() []Write {
+	return t.writes
+}
+
+// XID returns the id of the XA transaction, and false if the transaction
+// was not started with XA START.
+func (t *Transaction) XID() (XID, bool) {
+	if t.xid == nil {
+		return XID{}, false
+	}
+	return *t.xid, true
+}
+
+// Lock takes a lock with the given mode on the key, which the transaction
+// keeps until it's committed or rolled back. It blocks while other
+// transactions hold conflicting locks, as Manager.Lock does. Transactions
+// not begun by a manager don't share locks with any other, so they take
+// none.
+func (t *Transaction) Lock(key []byte, mode LockMode) error {
+	if t.manager == nil {
+		return nil
+	}
+	return t.manager.Lock(t, key, mode)
 }
 
 // Iterator returns an iterator for a given key prefix within the transaction
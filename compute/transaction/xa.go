@@ -0,0 +1,293 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/turtacn/guocedb/common/errors"
+)
+
+// xaPrefix is the prefix of the keys where prepared XA transactions are
+// stored until they are committed or rolled back.
+const xaPrefix = "\x00xa/"
+
+// XID identifies a XA transaction. It's made of the global transaction id
+// given by the transaction coordinator, the branch qualifier and the format
+// id, which tells how the other two are built.
+type XID struct {
+	GTRID    string
+	BQUAL    string
+	FormatID int64
+}
+
+// String returns the XID as it's written in XA statements.
+func (x XID) String() string {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return fmt.Sprintf("'%s','%s',%d", quote.Replace(x.GTRID), quote.Replace(x.BQUAL), x.FormatID)
+}
+
+func (x XID) key() []byte {
+	return []byte(fmt.Sprintf("%s%d/%d/%s%s", xaPrefix, x.FormatID, len(x.GTRID), x.GTRID, x.BQUAL))
+}
+
+type xaState byte
+
+const (
+	// xaActive transactions were started and can be used to make changes.
+	xaActive xaState = iota
+	// xaIdle transactions were ended and can only be prepared, committed in
+	// one phase or rolled back.
+	xaIdle
+)
+
+// xaTransaction is a XA transaction that has not been prepared yet. Once
+// prepared, XA transactions are only kept in storage.
+type xaTransaction struct {
+	txn   *Transaction
+	state xaState
+}
+
+// preparedXA is what is stored for a prepared XA transaction.
+type preparedXA struct {
+	XID    XID
+	Writes []Write
+}
+
+// XAStart begins a XA transaction with the given XID.
+func (m *Manager) XAStart(xid XID, opts *TransactionOptions) (*Transaction, error) {
+	if m.db == nil {
+		return nil, errors.ErrNotImplemented
+	}
+
+	if opts == nil {
+		opts = &TransactionOptions{IsolationLevel: m.defaultIsolation}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.xaTxns[xid]; ok {
+		return nil, ErrXAExists
+	}
+
+	prepared, err := m.isPrepared(xid)
+	if err != nil {
+		return nil, err
+	}
+	if prepared {
+		return nil, ErrXAExists
+	}
+
+	txn := NewTransaction(m.db, *opts)
+	txn.manager = m
+	txn.xid = &xid
+	m.activeTxns[txn.ID()] = txn
+	m.xaTxns[xid] = &xaTransaction{txn: txn, state: xaActive}
+	return txn, nil
+}
+
+// XAEnd ends the XA transaction, after which it can no longer be used to
+// make changes.
+func (m *Manager) XAEnd(xid XID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	x, ok := m.xaTxns[xid]
+	if !ok {
+		return ErrXANotFound
+	}
+	if x.state != xaActive {
+		return ErrXAState
+	}
+
+	x.state = xaIdle
+	return nil
+}
+
+// XAPrepare stores the changes of an ended XA transaction durably, so it
+// can be committed or rolled back later on, even after a restart. A prepared
+// transaction is no longer active; its changes are applied again from
+// storage when it's committed.
+func (m *Manager) XAPrepare(xid XID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	x, ok := m.xaTxns[xid]
+	if !ok {
+		return ErrXANotFound
+	}
+	if x.state != xaIdle {
+		return ErrXAState
+	}
+
+	value, err := json.Marshal(preparedXA{XID: xid, Writes: x.txn.Writes()})
+	if err != nil {
+		return err
+	}
+
+	err = m.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(xid.key(), value)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := syncWAL(m.db); err != nil {
+		return err
+	}
+
+	m.closeXA(x)
+	return nil
+}
+
+// XACommit commits a XA transaction. Prepared transactions are committed
+// unless onePhase is given, which commits an ended transaction that was not
+// prepared instead.
+func (m *Manager) XACommit(xid XID, onePhase bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if x, ok := m.xaTxns[xid]; ok {
+		if !onePhase || x.state != xaIdle {
+			return ErrXAState
+		}
+
+		err := x.txn.Commit()
+		if err == nil && m.group != nil {
+			// Writes are not synced by badger with group commit.
+			err = syncWAL(m.db)
+		}
+		m.closeXA(x)
+		return err
+	}
+
+	if onePhase {
+		prepared, err := m.isPrepared(xid)
+		if err != nil {
+			return err
+		}
+		if prepared {
+			return ErrXAState
+		}
+		return ErrXANotFound
+	}
+
+	return m.completePrepared(xid, true)
+}
+
+// XARollback rolls back an ended or prepared XA transaction.
+func (m *Manager) XARollback(xid XID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if x, ok := m.xaTxns[xid]; ok {
+		if x.state != xaIdle {
+			return ErrXAState
+		}
+
+		err := x.txn.Rollback()
+		m.closeXA(x)
+		return err
+	}
+
+	return m.completePrepared(xid, false)
+}
+
+// XARecover returns the XA transactions that are prepared but were neither
+// committed nor rolled back, including the ones prepared before a restart.
+func (m *Manager) XARecover() ([]XID, error) {
+	if m.db == nil {
+		return nil, errors.ErrNotImplemented
+	}
+
+	var xids []XID
+	err := m.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(xaPrefix)
+		iter := txn.NewIterator(opts)
+		defer iter.Close()
+
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			var p preparedXA
+			err := iter.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &p)
+			})
+			if err != nil {
+				return err
+			}
+
+			xids = append(xids, p.XID)
+		}
+
+		return nil
+	})
+	return xids, err
+}
+
+func (m *Manager) closeXA(x *xaTransaction) {
+	if !x.txn.IsClosed() {
+		_ = x.txn.Rollback()
+	}
+	delete(m.activeTxns, x.txn.ID())
+	delete(m.xaTxns, *x.txn.xid)
+	m.releaseLocks(x.txn)
+}
+
+func (m *Manager) isPrepared(xid XID) (bool, error) {
+	if m.db == nil {
+		return false, nil
+	}
+
+	err := m.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(xid.key())
+		return err
+	})
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// completePrepared commits or rolls back a prepared XA transaction. The
+// changes are applied in the same transaction that removes the prepared
+// transaction, so they are applied exactly once.
+func (m *Manager) completePrepared(xid XID, commit bool) error {
+	if m.db == nil {
+		return ErrXANotFound
+	}
+
+	return m.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(xid.key())
+		if err == badger.ErrKeyNotFound {
+			return ErrXANotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if commit {
+			var p preparedXA
+			err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &p)
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, w := range p.Writes {
+				if w.Delete {
+					err = txn.Delete(w.Key)
+				} else {
+					err = txn.Set(w.Key, w.Value)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return txn.Delete(xid.key())
+	})
+}
@@ -0,0 +1,117 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_XARecovery(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+	xid := XID{GTRID: "trx1", BQUAL: "branch1", FormatID: 1}
+
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(err)
+	require.NoError(db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("gone"), []byte("value"))
+	}))
+
+	mgr := NewManagerWithDB(db)
+	txn, err := mgr.XAStart(xid, nil)
+	require.NoError(err)
+	require.NoError(txn.Set([]byte("key1"), []byte("value1")))
+	require.NoError(txn.Set([]byte("key2"), []byte("value2")))
+	require.NoError(txn.Delete([]byte("gone")))
+	require.NoError(mgr.XAEnd(xid))
+	require.NoError(mgr.XAPrepare(xid))
+	require.Equal(0, mgr.ActiveCount())
+
+	// Prepared changes are not visible until they are committed.
+	require.NoError(db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte("key1"))
+		require.Equal(badger.ErrKeyNotFound, err)
+		return nil
+	}))
+
+	// Restart without completing the transaction.
+	require.NoError(db.Close())
+	db, err = badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(err)
+	defer db.Close()
+
+	mgr = NewManagerWithDB(db)
+	xids, err := mgr.XARecover()
+	require.NoError(err)
+	require.Equal([]XID{xid}, xids)
+
+	_, err = mgr.XAStart(xid, nil)
+	require.Equal(ErrXAExists, err)
+	require.Equal(ErrXAState, mgr.XACommit(xid, true))
+
+	require.NoError(mgr.XACommit(xid, false))
+	require.Equal(ErrXANotFound, mgr.XACommit(xid, false))
+	require.Equal(ErrXANotFound, mgr.XARollback(xid))
+
+	xids, err = mgr.XARecover()
+	require.NoError(err)
+	require.Empty(xids)
+
+	require.NoError(db.View(func(txn *badger.Txn) error {
+		for key, value := range map[string]string{"key1": "value1", "key2": "value2"} {
+			item, err := txn.Get([]byte(key))
+			require.NoError(err)
+			v, err := item.ValueCopy(nil)
+			require.NoError(err)
+			require.Equal(value, string(v))
+		}
+
+		_, err := txn.Get([]byte("gone"))
+		require.Equal(badger.ErrKeyNotFound, err)
+		return nil
+	}))
+}
+
+func TestManager_XAStates(t *testing.T) {
+	require := require.New(t)
+	db := openTestBadger(t)
+	mgr := NewManagerWithDB(db)
+	xid := XID{GTRID: "trx1", FormatID: 1}
+
+	require.Equal(ErrXANotFound, mgr.XAEnd(xid))
+	require.Equal(ErrXANotFound, mgr.XAPrepare(xid))
+
+	txn, err := mgr.XAStart(xid, nil)
+	require.NoError(err)
+	require.NoError(txn.Set([]byte("key"), []byte("value")))
+
+	_, err = mgr.XAStart(xid, nil)
+	require.Equal(ErrXAExists, err)
+	require.Equal(ErrXAState, mgr.XAPrepare(xid))
+	require.Equal(ErrXAState, mgr.XACommit(xid, true))
+	require.Equal(ErrXAState, mgr.XARollback(xid))
+	require.Equal(ErrXAState, mgr.Commit(txn))
+
+	require.NoError(mgr.XAEnd(xid))
+	require.Equal(ErrXAState, mgr.XAEnd(xid))
+	require.Equal(ErrXAState, mgr.XACommit(xid, false))
+	require.NoError(mgr.XACommit(xid, true))
+
+	// A prepared transaction can be rolled back.
+	txn, err = mgr.XAStart(xid, nil)
+	require.NoError(err)
+	require.NoError(txn.Set([]byte("key"), []byte("other")))
+	require.NoError(mgr.XAEnd(xid))
+	require.NoError(mgr.XAPrepare(xid))
+	require.NoError(mgr.XARollback(xid))
+
+	require.NoError(db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte("key"))
+		require.NoError(err)
+		v, err := item.ValueCopy(nil)
+		require.NoError(err)
+		require.Equal("value", string(v))
+		return nil
+	}))
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"time"
+)
+
+// numberKind is the kind of number of the data of a value, in the order
+// the kinds are promoted to when numbers of two kinds are compared.
+type numberKind int
+
+const (
+	notNumber numberKind = iota
+	signedNumber
+	unsignedNumber
+	decimalNumber
+	floatNumber
+)
+
+// kindOfNumber returns the kind of number of the data of a value.
+func kindOfNumber(v interface{}) numberKind {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, bool:
+		return signedNumber
+	case uint, uint8, uint16, uint32, uint64:
+		return unsignedNumber
+	case Decimal, *Decimal:
+		return decimalNumber
+	case float32, float64:
+		return floatNumber
+	default:
+		return notNumber
+	}
+}
+
+// compareNumbers compares a and b if either is a number, converting both
+// to the kind of number both fit in, and returns whether it did. Strings
+// compared with numbers are read as floats.
+func compareNumbers(a, b interface{}) (int, bool, error) {
+	ka, kb := kindOfNumber(a), kindOfNumber(b)
+	if ka == notNumber && kb == notNumber {
+		return 0, false, nil
+	}
+	if !isNumeric(a, ka) || !isNumeric(b, kb) {
+		return 0, false, nil
+	}
+
+	kind := ka
+	if kb > kind {
+		kind = kb
+	}
+	if ka == notNumber || kb == notNumber {
+		kind = floatNumber
+	}
+
+	var cmp int
+	var err error
+	switch kind {
+	case signedNumber:
+		cmp, err = Int64.Compare(a, b)
+	case unsignedNumber:
+		cmp, err = compareUnsigned(a, b)
+	case decimalNumber:
+		cmp, err = DefaultDecimal.Compare(a, b)
+	default:
+		cmp, err = Float64.Compare(a, b)
+	}
+	return cmp, true, err
+}
+
+// isNumeric returns whether data of the given kind of number can be
+// compared as a number: numbers, and strings and byte slices, which are
+// read as numbers.
+func isNumeric(v interface{}, kind numberKind) bool {
+	if kind != notNumber {
+		return true
+	}
+	switch v.(type) {
+	case string, []byte:
+		return true
+	default:
+		return false
+	}
+}
+
+// compareUnsigned compares two integers, at least one of them unsigned, so
+// the unsigned ones over the range of int64 aren't out of range.
+func compareUnsigned(a, b interface{}) (int, error) {
+	aNeg, aVal, err := toUnsigned(a)
+	if err != nil {
+		return 0, err
+	}
+	bNeg, bVal, err := toUnsigned(b)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case aNeg && !bNeg:
+		return -1, nil
+	case !aNeg && bNeg:
+		return 1, nil
+	case aVal < bVal:
+		return -1, nil
+	case aVal > bVal:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// toUnsigned returns whether an integer is negative, and its value as a
+// uint64 if it isn't, or as the uint64 of its two's complement if it is, so
+// negative integers are ordered among themselves too.
+func toUnsigned(v interface{}) (bool, uint64, error) {
+	switch val := v.(type) {
+	case uint:
+		return false, uint64(val), nil
+	case uint8:
+		return false, uint64(val), nil
+	case uint16:
+		return false, uint64(val), nil
+	case uint32:
+		return false, uint64(val), nil
+	case uint64:
+		return false, val, nil
+	default:
+		i, err := ConvertToInt64(v)
+		if err != nil {
+			return false, 0, err
+		}
+		return i < 0, uint64(i), nil
+	}
+}
+
+// compareTemporals compares a and b if both are instants or both are
+// durations, and returns whether it did.
+func compareTemporals(a, b interface{}) (int, bool) {
+	switch aVal := a.(type) {
+	case time.Time:
+		if bVal, ok := b.(time.Time); ok {
+			return compareTimes(aVal, bVal), true
+		}
+	case time.Duration:
+		if bVal, ok := b.(time.Duration); ok {
+			switch {
+			case aVal < bVal:
+				return -1, true
+			case aVal > bVal:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+// isTemporal returns whether a type is one of the temporal types.
+func isTemporal(t Type) bool {
+	switch t.QueryType() {
+	case TIMESTAMP, DATETIME, DATE, TIME:
+		return true
+	default:
+		return false
+	}
+}
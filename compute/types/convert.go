@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -87,6 +88,39 @@ func ConvertToInt64(v interface{}) (int64, error) {
 	}
 }
 
+// ConvertToFloat64 converts a value to a float64.
+func ConvertToFloat64(v interface{}) (float64, error) {
+	switch val := v.(type) {
+	case float32:
+		return float64(val), nil
+	case float64:
+		return val, nil
+	case uint:
+		return float64(val), nil
+	case uint64:
+		return float64(val), nil
+	case Decimal:
+		return val.Float64(), nil
+	case string:
+		if val == "" {
+			return 0, nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: cannot convert %q to float64", ErrInvalidConversion, val)
+		}
+		return f, nil
+	case []byte:
+		return ConvertToFloat64(string(val))
+	default:
+		i, err := ConvertToInt64(v)
+		if err != nil {
+			return 0, fmt.Errorf("%w: cannot convert %T to float64", ErrInvalidConversion, v)
+		}
+		return float64(i), nil
+	}
+}
+
 // ConvertToString converts a value to a string.
 func ConvertToString(v interface{}) (string, error) {
 	switch val := v.(type) {
@@ -105,16 +139,8 @@ func ConvertToTimestamp(v interface{}) (time.Time, error) {
 	case time.Time:
 		return val, nil
 	case string:
-		layouts := []string{
-			"2006-01-02 15:04:05",
-			"2006-01-02",
-			time.RFC3339,
-			time.RFC3339Nano,
-		}
-		for _, layout := range layouts {
-			if t, err := time.Parse(layout, val); err == nil {
-				return t, nil
-			}
+		if t, err := ParseDateTime(val); err == nil {
+			return t, nil
 		}
 		// Try parsing as Unix timestamp
 		if i, err := strconv.ParseInt(val, 10, 64); err == nil {
@@ -148,10 +174,20 @@ func Convert(typ QueryType, v interface{}) (interface{}, error) {
 		// For simplicity, we'll use int64 for unsigned as well for now.
 		// This can be changed later if needed.
 		return ConvertToInt64(v)
+	case FLOAT32, FLOAT64:
+		return ConvertToFloat64(v)
 	case TEXT, VARCHAR, CHAR:
 		return ConvertToString(v)
-	case TIMESTAMP, DATETIME, DATE:
+	case TIMESTAMP:
 		return ConvertToTimestamp(v)
+	case DATETIME:
+		return ConvertToDateTime(v)
+	case DATE:
+		return ConvertToDate(v)
+	case TIME:
+		return ConvertToTime(v)
+	case DECIMAL:
+		return ConvertToDecimal(v)
 	case NULL_TYPE:
 		return nil, nil
 	default:
@@ -0,0 +1,422 @@
+package types
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrDivisionByZero = fmt.Errorf("division by zero")
+)
+
+// MaxDecimalPrecision is the maximum number of digits of a DECIMAL column.
+const MaxDecimalPrecision = 65
+
+func init() {
+	gob.Register(Decimal{})
+}
+
+var bigTen = big.NewInt(10)
+
+// Decimal is an exact fixed-point number, stored as an integer mantissa
+// and the number of digits after the decimal point. Its value is
+// mantissa * 10^-scale.
+type Decimal struct {
+	mantissa *big.Int
+	scale    int32
+}
+
+// NewDecimal creates a decimal with the value mantissa * 10^-scale.
+func NewDecimal(mantissa int64, scale int32) Decimal {
+	return Decimal{mantissa: big.NewInt(mantissa), scale: scale}
+}
+
+// ParseDecimal parses a decimal written as [+-]digits[.digits][e[+-]digits].
+// The scale of the result is the number of digits after the decimal point.
+func ParseDecimal(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+
+	var exp int64
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(str[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("%w: cannot convert %q to decimal", ErrInvalidConversion, s)
+		}
+		exp, str = e, str[:i]
+	}
+
+	neg := false
+	if str != "" && (str[0] == '+' || str[0] == '-') {
+		neg, str = str[0] == '-', str[1:]
+	}
+
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+
+	digits := intPart + fracPart
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return Decimal{}, fmt.Errorf("%w: cannot convert %q to decimal", ErrInvalidConversion, s)
+	}
+
+	m, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		m.Neg(m)
+	}
+
+	scale := int64(len(fracPart)) - exp
+	if scale < 0 {
+		m.Mul(m, pow10(int32(-scale)))
+		scale = 0
+	}
+	if scale > math.MaxInt32 {
+		return Decimal{}, fmt.Errorf("%w: cannot convert %q to decimal", ErrOutOfRange, s)
+	}
+
+	return Decimal{mantissa: m, scale: int32(scale)}, nil
+}
+
+func (d Decimal) m() *big.Int {
+	if d.mantissa == nil {
+		return new(big.Int)
+	}
+	return d.mantissa
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Precision returns the number of digits of the decimal, without counting
+// the leading zeros of its integer part.
+func (d Decimal) Precision() int {
+	m := d.m()
+	if m.Sign() == 0 {
+		return 1
+	}
+	return len(new(big.Int).Abs(m).String())
+}
+
+// Sign returns -1, 0 or 1 when the decimal is negative, zero or positive.
+func (d Decimal) Sign() int {
+	return d.m().Sign()
+}
+
+// rescale returns the mantissas of a and b with the same scale, which is
+// the largest of both.
+func rescale(a, b Decimal) (*big.Int, *big.Int, int32) {
+	am, bm := a.m(), b.m()
+	switch {
+	case a.scale < b.scale:
+		return new(big.Int).Mul(am, pow10(b.scale-a.scale)), bm, b.scale
+	case a.scale > b.scale:
+		return am, new(big.Int).Mul(bm, pow10(a.scale-b.scale)), a.scale
+	default:
+		return am, bm, a.scale
+	}
+}
+
+// Add returns d + other. The result is exact, with the largest scale of both.
+func (d Decimal) Add(other Decimal) Decimal {
+	am, bm, scale := rescale(d, other)
+	return Decimal{mantissa: new(big.Int).Add(am, bm), scale: scale}
+}
+
+// Sub returns d - other. The result is exact, with the largest scale of both.
+func (d Decimal) Sub(other Decimal) Decimal {
+	am, bm, scale := rescale(d, other)
+	return Decimal{mantissa: new(big.Int).Sub(am, bm), scale: scale}
+}
+
+// Mul returns d * other. The result is exact, and its scale is the sum of
+// both scales.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{
+		mantissa: new(big.Int).Mul(d.m(), other.m()),
+		scale:    d.scale + other.scale,
+	}
+}
+
+// Div returns d / other with the given scale, rounding half to even.
+func (d Decimal) Div(other Decimal, scale int32) (Decimal, error) {
+	if other.Sign() == 0 {
+		return Decimal{}, ErrDivisionByZero
+	}
+
+	// d / other = (dm / om) * 10^(os - ds), which is multiplied by 10^scale
+	// to get the mantissa of the result.
+	num, den := new(big.Int).Set(d.m()), new(big.Int).Set(other.m())
+	if shift := scale - d.scale + other.scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+
+	return Decimal{mantissa: quoHalfEven(num, den), scale: scale}, nil
+}
+
+// Round returns the decimal with the given scale, rounding half to even if
+// digits are dropped.
+func (d Decimal) Round(scale int32) Decimal {
+	switch {
+	case scale > d.scale:
+		return Decimal{mantissa: new(big.Int).Mul(d.m(), pow10(scale-d.scale)), scale: scale}
+	case scale < d.scale:
+		return Decimal{mantissa: quoHalfEven(d.m(), pow10(d.scale-scale)), scale: scale}
+	default:
+		return d
+	}
+}
+
+// Cmp compares two decimals, and returns -1, 0 or 1 if d is less than,
+// equal to or greater than other.
+func (d Decimal) Cmp(other Decimal) int {
+	am, bm, _ := rescale(d, other)
+	return am.Cmp(bm)
+}
+
+// Equal returns whether both decimals have the same value, whatever their
+// scale is.
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// String returns the decimal with all the digits of its scale.
+func (d Decimal) String() string {
+	m := d.m()
+	digits := new(big.Int).Abs(m).String()
+
+	if d.scale > 0 {
+		if pad := int(d.scale) - len(digits) + 1; pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		digits = digits[:len(digits)-int(d.scale)] + "." + digits[len(digits)-int(d.scale):]
+	} else if d.scale < 0 && m.Sign() != 0 {
+		digits += strings.Repeat("0", int(-d.scale))
+	}
+
+	if m.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Float64 returns the nearest float64 to the decimal.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// GobEncode implements the gob.GobEncoder interface.
+func (d Decimal) GobEncode() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// GobDecode implements the gob.GobDecoder interface.
+func (d *Decimal) GobDecode(b []byte) error {
+	v, err := ParseDecimal(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// ConvertToDecimal converts a value to a decimal. Floats are converted from
+// the shortest representation that reads back as the same float, so 0.1 is
+// converted to exactly 0.1.
+func ConvertToDecimal(v interface{}) (Decimal, error) {
+	switch val := v.(type) {
+	case Decimal:
+		return val, nil
+	case *Decimal:
+		return *val, nil
+	case string:
+		return ParseDecimal(val)
+	case []byte:
+		return ParseDecimal(string(val))
+	case float32:
+		return convertFloatToDecimal(float64(val), 32)
+	case float64:
+		return convertFloatToDecimal(val, 64)
+	case uint:
+		return Decimal{mantissa: new(big.Int).SetUint64(uint64(val))}, nil
+	case uint64:
+		return Decimal{mantissa: new(big.Int).SetUint64(val)}, nil
+	case nil:
+		return NewDecimal(0, 0), nil
+	default:
+		i, err := ConvertToInt64(v)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("%w: cannot convert %T to decimal", ErrInvalidConversion, v)
+		}
+		return NewDecimal(i, 0), nil
+	}
+}
+
+func convertFloatToDecimal(f float64, bitSize int) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("%w: cannot convert %v to decimal", ErrInvalidConversion, f)
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
+
+// pow10 returns 10^n.
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// quoHalfEven returns num / den rounded half to even.
+func quoHalfEven(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// Compare twice the remainder with the divisor to know whether it's
+	// below, above or exactly at the half.
+	half := new(big.Int).Abs(r)
+	cmp := half.Lsh(half, 1).Cmp(new(big.Int).Abs(den))
+	if cmp > 0 || (cmp == 0 && q.Bit(0) == 1) {
+		// Round away from zero, in the direction of the exact result.
+		if (num.Sign() < 0) != (den.Sign() < 0) {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// DecimalType is the DECIMAL type, whose arithmetic keeps the results
+// within its precision and scale.
+type DecimalType interface {
+	Type
+	// Precision returns the number of digits of the values of the type.
+	Precision() int
+	// Scale returns the number of digits after the decimal point.
+	Scale() int32
+	// Add returns a + b, which must fit in the type.
+	Add(a, b Decimal) (Decimal, error)
+	// Sub returns a - b, which must fit in the type.
+	Sub(a, b Decimal) (Decimal, error)
+	// Mul returns a * b rounded to the scale of the type, which must fit in it.
+	Mul(a, b Decimal) (Decimal, error)
+	// Div returns a / b rounded to the scale of the type, which must fit in it.
+	Div(a, b Decimal) (Decimal, error)
+}
+
+// DefaultDecimal is the DECIMAL type without a precision and scale, which
+// is DECIMAL(10,0).
+var DefaultDecimal DecimalType = &decimalType{baseType{typ: DECIMAL}, 10, 0}
+
+// decimalType is the implementation of the DECIMAL type, with the number
+// of digits and the number of them after the decimal point of its values.
+type decimalType struct {
+	baseType
+	precision int
+	scale     int32
+}
+
+// NewDecimalType returns the DECIMAL(precision, scale) type.
+func NewDecimalType(precision, scale int) (DecimalType, error) {
+	if precision < 1 || precision > MaxDecimalPrecision {
+		return nil, fmt.Errorf("invalid DECIMAL precision %d, it must be between 1 and %d", precision, MaxDecimalPrecision)
+	}
+	if scale < 0 || scale > precision {
+		return nil, fmt.Errorf("invalid DECIMAL scale %d, it must be between 0 and the precision %d", scale, precision)
+	}
+	return &decimalType{baseType{typ: DECIMAL}, precision, int32(scale)}, nil
+}
+
+// Precision implements the DecimalType interface.
+func (t *decimalType) Precision() int {
+	return t.precision
+}
+
+// Scale implements the DecimalType interface.
+func (t *decimalType) Scale() int32 {
+	return t.scale
+}
+
+// SQL implements the Type interface.
+func (t *decimalType) SQL() string {
+	return fmt.Sprintf("DECIMAL(%d,%d)", t.precision, t.scale)
+}
+
+// Compare implements the Type interface.
+func (t *decimalType) Compare(a interface{}, b interface{}) (int, error) {
+	if a == nil || b == nil {
+		return 0, ErrNullComparison
+	}
+
+	aVal, err := ConvertToDecimal(a)
+	if err != nil {
+		return 0, err
+	}
+	bVal, err := ConvertToDecimal(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return aVal.Cmp(bVal), nil
+}
+
+// Convert implements the Type interface. The value is rounded half to even
+// to the scale of the type, and it's out of range if it has more digits
+// than its precision.
+func (t *decimalType) Convert(v interface{}) (interface{}, error) {
+	d, err := ConvertToDecimal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	d = d.Round(t.scale)
+	if d.Precision() > t.precision {
+		return nil, fmt.Errorf("%w: %s does not fit in %s", ErrOutOfRange, d, t.SQL())
+	}
+	return d, nil
+}
+
+// Zero implements the Type interface.
+func (t *decimalType) Zero() interface{} {
+	return NewDecimal(0, t.scale)
+}
+
+// Add implements the DecimalType interface.
+func (t *decimalType) Add(a, b Decimal) (Decimal, error) {
+	return t.fit(a.Add(b))
+}
+
+// Sub implements the DecimalType interface.
+func (t *decimalType) Sub(a, b Decimal) (Decimal, error) {
+	return t.fit(a.Sub(b))
+}
+
+// Mul implements the DecimalType interface.
+func (t *decimalType) Mul(a, b Decimal) (Decimal, error) {
+	return t.fit(a.Mul(b))
+}
+
+// Div implements the DecimalType interface.
+func (t *decimalType) Div(a, b Decimal) (Decimal, error) {
+	d, err := a.Div(b, t.scale)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return t.fit(d)
+}
+
+func (t *decimalType) fit(d Decimal) (Decimal, error) {
+	v, err := t.Convert(d)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return v.(Decimal), nil
+}
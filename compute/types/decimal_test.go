@@ -0,0 +1,149 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecimal(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := ParseDecimal(s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a, err := ConvertToDecimal(0.1)
+	require.NoError(t, err)
+	b, err := ConvertToDecimal(0.2)
+	require.NoError(t, err)
+
+	sum := a.Add(b)
+	require.True(t, sum.Equal(mustDecimal(t, "0.3")))
+	require.Equal(t, "0.3", sum.String())
+
+	require.Equal(t, "-0.1", a.Sub(b).String())
+	require.Equal(t, "0.02", a.Mul(b).String())
+	require.Equal(t, "2.4690", mustDecimal(t, "1.2345").Mul(NewDecimal(2, 0)).Round(4).String())
+
+	q, err := NewDecimal(1, 0).Div(NewDecimal(3, 0), 4)
+	require.NoError(t, err)
+	require.Equal(t, "0.3333", q.String())
+
+	q, err = NewDecimal(-2, 0).Div(NewDecimal(3, 0), 2)
+	require.NoError(t, err)
+	require.Equal(t, "-0.67", q.String())
+
+	_, err = a.Div(NewDecimal(0, 2), 2)
+	require.ErrorIs(t, err, ErrDivisionByZero)
+}
+
+func TestDecimalParse(t *testing.T) {
+	testCases := []struct {
+		in       string
+		expected string
+		err      bool
+	}{
+		{"123", "123", false},
+		{"-0.05", "-0.05", false},
+		{"+1.50", "1.50", false},
+		{".5", "0.5", false},
+		{"1.5e2", "150", false},
+		{"15e-3", "0.015", false},
+		{"", "", true},
+		{"1.2.3", "", true},
+		{"abc", "", true},
+	}
+
+	for _, tc := range testCases {
+		d, err := ParseDecimal(tc.in)
+		if tc.err {
+			require.ErrorIs(t, err, ErrInvalidConversion, tc.in)
+			continue
+		}
+		require.NoError(t, err, tc.in)
+		require.Equal(t, tc.expected, d.String(), tc.in)
+	}
+}
+
+func TestDecimalRoundHalfEven(t *testing.T) {
+	testCases := []struct {
+		in       interface{}
+		scale    int32
+		expected string
+	}{
+		{"2.345", 2, "2.34"},
+		{"2.355", 2, "2.36"},
+		{"2.3451", 2, "2.35"},
+		{"-2.345", 2, "-2.34"},
+		{"-2.355", 2, "-2.36"},
+		{"0.5", 0, "0"},
+		{"1.5", 0, "2"},
+		{2.5, 0, "2"},
+		{0.125, 2, "0.12"},
+		{"7", 2, "7.00"},
+	}
+
+	for _, tc := range testCases {
+		d, err := ConvertToDecimal(tc.in)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, d.Round(tc.scale).String(), "%v", tc.in)
+	}
+}
+
+func TestDecimalType(t *testing.T) {
+	typ, err := NewDecimalType(5, 2)
+	require.NoError(t, err)
+	require.Equal(t, "DECIMAL(5,2)", typ.SQL())
+
+	v, err := typ.Convert("999.99")
+	require.NoError(t, err)
+	require.Equal(t, "999.99", v.(Decimal).String())
+
+	v, err = typ.Convert(1.005)
+	require.NoError(t, err)
+	require.Equal(t, "1.00", v.(Decimal).String())
+
+	_, err = typ.Convert("1000.00")
+	require.ErrorIs(t, err, ErrOutOfRange)
+
+	_, err = typ.Convert(999.995)
+	require.ErrorIs(t, err, ErrOutOfRange)
+
+	_, err = typ.Add(mustDecimal(t, "999.99"), mustDecimal(t, "0.01"))
+	require.ErrorIs(t, err, ErrOutOfRange)
+
+	q, err := typ.Div(NewDecimal(10, 0), NewDecimal(3, 0))
+	require.NoError(t, err)
+	require.Equal(t, "3.33", q.String())
+
+	cmp, err := typ.Compare("1.10", 1.1)
+	require.NoError(t, err)
+	require.Equal(t, 0, cmp)
+
+	_, err = NewDecimalType(66, 0)
+	require.Error(t, err)
+	_, err = NewDecimalType(5, 6)
+	require.Error(t, err)
+
+	schema := Schema{{Name: "price", Type: typ}}
+	require.NoError(t, schema.CheckRow(Row{"999.99"}))
+	require.ErrorIs(t, schema.CheckRow(Row{"1000.00"}), ErrOutOfRange)
+}
+
+func TestDecimalValueSerialization(t *testing.T) {
+	typ, err := NewDecimalType(10, 3)
+	require.NoError(t, err)
+
+	val, err := NewValue(typ, "-12.5")
+	require.NoError(t, err)
+
+	b, err := val.ToBytes()
+	require.NoError(t, err)
+
+	newValue := &Value{}
+	require.NoError(t, newValue.FromBytes(b))
+	require.Equal(t, DECIMAL, newValue.typ.QueryType())
+	require.Equal(t, "-12.500", newValue.data.(Decimal).String())
+}
@@ -91,6 +91,50 @@ func (t *int64Type) Zero() interface{} {
 	return int64(0)
 }
 
+// float64Type is the implementation of the DOUBLE type.
+type float64Type struct {
+	baseType
+}
+
+// SQL implements the Type interface.
+func (t *float64Type) SQL() string {
+	return "DOUBLE"
+}
+
+// Compare implements the Type interface.
+func (t *float64Type) Compare(a interface{}, b interface{}) (int, error) {
+	if a == nil || b == nil {
+		return 0, ErrNullComparison
+	}
+
+	aVal, err := ConvertToFloat64(a)
+	if err != nil {
+		return 0, err
+	}
+	bVal, err := ConvertToFloat64(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if aVal < bVal {
+		return -1, nil
+	}
+	if aVal > bVal {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Convert implements the Type interface.
+func (t *float64Type) Convert(v interface{}) (interface{}, error) {
+	return ConvertToFloat64(v)
+}
+
+// Zero implements the Type interface.
+func (t *float64Type) Zero() interface{} {
+	return float64(0)
+}
+
 // stringType is the implementation of the TEXT type.
 type stringType struct {
 	baseType
@@ -207,6 +251,8 @@ func (t *nullType) Zero() interface{} {
 var (
 	// Int64 is the INT64 type.
 	Int64 Type = &int64Type{baseType{typ: INT64}}
+	// Float64 is the FLOAT64 type.
+	Float64 Type = &float64Type{baseType{typ: FLOAT64}}
 	// Text is the TEXT type.
 	Text Type = &stringType{baseType{typ: TEXT}}
 	// Timestamp is the TIMESTAMP type.
@@ -220,10 +266,20 @@ func MysqlTypeToType(qt QueryType) (Type, error) {
 	switch qt {
 	case INT8, INT16, INT24, INT32, INT64, UINT8, UINT16, UINT24, UINT32, UINT64:
 		return Int64, nil
+	case FLOAT32, FLOAT64:
+		return Float64, nil
 	case TEXT, VARCHAR, CHAR:
 		return Text, nil
-	case TIMESTAMP, DATETIME, DATE:
+	case TIMESTAMP:
 		return Timestamp, nil
+	case DATETIME:
+		return DateTime, nil
+	case DATE:
+		return Date, nil
+	case TIME:
+		return Time, nil
+	case DECIMAL:
+		return DefaultDecimal, nil
 	case NULL_TYPE:
 		return Null, nil
 	default:
@@ -0,0 +1,377 @@
+package types
+
+import (
+	"encoding/gob"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	gob.Register(time.Time{})
+	gob.Register(time.Duration(0))
+}
+
+// ErrInvalidIntervalUnit is returned when an interval has a unit dates
+// can't be moved by.
+var ErrInvalidIntervalUnit = fmt.Errorf("invalid interval unit")
+
+// MaxTime is the largest magnitude of a TIME value, 838:59:59.
+const MaxTime = 838*time.Hour + 59*time.Minute + 59*time.Second
+
+// dateTimeLayouts are the layouts of the date and time literals MySQL
+// accepts, from the most to the least common. Single-digit months and days
+// are accepted, and fractional seconds are optional.
+var dateTimeLayouts = []string{
+	"2006-1-2 15:04:05.999999999",
+	"2006-1-2T15:04:05.999999999",
+	"2006-1-2 15:04",
+	"2006-1-2",
+	"20060102150405",
+	"20060102",
+	time.RFC3339Nano,
+}
+
+// ParseDateTime parses a MySQL DATETIME or DATE literal, such as
+// '2024-01-15 10:30:00', in UTC.
+func ParseDateTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q is not a valid datetime", ErrInvalidConversion, s)
+}
+
+// ParseTime parses a MySQL TIME literal, such as '10:30:00', '-1:15' or
+// '2 10:30:00', where the number before the space is a number of days.
+func ParseTime(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("%w: %q is not a valid time", ErrInvalidConversion, s)
+
+	str := strings.TrimSpace(s)
+	neg := strings.HasPrefix(str, "-")
+	str = strings.TrimPrefix(str, "-")
+
+	var days int64
+	if i := strings.IndexByte(str, ' '); i >= 0 {
+		d, err := strconv.ParseInt(str[:i], 10, 64)
+		if err != nil {
+			return 0, invalid
+		}
+		days, str = d, str[i+1:]
+	}
+
+	var frac string
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		str, frac = str[:i], str[i+1:]
+	}
+
+	var parts []string
+	if strings.Contains(str, ":") {
+		parts = strings.Split(str, ":")
+	} else if days == 0 {
+		// HHMMSS, MMSS or SS, as in 103000.
+		for len(str) > 2 {
+			parts = append([]string{str[len(str)-2:]}, parts...)
+			str = str[:len(str)-2]
+		}
+		parts = append([]string{str}, parts...)
+		for len(parts) < 3 {
+			parts = append([]string{"0"}, parts...)
+		}
+	} else {
+		parts = []string{str}
+	}
+	if len(parts) > 3 {
+		return 0, invalid
+	}
+
+	var fields [3]int64
+	for i, p := range parts {
+		n, err := strconv.ParseInt(p, 10, 64)
+		if err != nil || n < 0 || (i > 0 && n > 59) {
+			return 0, invalid
+		}
+		fields[i] = n
+	}
+
+	d := time.Duration(days*24+fields[0])*time.Hour +
+		time.Duration(fields[1])*time.Minute +
+		time.Duration(fields[2])*time.Second
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		n, err := strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+		if err != nil || n < 0 {
+			return 0, invalid
+		}
+		d += time.Duration(n)
+	}
+
+	if d > MaxTime {
+		return 0, fmt.Errorf("%w: %q is not a valid time", ErrOutOfRange, s)
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// FormatTime formats a TIME value as MySQL does, such as -01:15:00 or
+// 58:30:00.
+func FormatTime(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	s := fmt.Sprintf("%s%02d:%02d:%02d", sign, d/time.Hour, d%time.Hour/time.Minute, d%time.Minute/time.Second)
+	if frac := d % time.Second; frac != 0 {
+		s += fmt.Sprintf(".%06d", frac/time.Microsecond)
+	}
+	return s
+}
+
+// ConvertToDateTime converts a value to a DATETIME, a time.Time in UTC.
+// Numbers are read as in 20240115103000.
+func ConvertToDateTime(v interface{}) (time.Time, error) {
+	switch val := v.(type) {
+	case time.Time:
+		return val.UTC(), nil
+	case string:
+		return ParseDateTime(val)
+	case []byte:
+		return ParseDateTime(string(val))
+	case int, int32, int64, uint32, uint64:
+		return ParseDateTime(fmt.Sprint(val))
+	case nil:
+		return time.Time{}, nil
+	default:
+		return time.Time{}, fmt.Errorf("%w: cannot convert %T to datetime", ErrInvalidConversion, v)
+	}
+}
+
+// ConvertToDate converts a value to a DATE, a time.Time at midnight UTC.
+func ConvertToDate(v interface{}) (time.Time, error) {
+	t, err := ConvertToDateTime(v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return truncateToDate(t), nil
+}
+
+func truncateToDate(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ConvertToTime converts a value to a TIME, a time.Duration. The time of
+// day is taken from datetimes, and numbers are read as in 103000.
+func ConvertToTime(v interface{}) (time.Duration, error) {
+	switch val := v.(type) {
+	case time.Duration:
+		if val > MaxTime || val < -MaxTime {
+			return 0, ErrOutOfRange
+		}
+		return val, nil
+	case time.Time:
+		return val.UTC().Sub(truncateToDate(val)), nil
+	case string:
+		return ParseTime(val)
+	case []byte:
+		return ParseTime(string(val))
+	case int, int32, int64, uint32, uint64:
+		return ParseTime(fmt.Sprint(val))
+	case nil:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("%w: cannot convert %T to time", ErrInvalidConversion, v)
+	}
+}
+
+// AddInterval moves t by n of the given unit, which is one of the units of
+// MySQL's INTERVAL, such as DAY or MONTH. Moving by months, quarters or
+// years keeps the day of the month unless the resulting month is shorter,
+// so '2024-01-31' plus a month is '2024-02-29'.
+func AddInterval(t time.Time, n int64, unit string) (time.Time, error) {
+	switch strings.ToUpper(unit) {
+	case "MICROSECOND":
+		return t.Add(time.Duration(n) * time.Microsecond), nil
+	case "SECOND":
+		return t.Add(time.Duration(n) * time.Second), nil
+	case "MINUTE":
+		return t.Add(time.Duration(n) * time.Minute), nil
+	case "HOUR":
+		return t.Add(time.Duration(n) * time.Hour), nil
+	case "DAY":
+		return t.AddDate(0, 0, int(n)), nil
+	case "WEEK":
+		return t.AddDate(0, 0, int(n)*7), nil
+	case "MONTH":
+		return addMonths(t, n), nil
+	case "QUARTER":
+		return addMonths(t, n*3), nil
+	case "YEAR":
+		return addMonths(t, n*12), nil
+	default:
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidIntervalUnit, unit)
+	}
+}
+
+func addMonths(t time.Time, n int64) time.Time {
+	y, m, d := t.Date()
+	months := int64(y)*12 + int64(m-1) + n
+	y, m = int(months/12), time.Month(months%12+1)
+
+	// The last day of the month is the day before the first of the next.
+	if last := time.Date(y, m+1, 0, 0, 0, 0, 0, t.Location()).Day(); d > last {
+		d = last
+	}
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// DateDiff returns the number of days from the date of b to the date of a,
+// ignoring their times, as MySQL's DATEDIFF does.
+func DateDiff(a, b time.Time) int64 {
+	return int64(truncateToDate(a).Sub(truncateToDate(b)) / (24 * time.Hour))
+}
+
+// compareTimes compares two instants.
+func compareTimes(a, b time.Time) int {
+	if a.Before(b) {
+		return -1
+	}
+	if a.After(b) {
+		return 1
+	}
+	return 0
+}
+
+// dateType is the implementation of the DATE type.
+type dateType struct {
+	baseType
+}
+
+// SQL implements the Type interface.
+func (t *dateType) SQL() string {
+	return "DATE"
+}
+
+// Compare implements the Type interface.
+func (t *dateType) Compare(a interface{}, b interface{}) (int, error) {
+	if a == nil || b == nil {
+		return 0, ErrNullComparison
+	}
+
+	aVal, err := ConvertToDate(a)
+	if err != nil {
+		return 0, err
+	}
+	bVal, err := ConvertToDate(b)
+	if err != nil {
+		return 0, err
+	}
+	return compareTimes(aVal, bVal), nil
+}
+
+// Convert implements the Type interface.
+func (t *dateType) Convert(v interface{}) (interface{}, error) {
+	return ConvertToDate(v)
+}
+
+// Zero implements the Type interface.
+func (t *dateType) Zero() interface{} {
+	return time.Time{}
+}
+
+// dateTimeType is the implementation of the DATETIME type.
+type dateTimeType struct {
+	baseType
+}
+
+// SQL implements the Type interface.
+func (t *dateTimeType) SQL() string {
+	return "DATETIME"
+}
+
+// Compare implements the Type interface.
+func (t *dateTimeType) Compare(a interface{}, b interface{}) (int, error) {
+	if a == nil || b == nil {
+		return 0, ErrNullComparison
+	}
+
+	aVal, err := ConvertToDateTime(a)
+	if err != nil {
+		return 0, err
+	}
+	bVal, err := ConvertToDateTime(b)
+	if err != nil {
+		return 0, err
+	}
+	return compareTimes(aVal, bVal), nil
+}
+
+// Convert implements the Type interface.
+func (t *dateTimeType) Convert(v interface{}) (interface{}, error) {
+	return ConvertToDateTime(v)
+}
+
+// Zero implements the Type interface.
+func (t *dateTimeType) Zero() interface{} {
+	return time.Time{}
+}
+
+// timeType is the implementation of the TIME type.
+type timeType struct {
+	baseType
+}
+
+// SQL implements the Type interface.
+func (t *timeType) SQL() string {
+	return "TIME"
+}
+
+// Compare implements the Type interface.
+func (t *timeType) Compare(a interface{}, b interface{}) (int, error) {
+	if a == nil || b == nil {
+		return 0, ErrNullComparison
+	}
+
+	aVal, err := ConvertToTime(a)
+	if err != nil {
+		return 0, err
+	}
+	bVal, err := ConvertToTime(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if aVal < bVal {
+		return -1, nil
+	}
+	if aVal > bVal {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Convert implements the Type interface.
+func (t *timeType) Convert(v interface{}) (interface{}, error) {
+	return ConvertToTime(v)
+}
+
+// Zero implements the Type interface.
+func (t *timeType) Zero() interface{} {
+	return time.Duration(0)
+}
+
+var (
+	// Date is the DATE type.
+	Date Type = &dateType{baseType{typ: DATE}}
+	// DateTime is the DATETIME type.
+	DateTime Type = &dateTimeType{baseType{typ: DATETIME}}
+	// Time is the TIME type.
+	Time Type = &timeType{baseType{typ: TIME}}
+)
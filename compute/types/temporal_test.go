@@ -0,0 +1,154 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDateTime(t *testing.T) {
+	want := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	for _, s := range []string{
+		"2024-01-15 10:30:00",
+		"2024-1-15 10:30:00",
+		"2024-01-15T10:30:00",
+		"2024-01-15 10:30",
+		"20240115103000",
+		"2024-01-15T10:30:00Z",
+	} {
+		got, err := ParseDateTime(s)
+		require.NoError(t, err, s)
+		require.True(t, want.Equal(got), "%s: %v", s, got)
+	}
+
+	got, err := ParseDateTime("2024-01-15 10:30:00.25")
+	require.NoError(t, err)
+	require.Equal(t, 250*time.Millisecond, got.Sub(want))
+
+	got, err = ParseDateTime("2024-01-15")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), got)
+
+	_, err = ParseDateTime("2024-13-01")
+	require.ErrorIs(t, err, ErrInvalidConversion)
+}
+
+func TestParseTime(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"10:30:00", 10*time.Hour + 30*time.Minute},
+		{"10:30", 10*time.Hour + 30*time.Minute},
+		{"-01:15:00", -(time.Hour + 15*time.Minute)},
+		{"838:59:59", MaxTime},
+		{"2 10:00:00", 58 * time.Hour},
+		{"103000", 10*time.Hour + 30*time.Minute},
+		{"00:00:01.5", 1500 * time.Millisecond},
+	}
+	for _, tc := range testCases {
+		got, err := ParseTime(tc.in)
+		require.NoError(t, err, tc.in)
+		require.Equal(t, tc.want, got, tc.in)
+	}
+
+	_, err := ParseTime("839:00:00")
+	require.ErrorIs(t, err, ErrOutOfRange)
+	_, err = ParseTime("10:61:00")
+	require.ErrorIs(t, err, ErrInvalidConversion)
+
+	require.Equal(t, "-01:15:00", FormatTime(-(time.Hour + 15*time.Minute)))
+	require.Equal(t, "58:00:00.500000", FormatTime(58*time.Hour+500*time.Millisecond))
+}
+
+func TestTemporalTypeComparison(t *testing.T) {
+	testCases := []struct {
+		typ      Type
+		a, b     interface{}
+		expected int
+	}{
+		{DateTime, "2024-01-15 10:30:00", "2024-01-15 10:30:01", -1},
+		{DateTime, "2024-01-15 10:30:00", "2024-01-15 09:59:59", 1},
+		{DateTime, "2024-01-15 10:30:00", time.Date(2024, 1, 15, 11, 30, 0, 0, time.FixedZone("CET", 3600)), 0},
+		// Dates ignore the time of day.
+		{Date, "2024-01-15 10:30:00", "2024-01-15 23:00:00", 0},
+		{Date, "2024-01-15", "2024-01-16", -1},
+		{Time, "10:30:00", "9:00:00", 1},
+		{Time, "-01:00:00", "00:00:00", -1},
+	}
+	for _, tc := range testCases {
+		cmp, err := tc.typ.Compare(tc.a, tc.b)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, cmp, "%s %v %v", tc.typ.SQL(), tc.a, tc.b)
+	}
+
+	_, err := DateTime.Compare(nil, "2024-01-15")
+	require.Equal(t, ErrNullComparison, err)
+}
+
+func TestValueEqualsTemporal(t *testing.T) {
+	a, err := NewValue(DateTime, "2024-01-15 10:30:00")
+	require.NoError(t, err)
+	// The same instant in another location.
+	b, err := NewValue(DateTime, time.Date(2024, 1, 15, 18, 30, 0, 0, time.FixedZone("CST", 8*3600)))
+	require.NoError(t, err)
+	c, err := NewValue(DateTime, "2024-01-15 10:30:01")
+	require.NoError(t, err)
+	null, err := NewValue(DateTime, nil)
+	require.NoError(t, err)
+
+	eq, err := a.Equals(b)
+	require.NoError(t, err)
+	require.True(t, eq)
+
+	eq, err = a.Equals(c)
+	require.NoError(t, err)
+	require.False(t, eq)
+
+	cmp, err := a.Compare(c)
+	require.NoError(t, err)
+	require.Equal(t, -1, cmp)
+
+	eq, err = null.Equals(null)
+	require.NoError(t, err)
+	require.False(t, eq)
+}
+
+func TestAddInterval(t *testing.T) {
+	base := time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		n    int64
+		unit string
+		want time.Time
+	}{
+		{1, "DAY", time.Date(2024, 2, 1, 10, 30, 0, 0, time.UTC)},
+		{-31, "day", time.Date(2023, 12, 31, 10, 30, 0, 0, time.UTC)},
+		{2, "HOUR", time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC)},
+		{1, "WEEK", time.Date(2024, 2, 7, 10, 30, 0, 0, time.UTC)},
+		// The day is clamped to the end of shorter months.
+		{1, "MONTH", time.Date(2024, 2, 29, 10, 30, 0, 0, time.UTC)},
+		{-2, "MONTH", time.Date(2023, 11, 30, 10, 30, 0, 0, time.UTC)},
+		{1, "QUARTER", time.Date(2024, 4, 30, 10, 30, 0, 0, time.UTC)},
+		{1, "YEAR", time.Date(2025, 1, 31, 10, 30, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		got, err := AddInterval(base, tc.n, tc.unit)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, got, "%d %s", tc.n, tc.unit)
+	}
+
+	_, err := AddInterval(base, 1, "FORTNIGHT")
+	require.ErrorIs(t, err, ErrInvalidIntervalUnit)
+}
+
+func TestDateDiff(t *testing.T) {
+	a, err := ConvertToDateTime("2024-03-01 00:00:01")
+	require.NoError(t, err)
+	b, err := ConvertToDateTime("2024-02-28 23:59:59")
+	require.NoError(t, err)
+
+	require.Equal(t, int64(2), DateDiff(a, b))
+	require.Equal(t, int64(-2), DateDiff(b, a))
+	require.Equal(t, int64(0), DateDiff(a, a))
+}
@@ -17,7 +17,10 @@ package types
 import (
 	"bytes"
 	"encoding/gob"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 )
 
 // Value is a SQL value.
@@ -43,11 +46,47 @@ func (v *Value) IsNull() bool {
 	return v.data == nil
 }
 
-// Compare compares the value with another value.
+// Compare compares the value with another value, and returns -1, 0 or 1 as
+// it's smaller than, equal to or larger than the other. Numbers of
+// different types are compared in a type both fit in, as MySQL does: the
+// integers as integers, along with decimals as decimals, and along with
+// floats, or with strings, as floats. Temporal values are compared as
+// instants or durations, strings compared with them are read as values of
+// their type, and the other values are compared with the type of the
+// value. NULL can't be compared, and fails with ErrNullComparison.
 func (v *Value) Compare(other *Value) (int, error) {
+	if v.IsNull() || other.IsNull() {
+		return 0, ErrNullComparison
+	}
+
+	if cmp, ok, err := compareNumbers(v.data, other.data); ok {
+		return cmp, err
+	}
+	if cmp, ok := compareTemporals(v.data, other.data); ok {
+		return cmp, nil
+	}
+	if isTemporal(other.typ) && !isTemporal(v.typ) {
+		return other.typ.Compare(v.data, other.data)
+	}
 	return v.typ.Compare(v.data, other.data)
 }
 
+// Equals returns whether the value is equal to other, as Compare compares
+// them, so 5 is equal to 5.0 whatever their types. Temporal values are
+// equal if they are the same instant or duration, whatever their location.
+// NULL isn't equal to anything, not even to NULL.
+func (v *Value) Equals(other *Value) (bool, error) {
+	if v.IsNull() || other.IsNull() {
+		return false, nil
+	}
+
+	cmp, err := v.Compare(other)
+	if err != nil {
+		return false, err
+	}
+	return cmp == 0, nil
+}
+
 // ToBytes serializes the value to a byte slice.
 func (v *Value) ToBytes() ([]byte, error) {
 	var buf bytes.Buffer
@@ -77,6 +116,175 @@ func (v *Value) FromBytes(b []byte) error {
 	return dec.Decode(&v.data)
 }
 
+// jsonValue is the JSON form of a Value: the SQL name of its type, such as
+// BIGINT or DECIMAL(10,2), and its data in a form the type reads back
+// exactly.
+type jsonValue struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. Integers are numbers,
+// decimals are strings with all the digits of their scale, dates and
+// timestamps are RFC 3339 strings and TIME values are strings such as
+// -01:15:00.5, so they decode to the same Go type and value.
+func (v *Value) MarshalJSON() ([]byte, error) {
+	if v.typ == nil {
+		return nil, fmt.Errorf("cannot marshal a value without a type")
+	}
+
+	var data interface{}
+	if !v.IsNull() {
+		switch v.typ.QueryType() {
+		case TIMESTAMP, DATETIME, DATE:
+			t, ok := v.data.(time.Time)
+			if !ok {
+				return nil, fmt.Errorf("%w: cannot marshal %T as %s", ErrInvalidConversion, v.data, v.typ.SQL())
+			}
+			data = t.Format(time.RFC3339Nano)
+		case TIME:
+			d, ok := v.data.(time.Duration)
+			if !ok {
+				return nil, fmt.Errorf("%w: cannot marshal %T as %s", ErrInvalidConversion, v.data, v.typ.SQL())
+			}
+			data = formatTimeExact(d)
+		case DECIMAL:
+			d, err := ConvertToDecimal(v.data)
+			if err != nil {
+				return nil, err
+			}
+			data = d.String()
+		default:
+			data = v.data
+		}
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonValue{Type: v.typ.SQL(), Data: raw})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The data is
+// converted to the Go type of the values of the type it was marshaled with.
+func (v *Value) UnmarshalJSON(b []byte) error {
+	var jv jsonValue
+	if err := json.Unmarshal(b, &jv); err != nil {
+		return err
+	}
+
+	typ, err := typeFromSQL(jv.Type)
+	if err != nil {
+		return err
+	}
+
+	var data interface{}
+	dec := json.NewDecoder(bytes.NewReader(jv.Data))
+	dec.UseNumber()
+	if len(jv.Data) > 0 {
+		if err := dec.Decode(&data); err != nil {
+			return err
+		}
+	}
+
+	if data == nil {
+		v.typ, v.data = typ, nil
+		return nil
+	}
+
+	switch typ.QueryType() {
+	case TIMESTAMP, DATETIME, DATE:
+		s, ok := data.(string)
+		if !ok {
+			return fmt.Errorf("%w: cannot unmarshal %T as %s", ErrInvalidConversion, data, typ.SQL())
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidConversion, err)
+		}
+		if typ.QueryType() != TIMESTAMP {
+			t = t.UTC()
+		}
+		data = t
+	case INT64:
+		n, ok := data.(json.Number)
+		if !ok {
+			return fmt.Errorf("%w: cannot unmarshal %T as %s", ErrInvalidConversion, data, typ.SQL())
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidConversion, err)
+		}
+		data = i
+	case FLOAT64:
+		n, ok := data.(json.Number)
+		if !ok {
+			return fmt.Errorf("%w: cannot unmarshal %T as %s", ErrInvalidConversion, data, typ.SQL())
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidConversion, err)
+		}
+		data = f
+	default:
+		if data, err = typ.Convert(data); err != nil {
+			return err
+		}
+	}
+
+	v.typ, v.data = typ, data
+	return nil
+}
+
+// typeFromSQL returns the type with the given SQL name, as returned by the
+// SQL method of the types.
+func typeFromSQL(name string) (Type, error) {
+	switch name {
+	case Int64.SQL():
+		return Int64, nil
+	case Float64.SQL():
+		return Float64, nil
+	case Text.SQL():
+		return Text, nil
+	case Timestamp.SQL():
+		return Timestamp, nil
+	case DateTime.SQL():
+		return DateTime, nil
+	case Date.SQL():
+		return Date, nil
+	case Time.SQL():
+		return Time, nil
+	case Null.SQL():
+		return Null, nil
+	}
+
+	if strings.HasPrefix(name, "DECIMAL(") {
+		var precision, scale int
+		if _, err := fmt.Sscanf(name, "DECIMAL(%d,%d)", &precision, &scale); err != nil {
+			return nil, fmt.Errorf("unsupported type: %s", name)
+		}
+		return NewDecimalType(precision, scale)
+	}
+
+	return nil, fmt.Errorf("unsupported type: %s", name)
+}
+
+// formatTimeExact formats a TIME value as FormatTime does, but with all the
+// digits of its fraction of a second, so ParseTime reads it back exactly.
+func formatTimeExact(d time.Duration) string {
+	if d%time.Microsecond == 0 {
+		return FormatTime(d)
+	}
+
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	frac := fmt.Sprintf(".%09d", d%time.Second)
+	return sign + FormatTime(d-d%time.Second) + strings.TrimRight(frac, "0")
+}
+
 // AsInt64 returns the value as an int64.
 func (v *Value) AsInt64() (int64, error) {
 	if v.IsNull() {
@@ -100,3 +308,56 @@ func (v *Value) AsString() (string, error) {
 	}
 	return val, nil
 }
+
+// Clone returns a copy of the value that shares nothing with it. Composite
+// data, such as the arrays and objects of JSON documents, is copied deeply,
+// along with the values nested in it.
+func (v *Value) Clone() *Value {
+	if v == nil {
+		return nil
+	}
+	return &Value{typ: v.typ, data: cloneData(v.data)}
+}
+
+// cloneData returns a deep copy of the slices, maps and values in data.
+// Scalars are immutable, so they are returned as they are.
+func cloneData(data interface{}) interface{} {
+	switch d := data.(type) {
+	case *Value:
+		return d.Clone()
+	case []interface{}:
+		if d == nil {
+			return d
+		}
+		c := make([]interface{}, len(d))
+		for i, e := range d {
+			c[i] = cloneData(e)
+		}
+		return c
+	case map[string]interface{}:
+		if d == nil {
+			return d
+		}
+		c := make(map[string]interface{}, len(d))
+		for k, e := range d {
+			c[k] = cloneData(e)
+		}
+		return c
+	case map[interface{}]interface{}:
+		if d == nil {
+			return d
+		}
+		c := make(map[interface{}]interface{}, len(d))
+		for k, e := range d {
+			c[k] = cloneData(e)
+		}
+		return c
+	case []byte:
+		if d == nil {
+			return d
+		}
+		return append([]byte{}, d...)
+	default:
+		return data
+	}
+}
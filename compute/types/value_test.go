@@ -16,6 +16,7 @@ package types
 
 import (
 	"encoding/gob"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -58,3 +59,168 @@ func TestValueSerialization(t *testing.T) {
 		})
 	}
 }
+
+func TestValueClone(t *testing.T) {
+	t.Run("array", func(t *testing.T) {
+		data := []interface{}{1, 2, 3}
+		v := &Value{data: data}
+		c := v.Clone()
+
+		data[0] = 100
+		data = append(data, 4)
+		require.Equal(t, []interface{}{1, 2, 3}, c.data)
+	})
+
+	t.Run("map", func(t *testing.T) {
+		data := map[string]interface{}{"a": 1, "nested": []interface{}{"x"}}
+		v := &Value{data: data}
+		c := v.Clone()
+
+		data["a"] = 2
+		data["b"] = 3
+		data["nested"].([]interface{})[0] = "y"
+		require.Equal(t, map[string]interface{}{"a": 1, "nested": []interface{}{"x"}}, c.data)
+	})
+
+	t.Run("nested values", func(t *testing.T) {
+		inner := &Value{typ: Text, data: "a"}
+		v := &Value{data: []interface{}{inner}}
+		c := v.Clone()
+
+		inner.data = "b"
+		require.Equal(t, "a", c.data.([]interface{})[0].(*Value).data)
+	})
+
+	t.Run("scalar", func(t *testing.T) {
+		v := &Value{typ: Int64, data: int64(1)}
+		require.Equal(t, v, v.Clone())
+		require.NotSame(t, v, v.Clone())
+	})
+}
+
+func TestValueJSON(t *testing.T) {
+	decimalType, err := NewDecimalType(12, 4)
+	require.NoError(t, err)
+
+	at := time.Date(2024, 1, 15, 10, 30, 0, 123456789, time.FixedZone("CET", 3600))
+	testCases := []struct {
+		name string
+		val  *Value
+	}{
+		{"int64", &Value{typ: Int64, data: int64(1<<62 + 1)}},
+		{"text", &Value{typ: Text, data: "héllo, 世界"}},
+		{"timestamp", &Value{typ: Timestamp, data: at}},
+		{"datetime", &Value{typ: DateTime, data: at.UTC()}},
+		{"date", &Value{typ: Date, data: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)}},
+		{"time", &Value{typ: Time, data: -(75*time.Minute + 500*time.Millisecond)}},
+		{"time with nanoseconds", &Value{typ: Time, data: -(time.Second/2 + 1)}},
+		{"decimal", &Value{typ: decimalType, data: NewDecimal(-1234567, 4)}},
+		{"default decimal", &Value{typ: DefaultDecimal, data: NewDecimal(42, 0)}},
+		{"double", &Value{typ: Float64, data: 2.5}},
+		{"null", &Value{typ: Null, data: nil}},
+		{"null int64", &Value{typ: Int64, data: nil}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(tc.val)
+			require.NoError(t, err)
+
+			var decoded Value
+			require.NoError(t, json.Unmarshal(b, &decoded))
+			require.Equal(t, tc.val.typ.SQL(), decoded.typ.SQL())
+			require.IsType(t, tc.val.data, decoded.data)
+
+			switch data := tc.val.data.(type) {
+			case time.Time:
+				require.True(t, data.Equal(decoded.data.(time.Time)))
+				_, offset := data.Zone()
+				_, decodedOffset := decoded.data.(time.Time).Zone()
+				require.Equal(t, offset, decodedOffset)
+			case Decimal:
+				require.Equal(t, data.String(), decoded.data.(Decimal).String())
+			default:
+				require.Equal(t, tc.val.data, decoded.data)
+			}
+		})
+	}
+
+	t.Run("in a row", func(t *testing.T) {
+		row := []*Value{{typ: Int64, data: int64(1)}, {typ: Text, data: "a"}}
+		b, err := json.Marshal(row)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"type":"BIGINT","data":1},{"type":"TEXT","data":"a"}]`, string(b))
+
+		var decoded []*Value
+		require.NoError(t, json.Unmarshal(b, &decoded))
+		require.Equal(t, row, decoded)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var v Value
+		require.Error(t, json.Unmarshal([]byte(`{"type":"GEOMETRY","data":1}`), &v))
+		require.Error(t, json.Unmarshal([]byte(`{"type":"BIGINT","data":"1"}`), &v))
+		require.Error(t, json.Unmarshal([]byte(`{"type":"DATE","data":"yesterday"}`), &v))
+
+		_, err := json.Marshal(&Value{data: int64(1)})
+		require.Error(t, err)
+	})
+}
+
+func TestValueCompare(t *testing.T) {
+	decimalType, err := NewDecimalType(10, 2)
+	require.NoError(t, err)
+
+	at := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		name string
+		a, b *Value
+		cmp  int
+	}{
+		{"int32 and int64", &Value{typ: Int64, data: int32(5)}, &Value{typ: Int64, data: int64(5)}, 0},
+		{"uint64 over int64", &Value{typ: Int64, data: uint64(1 << 63)}, &Value{typ: Int64, data: int64(-1)}, 1},
+		{"negative and uint64", &Value{typ: Int64, data: int64(-1)}, &Value{typ: Int64, data: uint64(0)}, -1},
+		{"float and int", &Value{typ: Float64, data: 5.0}, &Value{typ: Int64, data: int64(5)}, 0},
+		{"int and float", &Value{typ: Int64, data: int64(5)}, &Value{typ: Float64, data: 5.5}, -1},
+		{"float and decimal", &Value{typ: Float64, data: 1.25}, &Value{typ: decimalType, data: NewDecimal(125, 2)}, 0},
+		{"decimals of different scales", &Value{typ: decimalType, data: NewDecimal(1999, 3)}, &Value{typ: DefaultDecimal, data: NewDecimal(2, 0)}, -1},
+		{"negative decimals", &Value{typ: decimalType, data: NewDecimal(-150, 2)}, &Value{typ: decimalType, data: NewDecimal(-15, 1)}, 0},
+		{"decimal and int", &Value{typ: decimalType, data: NewDecimal(1001, 2)}, &Value{typ: Int64, data: int64(10)}, 1},
+		{"numeric string and int", &Value{typ: Text, data: "10"}, &Value{typ: Int64, data: int64(9)}, 1},
+		{"strings", &Value{typ: Text, data: "10"}, &Value{typ: Text, data: "9"}, -1},
+		{"times in other locations", &Value{typ: Timestamp, data: at}, &Value{typ: DateTime, data: at.In(time.FixedZone("CET", 3600))}, 0},
+		{"string and date", &Value{typ: Text, data: "2024-01-16"}, &Value{typ: Date, data: at}, 1},
+		{"durations", &Value{typ: Time, data: time.Hour}, &Value{typ: Time, data: time.Minute}, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmp, err := tc.a.Compare(tc.b)
+			require.NoError(t, err)
+			require.Equal(t, tc.cmp, cmp)
+
+			cmp, err = tc.b.Compare(tc.a)
+			require.NoError(t, err)
+			require.Equal(t, -tc.cmp, cmp)
+
+			equal, err := tc.a.Equals(tc.b)
+			require.NoError(t, err)
+			require.Equal(t, tc.cmp == 0, equal)
+		})
+	}
+
+	t.Run("null", func(t *testing.T) {
+		null := &Value{typ: Null}
+		_, err := null.Compare(&Value{typ: Int64, data: int64(1)})
+		require.ErrorIs(t, err, ErrNullComparison)
+
+		equal, err := null.Equals(null)
+		require.NoError(t, err)
+		require.False(t, equal)
+	})
+
+	t.Run("non-numeric string and int", func(t *testing.T) {
+		_, err := (&Value{typ: Text, data: "abc"}).Compare(&Value{typ: Int64, data: int64(1)})
+		require.ErrorIs(t, err, ErrInvalidConversion)
+	})
+}
@@ -2,6 +2,7 @@
 package config
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -24,16 +25,92 @@ type ServerConfig struct {
 	WriteTimeout    time.Duration `yaml:"write_timeout" mapstructure:"write_timeout"`
 	IdleTimeout     time.Duration `yaml:"idle_timeout" mapstructure:"idle_timeout"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" mapstructure:"shutdown_timeout"`
+	// MaxExecutionTime is the longest a query may run before it's
+	// interrupted. Zero means queries run for as long as they need.
+	MaxExecutionTime time.Duration `yaml:"max_execution_time" mapstructure:"max_execution_time"`
+	// LockWaitTimeout is how long a transaction waits for a row locked by
+	// another one before the statement fails.
+	LockWaitTimeout time.Duration `yaml:"lock_wait_timeout" mapstructure:"lock_wait_timeout"`
+	// ResultBatchSize is the number of rows of a result set sent to the
+	// client at a time, which bounds the memory a query result takes.
+	ResultBatchSize int `yaml:"result_batch_size" mapstructure:"result_batch_size"`
+	// MaxResultRows is the most rows a result set may have, so a query
+	// can't exhaust the memory of the server. Zero means no limit.
+	MaxResultRows int64 `yaml:"max_result_rows" mapstructure:"max_result_rows"`
+	// TruncateResults cuts the result sets over MaxResultRows short with a
+	// warning, instead of failing their queries.
+	TruncateResults bool `yaml:"truncate_results" mapstructure:"truncate_results"`
+	// ScanParallelism is the most partitions of a table scanned at the
+	// same time by a query. 1 scans them one after the other.
+	ScanParallelism int `yaml:"scan_parallelism" mapstructure:"scan_parallelism"`
+	// GRPCPort is the port of the gRPC management service. The service is
+	// not started if it's zero.
+	GRPCPort int `yaml:"grpc_port" mapstructure:"grpc_port"`
+	// HTTPPort is the port of the HTTP query gateway, which runs the SQL
+	// posted to /query without authenticating the clients. The gateway is
+	// not started if it's zero.
+	HTTPPort int `yaml:"http_port" mapstructure:"http_port"`
+	// ReplicaOf is the gRPC address of the primary this node is a read
+	// replica of. The node is a primary if it's empty.
+	ReplicaOf string `yaml:"replica_of" mapstructure:"replica_of"`
+	// QueryCache keeps the results of SELECT queries to be sent again when
+	// the same queries are run.
+	QueryCache QueryCacheConfig `yaml:"query_cache" mapstructure:"query_cache"`
+	// StatementCacheSize is the number of plans of statements each session
+	// keeps, so the statements run again, or that only differ in their
+	// literals, are not parsed again.
+	StatementCacheSize int `yaml:"statement_cache_size" mapstructure:"statement_cache_size"`
+	// MaxAllowedPacket is the largest packet a client may send, in bytes,
+	// which bounds the size of its queries and of the values they have.
+	// Zero means 64MB.
+	MaxAllowedPacket int `yaml:"max_allowed_packet" mapstructure:"max_allowed_packet"`
+	// ConnectRate is the most new connections let in per second, so the
+	// clients reconnecting at once after a restart are set up over time.
+	// Zero means no limit.
+	ConnectRate float64 `yaml:"connect_rate" mapstructure:"connect_rate"`
+	// ConnectBurst is the most connections let in at once under
+	// ConnectRate. Zero means ConnectRate, rounded up.
+	ConnectBurst int `yaml:"connect_burst" mapstructure:"connect_burst"`
+	// ConnectQueueTimeout is the longest a connection over ConnectRate
+	// waits to be let in before it's rejected. Zero means 1s.
+	ConnectQueueTimeout time.Duration `yaml:"connect_queue_timeout" mapstructure:"connect_queue_timeout"`
+}
+
+// QueryCacheConfig holds query result cache configuration.
+type QueryCacheConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+	// Capacity is the most results kept. The least recently used ones are
+	// dropped to make room for new ones.
+	Capacity int `yaml:"capacity" mapstructure:"capacity"`
 }
 
 // StorageConfig holds storage-related configuration.
 type StorageConfig struct {
+	// Engine is the name of the storage engine, such as badger, or memory
+	// to keep everything in memory. BadgerDB is used if it's empty.
+	Engine          string `yaml:"engine" mapstructure:"engine"`
 	DataDir         string `yaml:"data_dir" mapstructure:"data_dir"`
 	WALDir          string `yaml:"wal_dir" mapstructure:"wal_dir"`
 	MaxMemTableSize int64  `yaml:"max_memtable_size" mapstructure:"max_memtable_size"`
 	NumCompactors   int    `yaml:"num_compactors" mapstructure:"num_compactors"`
 	SyncWrites      bool   `yaml:"sync_writes" mapstructure:"sync_writes"`
 	ValueLogGC      bool   `yaml:"valuelog_gc" mapstructure:"valuelog_gc"`
+	// ValueLogGCInterval is the time between two value log garbage
+	// collections of each database.
+	ValueLogGCInterval time.Duration `yaml:"valuelog_gc_interval" mapstructure:"valuelog_gc_interval"`
+	// ValueLogGCDiscardRatio is the least fraction of a value log file
+	// that must be stale for the collection to rewrite it.
+	ValueLogGCDiscardRatio float64 `yaml:"valuelog_gc_discard_ratio" mapstructure:"valuelog_gc_discard_ratio"`
+	// RecoverCorruption opens the storage even if its value log is
+	// corrupted, as it may be after an unclean shutdown, dropping the values
+	// that can't be read. Otherwise the tables that lost values can't be
+	// used and a value log that can't be opened keeps the server from
+	// starting.
+	RecoverCorruption bool `yaml:"recover_corruption" mapstructure:"recover_corruption"`
+	// OperationMetrics records the number and latency of the reads,
+	// writes, scans and commits of the storage, which are exported with
+	// the other metrics.
+	OperationMetrics bool `yaml:"operation_metrics" mapstructure:"operation_metrics"`
 }
 
 // SecurityConfig holds security-related configuration.
@@ -44,6 +121,24 @@ type SecurityConfig struct {
 	MaxAuthAttempts int            `yaml:"max_auth_attempts" mapstructure:"max_auth_attempts"`
 	LockDuration    time.Duration  `yaml:"lock_duration" mapstructure:"lock_duration"`
 	AuditLog        AuditLogConfig `yaml:"audit_log" mapstructure:"audit_log"`
+	// AuthProvider is the name of the registered authentication provider
+	// the users are checked by, such as native for the passwords of the
+	// users created in the server. Providers that need the passwords of
+	// the clients, as LDAP ones do, get them in clear text, so clients
+	// should connect with TLS.
+	AuthProvider string `yaml:"auth_provider" mapstructure:"auth_provider"`
+	// AuthProviderOptions are the settings of the provider, such as the
+	// address of a LDAP directory.
+	AuthProviderOptions map[string]string `yaml:"auth_provider_options" mapstructure:"auth_provider_options"`
+	// ProtectDatabases lists the databases that can't be dropped unless the
+	// drop is explicitly confirmed in the session first.
+	ProtectDatabases []string `yaml:"protect_databases" mapstructure:"protect_databases"`
+	// TLSCertFile and TLSKeyFile are the PEM files of the certificate and
+	// private key of the MySQL listener. TLS is disabled unless both are set.
+	TLSCertFile string `yaml:"tls_cert_file" mapstructure:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file" mapstructure:"tls_key_file"`
+	// RequireSecureTransport rejects clients that don't connect with TLS.
+	RequireSecureTransport bool `yaml:"require_secure_transport" mapstructure:"require_secure_transport"`
 }
 
 // AuditLogConfig holds audit logging configuration.
@@ -51,6 +146,15 @@ type AuditLogConfig struct {
 	Enabled  bool   `yaml:"enabled" mapstructure:"enabled"`
 	FilePath string `yaml:"file_path" mapstructure:"file_path"`
 	Async    bool   `yaml:"async" mapstructure:"async"`
+	// Format is json, which writes each event as a JSON object for the
+	// tools that ingest the log, or text. Empty means json.
+	Format string `yaml:"format" mapstructure:"format"`
+	// EventTypes are the types of the events logged, such as QUERY or
+	// AUTHENTICATION. Empty means all of them.
+	EventTypes []string `yaml:"event_types" mapstructure:"event_types"`
+	// Users are the users whose events are logged. Empty means all of
+	// them.
+	Users []string `yaml:"users" mapstructure:"users"`
 }
 
 // ObservabilityConfig holds observability configuration.
@@ -65,10 +169,22 @@ type ObservabilityConfig struct {
 type LoggingConfig struct {
 	Level      string `yaml:"level" mapstructure:"level"`
 	Format     string `yaml:"format" mapstructure:"format"`        // json, text
-	Output     string `yaml:"output" mapstructure:"output"`        // stdout, file path
+	Output     string `yaml:"output" mapstructure:"output"`        // stdout, stderr, file path
 	MaxSize    int    `yaml:"max_size" mapstructure:"max_size"`    // MB
 	MaxBackups int    `yaml:"max_backups" mapstructure:"max_backups"`
 	MaxAge     int    `yaml:"max_age" mapstructure:"max_age"`      // days
+	// SlowLog is the log of the statements that take too long. It's
+	// rotated as the main log is.
+	SlowLog SlowLogConfig `yaml:"slow_log" mapstructure:"slow_log"`
+}
+
+// SlowLogConfig holds slow query logging configuration.
+type SlowLogConfig struct {
+	Enabled  bool   `yaml:"enabled" mapstructure:"enabled"`
+	FilePath string `yaml:"file_path" mapstructure:"file_path"`
+	// Threshold is the shortest a statement must take to be logged. Zero
+	// logs every statement.
+	Threshold time.Duration `yaml:"threshold" mapstructure:"threshold"`
 }
 
 // Validate validates the entire configuration.
@@ -87,6 +203,9 @@ func (c *Config) Validate() error {
 	if err := c.Logging.Validate(); err != nil {
 		errs = append(errs, err)
 	}
+	if c.Server.ReplicaOf != "" && c.Storage.Engine != "" && c.Storage.Engine != "badger" {
+		errs = append(errs, fmt.Errorf("server.replica_of: replicas must use the badger storage engine, got %q", c.Storage.Engine))
+	}
 
 	if len(errs) > 0 {
 		return &ValidationError{Errors: errs}
@@ -17,6 +17,8 @@ func TestDefaultConfig(t *testing.T) {
 	require.False(t, cfg.Security.Enabled)
 	require.True(t, cfg.Observability.Enabled)
 	require.Equal(t, "info", cfg.Logging.Level)
+	require.Equal(t, 100, cfg.Server.ResultBatchSize)
+	require.Equal(t, 4, cfg.Server.ScanParallelism)
 }
 
 func TestConfigValidation(t *testing.T) {
@@ -55,11 +57,43 @@ func TestConfigValidation(t *testing.T) {
 			modify:  func(c *Config) { c.Server.MaxConnections = -1 },
 			wantErr: true,
 		},
+		{
+			name:    "TLS certificate without key",
+			modify:  func(c *Config) { c.Security.TLSCertFile = "server.crt" },
+			wantErr: true,
+		},
+		{
+			name:    "secure transport without TLS",
+			modify:  func(c *Config) { c.Security.RequireSecureTransport = true },
+			wantErr: true,
+		},
+		{
+			name: "secure transport with TLS",
+			modify: func(c *Config) {
+				c.Security.TLSCertFile = "server.crt"
+				c.Security.TLSKeyFile = "server.key"
+				c.Security.RequireSecureTransport = true
+			},
+			wantErr: false,
+		},
 		{
 			name:    "invalid memtable size",
 			modify:  func(c *Config) { c.Storage.MaxMemTableSize = 100 }, // < 1MB
 			wantErr: true,
 		},
+		{
+			name: "query cache enabled without capacity",
+			modify: func(c *Config) {
+				c.Server.QueryCache.Enabled = true
+				c.Server.QueryCache.Capacity = 0
+			},
+			wantErr: true,
+		},
+		{
+			name:    "negative query cache capacity",
+			modify:  func(c *Config) { c.Server.QueryCache.Capacity = -1 },
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -115,3 +149,19 @@ func TestObservabilityConfig(t *testing.T) {
 	require.Equal(t, "/metrics", cfg.Observability.MetricsPath)
 	require.True(t, cfg.Observability.EnablePprof)
 }
+
+func TestCheckReload(t *testing.T) {
+	cfg := Default()
+
+	next := Default()
+	next.Logging.Level = "debug"
+	next.Logging.SlowLog.Threshold = time.Second
+	next.Server.MaxConnections = 10
+	require.NoError(t, cfg.CheckReload(next))
+
+	next.Server.Port = 3307
+	next.Storage.DataDir = "/elsewhere"
+	err := cfg.CheckReload(next)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "server.port, storage.data_dir")
+}
@@ -14,19 +14,35 @@ func Default() *Config {
 			WriteTimeout:    30 * time.Second,
 			IdleTimeout:     8 * time.Hour,
 			ShutdownTimeout: 30 * time.Second,
+			LockWaitTimeout: 50 * time.Second,
+			ResultBatchSize: 100,
+			ScanParallelism: 4,
+			GRPCPort:        50051,
+			QueryCache: QueryCacheConfig{
+				Enabled:  false,
+				Capacity: 1024,
+			},
+			StatementCacheSize: 256,
+			MaxAllowedPacket:   64 << 20,
 		},
 		Storage: StorageConfig{
+			Engine:          "badger",
 			DataDir:         "./data",
 			WALDir:          "",        // Default to DataDir
 			MaxMemTableSize: 64 << 20,  // 64MB
 			NumCompactors:   4,
 			SyncWrites:      false,
 			ValueLogGC:      true,
+
+			ValueLogGCInterval:     10 * time.Minute,
+			ValueLogGCDiscardRatio: 0.5,
+			RecoverCorruption:      true,
 		},
 		Security: SecurityConfig{
 			Enabled:         false,
 			RootPassword:    "",
 			AuthPlugin:      "mysql_native_password",
+			AuthProvider:    "native",
 			MaxAuthAttempts: 5,
 			LockDuration:    15 * time.Minute,
 			AuditLog: AuditLogConfig{
@@ -48,6 +64,11 @@ func Default() *Config {
 			MaxSize:    100,
 			MaxBackups: 3,
 			MaxAge:     7,
+			SlowLog: SlowLogConfig{
+				Enabled:   false,
+				FilePath:  "./slow.log",
+				Threshold: time.Second,
+			},
 		},
 	}
 }
@@ -81,8 +102,29 @@ func (c *Config) ApplyDefaults() {
 	if c.Server.ShutdownTimeout == 0 {
 		c.Server.ShutdownTimeout = defaults.Server.ShutdownTimeout
 	}
+	if c.Server.LockWaitTimeout == 0 {
+		c.Server.LockWaitTimeout = defaults.Server.LockWaitTimeout
+	}
+	if c.Server.ResultBatchSize == 0 {
+		c.Server.ResultBatchSize = defaults.Server.ResultBatchSize
+	}
+	if c.Server.ScanParallelism == 0 {
+		c.Server.ScanParallelism = defaults.Server.ScanParallelism
+	}
+	if c.Server.QueryCache.Capacity == 0 {
+		c.Server.QueryCache.Capacity = defaults.Server.QueryCache.Capacity
+	}
+	if c.Server.StatementCacheSize == 0 {
+		c.Server.StatementCacheSize = defaults.Server.StatementCacheSize
+	}
+	if c.Server.MaxAllowedPacket == 0 {
+		c.Server.MaxAllowedPacket = defaults.Server.MaxAllowedPacket
+	}
 
 	// Storage defaults
+	if c.Storage.Engine == "" {
+		c.Storage.Engine = defaults.Storage.Engine
+	}
 	if c.Storage.DataDir == "" {
 		c.Storage.DataDir = defaults.Storage.DataDir
 	}
@@ -92,11 +134,20 @@ func (c *Config) ApplyDefaults() {
 	if c.Storage.NumCompactors == 0 {
 		c.Storage.NumCompactors = defaults.Storage.NumCompactors
 	}
+	if c.Storage.ValueLogGCInterval == 0 {
+		c.Storage.ValueLogGCInterval = defaults.Storage.ValueLogGCInterval
+	}
+	if c.Storage.ValueLogGCDiscardRatio == 0 {
+		c.Storage.ValueLogGCDiscardRatio = defaults.Storage.ValueLogGCDiscardRatio
+	}
 
 	// Security defaults
 	if c.Security.AuthPlugin == "" {
 		c.Security.AuthPlugin = defaults.Security.AuthPlugin
 	}
+	if c.Security.AuthProvider == "" {
+		c.Security.AuthProvider = defaults.Security.AuthProvider
+	}
 	if c.Security.MaxAuthAttempts == 0 {
 		c.Security.MaxAuthAttempts = defaults.Security.MaxAuthAttempts
 	}
@@ -134,4 +185,7 @@ func (c *Config) ApplyDefaults() {
 	if c.Logging.MaxAge == 0 {
 		c.Logging.MaxAge = defaults.Logging.MaxAge
 	}
+	if c.Logging.SlowLog.FilePath == "" {
+		c.Logging.SlowLog.FilePath = defaults.Logging.SlowLog.FilePath
+	}
 }
@@ -24,6 +24,10 @@ func NewLoader() *Loader {
 	v.BindEnv("server.host")
 	v.BindEnv("server.port")
 	v.BindEnv("server.max_connections")
+	v.BindEnv("server.grpc_port")
+	v.BindEnv("server.http_port")
+	v.BindEnv("server.replica_of")
+	v.BindEnv("storage.engine")
 	v.BindEnv("storage.data_dir")
 	v.BindEnv("storage.sync_writes")
 	v.BindEnv("security.enabled")
@@ -95,6 +99,9 @@ func (l *Loader) BindFlags(flags *pflag.FlagSet) {
 	if f := flags.Lookup("host"); f != nil {
 		l.v.BindPFlag("server.host", f)
 	}
+	if f := flags.Lookup("replica-of"); f != nil {
+		l.v.BindPFlag("server.replica_of", f)
+	}
 	if f := flags.Lookup("data-dir"); f != nil {
 		l.v.BindPFlag("storage.data_dir", f)
 	}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// reloadable are the settings that can be changed while the server runs,
+// by their key in the configuration file.
+var reloadable = map[string]bool{
+	"server.max_connections":     true,
+	"logging.level":              true,
+	"logging.slow_log.threshold": true,
+}
+
+// CheckReload checks that next changes only the settings that can be
+// changed while the server runs. The error names the other settings it
+// changes, which need a restart.
+func (c *Config) CheckReload(next *Config) error {
+	var changed []string
+	diffSettings("", reflect.ValueOf(*c), reflect.ValueOf(*next), &changed)
+	if len(changed) > 0 {
+		return fmt.Errorf("settings can't be changed without a restart: %s", strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// diffSettings appends to changed the keys of the settings that differ
+// between a and b, other than the reloadable ones.
+func diffSettings(prefix string, a, b reflect.Value, changed *[]string) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if reloadable[key] {
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Struct {
+			diffSettings(key, a.Field(i), b.Field(i), changed)
+		} else if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			*changed = append(*changed, key)
+		}
+	}
+}
+
+// SlogLevel returns the level of the logs. Unknown levels, which Validate
+// rejects, are taken as info.
+func (c LoggingConfig) SlogLevel() slog.Level {
+	switch strings.ToLower(c.Level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
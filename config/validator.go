@@ -27,6 +27,18 @@ func (c *ServerConfig) Validate() error {
 		errs = append(errs, fmt.Errorf("server.port: must be between 1 and 65535, got %d", c.Port))
 	}
 
+	if c.GRPCPort < 0 || c.GRPCPort > 65535 {
+		errs = append(errs, fmt.Errorf("server.grpc_port: must be between 0 and 65535, got %d", c.GRPCPort))
+	} else if c.GRPCPort != 0 && c.GRPCPort == c.Port {
+		errs = append(errs, fmt.Errorf("server.grpc_port: must differ from server.port, got %d", c.GRPCPort))
+	}
+
+	if c.HTTPPort < 0 || c.HTTPPort > 65535 {
+		errs = append(errs, fmt.Errorf("server.http_port: must be between 0 and 65535, got %d", c.HTTPPort))
+	} else if c.HTTPPort != 0 && (c.HTTPPort == c.Port || c.HTTPPort == c.GRPCPort) {
+		errs = append(errs, fmt.Errorf("server.http_port: must differ from server.port and server.grpc_port, got %d", c.HTTPPort))
+	}
+
 	if c.MaxConnections < 1 {
 		errs = append(errs, fmt.Errorf("server.max_connections: must be positive, got %d", c.MaxConnections))
 	}
@@ -47,6 +59,52 @@ func (c *ServerConfig) Validate() error {
 		errs = append(errs, fmt.Errorf("server.write_timeout: must be non-negative, got %v", c.WriteTimeout))
 	}
 
+	if c.MaxExecutionTime < 0 {
+		errs = append(errs, fmt.Errorf("server.max_execution_time: must be non-negative, got %v", c.MaxExecutionTime))
+	}
+
+	if c.LockWaitTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server.lock_wait_timeout: must be non-negative, got %v", c.LockWaitTimeout))
+	}
+
+	if c.ResultBatchSize < 0 {
+		errs = append(errs, fmt.Errorf("server.result_batch_size: must be non-negative, got %d", c.ResultBatchSize))
+	}
+
+	if c.ScanParallelism < 0 {
+		errs = append(errs, fmt.Errorf("server.scan_parallelism: must be non-negative, got %d", c.ScanParallelism))
+	}
+
+	if c.MaxResultRows < 0 {
+		errs = append(errs, fmt.Errorf("server.max_result_rows: must be non-negative, got %d", c.MaxResultRows))
+	}
+
+	if c.QueryCache.Capacity < 0 {
+		errs = append(errs, fmt.Errorf("server.query_cache.capacity: must be non-negative, got %d", c.QueryCache.Capacity))
+	} else if c.QueryCache.Enabled && c.QueryCache.Capacity == 0 {
+		errs = append(errs, fmt.Errorf("server.query_cache.capacity: must be positive when the query cache is enabled"))
+	}
+
+	if c.StatementCacheSize < 0 {
+		errs = append(errs, fmt.Errorf("server.statement_cache_size: must be non-negative, got %d", c.StatementCacheSize))
+	}
+
+	if c.MaxAllowedPacket != 0 && (c.MaxAllowedPacket < 1<<10 || c.MaxAllowedPacket > 1<<30) {
+		errs = append(errs, fmt.Errorf("server.max_allowed_packet: must be between 1KB and 1GB, got %d", c.MaxAllowedPacket))
+	}
+
+	if c.ConnectRate < 0 {
+		errs = append(errs, fmt.Errorf("server.connect_rate: must be non-negative, got %v", c.ConnectRate))
+	}
+
+	if c.ConnectBurst < 0 {
+		errs = append(errs, fmt.Errorf("server.connect_burst: must be non-negative, got %d", c.ConnectBurst))
+	}
+
+	if c.ConnectQueueTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server.connect_queue_timeout: must be non-negative, got %v", c.ConnectQueueTimeout))
+	}
+
 	if len(errs) > 0 {
 		return &ValidationError{Errors: errs}
 	}
@@ -69,6 +127,15 @@ func (c *StorageConfig) Validate() error {
 		errs = append(errs, fmt.Errorf("storage.num_compactors: must be positive, got %d", c.NumCompactors))
 	}
 
+	if c.ValueLogGC {
+		if c.ValueLogGCInterval <= 0 {
+			errs = append(errs, fmt.Errorf("storage.valuelog_gc_interval: must be positive, got %s", c.ValueLogGCInterval))
+		}
+		if c.ValueLogGCDiscardRatio <= 0 || c.ValueLogGCDiscardRatio >= 1 {
+			errs = append(errs, fmt.Errorf("storage.valuelog_gc_discard_ratio: must be between 0 and 1, got %g", c.ValueLogGCDiscardRatio))
+		}
+	}
+
 	if len(errs) > 0 {
 		return &ValidationError{Errors: errs}
 	}
@@ -97,6 +164,40 @@ func (c *SecurityConfig) Validate() error {
 		}
 	}
 
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("security.tls_cert_file and security.tls_key_file: must be set together"))
+	}
+	if c.RequireSecureTransport && c.TLSCertFile == "" {
+		errs = append(errs, fmt.Errorf("security.require_secure_transport: needs security.tls_cert_file and security.tls_key_file"))
+	}
+
+	switch strings.ToLower(c.AuditLog.Format) {
+	case "", "json", "text":
+	default:
+		errs = append(errs, fmt.Errorf("security.audit_log.format: invalid format %q (must be json or text)", c.AuditLog.Format))
+	}
+
+	validEventTypes := map[string]bool{
+		"AUTHENTICATION": true,
+		"AUTHORIZATION":  true,
+		"QUERY":          true,
+		"DDL":            true,
+		"DML":            true,
+		"ADMIN":          true,
+		"CONNECTION":     true,
+	}
+	for i, typ := range c.AuditLog.EventTypes {
+		if !validEventTypes[strings.ToUpper(typ)] {
+			errs = append(errs, fmt.Errorf("security.audit_log.event_types[%d]: unknown event type %q", i, typ))
+		}
+	}
+
+	for i, name := range c.ProtectDatabases {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, fmt.Errorf("security.protect_databases[%d]: must not be empty", i))
+		}
+	}
+
 	if len(errs) > 0 {
 		return &ValidationError{Errors: errs}
 	}
@@ -135,5 +236,13 @@ func (c *LoggingConfig) Validate() error {
 		return fmt.Errorf("logging.max_age: must be non-negative, got %d", c.MaxAge)
 	}
 
+	if c.SlowLog.Threshold < 0 {
+		return fmt.Errorf("logging.slow_log.threshold: must be non-negative, got %v", c.SlowLog.Threshold)
+	}
+
+	if c.SlowLog.Enabled && c.SlowLog.FilePath == "" {
+		return fmt.Errorf("logging.slow_log.file_path: required when the slow query log is enabled")
+	}
+
 	return nil
 }
@@ -118,6 +118,57 @@ func TestValidateAuthPlugin(t *testing.T) {
 	}
 }
 
+func TestValidateProtectDatabases(t *testing.T) {
+	tests := []struct {
+		name    string
+		dbs     []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"valid", []string{"prod", "billing"}, false},
+		{"empty name", []string{"prod", " "}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := SecurityConfig{ProtectDatabases: tt.dbs}
+			err := cfg.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateReplicaOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		engine  string
+		wantErr bool
+	}{
+		{"badger", "badger", false},
+		{"default engine", "", false},
+		{"memory", "memory", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Server.ReplicaOf = "primary:50051"
+			cfg.Storage.Engine = tt.engine
+			err := cfg.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "replica_of")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestValidateLogLevel(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -1,8 +1,10 @@
 package integration
 
 import (
+	"os"
 	"testing"
 
+	"github.com/stretchr/testify/require"
 	"github.com/turtacn/guocedb/integration/testutil"
 )
 
@@ -118,3 +120,27 @@ func TestE2E_AlterTable(t *testing.T) {
 
 	client.Exec("DROP DATABASE testdb")
 }
+
+// TestE2E_MemoryStorageEngine tests a server that keeps its data in memory
+func TestE2E_MemoryStorageEngine(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	dataDir := t.TempDir()
+	ts := testutil.NewTestServer(t, testutil.WithDataDir(dataDir), testutil.WithStorageEngine("memory")).Start()
+	defer ts.Stop()
+
+	client := testutil.NewTestClient(t, ts.DSN())
+	defer client.Close()
+
+	client.Exec("CREATE DATABASE memdb")
+	client.Exec("USE memdb")
+	require.Equal(t, 1, client.MustQueryInt("SELECT 1"))
+	client.Exec("DROP DATABASE memdb")
+
+	// Nothing is written to the data directory.
+	entries, err := os.ReadDir(dataDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
@@ -0,0 +1,70 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	mgmtv1 "github.com/turtacn/guocedb/api/protobuf/mgmt/v1"
+	"github.com/turtacn/guocedb/integration/testutil"
+)
+
+// TestE2E_ManagementService tests managing databases over gRPC
+func TestE2E_ManagementService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	ts := testutil.NewTestServer(t, testutil.WithGRPC()).Start()
+	defer ts.Stop()
+
+	conn, err := grpc.NewClient(ts.GRPCAddr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	mgmt := mgmtv1.NewManagementServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := testutil.NewTestClient(t, ts.DSN())
+	defer client.Close()
+
+	st, err := mgmt.GetServerStatus(ctx, &mgmtv1.GetServerStatusRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "SERVING", st.Status)
+	require.NotEmpty(t, st.Version)
+	require.GreaterOrEqual(t, st.ActiveConnections, uint64(1))
+
+	_, err = mgmt.CreateDatabase(ctx, &mgmtv1.CreateDatabaseRequest{Name: "grpcdb"})
+	require.NoError(t, err)
+
+	_, err = mgmt.CreateDatabase(ctx, &mgmtv1.CreateDatabaseRequest{Name: "grpcdb"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	rows := client.Query("SHOW DATABASES")
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	rows.Close()
+	require.Contains(t, names, "grpcdb")
+
+	list, err := mgmt.ListDatabases(ctx, &mgmtv1.ListDatabasesRequest{})
+	require.NoError(t, err)
+	require.Contains(t, list.Names, "grpcdb")
+
+	_, err = mgmt.DropDatabase(ctx, &mgmtv1.DropDatabaseRequest{Name: "grpcdb"})
+	require.NoError(t, err)
+
+	_, err = mgmt.DropDatabase(ctx, &mgmtv1.DropDatabaseRequest{Name: "grpcdb"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/guocedb/integration/testutil"
+)
+
+// protocolCases are the MySQL wire protocol edge cases checked against the
+// real server. The expected results are the ones MySQL gives for the same
+// queries and DSN options.
+var protocolCases = []testutil.ProtocolCase{
+	{
+		Name:   "multi-statement result sets",
+		Params: map[string]string{"multiStatements": "true"},
+		Run: func(t *testing.T, db *sql.DB) {
+			rows, err := db.Query("SELECT 1; SELECT 2, 3")
+			require.NoError(t, err)
+			defer rows.Close()
+
+			require.Equal(t, [][]int64{{1}}, scanInt64Rows(t, rows))
+			require.True(t, rows.NextResultSet())
+			require.Equal(t, [][]int64{{2, 3}}, scanInt64Rows(t, rows))
+			require.False(t, rows.NextResultSet())
+			require.NoError(t, rows.Err())
+		},
+	},
+	{
+		Name:   "multi-statement error after first result",
+		Params: map[string]string{"multiStatements": "true"},
+		Run: func(t *testing.T, db *sql.DB) {
+			rows, err := db.Query("SELECT 1; SELECT * FROM missing_table")
+			require.NoError(t, err)
+			defer rows.Close()
+
+			require.Equal(t, [][]int64{{1}}, scanInt64Rows(t, rows))
+			require.False(t, rows.NextResultSet())
+			require.Error(t, rows.Err())
+		},
+	},
+	{
+		Name:   "multi-statement exec",
+		Params: map[string]string{"multiStatements": "true"},
+		Run: func(t *testing.T, db *sql.DB) {
+			_, err := db.Exec("SET @a = 1; SET @b = 2")
+			require.NoError(t, err)
+		},
+	},
+	{
+		Name: "multi-statement rejected without client flag",
+		Run: func(t *testing.T, db *sql.DB) {
+			_, err := db.Exec("SELECT 1; SELECT 2")
+			require.Error(t, err)
+		},
+	},
+	{
+		Name:   "prepared text protocol",
+		Params: map[string]string{"interpolateParams": "true"},
+		Run: func(t *testing.T, db *sql.DB) {
+			var n int64
+			require.NoError(t, db.QueryRow("SELECT ? + 1", 41).Scan(&n))
+			require.Equal(t, int64(42), n)
+
+			var s string
+			quoted := `O'Brien \ "quoted"`
+			require.NoError(t, db.QueryRow("SELECT ?", quoted).Scan(&s))
+			require.Equal(t, quoted, s)
+
+			var null sql.NullString
+			require.NoError(t, db.QueryRow("SELECT ?", nil).Scan(&null))
+			require.False(t, null.Valid)
+		},
+	},
+	{
+		Name: "prepared binary protocol",
+		Run: func(t *testing.T, db *sql.DB) {
+			stmt, err := db.Prepare("SELECT ? + 1")
+			require.NoError(t, err)
+			defer stmt.Close()
+
+			for _, v := range []int64{1, 41} {
+				var n int64
+				require.NoError(t, stmt.QueryRow(v).Scan(&n))
+				require.Equal(t, v+1, n)
+			}
+		},
+	},
+	{
+		Name: "large packets",
+		Params: map[string]string{
+			"interpolateParams": "true",
+			"maxAllowedPacket":  strconv.Itoa(64 << 20),
+		},
+		Run: func(t *testing.T, db *sql.DB) {
+			// Larger than the 16MB limit of a single protocol packet, so the
+			// payload is split in both directions.
+			value := strings.Repeat("x", 17<<20)
+
+			var s string
+			require.NoError(t, db.QueryRow("SELECT ?", value).Scan(&s))
+			require.Equal(t, len(value), len(s))
+			require.Equal(t, value, s)
+		},
+	},
+	{
+		Name:    "load data local infile",
+		Params:  map[string]string{"allowAllFiles": "true"},
+		Pending: "LOAD DATA is not supported",
+	},
+	{
+		Name:    "compression",
+		Params:  map[string]string{"compress": "true"},
+		Pending: "the compressed protocol is not supported",
+	},
+}
+
+// TestE2E_Protocol runs the wire protocol edge cases against the server.
+func TestE2E_Protocol(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	ts := testutil.NewTestServer(t).Start()
+	defer ts.Stop()
+
+	ts.RunProtocolCases("protocoldb", protocolCases)
+}
+
+func scanInt64Rows(t *testing.T, rows *sql.Rows) [][]int64 {
+	t.Helper()
+
+	cols, err := rows.Columns()
+	require.NoError(t, err)
+
+	var result [][]int64
+	for rows.Next() {
+		row := make([]int64, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		require.NoError(t, rows.Scan(ptrs...))
+		result = append(result, row)
+	}
+
+	return result
+}
@@ -0,0 +1,77 @@
+package testutil
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sort"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// ProtocolCase is a MySQL wire protocol edge case that is run against a
+// live server using the go-sql-driver client.
+type ProtocolCase struct {
+	// Name identifies the case in the test output.
+	Name string
+	// Params are the DSN parameters the client connects with, such as
+	// multiStatements or interpolateParams.
+	Params map[string]string
+	// Pending is the reason why the server does not support the case yet.
+	// Pending cases are skipped instead of run.
+	Pending string
+	// Run exercises the case on a connection pool opened with Params.
+	Run func(t *testing.T, db *sql.DB)
+}
+
+// DSNWithParams returns the DSN of the server using the given database and
+// driver parameters.
+func (ts *TestServer) DSNWithParams(dbName string, params map[string]string) string {
+	dsn := ts.DSN() + dbName
+	if len(params) == 0 {
+		return dsn
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	values := url.Values{}
+	for _, k := range keys {
+		values.Set(k, params[k])
+	}
+
+	return fmt.Sprintf("%s?%s", dsn, values.Encode())
+}
+
+// RunProtocolCases runs each case as a subtest on its own connection pool,
+// so the DSN parameters of one case don't leak into the others. The cases
+// are connected to the given database, which is created if needed.
+func (ts *TestServer) RunProtocolCases(dbName string, cases []ProtocolCase) {
+	ts.t.Helper()
+	ts.MustExec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", dbName))
+
+	for _, c := range cases {
+		c := c
+		ts.t.Run(c.Name, func(t *testing.T) {
+			if c.Pending != "" {
+				t.Skipf("pending: %s", c.Pending)
+			}
+
+			db, err := sql.Open("mysql", ts.DSNWithParams(dbName, c.Params))
+			if err != nil {
+				t.Fatalf("failed to open connection: %v", err)
+			}
+			defer db.Close()
+
+			if err := db.Ping(); err != nil {
+				t.Fatalf("failed to ping server: %v", err)
+			}
+
+			c.Run(t, db)
+		})
+	}
+}
@@ -15,11 +15,13 @@ import (
 
 // TestServer wraps a GuoceDB server instance for testing
 type TestServer struct {
-	t       *testing.T
-	srv     *server.Server
-	cfg     *config.Config
-	dataDir string
-	port    int
+	t        *testing.T
+	srv      *server.Server
+	cfg      *config.Config
+	dataDir  string
+	port     int
+	grpcPort int
+	engine   string
 }
 
 // TestServerOption configures a TestServer
@@ -32,6 +34,13 @@ func WithPort(port int) TestServerOption {
 	}
 }
 
+// WithGRPC enables the gRPC management service on a random port
+func WithGRPC() TestServerOption {
+	return func(ts *TestServer) {
+		ts.grpcPort = findFreePort(ts.t)
+	}
+}
+
 // WithAuth enables authentication with a root password
 func WithAuth(enabled bool, rootPass string) TestServerOption {
 	return func(ts *TestServer) {
@@ -47,6 +56,14 @@ func WithDataDir(dir string) TestServerOption {
 	}
 }
 
+// WithStorageEngine sets the storage engine, such as memory to keep the
+// data of the server off the disk
+func WithStorageEngine(engine string) TestServerOption {
+	return func(ts *TestServer) {
+		ts.engine = engine
+	}
+}
+
 // NewTestServer creates and configures a test server
 func NewTestServer(t *testing.T, opts ...TestServerOption) *TestServer {
 	t.Helper()
@@ -79,8 +96,10 @@ func NewTestServer(t *testing.T, opts ...TestServerOption) *TestServer {
 			WriteTimeout:    30 * time.Second,
 			IdleTimeout:     8 * time.Hour,
 			ShutdownTimeout: 5 * time.Second,
+			GRPCPort:        ts.grpcPort,
 		},
 		Storage: config.StorageConfig{
+			Engine:          ts.engine,
 			DataDir:         ts.dataDir,
 			MaxMemTableSize: 64 << 20, // 64MB - meets minimum 1MB requirement
 			NumCompactors:   2,         // Positive number required
@@ -169,6 +188,11 @@ func (ts *TestServer) Port() int {
 	return ts.port
 }
 
+// GRPCAddr returns the address of the gRPC management service
+func (ts *TestServer) GRPCAddr() string {
+	return fmt.Sprintf("127.0.0.1:%d", ts.grpcPort)
+}
+
 // DataDir returns the data directory
 func (ts *TestServer) DataDir() string {
 	return ts.dataDir
@@ -0,0 +1,15 @@
+// Package encoding provides the encoders used to persist values that have
+// no dedicated format, such as catalog metadata, using encoding/gob.
+package encoding
+
+// Encoder encodes values into bytes.
+type Encoder interface {
+	// Encode returns the encoding of v.
+	Encode(v interface{}) ([]byte, error)
+}
+
+// Decoder decodes the bytes written by an Encoder.
+type Decoder interface {
+	// Decode decodes data into the value v points to.
+	Decode(data []byte, v interface{}) error
+}
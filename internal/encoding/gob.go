@@ -0,0 +1,69 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/turtacn/guocedb/common/constants"
+	"github.com/turtacn/guocedb/common/errors"
+)
+
+// GobEncoder encodes arbitrary Go values with encoding/gob.
+//
+// Values stored in interface fields are encoded along with the name of
+// their type, so their types must be registered with RegisterGobTypes
+// before they are encoded or decoded.
+type GobEncoder struct{}
+
+// GobDecoder decodes the values encoded by a GobEncoder.
+type GobDecoder struct{}
+
+var (
+	_ Encoder = GobEncoder{}
+	_ Decoder = GobDecoder{}
+)
+
+// NewGobEncoder returns a new GobEncoder.
+func NewGobEncoder() GobEncoder {
+	return GobEncoder{}
+}
+
+// NewGobDecoder returns a new GobDecoder.
+func NewGobDecoder() GobDecoder {
+	return GobDecoder{}
+}
+
+// Encode implements the Encoder interface.
+func (GobEncoder) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, errors.Wrapf(err, constants.ErrCodeSerialization, "failed to encode %T with gob", v)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode implements the Decoder interface.
+func (GobDecoder) Decode(data []byte, v interface{}) error {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+		return errors.Wrapf(err, constants.ErrCodeSerialization, "failed to decode %T with gob", v)
+	}
+	return nil
+}
+
+// RegisterGobTypes registers the types of the values, so they can be
+// encoded and decoded in interface fields. Registering a type again is a
+// no-op, but registering two types under the same name fails.
+func RegisterGobTypes(values ...interface{}) (err error) {
+	defer func() {
+		// gob panics when the name of a type is taken by another one.
+		if r := recover(); r != nil {
+			err = errors.New(constants.ErrCodeSerialization, fmt.Sprintf("failed to register gob type: %v", r))
+		}
+	}()
+
+	for _, v := range values {
+		gob.Register(v)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package encoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/common/constants"
+	"github.com/turtacn/guocedb/common/errors"
+)
+
+type gobColumn struct {
+	Name     string
+	Type     string
+	Nullable bool
+}
+
+type gobIndex struct {
+	Name    string
+	Columns []string
+}
+
+type gobTable struct {
+	Name    string
+	Columns []gobColumn
+	Indexes map[string]gobIndex
+	Options map[string]string
+	// Extra holds values of registered types.
+	Extra []interface{}
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	require := require.New(t)
+	require.NoError(RegisterGobTypes(gobIndex{}))
+
+	table := gobTable{
+		Name: "orders",
+		Columns: []gobColumn{
+			{Name: "id", Type: "BIGINT"},
+			{Name: "note", Type: "TEXT", Nullable: true},
+		},
+		Indexes: map[string]gobIndex{
+			"idx_note": {Name: "idx_note", Columns: []string{"note"}},
+		},
+		Options: map[string]string{"ENGINE": "InnoDB"},
+		Extra:   []interface{}{gobIndex{Name: "pk", Columns: []string{"id"}}},
+	}
+
+	data, err := NewGobEncoder().Encode(table)
+	require.NoError(err)
+
+	var decoded gobTable
+	require.NoError(NewGobDecoder().Decode(data, &decoded))
+	require.Equal(table, decoded)
+}
+
+func TestGobErrors(t *testing.T) {
+	require := require.New(t)
+
+	// Unregistered types can't be encoded in interface fields.
+	type unregistered struct{ A int }
+	_, err := NewGobEncoder().Encode(gobTable{Extra: []interface{}{unregistered{1}}})
+	var e *errors.Error
+	require.ErrorAs(err, &e)
+	require.Equal(constants.ErrCodeSerialization, e.Code)
+
+	var decoded gobTable
+	err = NewGobDecoder().Decode([]byte("not gob"), &decoded)
+	require.ErrorAs(err, &e)
+	require.Equal(constants.ErrCodeSerialization, e.Code)
+
+	// Registering a type again is fine.
+	require.NoError(RegisterGobTypes(gobIndex{}))
+}
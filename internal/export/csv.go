@@ -0,0 +1,319 @@
+package export
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCSVNull is how NULL is written to CSV files unless told otherwise,
+// as MySQL does with SELECT ... INTO OUTFILE.
+const DefaultCSVNull = `\N`
+
+// CSVOptions control how tables are written to and read from CSV files.
+type CSVOptions struct {
+	// Null is the text of NULL values. If it's empty, NULL and the empty
+	// string can't be told apart, and empty fields are read as NULL.
+	Null string
+	// BatchSize is the number of rows inserted with each INSERT when
+	// importing. It defaults to 1000.
+	BatchSize int
+}
+
+// DefaultCSVOptions returns the options used when none are given.
+func DefaultCSVOptions() CSVOptions {
+	return CSVOptions{Null: DefaultCSVNull, BatchSize: 1000}
+}
+
+// ExportCSV writes the rows of the table to w as CSV, with a header row
+// with the names of the columns. Rows are written as they are read, so
+// tables don't need to fit in memory. It returns the number of rows
+// written.
+func ExportCSV(db *sql.DB, table string, w io.Writer, opts CSVOptions) (int, error) {
+	rows, err := db.Query("SELECT * FROM " + quoteIdentifier(table))
+	if err != nil {
+		return 0, fmt.Errorf("failed to select from table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get columns for table %s: %w", table, err)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(cols); err != nil {
+		return 0, err
+	}
+
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	record := make([]string, len(cols))
+	n := 0
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return n, fmt.Errorf("failed to scan row from table %s: %w", table, err)
+		}
+
+		for i, v := range values {
+			record[i] = formatCSVValue(v, opts.Null)
+		}
+		if err := cw.Write(record); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("error iterating rows from table %s: %w", table, err)
+	}
+
+	cw.Flush()
+	return n, cw.Error()
+}
+
+// formatCSVValue formats a value read from the database as a CSV field.
+func formatCSVValue(v interface{}, null string) string {
+	switch val := v.(type) {
+	case nil:
+		return null
+	case []byte:
+		return string(val)
+	case string:
+		return val
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05")
+	case bool:
+		if val {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// ImportCSV reads CSV from r and inserts its rows in the table. The first
+// row is a header with the names of the columns of the fields, which may
+// be in any order; the columns missing from it get their default values.
+// Fields are converted to the types of their columns, and a field that
+// can't be is reported along with its line. It returns the number of rows
+// inserted, which are all the ones before the failing batch if there's an
+// error.
+func ImportCSV(db *sql.DB, table string, r io.Reader, opts CSVOptions) (int, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultCSVOptions().BatchSize
+	}
+
+	columns, err := tableColumns(db, table)
+	if err != nil {
+		return 0, err
+	}
+
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return 0, fmt.Errorf("CSV file for table %s is empty", table)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	cols := make([]csvColumn, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		col, ok := columns[strings.ToLower(name)]
+		if !ok {
+			return 0, fmt.Errorf("column %s in the CSV header not found in table %s", name, table)
+		}
+		if seen[col.name] {
+			return 0, fmt.Errorf("column %s appears more than once in the CSV header", name)
+		}
+		seen[col.name] = true
+		cols[i] = col
+	}
+
+	ins := &csvInserter{db: db, table: table, cols: cols, batchSize: opts.BatchSize}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ins.inserted, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		for i, field := range record {
+			v, err := convertCSVField(field, cols[i].typ, opts.Null)
+			if err != nil {
+				return ins.inserted, fmt.Errorf("line %d: column %s: %w", line, cols[i].name, err)
+			}
+			ins.args = append(ins.args, v)
+		}
+
+		if err := ins.add(); err != nil {
+			return ins.inserted, err
+		}
+	}
+
+	return ins.inserted, ins.flush()
+}
+
+// csvColumn is a column of the table named in the CSV header.
+type csvColumn struct {
+	// name is the name of the column in the table, which may differ in
+	// case from the header.
+	name string
+	// typ is the database type name of the column.
+	typ string
+}
+
+// tableColumns returns the columns of the table keyed by their lower-case
+// name.
+func tableColumns(db *sql.DB, table string) (map[string]csvColumn, error) {
+	rows, err := db.Query("SELECT * FROM " + quoteIdentifier(table) + " LIMIT 0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns for table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns for table %s: %w", table, err)
+	}
+
+	columns := make(map[string]csvColumn, len(cts))
+	for _, ct := range cts {
+		columns[strings.ToLower(ct.Name())] = csvColumn{
+			name: ct.Name(),
+			typ:  strings.ToUpper(ct.DatabaseTypeName()),
+		}
+	}
+	return columns, nil
+}
+
+// convertCSVField converts a CSV field to a value of the column type, so
+// values that are not valid are caught before they are sent to the server.
+func convertCSVField(field, typ, null string) (interface{}, error) {
+	if field == null {
+		return nil, nil
+	}
+
+	switch typ {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR":
+		v, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", typ, field)
+		}
+		return v, nil
+	case "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT", "UNSIGNED BIGINT":
+		v, err := strconv.ParseUint(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", typ, field)
+		}
+		return v, nil
+	case "FLOAT", "DOUBLE", "DECIMAL":
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", typ, field)
+		}
+		if typ == "DECIMAL" {
+			// Sent as text, so no precision is lost.
+			return strings.TrimSpace(field), nil
+		}
+		return v, nil
+	case "BIT", "BOOL", "BOOLEAN":
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "1", "true":
+			return true, nil
+		case "0", "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid %s value %q", typ, field)
+	case "DATE":
+		if _, err := time.Parse("2006-01-02", field); err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", typ, field)
+		}
+		return field, nil
+	case "DATETIME", "TIMESTAMP":
+		if _, err := parseCSVTime(field); err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", typ, field)
+		}
+		return field, nil
+	case "BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB":
+		return []byte(field), nil
+	default:
+		return field, nil
+	}
+}
+
+// parseCSVTime parses the date and time formats the server accepts.
+func parseCSVTime(s string) (time.Time, error) {
+	for _, layout := range []string{
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04:05.999999999Z07:00",
+		"2006-01-02",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unknown format")
+}
+
+// csvInserter inserts the rows read from a CSV file in batches.
+type csvInserter struct {
+	db        *sql.DB
+	table     string
+	cols      []csvColumn
+	batchSize int
+	// args are the values of the rows not inserted yet.
+	args     []interface{}
+	inserted int
+}
+
+// add inserts the pending rows if there are enough for a batch.
+func (ins *csvInserter) add() error {
+	if len(ins.args)/len(ins.cols) < ins.batchSize {
+		return nil
+	}
+	return ins.flush()
+}
+
+// flush inserts the pending rows.
+func (ins *csvInserter) flush() error {
+	rows := len(ins.args) / len(ins.cols)
+	if rows == 0 {
+		return nil
+	}
+
+	quotedCols := make([]string, len(ins.cols))
+	for i, col := range ins.cols {
+		quotedCols[i] = quoteIdentifier(col.name)
+	}
+
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(ins.cols)), ", ") + ")"
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		quoteIdentifier(ins.table), strings.Join(quotedCols, ", "),
+		strings.TrimSuffix(strings.Repeat(placeholders+", ", rows), ", "))
+
+	if _, err := ins.db.Exec(query, ins.args...); err != nil {
+		return fmt.Errorf("failed to insert rows %d to %d in table %s: %w",
+			ins.inserted+1, ins.inserted+rows, ins.table, err)
+	}
+
+	ins.inserted += rows
+	ins.args = ins.args[:0]
+	return nil
+}
@@ -0,0 +1,189 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dolthub/vitess/go/mysql"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/auth"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	"github.com/turtacn/guocedb/compute/server"
+	sqle "github.com/turtacn/guocedb/compute/sql"
+)
+
+// startTestServer serves an empty testdb database and returns a client
+// connected to it.
+func startTestServer(t *testing.T) *sql.DB {
+	catalog := sqle.NewCatalog()
+	catalog.AddDatabase(mem.NewDatabase("testdb"))
+	engine := executor.NewEngine(analyzer.NewAnalyzer(catalog), optimizer.NewOptimizer(), catalog)
+	handler := server.NewHandler(engine, server.NewSessionManager(server.DefaultSessionBuilder, nil, "localhost:0"))
+
+	authServer := auth.NewNativeSingle("root", "", auth.AllPermissions)
+	l, err := mysql.NewListener("tcp", "127.0.0.1:0", authServer.Mysql(), handler, 0, 0)
+	require.NoError(t, err)
+	go l.Accept()
+	t.Cleanup(l.Close)
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/testdb", l.Addr()))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func queryStrings(t *testing.T, db *sql.DB, query string) [][]*string {
+	t.Helper()
+	rows, err := db.Query(query)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	require.NoError(t, err)
+
+	var result [][]*string
+	for rows.Next() {
+		row := make([]*string, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		require.NoError(t, rows.Scan(ptrs...))
+		result = append(result, row)
+	}
+	require.NoError(t, rows.Err())
+	return result
+}
+
+func TestCSV_RoundTrip(t *testing.T) {
+	require := require.New(t)
+	db := startTestServer(t)
+
+	for _, table := range []string{"src", "dst1", "dst2"} {
+		_, err := db.Exec("CREATE TABLE " + table + " (id BIGINT PRIMARY KEY, name TEXT, score DOUBLE)")
+		require.NoError(err)
+	}
+	_, err := db.Exec(`INSERT INTO src (id, name, score) VALUES
+		(1, 'plain', 1.5),
+		(2, 'with, commas', NULL),
+		(3, 'two\nlines', 3),
+		(4, 'say "hi"', 4.25),
+		(5, '', 5),
+		(6, NULL, 6)`)
+	require.NoError(err)
+
+	for i, null := range []string{DefaultCSVNull, ""} {
+		dst := fmt.Sprintf("dst%d", i+1)
+		t.Run(fmt.Sprintf("null=%q", null), func(t *testing.T) {
+			testCSVRoundTrip(t, db, "src", dst, null)
+		})
+	}
+}
+
+// testCSVRoundTrip exports table src to CSV and imports it into table dst,
+// which must end up with the same rows.
+func testCSVRoundTrip(t *testing.T, db *sql.DB, src, dst, null string) {
+	require := require.New(t)
+	opts := DefaultCSVOptions()
+	opts.Null = null
+	opts.BatchSize = 4
+
+	var buf bytes.Buffer
+	n, err := ExportCSV(db, src, &buf, opts)
+	require.NoError(err)
+	require.Equal(6, n)
+	require.True(strings.HasPrefix(buf.String(), "id,name,score\n"))
+	require.Contains(buf.String(), `"with, commas"`)
+	require.Contains(buf.String(), "\"two\nlines\"")
+
+	n, err = ImportCSV(db, dst, &buf, opts)
+	require.NoError(err)
+	require.Equal(6, n)
+
+	expected := queryStrings(t, db, "SELECT id, name, score FROM "+src+" ORDER BY id")
+	if null == "" {
+		// The empty string can't be told apart from NULL.
+		expected[4][1] = nil
+	}
+	require.Equal(expected, queryStrings(t, db, "SELECT id, name, score FROM "+dst+" ORDER BY id"))
+}
+
+func TestImportCSV_Header(t *testing.T) {
+	require := require.New(t)
+	db := startTestServer(t)
+
+	_, err := db.Exec("CREATE TABLE t (id BIGINT PRIMARY KEY, name TEXT, score DOUBLE)")
+	require.NoError(err)
+
+	// Columns are mapped by name, whatever their order and case.
+	n, err := ImportCSV(db, "t", strings.NewReader("NAME,id\nb,2\na,1\n"), DefaultCSVOptions())
+	require.NoError(err)
+	require.Equal(2, n)
+
+	a, b := "a", "b"
+	one, two := "1", "2"
+	require.Equal([][]*string{{&one, &a}, {&two, &b}},
+		queryStrings(t, db, "SELECT id, name FROM t ORDER BY id"))
+
+	_, err = ImportCSV(db, "t", strings.NewReader("id,missing\n1,2\n"), DefaultCSVOptions())
+	require.EqualError(err, "column missing in the CSV header not found in table t")
+
+	_, err = ImportCSV(db, "t", strings.NewReader("id,id\n1,2\n"), DefaultCSVOptions())
+	require.EqualError(err, "column id appears more than once in the CSV header")
+}
+
+func TestImportCSV_ConversionError(t *testing.T) {
+	require := require.New(t)
+	db := startTestServer(t)
+
+	_, err := db.Exec("CREATE TABLE t (id BIGINT PRIMARY KEY, score DOUBLE)")
+	require.NoError(err)
+
+	csv := "id,score\n1,1.5\n2,\"not\na number\"\n"
+	n, err := ImportCSV(db, "t", strings.NewReader(csv), DefaultCSVOptions())
+	require.EqualError(err, `line 3: column score: invalid DOUBLE value "not\na number"`)
+	require.Equal(0, n)
+}
+
+func TestConvertCSVField(t *testing.T) {
+	tests := []struct {
+		field    string
+		typ      string
+		expected interface{}
+		err      bool
+	}{
+		{`\N`, "BIGINT", nil, false},
+		{"42", "INT", int64(42), false},
+		{"-1", "UNSIGNED INT", nil, true},
+		{"7", "UNSIGNED BIGINT", uint64(7), false},
+		{"x", "BIGINT", nil, true},
+		{"1.25", "DOUBLE", 1.25, false},
+		{"10.50", "DECIMAL", "10.50", false},
+		{"true", "BOOL", true, false},
+		{"2024-01-02", "DATE", "2024-01-02", false},
+		{"2024-13-02", "DATE", nil, true},
+		{"2024-01-02 03:04:05", "DATETIME", "2024-01-02 03:04:05", false},
+		{"abc", "BLOB", []byte("abc"), false},
+		{"", "TEXT", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ+"/"+tt.field, func(t *testing.T) {
+			v, err := convertCSVField(tt.field, tt.typ, DefaultCSVNull)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, v)
+		})
+	}
+}
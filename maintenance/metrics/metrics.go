@@ -40,6 +40,53 @@ var (
 	)
 )
 
+// ConnectionStats is a snapshot of the connections served by the MySQL
+// server.
+type ConnectionStats struct {
+	ActiveConnections int64
+	Queries           uint64
+}
+
+// ConnectionCollector reports the connections of the MySQL server. The
+// stats are read every time the metrics are collected, so they are never
+// out of date.
+type ConnectionCollector struct {
+	stats       func() ConnectionStats
+	activeDesc  *prometheus.Desc
+	queriesDesc *prometheus.Desc
+}
+
+// NewConnectionCollector creates a collector that reports the stats given
+// by the stats function.
+func NewConnectionCollector(stats func() ConnectionStats) *ConnectionCollector {
+	return &ConnectionCollector{
+		stats: stats,
+		activeDesc: prometheus.NewDesc(
+			"guocedb_server_connections_active",
+			"Number of client connections open in the MySQL server.",
+			nil, nil,
+		),
+		queriesDesc: prometheus.NewDesc(
+			"guocedb_server_queries_total",
+			"Total number of queries received by the MySQL server.",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *ConnectionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.activeDesc
+	ch <- c.queriesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *ConnectionCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.activeDesc, prometheus.GaugeValue, float64(stats.ActiveConnections))
+	ch <- prometheus.MustNewConstMetric(c.queriesDesc, prometheus.CounterValue, float64(stats.Queries))
+}
+
 // Registry is a convenience wrapper around a Prometheus registry.
 type Registry struct {
 	*prometheus.Registry
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Statement kinds the statements are classified by.
+const (
+	StatementSelect = "select"
+	StatementInsert = "insert"
+	StatementUpdate = "update"
+	StatementDelete = "delete"
+	StatementDDL    = "ddl"
+	StatementOther  = "other"
+)
+
+// StatementCollector reports how long the statements run by the MySQL
+// server take, by kind of statement, and the errors they fail with, by
+// MySQL error code.
+type StatementCollector struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewStatementCollector creates a collector without any statement
+// observed.
+func NewStatementCollector() *StatementCollector {
+	return &StatementCollector{
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "guocedb_statement_duration_seconds",
+				Help:    "Execution time of the statements run by the MySQL server, by kind of statement.",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"type"},
+		),
+		errors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "guocedb_statement_errors_total",
+				Help: "Total number of statements that failed in the MySQL server, by MySQL error code.",
+			},
+			[]string{"code"},
+		),
+	}
+}
+
+// Observe records a statement of the given kind that took d. A non-zero
+// code is the MySQL error code the statement failed with.
+func (c *StatementCollector) Observe(kind string, d time.Duration, code int) {
+	c.duration.WithLabelValues(kind).Observe(d.Seconds())
+	if code != 0 {
+		c.errors.WithLabelValues(strconv.Itoa(code)).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *StatementCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.duration.Describe(ch)
+	c.errors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *StatementCollector) Collect(ch chan<- prometheus.Metric) {
+	c.duration.Collect(ch)
+	c.errors.Collect(ch)
+}
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DatabaseStorageStats is the disk usage of a database and the result of
+// its last value log garbage collection.
+type DatabaseStorageStats struct {
+	LSMBytes      int64
+	ValueLogBytes int64
+	GCRuns        int64
+	// LastGC is when the last collection finished, or zero if there was
+	// none yet.
+	LastGC               time.Time
+	LastGCReclaimedBytes int64
+	GCReclaimedBytes     int64
+}
+
+// StorageCollector reports the disk usage and value log garbage collection
+// of each database. Like ConnectionCollector, the stats are read every time
+// the metrics are collected.
+type StorageCollector struct {
+	stats func() map[string]DatabaseStorageStats
+
+	lsmDesc           *prometheus.Desc
+	valueLogDesc      *prometheus.Desc
+	gcRunsDesc        *prometheus.Desc
+	lastGCDesc        *prometheus.Desc
+	lastReclaimedDesc *prometheus.Desc
+	reclaimedDesc     *prometheus.Desc
+}
+
+// NewStorageCollector creates a collector that reports the stats given by
+// the stats function, by database name.
+func NewStorageCollector(stats func() map[string]DatabaseStorageStats) *StorageCollector {
+	labels := []string{"database"}
+	return &StorageCollector{
+		stats: stats,
+		lsmDesc: prometheus.NewDesc(
+			"guocedb_storage_database_lsm_bytes",
+			"Size in bytes of the LSM tree of a database.",
+			labels, nil,
+		),
+		valueLogDesc: prometheus.NewDesc(
+			"guocedb_storage_database_value_log_bytes",
+			"Size in bytes of the value log of a database.",
+			labels, nil,
+		),
+		gcRunsDesc: prometheus.NewDesc(
+			"guocedb_storage_value_log_gc_runs_total",
+			"Total number of value log garbage collections of a database.",
+			labels, nil,
+		),
+		lastGCDesc: prometheus.NewDesc(
+			"guocedb_storage_value_log_gc_last_run_timestamp_seconds",
+			"Unix time of the last value log garbage collection of a database.",
+			labels, nil,
+		),
+		lastReclaimedDesc: prometheus.NewDesc(
+			"guocedb_storage_value_log_gc_last_reclaimed_bytes",
+			"Bytes freed by the last value log garbage collection of a database.",
+			labels, nil,
+		),
+		reclaimedDesc: prometheus.NewDesc(
+			"guocedb_storage_value_log_gc_reclaimed_bytes_total",
+			"Total bytes freed by the value log garbage collections of a database.",
+			labels, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *StorageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lsmDesc
+	ch <- c.valueLogDesc
+	ch <- c.gcRunsDesc
+	ch <- c.lastGCDesc
+	ch <- c.lastReclaimedDesc
+	ch <- c.reclaimedDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *StorageCollector) Collect(ch chan<- prometheus.Metric) {
+	for db, stats := range c.stats() {
+		ch <- prometheus.MustNewConstMetric(c.lsmDesc, prometheus.GaugeValue, float64(stats.LSMBytes), db)
+		ch <- prometheus.MustNewConstMetric(c.valueLogDesc, prometheus.GaugeValue, float64(stats.ValueLogBytes), db)
+		ch <- prometheus.MustNewConstMetric(c.gcRunsDesc, prometheus.CounterValue, float64(stats.GCRuns), db)
+		if !stats.LastGC.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.lastGCDesc, prometheus.GaugeValue, float64(stats.LastGC.Unix()), db)
+		}
+		ch <- prometheus.MustNewConstMetric(c.lastReclaimedDesc, prometheus.GaugeValue, float64(stats.LastGCReclaimedBytes), db)
+		ch <- prometheus.MustNewConstMetric(c.reclaimedDesc, prometheus.CounterValue, float64(stats.GCReclaimedBytes), db)
+	}
+}
+
+// Storage operations recorded by StorageOperationCollector.
+const (
+	StorageGet    = "get"
+	StorageSet    = "set"
+	StorageDelete = "delete"
+	StorageScan   = "scan"
+	StorageCommit = "commit"
+)
+
+// StorageOperationCollector reports the operations run on the storage, by
+// kind of operation: how many there were, how many failed and how long
+// they took. Along with StatementCollector, it tells whether statements
+// are slow because of the storage.
+type StorageOperationCollector struct {
+	operations *prometheus.CounterVec
+	errors     *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+}
+
+// NewStorageOperationCollector creates a collector without any operation
+// observed.
+func NewStorageOperationCollector() *StorageOperationCollector {
+	labels := []string{"operation"}
+	return &StorageOperationCollector{
+		operations: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "guocedb_storage_operations_total",
+				Help: "Total number of operations run on the storage, by operation.",
+			},
+			labels,
+		),
+		errors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "guocedb_storage_operation_errors_total",
+				Help: "Total number of operations that failed in the storage, by operation.",
+			},
+			labels,
+		),
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "guocedb_storage_operation_duration_seconds",
+				Help: "Time taken by the operations run on the storage, by operation.",
+				// Most operations take microseconds, while scans of big
+				// tables take seconds.
+				Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10),
+			},
+			labels,
+		),
+	}
+}
+
+// Observe records an operation that took d, and failed if err isn't nil.
+func (c *StorageOperationCollector) Observe(op string, d time.Duration, err error) {
+	c.operations.WithLabelValues(op).Inc()
+	c.duration.WithLabelValues(op).Observe(d.Seconds())
+	if err != nil {
+		c.errors.WithLabelValues(op).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *StorageOperationCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.operations.Describe(ch)
+	c.errors.Describe(ch)
+	c.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *StorageOperationCollector) Collect(ch chan<- prometheus.Metric) {
+	c.operations.Collect(ch)
+	c.errors.Collect(ch)
+	c.duration.Collect(ch)
+}
@@ -0,0 +1,146 @@
+// Package slowlog records the statements that take longer than a threshold
+// to run, in the format of the MySQL slow query log, so the tools that read
+// MySQL's, such as mysqldumpslow, read it too.
+package slowlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Config configures a slow query log.
+type Config struct {
+	// FilePath is the file the log is written to.
+	FilePath string
+	// Threshold is the shortest a statement must take to be logged. Zero
+	// logs every statement.
+	Threshold time.Duration
+	// MaxSize is the size in megabytes the file is rotated at, MaxBackups
+	// the number of rotated files kept and MaxAge the number of days they
+	// are kept for. Zero means the defaults of lumberjack.
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
+}
+
+// Entry is a statement that was run.
+type Entry struct {
+	// Time is when the statement finished.
+	Time         time.Time
+	User         string
+	Host         string
+	Database     string
+	Query        string
+	Duration     time.Duration
+	RowsSent     uint64
+	RowsExamined uint64
+}
+
+// Logger writes the statements slower than its threshold to a file.
+type Logger struct {
+	// threshold is changed while statements are logged when the
+	// configuration is reloaded.
+	threshold atomic.Int64
+
+	mu sync.Mutex
+	w  io.WriteCloser
+	db string // Database of the last entry written
+}
+
+// New opens the slow query log described by cfg.
+func New(cfg Config) (*Logger, error) {
+	if cfg.FilePath == "" {
+		return nil, fmt.Errorf("slow query log: no file path")
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.FilePath), 0755); err != nil {
+		return nil, fmt.Errorf("slow query log: create directory: %w", err)
+	}
+
+	// The rotating writer opens the file on the first write, so check now
+	// that it can be opened rather than losing the entries later.
+	f, err := os.OpenFile(cfg.FilePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("slow query log: %w", err)
+	}
+	f.Close()
+
+	return NewWithWriter(cfg.Threshold, &lumberjack.Logger{
+		Filename:   cfg.FilePath,
+		MaxSize:    cfg.MaxSize,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAge,
+	}), nil
+}
+
+// NewWithWriter creates a slow query log that writes to w.
+func NewWithWriter(threshold time.Duration, w io.WriteCloser) *Logger {
+	l := &Logger{w: w}
+	l.SetThreshold(threshold)
+	return l
+}
+
+// Threshold returns the shortest a statement must take to be logged.
+func (l *Logger) Threshold() time.Duration {
+	return time.Duration(l.threshold.Load())
+}
+
+// SetThreshold sets the shortest a statement must take to be logged.
+func (l *Logger) SetThreshold(threshold time.Duration) {
+	l.threshold.Store(int64(threshold))
+}
+
+// Log writes the entry if the statement took at least the threshold.
+func (l *Logger) Log(e Entry) error {
+	if e.Duration < l.Threshold() {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Time: %s\n", e.Time.UTC().Format("2006-01-02T15:04:05.000000Z"))
+	fmt.Fprintf(&b, "# User@Host: %s[%[1]s] @ %s\n", e.User, hostName(e.Host))
+	fmt.Fprintf(&b, "# Query_time: %.6f  Lock_time: 0.000000 Rows_sent: %d  Rows_examined: %d\n",
+		e.Duration.Seconds(), e.RowsSent, e.RowsExamined)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The database is only written when it changes, as MySQL does.
+	if e.Database != "" && e.Database != l.db {
+		fmt.Fprintf(&b, "use %s;\n", e.Database)
+		l.db = e.Database
+	}
+	fmt.Fprintf(&b, "SET timestamp=%d;\n", e.Time.Unix())
+
+	query := strings.TrimSpace(e.Query)
+	if !strings.HasSuffix(query, ";") {
+		query += ";"
+	}
+	b.WriteString(query + "\n")
+
+	_, err := io.WriteString(l.w, b.String())
+	return err
+}
+
+// hostName strips the port from the address of a client.
+func hostName(addr string) string {
+	i := strings.LastIndexByte(addr, ':')
+	if i < 0 || strings.HasSuffix(addr, "]") {
+		return addr
+	}
+	return strings.Trim(addr[:i], "[]")
+}
+
+// Close closes the file of the log.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Close()
+}
@@ -0,0 +1,290 @@
+// Package gateway provides an HTTP endpoint that runs SQL queries and
+// returns their results as JSON.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/dolthub/vitess/go/vt/proto/query"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/turtacn/guocedb/common/log"
+	"github.com/turtacn/guocedb/compute/executor"
+	mysqlserver "github.com/turtacn/guocedb/compute/server"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/types"
+)
+
+// maxRequestSize is the largest request body accepted, which bounds the
+// memory taken by a single query.
+const maxRequestSize = 16 << 20
+
+// Request is the body of a POST /query request.
+type Request struct {
+	// SQL is the statement to run. Its ? placeholders are replaced by the
+	// params, in order.
+	SQL string `json:"sql"`
+	// Database is the database the statement runs in. It becomes the
+	// current database, as with USE. The current one is kept if it's empty.
+	Database string `json:"database,omitempty"`
+	// Params are the values of the placeholders of the statement. They are
+	// bound as literals, so they can't change the statement.
+	Params []interface{} `json:"params,omitempty"`
+}
+
+// Column is a column of the results of a query.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Response is the body of the response to a successful query. Each value
+// of a row is an object with its type and data, as types.Value is encoded.
+type Response struct {
+	Columns []Column        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// Error is the body of the response to a failed query, with the MySQL
+// error code and SQLSTATE a MySQL client would get.
+type Error struct {
+	Code    int    `json:"code"`
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
+
+// Gateway is an HTTP server that runs the queries posted to /query on an
+// engine. It doesn't authenticate its clients, so it must only be reachable
+// by trusted ones.
+type Gateway struct {
+	engine     *executor.Engine
+	listener   net.Listener
+	httpServer *http.Server
+}
+
+// NewGateway creates a gateway listening on the given address.
+func NewGateway(addr string, e *executor.Engine) (*Gateway, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Gateway{engine: e, listener: l}
+	g.httpServer = &http.Server{Handler: g.Handler()}
+	return g, nil
+}
+
+// Handler returns the HTTP handler of the gateway.
+func (g *Gateway) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/query", g.queryHandler)
+	return mux
+}
+
+// Start implements the server.ManagedServer interface.
+func (g *Gateway) Start() {
+	log.GetLogger().Infof("Starting HTTP query gateway on %s", g.Addr())
+	go func() {
+		if err := g.httpServer.Serve(g.listener); err != http.ErrServerClosed {
+			log.GetLogger().Errorf("HTTP query gateway failed: %v", err)
+		}
+	}()
+}
+
+// Close implements the server.ManagedServer interface. The queries being
+// run are let finish.
+func (g *Gateway) Close() error {
+	return g.httpServer.Shutdown(context.Background())
+}
+
+// Addr implements the server.ManagedServer interface.
+func (g *Gateway) Addr() string {
+	return g.listener.Addr().String()
+}
+
+func (g *Gateway) queryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed, use POST", r.Method))
+		return
+	}
+
+	var req Request
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize))
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+		return
+	}
+	if req.SQL == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: sql is required"))
+		return
+	}
+
+	resp, err := g.query(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.GetLogger().Errorf("Unable to write query response: %v", err)
+	}
+}
+
+// query runs the query of the request and returns all its rows.
+func (g *Gateway) query(ctx context.Context, req *Request) (*Response, error) {
+	q := req.SQL
+	if req.Params != nil {
+		var err error
+		if q, err = bindParams(q, req.Params); err != nil {
+			return nil, err
+		}
+	}
+
+	sqlCtx := sql.NewContext(ctx, sql.WithSession(sql.NewBaseSession()), sql.WithQuery(q))
+	if req.Database != "" {
+		if _, err := g.engine.Catalog.Database(req.Database); err != nil {
+			return nil, err
+		}
+		g.engine.Catalog.SetCurrentDatabase(req.Database)
+		sqlCtx.SetCurrentDatabase(req.Database)
+	}
+
+	schema, iter, err := g.engine.Query(sqlCtx, q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := sql.RowIterToRows(iter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Response{
+		Columns: make([]Column, len(schema)),
+		Rows:    make([][]interface{}, len(rows)),
+	}
+	for i, col := range schema {
+		resp.Columns[i] = Column{Name: col.Name, Type: col.Type.String()}
+	}
+	for i, row := range rows {
+		resp.Rows[i] = make([]interface{}, len(row))
+		for j, v := range row {
+			var typ sql.Type = sql.Null
+			if j < len(schema) {
+				typ = schema[j].Type
+			}
+			if resp.Rows[i][j], err = cellValue(typ, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+// bindParams replaces the ? placeholders of the query by the params, which
+// must be as many as them.
+func bindParams(q string, params []interface{}) (string, error) {
+	stmt, err := sqlparser.Parse(q)
+	if err != nil {
+		return "", err
+	}
+
+	placeholders := 0
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if v, ok := node.(*sqlparser.SQLVal); ok && v.Type == sqlparser.ValArg {
+			placeholders++
+		}
+		return true, nil
+	}, stmt)
+	if placeholders != len(params) {
+		return "", mysql.NewSQLError(mysql.ERWrongArguments, mysqlserver.SSUnknownSQLState,
+			"the query has %d placeholders but %d params were given", placeholders, len(params))
+	}
+
+	bindVars := make(map[string]*query.BindVariable, len(params))
+	for i, p := range params {
+		bv, err := bindVariable(p)
+		if err != nil {
+			return "", mysql.NewSQLError(mysql.ERWrongArguments, mysqlserver.SSUnknownSQLState,
+				"param %d: %s", i+1, err)
+		}
+		bindVars["v"+strconv.Itoa(i+1)] = bv
+	}
+
+	return sqlparser.NewParsedQuery(stmt).GenerateQuery(bindVars, nil)
+}
+
+// bindVariable returns the bind variable of a JSON param. Numbers are bound
+// as integers if they are, so they aren't compared as floats.
+func bindVariable(p interface{}) (*query.BindVariable, error) {
+	switch p := p.(type) {
+	case nil:
+		return sqltypes.ValueBindVariable(sqltypes.NULL), nil
+	case json.Number:
+		if i, err := p.Int64(); err == nil {
+			return sqltypes.Int64BindVariable(i), nil
+		}
+		f, err := p.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return sqltypes.Float64BindVariable(f), nil
+	case bool, string:
+		return sqltypes.BuildBindVariable(p)
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T", p)
+	}
+}
+
+// rawCell is a value of a type without a types.Value counterpart, encoded
+// with the same fields.
+type rawCell struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// cellValue returns the value of a row encoded with its type, which
+// decodes to the same Go type and value.
+func cellValue(typ sql.Type, v interface{}) (interface{}, error) {
+	if v == nil {
+		return types.NewValue(types.Null, nil)
+	}
+
+	switch {
+	case typ == sql.Blob || typ == sql.JSON || typ == sql.Uint64:
+	case sql.IsInteger(typ):
+		return types.NewValue(types.Int64, v)
+	case sql.IsText(typ):
+		return types.NewValue(types.Text, v)
+	case typ == sql.Timestamp:
+		return types.NewValue(types.Timestamp, v)
+	case typ == sql.Date:
+		return types.NewValue(types.Date, v)
+	}
+
+	return rawCell{Type: typ.String(), Data: v}, nil
+}
+
+// writeError writes the MySQL error of err as the response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	body := Error{Code: mysqlserver.ERUnknownError, State: mysqlserver.SSUnknownSQLState, Message: err.Error()}
+	if sqlErr, ok := mysqlserver.ConvertToMySQLError(err).(*mysql.SQLError); ok {
+		body = Error{Code: sqlErr.Num, State: sqlErr.State, Message: sqlErr.Message}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error Error `json:"error"`
+	}{body})
+}
@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/types"
+	"github.com/turtacn/guocedb/storage/engines/badger"
+)
+
+func newTestGateway(t *testing.T) *httptest.Server {
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	t.Cleanup(func() { kv.Close() })
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badger.NewDatabase("test_db", kv))
+	c.AddDatabase(badger.NewDatabase("other_db", kv))
+	c.SetCurrentDatabase("test_db")
+
+	e := executor.NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+	for _, q := range []string{
+		"CREATE TABLE t (id BIGINT PRIMARY KEY, name TEXT, born DATE)",
+		"INSERT INTO t VALUES (1, 'alice', '1990-05-01'), (2, 'bob', NULL)",
+	} {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(t, err, q)
+		_, err = sql.RowIterToRows(iter)
+		require.NoError(t, err, q)
+	}
+
+	// The requests choose their database.
+	c.SetCurrentDatabase("other_db")
+
+	g := &Gateway{engine: e}
+	srv := httptest.NewServer(g.Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, srv *httptest.Server, body string) (int, map[string]json.RawMessage) {
+	resp, err := http.Post(srv.URL+"/query", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var decoded map[string]json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	return resp.StatusCode, decoded
+}
+
+func TestGateway_Query(t *testing.T) {
+	srv := newTestGateway(t)
+
+	status, body := post(t, srv, `{"sql": "SELECT id, name, born FROM t ORDER BY id", "database": "test_db"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `[
+		{"name": "id", "type": "INT64"},
+		{"name": "name", "type": "TEXT"},
+		{"name": "born", "type": "DATE"}
+	]`, string(body["columns"]))
+	require.JSONEq(t, `[
+		[{"type": "BIGINT", "data": 1}, {"type": "TEXT", "data": "alice"}, {"type": "DATE", "data": "1990-05-01T00:00:00Z"}],
+		[{"type": "BIGINT", "data": 2}, {"type": "TEXT", "data": "bob"}, {"type": "NULL", "data": null}]
+	]`, string(body["rows"]))
+
+	// The values decode to the Go types of their SQL types.
+	var rows [][]*types.Value
+	require.NoError(t, json.Unmarshal(body["rows"], &rows))
+	id, err := rows[0][0].AsInt64()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), id)
+}
+
+func TestGateway_Params(t *testing.T) {
+	srv := newTestGateway(t)
+
+	status, body := post(t, srv, `{"sql": "SELECT id FROM t WHERE name = ? AND id < ?", "database": "test_db", "params": ["bob", 10]}`)
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `[[{"type": "BIGINT", "data": 2}]]`, string(body["rows"]))
+
+	// Params are values, not SQL.
+	status, body = post(t, srv, `{"sql": "SELECT id FROM t WHERE name = ?", "database": "test_db", "params": ["x' OR '1'='1"]}`)
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `[]`, string(body["rows"]))
+
+	status, body = post(t, srv, `{"sql": "SELECT id FROM t WHERE id = ?", "database": "test_db", "params": []}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.JSONEq(t, `{"code": 1210, "state": "HY000", "message": "the query has 1 placeholders but 0 params were given"}`, string(body["error"]))
+}
+
+func TestGateway_Errors(t *testing.T) {
+	srv := newTestGateway(t)
+
+	status, body := post(t, srv, `{"sql": "SELECT * FROM missing", "database": "test_db"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	var sqlErr Error
+	require.NoError(t, json.Unmarshal(body["error"], &sqlErr))
+	require.Equal(t, 1146, sqlErr.Code)
+	require.Equal(t, "42S02", sqlErr.State)
+
+	status, body = post(t, srv, `{"sql": "SELECT 1", "database": "missing"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.NoError(t, json.Unmarshal(body["error"], &sqlErr))
+	require.Equal(t, 1049, sqlErr.Code)
+
+	status, _ = post(t, srv, `{"database": "test_db"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	resp, err := http.Get(srv.URL + "/query")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -11,6 +11,7 @@ func (c *Checker) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", c.healthHandler)
 	mux.HandleFunc("/ready", c.readyHandler)
+	mux.HandleFunc("/readyz", c.readyzHandler)
 	mux.HandleFunc("/live", c.liveHandler)
 	return mux
 }
@@ -54,6 +55,22 @@ func (c *Checker) readyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (c *Checker) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	WriteReadiness(w, c.Readiness(r.Context()))
+}
+
+// WriteReadiness writes the readiness as JSON, with 503 Service Unavailable
+// as the status code if any component is down.
+func WriteReadiness(w http.ResponseWriter, response *ReadinessResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	if response.Status == ReadinessUp {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
 func (c *Checker) liveHandler(w http.ResponseWriter, r *http.Request) {
 	// Liveness: process is alive (simple check)
 	w.Header().Set("Content-Type", "application/json")
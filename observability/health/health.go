@@ -1,10 +1,15 @@
 package health
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"net"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/turtacn/guocedb/compute/sql"
 )
 
 // Status represents health status
@@ -133,6 +138,45 @@ func (c *Checker) Check(ctx context.Context) *HealthResponse {
 	return response
 }
 
+// Readiness states of a component, as /readyz reports them.
+const (
+	ReadinessUp   = "UP"
+	ReadinessDown = "DOWN"
+)
+
+// ComponentStatus is the readiness of a component.
+type ComponentStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// ReadinessResponse is the readiness of the service, which is UP only if all
+// of its components are.
+type ReadinessResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// Readiness runs all health checks and reports the readiness of each of
+// their components.
+func (c *Checker) Readiness(ctx context.Context) *ReadinessResponse {
+	health := c.Check(ctx)
+
+	response := &ReadinessResponse{
+		Status:     ReadinessUp,
+		Components: make(map[string]ComponentStatus, len(health.Checks)),
+	}
+	for _, check := range health.Checks {
+		status := ComponentStatus{Status: ReadinessUp}
+		if check.Status == StatusUnhealthy {
+			status = ComponentStatus{Status: ReadinessDown, Message: check.Message}
+			response.Status = ReadinessDown
+		}
+		response.Components[check.Name] = status
+	}
+	return response
+}
+
 // IsHealthy returns whether the system is healthy
 func (c *Checker) IsHealthy(ctx context.Context) bool {
 	return c.Check(ctx).Status == StatusHealthy
@@ -166,6 +210,82 @@ func StorageHealthCheck(storage interface {
 	}
 }
 
+// Names of the components whose checks the server registers.
+const (
+	ComponentStorage  = "storage"
+	ComponentListener = "mysql_listener"
+)
+
+// Storage is the part of the storage abstraction layer the storage check
+// uses.
+type Storage interface {
+	Get(ctx *sql.Context, db, table string, key []byte) ([]byte, error)
+	Set(ctx *sql.Context, db, table string, key, value []byte) error
+	Delete(ctx *sql.Context, db, table string, key []byte) error
+}
+
+// The sentinel key the storage check writes and deletes again. It's kept
+// apart from the rows of user tables, which are keyed by their own database
+// and table.
+const (
+	sentinelDB    = "__guocedb__"
+	sentinelTable = "__health__"
+)
+
+var sentinelKey = []byte("readiness")
+
+// SALStorageCheck creates a check that writes a sentinel key through the
+// storage abstraction layer and reads it back, failing if storage doesn't
+// answer before the check times out or returns another value.
+func SALStorageCheck(storage Storage) CheckFunc {
+	return func(ctx context.Context) error {
+		errc := make(chan error, 1)
+		go func() {
+			errc <- storageRoundTrip(sql.NewContext(ctx), storage)
+		}()
+
+		select {
+		case err := <-errc:
+			return err
+		case <-ctx.Done():
+			return fmt.Errorf("storage unresponsive: %w", ctx.Err())
+		}
+	}
+}
+
+func storageRoundTrip(ctx *sql.Context, storage Storage) error {
+	value := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err := storage.Set(ctx, sentinelDB, sentinelTable, sentinelKey, value); err != nil {
+		return fmt.Errorf("storage write failed: %w", err)
+	}
+
+	got, err := storage.Get(ctx, sentinelDB, sentinelTable, sentinelKey)
+	if err != nil {
+		return fmt.Errorf("storage read failed: %w", err)
+	}
+	if !bytes.Equal(got, value) {
+		return fmt.Errorf("storage read returned %q, wrote %q", got, value)
+	}
+
+	if err := storage.Delete(ctx, sentinelDB, sentinelTable, sentinelKey); err != nil {
+		return fmt.Errorf("storage delete failed: %w", err)
+	}
+	return nil
+}
+
+// ListenerCheck creates a check that connects to the given TCP address,
+// failing if nothing accepts the connection.
+func ListenerCheck(address string) CheckFunc {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return fmt.Errorf("listener not accepting connections: %w", err)
+		}
+		return conn.Close()
+	}
+}
+
 // AlwaysHealthyCheck creates a check that always returns healthy (for testing)
 func AlwaysHealthyCheck() CheckFunc {
 	return func(ctx context.Context) error {
@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/storage/engines/memory"
 )
 
 func TestHealthyStatus(t *testing.T) {
@@ -190,3 +193,94 @@ func TestRemoveCheck(t *testing.T) {
 	result = checker.Check(context.Background())
 	require.Len(t, result.Checks, 1)
 }
+
+// failingStorage fails every read and write.
+type failingStorage struct{}
+
+func (failingStorage) Get(ctx *sql.Context, db, table string, key []byte) ([]byte, error) {
+	return nil, errors.New("disk unavailable")
+}
+
+func (failingStorage) Set(ctx *sql.Context, db, table string, key, value []byte) error {
+	return errors.New("disk unavailable")
+}
+
+func (failingStorage) Delete(ctx *sql.Context, db, table string, key []byte) error {
+	return errors.New("disk unavailable")
+}
+
+func TestReadyzStorageDown(t *testing.T) {
+	checker := NewChecker()
+	checker.AddCheck(ComponentStorage, SALStorageCheck(failingStorage{}))
+	checker.AddCheck("init", AlwaysHealthyCheck())
+
+	srv := httptest.NewServer(checker.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/readyz")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	var result ReadinessResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Equal(t, ReadinessDown, result.Status)
+	require.Equal(t, ReadinessDown, result.Components[ComponentStorage].Status)
+	require.Contains(t, result.Components[ComponentStorage].Message, "disk unavailable")
+	require.Equal(t, ReadinessUp, result.Components["init"].Status)
+}
+
+func TestReadyzUp(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	checker := NewChecker()
+	checker.AddCheck(ComponentStorage, SALStorageCheck(memory.NewStorage()))
+	checker.AddCheck(ComponentListener, ListenerCheck(l.Addr().String()))
+
+	srv := httptest.NewServer(checker.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/readyz")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result ReadinessResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Equal(t, ReadinessUp, result.Status)
+	require.Len(t, result.Components, 2)
+}
+
+func TestListenerCheckClosed(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	require.Error(t, ListenerCheck(addr)(context.Background()))
+}
+
+func TestStorageCheckUnresponsive(t *testing.T) {
+	checker := NewChecker()
+	checker.SetTimeout(50 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	checker.AddCheck(ComponentStorage, SALStorageCheck(blockingStorage{release: release}))
+
+	result := checker.Readiness(context.Background())
+	require.Equal(t, ReadinessDown, result.Status)
+	require.Contains(t, result.Components[ComponentStorage].Message, "unresponsive")
+}
+
+// blockingStorage doesn't answer writes until it's released.
+type blockingStorage struct {
+	failingStorage
+	release chan struct{}
+}
+
+func (s blockingStorage) Set(ctx *sql.Context, db, table string, key, value []byte) error {
+	<-s.release
+	return nil
+}
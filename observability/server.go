@@ -66,6 +66,7 @@ func (s *Server) Start() error {
 	if s.checker != nil {
 		mux.HandleFunc("/health", s.healthHandler)
 		mux.HandleFunc("/ready", s.readyHandler)
+		mux.HandleFunc("/readyz", s.readyzHandler)
 		mux.HandleFunc("/live", s.liveHandler)
 	}
 
@@ -151,6 +152,15 @@ func (s *Server) readyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	if s.checker == nil {
+		http.Error(w, "Health checker not configured", http.StatusNotImplemented)
+		return
+	}
+
+	health.WriteReadiness(w, s.checker.Readiness(r.Context()))
+}
+
 func (s *Server) liveHandler(w http.ResponseWriter, r *http.Request) {
 	if s.checker == nil {
 		http.Error(w, "Health checker not configured", http.StatusNotImplemented)
@@ -178,6 +188,7 @@ func (s *Server) indexHandler(w http.ResponseWriter, r *http.Request) {
 	if s.checker != nil {
 		endpoints["health"] = "/health"
 		endpoints["ready"] = "/ready"
+		endpoints["readyz"] = "/readyz"
 		endpoints["live"] = "/live"
 	}
 
@@ -2,6 +2,9 @@
 package audit
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -40,11 +43,71 @@ type AuditEvent struct {
 	Object       string                 `json:"object,omitempty"`
 	Privilege    string                 `json:"privilege,omitempty"`
 	ErrorMsg     string                 `json:"error_msg,omitempty"`
-	Duration     time.Duration          `json:"duration_ms,omitempty"`
+	Duration     time.Duration          `json:"-"`
 	RowsAffected int64                  `json:"rows_affected,omitempty"`
 	Extra        map[string]interface{} `json:"extra,omitempty"`
 }
 
+// jsonEvent is the JSON form of an AuditEvent, with its duration in
+// milliseconds.
+type jsonEvent struct {
+	*eventAlias
+	DurationMS float64 `json:"duration_ms,omitempty"`
+}
+
+// eventAlias is AuditEvent without its JSON methods.
+type eventAlias AuditEvent
+
+// MarshalJSON implements the json.Marshaler interface.
+func (e *AuditEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonEvent{
+		eventAlias: (*eventAlias)(e),
+		DurationMS: float64(e.Duration) / float64(time.Millisecond),
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (e *AuditEvent) UnmarshalJSON(b []byte) error {
+	je := jsonEvent{eventAlias: (*eventAlias)(e)}
+	if err := json.Unmarshal(b, &je); err != nil {
+		return err
+	}
+	e.Duration = time.Duration(je.DurationMS * float64(time.Millisecond))
+	return nil
+}
+
+// formatText formats an event as a line of FormatText: its time, type and
+// result, followed by the fields it has as key=value pairs, with the values
+// that may have spaces quoted.
+func formatText(e *AuditEvent) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s", e.Timestamp.Format(time.RFC3339Nano), e.EventType, e.Result)
+
+	field := func(key, value string, quote bool) {
+		if value == "" {
+			return
+		}
+		if quote {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+	field("user", e.Username, true)
+	field("client_ip", e.ClientIP, false)
+	field("database", e.Database, true)
+	field("object", e.Object, true)
+	field("privilege", e.Privilege, false)
+	if e.Duration > 0 {
+		field("duration_ms", strconv.FormatFloat(float64(e.Duration)/float64(time.Millisecond), 'f', -1, 64), false)
+	}
+	if e.RowsAffected > 0 {
+		field("rows", strconv.FormatInt(e.RowsAffected, 10), false)
+	}
+	field("statement", e.Statement, true)
+	field("error", e.ErrorMsg, true)
+	return []byte(b.String())
+}
+
 // NewAuthenticationEvent creates an audit event for authentication attempts.
 func NewAuthenticationEvent(username, clientIP string, success bool) *AuditEvent {
 	result := ResultSuccess
@@ -4,8 +4,10 @@ package audit
 import (
 	"bufio"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -19,10 +21,26 @@ type AuditLogger struct {
 	eventChan chan *AuditEvent
 	done      chan struct{}
 	
+	format Format
+
 	// Filter configuration
 	excludeIPs []string
+	eventTypes map[EventType]bool
+	users      map[string]bool
 }
 
+// Format is the format of the lines of the audit log.
+type Format string
+
+const (
+	// FormatJSON writes each event as a JSON object, for the tools that
+	// ingest the log, such as SIEMs. It's the default.
+	FormatJSON Format = "json"
+	// FormatText writes each event as a line of text, with its time, type
+	// and result followed by its fields as key=value pairs.
+	FormatText Format = "text"
+)
+
 // AuditConfig configures the audit logger.
 type AuditConfig struct {
 	FilePath    string
@@ -30,10 +48,29 @@ type AuditConfig struct {
 	BufferSize  int
 	ExcludeIPs  []string
 	IncludeStmt bool
+
+	// Format is the format of the log, whatever its case. Empty means
+	// FormatJSON.
+	Format Format
+	// EventTypes are the types of the events logged, whatever their case.
+	// Empty means all of them.
+	EventTypes []EventType
+	// Users are the users whose events are logged. Empty means all of
+	// them.
+	Users []string
 }
 
 // NewAuditLogger creates a new audit logger with the given configuration.
 func NewAuditLogger(config AuditConfig) (*AuditLogger, error) {
+	format := Format(strings.ToLower(string(config.Format)))
+	switch format {
+	case "":
+		format = FormatJSON
+	case FormatJSON, FormatText:
+	default:
+		return nil, fmt.Errorf("unknown audit log format %q", config.Format)
+	}
+
 	var writer io.Writer
 	
 	if config.FilePath == "" || config.FilePath == "stdout" {
@@ -50,8 +87,21 @@ func NewAuditLogger(config AuditConfig) (*AuditLogger, error) {
 		writer:     writer,
 		bufWriter:  bufio.NewWriter(writer),
 		async:      config.Async,
+		format:     format,
 		excludeIPs: config.ExcludeIPs,
 	}
+	if len(config.EventTypes) > 0 {
+		logger.eventTypes = make(map[EventType]bool, len(config.EventTypes))
+		for _, typ := range config.EventTypes {
+			logger.eventTypes[EventType(strings.ToUpper(string(typ)))] = true
+		}
+	}
+	if len(config.Users) > 0 {
+		logger.users = make(map[string]bool, len(config.Users))
+		for _, user := range config.Users {
+			logger.users[user] = true
+		}
+	}
 	
 	if config.Async {
 		bufSize := config.BufferSize
@@ -89,10 +139,16 @@ func (l *AuditLogger) writeEvent(event *AuditEvent) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	
-	data, err := json.Marshal(event)
-	if err != nil {
-		// Log marshal error but don't fail
-		return
+	var data []byte
+	if l.format == FormatText {
+		data = formatText(event)
+	} else {
+		var err error
+		data, err = json.Marshal(event)
+		if err != nil {
+			// Log marshal error but don't fail
+			return
+		}
 	}
 	
 	l.bufWriter.Write(data)
@@ -138,6 +194,14 @@ func (l *AuditLogger) shouldFilter(event *AuditEvent) bool {
 			return true
 		}
 	}
+
+	// Filter by event type and user
+	if l.eventTypes != nil && !l.eventTypes[event.EventType] {
+		return true
+	}
+	if l.users != nil && !l.users[event.Username] {
+		return true
+	}
 	
 	return false
 }
@@ -163,7 +227,7 @@ func (l *AuditLogger) Close() error {
 }
 
 // GetEvents retrieves audit events within a time range (for testing/analysis).
-// This is a simple implementation that reads from a file.
+// This is a simple implementation that reads from a file in FormatJSON.
 func GetEvents(filePath string, start, end time.Time) ([]*AuditEvent, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -147,6 +148,117 @@ func TestAuditLogFilter(t *testing.T) {
 	}
 }
 
+func TestAuditLogJSONFields(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "audit_json.log")
+
+	logger, err := NewAuditLogger(AuditConfig{
+		FilePath: tmpFile,
+		Format:   FormatJSON,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	event := NewQueryEvent("alice", "10.0.0.7", "shop", "SELECT * FROM orders", 1500*time.Microsecond, 3)
+	event.ErrorMsg = "Lock wait timeout exceeded"
+	logger.Log(event)
+	logger.Close()
+
+	data, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &parsed); err != nil {
+		t.Fatalf("Log line should be valid JSON: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"event_type":    "QUERY",
+		"result":        "SUCCESS",
+		"username":      "alice",
+		"client_ip":     "10.0.0.7",
+		"database":      "shop",
+		"statement":     "SELECT * FROM orders",
+		"duration_ms":   1.5,
+		"rows_affected": float64(3),
+		"error_msg":     "Lock wait timeout exceeded",
+	}
+	for key, value := range expected {
+		if parsed[key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, parsed[key])
+		}
+	}
+	if _, err := time.Parse(time.RFC3339Nano, parsed["timestamp"].(string)); err != nil {
+		t.Errorf("Timestamp should be RFC 3339: %v", err)
+	}
+
+	// The events are read back as they were logged.
+	events, err := GetEvents(tmpFile, event.Timestamp, event.Timestamp)
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(events) != 1 || events[0].Duration != event.Duration {
+		t.Errorf("Expected the event with a duration of %v, got %+v", event.Duration, events)
+	}
+}
+
+func TestAuditLogTextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &AuditLogger{
+		writer:    &buf,
+		bufWriter: bufio.NewWriter(&buf),
+		format:    FormatText,
+	}
+
+	event := NewQueryEvent("bob", "127.0.0.1", "shop", "SELECT 'a b'", 2*time.Millisecond, 1)
+	event.Timestamp = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	logger.writeEvent(event)
+
+	expected := `2024-03-01T12:00:00Z QUERY SUCCESS user="bob" client_ip=127.0.0.1 database="shop" duration_ms=2 rows=1 statement="SELECT 'a b'"` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	if _, err := NewAuditLogger(AuditConfig{FilePath: filepath.Join(t.TempDir(), "a.log"), Format: "xml"}); err == nil {
+		t.Error("Unknown formats should be rejected")
+	}
+}
+
+func TestAuditLogFilterByTypeAndUser(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "audit_types.log")
+	logger, err := NewAuditLogger(AuditConfig{
+		FilePath:   tmpFile,
+		EventTypes: []EventType{EventTypeQuery, "ddl"},
+		Users:      []string{"alice"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	logger.Log(NewQueryEvent("alice", "127.0.0.1", "shop", "SELECT 1", 0, 0))
+	logger.Log(&AuditEvent{EventType: EventTypeDDL, Username: "alice", Statement: "CREATE TABLE t (a INT)"})
+	logger.Log(NewQueryEvent("bob", "127.0.0.1", "shop", "SELECT 2", 0, 0))
+	logger.Log(NewAuthenticationEvent("alice", "127.0.0.1", true))
+
+	data, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	content := string(data)
+	if lines := strings.Count(content, "\n"); lines != 2 {
+		t.Errorf("Expected 2 events, got %d: %s", lines, content)
+	}
+	if !containsString(content, "SELECT 1") || !containsString(content, "CREATE TABLE") {
+		t.Error("Events of the types and users audited should be logged")
+	}
+	if containsString(content, "SELECT 2") || containsString(content, "AUTHENTICATION") {
+		t.Error("Events of other types or users should not be logged")
+	}
+}
+
 func TestNewAuthenticationEvent(t *testing.T) {
 	event := NewAuthenticationEvent("testuser", "127.0.0.1", true)
 	
@@ -32,6 +32,23 @@ func NewAuthenticator(store UserStore) *Authenticator {
 
 // Authenticate verifies user credentials and returns the user on success.
 func (a *Authenticator) Authenticate(ctx context.Context, username, password string) (*User, error) {
+	return a.authenticate(ctx, username, func(user *User) bool {
+		return VerifyPassword(password, user.PasswordHash)
+	})
+}
+
+// AuthenticateNative verifies the response of a client to the
+// mysql_native_password challenge with the given salt, and returns the user
+// on success. Failures count towards the lock of the user as the ones of
+// Authenticate do.
+func (a *Authenticator) AuthenticateNative(ctx context.Context, username string, salt, scramble []byte) (*User, error) {
+	return a.authenticate(ctx, username, func(user *User) bool {
+		return VerifyMySQLNativeScramble(salt, scramble, user.NativePasswordHash)
+	})
+}
+
+// authenticate returns the user if verify accepts its credentials.
+func (a *Authenticator) authenticate(ctx context.Context, username string, verify func(*User) bool) (*User, error) {
 	// Check if user is temporarily locked due to failed attempts
 	if a.isLocked(username) {
 		return nil, ErrAccountLocked
@@ -54,7 +71,7 @@ func (a *Authenticator) Authenticate(ctx context.Context, username, password str
 	}
 	
 	// Verify password
-	if !VerifyPassword(password, user.PasswordHash) {
+	if !verify(user) {
 		a.recordFailure(username)
 		return nil, ErrAuthenticationFailed
 	}
@@ -3,6 +3,8 @@ package auth
 
 import (
 	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 
 	"golang.org/x/crypto/bcrypt"
@@ -38,3 +40,33 @@ func HashMySQLNativePassword(password string) string {
 	second := sha1.Sum(first[:])
 	return fmt.Sprintf("*%X", second)
 }
+
+// VerifyMySQLNativeScramble verifies the response of a client to the
+// mysql_native_password challenge with the given salt against the native
+// hash of its password. The client sends SHA1(password) XOR
+// SHA1(salt + SHA1(SHA1(password))), so SHA1(password) is recovered with the
+// hash and checked against it.
+func VerifyMySQLNativeScramble(salt, scramble []byte, nativeHash string) bool {
+	if nativeHash == "" {
+		return len(scramble) == 0
+	}
+	if len(scramble) != sha1.Size || len(nativeHash) != 2*sha1.Size+1 || nativeHash[0] != '*' {
+		return false
+	}
+
+	stage2, err := hex.DecodeString(nativeHash[1:])
+	if err != nil {
+		return false
+	}
+
+	crypt := sha1.New()
+	crypt.Write(salt)
+	crypt.Write(stage2)
+	stage1 := crypt.Sum(nil)
+	for i := range stage1 {
+		stage1[i] ^= scramble[i]
+	}
+
+	check := sha1.Sum(stage1)
+	return subtle.ConstantTimeCompare(check[:], stage2) == 1
+}
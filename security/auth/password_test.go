@@ -2,6 +2,8 @@ package auth
 
 import (
 	"testing"
+
+	"github.com/dolthub/vitess/go/mysql"
 )
 
 func TestHashPassword(t *testing.T) {
@@ -83,3 +85,33 @@ func TestPasswordHashUniqueness(t *testing.T) {
 		t.Error("Both hashes should verify correctly")
 	}
 }
+
+func TestVerifyMySQLNativeScramble(t *testing.T) {
+	salt, err := mysql.NewSalt()
+	if err != nil {
+		t.Fatalf("NewSalt failed: %v", err)
+	}
+	hash := HashMySQLNativePassword("secret123")
+
+	scramble := mysql.ScrambleMysqlNativePassword(salt, []byte("secret123"))
+	if !VerifyMySQLNativeScramble(salt, scramble, hash) {
+		t.Error("VerifyMySQLNativeScramble should accept the scramble of the password")
+	}
+
+	wrong := mysql.ScrambleMysqlNativePassword(salt, []byte("wrong"))
+	if VerifyMySQLNativeScramble(salt, wrong, hash) {
+		t.Error("VerifyMySQLNativeScramble should reject the scramble of another password")
+	}
+
+	if VerifyMySQLNativeScramble(salt, nil, hash) {
+		t.Error("VerifyMySQLNativeScramble should reject an empty scramble for a password")
+	}
+
+	// Users without password send an empty scramble.
+	if !VerifyMySQLNativeScramble(salt, nil, HashMySQLNativePassword("")) {
+		t.Error("VerifyMySQLNativeScramble should accept an empty scramble without password")
+	}
+	if VerifyMySQLNativeScramble(salt, scramble, HashMySQLNativePassword("")) {
+		t.Error("VerifyMySQLNativeScramble should reject a scramble without password")
+	}
+}
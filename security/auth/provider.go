@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/turtacn/guocedb/security/authz"
+)
+
+// Names of the built-in providers.
+const (
+	// NativeProviderName is the provider checking the passwords of the
+	// users of the user store. It's used if no provider is configured.
+	NativeProviderName = "native"
+	// NoneProviderName is the provider letting anyone in with all the
+	// privileges.
+	NoneProviderName = "none"
+)
+
+// Credential is what a client proves who it is with.
+type Credential struct {
+	// Password is the password in clear text, as sent with
+	// mysql_clear_password.
+	Password string
+	// Salt and Scramble are the challenge of the server and the response
+	// of the client of mysql_native_password, which proves the client
+	// knows the password without sending it.
+	Salt     []byte
+	Scramble []byte
+}
+
+// Provider authenticates the users connecting to the server. Custom
+// providers, such as one checking the passwords against a LDAP directory,
+// are made available with RegisterProvider.
+type Provider interface {
+	// Authenticate returns the user the credential proves the client
+	// connecting from clientAddr is, or ErrAuthenticationFailed if it
+	// doesn't.
+	Authenticate(ctx context.Context, username string, credential Credential, clientAddr string) (*User, error)
+}
+
+// ScrambleProvider is implemented by the providers that check the
+// scrambles of mysql_native_password, so clients never send their
+// passwords. The other providers are given passwords, which clients send
+// in clear text with mysql_clear_password.
+type ScrambleProvider interface {
+	Provider
+	// AcceptsScrambles returns whether Authenticate checks the salt and
+	// scramble of the credentials.
+	AcceptsScrambles() bool
+}
+
+// AcceptsScrambles returns whether the provider checks the scrambles of
+// mysql_native_password.
+func AcceptsScrambles(p Provider) bool {
+	sp, ok := p.(ScrambleProvider)
+	return ok && sp.AcceptsScrambles()
+}
+
+// ProviderConfig is what a provider is created with.
+type ProviderConfig struct {
+	// Users are the users of the security manager.
+	Users UserStore
+	// Authenticator checks the passwords of Users, locking out the ones
+	// failing too often.
+	Authenticator *Authenticator
+	// Options are the settings of the provider in the configuration.
+	Options map[string]string
+}
+
+// ProviderFactory creates a provider with the given configuration.
+type ProviderFactory func(config ProviderConfig) (Provider, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ProviderFactory{
+		NativeProviderName: func(config ProviderConfig) (Provider, error) {
+			return NewNativeProvider(config.Authenticator), nil
+		},
+		NoneProviderName: func(ProviderConfig) (Provider, error) {
+			return NoneProvider{}, nil
+		},
+	}
+)
+
+// RegisterProvider makes a provider available with the given name, which
+// the configuration chooses it by. It replaces the provider registered with
+// the same name, if any.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = factory
+}
+
+// NewProvider creates the provider registered with the given name.
+func NewProvider(name string, config ProviderConfig) (Provider, error) {
+	providersMu.RLock()
+	factory, ok := providers[name]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown authentication provider %q", name)
+	}
+	return factory(config)
+}
+
+// ProviderNames returns the names of the registered providers, sorted.
+func ProviderNames() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NativeProvider checks the credentials against the password hashes of the
+// users of an Authenticator.
+type NativeProvider struct {
+	a *Authenticator
+}
+
+// NewNativeProvider creates a provider checking the credentials with the
+// given authenticator.
+func NewNativeProvider(a *Authenticator) *NativeProvider {
+	return &NativeProvider{a}
+}
+
+// Authenticate implements Provider. The scramble is checked if there is
+// one, and the password otherwise.
+func (p *NativeProvider) Authenticate(ctx context.Context, username string, credential Credential, clientAddr string) (*User, error) {
+	if credential.Scramble != nil || credential.Salt != nil {
+		return p.a.AuthenticateNative(ctx, username, credential.Salt, credential.Scramble)
+	}
+	return p.a.Authenticate(ctx, username, credential.Password)
+}
+
+// AcceptsScrambles implements ScrambleProvider.
+func (p *NativeProvider) AcceptsScrambles() bool {
+	return true
+}
+
+// NoneProvider lets anyone in, with all the privileges.
+type NoneProvider struct{}
+
+// Authenticate implements Provider.
+func (NoneProvider) Authenticate(ctx context.Context, username string, credential Credential, clientAddr string) (*User, error) {
+	return &User{Username: username, Privileges: authz.PrivilegeAll}, nil
+}
+
+// AcceptsScrambles implements ScrambleProvider. Any scramble is accepted,
+// so clients don't have to send their passwords.
+func (NoneProvider) AcceptsScrambles() bool {
+	return true
+}
@@ -16,6 +16,9 @@ type User struct {
 	ID           uint64
 	Username     string
 	PasswordHash string
+	// NativePasswordHash is the mysql_native_password hash of the password,
+	// which is what the scrambles of the MySQL handshake are checked with.
+	NativePasswordHash string
 	Roles        []string
 	Privileges   authz.Privilege
 	CreatedAt    time.Time
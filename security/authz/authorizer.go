@@ -3,11 +3,13 @@ package authz
 
 import (
 	"context"
+	"strings"
 )
 
 // Authorizer handles privilege checking for users.
 type Authorizer struct {
-	roleStore RoleStore
+	roleStore  RoleStore
+	grantStore GrantStore
 }
 
 // NewAuthorizer creates a new authorizer with the given role store.
@@ -17,6 +19,12 @@ func NewAuthorizer(roleStore RoleStore) *Authorizer {
 	}
 }
 
+// SetGrantStore sets the store of the privileges granted to the users on
+// tables and columns, which are not checked without one.
+func (a *Authorizer) SetGrantStore(grantStore GrantStore) {
+	a.grantStore = grantStore
+}
+
 // User interface for authorization - we only need username, roles, and privileges.
 type User interface {
 	GetUsername() string
@@ -25,12 +33,32 @@ type User interface {
 }
 
 // CheckPrivilege verifies if a user has the required privilege on a resource.
+// The most specific grant wins: if the user was granted privileges on the
+// table, or on some of its columns, those are the only ones the user has
+// on it, whatever the privileges of the user on the database.
 func (a *Authorizer) CheckPrivilege(ctx context.Context, user User, database, table string, required Privilege) error {
 	// Super users bypass all checks
 	if user.GetPrivileges().Has(PrivilegeAdmin) {
 		return nil
 	}
 	
+	grants, err := a.tableGrants(ctx, user, database, table)
+	if err != nil {
+		return err
+	}
+	if len(grants) > 0 {
+		// The table may be referenced with the privileges of any of its
+		// columns, which are then checked with CheckColumnPrivilege.
+		var granted Privilege
+		for _, g := range grants {
+			granted |= g.Privileges
+		}
+		if granted.Has(required) {
+			return nil
+		}
+		return ErrAccessDenied
+	}
+	
 	// Check user's direct privileges
 	if user.GetPrivileges().Has(required) {
 		return nil
@@ -72,6 +100,49 @@ func (a *Authorizer) CheckPrivilege(ctx context.Context, user User, database, ta
 	return ErrAccessDenied
 }
 
+// CheckColumnPrivilege verifies if a user has the required privilege on a
+// column of a table. The privileges granted on the column win over those
+// granted on the table. If only other columns of the table were granted to
+// the user, the column is not accessible.
+func (a *Authorizer) CheckColumnPrivilege(ctx context.Context, user User, database, table, column string, required Privilege) error {
+	if user.GetPrivileges().Has(PrivilegeAdmin) {
+		return nil
+	}
+
+	grants, err := a.tableGrants(ctx, user, database, table)
+	if err != nil {
+		return err
+	}
+	if len(grants) == 0 {
+		return a.CheckPrivilege(ctx, user, database, table, required)
+	}
+
+	var tableGrant *Grant
+	for i, g := range grants {
+		if g.Column == "" {
+			tableGrant = &grants[i]
+		} else if strings.EqualFold(g.Column, column) {
+			if g.Privileges.Has(required) {
+				return nil
+			}
+			return ErrAccessDenied
+		}
+	}
+
+	if tableGrant != nil && tableGrant.Privileges.Has(required) {
+		return nil
+	}
+	return ErrAccessDenied
+}
+
+// tableGrants returns the grants of the user on the table and its columns.
+func (a *Authorizer) tableGrants(ctx context.Context, user User, database, table string) ([]Grant, error) {
+	if a.grantStore == nil || table == "" {
+		return nil, nil
+	}
+	return a.grantStore.GetGrants(ctx, user.GetUsername(), database, table)
+}
+
 // CheckPrivileges performs multiple privilege checks in batch.
 func (a *Authorizer) CheckPrivileges(ctx context.Context, user User, checks []PrivilegeCheck) error {
 	for _, check := range checks {
@@ -265,3 +265,45 @@ func TestCheckPrivilegeMultipleRoles(t *testing.T) {
 		t.Errorf("Should not have DELETE: %v", err)
 	}
 }
+
+func TestCheckPrivilegeObjectGrants(t *testing.T) {
+	ctx := context.Background()
+	grants := NewInMemoryGrantStore()
+	authz := NewAuthorizer(NewInMemoryRoleStore())
+	authz.SetGrantStore(grants)
+
+	user := &mockUser{
+		username: "granted",
+		roles:    []string{"readwrite"},
+	}
+
+	grants.Grant(ctx, Grant{Username: "Granted", Database: "testdb", Table: "orders", Privileges: PrivilegeSelect})
+	grants.Grant(ctx, Grant{Username: "granted", Database: "testdb", Table: "users", Column: "name", Privileges: PrivilegeSelect})
+
+	// The table grant wins over the privileges of the role.
+	if err := authz.CheckPrivilege(ctx, user, "testdb", "orders", PrivilegeSelect); err != nil {
+		t.Errorf("Should have SELECT on testdb.orders: %v", err)
+	}
+	if err := authz.CheckPrivilege(ctx, user, "testdb", "orders", PrivilegeInsert); err != ErrAccessDenied {
+		t.Errorf("Should not have INSERT on testdb.orders: %v", err)
+	}
+	if err := authz.CheckPrivilege(ctx, user, "testdb", "items", PrivilegeInsert); err != nil {
+		t.Errorf("Should have INSERT on testdb.items from the role: %v", err)
+	}
+
+	// Only the granted columns are accessible.
+	if err := authz.CheckColumnPrivilege(ctx, user, "testdb", "users", "NAME", PrivilegeSelect); err != nil {
+		t.Errorf("Should have SELECT on testdb.users.name: %v", err)
+	}
+	if err := authz.CheckColumnPrivilege(ctx, user, "testdb", "users", "password", PrivilegeSelect); err != ErrAccessDenied {
+		t.Errorf("Should not have SELECT on testdb.users.password: %v", err)
+	}
+	if err := authz.CheckColumnPrivilege(ctx, user, "testdb", "orders", "total", PrivilegeSelect); err != nil {
+		t.Errorf("Should have SELECT on testdb.orders.total: %v", err)
+	}
+
+	grants.Revoke(ctx, Grant{Username: "granted", Database: "testdb", Table: "orders", Privileges: PrivilegeSelect})
+	if err := authz.CheckPrivilege(ctx, user, "testdb", "orders", PrivilegeInsert); err != nil {
+		t.Errorf("Should have INSERT on testdb.orders from the role again: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+// Package authz provides authorization services for GuoceDB.
+package authz
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Grant is a set of privileges granted to a user on a table, or on a
+// column of it.
+type Grant struct {
+	Username   string
+	Database   string
+	Table      string
+	Column     string // Empty for the grants on the whole table
+	Privileges Privilege
+}
+
+// GrantStore is the interface for the persistence of the grants on tables
+// and columns.
+type GrantStore interface {
+	// GetGrants returns the grants of a user on a table, those on its
+	// columns included.
+	GetGrants(ctx context.Context, username, database, table string) ([]Grant, error)
+	// Grant adds the privileges of the grant to those the user has on
+	// its object.
+	Grant(ctx context.Context, grant Grant) error
+	// Revoke removes the privileges of the grant from those the user has
+	// on its object.
+	Revoke(ctx context.Context, grant Grant) error
+	// DeleteGrants removes all the grants of a user.
+	DeleteGrants(ctx context.Context, username string) error
+}
+
+// grantKey identifies the object of a grant. Names are case-insensitive.
+type grantKey struct {
+	username string
+	database string
+	table    string
+	column   string
+}
+
+func keyOf(g Grant) grantKey {
+	return grantKey{
+		username: strings.ToLower(g.Username),
+		database: strings.ToLower(g.Database),
+		table:    strings.ToLower(g.Table),
+		column:   strings.ToLower(g.Column),
+	}
+}
+
+// InMemoryGrantStore is an in-memory implementation of GrantStore.
+type InMemoryGrantStore struct {
+	mu     sync.RWMutex
+	grants map[grantKey]Grant
+}
+
+// NewInMemoryGrantStore creates a new empty in-memory grant store.
+func NewInMemoryGrantStore() *InMemoryGrantStore {
+	return &InMemoryGrantStore{
+		grants: make(map[grantKey]Grant),
+	}
+}
+
+// GetGrants implements GrantStore.
+func (s *InMemoryGrantStore) GetGrants(ctx context.Context, username, database, table string) ([]Grant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := keyOf(Grant{Username: username, Database: database, Table: table})
+	var grants []Grant
+	for k, g := range s.grants {
+		if k.username == key.username && k.database == key.database && k.table == key.table {
+			grants = append(grants, g)
+		}
+	}
+	return grants, nil
+}
+
+// Grant implements GrantStore.
+func (s *InMemoryGrantStore) Grant(ctx context.Context, grant Grant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := keyOf(grant)
+	if g, ok := s.grants[key]; ok {
+		grant.Privileges |= g.Privileges
+	}
+	s.grants[key] = grant
+	return nil
+}
+
+// Revoke implements GrantStore. The grants left without privileges are
+// removed, so the object is no longer considered granted to the user.
+func (s *InMemoryGrantStore) Revoke(ctx context.Context, grant Grant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := keyOf(grant)
+	g, ok := s.grants[key]
+	if !ok {
+		return nil
+	}
+
+	g.Privileges &^= grant.Privileges
+	if g.Privileges == PrivilegeNone {
+		delete(s.grants, key)
+	} else {
+		s.grants[key] = g
+	}
+	return nil
+}
+
+// DeleteGrants implements GrantStore.
+func (s *InMemoryGrantStore) DeleteGrants(ctx context.Context, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	username = strings.ToLower(username)
+	for k := range s.grants {
+		if k.username == username {
+			delete(s.grants, k)
+		}
+	}
+	return nil
+}
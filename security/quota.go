@@ -0,0 +1,139 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Quota limits the resources a user may use. Zero fields mean no limit.
+type Quota struct {
+	// MaxConnections is the number of connections the user may have open
+	// at the same time.
+	MaxConnections int
+	// MaxQueriesPerMinute is the number of queries the user may run in any
+	// minute.
+	MaxQueriesPerMinute int
+	// MaxRowsPerQuery is the number of rows a query of the user may return.
+	MaxRowsPerQuery int64
+}
+
+// Names of the resources limited by a Quota, as reported in errors.
+const (
+	ResourceMaxConnections      = "max_user_connections"
+	ResourceMaxQueriesPerMinute = "max_queries_per_minute"
+	ResourceMaxRowsPerQuery     = "max_rows_per_query"
+)
+
+// QuotaExceededError is returned when a user goes over one of its limits.
+type QuotaExceededError struct {
+	User     string
+	Resource string
+	Limit    int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("User '%s' has exceeded the '%s' resource (current value: %d)", e.User, e.Resource, e.Limit)
+}
+
+// UserOption configures a user created with CreateUser.
+type UserOption func(*userOptions)
+
+type userOptions struct {
+	quota Quota
+}
+
+// WithQuota sets the quota of the user.
+func WithQuota(q Quota) UserOption {
+	return func(o *userOptions) {
+		o.quota = q
+	}
+}
+
+// quotaTracker keeps the quotas of the users along with what they use.
+// Usernames are case-insensitive, as in the user store.
+type quotaTracker struct {
+	mu     sync.Mutex
+	quotas map[string]Quota
+	conns  map[string]int
+	// queries are the times of the queries each user ran in the last
+	// minute, oldest first.
+	queries map[string][]time.Time
+	now     func() time.Time
+}
+
+func newQuotaTracker() *quotaTracker {
+	return &quotaTracker{
+		quotas:  make(map[string]Quota),
+		conns:   make(map[string]int),
+		queries: make(map[string][]time.Time),
+		now:     time.Now,
+	}
+}
+
+func (t *quotaTracker) set(user string, q Quota) {
+	key := strings.ToLower(user)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if q == (Quota{}) {
+		delete(t.quotas, key)
+		return
+	}
+	t.quotas[key] = q
+}
+
+func (t *quotaTracker) get(user string) Quota {
+	key := strings.ToLower(user)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.quotas[key]
+}
+
+func (t *quotaTracker) acquireConnection(user string) error {
+	key := strings.ToLower(user)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if max := t.quotas[key].MaxConnections; max > 0 && t.conns[key] >= max {
+		return &QuotaExceededError{User: user, Resource: ResourceMaxConnections, Limit: int64(max)}
+	}
+	t.conns[key]++
+	return nil
+}
+
+func (t *quotaTracker) releaseConnection(user string) {
+	key := strings.ToLower(user)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conns[key] <= 1 {
+		delete(t.conns, key)
+		return
+	}
+	t.conns[key]--
+}
+
+func (t *quotaTracker) allowQuery(user string) error {
+	key := strings.ToLower(user)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	max := t.quotas[key].MaxQueriesPerMinute
+	if max <= 0 {
+		return nil
+	}
+
+	now := t.now()
+	recent := t.queries[key]
+	for len(recent) > 0 && now.Sub(recent[0]) >= time.Minute {
+		recent = recent[1:]
+	}
+	if len(recent) >= max {
+		t.queries[key] = recent
+		return &QuotaExceededError{User: user, Resource: ResourceMaxQueriesPerMinute, Limit: int64(max)}
+	}
+	t.queries[key] = append(recent, now)
+	return nil
+}
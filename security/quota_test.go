@@ -0,0 +1,117 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/turtacn/guocedb/security/audit"
+)
+
+func newQuotaTestManager(t *testing.T) *SecurityManager {
+	sm, err := NewSecurityManager(SecurityConfig{
+		Enabled:     true,
+		AuditConfig: audit.AuditConfig{FilePath: filepath.Join(t.TempDir(), "audit.log")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create SecurityManager: %v", err)
+	}
+	t.Cleanup(func() { sm.Close() })
+	return sm
+}
+
+func TestQuotaMaxConnections(t *testing.T) {
+	sm := newQuotaTestManager(t)
+	ctx := context.Background()
+
+	err := sm.CreateUser(ctx, "app", "secret", nil, WithQuota(Quota{MaxConnections: 2}))
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := sm.AcquireConnection("app"); err != nil {
+			t.Fatalf("Connection %d should be allowed: %v", i+1, err)
+		}
+	}
+
+	var quotaErr *QuotaExceededError
+	err = sm.AcquireConnection("APP")
+	if !errors.As(err, &quotaErr) || quotaErr.Resource != ResourceMaxConnections {
+		t.Fatalf("Third connection should exceed %s, got: %v", ResourceMaxConnections, err)
+	}
+
+	sm.ReleaseConnection("app")
+	if err := sm.AcquireConnection("app"); err != nil {
+		t.Errorf("Connection should be allowed after one is released: %v", err)
+	}
+
+	// Users without quota are not limited.
+	for i := 0; i < 10; i++ {
+		if err := sm.AcquireConnection("root"); err != nil {
+			t.Fatalf("root should not be limited: %v", err)
+		}
+	}
+}
+
+func TestQuotaMaxQueriesPerMinute(t *testing.T) {
+	sm := newQuotaTestManager(t)
+	ctx := context.Background()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sm.quotas.now = func() time.Time { return now }
+
+	if err := sm.SetQuota(ctx, "root", Quota{MaxQueriesPerMinute: 3}); err != nil {
+		t.Fatalf("Failed to set quota: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := sm.AllowQuery("root"); err != nil {
+			t.Fatalf("Query %d should be allowed: %v", i+1, err)
+		}
+		now = now.Add(10 * time.Second)
+	}
+
+	var quotaErr *QuotaExceededError
+	if err := sm.AllowQuery("root"); !errors.As(err, &quotaErr) || quotaErr.Resource != ResourceMaxQueriesPerMinute {
+		t.Fatalf("Fourth query should exceed %s, got: %v", ResourceMaxQueriesPerMinute, err)
+	}
+
+	// A minute after the first query, there is room for one more.
+	now = now.Add(30 * time.Second)
+	if err := sm.AllowQuery("root"); err != nil {
+		t.Errorf("Query should be allowed once the first one is a minute old: %v", err)
+	}
+	if err := sm.AllowQuery("root"); err == nil {
+		t.Error("Query should exceed the limit again")
+	}
+}
+
+func TestSetQuota(t *testing.T) {
+	sm := newQuotaTestManager(t)
+	ctx := context.Background()
+
+	if err := sm.SetQuota(ctx, "nobody", Quota{MaxConnections: 1}); err != ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound, got: %v", err)
+	}
+
+	q := Quota{MaxConnections: 5, MaxRowsPerQuery: 100}
+	if err := sm.SetQuota(ctx, "root", q); err != nil {
+		t.Fatalf("Failed to set quota: %v", err)
+	}
+	if got := sm.Quota("root"); got != q {
+		t.Errorf("Expected quota %+v, got %+v", q, got)
+	}
+	if got := sm.MaxRowsPerQuery("root"); got != 100 {
+		t.Errorf("Expected 100 rows per query, got %d", got)
+	}
+
+	if err := sm.SetQuota(ctx, "root", Quota{}); err != nil {
+		t.Fatalf("Failed to clear quota: %v", err)
+	}
+	if got := sm.Quota("root"); got != (Quota{}) {
+		t.Errorf("Expected no quota, got %+v", got)
+	}
+}
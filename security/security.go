@@ -3,6 +3,7 @@ package security
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/turtacn/guocedb/security/audit"
@@ -17,7 +18,14 @@ type SecurityManager struct {
 	auditLogger   *audit.AuditLogger
 	userStore     auth.UserStore
 	roleStore     authz.RoleStore
+	grantStore    authz.GrantStore
+	quotas        *quotaTracker
 	enabled       bool
+
+	// provider checks the credentials of the users, and external are the
+	// users it authenticated that are not in the user store, by name.
+	provider auth.Provider
+	external sync.Map
 }
 
 // SecurityConfig configures the security manager.
@@ -26,6 +34,11 @@ type SecurityConfig struct {
 	AuditConfig  audit.AuditConfig
 	MaxAuthFails int
 	LockDuration time.Duration
+	// AuthProvider is the name of the registered provider the users are
+	// authenticated by. auth.NativeProviderName is used if it's empty.
+	AuthProvider string
+	// AuthProviderOptions are the settings given to the provider.
+	AuthProviderOptions map[string]string
 }
 
 // NewSecurityManager creates a new security manager with the given configuration.
@@ -37,6 +50,7 @@ func NewSecurityManager(config SecurityConfig) (*SecurityManager, error) {
 	// Initialize stores
 	userStore := auth.NewInMemoryUserStore()
 	roleStore := authz.NewInMemoryRoleStore()
+	grantStore := authz.NewInMemoryGrantStore()
 	
 	// Initialize audit logger
 	auditLogger, err := audit.NewAuditLogger(config.AuditConfig)
@@ -53,15 +67,33 @@ func NewSecurityManager(config SecurityConfig) (*SecurityManager, error) {
 		authenticator.SetLockDuration(config.LockDuration)
 	}
 	
+	providerName := config.AuthProvider
+	if providerName == "" {
+		providerName = auth.NativeProviderName
+	}
+	provider, err := auth.NewProvider(providerName, auth.ProviderConfig{
+		Users:         userStore,
+		Authenticator: authenticator,
+		Options:       config.AuthProviderOptions,
+	})
+	if err != nil {
+		auditLogger.Close()
+		return nil, err
+	}
+
 	// Initialize authorizer
 	authorizer := authz.NewAuthorizer(roleStore)
+	authorizer.SetGrantStore(grantStore)
 	
 	return &SecurityManager{
 		authenticator: authenticator,
+		provider:      provider,
 		authorizer:    authorizer,
 		auditLogger:   auditLogger,
 		userStore:     userStore,
 		roleStore:     roleStore,
+		grantStore:    grantStore,
+		quotas:        newQuotaTracker(),
 		enabled:       true,
 	}, nil
 }
@@ -76,12 +108,47 @@ func (sm *SecurityManager) Authenticate(ctx context.Context, username, password,
 		}, nil
 	}
 	
-	user, err := sm.authenticator.Authenticate(ctx, username, password)
-	
-	// Audit the authentication attempt
+	return sm.authenticate(ctx, username, auth.Credential{Password: password}, clientIP)
+}
+
+// AuthenticateNative verifies the response of a MySQL client to the
+// mysql_native_password challenge with the given salt, and returns the
+// authenticated user.
+func (sm *SecurityManager) AuthenticateNative(ctx context.Context, username string, salt, scramble []byte, clientIP string) (*auth.User, error) {
+	if !sm.enabled {
+		return &auth.User{
+			Username:   username,
+			Privileges: authz.PrivilegeAll,
+		}, nil
+	}
+
+	return sm.authenticate(ctx, username, auth.Credential{Salt: salt, Scramble: scramble}, clientIP)
+}
+
+// authenticate checks the credential with the provider and audits the
+// attempt. The users the provider knows of but the user store doesn't are
+// kept, so their privileges can be checked while they are connected.
+func (sm *SecurityManager) authenticate(ctx context.Context, username string, credential auth.Credential, clientIP string) (*auth.User, error) {
+	user, err := sm.provider.Authenticate(ctx, username, credential, clientIP)
+	if err == nil && user == nil {
+		err = auth.ErrAuthenticationFailed
+	}
 	sm.auditLogger.Log(audit.NewAuthenticationEvent(username, clientIP, err == nil))
-	
-	return user, err
+	if err != nil {
+		return nil, err
+	}
+
+	if stored, _ := sm.userStore.GetUser(ctx, username); stored == nil {
+		sm.external.Store(username, user)
+	}
+	return user, nil
+}
+
+// AcceptsScrambles returns whether the users can be authenticated with the
+// scrambles of mysql_native_password. If they can't, their passwords are
+// needed in clear text.
+func (sm *SecurityManager) AcceptsScrambles() bool {
+	return !sm.enabled || auth.AcceptsScrambles(sm.provider)
 }
 
 // CheckPrivilege verifies if a user has the required privilege on a resource.
@@ -108,6 +175,29 @@ func (sm *SecurityManager) CheckPrivilege(ctx context.Context, user *auth.User,
 	return err
 }
 
+// CheckColumnPrivilege verifies if a user has the required privilege on a
+// column of a table.
+func (sm *SecurityManager) CheckColumnPrivilege(ctx context.Context, user *auth.User, database, table, column string, privilege authz.Privilege) error {
+	if !sm.enabled {
+		return nil
+	}
+
+	err := sm.authorizer.CheckColumnPrivilege(ctx, user, database, table, column, privilege)
+	if err != nil {
+		event := audit.NewAuthorizationEvent(
+			user.Username,
+			"",
+			database,
+			table+"."+column,
+			privilege.String(),
+			true,
+		)
+		sm.auditLogger.Log(event)
+	}
+
+	return err
+}
+
 // CheckPrivileges performs multiple privilege checks in batch.
 func (sm *SecurityManager) CheckPrivileges(ctx context.Context, user *auth.User, checks []authz.PrivilegeCheck) error {
 	if !sm.enabled {
@@ -138,10 +228,15 @@ func (sm *SecurityManager) AuditConnection(username, clientIP string, success bo
 }
 
 // CreateUser creates a new user with the specified credentials and roles.
-func (sm *SecurityManager) CreateUser(ctx context.Context, username, password string, roles []string) error {
+func (sm *SecurityManager) CreateUser(ctx context.Context, username, password string, roles []string, opts ...UserOption) error {
 	if !sm.enabled {
 		return nil
 	}
+
+	var o userOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 	
 	hash, err := auth.HashPassword(password)
 	if err != nil {
@@ -149,14 +244,20 @@ func (sm *SecurityManager) CreateUser(ctx context.Context, username, password st
 	}
 	
 	user := &auth.User{
-		Username:     username,
-		PasswordHash: hash,
-		Roles:        roles,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		Username:           username,
+		PasswordHash:       hash,
+		NativePasswordHash: auth.HashMySQLNativePassword(password),
+		Roles:              roles,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
 	}
 	
-	return sm.userStore.CreateUser(ctx, user)
+	if err := sm.userStore.CreateUser(ctx, user); err != nil {
+		return err
+	}
+
+	sm.quotas.set(username, o.quota)
+	return nil
 }
 
 // DropUser removes a user from the system.
@@ -165,7 +266,104 @@ func (sm *SecurityManager) DropUser(ctx context.Context, username string) error
 		return nil
 	}
 	
-	return sm.userStore.DeleteUser(ctx, username)
+	if err := sm.userStore.DeleteUser(ctx, username); err != nil {
+		return err
+	}
+	if err := sm.grantStore.DeleteGrants(ctx, username); err != nil {
+		return err
+	}
+
+	sm.quotas.set(username, Quota{})
+	return nil
+}
+
+// SetPassword changes the password of a user.
+func (sm *SecurityManager) SetPassword(ctx context.Context, username, password string) error {
+	if !sm.enabled {
+		return nil
+	}
+
+	user, err := sm.userStore.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = hash
+	user.NativePasswordHash = auth.HashMySQLNativePassword(password)
+	return sm.userStore.UpdateUser(ctx, user)
+}
+
+// SetQuota replaces the quota of a user. The connections and queries the
+// user has already are not affected.
+func (sm *SecurityManager) SetQuota(ctx context.Context, username string, q Quota) error {
+	if !sm.enabled {
+		return nil
+	}
+
+	user, err := sm.userStore.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	sm.quotas.set(username, q)
+	return nil
+}
+
+// Quota returns the quota of a user.
+func (sm *SecurityManager) Quota(username string) Quota {
+	if !sm.enabled {
+		return Quota{}
+	}
+
+	return sm.quotas.get(username)
+}
+
+// AcquireConnection accounts for a new connection of the user, or returns a
+// *QuotaExceededError if the user has as many as allowed already. Each
+// connection acquired must be released with ReleaseConnection.
+func (sm *SecurityManager) AcquireConnection(username string) error {
+	if !sm.enabled {
+		return nil
+	}
+
+	return sm.quotas.acquireConnection(username)
+}
+
+// ReleaseConnection accounts for a connection of the user being closed.
+func (sm *SecurityManager) ReleaseConnection(username string) {
+	if !sm.enabled {
+		return
+	}
+
+	sm.quotas.releaseConnection(username)
+}
+
+// AllowQuery accounts for a new query of the user, or returns a
+// *QuotaExceededError if the user ran as many as allowed in the last
+// minute. Refused queries are not accounted for.
+func (sm *SecurityManager) AllowQuery(username string) error {
+	if !sm.enabled {
+		return nil
+	}
+
+	return sm.quotas.allowQuery(username)
+}
+
+// MaxRowsPerQuery returns the number of rows a query of the user may
+// return, or zero if there is no limit.
+func (sm *SecurityManager) MaxRowsPerQuery(username string) int64 {
+	return sm.Quota(username).MaxRowsPerQuery
 }
 
 // GetUser retrieves a user by username.
@@ -174,7 +372,13 @@ func (sm *SecurityManager) GetUser(ctx context.Context, username string) (*auth.
 		return nil, nil
 	}
 	
-	return sm.userStore.GetUser(ctx, username)
+	user, err := sm.userStore.GetUser(ctx, username)
+	if err == nil && user == nil {
+		if u, ok := sm.external.Load(username); ok {
+			return u.(*auth.User), nil
+		}
+	}
+	return user, err
 }
 
 // ListUsers returns all users in the system.
@@ -250,6 +454,93 @@ func (sm *SecurityManager) RevokeRole(ctx context.Context, username, roleName st
 	return sm.userStore.UpdateUser(ctx, user)
 }
 
+// CheckGrant verifies if a user may grant or revoke the privileges on a
+// table, which needs the GRANT privilege on it besides them.
+func (sm *SecurityManager) CheckGrant(ctx context.Context, username, database, table string, privileges authz.Privilege) error {
+	if !sm.enabled {
+		return nil
+	}
+
+	user, err := sm.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	return sm.CheckPrivilege(ctx, user, database, table, privileges|authz.PrivilegeGrant)
+}
+
+// GrantPrivileges grants privileges on a table to a user, or on the given
+// columns of it only.
+func (sm *SecurityManager) GrantPrivileges(ctx context.Context, username, database, table string, columns []string, privileges authz.Privilege) error {
+	if !sm.enabled {
+		return nil
+	}
+
+	grants, err := sm.objectGrants(ctx, username, database, table, columns, privileges)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range grants {
+		if err := sm.grantStore.Grant(ctx, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokePrivileges revokes privileges on a table from a user, or on the
+// given columns of it only.
+func (sm *SecurityManager) RevokePrivileges(ctx context.Context, username, database, table string, columns []string, privileges authz.Privilege) error {
+	if !sm.enabled {
+		return nil
+	}
+
+	grants, err := sm.objectGrants(ctx, username, database, table, columns, privileges)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range grants {
+		if err := sm.grantStore.Revoke(ctx, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// objectGrants returns the grants of the privileges on the table or each of
+// its columns to an existing user.
+func (sm *SecurityManager) objectGrants(ctx context.Context, username, database, table string, columns []string, privileges authz.Privilege) ([]authz.Grant, error) {
+	user, err := sm.userStore.GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	grant := authz.Grant{
+		Username:   username,
+		Database:   database,
+		Table:      table,
+		Privileges: privileges,
+	}
+	if len(columns) == 0 {
+		return []authz.Grant{grant}, nil
+	}
+
+	grants := make([]authz.Grant, len(columns))
+	for i, column := range columns {
+		grants[i] = grant
+		grants[i].Column = column
+	}
+	return grants, nil
+}
+
 // CreateRole creates a new role with the specified privileges.
 func (sm *SecurityManager) CreateRole(ctx context.Context, roleName string, privileges authz.Privilege) error {
 	if !sm.enabled {
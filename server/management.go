@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	mgmtv1 "github.com/turtacn/guocedb/api/protobuf/mgmt/v1"
+	"github.com/turtacn/guocedb/common/constants"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/storage/replication"
+)
+
+// managementService implements the gRPC ManagementService on top of the
+// server's catalog.
+type managementService struct {
+	mgmtv1.UnimplementedManagementServiceServer
+	srv *Server
+}
+
+// GetServerStatus implements the ManagementService interface.
+func (m *managementService) GetServerStatus(ctx context.Context, req *mgmtv1.GetServerStatusRequest) (*mgmtv1.GetServerStatusResponse, error) {
+	resp := &mgmtv1.GetServerStatusResponse{
+		Version:       constants.DatabaseVersion,
+		Status:        "NOT_READY",
+		UptimeSeconds: uint64(m.srv.Uptime().Seconds()),
+	}
+	if m.srv.IsReady() {
+		resp.Status = "SERVING"
+	}
+	if m.srv.mysqlServer != nil {
+		if active := m.srv.mysqlServer.Handler.Stats().ActiveConnections; active > 0 {
+			resp.ActiveConnections = uint64(active)
+		}
+	}
+	return resp, nil
+}
+
+// CreateDatabase implements the ManagementService interface.
+func (m *managementService) CreateDatabase(ctx context.Context, req *mgmtv1.CreateDatabaseRequest) (*emptypb.Empty, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "database name is required")
+	}
+	if err := m.srv.catalog.CreateDatabase(sql.NewContext(ctx), req.GetName()); err != nil {
+		return nil, managementError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// DropDatabase implements the ManagementService interface.
+func (m *managementService) DropDatabase(ctx context.Context, req *mgmtv1.DropDatabaseRequest) (*emptypb.Empty, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "database name is required")
+	}
+	if err := m.srv.catalog.DropDatabase(sql.NewContext(ctx), req.GetName()); err != nil {
+		return nil, managementError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// ListDatabases implements the ManagementService interface.
+func (m *managementService) ListDatabases(ctx context.Context, req *mgmtv1.ListDatabasesRequest) (*mgmtv1.ListDatabasesResponse, error) {
+	dbs := m.srv.catalog.AllDatabases()
+	names := make([]string, 0, len(dbs))
+	for _, db := range dbs {
+		names = append(names, db.Name())
+	}
+	sort.Strings(names)
+	return &mgmtv1.ListDatabasesResponse{Names: names}, nil
+}
+
+// CompactDatabase implements the ManagementService interface.
+func (m *managementService) CompactDatabase(ctx context.Context, req *mgmtv1.CompactDatabaseRequest) (*emptypb.Empty, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "database name is required")
+	}
+	db, err := m.srv.catalog.Database(req.GetName())
+	if err != nil {
+		return nil, managementError(err)
+	}
+	sdb, ok := db.(sql.StorageDatabase)
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "database %s can't be compacted", db.Name())
+	}
+	if err := sdb.Compact(sql.NewContext(ctx)); err != nil {
+		return nil, managementError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// FlushAll implements the ManagementService interface.
+func (m *managementService) FlushAll(ctx context.Context, req *mgmtv1.FlushAllRequest) (*emptypb.Empty, error) {
+	sctx := sql.NewContext(ctx)
+	for _, db := range m.srv.catalog.AllDatabases() {
+		if sdb, ok := db.(sql.StorageDatabase); ok {
+			if err := sdb.Flush(sctx); err != nil {
+				return nil, managementError(err)
+			}
+		}
+	}
+	if db := m.srv.badgerDB(); db != nil {
+		if err := db.Sync(); err != nil {
+			return nil, managementError(err)
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// GetStorageStats implements the ManagementService interface. The rows of
+// the tables are estimated from their statistics, collected by ANALYZE
+// TABLE, or counted by the tables that know how many rows they have, and
+// the size is only known for the databases kept in storage.
+func (m *managementService) GetStorageStats(ctx context.Context, req *mgmtv1.GetStorageStatsRequest) (*mgmtv1.GetStorageStatsResponse, error) {
+	sctx := sql.NewContext(ctx)
+	dbs := m.srv.catalog.AllDatabases()
+	sort.Slice(dbs, func(i, j int) bool { return dbs[i].Name() < dbs[j].Name() })
+
+	resp := &mgmtv1.GetStorageStatsResponse{}
+	for _, db := range dbs {
+		stats := &mgmtv1.DatabaseStorageStats{Name: db.Name()}
+		if sdb, ok := db.(sql.StorageDatabase); ok {
+			size, err := sdb.StorageSize(sctx)
+			if err != nil {
+				return nil, managementError(err)
+			}
+			stats.SizeBytes = uint64(size)
+		}
+
+		tables := db.Tables()
+		stats.TableCount = uint64(len(tables))
+		for name, t := range tables {
+			stats.EstimatedRows += m.estimateRows(sctx, db.Name(), name, t)
+		}
+		resp.Databases = append(resp.Databases, stats)
+	}
+	return resp, nil
+}
+
+// estimateRows returns the number of rows the statistics of a table expect
+// it to have, or the number it counts if it has none, or 0 if it can't
+// tell.
+func (m *managementService) estimateRows(ctx *sql.Context, db, name string, t sql.Table) uint64 {
+	if stats := m.srv.catalog.TableStatistics(db, name); stats != nil {
+		return stats.RowCount
+	}
+	if stats := sql.StatisticsOf(t); stats != nil {
+		return stats.RowCount
+	}
+	if c, ok := t.(sql.RowCounter); ok {
+		if n, err := c.RowCount(ctx); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// managementError converts a catalog error to a gRPC status error.
+func managementError(err error) error {
+	switch {
+	case sql.ErrDatabaseExists.Is(err):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case sql.ErrDatabaseNotFound.Is(err):
+		return status.Error(codes.NotFound, err.Error())
+	case sql.ErrDatabaseProtected.Is(err):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// initGRPCServer starts the gRPC management service, unless it's disabled.
+func (s *Server) initGRPCServer() error {
+	if s.cfg.Server.GRPCPort == 0 {
+		s.logger.Info("gRPC management service disabled")
+		return nil
+	}
+
+	addr := fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.GRPCPort)
+	s.logger.Info("Initializing gRPC server", "address", addr)
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.grpcServer = grpc.NewServer()
+	mgmtv1.RegisterManagementServiceServer(s.grpcServer, &managementService{srv: s})
+	if db := s.badgerDB(); db != nil {
+		replication.Register(s.grpcServer, replication.NewReplicationSource(db))
+	}
+
+	go func(grpcSrv *grpc.Server) {
+		if err := grpcSrv.Serve(lis); err != nil {
+			s.logger.Error("gRPC server stopped", "error", err)
+		}
+	}(s.grpcServer)
+
+	return nil
+}
+
+// stopGRPCServer lets the in-flight management calls finish, unless ctx is
+// done first, in which case they are cancelled.
+func (s *Server) stopGRPCServer(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		<-stopped
+	}
+}
+
+// GRPCAddr returns the address of the gRPC management service, or an empty
+// string if it's disabled.
+func (s *Server) GRPCAddr() string {
+	if s.cfg.Server.GRPCPort == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.GRPCPort)
+}
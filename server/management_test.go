@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	mgmtv1 "github.com/turtacn/guocedb/api/protobuf/mgmt/v1"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/storage/engines/badger"
+)
+
+func TestManagementService_Storage(t *testing.T) {
+	require := require.New(t)
+
+	kv, err := badgerdb.Open(badgerdb.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer kv.Close()
+
+	catalog := sql.NewCatalog()
+	sctx := sql.NewEmptyContext()
+	schema := func(table string) sql.Schema {
+		return sql.Schema{
+			{Name: "id", Type: sql.Int64, Source: table, PrimaryKey: true},
+			{Name: "name", Type: sql.Text, Source: table},
+		}
+	}
+
+	sales := badger.NewDatabase("sales", kv)
+	for _, table := range []string{"orders", "customers"} {
+		require.NoError(sales.Create(table, schema(table)))
+		tbl, ok, err := sales.GetTableInsensitive(sctx, table)
+		require.NoError(err)
+		require.True(ok)
+		for i := int64(0); i < 100; i++ {
+			require.NoError(tbl.(*badger.Table).Insert(sctx, sql.NewRow(i, fmt.Sprint("row ", i))))
+		}
+	}
+	catalog.AddDatabase(sales)
+	catalog.SetTableStatistics("sales", "orders", &sql.TableStatistics{RowCount: 100})
+
+	logs := badger.NewDatabase("logs", kv)
+	require.NoError(logs.Create("events", schema("events")))
+	catalog.AddDatabase(logs)
+
+	ctx := context.Background()
+	require.NoError(catalog.CreateDatabase(sql.NewContext(ctx), "scratch"))
+
+	m := &managementService{srv: &Server{catalog: catalog}}
+
+	resp, err := m.GetStorageStats(ctx, &mgmtv1.GetStorageStatsRequest{})
+	require.NoError(err)
+	require.Len(resp.Databases, 3)
+
+	stats := make(map[string]*mgmtv1.DatabaseStorageStats)
+	for _, db := range resp.Databases {
+		stats[db.Name] = db
+	}
+	require.Equal(uint64(2), stats["sales"].TableCount)
+	require.Equal(uint64(100), stats["sales"].EstimatedRows)
+	require.Equal(uint64(1), stats["logs"].TableCount)
+	require.Equal(uint64(0), stats["logs"].EstimatedRows)
+	require.Equal(uint64(0), stats["scratch"].TableCount)
+	require.Equal(uint64(0), stats["scratch"].SizeBytes)
+
+	// The rows take more than the schema of a table alone.
+	require.NotZero(stats["logs"].SizeBytes)
+	require.Greater(stats["sales"].SizeBytes, stats["logs"].SizeBytes)
+
+	_, err = m.FlushAll(ctx, &mgmtv1.FlushAllRequest{})
+	require.NoError(err)
+
+	_, err = m.CompactDatabase(ctx, &mgmtv1.CompactDatabaseRequest{Name: "sales"})
+	require.NoError(err)
+
+	// The data is the same once compacted.
+	resp, err = m.GetStorageStats(ctx, &mgmtv1.GetStorageStatsRequest{})
+	require.NoError(err)
+	require.Equal(stats["sales"].SizeBytes, resp.Databases[1].SizeBytes)
+
+	_, err = m.CompactDatabase(ctx, &mgmtv1.CompactDatabaseRequest{})
+	require.Equal(codes.InvalidArgument, status.Code(err))
+	_, err = m.CompactDatabase(ctx, &mgmtv1.CompactDatabaseRequest{Name: "missing"})
+	require.Equal(codes.NotFound, status.Code(err))
+	_, err = m.CompactDatabase(ctx, &mgmtv1.CompactDatabaseRequest{Name: "scratch"})
+	require.Equal(codes.FailedPrecondition, status.Code(err))
+}
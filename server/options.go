@@ -16,6 +16,14 @@ func WithLogger(logger *slog.Logger) Option {
 	}
 }
 
+// WithLogLevel sets the level of the logger set with WithLogger, which
+// the server changes when its configuration is reloaded.
+func WithLogLevel(level *slog.LevelVar) Option {
+	return func(s *Server) {
+		s.logLevel = level
+	}
+}
+
 // WithHook adds a lifecycle hook to the server.
 func WithHook(phase string, fn HookFunc) Option {
 	return func(s *Server) {
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+
+	"github.com/turtacn/guocedb/storage/engines/badger"
+	"github.com/turtacn/guocedb/storage/replication"
+)
+
+// badgerDB returns the BadgerDB of the server's storage, or nil if it uses
+// another engine.
+func (s *Server) badgerDB() *badgerdb.DB {
+	if s.storage == nil {
+		return nil
+	}
+	if b, ok := s.storage.Engine().(*badger.Storage); ok {
+		return b.DB()
+	}
+	return nil
+}
+
+// initReplication starts applying the commits of the primary when the
+// server is a read replica.
+func (s *Server) initReplication() error {
+	primary := s.cfg.Server.ReplicaOf
+	if primary == "" {
+		return nil
+	}
+
+	db := s.badgerDB()
+	if db == nil {
+		return fmt.Errorf("read replicas must use the badger storage engine")
+	}
+
+	s.logger.Info("Starting replication", "primary", primary)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.stopReplication = func() {
+		cancel()
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+		err := replication.NewReplicaApplier(db).Follow(ctx, primary, s.logger)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			s.logger.Error("Replication stopped", "error", err)
+		}
+	}()
+
+	return nil
+}
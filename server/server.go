@@ -6,10 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	commonConfig "github.com/turtacn/guocedb/common/config"
 	"github.com/turtacn/guocedb/compute/analyzer"
 	"github.com/turtacn/guocedb/compute/auth"
@@ -18,9 +22,16 @@ import (
 	mysql "github.com/turtacn/guocedb/compute/server"
 	"github.com/turtacn/guocedb/compute/sql"
 	"github.com/turtacn/guocedb/config"
+	"github.com/turtacn/guocedb/maintenance/metrics"
+	"github.com/turtacn/guocedb/maintenance/slowlog"
+	"github.com/turtacn/guocedb/network/gateway"
 	"github.com/turtacn/guocedb/observability"
 	"github.com/turtacn/guocedb/observability/health"
+	"github.com/turtacn/guocedb/security"
+	"github.com/turtacn/guocedb/security/audit"
+	"github.com/turtacn/guocedb/storage/engines/badger"
 	"github.com/turtacn/guocedb/storage/sal"
+	"google.golang.org/grpc"
 )
 
 // Server state constants.
@@ -44,11 +55,40 @@ type Server struct {
 	engine      *executor.Engine
 	mysqlServer *mysql.Server
 	obsServer   *observability.Server
+	grpcServer  *grpc.Server
+	httpGateway *gateway.Gateway
+	security    *security.SecurityManager
+
+	// connCollector reports the connections of mysqlServer on the
+	// observability endpoint.
+	connCollector prometheus.Collector
+	// statementCollector reports the latency and errors of the statements
+	// run by mysqlServer.
+	statementCollector *metrics.StatementCollector
+
+	// gc collects the value log garbage of the storage, if it's enabled,
+	// and storageCollector reports it along with the disk usage.
+	gc               *badger.GCScheduler
+	storageCollector prometheus.Collector
+	// storageOperations reports the operations of storage, if it's
+	// enabled.
+	storageOperations *metrics.StorageOperationCollector
+
+	// slowLog records the slow statements, if it's enabled.
+	slowLog *slowlog.Logger
+
+	// stopReplication stops applying the commits of the primary, if the
+	// server is a read replica.
+	stopReplication func()
 
 	// State management
 	state     atomic.Int32
 	startTime time.Time
 	logger    *slog.Logger
+	// logLevel is the level of logger, which Reload changes.
+	logLevel *slog.LevelVar
+	// reloadMu keeps the settings of concurrent reloads from mixing.
+	reloadMu sync.Mutex
 
 	// Lifecycle
 	hooks    *LifecycleHooks
@@ -67,8 +107,10 @@ func New(cfg *config.Config) (*Server, error) {
 		hooks:    NewLifecycleHooks(),
 		stopChan: make(chan struct{}),
 		doneChan: make(chan struct{}),
-		logger:   slog.New(slog.NewJSONHandler(os.Stdout, nil)),
+		logLevel: new(slog.LevelVar),
 	}
+	srv.logLevel.Set(cfg.Logging.SlogLevel())
+	srv.logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: srv.logLevel}))
 	srv.state.Store(stateNew)
 
 	return srv, nil
@@ -84,6 +126,44 @@ func (s *Server) Hooks() *LifecycleHooks {
 	return s.hooks
 }
 
+// Reload applies the settings of cfg that can be changed while the server
+// runs: the log level, the slow query threshold and the most connections.
+// The connections are kept. If cfg changes any other setting, such as the
+// port or the data directory, the reload is rejected and nothing applied.
+func (s *Server) Reload(cfg *config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if err := s.cfg.CheckReload(cfg); err != nil {
+		return err
+	}
+
+	if s.mysqlServer != nil {
+		if err := s.mysqlServer.Handler.SetMaxConnections(cfg.Server.MaxConnections); err != nil {
+			return err
+		}
+	}
+	s.logLevel.Set(cfg.Logging.SlogLevel())
+	if s.slowLog != nil {
+		s.slowLog.SetThreshold(cfg.Logging.SlowLog.Threshold)
+	}
+
+	s.cfg.Server.MaxConnections = cfg.Server.MaxConnections
+	s.cfg.Logging.Level = cfg.Logging.Level
+	s.cfg.Logging.SlowLog.Threshold = cfg.Logging.SlowLog.Threshold
+
+	s.logger.Info("Configuration reloaded",
+		"log_level", cfg.Logging.Level,
+		"slow_log_threshold", cfg.Logging.SlowLog.Threshold,
+		"max_connections", cfg.Server.MaxConnections,
+	)
+	return nil
+}
+
 // Start initializes and starts all server components.
 func (s *Server) Start() error {
 	if !s.state.CompareAndSwap(stateNew, stateStarting) {
@@ -118,6 +198,21 @@ func (s *Server) Start() error {
 		return fmt.Errorf("init mysql server: %w", err)
 	}
 
+	// Initialize gRPC management service
+	if err := s.initGRPCServer(); err != nil {
+		return fmt.Errorf("init grpc server: %w", err)
+	}
+
+	// Initialize HTTP query gateway
+	if err := s.initHTTPGateway(); err != nil {
+		return fmt.Errorf("init http gateway: %w", err)
+	}
+
+	// Follow the primary if this is a read replica
+	if err := s.initReplication(); err != nil {
+		return fmt.Errorf("init replication: %w", err)
+	}
+
 	s.state.Store(stateRunning)
 	s.hooks.RunPostStart(s)
 
@@ -148,11 +243,30 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	if s.grpcServer != nil {
+		s.logger.Info("Stopping gRPC server...")
+		s.stopGRPCServer(ctx)
+	}
+
+	if s.httpGateway != nil {
+		s.logger.Info("Stopping HTTP query gateway...")
+		if err := s.httpGateway.Close(); err != nil {
+			s.logger.Error("Error closing HTTP query gateway", "error", err)
+		}
+	}
+
 	// Wait for active connections with timeout
 	if err := s.drainConnections(ctx); err != nil {
 		s.logger.Warn("Drain connections timeout", "error", err)
 	}
 
+	if s.connCollector != nil {
+		prometheus.Unregister(s.connCollector)
+	}
+	if s.statementCollector != nil {
+		prometheus.Unregister(s.statementCollector)
+	}
+
 	// Stop observability server
 	if s.obsServer != nil {
 		s.logger.Info("Stopping observability server...")
@@ -163,6 +277,35 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	if s.slowLog != nil {
+		if err := s.slowLog.Close(); err != nil {
+			s.logger.Error("Error closing slow query log", "error", err)
+		}
+	}
+
+	if s.security != nil {
+		if err := s.security.Close(); err != nil {
+			s.logger.Error("Error closing security manager", "error", err)
+		}
+	}
+
+	if s.stopReplication != nil {
+		s.logger.Info("Stopping replication...")
+		s.stopReplication()
+	}
+
+	if s.storageCollector != nil {
+		prometheus.Unregister(s.storageCollector)
+	}
+	if s.storageOperations != nil {
+		prometheus.Unregister(s.storageOperations)
+	}
+
+	if s.gc != nil {
+		s.logger.Info("Stopping value log GC...")
+		s.gc.Stop()
+	}
+
 	// Close storage
 	if s.storage != nil {
 		s.logger.Info("Closing storage...")
@@ -199,7 +342,11 @@ func (s *Server) DSN() string {
 
 // initStorage initializes the storage layer.
 func (s *Server) initStorage() error {
-	s.logger.Info("Initializing storage", "data_dir", s.cfg.Storage.DataDir)
+	engine := s.cfg.Storage.Engine
+	if engine == "" {
+		engine = "badger"
+	}
+	s.logger.Info("Initializing storage", "engine", engine, "data_dir", s.cfg.Storage.DataDir)
 
 	// Set default ValueLogFileSize if not configured (1GB, within BadgerDB's 1MB-2GB range)
 	valueLogFileSize := 1 << 30 // 1GB
@@ -207,11 +354,12 @@ func (s *Server) initStorage() error {
 	// Convert new config to old common/config format for compatibility
 	legacyCfg := &commonConfig.Config{
 		Storage: commonConfig.StorageConfig{
-			Engine:  "badger",
+			Engine:  engine,
 			DataDir: s.cfg.Storage.DataDir,
 			Badger: commonConfig.BadgerConfig{
-				ValueLogFileSize: valueLogFileSize,
-				SyncWrites:       s.cfg.Storage.SyncWrites,
+				ValueLogFileSize:  valueLogFileSize,
+				SyncWrites:        s.cfg.Storage.SyncWrites,
+				RecoverCorruption: s.cfg.Storage.RecoverCorruption,
 			},
 		},
 	}
@@ -222,13 +370,65 @@ func (s *Server) initStorage() error {
 	}
 
 	s.storage = storage
+	if s.cfg.Storage.OperationMetrics {
+		operations := metrics.NewStorageOperationCollector()
+		if err := prometheus.Register(operations); err != nil {
+			s.logger.Warn("Unable to register storage operation metrics", "error", err)
+		} else {
+			s.storageOperations = operations
+			storage.Instrument(operations)
+		}
+	}
+	s.initValueLogGC()
 	return nil
 }
 
+// initValueLogGC starts collecting the value log garbage of the storage
+// periodically, if it's enabled and the storage is BadgerDB.
+func (s *Server) initValueLogGC() {
+	st, ok := s.storage.Engine().(*badger.Storage)
+	if !ok || !s.cfg.Storage.ValueLogGC {
+		return
+	}
+
+	name := s.cfg.Storage.DataDir
+	s.logger.Info("Starting value log GC", "database", name,
+		"interval", s.cfg.Storage.ValueLogGCInterval,
+		"discard_ratio", s.cfg.Storage.ValueLogGCDiscardRatio)
+
+	s.gc = badger.NewGCScheduler(name, st.DB(), badger.GCConfig{
+		Interval:     s.cfg.Storage.ValueLogGCInterval,
+		DiscardRatio: s.cfg.Storage.ValueLogGCDiscardRatio,
+		Logger:       s.logger,
+	})
+	s.gc.Start()
+
+	s.storageCollector = metrics.NewStorageCollector(func() map[string]metrics.DatabaseStorageStats {
+		stats := s.gc.Stats()
+		return map[string]metrics.DatabaseStorageStats{
+			name: {
+				LSMBytes:             stats.LSMSize,
+				ValueLogBytes:        stats.ValueLogSize,
+				GCRuns:               stats.Runs,
+				LastGC:               stats.LastRun,
+				LastGCReclaimedBytes: stats.LastReclaimed,
+				GCReclaimedBytes:     stats.TotalReclaimed,
+			},
+		}
+	})
+	if err := prometheus.Register(s.storageCollector); err != nil {
+		s.logger.Warn("Unable to register storage metrics", "error", err)
+		s.storageCollector = nil
+	}
+}
+
 // initCatalog initializes the catalog service.
 func (s *Server) initCatalog() error {
 	s.logger.Info("Initializing catalog")
 	s.catalog = sql.NewCatalog()
+	s.catalog.AddDatabase(sql.NewInformationSchemaDatabase(s.catalog))
+	sal.RegisterEngines(s.catalog.EngineRegistry)
+	s.catalog.ProtectDatabases(s.cfg.Security.ProtectDatabases...)
 	return nil
 }
 
@@ -236,6 +436,7 @@ func (s *Server) initCatalog() error {
 func (s *Server) initEngine() error {
 	s.logger.Info("Initializing SQL engine")
 	s.analyzer = analyzer.NewAnalyzer(s.catalog)
+	s.analyzer.Parallelism = s.cfg.Server.ScanParallelism
 	s.optimizer = optimizer.NewOptimizer()
 	s.engine = executor.NewEngine(s.analyzer, s.optimizer, s.catalog)
 	return nil
@@ -250,8 +451,13 @@ func (s *Server) initObservability() error {
 
 	s.logger.Info("Initializing observability", "address", s.cfg.Observability.Address)
 
-	// Create health checker
+	// Create health checker. The server is ready once storage can be
+	// written and read and the MySQL listener accepts connections.
 	checker := health.NewChecker()
+	if s.storage != nil {
+		checker.AddCheck(health.ComponentStorage, health.SALStorageCheck(s.storage))
+	}
+	checker.AddCheck(health.ComponentListener, health.ListenerCheck(s.listenerAddress()))
 
 	// Start observability server (health + metrics)
 	obsCfg := observability.ServerConfig{
@@ -270,19 +476,89 @@ func (s *Server) initObservability() error {
 	return nil
 }
 
+// listenerAddress returns the address the MySQL listener can be reached at
+// from this host.
+func (s *Server) listenerAddress() string {
+	host := s.cfg.Server.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(s.cfg.Server.Port))
+}
+
 // initMySQLServer initializes the MySQL protocol server.
 func (s *Server) initMySQLServer() error {
 	addr := fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.Port)
 	s.logger.Info("Initializing MySQL server", "address", addr)
 
-	// Use native authentication with root user (no password by default)
-	// This is compatible with MySQL clients and test tools
-	auth := auth.NewNativeSingle("root", "", auth.AllPermissions)
-
 	serverCfg := mysql.Config{
-		Protocol: "tcp",
-		Address:  addr,
-		Auth:     auth,
+		Protocol:         "tcp",
+		Address:          addr,
+		ConnReadTimeout:  s.cfg.Server.ReadTimeout,
+		ConnWriteTimeout: s.cfg.Server.WriteTimeout,
+		IdleTimeout:      s.cfg.Server.IdleTimeout,
+		MaxExecutionTime: s.cfg.Server.MaxExecutionTime,
+		LockWaitTimeout:  s.cfg.Server.LockWaitTimeout,
+		ResultBatchSize:  s.cfg.Server.ResultBatchSize,
+		MaxResultRows:    s.cfg.Server.MaxResultRows,
+		TruncateResults:  s.cfg.Server.TruncateResults,
+		MaxConnections:   s.cfg.Server.MaxConnections,
+
+		StatementCacheSize: s.cfg.Server.StatementCacheSize,
+		MaxAllowedPacket:   s.cfg.Server.MaxAllowedPacket,
+
+		ConnectRate:         s.cfg.Server.ConnectRate,
+		ConnectBurst:        s.cfg.Server.ConnectBurst,
+		ConnectQueueTimeout: s.cfg.Server.ConnectQueueTimeout,
+	}
+	if qc := s.cfg.Server.QueryCache; qc.Enabled {
+		serverCfg.QueryCacheSize = qc.Capacity
+	}
+
+	statements := metrics.NewStatementCollector()
+	if err := prometheus.Register(statements); err != nil {
+		s.logger.Warn("Unable to register statement metrics", "error", err)
+	} else {
+		s.statementCollector = statements
+		serverCfg.StatementMetrics = statements
+	}
+
+	if sl := s.cfg.Logging.SlowLog; sl.Enabled {
+		slowLog, err := slowlog.New(slowlog.Config{
+			FilePath:   sl.FilePath,
+			Threshold:  sl.Threshold,
+			MaxSize:    s.cfg.Logging.MaxSize,
+			MaxBackups: s.cfg.Logging.MaxBackups,
+			MaxAge:     s.cfg.Logging.MaxAge,
+		})
+		if err != nil {
+			return err
+		}
+		s.slowLog = slowLog
+		serverCfg.SlowLog = slowLog
+	}
+
+	if s.cfg.Security.Enabled {
+		if err := s.initSecurity(); err != nil {
+			return fmt.Errorf("init security: %w", err)
+		}
+		serverCfg.Auth = auth.NewSecurity(s.security)
+		serverCfg.Quotas = s.security
+		serverCfg.Grants = s.security
+	} else {
+		// Without security, root logs in without a password, which is
+		// compatible with MySQL clients and test tools.
+		serverCfg.Auth = auth.NewNativeSingle("root", "", auth.AllPermissions)
+	}
+
+	if sec := s.cfg.Security; sec.TLSCertFile != "" {
+		tlsConfig, err := mysql.LoadTLSConfig(sec.TLSCertFile, sec.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		serverCfg.TLSConfig = tlsConfig
+		serverCfg.RequireSecureTransport = sec.RequireSecureTransport
+		s.logger.Info("TLS enabled for MySQL connections", "require_secure_transport", sec.RequireSecureTransport)
 	}
 
 	mysqlSrv, err := mysql.NewDefaultServer(serverCfg, s.engine)
@@ -292,6 +568,18 @@ func (s *Server) initMySQLServer() error {
 
 	s.mysqlServer = mysqlSrv
 
+	s.connCollector = metrics.NewConnectionCollector(func() metrics.ConnectionStats {
+		stats := mysqlSrv.Handler.Stats()
+		return metrics.ConnectionStats{
+			ActiveConnections: stats.ActiveConnections,
+			Queries:           stats.Queries,
+		}
+	})
+	if err := prometheus.Register(s.connCollector); err != nil {
+		s.logger.Warn("Unable to register connection metrics", "error", err)
+		s.connCollector = nil
+	}
+
 	// Start MySQL server in goroutine
 	go func() {
 		s.mysqlServer.Start()
@@ -303,29 +591,81 @@ func (s *Server) initMySQLServer() error {
 	return nil
 }
 
-// drainConnections waits for active connections to complete.
-func (s *Server) drainConnections(ctx context.Context) error {
-	// Simple implementation - wait for a grace period
-	// In a full implementation, this would query the MySQL server for active connection count
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-	deadline := time.Now().Add(s.cfg.Server.ShutdownTimeout)
-
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ticker.C:
-			// Check if we've exceeded the shutdown timeout
-			if time.Now().After(deadline) {
-				return fmt.Errorf("shutdown timeout exceeded")
-			}
-			// TODO: Query actual connection count from MySQL server
-			// For now, wait a minimal period
-			return nil
+// initHTTPGateway starts the HTTP query gateway, unless it's disabled.
+func (s *Server) initHTTPGateway() error {
+	if s.cfg.Server.HTTPPort == 0 {
+		s.logger.Info("HTTP query gateway disabled")
+		return nil
+	}
+
+	addr := fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.HTTPPort)
+	s.logger.Info("Initializing HTTP query gateway", "address", addr)
+
+	gw, err := gateway.NewGateway(addr, s.engine)
+	if err != nil {
+		return err
+	}
+
+	s.httpGateway = gw
+	s.httpGateway.Start()
+	return nil
+}
+
+// initSecurity initializes the security manager that authenticates the
+// users of the MySQL server, setting the password of root.
+func (s *Server) initSecurity() error {
+	sec := s.cfg.Security
+
+	// The audit log can't be turned off in the security manager, so its
+	// events are discarded unless it's enabled.
+	auditCfg := audit.AuditConfig{FilePath: os.DevNull}
+	if sec.AuditLog.Enabled {
+		auditCfg = audit.AuditConfig{
+			FilePath: sec.AuditLog.FilePath,
+			Async:    sec.AuditLog.Async,
+			Format:   audit.Format(sec.AuditLog.Format),
+			Users:    sec.AuditLog.Users,
+		}
+		for _, typ := range sec.AuditLog.EventTypes {
+			auditCfg.EventTypes = append(auditCfg.EventTypes, audit.EventType(typ))
 		}
 	}
+
+	sm, err := security.NewSecurityManager(security.SecurityConfig{
+		Enabled:      true,
+		AuditConfig:  auditCfg,
+		MaxAuthFails: sec.MaxAuthAttempts,
+		LockDuration: sec.LockDuration,
+
+		AuthProvider:        sec.AuthProvider,
+		AuthProviderOptions: sec.AuthProviderOptions,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := sm.SetPassword(context.Background(), "root", sec.RootPassword); err != nil {
+		sm.Close()
+		return err
+	}
+
+	s.security = sm
+	return nil
 }
 
+// drainConnections waits for the queries being executed to finish, up to
+// the shutdown timeout. The listener is closed before, so no connections
+// arrive meanwhile, and the queries received on the open ones are refused.
+func (s *Server) drainConnections(ctx context.Context) error {
+	if s.mysqlServer == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Server.ShutdownTimeout)
+	defer cancel()
 
+	if err := s.mysqlServer.Handler.Drain(ctx); err != nil {
+		return fmt.Errorf("shutdown timeout exceeded: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package backup writes a point-in-time copy of the BadgerDB store of a
+// data directory to a stream, and rebuilds a data directory from one.
+//
+// The store holds every database along with the catalog metadata, the
+// schemas of the tables and their AUTO_INCREMENT counters, so a backup has
+// all that's needed to start a server on the restored directory. A backup
+// is a header, which identifies the file and the version of its format,
+// followed by the output of BadgerDB's stream backup, which reads the store
+// at a single timestamp and writes it as it goes, so neither side has to
+// hold the whole store in memory.
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// Magic is the first line of every backup.
+const Magic = "GUOCEDB-BACKUP"
+
+// FormatVersion is the version of the format of the backups written by this
+// package. Backups with a newer version can't be restored.
+const FormatVersion = 1
+
+// maxPendingWrites is the number of writes a restore keeps in flight.
+const maxPendingWrites = 256
+
+// Header describes a backup. It's written as a line of JSON after Magic.
+type Header struct {
+	Version int       `json:"version"`
+	Engine  string    `json:"engine"`
+	Created time.Time `json:"created"`
+}
+
+// Backup writes a backup of db to w.
+func Backup(db *badger.DB, w io.Writer) error {
+	header, err := json.Marshal(Header{
+		Version: FormatVersion,
+		Engine:  "badger",
+		Created: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s\n%s\n", Magic, header); err != nil {
+		return fmt.Errorf("backup: write header: %w", err)
+	}
+
+	if _, err := db.Backup(w, 0); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	return nil
+}
+
+// BackupDir writes a backup of the store in the data directory dir to w.
+// The store must not be open, as BadgerDB locks its directory.
+func BackupDir(dir string, w io.Writer) error {
+	if _, err := os.Stat(filepath.Join(dir, badger.ManifestFilename)); err != nil {
+		return fmt.Errorf("backup: no database in %s: %w", dir, err)
+	}
+
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		return fmt.Errorf("backup: open %s: %w", dir, err)
+	}
+	defer db.Close()
+
+	return Backup(db, w)
+}
+
+// ReadHeader reads the header of the backup from r, and checks it can be
+// restored.
+func ReadHeader(r *bufio.Reader) (*Header, error) {
+	magic, err := r.ReadString('\n')
+	if err != nil || strings.TrimSuffix(magic, "\n") != Magic {
+		return nil, fmt.Errorf("restore: not a guocedb backup")
+	}
+
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("restore: read header: %w", err)
+	}
+
+	var h Header
+	if err := json.Unmarshal(line, &h); err != nil {
+		return nil, fmt.Errorf("restore: invalid header: %w", err)
+	}
+	if h.Version > FormatVersion {
+		return nil, fmt.Errorf("restore: backup format version %d is newer than the supported version %d", h.Version, FormatVersion)
+	}
+	if h.Engine != "badger" {
+		return nil, fmt.Errorf("restore: unsupported storage engine %q", h.Engine)
+	}
+	return &h, nil
+}
+
+// Restore rebuilds the store in the data directory dir from the backup
+// read from r. dir must not exist or be empty.
+func Restore(r io.Reader, dir string) (*Header, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("restore: %w", err)
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("restore: data directory %s is not empty", dir)
+	}
+
+	br := bufio.NewReader(r)
+	h, err := ReadHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("restore: create data directory: %w", err)
+	}
+
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		return nil, fmt.Errorf("restore: open %s: %w", dir, err)
+	}
+
+	if err := db.Load(br, maxPendingWrites); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("restore: %w", err)
+	}
+	if err := db.Close(); err != nil {
+		return nil, fmt.Errorf("restore: %w", err)
+	}
+	return h, nil
+}
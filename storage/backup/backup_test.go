@@ -0,0 +1,105 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/guocedb/compute/analyzer"
+	"github.com/turtacn/guocedb/compute/executor"
+	"github.com/turtacn/guocedb/compute/optimizer"
+	"github.com/turtacn/guocedb/compute/sql"
+	badgerengine "github.com/turtacn/guocedb/storage/engines/badger"
+)
+
+// openEngine opens the store in dir with the databases db1 and db2, and
+// returns a function to run queries on one of them.
+func openEngine(t *testing.T, dir string) (*badger.DB, func(db, q string) []sql.Row) {
+	t.Helper()
+	kv, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+
+	c := sql.NewCatalog()
+	c.AddDatabase(badgerengine.NewDatabase("db1", kv))
+	c.AddDatabase(badgerengine.NewDatabase("db2", kv))
+
+	e := executor.NewEngine(analyzer.NewAnalyzer(c), optimizer.NewOptimizer(), c)
+	ctx := sql.NewContext(context.Background())
+
+	return kv, func(db, q string) []sql.Row {
+		c.SetCurrentDatabase(db)
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(t, err, q)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(t, err, q)
+		return rows
+	}
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "data")
+	kv, query := openEngine(t, src)
+
+	query("db1", "CREATE TABLE users (id BIGINT PRIMARY KEY, name TEXT)")
+	query("db1", "CREATE TABLE orders (id BIGINT PRIMARY KEY AUTO_INCREMENT, user_id BIGINT, amount DOUBLE)")
+	query("db2", "CREATE TABLE items (sku TEXT, qty BIGINT)")
+	query("db1", "INSERT INTO users VALUES (1, 'alice'), (2, 'bob'), (3, NULL)")
+	query("db1", "INSERT INTO orders (user_id, amount) VALUES (1, 9.5), (2, 20), (1, 3.25)")
+	query("db2", "INSERT INTO items VALUES ('a-1', 10), ('b-2', 0)")
+	query("db1", "DELETE FROM users WHERE id = 2")
+
+	queries := [][2]string{
+		{"db1", "SELECT * FROM users ORDER BY id"},
+		{"db1", "SELECT * FROM orders ORDER BY id"},
+		{"db2", "SELECT * FROM items ORDER BY sku"},
+	}
+	var expected [][]sql.Row
+	for _, q := range queries {
+		expected = append(expected, query(q[0], q[1]))
+	}
+	require.NoError(t, kv.Close())
+
+	var buf bytes.Buffer
+	require.NoError(t, BackupDir(src, &buf))
+	require.True(t, strings.HasPrefix(buf.String(), Magic+"\n"))
+
+	dst := filepath.Join(t.TempDir(), "restored")
+	h, err := Restore(&buf, dst)
+	require.NoError(t, err)
+	require.Equal(t, FormatVersion, h.Version)
+
+	kv, query = openEngine(t, dst)
+	defer kv.Close()
+
+	for i, q := range queries {
+		require.Equal(t, expected[i], query(q[0], q[1]), q[1])
+	}
+
+	// The AUTO_INCREMENT counter is restored too.
+	query("db1", "INSERT INTO orders (user_id, amount) VALUES (3, 1)")
+	require.Equal(t, []sql.Row{{int64(4)}}, query("db1", "SELECT MAX(id) FROM orders"))
+}
+
+func TestRestoreRejects(t *testing.T) {
+	_, err := Restore(strings.NewReader("not a backup\n"), t.TempDir())
+	require.ErrorContains(t, err, "not a guocedb backup")
+
+	_, err = Restore(strings.NewReader(Magic+"\n{\"version\":99,\"engine\":\"badger\"}\n"), t.TempDir())
+	require.ErrorContains(t, err, "newer than the supported version")
+
+	dir := t.TempDir()
+	kv, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+	require.NoError(t, kv.Close())
+	_, err = Restore(strings.NewReader(Magic+"\n{\"version\":1,\"engine\":\"badger\"}\n"), dir)
+	require.ErrorContains(t, err, "is not empty")
+}
+
+func TestBackupDirWithoutDatabase(t *testing.T) {
+	require.ErrorContains(t, BackupDir(t.TempDir(), &bytes.Buffer{}), "no database")
+}
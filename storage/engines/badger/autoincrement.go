@@ -0,0 +1,187 @@
+package badger
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// autoIncrement is the counter of the AUTO_INCREMENT column of a table. It's
+// shared by the versions of a table ALTER TABLE creates, so inserters that
+// started before and after a change don't hand out the same values.
+type autoIncrement struct {
+	mu sync.Mutex
+	// next is the value the next row gets, or 0 if it hasn't been loaded
+	// yet.
+	next uint64
+}
+
+// autoIncrementColumn returns the index of the AUTO_INCREMENT column of the
+// schema, or -1 if it has none.
+func autoIncrementColumn(schema sql.Schema) int {
+	for i, col := range schema {
+		if col.AutoIncrement {
+			return i
+		}
+	}
+	return -1
+}
+
+// assignAutoIncrement returns the row with the next value of the counter
+// in its AUTO_INCREMENT column if it's NULL or 0. A greater value given
+// explicitly moves the counter past it. The counter is saved in its own
+// transaction, so values are never handed out twice, even if the rows they
+// were given to are discarded.
+func (t *Table) assignAutoIncrement(ctx *sql.Context, row sql.Row) (sql.Row, error) {
+	idx := autoIncrementColumn(t.schema)
+	if idx < 0 || idx >= len(row) {
+		return row, nil
+	}
+
+	t.autoInc.mu.Lock()
+	defer t.autoInc.mu.Unlock()
+
+	if err := t.loadAutoIncrement(); err != nil {
+		return nil, err
+	}
+
+	col := t.schema[idx]
+	if v := row[idx]; v != nil {
+		n, err := sql.Int64.Convert(v)
+		if err != nil {
+			return nil, err
+		}
+
+		if n := n.(int64); n != 0 {
+			if n > 0 && uint64(n) >= t.autoInc.next {
+				return row, t.saveAutoIncrement(uint64(n) + 1)
+			}
+			return row, nil
+		}
+	}
+
+	id := t.autoInc.next
+	v, err := col.Type.Convert(id)
+	if err != nil {
+		return nil, fmt.Errorf("AUTO_INCREMENT value %d out of range for column %s: %v", id, col.Name, err)
+	}
+	if err := t.saveAutoIncrement(id + 1); err != nil {
+		return nil, err
+	}
+
+	row = row.Copy()
+	row[idx] = v
+	ctx.SetInsertID(id)
+	return row, nil
+}
+
+// loadAutoIncrement reads the counter the first time it's needed. Tables
+// that haven't stored it yet start at their AUTO_INCREMENT option, or 1. It
+// must be called with t.autoInc.mu held.
+func (t *Table) loadAutoIncrement() error {
+	if t.autoInc.next != 0 {
+		return nil
+	}
+
+	next := uint64(1)
+	if opt, ok := t.options[sql.TableOptionAutoIncrement]; ok {
+		n, err := strconv.ParseUint(opt, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid AUTO_INCREMENT option %q of table %s", opt, t.name)
+		}
+		if n > 0 {
+			next = n
+		}
+	}
+
+	err := t.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(EncodeAutoIncrementKey(t.dbName, t.name))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			next = BytesToUint64(val)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	t.autoInc.next = next
+	return nil
+}
+
+// saveAutoIncrement stores next as the value the next row gets. It must be
+// called with t.autoInc.mu held.
+func (t *Table) saveAutoIncrement(next uint64) error {
+	err := t.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(EncodeAutoIncrementKey(t.dbName, t.name), Uint64ToBytes(next))
+	})
+	if err != nil {
+		return err
+	}
+
+	t.autoInc.next = next
+	return nil
+}
+
+// SetAutoIncrement implements sql.AutoIncrementAlterable. The counter is
+// never set below the greatest value the table already has, so the next
+// rows don't collide with the existing ones.
+func (d *Database) SetAutoIncrement(ctx *sql.Context, tableName string, next uint64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.badgerTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	idx := autoIncrementColumn(t.schema)
+	if idx < 0 {
+		return fmt.Errorf("table %s has no AUTO_INCREMENT column", t.name)
+	}
+
+	t.autoInc.mu.Lock()
+	defer t.autoInc.mu.Unlock()
+
+	err = d.db.View(func(txn *badger.Txn) error {
+		prefix := EncodeTablePrefix(d.name, t.storageName())
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			row, err := t.getRow(txn, it.Item().Key())
+			if err != nil {
+				return err
+			}
+			if idx >= len(row) || row[idx] == nil {
+				continue
+			}
+
+			n, err := sql.Int64.Convert(row[idx])
+			if err != nil {
+				return err
+			}
+			if n := n.(int64); n > 0 && uint64(n) >= next {
+				next = uint64(n) + 1
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if next == 0 {
+		next = 1
+	}
+	return t.saveAutoIncrement(next)
+}
@@ -3,25 +3,36 @@ package badger
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 
 	"github.com/dgraph-io/badger/v3"
-	"github.com/turtacn/guocedb/compute/sql"
 	"github.com/turtacn/guocedb/common/config"
+	cerrors "github.com/turtacn/guocedb/common/errors"
+	"github.com/turtacn/guocedb/common/types/enum"
+	"github.com/turtacn/guocedb/compute/sql"
 	"github.com/turtacn/guocedb/interfaces"
 )
 
+// Engine describes the BadgerDB storage engine for SHOW ENGINES.
+var Engine = sql.StorageEngine{
+	Name:         "badger",
+	Comment:      "Persistent key-value storage based on BadgerDB, supports transactions",
+	Transactions: true,
+	XA:           true,
+}
+
 // Storage is the BadgerDB implementation of the interfaces.Storage interface.
 type Storage struct {
-	db *badger.DB
+	db       *badger.DB
+	recovery *RecoveryReport
 }
 
-// NewStorage creates a new instance of the BadgerDB storage engine.
-func NewStorage(cfg config.BadgerConfig) (*Storage, error) {
-	// A data directory must be specified in the main config.
-	// For now, let's assume a path is passed or use a default.
-	// This should be improved to take the dataDir from the top-level storage config.
-	dataDir := config.Get().Storage.DataDir
+// NewStorage creates a new instance of the BadgerDB storage engine in the
+// given data directory. A corrupted value log doesn't keep the storage from
+// opening: the tables whose rows were lost in it fail with a DataCorruption
+// error instead, until the server is restarted.
+func NewStorage(dataDir string, cfg config.BadgerConfig) (*Storage, error) {
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return nil, err
 	}
@@ -29,19 +40,42 @@ func NewStorage(cfg config.BadgerConfig) (*Storage, error) {
 	opts := badger.DefaultOptions(dataDir)
 	opts.ValueLogFileSize = int64(cfg.ValueLogFileSize)
 	opts.SyncWrites = cfg.SyncWrites
-	// Disable Badger's own logger to use our structured logger
-	opts.Logger = nil
 
-	db, err := badger.Open(opts)
+	db, report, err := OpenWithRecovery(opts, RecoveryConfig{Recover: cfg.RecoverCorruption})
 	if err != nil {
 		return nil, err
 	}
 
-	return &Storage{db: db}, nil
+	return &Storage{db: db, recovery: report}, nil
+}
+
+// Recovery returns what was found corrupted when the storage was opened.
+func (s *Storage) Recovery() *RecoveryReport {
+	return s.recovery
+}
+
+// checkCorrupted returns a DataCorruption error if rows of the table were
+// lost in a corrupted value log.
+func (s *Storage) checkCorrupted(db, table string) error {
+	if s.recovery == nil {
+		return nil
+	}
+	t, ok := s.recovery.Tables[string(EncodeTablePrefix(db, table))]
+	if !ok {
+		return nil
+	}
+	return cerrors.WithCode(
+		fmt.Errorf("table %s.%s is corrupted: %d rows were lost in its value log", db, table, t.LostRows),
+		enum.DataCorruption,
+	)
 }
 
 // Get retrieves a value for a given key from a specific table.
 func (s *Storage) Get(ctx *sql.Context, db, table string, key []byte) ([]byte, error) {
+	if err := s.checkCorrupted(db, table); err != nil {
+		return nil, err
+	}
+
 	var value []byte
 	err := s.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get(EncodeRowKey(db, table, key))
@@ -61,6 +95,9 @@ func (s *Storage) Get(ctx *sql.Context, db, table string, key []byte) ([]byte, e
 
 // Set stores a key-value pair in a specific table.
 func (s *Storage) Set(ctx *sql.Context, db, table string, key, value []byte) error {
+	if err := s.checkCorrupted(db, table); err != nil {
+		return err
+	}
 	return s.db.Update(func(txn *badger.Txn) error {
 		return txn.Set(EncodeRowKey(db, table, key), value)
 	})
@@ -68,6 +105,9 @@ func (s *Storage) Set(ctx *sql.Context, db, table string, key, value []byte) err
 
 // Delete removes a key from a specific table.
 func (s *Storage) Delete(ctx *sql.Context, db, table string, key []byte) error {
+	if err := s.checkCorrupted(db, table); err != nil {
+		return err
+	}
 	return s.db.Update(func(txn *badger.Txn) error {
 		return txn.Delete(EncodeRowKey(db, table, key))
 	})
@@ -75,6 +115,9 @@ func (s *Storage) Delete(ctx *sql.Context, db, table string, key []byte) error {
 
 // Iterator returns an iterator for a given key prefix in a table.
 func (s *Storage) Iterator(ctx *sql.Context, db, table string, prefix []byte) (interfaces.Iterator, error) {
+	if err := s.checkCorrupted(db, table); err != nil {
+		return nil, err
+	}
 	txn := s.db.NewTransaction(false) // Read-only iterator
 	// The caller is responsible for closing the transaction via the iterator's context.
 	// This is a simplification. A better design would manage the txn lifecycle more carefully.
@@ -125,7 +168,10 @@ func (s *Storage) CreateTable(ctx *sql.Context, dbName string, table sql.Table)
 			return err
 		}
 		// Serialize and store the table schema
-		schemaBytes, err := json.Marshal(table.Schema())
+		schemaBytes, err := json.Marshal(tableMeta{
+			Version: tableMetaVersion,
+			Columns: serializeSchema(table.Schema()),
+		})
 		if err != nil {
 			return err
 		}
@@ -151,6 +197,11 @@ func (s *Storage) ListTables(ctx *sql.Context, dbName string) ([]string, error)
 	return nil, nil // Placeholder
 }
 
+// DB returns the underlying BadgerDB instance.
+func (s *Storage) DB() *badger.DB {
+	return s.db
+}
+
 // Close shuts down the storage engine gracefully.
 func (s *Storage) Close() error {
 	return s.db.Close()
@@ -0,0 +1,142 @@
+package badger
+
+import (
+	"github.com/dgraph-io/badger/v3"
+)
+
+// statementTxn is the transaction an editor writes the changes of a
+// statement to when there's no external transaction, so they are committed
+// together. Badger limits how big a transaction can be, so once it's full
+// the changes made so far are committed and the statement goes on in a new
+// one. To keep the statement atomic, the values the committed changes
+// replaced are read from a snapshot taken when the statement began, and
+// written back if it's discarded.
+type statementTxn struct {
+	db  *badger.DB
+	txn *badger.Txn
+	// snapshot is a read-only transaction as of the start of the
+	// statement.
+	snapshot *badger.Txn
+	// written are the keys changed in txn.
+	written [][]byte
+	// undo are the values the keys changed in committed transactions had
+	// before the statement, and undone the keys already in it.
+	undo   []undoEntry
+	undone map[string]struct{}
+}
+
+// undoEntry is the value a key had before a statement changed it.
+type undoEntry struct {
+	key    []byte
+	value  []byte
+	exists bool
+}
+
+func newStatementTxn(db *badger.DB) *statementTxn {
+	return &statementTxn{
+		db:       db,
+		txn:      db.NewTransaction(true),
+		snapshot: db.NewTransaction(false),
+	}
+}
+
+// Get returns the item at key, as seen by the statement.
+func (t *statementTxn) Get(key []byte) (*badger.Item, error) {
+	return t.txn.Get(key)
+}
+
+// Set sets the value of key.
+func (t *statementTxn) Set(key, value []byte) error {
+	return t.modify(key, func() error { return t.txn.Set(key, value) })
+}
+
+// Delete deletes key.
+func (t *statementTxn) Delete(key []byte) error {
+	return t.modify(key, func() error { return t.txn.Delete(key) })
+}
+
+func (t *statementTxn) modify(key []byte, f func() error) error {
+	err := f()
+	if err == badger.ErrTxnTooBig {
+		if err = t.flush(); err != nil {
+			return err
+		}
+		err = f()
+	}
+	if err != nil {
+		return err
+	}
+
+	t.written = append(t.written, key)
+	return nil
+}
+
+// flush commits the changes made so far and starts a new transaction,
+// remembering what the committed changes replaced.
+func (t *statementTxn) flush() error {
+	if t.undone == nil {
+		t.undone = make(map[string]struct{})
+	}
+
+	for _, key := range t.written {
+		if _, ok := t.undone[string(key)]; ok {
+			continue
+		}
+
+		e := undoEntry{key: key}
+		item, err := t.snapshot.Get(key)
+		switch err {
+		case nil:
+			if e.value, err = item.ValueCopy(nil); err != nil {
+				return err
+			}
+			e.exists = true
+		case badger.ErrKeyNotFound:
+		default:
+			return err
+		}
+
+		t.undone[string(key)] = struct{}{}
+		t.undo = append(t.undo, e)
+	}
+
+	if err := t.txn.Commit(); err != nil {
+		return err
+	}
+	t.txn = t.db.NewTransaction(true)
+	t.written = nil
+	return nil
+}
+
+// Commit commits the changes of the statement.
+func (t *statementTxn) Commit() error {
+	defer t.snapshot.Discard()
+	return t.txn.Commit()
+}
+
+// Discard discards the changes of the statement, undoing those that were
+// already committed. Changes other transactions made to the same keys since
+// the statement began are lost.
+func (t *statementTxn) Discard() error {
+	t.txn.Discard()
+	t.snapshot.Discard()
+	if len(t.undo) == 0 {
+		return nil
+	}
+
+	wb := t.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, e := range t.undo {
+		var err error
+		if e.exists {
+			err = wb.Set(e.key, e.value)
+		} else {
+			err = wb.Delete(e.key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	t.undo = nil
+	return wb.Flush()
+}
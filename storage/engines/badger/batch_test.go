@@ -0,0 +1,129 @@
+package badger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+	cerrors "github.com/turtacn/guocedb/common/errors"
+	"github.com/turtacn/guocedb/common/types/enum"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+var batchSchema = sql.Schema{
+	{Name: "id", Type: sql.Int64, Source: "t", PrimaryKey: true},
+	{Name: "name", Type: sql.Text, Source: "t"},
+}
+
+// insertValues inserts the rows with the given ids in a single INSERT
+// statement.
+func insertValues(ctx *sql.Context, table sql.Table, name string, ids []int64) (int, error) {
+	tuples := make([][]sql.Expression, len(ids))
+	for i, id := range ids {
+		tuples[i] = []sql.Expression{
+			expression.NewLiteral(id, sql.Int64),
+			expression.NewLiteral(name, sql.Text),
+		}
+	}
+	insert := plan.NewInsertInto(plan.NewResolvedTable(table), plan.NewValues(tuples), nil)
+	return insert.Execute(ctx)
+}
+
+func idRange(from, to int64) []int64 {
+	ids := make([]int64, 0, to-from)
+	for id := from; id < to; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestInsertInto_Statement(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	table := NewTable("t", "testdb", batchSchema, db)
+	ctx := sql.NewEmptyContext()
+
+	n, err := insertValues(ctx, table, "a", idRange(0, 10000))
+	require.NoError(t, err)
+	require.Equal(t, 10000, n)
+	require.Len(t, tableRows(t, ctx, table), 10000)
+
+	// A duplicate key in the middle of the statement discards all of its
+	// rows, including those inserted before it.
+	ids := idRange(10000, 20000)
+	ids[5000] = 42
+	_, err = insertValues(ctx, table, "b", ids)
+	require.True(t, sql.IsKind(sql.ErrDuplicateKey, err), err)
+	require.Equal(t, enum.DuplicateKey, cerrors.CodeOf(err))
+
+	rows := tableRows(t, ctx, table)
+	require.Len(t, rows, 10000)
+	for _, row := range rows {
+		require.Equal(t, "a", row[1])
+	}
+}
+
+func TestInsertInto_StatementTooBigForTransaction(t *testing.T) {
+	// A small memtable makes badger's transactions small, so the statements
+	// span several of them.
+	opts := badger.DefaultOptions(t.TempDir()).WithLogger(nil).WithMemTableSize(1 << 20).WithValueThreshold(1 << 10)
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	table := NewTable("t", "testdb", batchSchema, db)
+	ctx := sql.NewEmptyContext()
+	name := strings.Repeat("a", 200)
+
+	_, err = insertValues(ctx, table, name, idRange(0, 10000))
+	require.NoError(t, err)
+	want := tableRows(t, ctx, table)
+	require.Len(t, want, 10000)
+
+	ids := idRange(10000, 20000)
+	ids[len(ids)-1] = 42
+	_, err = insertValues(ctx, table, name, ids)
+	require.True(t, sql.IsKind(sql.ErrDuplicateKey, err), err)
+	require.Equal(t, want, tableRows(t, ctx, table))
+}
+
+func BenchmarkInsert(b *testing.B) {
+	const rows = 1000
+
+	b.Run("row by row", func(b *testing.B) {
+		db, err := badger.Open(badger.DefaultOptions(b.TempDir()).WithLogger(nil))
+		require.NoError(b, err)
+		defer db.Close()
+
+		table := NewTable("t", "testdb", batchSchema, db)
+		ctx := sql.NewEmptyContext()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for id := int64(0); id < rows; id++ {
+				row := sql.NewRow(int64(i)*rows+id, fmt.Sprint(id))
+				require.NoError(b, table.Insert(ctx, row))
+			}
+		}
+	})
+
+	b.Run("statement", func(b *testing.B) {
+		db, err := badger.Open(badger.DefaultOptions(b.TempDir()).WithLogger(nil))
+		require.NoError(b, err)
+		defer db.Close()
+
+		table := NewTable("t", "testdb", batchSchema, db)
+		ctx := sql.NewEmptyContext()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			from := int64(i) * rows
+			_, err := insertValues(ctx, table, "a", idRange(from, from+rows))
+			require.NoError(b, err)
+		}
+	})
+}
@@ -1,9 +1,18 @@
 package badger
 
 import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/dgraph-io/badger/v3"
 	"github.com/turtacn/guocedb/compute/sql"
 )
 
@@ -18,70 +27,452 @@ type DatabaseProvider interface {
 // Catalog implements a catalog of databases and the DatabaseProvider interface.
 type Catalog struct {
 	mu   sync.RWMutex
-	dbs  map[string]*Database
+	dbs  map[string]*catalogDatabase
 	path string
+	// meta keeps the set of databases of a catalog opened with OpenCatalog.
+	// It's nil if the catalog only keeps its databases in memory.
+	meta *badger.DB
+	// gc is the value log GC configuration of the databases, or nil if
+	// their garbage isn't collected. It's read as the databases are
+	// opened, without locking the catalog.
+	gc atomic.Pointer[GCConfig]
+	// stopIdle stops closing the idle databases, if it was started.
+	stopIdle chan struct{}
+	idleDone chan struct{}
 }
 
-// NewCatalog creates a new Catalog.
+// catalogEntry is the persisted description of a database in the catalog.
+type catalogEntry struct {
+	Name string
+	Path string
+}
+
+// catalogDatabase is a database of the catalog. The databases of a catalog
+// opened with OpenCatalog are opened the first time they're used, and they
+// may be closed again when they're idle.
+type catalogDatabase struct {
+	name string
+	// path is the directory of the database if the catalog is persistent,
+	// which is where it's opened from.
+	path string
+
+	// mu serializes opening and closing the database, so it's never
+	// opened twice.
+	mu sync.Mutex
+	// db is nil while the database is closed.
+	db *Database
+	// dropped is set once the database is dropped, so it's not opened
+	// again.
+	dropped bool
+	// lastUsed is when the database was last looked up, in Unix
+	// nanoseconds.
+	lastUsed atomic.Int64
+}
+
+// NewCatalog creates a new Catalog that keeps its databases in memory only.
 func NewCatalog(path string) *Catalog {
 	return &Catalog{
-		dbs:  make(map[string]*Database),
+		dbs:  make(map[string]*catalogDatabase),
 		path: path,
 	}
 }
 
-// Database returns a database by name (case-insensitive).
-func (c *Catalog) Database(ctx *sql.Context, name string) (sql.Database, error) {
+// OpenCatalog opens the catalog stored in path, with every database that
+// was added to it. The databases aren't opened until they're used. The set
+// of databases is kept up to date as they are added and dropped, so it
+// survives restarts.
+func OpenCatalog(path string) (*Catalog, error) {
+	meta, err := openBadger(filepath.Join(path, CatalogMetaPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewCatalog(path)
+	c.meta = meta
+
+	entries, err := c.loadEntries()
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	for _, e := range entries {
+		c.dbs[e.Name] = &catalogDatabase{name: e.Name, path: e.Path}
+	}
+
+	return c, nil
+}
+
+func openBadger(path string) (*badger.DB, error) {
+	opts := badger.DefaultOptions(path)
+	// BadgerDB crashes reading a corrupted value without a logger.
+	opts.Logger = badgerLogger{slog.Default()}
+	return badger.Open(opts)
+}
+
+func (c *Catalog) loadEntries() ([]catalogEntry, error) {
+	var entries []catalogEntry
+	err := c.meta.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(EncodeCatalogKey())
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &entries)
+		})
+	})
+	return entries, err
+}
+
+// saveDatabases persists the given set of databases. It must be called with
+// c.mu held, and it does nothing if the catalog is not persistent.
+func (c *Catalog) saveDatabases(dbs map[string]*catalogDatabase) error {
+	if c.meta == nil {
+		return nil
+	}
+
+	entries := make([]catalogEntry, 0, len(dbs))
+	for name, db := range dbs {
+		entries = append(entries, catalogEntry{Name: name, Path: db.path})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	val, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return c.meta.Update(func(txn *badger.Txn) error {
+		return txn.Set(EncodeCatalogKey(), val)
+	})
+}
+
+// Close closes the databases in the catalog and the store that keeps them.
+func (c *Catalog) Close() error {
+	c.SetIdleTimeout(0)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var firstErr error
+	for _, db := range c.dbs {
+		if err := db.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if c.meta != nil {
+		if err := c.meta.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// lookup returns the database with the given name (case-insensitive), if
+// it's in the catalog.
+func (c *Catalog) lookup(name string) (*catalogDatabase, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	lowerName := strings.ToLower(name)
 	for dbName, db := range c.dbs {
 		if strings.ToLower(dbName) == lowerName {
-			return db, nil
+			return db, true
 		}
 	}
-	return nil, sql.ErrDatabaseNotFound.New(name)
+	return nil, false
 }
 
-// HasDatabase checks if a database exists (case-insensitive).
+// open returns the database of the entry, opening it if it's closed.
+func (c *Catalog) open(db *catalogDatabase) (*Database, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.dropped {
+		return nil, sql.ErrDatabaseNotFound.New(db.name)
+	}
+	db.lastUsed.Store(time.Now().UnixNano())
+	if db.db != nil {
+		return db.db, nil
+	}
+
+	kv, err := openBadger(db.path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database %s: %v", db.name, err)
+	}
+	d, err := OpenDatabase(db.name, kv)
+	if err != nil {
+		kv.Close()
+		return nil, err
+	}
+	if gc := c.gc.Load(); gc != nil {
+		d.StartGC(*gc)
+	}
+
+	db.db = d
+	return d, nil
+}
+
+// close closes the database of the entry, if it's open.
+func (db *catalogDatabase) close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.db == nil {
+		return nil
+	}
+	db.db.StopGC()
+	err := db.db.db.Close()
+	db.db = nil
+	return err
+}
+
+// Database returns a database by name (case-insensitive), opening it if
+// it's not open.
+func (c *Catalog) Database(ctx *sql.Context, name string) (sql.Database, error) {
+	db, ok := c.lookup(name)
+	if !ok {
+		return nil, sql.ErrDatabaseNotFound.New(name)
+	}
+	return c.open(db)
+}
+
+// HasDatabase checks if a database exists (case-insensitive). The database
+// isn't opened.
 func (c *Catalog) HasDatabase(ctx *sql.Context, name string) bool {
+	_, ok := c.lookup(name)
+	return ok
+}
+
+// AllDatabases returns all databases in the catalog, opening them. The ones
+// that can't be opened are left out, Database reports why.
+func (c *Catalog) AllDatabases(ctx *sql.Context) []sql.Database {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
+	entries := make([]*catalogDatabase, 0, len(c.dbs))
+	for _, db := range c.dbs {
+		entries = append(entries, db)
+	}
+	c.mu.RUnlock()
 
-	lowerName := strings.ToLower(name)
-	for dbName := range c.dbs {
-		if strings.ToLower(dbName) == lowerName {
-			return true
+	dbs := make([]sql.Database, 0, len(entries))
+	for _, e := range entries {
+		if db, err := c.open(e); err == nil {
+			dbs = append(dbs, db)
 		}
 	}
-	return false
+	return dbs
 }
 
-// AllDatabases returns all databases in the catalog.
-func (c *Catalog) AllDatabases(ctx *sql.Context) []sql.Database {
+// OpenDatabases returns the names of the databases that are open, sorted.
+func (c *Catalog) OpenDatabases() []string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	dbs := make([]sql.Database, 0, len(c.dbs))
-	for _, db := range c.dbs {
-		dbs = append(dbs, db)
+	var names []string
+	for name, db := range c.dbs {
+		db.mu.Lock()
+		if db.db != nil {
+			names = append(names, name)
+		}
+		db.mu.Unlock()
 	}
-	return dbs
+	sort.Strings(names)
+	return names
 }
 
 // AddDatabase adds a database to the catalog.
-func (c *Catalog) AddDatabase(db *Database) {
+func (c *Catalog) AddDatabase(db *Database) error {
+	return c.CreateDatabase(db.Name(), db)
+}
+
+// CreateDatabase creates a new database.
+func (c *Catalog) CreateDatabase(name string, db *Database) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.dbs[db.Name()] = db
+
+	entry := &catalogDatabase{name: name, db: db}
+	entry.lastUsed.Store(time.Now().UnixNano())
+	if c.meta != nil {
+		dir := db.db.Opts().Dir
+		if db.db.Opts().InMemory || dir == "" {
+			return fmt.Errorf("database %s is not stored on disk", name)
+		}
+		path, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		entry.path = path
+	}
+
+	dbs := make(map[string]*catalogDatabase, len(c.dbs)+1)
+	for n, d := range c.dbs {
+		dbs[n] = d
+	}
+	dbs[name] = entry
+
+	if err := c.saveDatabases(dbs); err != nil {
+		return err
+	}
+	c.dbs = dbs
+	if gc := c.gc.Load(); gc != nil {
+		db.StartGC(*gc)
+	}
+	return nil
 }
 
-// CreateDatabase creates a new database.
-func (c *Catalog) CreateDatabase(name string, db *Database) {
+// DropDatabase removes a database (case-insensitive) from the catalog,
+// closing it and removing its files from disk.
+func (c *Catalog) DropDatabase(name string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.dbs[name] = db
+
+	dbs := make(map[string]*catalogDatabase, len(c.dbs))
+	var dropped *catalogDatabase
+	for n, d := range c.dbs {
+		if strings.EqualFold(n, name) {
+			dropped = d
+			continue
+		}
+		dbs[n] = d
+	}
+	if dropped == nil {
+		return sql.ErrDatabaseNotFound.New(name)
+	}
+
+	if err := c.saveDatabases(dbs); err != nil {
+		return err
+	}
+	c.dbs = dbs
+
+	dir := dropped.path
+	dropped.mu.Lock()
+	dropped.dropped = true
+	if dropped.db != nil && !dropped.db.db.Opts().InMemory {
+		dir = dropped.db.db.Opts().Dir
+	}
+	dropped.mu.Unlock()
+
+	if err := dropped.close(); err != nil {
+		return err
+	}
+	if dir == "" {
+		return nil
+	}
+	return os.RemoveAll(dir)
+}
+
+// CloseIdle closes the databases that weren't used for ttl. They are opened
+// again the next time they're used. Only the databases of a catalog opened
+// with OpenCatalog can be opened again, so the others are kept open.
+func (c *Catalog) CloseIdle(ttl time.Duration) error {
+	c.mu.RLock()
+	entries := make([]*catalogDatabase, 0, len(c.dbs))
+	for _, db := range c.dbs {
+		if db.path != "" {
+			entries = append(entries, db)
+		}
+	}
+	c.mu.RUnlock()
+
+	idleSince := time.Now().Add(-ttl).UnixNano()
+	var firstErr error
+	for _, db := range entries {
+		db.mu.Lock()
+		idle := db.db != nil && db.lastUsed.Load() <= idleSince
+		db.mu.Unlock()
+		if !idle {
+			continue
+		}
+		// The database may be used again before it's closed, which only
+		// closes it earlier than it should.
+		if err := db.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// SetIdleTimeout makes the catalog close the databases that weren't used
+// for ttl, checking them every ttl/2, so the databases that are rarely used
+// don't keep their files open. A database is closed even if a value it
+// returned is still held, so it must be looked up again to be used once it
+// was idle. A ttl of zero keeps the databases open.
+func (c *Catalog) SetIdleTimeout(ttl time.Duration) {
+	c.mu.Lock()
+	stop, done := c.stopIdle, c.idleDone
+	c.stopIdle, c.idleDone = nil, nil
+	if ttl > 0 {
+		c.stopIdle, c.idleDone = make(chan struct{}), make(chan struct{})
+		go c.closeIdle(ttl, c.stopIdle, c.idleDone)
+	}
+	c.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+func (c *Catalog) closeIdle(ttl time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(ttl / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := c.CloseIdle(ttl); err != nil {
+				slog.Warn("unable to close idle database", "error", err)
+			}
+		}
+	}
+}
+
+// StartGC collects the value log garbage of every database of the catalog
+// periodically, including the ones added or opened later. Each database has
+// its own BadgerDB instance, so each one is collected on its own.
+func (c *Catalog) StartGC(config GCConfig) {
+	c.mu.Lock()
+	c.gc.Store(&config)
+	dbs := make([]*catalogDatabase, 0, len(c.dbs))
+	for _, db := range c.dbs {
+		dbs = append(dbs, db)
+	}
+	c.mu.Unlock()
+
+	for _, db := range dbs {
+		db.mu.Lock()
+		if db.db != nil {
+			db.db.StartGC(config)
+		}
+		db.mu.Unlock()
+	}
+}
+
+// GCStats returns the disk usage and last value log GC of the open
+// databases whose garbage is collected, by database name.
+func (c *Catalog) GCStats() map[string]GCStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := make(map[string]GCStats, len(c.dbs))
+	for name, db := range c.dbs {
+		db.mu.Lock()
+		if db.db != nil {
+			if gc := db.db.GC(); gc != nil {
+				stats[name] = gc.Stats()
+			}
+		}
+		db.mu.Unlock()
+	}
+	return stats
 }
 
 // Tables returns the tables of a database.
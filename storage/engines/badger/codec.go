@@ -0,0 +1,128 @@
+package badger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// RowCodec encodes the rows of a table as they are stored in badger. The
+// codec of a table is chosen by name when it's created, with its
+// COMPRESSION option, and never changes, so the rows of a table are always
+// decoded by the codec that encoded them.
+type RowCodec interface {
+	// Encode encodes a row of a table with the given schema.
+	Encode(schema sql.Schema, row sql.Row) ([]byte, error)
+	// Decode decodes a row encoded by Encode with the same schema.
+	Decode(schema sql.Schema, val []byte) (sql.Row, error)
+}
+
+// Names of the row codecs that are always registered.
+const (
+	// BinaryCodec encodes the rows as encodeRow does. It's the codec of the
+	// tables created without the COMPRESSION option.
+	BinaryCodec = "binary"
+	// CompressedCodec compresses the rows encoded by BinaryCodec with
+	// snappy.
+	CompressedCodec = "compressed"
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]RowCodec{
+		BinaryCodec:     binaryCodec{},
+		CompressedCodec: compressedCodec{},
+	}
+)
+
+// RegisterRowCodec makes a row codec available to the tables created with
+// the given name as their COMPRESSION option. Names are case-insensitive. It
+// panics if a codec was already registered with the name, as the rows of
+// the existing tables could no longer be decoded otherwise.
+func RegisterRowCodec(name string, codec RowCodec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	name = strings.ToLower(name)
+	if codec == nil {
+		panic("badger: RegisterRowCodec codec is nil")
+	}
+	if _, ok := codecs[name]; ok {
+		panic("badger: RegisterRowCodec called twice for codec " + name)
+	}
+	codecs[name] = codec
+}
+
+// RowCodecs returns the sorted names of the registered row codecs.
+func RowCodecs() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// codecOf returns the row codec the options of a table choose, BinaryCodec
+// if they don't.
+func codecOf(options sql.TableOptions) (RowCodec, error) {
+	name, ok := options[sql.TableOptionCompression]
+	if !ok {
+		return binaryCodec{}, nil
+	}
+
+	codecsMu.RLock()
+	codec, ok := codecs[strings.ToLower(name)]
+	codecsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown row codec %q, expected one of %s",
+			name, strings.Join(RowCodecs(), ", "))
+	}
+	return codec, nil
+}
+
+// binaryCodec is the RowCodec of BinaryCodec.
+type binaryCodec struct{}
+
+func (binaryCodec) Encode(schema sql.Schema, row sql.Row) ([]byte, error) {
+	return encodeRow(row)
+}
+
+func (binaryCodec) Decode(schema sql.Schema, val []byte) (sql.Row, error) {
+	return decodeRow(val)
+}
+
+// compressedFormat is the first byte of the rows compressed by
+// compressedCodec. Rows that don't start with it are decoded by decodeRow.
+const compressedFormat byte = 0x82
+
+// compressedCodec is the RowCodec of CompressedCodec.
+type compressedCodec struct{}
+
+func (compressedCodec) Encode(schema sql.Schema, row sql.Row) ([]byte, error) {
+	val, err := encodeRow(row)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{compressedFormat}, snappy.Encode(nil, val)...), nil
+}
+
+func (compressedCodec) Decode(schema sql.Schema, val []byte) (sql.Row, error) {
+	if len(val) == 0 || val[0] != compressedFormat {
+		return decodeRow(val)
+	}
+
+	decoded, err := snappy.Decode(nil, val[1:])
+	if err != nil {
+		return nil, fmt.Errorf("corrupt compressed row: %v", err)
+	}
+	return decodeRow(decoded)
+}
@@ -0,0 +1,108 @@
+package badger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+func TestRowCodecs(t *testing.T) {
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "events"},
+		{Name: "kind", Type: sql.Text, Source: "events"},
+		{Name: "payload", Type: sql.Text, Source: "events", Nullable: true},
+	}
+	payload := strings.Repeat(`{"source":"sensor","unit":"celsius"}`, 8)
+
+	var sizes []int
+	for _, name := range []string{BinaryCodec, CompressedCodec} {
+		codec, err := codecOf(sql.TableOptions{sql.TableOptionCompression: name})
+		require.NoError(t, err)
+
+		size := 0
+		for i := 0; i < 10; i++ {
+			row := sql.NewRow(int64(i), "reading", payload)
+			if i%3 == 0 {
+				row[2] = nil
+			}
+
+			val, err := codec.Encode(schema, row)
+			require.NoError(t, err)
+			size += len(val)
+
+			decoded, err := codec.Decode(schema, val)
+			require.NoError(t, err)
+			require.Equal(t, row, decoded, name)
+		}
+		sizes = append(sizes, size)
+	}
+	require.Less(t, sizes[1], sizes[0])
+
+	// Tables created without the option use the binary codec.
+	codec, err := codecOf(nil)
+	require.NoError(t, err)
+	require.Equal(t, binaryCodec{}, codec)
+
+	_, err = codecOf(sql.TableOptions{sql.TableOptionCompression: "zlib"})
+	require.Error(t, err)
+}
+
+func TestCreateWithCompressedCodec(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	database := NewDatabase("mydb", db)
+	ctx := sql.NewEmptyContext()
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "logs"},
+		{Name: "line", Type: sql.Text, Source: "logs"},
+	}
+
+	require.Error(t, database.CreateWithOptions("bad", schema,
+		sql.TableOptions{sql.TableOptionCompression: "zlib"}))
+	require.NoError(t, database.CreateWithOptions("logs", schema,
+		sql.TableOptions{sql.TableOptionCompression: "Compressed"}))
+
+	table, ok, err := database.GetTableInsensitive(ctx, "logs")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var rows []sql.Row
+	for i := int64(1); i <= 5; i++ {
+		row := sql.NewRow(i, strings.Repeat(fmt.Sprintf("line %d ", i), 20))
+		require.NoError(t, table.(*Table).Insert(ctx, row))
+		rows = append(rows, row)
+	}
+
+	// The rows are stored compressed, and read back as they were written,
+	// also once the database is loaded again.
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		prefix := EncodeTablePrefix("mydb", "logs")
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		n := 0
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			require.Equal(t, compressedFormat, val[0])
+			n++
+		}
+		require.Equal(t, len(rows), n)
+		return nil
+	}))
+	require.Equal(t, rows, tableRows(t, ctx, table))
+
+	reloaded := NewDatabase("mydb", db)
+	table, ok, err = reloaded.GetTableInsensitive(ctx, "logs")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, rows, tableRows(t, ctx, table))
+}
@@ -4,6 +4,7 @@ package badger
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"sync"
 
@@ -19,17 +20,30 @@ type Database struct {
 	// tables cache map from table name to sql.Table
 	tables map[string]sql.Table
 	mu     sync.RWMutex
+	// gc collects the garbage of the value log of db, if it was started.
+	gc *GCScheduler
+	// online configures the online ALTERs, nil if they're not enabled.
+	online *OnlineAlterOptions
 }
 
 // NewDatabase creates a new Database instance and loads existing tables.
+// The tables whose metadata can't be read are left out, OpenDatabase
+// reports them.
 func NewDatabase(name string, db *badger.DB) *Database {
+	d, _ := OpenDatabase(name, db)
+	return d
+}
+
+// OpenDatabase creates a new Database instance and loads existing tables,
+// returning the error of the first table whose metadata can't be read, such
+// as one written by a newer version, along with the database without it.
+func OpenDatabase(name string, db *badger.DB) (*Database, error) {
 	d := &Database{
 		name:   name,
 		db:     db,
 		tables: make(map[string]sql.Table),
 	}
-	d.loadTables()
-	return d
+	return d, d.loadTables()
 }
 
 // SerializableColumn is a struct used for persisting column metadata.
@@ -38,44 +52,204 @@ type SerializableColumn struct {
 	Type     int32 // query.Type
 	Nullable bool
 	Source   string
+	// AutoIncrement is omitted for the columns that are not, so tables
+	// stored before it was kept read the same.
+	AutoIncrement bool `json:",omitempty"`
+	// PrimaryKey is set for the columns of a declared primary key.
+	PrimaryKey bool `json:",omitempty"`
+	// Length is the maximum length of VARCHAR columns.
+	Length int `json:",omitempty"`
+	// Collation is the collation of the text columns that don't have the
+	// default one.
+	Collation sql.Collation `json:",omitempty"`
+	// Default is the default value of the column as SQL text, which is
+	// converted back with the type of the column.
+	Default *string `json:",omitempty"`
+	// DefaultCurrentTimestamp is set for the columns defaulting to
+	// CURRENT_TIMESTAMP.
+	DefaultCurrentTimestamp bool `json:",omitempty"`
+	// OnUpdateCurrentTimestamp is set for the columns declared ON UPDATE
+	// CURRENT_TIMESTAMP.
+	OnUpdateCurrentTimestamp bool `json:",omitempty"`
+	// Comment is the COMMENT of the column.
+	Comment string `json:",omitempty"`
+}
+
+// tableMeta is the persisted metadata of a table. Tables created before
+// table options were kept are stored as a bare list of columns.
+type tableMeta struct {
+	// Version is the version of the format of the metadata, which is
+	// upgraded to tableMetaVersion when it's read.
+	Version int
+	Columns []SerializableColumn
+	Options sql.TableOptions      `json:",omitempty"`
+	Indexes []IndexDef            `json:",omitempty"`
+	Checks  []sql.CheckConstraint `json:",omitempty"`
+	// Storage is the name the rows and the index entries of the table are
+	// keyed by, if it's not the name of the table.
+	Storage string `json:",omitempty"`
+}
+
+// tableMetaVersion is the version of the format of the table metadata
+// written. Version 0 is the bare list of columns tables were stored as
+// before they had options, and version 1 the tableMeta object, which had no
+// Version before it was kept. Version 2 has the Storage of the tables
+// altered online, whose rows older versions would not find.
+var tableMetaVersion = 2
+
+// tableMetaMigrations upgrade the table metadata of each version to the
+// next one, the first one upgrading version 0, so metadata written by older
+// versions is read as the current one. A change of the format needs a bump
+// of tableMetaVersion along with the migration from the previous version.
+var tableMetaMigrations = []func(data []byte) ([]byte, error){
+	// The bare list of columns becomes the columns of the metadata.
+	func(data []byte) ([]byte, error) {
+		return json.Marshal(map[string]interface{}{
+			"Version": 1,
+			"Columns": json.RawMessage(data),
+		})
+	},
+	// The tables of version 1 are keyed by their name, as when they have
+	// no Storage.
+	func(data []byte) ([]byte, error) {
+		var meta map[string]json.RawMessage
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, err
+		}
+		meta["Version"] = json.RawMessage("2")
+		return json.Marshal(meta)
+	},
+}
+
+func marshalTableMeta(t *Table) ([]byte, error) {
+	return json.Marshal(tableMeta{
+		Version: tableMetaVersion,
+		Columns: serializeSchema(t.schema),
+		Options: t.options,
+		Indexes: t.Indexes(),
+		Checks:  t.checks,
+		Storage: t.storage,
+	})
+}
+
+// unmarshalTableMeta returns the schema of a table along with the rest of
+// its metadata, upgraded to the current version.
+func unmarshalTableMeta(data []byte) (sql.Schema, tableMeta, error) {
+	data, err := migrateTableMeta(data)
+	if err != nil {
+		return nil, tableMeta{}, err
+	}
+
+	var meta tableMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, tableMeta{}, err
+	}
+	meta.Version = tableMetaVersion
+
+	schema, err := deserializeSchema(meta.Columns)
+	if err != nil {
+		return nil, tableMeta{}, err
+	}
+	return schema, meta, nil
+}
+
+// migrateTableMeta upgrades the metadata of a table to tableMetaVersion.
+func migrateTableMeta(data []byte) ([]byte, error) {
+	version := 0
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var v struct{ Version int }
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		version = v.Version
+		if version == 0 {
+			version = 1
+		}
+	}
+
+	// Metadata written by a newer version can't be read safely.
+	if version > tableMetaVersion {
+		return nil, fmt.Errorf("metadata has version %d, but the most recent version supported is %d: upgrade guocedb to open the database",
+			version, tableMetaVersion)
+	}
+
+	for ; version < tableMetaVersion; version++ {
+		var err error
+		if data, err = tableMetaMigrations[version](data); err != nil {
+			return nil, fmt.Errorf("unable to upgrade metadata from version %d: %v", version, err)
+		}
+	}
+	return data, nil
 }
 
-func marshalSchema(s sql.Schema) ([]byte, error) {
+func serializeSchema(s sql.Schema) []SerializableColumn {
 	cols := make([]SerializableColumn, len(s))
 	for i, c := range s {
 		cols[i] = SerializableColumn{
-			Name:       c.Name,
-			Type:       int32(c.Type.Type()),
-			Nullable:   c.Nullable,
-			Source:     c.Source,
+			Name:          c.Name,
+			Type:          int32(c.Type.Type()),
+			Nullable:      c.Nullable,
+			Source:        c.Source,
+			AutoIncrement: c.AutoIncrement,
+			PrimaryKey:    c.PrimaryKey,
+			Length:        sql.MaxLength(c.Type),
+			Comment:       c.Comment,
+
+			OnUpdateCurrentTimestamp: c.OnUpdateCurrentTimestamp,
+		}
+		if collation := sql.CollationOf(c.Type); collation != sql.DefaultCollation {
+			cols[i].Collation = collation
+		}
+		switch c.Default {
+		case nil:
+		case sql.CurrentTimestamp:
+			cols[i].DefaultCurrentTimestamp = true
+		default:
+			def := c.Type.SQL(c.Default).ToString()
+			cols[i].Default = &def
 		}
 	}
-	return json.Marshal(cols)
+	return cols
 }
 
-func unmarshalSchema(data []byte) (sql.Schema, error) {
-	var cols []SerializableColumn
-	if err := json.Unmarshal(data, &cols); err != nil {
-		return nil, err
-	}
-
+func deserializeSchema(cols []SerializableColumn) (sql.Schema, error) {
 	schema := make(sql.Schema, len(cols))
 	for i, c := range cols {
 		typ, err := sql.MysqlTypeToType(query.Type(c.Type))
 		if err != nil {
 			return nil, err
 		}
+		if c.Length > 0 {
+			typ = sql.VarChar(c.Length)
+		}
+		if c.Collation != "" {
+			if typ, err = sql.WithCollation(typ, c.Collation); err != nil {
+				return nil, err
+			}
+		}
 		schema[i] = &sql.Column{
-			Name:     c.Name,
-			Type:     typ,
-			Nullable: c.Nullable,
-			Source:   c.Source,
+			Name:          c.Name,
+			Type:          typ,
+			Nullable:      c.Nullable,
+			Source:        c.Source,
+			AutoIncrement: c.AutoIncrement,
+			PrimaryKey:    c.PrimaryKey,
+			Comment:       c.Comment,
+
+			OnUpdateCurrentTimestamp: c.OnUpdateCurrentTimestamp,
+		}
+		if c.DefaultCurrentTimestamp {
+			schema[i].Default = sql.CurrentTimestamp
+		} else if c.Default != nil {
+			if schema[i].Default, err = typ.Convert(*c.Default); err != nil {
+				return nil, fmt.Errorf("invalid default of column %s: %v", c.Name, err)
+			}
 		}
 	}
 	return schema, nil
 }
 
-func (d *Database) loadTables() {
+func (d *Database) loadTables() error {
 	// Construct prefix using EncodeTableKey logic, but stop before tableName
 	// EncodeTableKey: MetaPrefix + dbName + "/" + TableMetaPrefix + tableName
 	// We want: MetaPrefix + dbName + "/" + TableMetaPrefix
@@ -87,7 +261,8 @@ func (d *Database) loadTables() {
 	prefix.WriteString(TableMetaPrefix)
 	prefixBytes := prefix.Bytes()
 
-	d.db.View(func(txn *badger.Txn) error {
+	var loadErr error
+	err := d.db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.Prefix = prefixBytes
 		it := txn.NewIterator(opts)
@@ -101,21 +276,34 @@ func (d *Database) loadTables() {
 			tableName := string(key[len(prefixBytes):])
 
 			err := item.Value(func(val []byte) error {
-				schema, err := unmarshalSchema(val)
+				schema, meta, err := unmarshalTableMeta(val)
 				if err != nil {
 					return err
 				}
 				// Reconstruct table
 				t := NewTable(tableName, d.name, schema, d.db)
+				t.options = meta.Options
+				t.storage = meta.Storage
+				if t.codec, err = codecOf(meta.Options); err != nil {
+					return err
+				}
+				t.checks = meta.Checks
+				if err := t.indexes.set(schema, meta.Indexes); err != nil {
+					return err
+				}
 				d.tables[tableName] = t
 				return nil
 			})
-			if err != nil {
-				// Log error?
+			if err != nil && loadErr == nil {
+				loadErr = fmt.Errorf("unable to load table %s of database %s: %v", tableName, d.name, err)
 			}
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	return loadErr
 }
 
 // Name returns the name of the database.
@@ -137,6 +325,18 @@ func (d *Database) Tables() map[string]sql.Table {
 
 // Create implements sql.Alterable.
 func (d *Database) Create(name string, schema sql.Schema) error {
+	return d.CreateWithOptions(name, schema, nil)
+}
+
+// CreateWithOptions implements sql.OptionsAlterable. The options are
+// persisted along with the schema.
+func (d *Database) CreateWithOptions(name string, schema sql.Schema, options sql.TableOptions) error {
+	return d.CreateWithChecks(name, schema, options, nil)
+}
+
+// CreateWithChecks implements sql.CheckAlterable. The CHECK constraints are
+// persisted along with the schema and the options.
+func (d *Database) CreateWithChecks(name string, schema sql.Schema, options sql.TableOptions, checks []sql.CheckConstraint) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -144,9 +344,17 @@ func (d *Database) Create(name string, schema sql.Schema) error {
 		return sql.ErrTableAlreadyExists.New(name)
 	}
 
+	codec, err := codecOf(options)
+	if err != nil {
+		return err
+	}
+
 	table := NewTable(name, d.name, schema, d.db)
+	table.options = options
+	table.codec = codec
+	table.checks = checks
 
-	err := d.db.Update(func(txn *badger.Txn) error {
+	err = d.db.Update(func(txn *badger.Txn) error {
 		key := EncodeTableKey(d.name, name)
 
 		_, err := txn.Get(key)
@@ -157,7 +365,7 @@ func (d *Database) Create(name string, schema sql.Schema) error {
 			return err
 		}
 
-		val, err := marshalSchema(schema)
+		val, err := marshalTableMeta(table)
 		if err != nil {
 			return err
 		}
@@ -219,9 +427,17 @@ func (d *Database) DropTable(ctx *sql.Context, name string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if _, ok := d.tables[name]; !ok {
+	table, ok := d.tables[name]
+	if !ok {
 		return sql.ErrTableNotFound.New(name)
 	}
+	storage := name
+	if t, ok := table.(*Table); ok {
+		if t.gate.isAltering() {
+			return errTableAltering(name)
+		}
+		storage = t.storageName()
+	}
 
 	err := d.db.Update(func(txn *badger.Txn) error {
 		// Delete metadata
@@ -229,13 +445,13 @@ func (d *Database) DropTable(ctx *sql.Context, name string) error {
 		if err := txn.Delete(metaKey); err != nil {
 			return err
 		}
+		if err := txn.Delete(EncodeAutoIncrementKey(d.name, name)); err != nil {
+			return err
+		}
 
-		// Delete all rows
-		dataPrefix := EncodeTablePrefix(d.name, name)
-		it := txn.NewIterator(badger.DefaultIteratorOptions)
-		defer it.Close()
-		for it.Seek(dataPrefix); it.ValidForPrefix(dataPrefix); it.Next() {
-			if err := txn.Delete(it.Item().Key()); err != nil {
+		// Delete all rows and index entries
+		for _, prefix := range [][]byte{EncodeTablePrefix(d.name, storage), EncodeTableIndexesPrefix(d.name, storage)} {
+			if err := deletePrefix(txn, prefix); err != nil {
 				return err
 			}
 		}
@@ -249,3 +465,407 @@ func (d *Database) DropTable(ctx *sql.Context, name string) error {
 	delete(d.tables, name)
 	return nil
 }
+
+// Truncate implements sql.TruncateAlterable. The rows and index entries of
+// the table are dropped by prefix, which takes the same time no matter how
+// many rows there are, and doesn't keep them around to be rolled back.
+func (d *Database) Truncate(ctx *sql.Context, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.badgerTable(name)
+	if err != nil {
+		return err
+	}
+
+	t.autoInc.mu.Lock()
+	defer t.autoInc.mu.Unlock()
+
+	err = d.db.DropPrefix(EncodeTablePrefix(d.name, t.storageName()), EncodeTableIndexesPrefix(d.name, t.storageName()))
+	if err != nil {
+		return err
+	}
+
+	err = d.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(EncodeAutoIncrementKey(d.name, t.name))
+	})
+	if err != nil {
+		return err
+	}
+
+	// The counter is loaded again from the table options the next time.
+	t.autoInc.next = 0
+	return nil
+}
+
+func deletePrefix(txn *badger.Txn, prefix []byte) error {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		if err := txn.Delete(it.Item().KeyCopy(nil)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateIndex creates a secondary index on the given columns of a table and
+// adds the entries of the rows it already has. From then on, the entries
+// are kept up to date in the same transaction that changes the rows.
+func (d *Database) CreateIndex(tableName, indexName string, columns []string) error {
+	return d.AddIndex(tableName, IndexDef{Name: indexName, Columns: columns})
+}
+
+// AddIndex implements the sql.IndexAlterable interface, see CreateIndex.
+// Unique indexes can't be added to tables whose rows have duplicate values.
+func (d *Database) AddIndex(tableName string, index IndexDef) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.badgerTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	if len(index.Columns) == 0 {
+		return fmt.Errorf("index %s must have at least one column", index.Name)
+	}
+
+	old := t.Indexes()
+	for _, def := range old {
+		if strings.EqualFold(def.Name, index.Name) {
+			return fmt.Errorf("index %s already exists in table %s", index.Name, tableName)
+		}
+	}
+
+	// The index is used by the editors before the existing rows are added
+	// to it, so the rows written meanwhile get their entries too. Adding the
+	// existing rows reads them, so it conflicts with any transaction that
+	// changes them before it's committed.
+	defs := append(append([]IndexDef(nil), old...), index)
+	if err := t.indexes.set(t.schema, defs); err != nil {
+		return err
+	}
+	_, columns := t.indexes.get()
+	cols := columns[len(columns)-1]
+
+	err = d.db.Update(func(txn *badger.Txn) error {
+		prefix := EncodeTablePrefix(d.name, t.storageName())
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		// The values of a unique index are the entries without the
+		// primary key of the row.
+		pkOffset := len(prefix)
+		seen := make(map[string]bool)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			key := it.Item().KeyCopy(nil)
+			row, err := t.getRow(txn, key)
+			if err != nil {
+				return err
+			}
+
+			entries, err := t.indexEntries(row, key)
+			if err != nil {
+				return err
+			}
+			entry := entries[len(entries)-1]
+			if index.Unique && !hasNull(row, cols) {
+				values := string(entry[:len(entry)-(len(key)-pkOffset)])
+				if seen[values] {
+					return duplicateUniqueKeyError(row, cols, index.Name)
+				}
+				seen[values] = true
+			}
+			if err := txn.Set(entry, key); err != nil {
+				return err
+			}
+		}
+
+		return d.saveTableMeta(txn, t)
+	})
+	if err != nil {
+		_ = t.indexes.set(t.schema, old)
+		return err
+	}
+
+	return nil
+}
+
+// DropIndex drops a secondary index of a table along with its entries.
+func (d *Database) DropIndex(tableName, indexName string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.badgerTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	old := t.Indexes()
+	var defs []IndexDef
+	for _, def := range old {
+		if strings.EqualFold(def.Name, indexName) {
+			indexName = def.Name
+		} else {
+			defs = append(defs, def)
+		}
+	}
+	if len(defs) == len(old) {
+		return fmt.Errorf("index %s not found in table %s", indexName, tableName)
+	}
+
+	if err := t.indexes.set(t.schema, defs); err != nil {
+		return err
+	}
+
+	err = d.db.Update(func(txn *badger.Txn) error {
+		if err := d.saveTableMeta(txn, t); err != nil {
+			return err
+		}
+		return deletePrefix(txn, EncodeIndexPrefix(d.name, t.storageName(), indexName))
+	})
+	if err != nil {
+		_ = t.indexes.set(t.schema, old)
+		return err
+	}
+
+	return nil
+}
+
+// AddColumn implements sql.AlterableTable. The column is added at the end of
+// the schema and the existing rows get its default value.
+func (d *Database) AddColumn(ctx *sql.Context, tableName string, column *sql.Column) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.badgerTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	if indexOfColumn(t.schema, column.Name) >= 0 {
+		return fmt.Errorf("column %s already exists in table %s", column.Name, t.name)
+	}
+	if column.AutoIncrement {
+		return fmt.Errorf("AUTO_INCREMENT column %s can't be added to table %s", column.Name, t.name)
+	}
+	if column.PrimaryKey {
+		return fmt.Errorf("primary key column %s can't be added to table %s", column.Name, t.name)
+	}
+
+	added := *column
+	added.Source = t.name
+	schema := append(append(sql.Schema(nil), t.schema...), &added)
+
+	// The rows the table has take the default of the column, or NULL.
+	var def interface{}
+	if added.Default != nil {
+		if def, err = added.DefaultValue(ctx); err != nil {
+			return err
+		}
+	}
+
+	return d.alterTable(t, schema, func(row sql.Row) (sql.Row, error) {
+		if def == nil && !added.Nullable {
+			return nil, fmt.Errorf("column %s can't be added to table %s with rows: it's NOT NULL and has no default", added.Name, t.name)
+		}
+		return append(row.Copy(), def), nil
+	})
+}
+
+// DropColumn implements sql.AlterableTable. The column is stripped from the
+// existing rows. The columns of the primary key, which is the first column
+// for tables that don't declare one, and the columns in indexes can't be
+// dropped.
+func (d *Database) DropColumn(ctx *sql.Context, tableName string, columnName string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.badgerTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	idx := indexOfColumn(t.schema, columnName)
+	switch {
+	case idx < 0:
+		return fmt.Errorf("column %s not found in table %s", columnName, t.name)
+	case t.schema[idx].PrimaryKey, idx == 0 && len(primaryKeyColumns(t.schema)) == 0:
+		return fmt.Errorf("column %s is the primary key of table %s and can't be dropped", columnName, t.name)
+	}
+
+	for _, def := range t.Indexes() {
+		for _, col := range def.Columns {
+			if strings.EqualFold(col, columnName) {
+				return fmt.Errorf("column %s is used by index %s and can't be dropped", columnName, def.Name)
+			}
+		}
+	}
+
+	schema := append(append(sql.Schema(nil), t.schema[:idx]...), t.schema[idx+1:]...)
+
+	return d.alterTable(t, schema, func(row sql.Row) (sql.Row, error) {
+		if idx >= len(row) {
+			return row, nil
+		}
+		return append(append(sql.Row(nil), row[:idx]...), row[idx+1:]...), nil
+	})
+}
+
+// ModifyColumn implements sql.AlterableTable. The values of the column in
+// the existing rows are converted to its new type. The columns of the
+// primary key, AUTO_INCREMENT columns and the columns used by indexes can't
+// be modified, as their encoding is part of the keys of the table.
+func (d *Database) ModifyColumn(ctx *sql.Context, tableName string, column *sql.Column) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, err := d.badgerTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	idx := indexOfColumn(t.schema, column.Name)
+	switch {
+	case idx < 0:
+		return fmt.Errorf("column %s not found in table %s", column.Name, t.name)
+	case t.schema[idx].PrimaryKey, column.PrimaryKey, idx == 0 && len(primaryKeyColumns(t.schema)) == 0:
+		return fmt.Errorf("column %s is the primary key of table %s and can't be modified", column.Name, t.name)
+	case t.schema[idx].AutoIncrement, column.AutoIncrement:
+		return fmt.Errorf("AUTO_INCREMENT column %s of table %s can't be modified", column.Name, t.name)
+	}
+
+	for _, def := range t.Indexes() {
+		for _, col := range def.Columns {
+			if strings.EqualFold(col, column.Name) {
+				return fmt.Errorf("column %s is used by index %s and can't be modified", column.Name, def.Name)
+			}
+		}
+	}
+
+	modified := *column
+	modified.Name = t.schema[idx].Name
+	modified.Source = t.name
+	schema := append(sql.Schema(nil), t.schema...)
+	schema[idx] = &modified
+
+	return d.alterTable(t, schema, func(row sql.Row) (sql.Row, error) {
+		if idx >= len(row) {
+			return row, nil
+		}
+
+		row = row.Copy()
+		if row[idx] == nil {
+			if !modified.Nullable {
+				return nil, fmt.Errorf("column %s of table %s can't be NOT NULL: it has NULL values", modified.Name, t.name)
+			}
+			return row, nil
+		}
+
+		v, err := modified.Type.Convert(row[idx])
+		if err != nil {
+			return nil, fmt.Errorf("column %s of table %s: %v", modified.Name, t.name, err)
+		}
+		row[idx] = v
+		return row, nil
+	})
+}
+
+// alterTable replaces table t with a table with the given schema, rewriting
+// its rows with migrate. Everything is done in a single transaction, and the
+// table is replaced rather than changed, so iterators that are already open
+// keep reading the rows and the schema they started with. It must be called
+// with d.mu held. Tables are altered online instead if it's enabled.
+func (d *Database) alterTable(t *Table, schema sql.Schema, migrate func(sql.Row) (sql.Row, error)) error {
+	if d.online != nil {
+		return d.alterTableOnline(t, schema, migrate)
+	}
+
+	altered := NewTable(t.name, d.name, schema, d.db)
+	altered.options = t.options
+	altered.codec = t.codec
+	altered.checks = t.checks
+	altered.autoInc = t.autoInc
+	if err := altered.indexes.set(schema, t.Indexes()); err != nil {
+		return err
+	}
+
+	err := d.db.Update(func(txn *badger.Txn) error {
+		prefix := EncodeTablePrefix(d.name, t.storageName())
+
+		var keys [][]byte
+		var rows []sql.Row
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			key := it.Item().KeyCopy(nil)
+			row, err := t.getRow(txn, key)
+			if err != nil {
+				it.Close()
+				return err
+			}
+			keys = append(keys, key)
+			rows = append(rows, row)
+		}
+		it.Close()
+
+		for i, row := range rows {
+			row, err := migrate(row)
+			if err != nil {
+				return err
+			}
+
+			val, err := altered.encodeRow(row)
+			if err != nil {
+				return err
+			}
+			if err := txn.Set(keys[i], val); err != nil {
+				return err
+			}
+		}
+
+		return d.saveTableMeta(txn, altered)
+	})
+	if err != nil {
+		return err
+	}
+
+	d.tables[t.name] = altered
+	return nil
+}
+
+// badgerTable returns the table with the given name, for it to be changed.
+// Tables being altered online can't be. It must be called with d.mu held.
+func (d *Database) badgerTable(name string) (*Table, error) {
+	table, ok := d.tables[name]
+	if !ok {
+		for n, t := range d.tables {
+			if strings.EqualFold(n, name) {
+				table, ok = t, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil, sql.ErrTableNotFound.New(name)
+	}
+
+	t, ok := table.(*Table)
+	if !ok {
+		return nil, fmt.Errorf("table %s does not support indexes", name)
+	}
+	if t.gate.isAltering() {
+		return nil, errTableAltering(t.name)
+	}
+	return t, nil
+}
+
+func (d *Database) saveTableMeta(txn *badger.Txn, t *Table) error {
+	val, err := marshalTableMeta(t)
+	if err != nil {
+		return err
+	}
+	return txn.Set(EncodeTableKey(d.name, t.name), val)
+}
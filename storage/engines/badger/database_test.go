@@ -1,9 +1,11 @@
 package badger
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/stretchr/testify/assert"
@@ -101,3 +103,141 @@ func TestDatabase_DropTable(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, sql.ErrTableNotFound.Is(err))
 }
+
+func TestDatabase_CreateWithOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "badger-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db, err := badger.Open(badger.DefaultOptions(dir))
+	require.NoError(t, err)
+	defer db.Close()
+
+	schema := sql.Schema{{Name: "id", Type: sql.Int64, Source: "t1"}}
+	options := sql.TableOptions{
+		sql.TableOptionEngine:    "InnoDB",
+		sql.TableOptionRowFormat: "DYNAMIC",
+		sql.TableOptionComment:   "users",
+	}
+
+	database := NewDatabase("testdb", db)
+	require.NoError(t, database.CreateWithOptions("t1", schema, options))
+	require.NoError(t, database.Create("t2", schema))
+
+	// Tables created before options were persisted store only the columns.
+	legacy, err := json.Marshal(serializeSchema(schema))
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return txn.Set(EncodeTableKey("testdb", "t3"), legacy)
+	}))
+
+	reloaded := NewDatabase("testdb", db)
+	tables := reloaded.Tables()
+	require.Len(t, tables, 3)
+
+	assert.Equal(t, options, tables["t1"].(sql.TableOptioner).TableOptions())
+	assert.Nil(t, tables["t2"].(sql.TableOptioner).TableOptions())
+	assert.Nil(t, tables["t3"].(sql.TableOptioner).TableOptions())
+	assert.Equal(t, schema, tables["t3"].Schema())
+}
+
+func TestDatabase_ColumnDefaults(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "t", PrimaryKey: true},
+		{Name: "name", Type: sql.VarChar(20), Source: "t", Nullable: true, Default: ""},
+		{Name: "count", Type: sql.Int32, Source: "t", Nullable: true, Default: int32(-3)},
+		{Name: "price", Type: sql.Float64, Source: "t", Nullable: true, Default: float64(0.25)},
+		{Name: "created_at", Type: sql.Timestamp, Source: "t", Nullable: true, Default: sql.CurrentTimestamp},
+		{Name: "updated_at", Type: sql.Timestamp, Source: "t", Nullable: true, OnUpdateCurrentTimestamp: true},
+		{Name: "day", Type: sql.Date, Source: "t", Nullable: true, Default: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)},
+		{Name: "note", Type: sql.Text, Source: "t", Nullable: true, Comment: "free text"},
+	}
+	require.NoError(t, NewDatabase("testdb", db).Create("t", schema))
+
+	tables := NewDatabase("testdb", db).Tables()
+	require.Equal(t, schema, tables["t"].Schema())
+}
+
+func TestDatabase_TableMetaMigration(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "t", PrimaryKey: true},
+		{Name: "name", Type: sql.VarChar(20), Source: "t", Nullable: true},
+	}
+
+	// Version 1 is the metadata as it was written before it had a version.
+	v1, err := json.Marshal(map[string]interface{}{"Columns": serializeSchema(schema)})
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return txn.Set(EncodeTableKey("testdb", "t"), v1)
+	}))
+
+	// A version 3 that keeps the comment of the tables gives one to the
+	// tables of version 1 when they're upgraded.
+	defer func(version int, migrations []func([]byte) ([]byte, error)) {
+		tableMetaVersion, tableMetaMigrations = version, migrations
+	}(tableMetaVersion, tableMetaMigrations)
+	tableMetaVersion = 3
+	tableMetaMigrations = append(tableMetaMigrations[:2:2], func(data []byte) ([]byte, error) {
+		var meta map[string]json.RawMessage
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, err
+		}
+		options, err := json.Marshal(sql.TableOptions{sql.TableOptionComment: "upgraded from v1"})
+		if err != nil {
+			return nil, err
+		}
+		meta["Options"] = options
+		return json.Marshal(meta)
+	})
+
+	database, err := OpenDatabase("testdb", db)
+	require.NoError(t, err)
+	table, ok := database.Tables()["t"].(*Table)
+	require.True(t, ok)
+	assert.Equal(t, schema, table.Schema())
+	assert.Equal(t, sql.TableOptions{sql.TableOptionComment: "upgraded from v1"}, table.TableOptions())
+
+	// The metadata is written with the current version.
+	data, err := marshalTableMeta(table)
+	require.NoError(t, err)
+	var meta tableMeta
+	require.NoError(t, json.Unmarshal(data, &meta))
+	assert.Equal(t, 3, meta.Version)
+}
+
+func TestDatabase_TableMetaFromNewerVersion(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	schema := sql.Schema{{Name: "id", Type: sql.Int64, Source: "t"}}
+	database := NewDatabase("testdb", db)
+	require.NoError(t, database.Create("old", schema))
+
+	future, err := json.Marshal(map[string]interface{}{
+		"Version": tableMetaVersion + 1,
+		"Columns": serializeSchema(schema),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return txn.Set(EncodeTableKey("testdb", "new"), future)
+	}))
+
+	reopened, err := OpenDatabase("testdb", db)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "table new of database testdb")
+	assert.Contains(t, err.Error(), "upgrade guocedb")
+
+	// The tables that can be read are still loaded.
+	tables := reopened.Tables()
+	assert.Len(t, tables, 1)
+	assert.Contains(t, tables, "old")
+}
@@ -14,6 +14,11 @@ const (
 	MetaPrefix byte = 0x01
 	// DataPrefix is the prefix for all table data keys.
 	DataPrefix byte = 0x02
+	// IndexPrefix is the prefix for all secondary index entries.
+	IndexPrefix byte = 0x03
+	// ReplicationPrefix is the prefix for the state of a read replica, which
+	// is never replicated itself.
+	ReplicationPrefix byte = 0x04
 )
 
 // Meta-data sub-prefixes
@@ -22,8 +27,19 @@ const (
 	DBMetaPrefix = "db"
 	// TableMetaPrefix is for table metadata.
 	TableMetaPrefix = "tbl"
+	// CatalogMetaPrefix is for the set of databases of a catalog.
+	CatalogMetaPrefix = "catalog"
+	// AutoIncrementMetaPrefix is for the AUTO_INCREMENT counters of tables.
+	AutoIncrementMetaPrefix = "ainc"
 )
 
+// EncodeCatalogKey creates the key under which a catalog stores its
+// databases.
+// Key: MetaPrefix | "catalog"
+func EncodeCatalogKey() []byte {
+	return append([]byte{MetaPrefix}, CatalogMetaPrefix...)
+}
+
 // EncodeDBKey creates a key for storing database metadata.
 // Key: MetaPrefix | "db" | dbName
 func EncodeDBKey(dbName string) []byte {
@@ -46,6 +62,19 @@ func EncodeTableKey(dbName, tableName string) []byte {
 	return key.Bytes()
 }
 
+// EncodeAutoIncrementKey creates the key under which the next value of the
+// AUTO_INCREMENT column of a table is stored.
+// Key: MetaPrefix | dbName | "ainc" | tableName
+func EncodeAutoIncrementKey(dbName, tableName string) []byte {
+	key := new(bytes.Buffer)
+	key.WriteByte(MetaPrefix)
+	key.WriteString(dbName)
+	key.WriteByte('/') // separator
+	key.WriteString(AutoIncrementMetaPrefix)
+	key.WriteString(tableName)
+	return key.Bytes()
+}
+
 // EncodeRowKey creates a key for a specific row in a table.
 // It uses a simple scheme for demonstration. A real implementation might use
 // table IDs instead of names for efficiency.
@@ -74,6 +103,55 @@ func EncodeTablePrefix(dbName, tableName string) []byte {
 	return key.Bytes()
 }
 
+// EncodeAppliedLSNKey creates the key under which a read replica stores
+// the LSN of the last commit of its primary it applied.
+// Key: ReplicationPrefix | "lsn"
+func EncodeAppliedLSNKey() []byte {
+	return append([]byte{ReplicationPrefix}, "lsn"...)
+}
+
+// EncodeIndexPrefix creates a key prefix for all entries of a secondary
+// index. An entry appends the encoded values of the indexed columns and the
+// primary key of the row to it.
+// Key: IndexPrefix | dbName | tableName | indexName
+func EncodeIndexPrefix(dbName, tableName, indexName string) []byte {
+	key := new(bytes.Buffer)
+	key.WriteByte(IndexPrefix)
+	key.WriteString(dbName)
+	key.WriteByte('/')
+	key.WriteString(tableName)
+	key.WriteByte('/')
+	key.WriteString(indexName)
+	key.WriteByte('/')
+	return key.Bytes()
+}
+
+// EncodeTableIndexesPrefix creates a key prefix for the entries of all the
+// secondary indexes of a table.
+// Key: IndexPrefix | dbName | tableName
+func EncodeTableIndexesPrefix(dbName, tableName string) []byte {
+	key := new(bytes.Buffer)
+	key.WriteByte(IndexPrefix)
+	key.WriteString(dbName)
+	key.WriteByte('/')
+	key.WriteString(tableName)
+	key.WriteByte('/')
+	return key.Bytes()
+}
+
+// EncodePrimaryKey encodes the values of the primary key columns of a row,
+// to be used as the pk of EncodeRowKey. Keys sort as the tuples of values
+// do, so scanning the rows of a table returns them in primary key order.
+func EncodePrimaryKey(values []interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, v := range values {
+		if err := encodeIndexValue(&buf, v); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
 // The following are placeholder functions for more complex encoding schemes,
 // such as those involving numeric IDs or composite keys.
 
@@ -0,0 +1,243 @@
+package badger
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// GCConfig configures the value log garbage collection of a database.
+type GCConfig struct {
+	// Interval is the time between two collections.
+	Interval time.Duration
+	// DiscardRatio is the least fraction of a value log file that must be
+	// stale for the file to be rewritten.
+	DiscardRatio float64
+	// Logger receives a message after every collection. slog.Default is
+	// used if it's nil.
+	Logger *slog.Logger
+}
+
+// DefaultGCConfig is the GC configuration used if none is given.
+var DefaultGCConfig = GCConfig{
+	Interval:     10 * time.Minute,
+	DiscardRatio: 0.5,
+}
+
+// GCStats are the disk usage of a database and the result of its last
+// value log garbage collection.
+type GCStats struct {
+	// LSMSize and ValueLogSize are the bytes on disk of the LSM tree and
+	// the value log.
+	LSMSize      int64
+	ValueLogSize int64
+	// Runs is the number of collections done.
+	Runs int64
+	// LastRun is when the last collection finished, or zero if there was
+	// none yet.
+	LastRun time.Time
+	// LastDuration is how long the last collection took.
+	LastDuration time.Duration
+	// LastReclaimed is the value log bytes freed by the last collection.
+	LastReclaimed int64
+	// TotalReclaimed is the value log bytes freed by all collections.
+	TotalReclaimed int64
+}
+
+// GCScheduler runs the value log garbage collection of a database
+// periodically. BadgerDB never removes the stale values of its value log on
+// its own, so the disk usage grows without it.
+type GCScheduler struct {
+	name   string
+	db     *badger.DB
+	config GCConfig
+
+	// run serializes the collections.
+	run   sync.Mutex
+	mu    sync.Mutex
+	stats GCStats
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewGCScheduler creates a scheduler for the database with the given name
+// stored in db. It doesn't collect anything until it's started. The zero
+// fields of config take their value from DefaultGCConfig.
+func NewGCScheduler(name string, db *badger.DB, config GCConfig) *GCScheduler {
+	if config.Interval <= 0 {
+		config.Interval = DefaultGCConfig.Interval
+	}
+	if config.DiscardRatio <= 0 || config.DiscardRatio >= 1 {
+		config.DiscardRatio = DefaultGCConfig.DiscardRatio
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &GCScheduler{name: name, db: db, config: config}
+}
+
+// Start collects the garbage of the database every interval until Stop is
+// called. It does nothing if the scheduler is already running.
+func (s *GCScheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go s.loop(s.stop, s.done)
+}
+
+func (s *GCScheduler) loop(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := s.RunGC(); err != nil {
+				s.config.Logger.Error("Value log GC failed", "database", s.name, "error", err)
+			}
+		}
+	}
+}
+
+// Stop stops the periodic collection and waits for the one in progress,
+// if any, to finish.
+func (s *GCScheduler) Stop() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// RunGC rewrites the value log files with more stale data than the discard
+// ratio until there are none left, and returns the bytes freed.
+func (s *GCScheduler) RunGC() (int64, error) {
+	s.run.Lock()
+	defer s.run.Unlock()
+
+	start := time.Now()
+	_, before := s.size()
+
+	var err error
+	for err == nil {
+		err = s.db.RunValueLogGC(s.config.DiscardRatio)
+	}
+	if err == badger.ErrNoRewrite || err == badger.ErrRejected {
+		err = nil
+	}
+
+	lsm, after := s.size()
+	reclaimed := before - after
+	if reclaimed < 0 {
+		reclaimed = 0
+	}
+
+	s.mu.Lock()
+	s.stats.LSMSize, s.stats.ValueLogSize = lsm, after
+	s.stats.Runs++
+	s.stats.LastRun = time.Now()
+	s.stats.LastDuration = s.stats.LastRun.Sub(start)
+	s.stats.LastReclaimed = reclaimed
+	s.stats.TotalReclaimed += reclaimed
+	s.mu.Unlock()
+
+	if err == nil {
+		s.config.Logger.Info("Value log GC finished",
+			"database", s.name,
+			"reclaimed_bytes", reclaimed,
+			"value_log_bytes", after,
+			"duration", time.Since(start))
+	}
+	return reclaimed, err
+}
+
+// Stats returns the current disk usage of the database and the result of
+// its last collection.
+func (s *GCScheduler) Stats() GCStats {
+	lsm, vlog := s.size()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.LSMSize, stats.ValueLogSize = lsm, vlog
+	return stats
+}
+
+// size returns the bytes on disk of the LSM tree and the value log. They
+// are read from the files, because the sizes kept by BadgerDB are only
+// updated once in a while.
+func (s *GCScheduler) size() (lsm, vlog int64) {
+	opts := s.db.Opts()
+	if opts.InMemory {
+		return s.db.Size()
+	}
+	return filesSize(opts.Dir, "*.sst"), filesSize(opts.ValueDir, "*.vlog")
+}
+
+func filesSize(dir, pattern string) int64 {
+	files, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return 0
+	}
+
+	var size int64
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// StartGC starts collecting the value log garbage of the database
+// periodically with the given configuration, and returns the scheduler
+// doing it. If it was already started, the running scheduler is returned.
+func (d *Database) StartGC(config GCConfig) *GCScheduler {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.gc == nil {
+		d.gc = NewGCScheduler(d.name, d.db, config)
+		d.gc.Start()
+	}
+	return d.gc
+}
+
+// GC returns the scheduler collecting the value log garbage of the
+// database, or nil if it wasn't started.
+func (d *Database) GC() *GCScheduler {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.gc
+}
+
+// StopGC stops collecting the value log garbage of the database.
+func (d *Database) StopGC() {
+	d.mu.Lock()
+	gc := d.gc
+	d.gc = nil
+	d.mu.Unlock()
+
+	if gc != nil {
+		gc.Stop()
+	}
+}
@@ -0,0 +1,130 @@
+package badger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCScheduler_RunGC(t *testing.T) {
+	require := require.New(t)
+
+	opts := badger.DefaultOptions(t.TempDir()).
+		WithLogger(nil).
+		WithValueLogFileSize(1 << 20).
+		WithValueThreshold(64).
+		WithNumVersionsToKeep(1).
+		WithNumLevelZeroTables(1)
+	db, err := badger.Open(opts)
+	require.NoError(err)
+	defer func() { db.Close() }()
+
+	value := make([]byte, 1<<10)
+	keys := 8 << 10
+	for i := 0; i < keys; i += 256 {
+		require.NoError(db.Update(func(txn *badger.Txn) error {
+			for j := i; j < i+256; j++ {
+				if err := txn.Set([]byte(fmt.Sprintf("key-%06d", j)), value); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+	// Closing the database flushes the writes to a table of the LSM tree.
+	require.NoError(db.Close())
+	db, err = badger.Open(opts)
+	require.NoError(err)
+
+	for i := 0; i < keys; i += 256 {
+		require.NoError(db.Update(func(txn *badger.Txn) error {
+			for j := i; j < i+256; j++ {
+				if err := txn.Delete([]byte(fmt.Sprintf("key-%06d", j))); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+	// Once the deletions are in another table, compacting both finds the
+	// values that are stale.
+	require.NoError(db.Close())
+	db, err = badger.Open(opts)
+	require.NoError(err)
+	require.NoError(db.Flatten(1))
+
+	gc := NewGCScheduler("test", db, GCConfig{DiscardRatio: 0.5, Logger: discardLogger})
+	before := gc.Stats().ValueLogSize
+	reclaimed, err := gc.RunGC()
+	require.NoError(err)
+
+	stats := gc.Stats()
+	require.True(reclaimed > 0)
+	require.Equal(before-reclaimed, stats.ValueLogSize)
+	require.Equal(int64(1), stats.Runs)
+	require.Equal(reclaimed, stats.LastReclaimed)
+}
+
+func TestGCScheduler_StartStop(t *testing.T) {
+	require := require.New(t)
+
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(err)
+	defer db.Close()
+
+	gc := NewGCScheduler("test", db, GCConfig{Interval: 10 * time.Millisecond, Logger: discardLogger})
+	gc.Start()
+	require.Eventually(func() bool {
+		return gc.Stats().Runs >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	gc.Stop()
+
+	runs := gc.Stats().Runs
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(runs, gc.Stats().Runs)
+
+	// Stopping twice does nothing.
+	gc.Stop()
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestCatalog_StartGC(t *testing.T) {
+	require := require.New(t)
+
+	dir := t.TempDir()
+	catalog := NewCatalog(dir)
+	open := func(name string) *Database {
+		db, err := badger.Open(badger.DefaultOptions(dir + "/" + name).WithLogger(nil))
+		require.NoError(err)
+		return NewDatabase(name, db)
+	}
+
+	require.NoError(catalog.AddDatabase(open("db1")))
+	require.Empty(catalog.GCStats())
+
+	catalog.StartGC(GCConfig{Interval: time.Hour, Logger: discardLogger})
+	require.NoError(catalog.AddDatabase(open("db2")))
+
+	stats := catalog.GCStats()
+	require.Len(stats, 2)
+	require.Contains(stats, "db1")
+	require.Contains(stats, "db2")
+
+	db1, err := catalog.Database(nil, "db1")
+	require.NoError(err)
+	require.NoError(catalog.DropDatabase("db1"))
+	require.Nil(db1.(*Database).GC())
+	require.NoError(db1.(*Database).db.Close())
+
+	stats = catalog.GCStats()
+	require.Len(stats, 1)
+	require.Contains(stats, "db2")
+
+	require.NoError(catalog.Close())
+}
@@ -0,0 +1,858 @@
+package badger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+)
+
+// IndexDef is the definition of a secondary index of a table.
+type IndexDef = sql.IndexDef
+
+// IndexRange is a range of the values of the columns of an index. Lower and
+// Upper may have fewer values than the index has columns, in which case only
+// the first columns are bounded. A nil bound means the range is unbounded on
+// that side, which includes the NULL values when it's the lower one.
+type IndexRange struct {
+	Lower, Upper         []interface{}
+	LowerOpen, UpperOpen bool
+}
+
+// PointRange returns the range of the rows whose indexed columns are equal
+// to the given values.
+func PointRange(values ...interface{}) IndexRange {
+	return IndexRange{Lower: values, Upper: values}
+}
+
+// IndexedTable is a table with secondary indexes, which can be used to read
+// only the rows in a range of the indexed values.
+type IndexedTable interface {
+	sql.Table
+	// Indexes returns the definitions of the indexes of the table.
+	Indexes() []IndexDef
+	// IndexRows returns the rows in the given range of an index, sorted by
+	// the indexed values.
+	IndexRows(ctx *sql.Context, index string, r IndexRange) (sql.RowIter, error)
+}
+
+var (
+	_ IndexedTable       = (*Table)(nil)
+	_ sql.FilteredTable  = (*Table)(nil)
+	_ sql.OrderedTable   = (*Table)(nil)
+	_ sql.IndexAlterable = (*Database)(nil)
+)
+
+// tableIndexes are the secondary indexes of a table. They are shared by the
+// copies of the table made when filters are pushed down to it.
+type tableIndexes struct {
+	mu   sync.RWMutex
+	defs []IndexDef
+	// columns are the positions in the schema of the columns of each index.
+	columns [][]int
+}
+
+func (ti *tableIndexes) get() ([]IndexDef, [][]int) {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+	return ti.defs, ti.columns
+}
+
+func (ti *tableIndexes) set(schema sql.Schema, defs []IndexDef) error {
+	columns := make([][]int, len(defs))
+	for i, def := range defs {
+		for _, name := range def.Columns {
+			idx := indexOfColumn(schema, name)
+			if idx < 0 {
+				return fmt.Errorf("index %s: column %s not found", def.Name, name)
+			}
+			columns[i] = append(columns[i], idx)
+		}
+	}
+
+	ti.mu.Lock()
+	ti.defs, ti.columns = defs, columns
+	ti.mu.Unlock()
+	return nil
+}
+
+func indexOfColumn(schema sql.Schema, name string) int {
+	for i, col := range schema {
+		if strings.EqualFold(col.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Tags of the encoded index values. Values of different types are sorted by
+// their tag, and NULL is sorted before anything else.
+const (
+	indexTagNull byte = iota + 1
+	indexTagBool
+	indexTagInt
+	// indexTagUint is only used for values that don't fit in an int64.
+	indexTagUint
+	indexTagFloat
+	indexTagTime
+	indexTagBytes
+)
+
+// encodeIndexValue appends the value to buf in an encoding that sorts the
+// same as the values. Strings are escaped and terminated so that a tuple of
+// values can be followed by more bytes without changing its order.
+func encodeIndexValue(buf *bytes.Buffer, v interface{}) error {
+	var b [8]byte
+	putInt := func(tag byte, i int64) {
+		buf.WriteByte(tag)
+		binary.BigEndian.PutUint64(b[:], uint64(i)^(1<<63))
+		buf.Write(b[:])
+	}
+
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(indexTagNull)
+	case bool:
+		buf.WriteByte(indexTagBool)
+		if v {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case int:
+		putInt(indexTagInt, int64(v))
+	case int8:
+		putInt(indexTagInt, int64(v))
+	case int16:
+		putInt(indexTagInt, int64(v))
+	case int32:
+		putInt(indexTagInt, int64(v))
+	case int64:
+		putInt(indexTagInt, v)
+	case uint:
+		return encodeIndexValue(buf, uint64(v))
+	case uint8:
+		putInt(indexTagInt, int64(v))
+	case uint16:
+		putInt(indexTagInt, int64(v))
+	case uint32:
+		putInt(indexTagInt, int64(v))
+	case uint64:
+		if v <= math.MaxInt64 {
+			putInt(indexTagInt, int64(v))
+			break
+		}
+		buf.WriteByte(indexTagUint)
+		binary.BigEndian.PutUint64(b[:], v)
+		buf.Write(b[:])
+	case float32:
+		return encodeIndexValue(buf, float64(v))
+	case float64:
+		bits := math.Float64bits(v)
+		if v >= 0 {
+			bits ^= 1 << 63
+		} else {
+			bits = ^bits
+		}
+		buf.WriteByte(indexTagFloat)
+		binary.BigEndian.PutUint64(b[:], bits)
+		buf.Write(b[:])
+	case time.Time:
+		putInt(indexTagTime, v.UnixNano())
+	case string:
+		encodeIndexBytes(buf, []byte(v))
+	case []byte:
+		encodeIndexBytes(buf, v)
+	default:
+		return fmt.Errorf("values of type %T can't be indexed", v)
+	}
+
+	return nil
+}
+
+func encodeIndexBytes(buf *bytes.Buffer, v []byte) {
+	buf.WriteByte(indexTagBytes)
+	for _, c := range v {
+		buf.WriteByte(c)
+		if c == 0 {
+			buf.WriteByte(0xff)
+		}
+	}
+	buf.Write([]byte{0, 1})
+}
+
+// indexKeyPrefix returns the key of the index entries whose first values
+// are the given ones.
+func (t *Table) indexKeyPrefix(index string, values []interface{}) ([]byte, error) {
+	defs, columns := t.indexes.get()
+	for i, def := range defs {
+		if def.Name == index {
+			values = collationKeys(t.schema, columns[i], values)
+			break
+		}
+	}
+
+	buf := bytes.NewBuffer(EncodeIndexPrefix(t.dbName, t.storageName(), index))
+	for _, v := range values {
+		if err := encodeIndexValue(buf, v); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// indexEntries returns the keys of the entries of the row in all the indexes
+// of the table, which end with the primary key of the row.
+func (t *Table) indexEntries(row sql.Row, rowKey []byte) ([][]byte, error) {
+	defs, columns := t.indexes.get()
+	if len(defs) == 0 {
+		return nil, nil
+	}
+
+	pk := rowKey[len(EncodeTablePrefix(t.dbName, t.storageName())):]
+	entries := make([][]byte, len(defs))
+	for i, def := range defs {
+		values := make([]interface{}, len(columns[i]))
+		for j, col := range columns[i] {
+			if col < len(row) {
+				values[j] = row[col]
+			}
+		}
+
+		key, err := t.indexKeyPrefix(def.Name, values)
+		if err != nil {
+			return nil, fmt.Errorf("index %s: %w", def.Name, err)
+		}
+		entries[i] = append(key, pk...)
+	}
+
+	return entries, nil
+}
+
+// checkUnique returns an error if another row has the values of the row in
+// the columns of a unique index of the table. entries are the index entries
+// of the row, and old the ones of the row it replaces, which don't conflict
+// with it. Rows with NULL in any of the columns never conflict.
+func (t *Table) checkUnique(w kvWriter, row sql.Row, rowKey []byte, entries, old [][]byte) error {
+	defs, columns := t.indexes.get()
+	pkLen := len(rowKey) - len(EncodeTablePrefix(t.dbName, t.storageName()))
+	for i, def := range defs {
+		if !def.Unique || i >= len(entries) || hasNull(row, columns[i]) {
+			continue
+		}
+
+		txn, err := badgerTxn(w)
+		if err != nil {
+			return err
+		}
+
+		// The values are encoded so that no entry with other values starts
+		// with them, so the entries with this prefix have the same values.
+		prefix := entries[i][:len(entries[i])-pkLen]
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		conflict := false
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			key := it.Item().Key()
+			if !bytes.Equal(key, entries[i]) && (i >= len(old) || !bytes.Equal(key, old[i])) {
+				conflict = true
+				break
+			}
+		}
+		it.Close()
+
+		if conflict {
+			return duplicateUniqueKeyError(row, columns[i], def.Name)
+		}
+	}
+
+	return nil
+}
+
+func hasNull(row sql.Row, cols []int) bool {
+	for _, c := range cols {
+		if c >= len(row) || row[c] == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Indexes implements the IndexedTable interface.
+func (t *Table) Indexes() []IndexDef {
+	defs, _ := t.indexes.get()
+	return append([]IndexDef(nil), defs...)
+}
+
+// IndexRows implements the IndexedTable interface. The filters pushed down
+// to the table are applied to the rows.
+func (t *Table) IndexRows(ctx *sql.Context, index string, r IndexRange) (sql.RowIter, error) {
+	return t.indexRows(ctx, index, r, false)
+}
+
+// indexRows returns the rows in a range of an index, sorted by the indexed
+// values in descending order if reverse is true.
+func (t *Table) indexRows(ctx *sql.Context, index string, r IndexRange, reverse bool) (sql.RowIter, error) {
+	found := false
+	for _, def := range t.Indexes() {
+		if strings.EqualFold(def.Name, index) {
+			index, found = def.Name, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("index %s not found in table %s", index, t.name)
+	}
+
+	prefix := EncodeIndexPrefix(t.dbName, t.storageName(), index)
+	lower, err := t.indexKeyPrefix(index, r.Lower)
+	if err != nil {
+		return nil, err
+	}
+
+	var upper []byte
+	if r.Upper != nil {
+		if upper, err = t.indexKeyPrefix(index, r.Upper); err != nil {
+			return nil, err
+		}
+	}
+
+	// The entries of a point range all start with the same values, so the
+	// iteration stops right after them instead of reading the next one.
+	if upper != nil && !r.LowerOpen && !r.UpperOpen && bytes.Equal(lower, upper) {
+		prefix, upper = lower, nil
+	}
+
+	txn, release := t.readTxn(ctx)
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	opts.Reverse = reverse
+	iter := txn.NewIterator(opts)
+
+	i := &indexRowIter{
+		ctx:       ctx,
+		table:     t,
+		txn:       txn,
+		release:   release,
+		iter:      iter,
+		prefix:    prefix,
+		upper:     upper,
+		upperOpen: r.UpperOpen,
+		reverse:   reverse,
+		filters:   t.filters,
+	}
+
+	if reverse {
+		// A reverse iteration starts at the last key before the given one,
+		// so it's given the first key after all the entries in the range.
+		start := prefix
+		if upper != nil {
+			start = upper
+		}
+		iter.Seek(prefixEnd(start))
+		if r.Lower != nil {
+			i.lower, i.lowerOpen = lower, r.LowerOpen
+		}
+		return i, nil
+	}
+
+	iter.Seek(lower)
+	if r.LowerOpen && r.Lower != nil {
+		i.skip = lower
+	}
+	return i, nil
+}
+
+// prefixEnd returns the first key that is after all the keys starting with
+// the prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		end[i]++
+		if end[i] != 0 {
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// indexRowIter reads the rows of the entries of an index in a range.
+type indexRowIter struct {
+	ctx   *sql.Context
+	table *Table
+	txn   *badger.Txn
+	// release releases txn.
+	release func()
+	iter    *badger.Iterator
+	prefix  []byte
+	// skip is the prefix of the entries at the start of the range that
+	// are not part of it, when its lower bound is open.
+	skip      []byte
+	upper     []byte
+	upperOpen bool
+	// reverse is true if the entries are read in descending order, in
+	// which case the iteration ends at the lower bound of the range.
+	reverse   bool
+	lower     []byte
+	lowerOpen bool
+	filters   []sql.Expression
+	// scanned is the number of index entries read.
+	scanned int
+}
+
+func (i *indexRowIter) Next() (sql.Row, error) {
+	for ; i.iter.ValidForPrefix(i.prefix); i.iter.Next() {
+		if err := i.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		item := i.iter.Item()
+		key := item.Key()
+		i.scanned++
+
+		if i.skip != nil {
+			if bytes.HasPrefix(key, i.skip) {
+				continue
+			}
+			i.skip = nil
+		}
+
+		if i.reverse {
+			if i.belowLower(key) {
+				return nil, io.EOF
+			}
+			if i.aboveUpper(key) {
+				continue
+			}
+		} else if i.aboveUpper(key) {
+			return nil, io.EOF
+		}
+
+		rowKey, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		rowItem, err := i.txn.Get(rowKey)
+		if err != nil {
+			return nil, err
+		}
+
+		var row sql.Row
+		err = rowItem.Value(func(val []byte) error {
+			row, err = i.table.decodeRow(val)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := evalFilters(i.ctx, i.filters, row)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		row, ok, err = lockRow(i.ctx, i.table, rowKey, row, i.filters)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			i.iter.Next()
+			return row, nil
+		}
+	}
+
+	return nil, io.EOF
+}
+
+// aboveUpper returns whether the entry is after the upper bound of the range.
+func (i *indexRowIter) aboveUpper(key []byte) bool {
+	if i.upper == nil {
+		return false
+	}
+	cmp := bytes.Compare(key, i.upper)
+	return (i.upperOpen && cmp >= 0) || (!i.upperOpen && cmp > 0 && !bytes.HasPrefix(key, i.upper))
+}
+
+// belowLower returns whether the entry is before the lower bound of the
+// range.
+func (i *indexRowIter) belowLower(key []byte) bool {
+	if i.lower == nil {
+		return false
+	}
+	return bytes.Compare(key, i.lower) < 0 || (i.lowerOpen && bytes.HasPrefix(key, i.lower))
+}
+
+func (i *indexRowIter) Close() error {
+	i.iter.Close()
+	i.release()
+	return nil
+}
+
+func evalFilters(ctx *sql.Context, filters []sql.Expression, row sql.Row) (bool, error) {
+	for _, f := range filters {
+		result, err := f.Eval(ctx, row)
+		if err != nil {
+			return false, err
+		}
+		if result != true {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// HandledFilters implements the sql.FilteredTable interface. All the
+// filters that only use columns of the table are handled, and the ones that
+// compare the first column of an index with a value, or its first columns
+// with a list of values, are used to read only the rows in that range of the
+// index.
+func (t *Table) HandledFilters(filters []sql.Expression) []sql.Expression {
+	var handled []sql.Expression
+	for _, f := range filters {
+		var hasOtherFields bool
+		_, _ = f.TransformUp(func(e sql.Expression) (sql.Expression, error) {
+			if e, ok := e.(*expression.GetField); ok {
+				if e.Table() != t.name || !t.schema.Contains(e.Name(), t.name) {
+					hasOtherFields = true
+				}
+			}
+			return e, nil
+		})
+
+		if !hasOtherFields {
+			handled = append(handled, f)
+		}
+	}
+	return handled
+}
+
+// WithFilters implements the sql.FilteredTable interface.
+func (t *Table) WithFilters(filters []sql.Expression) sql.Table {
+	if len(filters) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.filters = filters
+	return &nt
+}
+
+// Filters implements the sql.FilteredTable interface.
+func (t *Table) Filters() []sql.Expression {
+	return t.filters
+}
+
+// Statistics implements the sql.StatisticsTable interface.
+func (t *Table) Statistics() *sql.TableStatistics {
+	return t.stats
+}
+
+// WithStatistics implements the sql.StatisticsTable interface.
+func (t *Table) WithStatistics(stats *sql.TableStatistics) sql.Table {
+	nt := *t
+	nt.stats = stats
+	return &nt
+}
+
+// indexOrder is the index the rows of a table are read from to be sorted.
+type indexOrder struct {
+	index string
+	desc  bool
+}
+
+// WithOrder implements the sql.OrderedTable interface. The rows can be
+// sorted by the first column of an index, unless the filters of the table
+// read a range of another index, which would be replaced by a full scan.
+func (t *Table) WithOrder(column string, desc bool) (sql.Table, bool) {
+	col := indexOfColumn(t.schema, column)
+	if col < 0 || !sortedByIndex(t.schema[col].Type) {
+		return nil, false
+	}
+
+	fr, filtered := t.indexRangeFromFilters()
+	defs, columns := t.indexes.get()
+	for i, def := range defs {
+		if columns[i][0] != col || (filtered && fr.index != def.Name) {
+			continue
+		}
+
+		nt := *t
+		nt.order = &indexOrder{index: def.Name, desc: desc}
+		return &nt, true
+	}
+	return nil, false
+}
+
+// sortedByIndex returns whether the values of the type are sorted in the
+// indexes as they are compared, which isn't the case of JSON documents.
+func sortedByIndex(t sql.Type) bool {
+	return sql.IsNumber(t) || (sql.IsText(t) && t != sql.JSON) ||
+		t == sql.Timestamp || t == sql.Date
+}
+
+// Access implements the sql.AccessDescriber interface. Reading all the
+// entries of an index to sort the rows is an index scan.
+func (t *Table) Access() (string, string) {
+	fr, ok := t.indexRangeFromFilters()
+	switch {
+	case t.order != nil && !ok:
+		return "index", t.order.index
+	case !ok:
+		return "ALL", ""
+	case fr.point:
+		return "ref", fr.index
+	default:
+		return "range", fr.index
+	}
+}
+
+// filterRange is the range of an index given by the filters of a table.
+type filterRange struct {
+	index string
+	r     IndexRange
+	// point is true if the range only has the rows with a value.
+	point bool
+}
+
+// indexRangeFromFilters returns the range of an index with the rows that
+// match the filters of the table, or false if no index can be used for
+// them. Equality is preferred over ranges bounded on both sides, and those
+// over ranges bounded only on one side. Among the indexes used alike, the
+// one whose column has the most distinct values is chosen if the table has
+// statistics, as it reads the fewest rows.
+func (t *Table) indexRangeFromFilters() (filterRange, bool) {
+	defs, columns := t.indexes.get()
+	if len(defs) == 0 || len(t.filters) == 0 {
+		return filterRange{}, false
+	}
+
+	var best filterRange
+	bestScore, bestNDV := 0, uint64(0)
+	for i, def := range defs {
+		col := columns[i][0]
+
+		var fr filterRange
+		var in IndexRange
+		fr.index = def.Name
+		for _, f := range t.filters {
+			if r, ok := t.inRange(f, columns[i]); ok {
+				in = r
+				continue
+			}
+			op, value, ok := t.columnComparison(f, col)
+			if !ok {
+				continue
+			}
+
+			switch op {
+			case "=":
+				fr.r, fr.point = PointRange(value), true
+			case ">", ">=":
+				if !fr.point {
+					fr.r.Lower, fr.r.LowerOpen = []interface{}{value}, op == ">"
+				}
+			case "<", "<=":
+				if !fr.point {
+					fr.r.Upper, fr.r.UpperOpen = []interface{}{value}, op == "<"
+				}
+			}
+		}
+
+		// The comparisons of the first column are preferred to an IN
+		// filter, which only bounds its range.
+		if in.Lower != nil && fr.r.Lower == nil && fr.r.Upper == nil && !fr.point {
+			fr.r = in
+			fr.point = t.compareIndexValues(columns[i], in.Lower, in.Upper) == 0
+		}
+
+		score := 0
+		switch {
+		case fr.point:
+			score = 3
+		case fr.r.Lower != nil && fr.r.Upper != nil:
+			score = 2
+		case fr.r.Lower != nil || fr.r.Upper != nil:
+			score = 1
+		}
+		var ndv uint64
+		if cs := t.stats.Column(t.schema[col].Name); cs != nil {
+			ndv = cs.NDV
+		}
+		if score > bestScore || (score == bestScore && score > 0 && ndv > bestNDV) {
+			best, bestScore, bestNDV = fr, score, ndv
+		}
+	}
+
+	return best, bestScore > 0
+}
+
+// columnComparison returns the operator and the value of a filter that
+// compares the column at position col with a literal, with the column on
+// the left.
+func (t *Table) columnComparison(f sql.Expression, col int) (string, interface{}, bool) {
+	c, ok := f.(expression.Comparer)
+	if !ok {
+		return "", nil, false
+	}
+
+	var op string
+	switch f.(type) {
+	case *expression.Equals:
+		op = "="
+	case *expression.GreaterThan:
+		op = ">"
+	case *expression.GreaterThanOrEqual:
+		op = ">="
+	case *expression.LessThan:
+		op = "<"
+	case *expression.LessThanOrEqual:
+		op = "<="
+	default:
+		return "", nil, false
+	}
+
+	field, fok := c.Left().(*expression.GetField)
+	lit, lok := c.Right().(*expression.Literal)
+	if !fok || !lok {
+		// The column may be on the right, which flips the comparison.
+		field, fok = c.Right().(*expression.GetField)
+		lit, lok = c.Left().(*expression.Literal)
+		if !fok || !lok {
+			return "", nil, false
+		}
+		op = strings.NewReplacer(">", "<", "<", ">").Replace(op)
+	}
+
+	if lit.Value() == nil || indexOfColumn(t.schema, field.Name()) != col {
+		return "", nil, false
+	}
+
+	v, ok := t.indexValue(col, lit)
+	return op, v, ok
+}
+
+// indexValue returns the value a literal compared with the column at
+// position col is looked up by in the indexes, or false if it can't be.
+// The values in the index are compared as they are stored, so the literal
+// must be of the type of the column for the range to have the same rows the
+// comparison matches.
+func (t *Table) indexValue(col int, lit *expression.Literal) (interface{}, bool) {
+	colType := t.schema[col].Type
+	if colType == sql.UUID && sql.IsText(lit.Type()) {
+		// UUIDs are looked up by the bytes of their text.
+		v, err := sql.UUID.Convert(lit.Value())
+		if err != nil {
+			return nil, false
+		}
+		return v, true
+	}
+	if colType != lit.Type() && !(sql.IsInteger(colType) && sql.IsInteger(lit.Type())) {
+		return nil, false
+	}
+	return lit.Value(), true
+}
+
+// inRange returns the range of an index with the rows an IN filter may
+// match, which goes from the smallest to the largest of the values in its
+// list. The filter must compare the first columns of the index, in any
+// order, with literals, so (a, b) IN ((1, 2), (3, 4)) reads the entries
+// from (1, 2) to (3, 4) of an index on (a, b), and those from 1 to 3 of an
+// index on (a, c). The filter is still applied to the rows of the range.
+func (t *Table) inRange(f sql.Expression, cols []int) (IndexRange, bool) {
+	in, ok := f.(*expression.In)
+	if !ok {
+		return IndexRange{}, false
+	}
+	list, ok := in.Right().(expression.Tuple)
+	if !ok {
+		return IndexRange{}, false
+	}
+	fields := []sql.Expression{in.Left()}
+	if tuple, ok := in.Left().(expression.Tuple); ok {
+		fields = tuple
+	}
+
+	// positions are those in the tuples of the first columns of the index.
+	var positions []int
+	for _, col := range cols {
+		pos := -1
+		for i, e := range fields {
+			if field, ok := e.(*expression.GetField); ok && indexOfColumn(t.schema, field.Name()) == col {
+				pos = i
+				break
+			}
+		}
+		if pos < 0 || !sortedByIndex(t.schema[col].Type) {
+			break
+		}
+		positions = append(positions, pos)
+	}
+	if len(positions) == 0 {
+		return IndexRange{}, false
+	}
+
+	var r IndexRange
+	for _, e := range list {
+		values := []sql.Expression{e}
+		if tuple, ok := e.(expression.Tuple); ok {
+			values = tuple
+		}
+		if len(values) != len(fields) {
+			return IndexRange{}, false
+		}
+
+		key := make([]interface{}, 0, len(positions))
+		for i, pos := range positions {
+			lit, ok := values[pos].(*expression.Literal)
+			if !ok {
+				return IndexRange{}, false
+			}
+			if lit.Value() == nil {
+				// Values compared with NULL are never matched.
+				key = nil
+				break
+			}
+			v, ok := t.indexValue(cols[i], lit)
+			if !ok {
+				return IndexRange{}, false
+			}
+			key = append(key, v)
+		}
+		if key == nil {
+			continue
+		}
+
+		if r.Lower == nil || t.compareIndexValues(cols, key, r.Lower) < 0 {
+			r.Lower = key
+		}
+		if r.Upper == nil || t.compareIndexValues(cols, key, r.Upper) > 0 {
+			r.Upper = key
+		}
+	}
+	return r, r.Lower != nil
+}
+
+// compareIndexValues compares the values of the given columns of two
+// entries of an index, in the order of the index.
+func (t *Table) compareIndexValues(cols []int, a, b []interface{}) int {
+	for i := range a {
+		if cmp, err := t.schema[cols[i]].Type.Compare(a[i], b[i]); err == nil && cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
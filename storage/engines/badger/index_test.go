@@ -0,0 +1,46 @@
+package badger
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeIndexValueOrder(t *testing.T) {
+	// Each value must be encoded before the ones after it.
+	values := []interface{}{
+		nil,
+		false,
+		true,
+		int64(math.MinInt64),
+		int32(-5),
+		int8(0),
+		uint16(7),
+		int64(math.MaxInt64),
+		uint64(math.MaxUint64),
+		math.Inf(-1),
+		-1.5,
+		float32(0),
+		2.25,
+		"",
+		"a",
+		"a\x00",
+		"a\x00b",
+		"ab",
+		[]byte("b"),
+	}
+
+	var prev []byte
+	for i, v := range values {
+		var buf bytes.Buffer
+		require.NoError(t, encodeIndexValue(&buf, v))
+		if i > 0 {
+			require.Equal(t, -1, bytes.Compare(prev, buf.Bytes()), "%v < %v", values[i-1], v)
+		}
+		prev = buf.Bytes()
+	}
+
+	require.Error(t, encodeIndexValue(new(bytes.Buffer), struct{}{}))
+}
@@ -1,15 +1,21 @@
 package badger
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	"github.com/turtacn/guocedb/compute/transaction"
 )
 
 func TestStorageRoundTrip(t *testing.T) {
@@ -360,3 +366,793 @@ func TestTableInserterUpdaterDeleter(t *testing.T) {
 
 	assert.Equal(t, 2, finalCount)
 }
+
+func TestXAPrepareSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	xid := transaction.XID{GTRID: "trx1", FormatID: 1}
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "items"},
+		{Name: "value", Type: sql.Int64, Source: "items"},
+	}
+
+	// Phase 1: Insert rows in a XA transaction and prepare it
+	{
+		db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+		require.NoError(t, err)
+
+		database := NewDatabase("mydb", db)
+		require.NoError(t, database.Create("items", schema))
+
+		mgr := transaction.NewManagerWithDB(db)
+		txn, err := mgr.XAStart(xid, nil)
+		require.NoError(t, err)
+
+		ctx := sql.NewEmptyContext()
+		ctx.SetTransaction(txn)
+		table, _, err := database.GetTableInsensitive(ctx, "items")
+		require.NoError(t, err)
+
+		inserter := table.(InsertableTable).Inserter(ctx)
+		inserter.StatementBegin(ctx)
+		require.NoError(t, inserter.Insert(ctx, sql.NewRow(int64(1), int64(100))))
+		require.NoError(t, inserter.Insert(ctx, sql.NewRow(int64(2), int64(200))))
+		require.NoError(t, inserter.StatementComplete(ctx))
+		require.NoError(t, inserter.Close(ctx))
+
+		require.NoError(t, mgr.XAEnd(xid))
+		require.NoError(t, mgr.XAPrepare(xid))
+		require.NoError(t, db.Close())
+	}
+
+	// Phase 2: Commit the prepared transaction after the restart
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	database := NewDatabase("mydb", db)
+	ctx := sql.NewEmptyContext()
+	table, ok, err := database.GetTableInsensitive(ctx, "items")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Empty(t, tableRows(t, ctx, table))
+
+	mgr := transaction.NewManagerWithDB(db)
+	xids, err := mgr.XARecover()
+	require.NoError(t, err)
+	require.Equal(t, []transaction.XID{xid}, xids)
+
+	require.NoError(t, mgr.XACommit(xid, false))
+	require.Equal(t, transaction.ErrXANotFound, mgr.XACommit(xid, false))
+
+	require.ElementsMatch(t, []sql.Row{
+		{int64(1), int64(100)},
+		{int64(2), int64(200)},
+	}, tableRows(t, ctx, table))
+}
+
+func tableRows(t *testing.T, ctx *sql.Context, table sql.Table) []sql.Row {
+	partIter, err := table.Partitions(ctx)
+	require.NoError(t, err)
+	defer partIter.Close()
+
+	var rows []sql.Row
+	for {
+		part, err := partIter.Next()
+		if err == io.EOF {
+			return rows
+		}
+		require.NoError(t, err)
+
+		rowIter, err := table.PartitionRows(ctx, part)
+		require.NoError(t, err)
+		partRows, err := sql.RowIterToRows(rowIter)
+		require.NoError(t, err)
+		rows = append(rows, partRows...)
+	}
+}
+
+// indexLookup reads the rows in a range of an index and returns them along
+// with the number of index entries that were read.
+func indexLookup(t *testing.T, ctx *sql.Context, table *Table, index string, r IndexRange) ([]sql.Row, int) {
+	iter, err := table.IndexRows(ctx, index, r)
+	require.NoError(t, err)
+
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	return rows, iter.(*indexRowIter).scanned
+}
+
+func TestSecondaryIndex(t *testing.T) {
+	dir := t.TempDir()
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+
+	database := NewDatabase("mydb", db)
+	ctx := sql.NewEmptyContext()
+
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "users"},
+		{Name: "age", Type: sql.Int64, Source: "users"},
+		{Name: "name", Type: sql.Text, Source: "users", Nullable: true},
+	}
+	require.NoError(t, database.Create("users", schema))
+
+	table, ok, err := database.GetTableInsensitive(ctx, "users")
+	require.NoError(t, err)
+	require.True(t, ok)
+	users := table.(*Table)
+
+	// The rows that exist when the index is created are added to it.
+	require.NoError(t, users.Insert(ctx, sql.NewRow(int64(0), int64(99), "first")))
+	require.NoError(t, database.CreateIndex("users", "idx_age", []string{"age"}))
+	require.Error(t, database.CreateIndex("users", "IDX_AGE", []string{"age"}))
+	require.Error(t, database.CreateIndex("users", "idx_missing", []string{"missing"}))
+
+	inserter := users.Inserter(ctx)
+	inserter.StatementBegin(ctx)
+	for i := int64(1); i <= 1000; i++ {
+		require.NoError(t, inserter.Insert(ctx, sql.NewRow(i, i%100, "user")))
+	}
+	require.NoError(t, inserter.StatementComplete(ctx))
+	require.NoError(t, inserter.Close(ctx))
+
+	rows, scanned := indexLookup(t, ctx, users, "idx_age", PointRange(int64(42)))
+	require.Len(t, rows, 10)
+	require.Equal(t, 10, scanned)
+	for _, row := range rows {
+		require.Equal(t, int64(42), row[1])
+	}
+
+	rows, scanned = indexLookup(t, ctx, users, "idx_age", PointRange(int64(99)))
+	require.Len(t, rows, 11)
+	require.Equal(t, 11, scanned)
+
+	// 10 <= age < 13
+	rows, scanned = indexLookup(t, ctx, users, "idx_age", IndexRange{
+		Lower: []interface{}{int64(10)}, Upper: []interface{}{int64(13)}, UpperOpen: true,
+	})
+	require.Len(t, rows, 30)
+	// The first entry after the range is read to know where it ends.
+	require.Equal(t, 31, scanned)
+	require.Equal(t, int64(10), rows[0][1])
+	require.Equal(t, int64(12), rows[29][1])
+
+	// Changing the indexed column moves the entry of the row.
+	require.NoError(t, users.Update(ctx, sql.NewRow(int64(42), int64(42), "user"), sql.NewRow(int64(42), int64(1042), "user")))
+	rows, _ = indexLookup(t, ctx, users, "idx_age", PointRange(int64(42)))
+	require.Len(t, rows, 9)
+	rows, _ = indexLookup(t, ctx, users, "idx_age", PointRange(int64(1042)))
+	require.Equal(t, []sql.Row{{int64(42), int64(1042), "user"}}, rows)
+
+	// Replacing a row by its primary key also replaces its entry.
+	require.NoError(t, users.Insert(ctx, sql.NewRow(int64(142), int64(7), "user")))
+	rows, _ = indexLookup(t, ctx, users, "idx_age", PointRange(int64(42)))
+	require.Len(t, rows, 8)
+
+	// Deleted rows are removed from the index.
+	require.NoError(t, users.Delete(ctx, sql.NewRow(int64(242), int64(42), "user")))
+	rows, scanned = indexLookup(t, ctx, users, "idx_age", PointRange(int64(42)))
+	require.Len(t, rows, 7)
+	require.Equal(t, 7, scanned)
+
+	// Filters pushed down to the table use the index.
+	filtered := users.WithFilters([]sql.Expression{
+		expression.NewEquals(
+			expression.NewGetFieldWithTable(1, sql.Int64, "users", "age", false),
+			expression.NewLiteral(int64(42), sql.Int64),
+		),
+		expression.NewGreaterThan(
+			expression.NewGetFieldWithTable(0, sql.Int64, "users", "id", false),
+			expression.NewLiteral(int64(500), sql.Int64),
+		),
+	}).(*Table)
+	iter, err := filtered.PartitionRows(ctx, &Partition{key: []byte("users")})
+	require.NoError(t, err)
+	rows, err = sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+	require.Equal(t, 7, iter.(*indexRowIter).scanned)
+
+	// Indexes are loaded again after a restart.
+	require.NoError(t, db.Close())
+	db, err = badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	database = NewDatabase("mydb", db)
+	table, _, err = database.GetTableInsensitive(ctx, "users")
+	require.NoError(t, err)
+	users = table.(*Table)
+	require.Equal(t, []IndexDef{{Name: "idx_age", Columns: []string{"age"}}}, users.Indexes())
+
+	rows, _ = indexLookup(t, ctx, users, "idx_age", PointRange(int64(42)))
+	require.Len(t, rows, 7)
+
+	require.NoError(t, database.DropIndex("users", "idx_age"))
+	require.Empty(t, users.Indexes())
+	_, err = users.IndexRows(ctx, "idx_age", PointRange(int64(42)))
+	require.Error(t, err)
+
+	err = db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = EncodeTableIndexesPrefix("mydb", "users")
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		it.Rewind()
+		require.False(t, it.Valid())
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestSecondaryIndexTransaction(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	database := NewDatabase("mydb", db)
+	require.NoError(t, database.Create("t", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "t"},
+		{Name: "name", Type: sql.Text, Source: "t"},
+	}))
+	require.NoError(t, database.CreateIndex("t", "idx_name", []string{"name"}))
+
+	table, _, err := database.GetTableInsensitive(sql.NewEmptyContext(), "t")
+	require.NoError(t, err)
+	tbl := table.(*Table)
+
+	// The index entries are written in the transaction of the session, so
+	// they are discarded along with the rows when it's rolled back.
+	mgr := transaction.NewManagerWithDB(db)
+	txn, err := mgr.Begin(nil)
+	require.NoError(t, err)
+
+	ctx := sql.NewEmptyContext()
+	ctx.SetTransaction(txn)
+	require.NoError(t, tbl.Insert(ctx, sql.NewRow(int64(1), "a")))
+	require.NoError(t, mgr.Rollback(txn))
+
+	rows, scanned := indexLookup(t, sql.NewEmptyContext(), tbl, "idx_name", PointRange("a"))
+	require.Empty(t, rows)
+	require.Zero(t, scanned)
+}
+
+func TestUniqueIndex(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	database := NewDatabase("mydb", db)
+	ctx := sql.NewEmptyContext()
+	require.NoError(t, database.Create("users", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "users", PrimaryKey: true},
+		{Name: "email", Type: sql.Text, Source: "users", Nullable: true},
+	}))
+
+	table, _, err := database.GetTableInsensitive(ctx, "users")
+	require.NoError(t, err)
+	users := table.(*Table)
+	require.NoError(t, users.Insert(ctx, sql.NewRow(int64(1), "a@example.com")))
+	require.NoError(t, users.Insert(ctx, sql.NewRow(int64(2), "a@example.com")))
+
+	// The rows the table already has can't have duplicate values.
+	unique := IndexDef{Name: "email", Columns: []string{"email"}, Unique: true}
+	err = database.AddIndex("users", unique)
+	require.True(t, sql.IsKind(sql.ErrDuplicateUniqueKey, err), err)
+	require.Empty(t, users.Indexes())
+
+	require.NoError(t, users.Delete(ctx, sql.NewRow(int64(2), "a@example.com")))
+	require.NoError(t, database.AddIndex("users", unique))
+
+	err = users.Insert(ctx, sql.NewRow(int64(2), "a@example.com"))
+	require.True(t, sql.IsKind(sql.ErrDuplicateUniqueKey, err), err)
+	require.EqualError(t, err, "Duplicate entry 'a@example.com' for key 'email'")
+
+	// NULL values never conflict, and a row keeps its own values.
+	require.NoError(t, users.Insert(ctx, sql.NewRow(int64(2), nil)))
+	require.NoError(t, users.Insert(ctx, sql.NewRow(int64(3), nil)))
+	require.NoError(t, users.Update(ctx, sql.NewRow(int64(1), "a@example.com"), sql.NewRow(int64(1), "a@example.com")))
+	require.NoError(t, users.Update(ctx, sql.NewRow(int64(1), "a@example.com"), sql.NewRow(int64(4), "a@example.com")))
+
+	err = users.Update(ctx, sql.NewRow(int64(2), nil), sql.NewRow(int64(2), "a@example.com"))
+	require.True(t, sql.IsKind(sql.ErrDuplicateUniqueKey, err), err)
+
+	// The index is unique once the database is opened again.
+	reopened, _, err := NewDatabase("mydb", db).GetTableInsensitive(ctx, "users")
+	require.NoError(t, err)
+	require.Equal(t, []IndexDef{unique}, reopened.(*Table).Indexes())
+	err = reopened.(*Table).Insert(ctx, sql.NewRow(int64(5), "a@example.com"))
+	require.True(t, sql.IsKind(sql.ErrDuplicateUniqueKey, err), err)
+}
+
+func TestIndexOrder(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	database := NewDatabase("mydb", db)
+	ctx := sql.NewEmptyContext()
+	require.NoError(t, database.Create("scores", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "scores"},
+		{Name: "score", Type: sql.Int64, Source: "scores", Nullable: true},
+		{Name: "doc", Type: sql.JSON, Source: "scores", Nullable: true},
+	}))
+	require.NoError(t, database.CreateIndex("scores", "idx_score", []string{"score"}))
+	require.NoError(t, database.CreateIndex("scores", "idx_doc", []string{"doc"}))
+
+	table, _, err := database.GetTableInsensitive(ctx, "scores")
+	require.NoError(t, err)
+	scores := table.(*Table)
+
+	require.NoError(t, scores.Insert(ctx, sql.NewRow(int64(0), nil, nil)))
+	for i := int64(1); i <= 100; i++ {
+		require.NoError(t, scores.Insert(ctx, sql.NewRow(i, (i*37)%100, nil)))
+	}
+
+	read := func(tbl sql.Table, n int) ([]interface{}, int) {
+		iter, err := tbl.PartitionRows(ctx, &Partition{key: []byte("scores")})
+		require.NoError(t, err)
+		defer iter.Close()
+
+		var values []interface{}
+		for len(values) < n {
+			row, err := iter.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			values = append(values, row[1])
+		}
+		return values, iter.(*indexRowIter).scanned
+	}
+
+	// Only the entries of the rows returned are read.
+	asc, ok := scores.WithOrder("SCORE", false)
+	require.True(t, ok)
+	values, scanned := read(asc, 4)
+	require.Equal(t, []interface{}{nil, int64(0), int64(1), int64(2)}, values)
+	require.Equal(t, 4, scanned)
+	access, index := asc.(*Table).Access()
+	require.Equal(t, "index", access)
+	require.Equal(t, "idx_score", index)
+
+	desc, ok := scores.WithOrder("score", true)
+	require.True(t, ok)
+	values, scanned = read(desc, 3)
+	require.Equal(t, []interface{}{int64(99), int64(98), int64(97)}, values)
+	require.Equal(t, 3, scanned)
+
+	// The range of the filters is read in reverse too: 10 < score <= 20.
+	filtered := scores.WithFilters([]sql.Expression{
+		expression.NewGreaterThan(
+			expression.NewGetFieldWithTable(1, sql.Int64, "scores", "score", true),
+			expression.NewLiteral(int64(10), sql.Int64),
+		),
+		expression.NewLessThanOrEqual(
+			expression.NewGetFieldWithTable(1, sql.Int64, "scores", "score", true),
+			expression.NewLiteral(int64(20), sql.Int64),
+		),
+	}).(*Table)
+	desc, ok = filtered.WithOrder("score", true)
+	require.True(t, ok)
+	values, _ = read(desc, 100)
+	require.Len(t, values, 10)
+	require.Equal(t, int64(20), values[0])
+	require.Equal(t, int64(11), values[9])
+
+	// Columns without an index, or whose values are not sorted as they are
+	// compared, can't be read in order.
+	_, ok = scores.WithOrder("id", false)
+	require.False(t, ok)
+	_, ok = scores.WithOrder("doc", false)
+	require.False(t, ok)
+}
+
+func TestIndexRowConstructorIn(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	database := NewDatabase("mydb", db)
+	ctx := sql.NewEmptyContext()
+	require.NoError(t, database.Create("points", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "points"},
+		{Name: "x", Type: sql.Int64, Source: "points"},
+		{Name: "y", Type: sql.Int64, Source: "points", Nullable: true},
+	}))
+	require.NoError(t, database.CreateIndex("points", "idx_xy", []string{"x", "y"}))
+
+	table, _, err := database.GetTableInsensitive(ctx, "points")
+	require.NoError(t, err)
+	points := table.(*Table)
+	for i := int64(0); i < 100; i++ {
+		require.NoError(t, points.Insert(ctx, sql.NewRow(i, i/10, i%10)))
+	}
+
+	x := expression.NewGetFieldWithTable(1, sql.Int64, "points", "x", false)
+	y := expression.NewGetFieldWithTable(2, sql.Int64, "points", "y", true)
+	pair := func(x, y interface{}) sql.Expression {
+		typ := func(v interface{}) sql.Type {
+			if v == nil {
+				return sql.Null
+			}
+			return sql.Int64
+		}
+		return expression.NewTuple(expression.NewLiteral(x, typ(x)), expression.NewLiteral(y, typ(y)))
+	}
+	read := func(filter sql.Expression) ([]interface{}, int) {
+		filtered := points.WithFilters([]sql.Expression{filter}).(*Table)
+		iter, err := filtered.PartitionRows(ctx, &Partition{key: []byte("points")})
+		require.NoError(t, err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(t, err)
+
+		var ids []interface{}
+		for _, row := range rows {
+			ids = append(ids, row[0])
+		}
+		return ids, iter.(*indexRowIter).scanned
+	}
+
+	// The entries from (1, 2) to (3, 4) are read, and the first after them.
+	in := expression.NewIn(expression.NewTuple(x, y), expression.NewTuple(pair(int64(3), int64(4)), pair(int64(1), int64(2))))
+	ids, scanned := read(in)
+	require.Equal(t, []interface{}{int64(12), int64(34)}, ids)
+	require.Equal(t, 24, scanned)
+	access, index := points.WithFilters([]sql.Expression{in}).(*Table).Access()
+	require.Equal(t, "range", access)
+	require.Equal(t, "idx_xy", index)
+
+	// The columns may be in any order.
+	in = expression.NewIn(expression.NewTuple(y, x), expression.NewTuple(pair(int64(2), int64(1)), pair(int64(9), int64(9))))
+	ids, _ = read(in)
+	require.Equal(t, []interface{}{int64(12), int64(99)}, ids)
+
+	// The values with NULL never match, so they don't widen the range.
+	in = expression.NewIn(expression.NewTuple(x, y), expression.NewTuple(pair(int64(1), nil), pair(int64(5), int64(5))))
+	ids, scanned = read(in)
+	require.Equal(t, []interface{}{int64(55)}, ids)
+	require.Equal(t, 1, scanned)
+	access, _ = points.WithFilters([]sql.Expression{in}).(*Table).Access()
+	require.Equal(t, "ref", access)
+
+	// A single column uses the first column of the index.
+	in = expression.NewIn(x, expression.NewTuple(expression.NewLiteral(int64(7), sql.Int64), expression.NewLiteral(int64(6), sql.Int64)))
+	ids, scanned = read(in)
+	require.Len(t, ids, 20)
+	require.Equal(t, 21, scanned)
+
+	// The list must only have literals.
+	in = expression.NewIn(expression.NewTuple(x, y), expression.NewTuple(expression.NewTuple(x, y)))
+	access, _ = points.WithFilters([]sql.Expression{in}).(*Table).Access()
+	require.Equal(t, "ALL", access)
+}
+
+func TestCatalogPersistsDatabases(t *testing.T) {
+	dir := t.TempDir()
+	ctx := sql.NewEmptyContext()
+	names := []string{"db1", "db2", "db3"}
+
+	catalog, err := OpenCatalog(dir)
+	require.NoError(t, err)
+	require.Empty(t, catalog.AllDatabases(ctx))
+
+	for i, name := range names {
+		db, err := openBadger(filepath.Join(dir, name))
+		require.NoError(t, err)
+
+		database := NewDatabase(name, db)
+		require.NoError(t, catalog.AddDatabase(database))
+
+		require.NoError(t, database.Create("items", sql.Schema{
+			{Name: "id", Type: sql.Int64, Source: "items"},
+		}))
+		table, _, err := database.GetTableInsensitive(ctx, "items")
+		require.NoError(t, err)
+		inserter := table.(InsertableTable).Inserter(ctx)
+		require.NoError(t, inserter.Insert(ctx, sql.NewRow(int64(i))))
+		require.NoError(t, inserter.Close(ctx))
+	}
+	require.NoError(t, catalog.Close())
+
+	catalog, err = OpenCatalog(dir)
+	require.NoError(t, err)
+
+	dbs := catalog.AllDatabases(ctx)
+	require.Len(t, dbs, len(names))
+	for i, name := range names {
+		db, err := catalog.Database(ctx, name)
+		require.NoError(t, err)
+
+		table, ok, err := db.(*Database).GetTableInsensitive(ctx, "items")
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		part, err := table.Partitions(ctx)
+		require.NoError(t, err)
+		p, err := part.Next()
+		require.NoError(t, err)
+		rows, err := table.PartitionRows(ctx, p)
+		require.NoError(t, err)
+		all, err := sql.RowIterToRows(rows)
+		require.NoError(t, err)
+		require.Equal(t, []sql.Row{{int64(i)}}, all)
+	}
+
+	require.NoError(t, catalog.DropDatabase("DB2"))
+	require.True(t, sql.ErrDatabaseNotFound.Is(catalog.DropDatabase("db2")))
+	require.NoError(t, catalog.Close())
+
+	catalog, err = OpenCatalog(dir)
+	require.NoError(t, err)
+	defer catalog.Close()
+
+	require.True(t, catalog.HasDatabase(ctx, "db1"))
+	require.False(t, catalog.HasDatabase(ctx, "db2"))
+	require.True(t, catalog.HasDatabase(ctx, "db3"))
+}
+
+func TestCatalogOpensDatabasesOnDemand(t *testing.T) {
+	dir := t.TempDir()
+	ctx := sql.NewEmptyContext()
+
+	catalog, err := OpenCatalog(dir)
+	require.NoError(t, err)
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("db%02d", i)
+		db, err := openBadger(filepath.Join(dir, name))
+		require.NoError(t, err)
+		database := NewDatabase(name, db)
+		require.NoError(t, catalog.AddDatabase(database))
+		require.NoError(t, database.Create("items", sql.Schema{
+			{Name: "id", Type: sql.Int64, Source: "items"},
+		}))
+	}
+	require.Len(t, catalog.OpenDatabases(), 50)
+	require.NoError(t, catalog.Close())
+
+	catalog, err = OpenCatalog(dir)
+	require.NoError(t, err)
+	defer catalog.Close()
+
+	// The databases are known, but none is opened until it's used.
+	require.True(t, catalog.HasDatabase(ctx, "db07"))
+	require.Empty(t, catalog.OpenDatabases())
+
+	// Concurrent first uses open the database once.
+	var wg sync.WaitGroup
+	dbs := make([]sql.Database, 10)
+	for i := range dbs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			db, err := catalog.Database(ctx, "DB07")
+			assert.NoError(t, err)
+			dbs[i] = db
+		}(i)
+	}
+	wg.Wait()
+	for _, db := range dbs {
+		require.Same(t, dbs[0], db)
+	}
+	require.Equal(t, []string{"db07"}, catalog.OpenDatabases())
+
+	tables, err := catalog.Tables(ctx, "db08")
+	require.NoError(t, err)
+	require.Contains(t, tables, "items")
+	require.Equal(t, []string{"db07", "db08"}, catalog.OpenDatabases())
+
+	// The databases that aren't used are closed after the TTL, and opened
+	// again when they're used.
+	catalog.SetIdleTimeout(50 * time.Millisecond)
+	require.Eventually(t, func() bool {
+		_, err := catalog.Database(ctx, "db08")
+		require.NoError(t, err)
+		return len(catalog.OpenDatabases()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"db08"}, catalog.OpenDatabases())
+
+	db, err := catalog.Database(ctx, "db07")
+	require.NoError(t, err)
+	require.NotSame(t, dbs[0], db)
+	_, ok, err := db.(*Database).GetTableInsensitive(ctx, "items")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Dropping a database closes it and removes its files.
+	require.NoError(t, catalog.DropDatabase("db07"))
+	_, err = os.Stat(filepath.Join(dir, "db07"))
+	require.True(t, os.IsNotExist(err))
+	require.NotContains(t, catalog.OpenDatabases(), "db07")
+	_, err = catalog.Database(ctx, "db07")
+	require.True(t, sql.ErrDatabaseNotFound.Is(err))
+}
+
+func TestAlterTableColumns(t *testing.T) {
+	dir := t.TempDir()
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	database := NewDatabase("mydb", db)
+	ctx := sql.NewEmptyContext()
+
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "users"},
+		{Name: "name", Type: sql.Text, Source: "users", Nullable: true},
+	}
+	require.NoError(t, database.Create("users", schema))
+	table := database.Tables()["users"]
+	require.NoError(t, table.(sql.Inserter).Insert(ctx, sql.NewRow(int64(1), "alice")))
+	require.NoError(t, table.(sql.Inserter).Insert(ctx, sql.NewRow(int64(2), "bob")))
+
+	// An iterator opened before the change keeps its rows and schema.
+	parts, err := table.Partitions(ctx)
+	require.NoError(t, err)
+	part, err := parts.Next()
+	require.NoError(t, err)
+	before, err := table.PartitionRows(ctx, part)
+	require.NoError(t, err)
+
+	var _ sql.AlterableTable = database
+	require.NoError(t, database.AddColumn(ctx, "users", &sql.Column{Name: "age", Type: sql.Int32, Nullable: true}))
+
+	oldRows, err := sql.RowIterToRows(before)
+	require.NoError(t, err)
+	require.Equal(t, []sql.Row{{int64(1), "alice"}, {int64(2), "bob"}}, oldRows)
+
+	table = database.Tables()["users"]
+	require.Equal(t, []string{"id", "name", "age"}, columnNames(table.Schema()))
+	require.Equal(t, []sql.Row{
+		{int64(1), "alice", nil},
+		{int64(2), "bob", nil},
+	}, tableRows(t, ctx, table))
+
+	require.NoError(t, table.(sql.Inserter).Insert(ctx, sql.NewRow(int64(3), "carol", int32(30))))
+
+	err = database.AddColumn(ctx, "users", &sql.Column{Name: "score", Type: sql.Int32})
+	require.Error(t, err)
+	require.Error(t, database.AddColumn(ctx, "users", &sql.Column{Name: "AGE", Type: sql.Int32, Nullable: true}))
+	require.Error(t, database.DropColumn(ctx, "users", "id"))
+	require.Error(t, database.DropColumn(ctx, "users", "nope"))
+
+	require.NoError(t, database.DropColumn(ctx, "users", "name"))
+
+	// The changes are kept along with the table.
+	database = NewDatabase("mydb", db)
+	table = database.Tables()["users"]
+	require.Equal(t, []string{"id", "age"}, columnNames(table.Schema()))
+	require.Equal(t, []sql.Row{
+		{int64(1), nil},
+		{int64(2), nil},
+		{int64(3), int32(30)},
+	}, tableRows(t, ctx, table))
+}
+
+func columnNames(schema sql.Schema) []string {
+	names := make([]string, len(schema))
+	for i, c := range schema {
+		names[i] = c.Name
+	}
+	return names
+}
+
+func TestAutoIncrement(t *testing.T) {
+	dir := t.TempDir()
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+
+	database := NewDatabase("mydb", db)
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int32, Source: "users", AutoIncrement: true},
+		{Name: "name", Type: sql.Text, Source: "users", Nullable: true},
+	}
+	options := sql.TableOptions{sql.TableOptionAutoIncrement: "5"}
+	require.NoError(t, database.CreateWithOptions("users", schema, options))
+
+	insert := func(database *Database, id interface{}) uint64 {
+		t.Helper()
+		ctx := sql.NewEmptyContext()
+		table := database.Tables()["users"].(sql.Inserter)
+		require.NoError(t, table.Insert(ctx, sql.NewRow(id, "x")))
+		return ctx.InsertID()
+	}
+
+	// The counter starts at the AUTO_INCREMENT table option.
+	require.Equal(t, uint64(5), insert(database, nil))
+	require.Equal(t, uint64(6), insert(database, int32(0)))
+	require.Equal(t, uint64(0), insert(database, int32(20)))
+	require.Equal(t, uint64(21), insert(database, nil))
+
+	// The counter outlives the database.
+	require.NoError(t, db.Close())
+	db, err = badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	database = NewDatabase("mydb", db)
+	require.Equal(t, uint64(22), insert(database, nil))
+
+	ctx := sql.NewEmptyContext()
+	var _ sql.AutoIncrementAlterable = database
+	require.NoError(t, database.SetAutoIncrement(ctx, "users", 100))
+	require.Equal(t, uint64(100), insert(database, nil))
+
+	// It can't be set below the values in the table, and it's kept when
+	// columns are added.
+	require.NoError(t, database.SetAutoIncrement(ctx, "users", 1))
+	require.NoError(t, database.AddColumn(ctx, "users", &sql.Column{Name: "age", Type: sql.Int32, Nullable: true}))
+	ctx = sql.NewEmptyContext()
+	require.NoError(t, database.Tables()["users"].(sql.Inserter).Insert(ctx, sql.NewRow(nil, "y", nil)))
+	require.Equal(t, uint64(101), ctx.InsertID())
+
+	err = database.AddColumn(ctx, "users", &sql.Column{Name: "seq", Type: sql.Int64, AutoIncrement: true})
+	require.Error(t, err)
+
+	// Dropping the table drops the counter.
+	require.NoError(t, database.DropTable(ctx, "users"))
+	require.NoError(t, database.Create("users", schema))
+	require.Equal(t, uint64(1), insert(database, nil))
+}
+
+func TestCompositePrimaryKey(t *testing.T) {
+	dir := t.TempDir()
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	database := NewDatabase("mydb", db)
+	ctx := sql.NewEmptyContext()
+
+	schema := sql.Schema{
+		{Name: "region", Type: sql.Text, Source: "orders", PrimaryKey: true},
+		{Name: "id", Type: sql.Int64, Source: "orders", PrimaryKey: true},
+		{Name: "amount", Type: sql.Int64, Source: "orders", Nullable: true},
+	}
+	require.NoError(t, database.Create("orders", schema))
+	table := database.Tables()["orders"]
+
+	// The same ids in different regions are different rows.
+	for _, row := range []sql.Row{
+		{"us", int64(2), int64(20)},
+		{"eu", int64(10), int64(100)},
+		{"us", int64(-1), int64(10)},
+		{"eu", int64(2), int64(200)},
+		{"e", int64(3), int64(300)},
+	} {
+		require.NoError(t, table.(sql.Inserter).Insert(ctx, row))
+	}
+
+	// Rows are scanned in the order of their (region, id) tuples.
+	expected := []sql.Row{
+		{"e", int64(3), int64(300)},
+		{"eu", int64(2), int64(200)},
+		{"eu", int64(10), int64(100)},
+		{"us", int64(-1), int64(10)},
+		{"us", int64(2), int64(20)},
+	}
+	require.Equal(t, expected, tableRows(t, ctx, table))
+
+	err = table.(sql.Inserter).Insert(ctx, sql.NewRow("eu", int64(2), int64(0)))
+	require.True(t, sql.IsKind(sql.ErrDuplicateKey, err), "unexpected error: %v", err)
+	require.EqualError(t, err, "Duplicate entry 'eu-2' for key 'PRIMARY'")
+
+	// Updates can't move a row onto another one either.
+	err = table.(sql.Updater).Update(ctx, sql.NewRow("us", int64(2), int64(20)), sql.NewRow("eu", int64(10), int64(20)))
+	require.True(t, sql.IsKind(sql.ErrDuplicateKey, err), "unexpected error: %v", err)
+	require.NoError(t, table.(sql.Updater).Update(ctx, sql.NewRow("us", int64(2), int64(20)), sql.NewRow("us", int64(2), int64(25))))
+	expected[4][2] = int64(25)
+	require.Equal(t, expected, tableRows(t, ctx, table))
+
+	require.Error(t, database.DropColumn(ctx, "orders", "id"))
+	require.NoError(t, database.DropColumn(ctx, "orders", "amount"))
+
+	// The primary key is kept along with the table.
+	database = NewDatabase("mydb", db)
+	table = database.Tables()["orders"]
+	require.True(t, table.Schema()[0].PrimaryKey)
+	require.True(t, table.Schema()[1].PrimaryKey)
+	err = table.(sql.Inserter).Insert(ctx, sql.NewRow("us", int64(-1)))
+	require.True(t, sql.IsKind(sql.ErrDuplicateKey, err), "unexpected error: %v", err)
+}
+
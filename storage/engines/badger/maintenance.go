@@ -0,0 +1,82 @@
+package badger
+
+import (
+	"sync"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+var _ sql.StorageDatabase = (*Database)(nil)
+
+// flatten serializes the compactions started by Compact, as flattening a
+// BadgerDB stops and restarts its compactors, which two calls can't do at
+// once.
+var flatten sync.Mutex
+
+// StorageSize implements the sql.StorageDatabase interface. It adds up the
+// estimated sizes of the keys and values of the metadata, rows and index
+// entries of the database, reading them from a snapshot so the writes
+// aren't blocked.
+func (d *Database) StorageSize(ctx *sql.Context) (int64, error) {
+	var size int64
+	err := d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for _, prefix := range d.keyPrefixes() {
+			for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+				size += it.Item().EstimatedSize()
+			}
+		}
+		return nil
+	})
+	return size, err
+}
+
+// keyPrefixes returns the prefixes of all the keys of the database.
+func (d *Database) keyPrefixes() [][]byte {
+	var prefixes [][]byte
+	for _, p := range []byte{MetaPrefix, DataPrefix, IndexPrefix} {
+		prefix := append([]byte{p}, d.name...)
+		prefixes = append(prefixes, append(prefix, '/'))
+	}
+	return prefixes
+}
+
+// Compact implements the sql.StorageDatabase interface. It merges the
+// levels of the LSM tree and then collects the value log garbage. The
+// BadgerDB may be shared with other databases, which are compacted too.
+// Queries keep running while it does.
+func (d *Database) Compact(ctx *sql.Context) error {
+	workers := d.db.Opts().NumCompactors
+	if workers < 1 {
+		workers = 1
+	}
+
+	flatten.Lock()
+	err := d.db.Flatten(workers)
+	flatten.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if gc := d.GC(); gc != nil {
+		_, err := gc.RunGC()
+		return err
+	}
+	for err == nil {
+		err = d.db.RunValueLogGC(DefaultGCConfig.DiscardRatio)
+	}
+	if err == badger.ErrNoRewrite || err == badger.ErrRejected || err == badger.ErrGCInMemoryMode {
+		return nil
+	}
+	return err
+}
+
+// Flush implements the sql.StorageDatabase interface.
+func (d *Database) Flush(ctx *sql.Context) error {
+	return d.db.Sync()
+}
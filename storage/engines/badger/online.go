@@ -0,0 +1,437 @@
+package badger
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/transaction"
+)
+
+// DefaultOnlineAlterChunkSize is the number of rows an online ALTER copies
+// in each transaction if its options don't say.
+const DefaultOnlineAlterChunkSize = 1000
+
+// OnlineAlterOptions configures how the tables of a database are altered
+// online.
+type OnlineAlterOptions struct {
+	// ChunkSize is the number of rows copied to the new table in each
+	// transaction. Zero means DefaultOnlineAlterChunkSize.
+	ChunkSize int
+	// SwapTimeout is how long the swap of the tables waits for the
+	// statements writing the table to end before the ALTER fails with
+	// transaction.ErrLockWaitTimeout. Zero means
+	// transaction.DefaultLockWaitTimeout.
+	SwapTimeout time.Duration
+}
+
+// EnableOnlineAlter makes the ALTER TABLE statements that rewrite the rows
+// of a table leave it writable while they run. The rows are copied in
+// chunks to a shadow table with the new schema, keyed by a name of its own,
+// while the keys of the rows written meanwhile are captured. Once they are
+// copied, the writes are stopped for as long as it takes to copy the rows
+// captured again, and the table is swapped with the shadow one by pointing
+// its metadata to its rows.
+//
+// Statements that began writing the old table carry on with the new one,
+// and transactions that wrote it fail to commit with a conflict once it's
+// replaced.
+func (d *Database) EnableOnlineAlter(opts OnlineAlterOptions) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultOnlineAlterChunkSize
+	}
+	if opts.SwapTimeout <= 0 {
+		opts.SwapTimeout = transaction.DefaultLockWaitTimeout
+	}
+	d.online = &opts
+}
+
+// errTableAltering is the error of the changes of a table that can't be
+// made while it's altered online.
+func errTableAltering(name string) error {
+	return fmt.Errorf("table %s is being altered, try again once it's done", name)
+}
+
+// writeGate is entered by the statements writing a table, so an online
+// ALTER can capture the rows they change, and wait for them to end before
+// the table is replaced.
+type writeGate struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// writers is the number of statements writing the table.
+	writers int
+	// closed is set while the writes are stopped, and the statements
+	// wait to begin writing the table until it's unset.
+	closed bool
+	// captured are the keys of the rows written while the table is
+	// altered online, nil if it's not.
+	captured map[string]struct{}
+	// replacement is the table that replaced this one once it was
+	// altered online, and migrate converts rows of this table into rows
+	// of the replacement.
+	replacement *Table
+	migrate     func(sql.Row) (sql.Row, error)
+}
+
+func newWriteGate() *writeGate {
+	g := &writeGate{}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+// isAltering returns whether the table of the gate is being altered online.
+func (g *writeGate) isAltering() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.captured != nil
+}
+
+// stop waits for the statements writing the table to end and keeps new
+// ones from beginning until resume is called. If they don't end within
+// the timeout, the writes are resumed and ErrLockWaitTimeout is returned.
+func (g *writeGate) stop(timeout time.Duration) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.closed = true
+	deadline := time.Now().Add(timeout)
+	timer := time.AfterFunc(timeout, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.cond.Broadcast()
+	})
+	defer timer.Stop()
+
+	for g.writers > 0 {
+		// A statement waiting on a table it's already writing would wait
+		// forever, so the stop gives up after a while.
+		if !time.Now().Before(deadline) {
+			g.closed = false
+			g.cond.Broadcast()
+			return transaction.ErrLockWaitTimeout
+		}
+		g.cond.Wait()
+	}
+	return nil
+}
+
+// resume lets the statements write the table again.
+func (g *writeGate) resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.closed = false
+	g.cond.Broadcast()
+}
+
+// capture starts or stops capturing the keys of the rows written.
+func (g *writeGate) capture(on bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if on {
+		g.captured = make(map[string]struct{})
+	} else {
+		g.captured = nil
+	}
+}
+
+// capturedKeys returns the keys captured so far.
+func (g *writeGate) capturedKeys() [][]byte {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	keys := make([][]byte, 0, len(g.captured))
+	for key := range g.captured {
+		keys = append(keys, []byte(key))
+	}
+	return keys
+}
+
+// replace records the table that replaced the one of the gate, so the
+// statements that begin writing it write the replacement instead. It stops
+// capturing the keys of the rows written.
+func (g *writeGate) replace(replacement *Table, migrate func(sql.Row) (sql.Row, error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.captured = nil
+	g.replacement, g.migrate = replacement, migrate
+}
+
+// beginWrite enters the write gate of the table for a statement. It returns
+// the table the statement writes, which is the table that replaced this one
+// if it was altered online, along with the function converting the rows of
+// this table into rows of the returned one, nil if it's the same table.
+func (t *Table) beginWrite() (*Table, func(sql.Row) (sql.Row, error)) {
+	var migrate func(sql.Row) (sql.Row, error)
+	for {
+		g := t.gate
+		g.mu.Lock()
+		for g.closed {
+			g.cond.Wait()
+		}
+		if g.replacement == nil {
+			g.writers++
+			g.mu.Unlock()
+			return t, migrate
+		}
+		t, migrate = g.replacement, chainMigrations(migrate, g.migrate)
+		g.mu.Unlock()
+	}
+}
+
+// endWrite leaves the write gate entered with beginWrite.
+func (t *Table) endWrite() {
+	g := t.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writers--
+	if g.writers == 0 {
+		g.cond.Broadcast()
+	}
+}
+
+// captureWrite records the keys of rows written to the table, if it's
+// being altered online.
+func (t *Table) captureWrite(keys ...[]byte) {
+	g := t.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.captured == nil {
+		return
+	}
+	for _, key := range keys {
+		g.captured[string(key)] = struct{}{}
+	}
+}
+
+func chainMigrations(first, then func(sql.Row) (sql.Row, error)) func(sql.Row) (sql.Row, error) {
+	if first == nil {
+		return then
+	}
+	return func(row sql.Row) (sql.Row, error) {
+		row, err := first(row)
+		if err != nil {
+			return nil, err
+		}
+		return then(row)
+	}
+}
+
+// alterTableOnline replaces table t with a table with the given schema,
+// whose rows are those of t rewritten with migrate, as described in
+// EnableOnlineAlter. It must be called with d.mu held, which is released
+// while the rows are copied.
+func (d *Database) alterTableOnline(t *Table, schema sql.Schema, migrate func(sql.Row) (sql.Row, error)) error {
+	opts := *d.online
+
+	shadow := NewTable(t.name, d.name, schema, d.db)
+	shadow.storage = t.name + "#" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	shadow.options = t.options
+	shadow.codec = t.codec
+	shadow.checks = t.checks
+	shadow.autoInc = t.autoInc
+	if err := shadow.indexes.set(schema, t.Indexes()); err != nil {
+		return err
+	}
+
+	// The capture starts with no statement writing the table, and with a
+	// change of its metadata that makes the transactions that wrote it
+	// before conflict if they commit later, so the rows written since the
+	// snapshot is taken are all either captured or never committed.
+	if err := t.gate.stop(opts.SwapTimeout); err != nil {
+		return err
+	}
+	t.gate.capture(true)
+	err := d.db.Update(func(txn *badger.Txn) error {
+		return d.saveTableMeta(txn, t)
+	})
+	var snapshot *badger.Txn
+	if err == nil {
+		snapshot = d.db.NewTransaction(false)
+	}
+	t.gate.resume()
+
+	if err == nil {
+		d.mu.Unlock()
+		err = d.copyRows(t, shadow, snapshot, migrate, opts.ChunkSize)
+		snapshot.Discard()
+		d.mu.Lock()
+	}
+	if err == nil {
+		err = d.swapTables(t, shadow, migrate, opts.SwapTimeout)
+	}
+
+	// Either the rows of the shadow table or those of the old one are left
+	// behind, and they're deleted without the lock.
+	garbage := t.storageName()
+	if err != nil {
+		t.gate.capture(false)
+		garbage = shadow.storageName()
+	}
+	d.mu.Unlock()
+	if derr := d.deleteStorage(garbage); err == nil {
+		err = derr
+	}
+	d.mu.Lock()
+	return err
+}
+
+// copyRows copies the rows of t read from the snapshot to the shadow table,
+// rewritten with migrate, in transactions of at most chunkSize rows.
+func (d *Database) copyRows(t, shadow *Table, snapshot *badger.Txn, migrate func(sql.Row) (sql.Row, error), chunkSize int) error {
+	prefix := EncodeTablePrefix(d.name, t.storageName())
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	it := snapshot.NewIterator(opts)
+	defer it.Close()
+
+	it.Seek(prefix)
+	for it.ValidForPrefix(prefix) {
+		err := d.db.Update(func(txn *badger.Txn) error {
+			for n := 0; n < chunkSize && it.ValidForPrefix(prefix); n++ {
+				var row sql.Row
+				err := it.Item().Value(func(val []byte) error {
+					var err error
+					row, err = t.decodeRow(val)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+
+				key := it.Item().Key()
+				if err := copyRow(txn, shadow, key[len(prefix):], row, migrate); err != nil {
+					return err
+				}
+				it.Next()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyRow writes a row of a table to the shadow table with the given
+// primary key bytes, along with its index entries. The row and the entries
+// the shadow table had for the key are replaced, and removed if row is nil.
+func copyRow(txn *badger.Txn, shadow *Table, pk []byte, row sql.Row, migrate func(sql.Row) (sql.Row, error)) error {
+	key := EncodeRowKey(shadow.dbName, shadow.storageName(), pk)
+
+	old, err := shadow.getRow(txn, key)
+	if err != nil {
+		return err
+	}
+	var oldEntries, entries [][]byte
+	if old != nil {
+		if oldEntries, err = shadow.indexEntries(old, key); err != nil {
+			return err
+		}
+	}
+
+	if row == nil {
+		if err := updateIndexEntries(txn, oldEntries, nil, nil); err != nil {
+			return err
+		}
+		return txn.Delete(key)
+	}
+
+	if row, err = migrate(row); err != nil {
+		return err
+	}
+	val, err := shadow.encodeRow(row)
+	if err != nil {
+		return err
+	}
+	if entries, err = shadow.indexEntries(row, key); err != nil {
+		return err
+	}
+	if err := updateIndexEntries(txn, oldEntries, entries, key); err != nil {
+		return err
+	}
+	return txn.Set(key, val)
+}
+
+// swapTables copies the rows captured while the rows of t were copied to
+// the shadow table, and replaces t with it, with the writes to t stopped.
+// It must be called with d.mu held.
+func (d *Database) swapTables(t, shadow *Table, migrate func(sql.Row) (sql.Row, error), timeout time.Duration) error {
+	if err := t.gate.stop(timeout); err != nil {
+		return err
+	}
+	defer t.gate.resume()
+
+	prefix := EncodeTablePrefix(d.name, t.storageName())
+	keys := t.gate.capturedKeys()
+
+	// The rows captured are read again, so the transactions that commit
+	// one of them meanwhile make the swap conflict, and it's retried.
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		err = d.db.Update(func(txn *badger.Txn) error {
+			for _, key := range keys {
+				row, err := t.getRow(txn, key)
+				if err != nil {
+					return err
+				}
+				if err := copyRow(txn, shadow, key[len(prefix):], row, migrate); err != nil {
+					return err
+				}
+			}
+			return d.saveTableMeta(txn, shadow)
+		})
+		if err != badger.ErrConflict {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	t.gate.replace(shadow, migrate)
+	d.tables[t.name] = shadow
+	return nil
+}
+
+// deleteStorage deletes the rows and the index entries keyed by the given
+// storage name, in transactions of at most DefaultOnlineAlterChunkSize
+// keys, so the iterators still reading them from a snapshot go on.
+func (d *Database) deleteStorage(storage string) error {
+	for _, prefix := range [][]byte{EncodeTablePrefix(d.name, storage), EncodeTableIndexesPrefix(d.name, storage)} {
+		for {
+			n := 0
+			err := d.db.Update(func(txn *badger.Txn) error {
+				opts := badger.DefaultIteratorOptions
+				opts.PrefetchValues = false
+				opts.Prefix = prefix
+				it := txn.NewIterator(opts)
+				defer it.Close()
+
+				var keys [][]byte
+				for it.Seek(prefix); it.ValidForPrefix(prefix) && len(keys) < DefaultOnlineAlterChunkSize; it.Next() {
+					keys = append(keys, it.Item().KeyCopy(nil))
+				}
+				for _, key := range keys {
+					if err := txn.Delete(key); err != nil {
+						return err
+					}
+				}
+				n = len(keys)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if n < DefaultOnlineAlterChunkSize {
+				break
+			}
+		}
+	}
+	return nil
+}
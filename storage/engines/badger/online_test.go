@@ -0,0 +1,107 @@
+package badger
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+func TestOnlineModifyColumn(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	database := NewDatabase("mydb", db)
+	database.EnableOnlineAlter(OnlineAlterOptions{ChunkSize: 10})
+	ctx := sql.NewEmptyContext()
+	require.NoError(t, database.Create("t", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "t", PrimaryKey: true},
+		{Name: "n", Type: sql.Int32, Source: "t"},
+	}))
+
+	table, ok, err := database.GetTableInsensitive(ctx, "t")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	next := int64(0)
+	for ; next < 500; next++ {
+		require.NoError(t, table.(*Table).Insert(ctx, sql.NewRow(next, int32(next))))
+	}
+
+	// The writers keep inserting through the table they got before the
+	// ALTER, so the rows they insert once it's done are forwarded to the
+	// new table, with the new type.
+	var (
+		wg       sync.WaitGroup
+		inserted int64
+		stop     = make(chan struct{})
+		errs     = make(chan error, 4)
+	)
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				id := atomic.AddInt64(&next, 1) - 1
+				if err := table.(*Table).Insert(ctx, sql.NewRow(id, int32(id))); err != nil {
+					errs <- err
+					return
+				}
+				atomic.AddInt64(&inserted, 1)
+			}
+		}()
+	}
+
+	for atomic.LoadInt64(&inserted) < 100 {
+		runtime.Gosched()
+	}
+	require.NoError(t, database.ModifyColumn(ctx, "t", &sql.Column{Name: "n", Type: sql.Int64}))
+	for done := atomic.LoadInt64(&inserted); atomic.LoadInt64(&inserted) < done+100; {
+		runtime.Gosched()
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	check := func(database *Database) {
+		table, ok, err := database.GetTableInsensitive(ctx, "t")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, sql.Int64, table.Schema()[1].Type)
+
+		rows := tableRows(t, ctx, table)
+		sort.Slice(rows, func(i, j int) bool { return rows[i][0].(int64) < rows[j][0].(int64) })
+		require.Len(t, rows, 500+int(inserted))
+		for i, row := range rows {
+			require.Equal(t, sql.NewRow(int64(i), int64(i)), row)
+		}
+	}
+	check(database)
+	check(NewDatabase("mydb", db))
+
+	// The rows of the old table are gone.
+	require.NoError(t, db.View(func(txn *badger.Txn) error {
+		prefix := EncodeTablePrefix("mydb", "t")
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		it.Seek(prefix)
+		require.False(t, it.ValidForPrefix(prefix))
+		return nil
+	}))
+}
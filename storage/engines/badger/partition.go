@@ -1,14 +1,24 @@
 package badger
 
 import (
+	"fmt"
 	"io"
 
+	"github.com/dgraph-io/badger/v3"
 	"github.com/turtacn/guocedb/compute/sql"
 )
 
-// Partition implements sql.Partition.
+// DefaultPartitionRows is the number of rows of the partitions a table is
+// split into, so its scans can read them in parallel.
+const DefaultPartitionRows = 16384
+
+// Partition implements sql.Partition. It's a range of the rows of a table,
+// from the key start up to, but not including, the key end. Nil keys are
+// the first and the last rows of the table.
 type Partition struct {
-	key []byte
+	key   []byte
+	start []byte
+	end   []byte
 }
 
 // Key returns the partition key.
@@ -16,6 +26,52 @@ func (p *Partition) Key() []byte {
 	return p.key
 }
 
+// partitions splits the rows of the table in ranges of partitionRows rows.
+// Only the keys are read to find them, which is much cheaper than a scan.
+func (t *Table) partitions() ([]*Partition, error) {
+	size := t.partitionRows
+	if size <= 0 {
+		size = DefaultPartitionRows
+	}
+
+	var bounds [][]byte
+	err := t.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = EncodeTablePrefix(t.dbName, t.storageName())
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		n := 0
+		for it.Rewind(); it.Valid(); it.Next() {
+			if n > 0 && n%size == 0 {
+				bounds = append(bounds, it.Item().KeyCopy(nil))
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([]*Partition, 0, len(bounds)+1)
+	var start []byte
+	for i := 0; i <= len(bounds); i++ {
+		var end []byte
+		if i < len(bounds) {
+			end = bounds[i]
+		}
+		partitions = append(partitions, &Partition{
+			key:   []byte(fmt.Sprintf("%s/%d", t.name, i)),
+			start: start,
+			end:   end,
+		})
+		start = end
+	}
+	return partitions, nil
+}
+
 // partitionIter implements sql.PartitionIter.
 type partitionIter struct {
 	partitions []*Partition
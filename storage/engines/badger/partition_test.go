@@ -0,0 +1,129 @@
+package badger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/compute/sql/expression"
+	"github.com/turtacn/guocedb/compute/sql/plan"
+)
+
+// newScanTable creates a table of n rows, split in partitions of
+// partitionRows rows.
+func newScanTable(tb testing.TB, n, partitionRows int) *Table {
+	db, err := badger.Open(badger.DefaultOptions(tb.TempDir()).WithLogger(nil))
+	require.NoError(tb, err)
+	tb.Cleanup(func() { db.Close() })
+
+	database := NewDatabase("mydb", db)
+	require.NoError(tb, database.Create("events", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "events", PrimaryKey: true},
+		{Name: "kind", Type: sql.Text, Source: "events"},
+	}))
+	table, _, err := database.GetTableInsensitive(sql.NewEmptyContext(), "events")
+	require.NoError(tb, err)
+	events := table.(*Table)
+	events.partitionRows = partitionRows
+
+	ctx := sql.NewEmptyContext()
+	inserter := events.Inserter(ctx)
+	inserter.StatementBegin(ctx)
+	for i := 0; i < n; i++ {
+		require.NoError(tb, inserter.Insert(ctx, sql.NewRow(int64(i), fmt.Sprintf("kind%d", i%7))))
+	}
+	require.NoError(tb, inserter.StatementComplete(ctx))
+	require.NoError(tb, inserter.Close(ctx))
+	return events
+}
+
+func TestPartitions(t *testing.T) {
+	ctx := sql.NewEmptyContext()
+	table := newScanTable(t, 1000, 128)
+
+	iter, err := table.Partitions(ctx)
+	require.NoError(t, err)
+	var partitions []*Partition
+	for {
+		p, err := iter.Next()
+		if err != nil {
+			break
+		}
+		partitions = append(partitions, p.(*Partition))
+	}
+	require.Len(t, partitions, 8)
+	require.Nil(t, partitions[0].start)
+	require.Nil(t, partitions[7].end)
+	for i := 1; i < len(partitions); i++ {
+		require.Equal(t, partitions[i-1].end, partitions[i].start)
+	}
+
+	// The partitions have all the rows, once.
+	rows := tableRows(t, ctx, table)
+	require.Len(t, rows, 1000)
+	seen := make(map[int64]bool)
+	for _, row := range rows {
+		require.False(t, seen[row[0].(int64)])
+		seen[row[0].(int64)] = true
+	}
+}
+
+func TestParallelScan(t *testing.T) {
+	ctx := sql.NewEmptyContext()
+	serial := newScanTable(t, 2000, 0)
+	parallel := newScanTable(t, 2000, 100)
+
+	kind := expression.NewGetFieldWithTable(1, sql.Text, "events", "kind", false)
+	filter := expression.NewEquals(kind, expression.NewLiteral("kind3", sql.Text))
+
+	scan := func(table sql.Table, parallelism int, order bool) []sql.Row {
+		// The filter is evaluated by the scan of each partition.
+		var node sql.Node = plan.NewResolvedTable(table.(sql.FilteredTable).WithFilters([]sql.Expression{filter}))
+		if parallelism > 1 {
+			node = plan.NewExchange(parallelism, node)
+		}
+		if order {
+			node = plan.NewSort([]plan.SortField{{
+				Column: expression.NewGetFieldWithTable(0, sql.Int64, "events", "id", false),
+				Order:  plan.Ascending,
+			}}, node)
+		}
+
+		iter, err := node.RowIter(ctx)
+		require.NoError(t, err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(t, err)
+		return rows
+	}
+
+	expected := scan(serial, 1, false)
+	require.Len(t, expected, 286)
+	for _, parallelism := range []int{1, 2, 4, 8} {
+		t.Run(fmt.Sprint(parallelism), func(t *testing.T) {
+			require.ElementsMatch(t, expected, scan(parallel, parallelism, false))
+			require.Equal(t, expected, scan(parallel, parallelism, true))
+		})
+	}
+}
+
+// BenchmarkParallelScan reports the rows per second scanned by every
+// parallelism, which grows with it up to the number of CPUs.
+func BenchmarkParallelScan(b *testing.B) {
+	ctx := sql.NewEmptyContext()
+	table := newScanTable(b, 100000, 4096)
+
+	for _, parallelism := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprint(parallelism), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				iter, err := plan.NewExchange(parallelism, plan.NewResolvedTable(table)).RowIter(ctx)
+				require.NoError(b, err)
+				rows, err := sql.RowIterToRows(iter)
+				require.NoError(b, err)
+				require.Len(b, rows, 100000)
+			}
+			b.ReportMetric(float64(100000*b.N)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package badger
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// RecoveryConfig configures how a database whose value log is corrupted,
+// as it may be after an unclean shutdown, is opened.
+type RecoveryConfig struct {
+	// Recover moves aside the value log files that keep the database from
+	// opening and deletes the keys whose values can't be read, so the rest
+	// of the data can be used. Otherwise the database is left as it is.
+	Recover bool
+	// Logger receives what was found and recovered, along with the errors
+	// and warnings of BadgerDB. slog.Default is used if it's nil.
+	Logger *slog.Logger
+}
+
+// RecoveryReport is what was found corrupted when a database was opened.
+type RecoveryReport struct {
+	// MovedFiles are the value log files that couldn't be opened, which
+	// were renamed with the corruptSuffix.
+	MovedFiles []string
+	// LostKeys is the number of keys whose values couldn't be read.
+	LostKeys int
+	// Recovered is true if the keys whose values couldn't be read were
+	// deleted.
+	Recovered bool
+	// Tables are the tables that have rows whose values couldn't be read,
+	// with the number of rows of each, keyed by their table prefix.
+	Tables map[string]CorruptedTable
+}
+
+// CorruptedTable is a table with rows whose values couldn't be read.
+type CorruptedTable struct {
+	Database string
+	Table    string
+	LostRows int
+}
+
+// corruptSuffix is appended to the names of the value log files moved
+// aside, which are kept so their data can be salvaged by hand.
+const corruptSuffix = ".corrupt"
+
+// maxMovedFiles bounds the value log files moved aside before giving up.
+const maxMovedFiles = 16
+
+// valueLogPath finds the value log file named in the error BadgerDB
+// returns when it can't open it.
+var valueLogPath = regexp.MustCompile(`"([^"]+\.vlog)"`)
+
+// OpenWithRecovery opens a BadgerDB database, checking that the values of
+// all its keys can be read. BadgerDB returns the values of a corrupted value
+// log as empty, so they are found by their size.
+func OpenWithRecovery(opts badger.Options, config RecoveryConfig) (*badger.DB, *RecoveryReport, error) {
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	// BadgerDB logs the values it can't read, so it needs a logger not to
+	// crash, and a checksum not to return garbage instead.
+	opts.Logger = badgerLogger{logger}
+	opts.VerifyValueChecksum = true
+
+	report := &RecoveryReport{Tables: make(map[string]CorruptedTable)}
+	db, err := badger.Open(opts)
+	for err != nil && config.Recover && len(report.MovedFiles) < maxMovedFiles {
+		m := valueLogPath.FindStringSubmatch(err.Error())
+		if m == nil {
+			break
+		}
+
+		logger.Warn("Moving aside a value log file that can't be opened", "file", m[1], "error", err)
+		if rerr := os.Rename(m[1], m[1]+corruptSuffix); rerr != nil {
+			return nil, report, fmt.Errorf("%v; unable to move the value log file aside: %v", err, rerr)
+		}
+		report.MovedFiles = append(report.MovedFiles, m[1])
+		db, err = badger.Open(opts)
+	}
+	if err != nil {
+		return nil, report, err
+	}
+
+	lost, err := unreadableKeys(db)
+	if err != nil {
+		db.Close()
+		return nil, report, err
+	}
+	report.LostKeys = len(lost)
+
+	for _, key := range lost {
+		db, table, ok := decodeRowKey(key)
+		if !ok {
+			continue
+		}
+		prefix := string(EncodeTablePrefix(db, table))
+		t := report.Tables[prefix]
+		t.Database, t.Table = db, table
+		t.LostRows++
+		report.Tables[prefix] = t
+	}
+
+	if len(lost) == 0 {
+		return db, report, nil
+	}
+
+	if config.Recover {
+		if err := deleteKeys(db, lost); err != nil {
+			db.Close()
+			return nil, report, err
+		}
+		report.Recovered = true
+	}
+
+	for _, prefix := range report.TablePrefixes() {
+		t := report.Tables[prefix]
+		logger.Error("Rows of a table were lost in a corrupted value log",
+			"database", t.Database, "table", t.Table, "rows", t.LostRows, "deleted", report.Recovered)
+	}
+	logger.Error("Values of the storage can't be read",
+		"dir", opts.Dir, "keys", report.LostKeys, "moved_files", report.MovedFiles, "deleted", report.Recovered)
+
+	return db, report, nil
+}
+
+// TablePrefixes returns the prefixes of the corrupted tables, sorted.
+func (r *RecoveryReport) TablePrefixes() []string {
+	prefixes := make([]string, 0, len(r.Tables))
+	for prefix := range r.Tables {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// unreadableKeys returns the keys whose values are stored but can't be
+// read.
+func unreadableKeys(db *badger.DB) ([][]byte, error) {
+	var lost [][]byte
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			if item.ValueSize() <= 0 {
+				continue
+			}
+
+			val, err := item.ValueCopy(nil)
+			if err != nil || len(val) == 0 {
+				lost = append(lost, item.KeyCopy(nil))
+			}
+		}
+		return nil
+	})
+	return lost, err
+}
+
+func deleteKeys(db *badger.DB, keys [][]byte) error {
+	wb := db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, key := range keys {
+		if err := wb.Delete(key); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// decodeRowKey returns the database and the table of the key of a row,
+// see EncodeRowKey.
+func decodeRowKey(key []byte) (db, table string, ok bool) {
+	if len(key) == 0 || key[0] != DataPrefix {
+		return "", "", false
+	}
+
+	parts := bytes.SplitN(key[1:], []byte{'/'}, 3)
+	if len(parts) != 3 {
+		return "", "", false
+	}
+	return string(parts[0]), string(parts[1]), true
+}
+
+// badgerLogger writes the errors and warnings of BadgerDB to a slog.Logger.
+// The rest of its messages are only logged at debug level.
+type badgerLogger struct {
+	logger *slog.Logger
+}
+
+func (l badgerLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Error("badger: " + fmt.Sprintf(format, args...))
+}
+
+func (l badgerLogger) Warningf(format string, args ...interface{}) {
+	l.logger.Warn("badger: " + fmt.Sprintf(format, args...))
+}
+
+func (l badgerLogger) Infof(format string, args ...interface{}) {
+	l.logger.Debug("badger: " + fmt.Sprintf(format, args...))
+}
+
+func (l badgerLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debug("badger: " + fmt.Sprintf(format, args...))
+}
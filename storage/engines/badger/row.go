@@ -0,0 +1,246 @@
+package badger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// rowFormat is the first byte of the rows stored with a null bitmap. Rows
+// stored before it are gob streams, which never start with it: gob starts
+// them with the length of their first message, which is either a byte
+// below 0x80 or a negated byte count from 0xf8 up.
+const rowFormat byte = 0x81
+
+// Tags of the values stored in rows, which are decoded with the same Go
+// type they were encoded with.
+const (
+	tagInt32 byte = iota + 1
+	tagInt64
+	tagUint32
+	tagUint64
+	tagFloat32
+	tagFloat64
+	tagString
+	tagBytes
+	tagBool
+	tagTime
+	// tagGob is the tag of the values of any other type, which are
+	// encoded with gob.
+	tagGob
+)
+
+// encodeRow encodes the values of a row as stored in badger: rowFormat,
+// the number of values, a bitmap with the bits of the NULL values set, and
+// the other values, each one with its tag. NULL values take no space other
+// than their bit, so they are never mistaken for empty strings or zeros.
+func encodeRow(row sql.Row) ([]byte, error) {
+	buf := []byte{rowFormat}
+	buf = binary.AppendUvarint(buf, uint64(len(row)))
+
+	nulls := len(buf)
+	buf = append(buf, make([]byte, (len(row)+7)/8)...)
+	for i, v := range row {
+		if v == nil {
+			buf[nulls+i/8] |= 1 << (i % 8)
+			continue
+		}
+
+		var err error
+		if buf, err = appendValue(buf, v); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func appendValue(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case int32:
+		return binary.AppendVarint(append(buf, tagInt32), int64(v)), nil
+	case int64:
+		return binary.AppendVarint(append(buf, tagInt64), v), nil
+	case uint32:
+		return binary.AppendUvarint(append(buf, tagUint32), uint64(v)), nil
+	case uint64:
+		return binary.AppendUvarint(append(buf, tagUint64), v), nil
+	case float32:
+		return binary.BigEndian.AppendUint32(append(buf, tagFloat32), math.Float32bits(v)), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(buf, tagFloat64), math.Float64bits(v)), nil
+	case string:
+		return appendBytes(append(buf, tagString), []byte(v)), nil
+	case []byte:
+		return appendBytes(append(buf, tagBytes), v), nil
+	case bool:
+		if v {
+			return append(buf, tagBool, 1), nil
+		}
+		return append(buf, tagBool, 0), nil
+	case time.Time:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(append(buf, tagTime), b), nil
+	default:
+		var b bytes.Buffer
+		if err := gob.NewEncoder(&b).Encode(sql.Row{v}); err != nil {
+			return nil, err
+		}
+		return appendBytes(append(buf, tagGob), b.Bytes()), nil
+	}
+}
+
+func appendBytes(buf, b []byte) []byte {
+	return append(binary.AppendUvarint(buf, uint64(len(b))), b...)
+}
+
+// decodeRow decodes a row stored in badger, either with encodeRow or as a
+// gob stream, as rows were stored before.
+func decodeRow(val []byte) (sql.Row, error) {
+	if len(val) == 0 || val[0] != rowFormat {
+		var row sql.Row
+		if err := gob.NewDecoder(bytes.NewReader(val)).Decode(&row); err != nil {
+			return nil, err
+		}
+		return row, nil
+	}
+
+	d := rowDecoder{buf: val[1:]}
+	n := int(d.uvarint())
+	nulls := d.next((n + 7) / 8)
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	row := make(sql.Row, n)
+	for i := range row {
+		if nulls[i/8]&(1<<(i%8)) != 0 {
+			continue
+		}
+		row[i] = d.value()
+		if d.err != nil {
+			return nil, fmt.Errorf("unable to decode value %d of row: %v", i, d.err)
+		}
+	}
+	return row, nil
+}
+
+// rowDecoder reads the values of a row encoded by encodeRow. The first
+// error is kept, and the reads after it return zero values.
+type rowDecoder struct {
+	buf []byte
+	err error
+}
+
+var errShortRow = fmt.Errorf("row is shorter than its values")
+
+func (d *rowDecoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.buf) {
+		d.err = errShortRow
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *rowDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = errShortRow
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *rowDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = errShortRow
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *rowDecoder) bytes() []byte {
+	b := d.next(int(d.uvarint()))
+	// The values must not share the buffer, which badger reuses.
+	return append([]byte{}, b...)
+}
+
+func (d *rowDecoder) value() interface{} {
+	tag := d.next(1)
+	if d.err != nil {
+		return nil
+	}
+
+	var v interface{}
+	switch tag[0] {
+	case tagInt32:
+		v = int32(d.varint())
+	case tagInt64:
+		v = d.varint()
+	case tagUint32:
+		v = uint32(d.uvarint())
+	case tagUint64:
+		v = d.uvarint()
+	case tagFloat32:
+		if b := d.next(4); d.err == nil {
+			v = math.Float32frombits(binary.BigEndian.Uint32(b))
+		}
+	case tagFloat64:
+		if b := d.next(8); d.err == nil {
+			v = math.Float64frombits(binary.BigEndian.Uint64(b))
+		}
+	case tagString:
+		v = string(d.next(int(d.uvarint())))
+	case tagBytes:
+		v = d.bytes()
+	case tagBool:
+		if b := d.next(1); d.err == nil {
+			v = b[0] != 0
+		}
+	case tagTime:
+		var t time.Time
+		if b := d.bytes(); d.err == nil {
+			d.err = t.UnmarshalBinary(b)
+			v = t
+		}
+	case tagGob:
+		var row sql.Row
+		if b := d.bytes(); d.err == nil {
+			d.err = gob.NewDecoder(bytes.NewReader(b)).Decode(&row)
+			if d.err == nil && len(row) != 1 {
+				d.err = fmt.Errorf("gob value has %d values", len(row))
+			}
+			if d.err == nil {
+				v = row[0]
+			}
+		}
+	default:
+		d.err = fmt.Errorf("unknown value tag %d", tag[0])
+	}
+
+	if d.err != nil {
+		return nil
+	}
+	return v
+}
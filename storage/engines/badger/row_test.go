@@ -0,0 +1,116 @@
+package badger
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+func TestRowEncoding(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)
+	rows := []sql.Row{
+		{},
+		{nil},
+		{int64(0), nil, "", nil, []byte{}, false},
+		{int32(-7), int64(1 << 40), uint32(7), uint64(1 << 63), float32(1.5), float64(-2.25)},
+		{"héllo", []byte{0, 1, 2}, true, now},
+		// Types without a tag of their own are encoded with gob.
+		{int8(3), int16(-4), nil, nil, nil, nil, nil, nil, nil, "ninth"},
+	}
+
+	for _, row := range rows {
+		val, err := encodeRow(row)
+		require.NoError(t, err)
+
+		decoded, err := decodeRow(val)
+		require.NoError(t, err)
+		require.Equal(t, len(row), len(decoded))
+		for i := range row {
+			require.IsType(t, row[i], decoded[i], "value %d of %v", i, row)
+			require.Equal(t, row[i], decoded[i], "value %d of %v", i, row)
+		}
+	}
+
+	// Rows stored before the null bitmap are gob streams.
+	var legacy bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&legacy).Encode(sql.Row{int64(1), nil, "a"}))
+	decoded, err := decodeRow(legacy.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, sql.Row{int64(1), nil, "a"}, decoded)
+
+	val, err := encodeRow(sql.Row{"abc", int64(1)})
+	require.NoError(t, err)
+	_, err = decodeRow(val[:len(val)-3])
+	require.Error(t, err)
+}
+
+// NULL values are read back as NULL, and empty strings and zeros as they
+// were inserted, once the database is opened again.
+func TestTable_NullValues(t *testing.T) {
+	dir := t.TempDir()
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "t", PrimaryKey: true},
+		{Name: "name", Type: sql.VarChar(20), Source: "t", Nullable: true},
+		{Name: "count", Type: sql.Int32, Source: "t", Nullable: true},
+		{Name: "price", Type: sql.Float64, Source: "t", Nullable: true},
+		{Name: "data", Type: sql.Blob, Source: "t", Nullable: true},
+	}
+	rows := []sql.Row{
+		{int64(1), nil, nil, nil, nil},
+		{int64(2), "", int32(0), float64(0), []byte{}},
+		{int64(3), "x", nil, float64(0), nil},
+	}
+
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+	require.NoError(t, NewDatabase("testdb", db).Create("t", schema))
+
+	ctx := sql.NewEmptyContext()
+	table, _, err := NewDatabase("testdb", db).GetTableInsensitive(ctx, "t")
+	require.NoError(t, err)
+	inserter := table.(*Table).Inserter(ctx)
+	inserter.StatementBegin(ctx)
+	for _, row := range rows {
+		require.NoError(t, inserter.Insert(ctx, row))
+	}
+	require.NoError(t, inserter.StatementComplete(ctx))
+	require.NoError(t, inserter.Close(ctx))
+	require.NoError(t, db.Close())
+
+	db, err = badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	table, _, err = NewDatabase("testdb", db).GetTableInsensitive(ctx, "t")
+	require.NoError(t, err)
+	partitions, err := table.Partitions(ctx)
+	require.NoError(t, err)
+	defer partitions.Close()
+
+	var read []sql.Row
+	for {
+		part, err := partitions.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		iter, err := table.PartitionRows(ctx, part)
+		require.NoError(t, err)
+		partRows, err := sql.RowIterToRows(iter)
+		require.NoError(t, err)
+		read = append(read, partRows...)
+	}
+
+	require.Equal(t, rows, read)
+	require.Nil(t, read[0][1])
+	require.Equal(t, "", read[1][1])
+	require.Nil(t, read[0][2])
+	require.Equal(t, int32(0), read[1][2])
+}
@@ -3,9 +3,13 @@ package badger
 import (
 	"bytes"
 	"encoding/gob"
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/dgraph-io/badger/v3"
+	cerrors "github.com/turtacn/guocedb/common/errors"
+	"github.com/turtacn/guocedb/common/types/enum"
 	"github.com/turtacn/guocedb/compute/sql"
 	"github.com/turtacn/guocedb/compute/transaction"
 )
@@ -15,13 +19,7 @@ import (
 // even if core.go doesn't enforce them yet.
 
 // RowInserter allows inserting rows.
-type RowInserter interface {
-	StatementBegin(ctx *sql.Context)
-	Insert(ctx *sql.Context, row sql.Row) error
-	StatementComplete(ctx *sql.Context) error
-	DiscardChanges(ctx *sql.Context, err error) error
-	Close(ctx *sql.Context) error
-}
+type RowInserter = sql.RowInserter
 
 // RowUpdater allows updating rows.
 type RowUpdater interface {
@@ -42,9 +40,7 @@ type RowDeleter interface {
 }
 
 // InsertableTable is a table that can be inserted into.
-type InsertableTable interface {
-	Inserter(ctx *sql.Context) RowInserter
-}
+type InsertableTable = sql.InsertableTable
 
 // UpdatableTable is a table that can be updated.
 type UpdatableTable interface {
@@ -56,23 +52,48 @@ type DeletableTable interface {
 	Deleter(ctx *sql.Context) RowDeleter
 }
 
-// Table implements sql.Table and sql.Inserter.
+// Table implements sql.Table, sql.Inserter, sql.Updater and sql.Deleter.
 // It also implements the extended InsertableTable/UpdatableTable/DeletableTable interfaces
 // defined in this package for future compatibility or advanced usage.
 type Table struct {
-	name   string
-	dbName string
-	schema sql.Schema
-	db     *badger.DB
+	name    string
+	dbName  string
+	// storage is the name the rows and the index entries of the table are
+	// keyed by, which is its name unless it was altered online.
+	storage string
+	schema  sql.Schema
+	options sql.TableOptions
+	// codec encodes the rows of the table, as its options choose.
+	codec   RowCodec
+	// gate is entered by the statements writing the table.
+	gate    *writeGate
+	checks  []sql.CheckConstraint
+	db      *badger.DB
+	indexes *tableIndexes
+	filters []sql.Expression
+	// order is the index the rows are read from when they must be sorted
+	// by its first column.
+	order   *indexOrder
+	// stats are the statistics collected by ANALYZE TABLE, which choose
+	// the index the filters are read from.
+	stats   *sql.TableStatistics
+	autoInc *autoIncrement
+	// partitionRows is the number of rows of the partitions the table is
+	// split into, DefaultPartitionRows if it's zero.
+	partitionRows int
 }
 
 // NewTable creates a new Table.
 func NewTable(name, dbName string, schema sql.Schema, db *badger.DB) *Table {
 	return &Table{
-		name:   name,
-		dbName: dbName,
-		schema: schema,
-		db:     db,
+		name:    name,
+		dbName:  dbName,
+		schema:  schema,
+		db:      db,
+		codec:   binaryCodec{},
+		gate:    newWriteGate(),
+		indexes: &tableIndexes{},
+		autoInc: &autoIncrement{},
 	}
 }
 
@@ -91,37 +112,93 @@ func (t *Table) Schema() sql.Schema {
 	return t.schema
 }
 
-// Partitions returns a PartitionIter for the table.
+// storageName returns the name the rows and the index entries of the table
+// are keyed by.
+func (t *Table) storageName() string {
+	if t.storage != "" {
+		return t.storage
+	}
+	return t.name
+}
+
+// TableOptions returns the options the table was created with.
+func (t *Table) TableOptions() sql.TableOptions {
+	return t.options
+}
+
+// Checks implements sql.CheckTable.
+func (t *Table) Checks() []sql.CheckConstraint {
+	return t.checks
+}
+
+// Partitions returns a PartitionIter for the table. The rows of a full
+// scan are split in ranges of keys, which can be read in parallel. The rows
+// read from an index are a single partition, so they keep its order.
 func (t *Table) Partitions(ctx *sql.Context) (sql.PartitionIter, error) {
-	return &partitionIter{
-		partitions: []*Partition{{key: []byte(t.name)}},
-		idx:        0,
-	}, nil
+	if _, ok := t.indexRangeFromFilters(); ok || t.order != nil {
+		return &partitionIter{
+			partitions: []*Partition{{key: []byte(t.name)}},
+		}, nil
+	}
+
+	partitions, err := t.partitions()
+	if err != nil {
+		return nil, err
+	}
+	return &partitionIter{partitions: partitions}, nil
 }
 
-// PartitionRows returns a RowIter for the given partition.
+// PartitionRows returns a RowIter for the given partition. If the filters
+// of the table can use an index, only the rows in its range are read. If the
+// rows must be sorted, they are read from the index giving their order.
+// Otherwise, the rows of the range of the partition are read, filtered by
+// the filters of the table.
 func (t *Table) PartitionRows(ctx *sql.Context, partition sql.Partition) (sql.RowIter, error) {
-	txn := t.db.NewTransaction(false) // Read-only
-	prefix := EncodeTablePrefix(t.dbName, t.name)
+	fr, ok := t.indexRangeFromFilters()
+	if t.order != nil {
+		var r IndexRange
+		if ok && fr.index == t.order.index {
+			r = fr.r
+		}
+		return t.indexRows(ctx, t.order.index, r, t.order.desc)
+	}
+	if ok {
+		return t.IndexRows(ctx, fr.index, fr.r)
+	}
+
+	txn, release := t.readTxn(ctx)
+	prefix := EncodeTablePrefix(t.dbName, t.storageName())
 
 	opts := badger.DefaultIteratorOptions
 	opts.Prefix = prefix
 	iter := txn.NewIterator(opts)
 
-	iter.Seek(prefix)
+	start, end := prefix, []byte(nil)
+	if p, ok := partition.(*Partition); ok {
+		if p.start != nil {
+			start = p.start
+		}
+		end = p.end
+	}
+	iter.Seek(start)
 
 	return &tableRowIter{
-		iter:   iter,
-		txn:    txn,
-		schema: t.schema,
-		prefix: prefix,
+		ctx:     ctx,
+		table:   t,
+		iter:    iter,
+		release: release,
+		schema:  t.schema,
+		prefix:  prefix,
+		end:     end,
+		filters: t.filters,
 	}, nil
 }
 
 // Insert implements sql.Inserter (core interface).
 // It delegates to a short-lived rowEditor to perform the insert.
 // Note: This creates a transaction per row, which is safe but slow.
-// If the engine supported StatementBegin/Complete hooks, we could optimize this.
+// Statements inserting many rows should use an Inserter instead, which
+// writes all of them in the same transaction.
 func (t *Table) Insert(ctx *sql.Context, row sql.Row) error {
 	inserter := t.Inserter(ctx)
 	defer inserter.Close(ctx)
@@ -134,6 +211,34 @@ func (t *Table) Insert(ctx *sql.Context, row sql.Row) error {
 	return inserter.StatementComplete(ctx)
 }
 
+// Update implements sql.Updater.
+// Like Insert, it runs the change in a short-lived rowEditor.
+func (t *Table) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row) error {
+	updater := t.Updater(ctx)
+	defer updater.Close(ctx)
+
+	updater.StatementBegin(ctx)
+	if err := updater.Update(ctx, oldRow, newRow); err != nil {
+		updater.DiscardChanges(ctx, err)
+		return err
+	}
+	return updater.StatementComplete(ctx)
+}
+
+// Delete implements sql.Deleter.
+// Like Insert, it runs the change in a short-lived rowEditor.
+func (t *Table) Delete(ctx *sql.Context, row sql.Row) error {
+	deleter := t.Deleter(ctx)
+	defer deleter.Close(ctx)
+
+	deleter.StatementBegin(ctx)
+	if err := deleter.Delete(ctx, row); err != nil {
+		deleter.DiscardChanges(ctx, err)
+		return err
+	}
+	return deleter.StatementComplete(ctx)
+}
+
 // Inserter returns a RowInserter for the table.
 func (t *Table) Inserter(ctx *sql.Context) RowInserter {
 	return &rowEditor{
@@ -157,8 +262,52 @@ func (t *Table) Deleter(ctx *sql.Context) RowDeleter {
 
 type rowEditor struct {
 	table   *Table
-	txn     *badger.Txn
+	txn     *statementTxn
 	ownsTxn bool // true if we created the transaction, false if using external transaction
+	// extTxn is the external transaction. Changes go through it instead of
+	// its badger transaction so it knows what to persist if it's prepared.
+	extTxn *transaction.Transaction
+	// writing is set while the statement of the editor is in the write
+	// gate of its table.
+	writing bool
+	// migrate converts the rows given to the editor into rows of its
+	// table, if the table they were read from was replaced by an online
+	// ALTER since.
+	migrate func(sql.Row) (sql.Row, error)
+}
+
+// kvWriter is where the editor writes changes to.
+type kvWriter interface {
+	Set(key, value []byte) error
+	Delete(key []byte) error
+}
+
+// writer returns the transaction changes are written to, or nil if the
+// editor has none.
+func (re *rowEditor) writer() kvWriter {
+	if re.extTxn != nil {
+		return re.extTxn
+	}
+	if re.txn != nil {
+		return re.txn
+	}
+	return nil
+}
+
+// readTxn returns the badger transaction the rows are read from, which is
+// the snapshot of the transaction of the query, if it has one, so that it
+// reads what its isolation level lets it see. Otherwise, a new one reads
+// the latest committed rows. The returned function must be called once
+// the reading is done.
+func (t *Table) readTxn(ctx *sql.Context) (*badger.Txn, func()) {
+	if txn := getTransactionFromContext(ctx); txn != nil {
+		if snapshot := txn.Snapshot(); snapshot != nil {
+			return snapshot, func() {}
+		}
+	}
+
+	txn := t.db.NewTransaction(false)
+	return txn, txn.Discard
 }
 
 // getTransactionFromContext extracts a transaction from the SQL context
@@ -175,28 +324,37 @@ func getTransactionFromContext(ctx *sql.Context) *transaction.Transaction {
 
 // StatementBegin starts a transaction.
 func (re *rowEditor) StatementBegin(ctx *sql.Context) {
+	if !re.writing {
+		re.table, re.migrate = re.table.beginWrite()
+		re.writing = true
+	}
+
 	// Check if there's an external transaction in the context
 	if extTxn := getTransactionFromContext(ctx); extTxn != nil {
-		re.txn = extTxn.BadgerTxn()
+		re.extTxn = extTxn
 		re.ownsTxn = false
 	} else {
 		// Create our own transaction
-		re.txn = re.table.db.NewTransaction(true)
+		re.txn = newStatementTxn(re.table.db)
 		re.ownsTxn = true
 	}
 }
 
-// DiscardChanges discards the transaction.
+// DiscardChanges discards the transaction, along with the changes of the
+// statement that were already committed.
 func (re *rowEditor) DiscardChanges(ctx *sql.Context, err error) error {
+	defer re.endWrite()
 	if re.txn != nil && re.ownsTxn {
-		re.txn.Discard()
+		txn := re.txn
 		re.txn = nil
+		return txn.Discard()
 	}
 	return nil
 }
 
 // StatementComplete commits the transaction.
 func (re *rowEditor) StatementComplete(ctx *sql.Context) error {
+	defer re.endWrite()
 	if re.txn != nil && re.ownsTxn {
 		err := re.txn.Commit()
 		re.txn = nil
@@ -208,6 +366,7 @@ func (re *rowEditor) StatementComplete(ctx *sql.Context) error {
 
 // Close closes the editor.
 func (re *rowEditor) Close(ctx *sql.Context) error {
+	defer re.endWrite()
 	if re.txn != nil && re.ownsTxn {
 		re.txn.Discard()
 		re.txn = nil
@@ -215,25 +374,122 @@ func (re *rowEditor) Close(ctx *sql.Context) error {
 	return nil
 }
 
+// endWrite leaves the write gate of the table once the statement is done.
+func (re *rowEditor) endWrite() {
+	if re.writing {
+		re.table.endWrite()
+		re.writing = false
+	}
+}
+
+// convert converts a row given to the editor into a row of its table.
+func (re *rowEditor) convert(row sql.Row) (sql.Row, error) {
+	if re.migrate == nil {
+		return row, nil
+	}
+	return re.migrate(row)
+}
+
 // Insert inserts a row.
 func (re *rowEditor) Insert(ctx *sql.Context, row sql.Row) error {
+	row, err := re.convert(row)
+	if err != nil {
+		return err
+	}
+
+	row, err = re.table.assignAutoIncrement(ctx, row)
+	if err != nil {
+		return err
+	}
+
+	row, err = re.table.checkJSON(row)
+	if err != nil {
+		return err
+	}
+
 	key, val, err := re.encodeRow(row)
 	if err != nil {
 		return err
 	}
 
-	if re.txn != nil {
-		return re.txn.Set(key, val)
+	entries, err := re.table.indexEntries(row, key)
+	if err != nil {
+		return err
 	}
 
-	// Fallback should not be reached if properly used, but just in case:
-	return re.table.db.Update(func(txn *badger.Txn) error {
-		return txn.Set(key, val)
+	pk := primaryKeyColumns(re.table.schema)
+	re.table.captureWrite(key)
+	return re.write(func(w kvWriter) error {
+		// A row with the same primary key is rejected if the table declares
+		// one, and replaced otherwise, so its index entries must be removed.
+		var oldEntries [][]byte
+		if len(entries) > 0 || len(pk) > 0 {
+			old, err := re.table.getRow(w, key)
+			if err != nil {
+				return err
+			}
+			if old != nil && len(pk) > 0 {
+				return duplicateKeyError(row, pk)
+			}
+			if old != nil {
+				if oldEntries, err = re.table.indexEntries(old, key); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := re.table.checkUnique(w, row, key, entries, oldEntries); err != nil {
+			return err
+		}
+		if err := updateIndexEntries(w, oldEntries, entries, key); err != nil {
+			return err
+		}
+		return w.Set(key, val)
 	})
 }
 
+// Duplicate implements sql.DuplicateKeyUpdater. The row is read from the
+// transaction of the editor, so rows inserted by the statement are found.
+func (re *rowEditor) Duplicate(ctx *sql.Context, row sql.Row) (sql.Row, error) {
+	row, err := re.convert(row)
+	if err != nil {
+		return nil, err
+	}
+
+	key, _, err := re.encodeRow(row)
+	if err != nil {
+		return nil, err
+	}
+
+	var old sql.Row
+	err = re.read(func(w kvWriter) error {
+		old, err = re.table.getRow(w, key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, fmt.Errorf("no row conflicts with %v", row)
+	}
+	return old, nil
+}
+
 // Update updates a row.
 func (re *rowEditor) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row) error {
+	oldRow, err := re.convert(oldRow)
+	if err != nil {
+		return err
+	}
+	if newRow, err = re.convert(newRow); err != nil {
+		return err
+	}
+
+	newRow, err = re.table.checkJSON(newRow)
+	if err != nil {
+		return err
+	}
+
 	oldKey, _, err := re.encodeRow(oldRow)
 	if err != nil {
 		return err
@@ -244,105 +500,423 @@ func (re *rowEditor) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row) er
 		return err
 	}
 
-	if bytes.Equal(oldKey, newKey) {
-		if re.txn != nil {
-			return re.txn.Set(newKey, newVal)
-		}
-		return re.table.db.Update(func(txn *badger.Txn) error {
-			return txn.Set(newKey, newVal)
-		})
+	oldEntries, err := re.table.indexEntries(oldRow, oldKey)
+	if err != nil {
+		return err
 	}
 
-	// PK changed
-	if re.txn != nil {
-		if err := re.txn.Delete(oldKey); err != nil {
-			return err
-		}
-		return re.txn.Set(newKey, newVal)
+	newEntries, err := re.table.indexEntries(newRow, newKey)
+	if err != nil {
+		return err
 	}
 
-	return re.table.db.Update(func(txn *badger.Txn) error {
-		if err := txn.Delete(oldKey); err != nil {
+	pkChanged := !bytes.Equal(oldKey, newKey)
+	re.table.captureWrite(oldKey, newKey)
+	return re.write(func(w kvWriter) error {
+		if pk := primaryKeyColumns(re.table.schema); pkChanged && len(pk) > 0 {
+			existing, err := re.table.getRow(w, newKey)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				return duplicateKeyError(newRow, pk)
+			}
+		}
+
+		if err := re.table.checkUnique(w, newRow, newKey, newEntries, oldEntries); err != nil {
+			return err
+		}
+		if err := updateIndexEntries(w, oldEntries, newEntries, newKey); err != nil {
 			return err
 		}
-		return txn.Set(newKey, newVal)
+
+		if pkChanged {
+			if err := w.Delete(oldKey); err != nil {
+				return err
+			}
+		}
+		return w.Set(newKey, newVal)
 	})
 }
 
 // Delete deletes a row.
 func (re *rowEditor) Delete(ctx *sql.Context, row sql.Row) error {
+	row, err := re.convert(row)
+	if err != nil {
+		return err
+	}
+
 	key, _, err := re.encodeRow(row)
 	if err != nil {
 		return err
 	}
 
-	if re.txn != nil {
-		return re.txn.Delete(key)
+	entries, err := re.table.indexEntries(row, key)
+	if err != nil {
+		return err
 	}
 
+	re.table.captureWrite(key)
+	return re.write(func(w kvWriter) error {
+		if err := updateIndexEntries(w, entries, nil, nil); err != nil {
+			return err
+		}
+		return w.Delete(key)
+	})
+}
+
+// write makes changes in the transaction of the editor, so they are
+// committed along with the index entries. The metadata of the table is
+// fenced in external transactions, which are committed after the statement
+// ends, so they conflict if the table is replaced before.
+func (re *rowEditor) write(f func(kvWriter) error) error {
+	if re.extTxn != nil {
+		if err := re.extTxn.Fence(EncodeTableKey(re.table.dbName, re.table.name)); err != nil {
+			return err
+		}
+	}
+	if w := re.writer(); w != nil {
+		return f(w)
+	}
+
+	// Fallback should not be reached if properly used, but just in case:
 	return re.table.db.Update(func(txn *badger.Txn) error {
-		return txn.Delete(key)
+		return f(txn)
 	})
 }
 
+// read reads from the transaction of the editor, or from a new one if it
+// has none.
+func (re *rowEditor) read(f func(kvWriter) error) error {
+	if w := re.writer(); w != nil {
+		return f(w)
+	}
+
+	return re.table.db.View(func(txn *badger.Txn) error {
+		return f(txn)
+	})
+}
+
+// updateIndexEntries replaces the old index entries of a row with the new
+// ones, which point to the row at rowKey. Entries that didn't change are
+// left as they are.
+func updateIndexEntries(w kvWriter, oldEntries, newEntries [][]byte, rowKey []byte) error {
+	for i, old := range oldEntries {
+		if i < len(newEntries) && bytes.Equal(old, newEntries[i]) {
+			continue
+		}
+		if err := w.Delete(old); err != nil {
+			return err
+		}
+	}
+
+	for i, entry := range newEntries {
+		if i < len(oldEntries) && bytes.Equal(entry, oldEntries[i]) {
+			continue
+		}
+		if err := w.Set(entry, rowKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getRow returns the row of the table stored at key in the transaction, or
+// nil if there is none.
+func (t *Table) getRow(w kvWriter, key []byte) (sql.Row, error) {
+	var val []byte
+	switch w := w.(type) {
+	case *transaction.Transaction:
+		v, err := w.Get(key)
+		if err == transaction.ErrKeyNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		val = v
+	case *statementTxn:
+		return t.getRow(w.txn, key)
+	case *badger.Txn:
+		item, err := w.Get(key)
+		if err == badger.ErrKeyNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if val, err = item.ValueCopy(nil); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unexpected transaction type %T", w)
+	}
+
+	return t.decodeRow(val)
+}
+
+// encodeRow encodes a row of the table as it's stored in badger.
+func (t *Table) encodeRow(row sql.Row) ([]byte, error) {
+	return t.codec.Encode(t.schema, row)
+}
+
+// decodeRow decodes a row of the table stored in badger.
+func (t *Table) decodeRow(val []byte) (sql.Row, error) {
+	return t.codec.Decode(t.schema, val)
+}
+
+// badgerTxn returns the badger transaction of a writer, so its keys can be
+// iterated.
+func badgerTxn(w kvWriter) (*badger.Txn, error) {
+	switch w := w.(type) {
+	case *transaction.Transaction:
+		return w.BadgerTxn(), nil
+	case *statementTxn:
+		return w.txn, nil
+	case *badger.Txn:
+		return w, nil
+	default:
+		return nil, fmt.Errorf("unexpected transaction type %T", w)
+	}
+}
+
+// checkJSON returns the row with the values of its JSON columns replaced by
+// the text of the documents, which is what is stored. Values that are not
+// valid JSON documents are rejected.
+func (t *Table) checkJSON(row sql.Row) (sql.Row, error) {
+	var checked sql.Row
+	for i, col := range t.schema {
+		if col.Type != sql.JSON || i >= len(row) || row[i] == nil {
+			continue
+		}
+
+		doc, err := sql.JSON.Convert(row[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if checked == nil {
+			checked = row.Copy()
+		}
+		checked[i] = doc
+	}
+
+	if checked == nil {
+		return row, nil
+	}
+	return checked, nil
+}
+
+// primaryKeyColumns returns the indexes of the columns of the declared
+// primary key of the schema, in schema order, or nil if it has none.
+func primaryKeyColumns(schema sql.Schema) []int {
+	var cols []int
+	for i, col := range schema {
+		if col.PrimaryKey {
+			cols = append(cols, i)
+		}
+	}
+	return cols
+}
+
+// collationKeys returns the values of the given columns with the strings of
+// the columns compared regardless of case replaced by their collation keys,
+// so the keys of equal values are the same.
+func collationKeys(schema sql.Schema, cols []int, values []interface{}) []interface{} {
+	keys := values
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok || i >= len(cols) || cols[i] >= len(schema) {
+			continue
+		}
+
+		collation := sql.CollationOf(schema[cols[i]].Type)
+		if collation.CaseSensitive() {
+			continue
+		}
+
+		if &keys[0] == &values[0] {
+			keys = append([]interface{}(nil), values...)
+		}
+		keys[i] = collation.Key(s)
+	}
+	return keys
+}
+
+// primaryKeyEntry formats the primary key of the row as MySQL does in
+// duplicate entry errors.
+func primaryKeyEntry(row sql.Row, cols []int) string {
+	values := make([]string, len(cols))
+	for i, c := range cols {
+		values[i] = fmt.Sprint(row[c])
+	}
+	return strings.Join(values, "-")
+}
+
+// duplicateKeyError returns the error of a write of the row whose primary
+// key, made of the given columns, is already taken.
+func duplicateKeyError(row sql.Row, pk []int) error {
+	return cerrors.WithCode(sql.ErrDuplicateKey.New(primaryKeyEntry(row, pk)), enum.DuplicateKey)
+}
+
+// duplicateUniqueKeyError returns the error of a write of the row whose
+// values in the columns of the given unique index are already taken.
+func duplicateUniqueKeyError(row sql.Row, cols []int, index string) error {
+	return cerrors.WithCode(sql.ErrDuplicateUniqueKey.New(primaryKeyEntry(row, cols), index), enum.DuplicateKey)
+}
+
 func (re *rowEditor) encodeRow(row sql.Row) ([]byte, []byte, error) {
-	// Simple assumption: First column is PK.
 	if len(row) == 0 {
 		return nil, nil, nil
 	}
 
-	pkVal := row[0]
 	var pkBytes []byte
+	if cols := primaryKeyColumns(re.table.schema); len(cols) > 0 {
+		values := make([]interface{}, len(cols))
+		for i, c := range cols {
+			if c >= len(row) {
+				return nil, nil, fmt.Errorf("row has no value for primary key column %s", re.table.schema[c].Name)
+			}
+			values[i] = row[c]
+		}
 
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	if err := enc.Encode(pkVal); err != nil {
-		return nil, nil, err
+		var err error
+		if pkBytes, err = EncodePrimaryKey(collationKeys(re.table.schema, cols, values)); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		// Tables without a declared primary key are keyed by their first
+		// column, and a row with the same value replaces the old one.
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		if err := enc.Encode(collationKeys(re.table.schema, []int{0}, row[:1])[0]); err != nil {
+			return nil, nil, err
+		}
+		pkBytes = buf.Bytes()
 	}
-	pkBytes = buf.Bytes()
 
-	key := EncodeRowKey(re.table.dbName, re.table.name, pkBytes)
+	key := EncodeRowKey(re.table.dbName, re.table.storageName(), pkBytes)
 
-	var valBuf bytes.Buffer
-	valEnc := gob.NewEncoder(&valBuf)
-	if err := valEnc.Encode(row); err != nil {
+	val, err := re.table.encodeRow(row)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	return key, valBuf.Bytes(), nil
+	return key, val, nil
 }
 
 // tableRowIter implements sql.RowIter.
 type tableRowIter struct {
-	iter   *badger.Iterator
-	txn    *badger.Txn
-	schema sql.Schema
-	prefix []byte
+	ctx     *sql.Context
+	table   *Table
+	iter    *badger.Iterator
+	// release releases the transaction the rows are read from.
+	release func()
+	schema  sql.Schema
+	prefix  []byte
+	// end is the key the rows of the partition end before, nil if they
+	// end with the table.
+	end     []byte
+	filters []sql.Expression
 }
 
 func (i *tableRowIter) Next() (sql.Row, error) {
-	if !i.iter.ValidForPrefix(i.prefix) {
-		return nil, io.EOF
+	for i.iter.ValidForPrefix(i.prefix) {
+		if i.end != nil && bytes.Compare(i.iter.Item().Key(), i.end) >= 0 {
+			break
+		}
+
+		// The query is interrupted between rows once it's killed or
+		// times out.
+		if err := i.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		item := i.iter.Item()
+		var row sql.Row
+		err := item.Value(func(val []byte) error {
+			var err error
+			row, err = i.table.decodeRow(val)
+			return err
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		key := item.KeyCopy(nil)
+		i.iter.Next()
+
+		ok, err := evalFilters(i.ctx, i.filters, row)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		row, ok, err = lockRow(i.ctx, i.table, key, row, i.filters)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return row, nil
+		}
 	}
 
-	item := i.iter.Item()
-	var row sql.Row
-	err := item.Value(func(val []byte) error {
-		buf := bytes.NewBuffer(val)
-		dec := gob.NewDecoder(buf)
-		return dec.Decode(&row)
-	})
+	return nil, io.EOF
+}
 
-	if err != nil {
-		return nil, err
+// lockRow takes the lock the query asks for on the row of the table stored
+// at key, if it's run in a transaction. Other transactions may have changed
+// the row while the lock was awaited, so the row is read again once it's
+// locked, and it's only returned if it still matches the filters.
+func lockRow(ctx *sql.Context, t *Table, key []byte, row sql.Row, filters []sql.Expression) (sql.Row, bool, error) {
+	mode := transaction.LockShared
+	switch ctx.RowLock() {
+	case sql.NoRowLock:
+		return row, true, nil
+	case sql.RowLockExclusive:
+		mode = transaction.LockExclusive
+	}
+
+	txn := getTransactionFromContext(ctx)
+	if txn == nil {
+		return row, true, nil
+	}
+	if err := txn.Lock(key, mode); err != nil {
+		return nil, false, err
 	}
 
-	i.iter.Next()
-	return row, nil
+	var latest sql.Row
+	err := t.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			var err error
+			latest, err = t.decodeRow(val)
+			return err
+		})
+	})
+	if err != nil || latest == nil {
+		return nil, false, err
+	}
+
+	ok, err := evalFilters(ctx, filters, latest)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return latest, true, nil
 }
 
 func (i *tableRowIter) Close() error {
 	i.iter.Close()
-	i.txn.Discard()
+	i.release()
 	return nil
 }
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// Catalog is a catalog of in-memory databases. It provides the same methods
+// as the catalog of the BadgerDB engine, so either can back the databases
+// of a server.
+type Catalog struct {
+	mu  sync.RWMutex
+	dbs map[string]*Database
+}
+
+// NewCatalog creates an empty catalog.
+func NewCatalog() *Catalog {
+	return &Catalog{dbs: make(map[string]*Database)}
+}
+
+// Database returns a database by name (case-insensitive).
+func (c *Catalog) Database(ctx *sql.Context, name string) (sql.Database, error) {
+	if db := c.lookup(name); db != nil {
+		return db, nil
+	}
+	return nil, sql.ErrDatabaseNotFound.New(name)
+}
+
+// HasDatabase checks if a database exists (case-insensitive).
+func (c *Catalog) HasDatabase(ctx *sql.Context, name string) bool {
+	return c.lookup(name) != nil
+}
+
+func (c *Catalog) lookup(name string) *Database {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for dbName, db := range c.dbs {
+		if strings.EqualFold(dbName, name) {
+			return db
+		}
+	}
+	return nil
+}
+
+// AllDatabases returns all databases in the catalog.
+func (c *Catalog) AllDatabases(ctx *sql.Context) []sql.Database {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	dbs := make([]sql.Database, 0, len(c.dbs))
+	for _, db := range c.dbs {
+		dbs = append(dbs, db)
+	}
+	return dbs
+}
+
+// AddDatabase adds a database to the catalog.
+func (c *Catalog) AddDatabase(db *Database) error {
+	return c.CreateDatabase(db.Name(), db)
+}
+
+// CreateDatabase adds the database to the catalog with the given name.
+func (c *Catalog) CreateDatabase(name string, db *Database) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dbs[name] = db
+	return nil
+}
+
+// DropDatabase removes a database (case-insensitive) from the catalog.
+func (c *Catalog) DropDatabase(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for n := range c.dbs {
+		if strings.EqualFold(n, name) {
+			delete(c.dbs, n)
+			return nil
+		}
+	}
+	return sql.ErrDatabaseNotFound.New(name)
+}
+
+// Tables returns the tables of a database.
+func (c *Catalog) Tables(ctx *sql.Context, dbName string) (map[string]sql.Table, error) {
+	db, err := c.Database(ctx, dbName)
+	if err != nil {
+		return nil, err
+	}
+	return db.Tables(), nil
+}
+
+// Close drops every database of the catalog.
+func (c *Catalog) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dbs = make(map[string]*Database)
+	return nil
+}
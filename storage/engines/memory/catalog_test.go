@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+var productsSchema = sql.Schema{
+	{Name: "id", Type: sql.Int64, Nullable: false, Source: "products", PrimaryKey: true},
+	{Name: "name", Type: sql.Text, Nullable: false, Source: "products"},
+	{Name: "price", Type: sql.Float64, Nullable: false, Source: "products"},
+}
+
+// TestCatalogTableLifecycle runs the catalog lifecycle of the BadgerDB
+// engine against the memory engine.
+func TestCatalogTableLifecycle(t *testing.T) {
+	catalog := NewCatalog()
+
+	database := NewDatabase("testdb")
+	require.NoError(t, catalog.AddDatabase(database))
+
+	// Verify database exists
+	assert.True(t, catalog.HasDatabase(nil, "testdb"))
+	assert.True(t, catalog.HasDatabase(nil, "TESTDB"))
+
+	// Create table
+	require.NoError(t, database.Create("products", productsSchema))
+	assert.True(t, sql.ErrTableAlreadyExists.Is(database.Create("products", productsSchema)))
+
+	// Verify Tables() includes new table
+	tables := database.Tables()
+	assert.Equal(t, 1, len(tables))
+	assert.Contains(t, tables, "products")
+
+	// Verify GetTableInsensitive() can find it
+	table, found, err := database.GetTableInsensitive(nil, "PRODUCTS")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "products", table.Name())
+
+	// Verify GetTableNames() includes it
+	names, err := database.GetTableNames(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"products"}, names)
+
+	// Drop table
+	require.NoError(t, database.DropTable(nil, "products"))
+	assert.True(t, sql.ErrTableNotFound.Is(database.DropTable(nil, "products")))
+
+	// Verify Tables() no longer includes it
+	assert.Equal(t, 0, len(database.Tables()))
+
+	// Verify GetTableInsensitive() doesn't find it
+	_, found, err = database.GetTableInsensitive(nil, "products")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestCatalogDatabases(t *testing.T) {
+	catalog := NewCatalog()
+	assert.Empty(t, catalog.AllDatabases(nil))
+
+	require.NoError(t, catalog.AddDatabase(NewDatabase("db1")))
+	require.NoError(t, catalog.AddDatabase(NewDatabase("db2")))
+	assert.Len(t, catalog.AllDatabases(nil), 2)
+
+	db, err := catalog.Database(nil, "DB1")
+	require.NoError(t, err)
+	assert.Equal(t, "db1", db.Name())
+
+	_, err = catalog.Database(nil, "nonexistent")
+	assert.True(t, sql.ErrDatabaseNotFound.Is(err))
+
+	require.NoError(t, db.(*Database).Create("t", productsSchema))
+	tables, err := catalog.Tables(nil, "db1")
+	require.NoError(t, err)
+	assert.Contains(t, tables, "t")
+
+	require.NoError(t, catalog.DropDatabase("Db1"))
+	assert.False(t, catalog.HasDatabase(nil, "db1"))
+	assert.True(t, sql.ErrDatabaseNotFound.Is(catalog.DropDatabase("db1")))
+	assert.Len(t, catalog.AllDatabases(nil), 1)
+}
+
+// TestCatalogTableRows runs the row operations of the catalog integration
+// test of the BadgerDB engine against the memory engine.
+func TestCatalogTableRows(t *testing.T) {
+	catalog := NewCatalog()
+	require.NoError(t, catalog.AddDatabase(NewDatabase("testdb")))
+
+	db, err := catalog.Database(nil, "testdb")
+	require.NoError(t, err)
+	require.NoError(t, db.(*Database).Create("products", productsSchema))
+
+	table, found, err := db.(*Database).GetTableInsensitive(nil, "products")
+	require.NoError(t, err)
+	require.True(t, found)
+
+	ctx := sql.NewEmptyContext()
+	mt := table.(*Table)
+	require.NoError(t, mt.Insert(ctx, sql.NewRow(int64(3), "c", 3.0)))
+	require.NoError(t, mt.Insert(ctx, sql.NewRow(int64(1), "a", 1.0)))
+	require.NoError(t, mt.Insert(ctx, sql.NewRow(int64(2), "b", 2.0)))
+
+	err = mt.Insert(ctx, sql.NewRow(int64(2), "dup", 0.0))
+	require.Error(t, err)
+	assert.True(t, sql.ErrDuplicateKey.Is(err))
+
+	require.NoError(t, mt.Update(ctx, sql.NewRow(int64(2), "b", 2.0), sql.NewRow(int64(2), "b", 2.5)))
+	require.NoError(t, mt.Delete(ctx, sql.NewRow(int64(3), "c", 3.0)))
+	assert.True(t, ErrRowNotFound.Is(mt.Delete(ctx, sql.NewRow(int64(3), "c", 3.0))))
+
+	// The rows are read in primary key order.
+	assert.Equal(t, []sql.Row{
+		sql.NewRow(int64(1), "a", 1.0),
+		sql.NewRow(int64(2), "b", 2.5),
+	}, tableRows(t, ctx, table))
+
+	count, err := mt.RowCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+}
+
+func tableRows(t *testing.T, ctx *sql.Context, table sql.Table) []sql.Row {
+	t.Helper()
+
+	parts, err := table.Partitions(ctx)
+	require.NoError(t, err)
+	defer parts.Close()
+
+	var rows []sql.Row
+	for {
+		part, err := parts.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		iter, err := table.PartitionRows(ctx, part)
+		require.NoError(t, err)
+		partRows, err := sql.RowIterToRows(iter)
+		require.NoError(t, err)
+		rows = append(rows, partRows...)
+	}
+	return rows
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+// Database implements sql.Database with tables that are kept in memory.
+type Database struct {
+	name   string
+	mu     sync.RWMutex
+	tables map[string]*Table
+}
+
+// NewDatabase creates an empty database.
+func NewDatabase(name string) *Database {
+	return &Database{
+		name:   name,
+		tables: make(map[string]*Table),
+	}
+}
+
+// Name returns the name of the database.
+func (d *Database) Name() string {
+	return d.name
+}
+
+// Tables returns all tables in the database.
+func (d *Database) Tables() map[string]sql.Table {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	tables := make(map[string]sql.Table, len(d.tables))
+	for k, v := range d.tables {
+		tables[k] = v
+	}
+	return tables
+}
+
+// Create implements sql.Alterable.
+func (d *Database) Create(name string, schema sql.Schema) error {
+	return d.CreateWithOptions(name, schema, nil)
+}
+
+// CreateWithOptions implements sql.OptionsAlterable.
+func (d *Database) CreateWithOptions(name string, schema sql.Schema, options sql.TableOptions) error {
+	return d.CreateWithChecks(name, schema, options, nil)
+}
+
+// CreateWithChecks implements sql.CheckAlterable.
+func (d *Database) CreateWithChecks(name string, schema sql.Schema, options sql.TableOptions, checks []sql.CheckConstraint) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[name]; ok {
+		return sql.ErrTableAlreadyExists.New(name)
+	}
+
+	table := NewTable(name, schema)
+	table.options = options
+	table.checks = checks
+	d.tables[name] = table
+	return nil
+}
+
+// GetTableInsensitive retrieves a table by name (case-insensitive).
+func (d *Database) GetTableInsensitive(ctx *sql.Context, name string) (sql.Table, bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if t, ok := d.tables[name]; ok {
+		return t, true, nil
+	}
+	for tableName, table := range d.tables {
+		if strings.EqualFold(tableName, name) {
+			return table, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// GetTableNames returns the names of all tables in the database, sorted.
+func (d *Database) GetTableNames(ctx *sql.Context) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	names := make([]string, 0, len(d.tables))
+	for name := range d.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DropTable drops a table along with its rows.
+func (d *Database) DropTable(ctx *sql.Context, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[name]; !ok {
+		return sql.ErrTableNotFound.New(name)
+	}
+	delete(d.tables, name)
+	return nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+// kv is a key-value pair.
+type kv struct {
+	key   []byte
+	value []byte
+}
+
+// Iterator implements interfaces.Iterator over a sorted copy of the pairs
+// it reads.
+type Iterator struct {
+	pairs []kv
+	pos   int
+}
+
+func newIterator(pairs []kv) *Iterator {
+	return &Iterator{pairs: pairs, pos: -1}
+}
+
+// Next moves the iterator to the next key/value pair.
+func (it *Iterator) Next() bool {
+	if it.pos+1 >= len(it.pairs) {
+		it.pos = len(it.pairs)
+		return false
+	}
+	it.pos++
+	return true
+}
+
+// Key returns the current key.
+func (it *Iterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.pairs) {
+		return nil
+	}
+	return append([]byte{}, it.pairs[it.pos].key...)
+}
+
+// Value returns the current value.
+func (it *Iterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.pairs) {
+		return nil
+	}
+	return append([]byte{}, it.pairs[it.pos].value...)
+}
+
+// Error returns any error that occurred during iteration, which is always
+// nil since the pairs are already in memory.
+func (it *Iterator) Error() error {
+	return nil
+}
+
+// Close releases the pairs of the iterator.
+func (it *Iterator) Close() error {
+	it.pairs = nil
+	return nil
+}
@@ -0,0 +1,272 @@
+// Package memory provides a storage engine that keeps everything in memory.
+// Its contents are lost when it's closed, which makes it useful for tests and
+// scratch databases that don't need to touch the disk.
+package memory
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/interfaces"
+)
+
+var (
+	// ErrReadOnlyTransaction is returned when a read-only transaction is
+	// used to write.
+	ErrReadOnlyTransaction = errors.New("memory: transaction is read-only")
+	// ErrTransactionDone is returned when a transaction is used after it was
+	// committed or rolled back.
+	ErrTransactionDone = errors.New("memory: transaction has already been committed or rolled back")
+	// ErrClosed is returned when the storage is used after it was closed.
+	ErrClosed = errors.New("memory: storage is closed")
+)
+
+// Storage is the in-memory implementation of the interfaces.Storage
+// interface. The keys are kept sorted in a skip list, so they are iterated in
+// the same order as in the BadgerDB engine.
+type Storage struct {
+	mu     sync.RWMutex
+	data   *skipList
+	dbs    map[string]map[string]sql.Table
+	closed bool
+}
+
+// NewStorage creates a new, empty, in-memory storage engine.
+func NewStorage() *Storage {
+	return &Storage{
+		data: newSkipList(compareBytes),
+		dbs:  make(map[string]map[string]sql.Table),
+	}
+}
+
+func compareBytes(a, b interface{}) int {
+	return bytes.Compare(a.([]byte), b.([]byte))
+}
+
+// rowKey returns the key under which the key of a table is stored.
+func rowKey(db, table string, key []byte) []byte {
+	k := make([]byte, 0, len(db)+len(table)+len(key)+2)
+	k = append(k, db...)
+	k = append(k, 0)
+	k = append(k, table...)
+	k = append(k, 0)
+	return append(k, key...)
+}
+
+// Get retrieves a value for a given key from a specific table.
+func (s *Storage) Get(ctx *sql.Context, db, table string, key []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	return s.get(rowKey(db, table, key)), nil
+}
+
+func (s *Storage) get(key []byte) []byte {
+	v, ok := s.data.Get(key)
+	if !ok {
+		return nil
+	}
+	return append([]byte{}, v.([]byte)...)
+}
+
+// Set stores a key-value pair in a specific table.
+func (s *Storage) Set(ctx *sql.Context, db, table string, key, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	s.data.Set(rowKey(db, table, key), append([]byte{}, value...))
+	return nil
+}
+
+// Delete removes a key from a specific table.
+func (s *Storage) Delete(ctx *sql.Context, db, table string, key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	s.data.Delete(rowKey(db, table, key))
+	return nil
+}
+
+// Iterator returns an iterator for a given key prefix in a table. It reads
+// the pairs the table had when it was created, so it's not affected by later
+// writes.
+func (s *Storage) Iterator(ctx *sql.Context, db, table string, prefix []byte) (interfaces.Iterator, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	return newIterator(s.scan(rowKey(db, table, prefix))), nil
+}
+
+// scan returns a copy of the pairs whose key starts with the prefix, sorted
+// by key. It must be called with s.mu held.
+func (s *Storage) scan(prefix []byte) []kv {
+	var pairs []kv
+	for n := s.data.Seek(prefix); n != nil; n = n.Next() {
+		key := n.key.([]byte)
+		if !bytes.HasPrefix(key, prefix) {
+			break
+		}
+		pairs = append(pairs, kv{key: key, value: n.value.([]byte)})
+	}
+	return pairs
+}
+
+// NewTransaction creates a new transaction.
+func (s *Storage) NewTransaction(ctx *sql.Context, readOnly bool) (interfaces.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	return newTransaction(s, readOnly), nil
+}
+
+// CreateDatabase creates an empty database.
+func (s *Storage) CreateDatabase(ctx *sql.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if _, ok := s.dbs[name]; ok {
+		return sql.ErrDatabaseExists.New(name)
+	}
+	s.dbs[name] = make(map[string]sql.Table)
+	return nil
+}
+
+// DropDatabase drops a database along with its tables and their data.
+func (s *Storage) DropDatabase(ctx *sql.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	tables, ok := s.dbs[name]
+	if !ok {
+		return sql.ErrDatabaseNotFound.New(name)
+	}
+	for table := range tables {
+		s.deletePrefix(rowKey(name, table, nil))
+	}
+	delete(s.dbs, name)
+	return nil
+}
+
+// ListDatabases returns the names of the databases, sorted.
+func (s *Storage) ListDatabases(ctx *sql.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	names := make([]string, 0, len(s.dbs))
+	for name := range s.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CreateTable adds the table to an existing database.
+func (s *Storage) CreateTable(ctx *sql.Context, dbName string, table sql.Table) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	tables, ok := s.dbs[dbName]
+	if !ok {
+		return sql.ErrDatabaseNotFound.New(dbName)
+	}
+	if _, ok := tables[table.Name()]; ok {
+		return sql.ErrTableAlreadyExists.New(table.Name())
+	}
+	tables[table.Name()] = table
+	return nil
+}
+
+// DropTable drops a table along with its data.
+func (s *Storage) DropTable(ctx *sql.Context, dbName, tableName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	tables, ok := s.dbs[dbName]
+	if !ok {
+		return sql.ErrDatabaseNotFound.New(dbName)
+	}
+	if _, ok := tables[tableName]; !ok {
+		return sql.ErrTableNotFound.New(tableName)
+	}
+	s.deletePrefix(rowKey(dbName, tableName, nil))
+	delete(tables, tableName)
+	return nil
+}
+
+// deletePrefix removes every key that starts with the prefix. It must be
+// called with s.mu held.
+func (s *Storage) deletePrefix(prefix []byte) {
+	for _, p := range s.scan(prefix) {
+		s.data.Delete(p.key)
+	}
+}
+
+// GetTable returns the table that was given to CreateTable.
+func (s *Storage) GetTable(ctx *sql.Context, dbName, tableName string) (sql.Table, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	tables, ok := s.dbs[dbName]
+	if !ok {
+		return nil, sql.ErrDatabaseNotFound.New(dbName)
+	}
+	table, ok := tables[tableName]
+	if !ok {
+		return nil, sql.ErrTableNotFound.New(tableName)
+	}
+	return table, nil
+}
+
+// ListTables returns the names of the tables of a database, sorted.
+func (s *Storage) ListTables(ctx *sql.Context, dbName string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	tables, ok := s.dbs[dbName]
+	if !ok {
+		return nil, sql.ErrDatabaseNotFound.New(dbName)
+	}
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Close discards everything the storage has.
+func (s *Storage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.data = newSkipList(compareBytes)
+	s.dbs = make(map[string]map[string]sql.Table)
+	return nil
+}
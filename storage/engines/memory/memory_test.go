@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/interfaces"
+)
+
+var _ interfaces.Storage = (*Storage)(nil)
+
+func TestStorageRoundTrip(t *testing.T) {
+	s := NewStorage()
+	ctx := sql.NewEmptyContext()
+
+	require.NoError(t, s.Set(ctx, "db", "t", []byte("k2"), []byte("v2")))
+	require.NoError(t, s.Set(ctx, "db", "t", []byte("k1"), []byte("v1")))
+	require.NoError(t, s.Set(ctx, "db", "t2", []byte("k1"), []byte("other")))
+
+	v, err := s.Get(ctx, "db", "t", []byte("k1"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), v)
+
+	require.NoError(t, s.Delete(ctx, "db", "t", []byte("k1")))
+	v, err = s.Get(ctx, "db", "t", []byte("k1"))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	require.NoError(t, s.Set(ctx, "db", "t", []byte("k0"), []byte("v0")))
+	it, err := s.Iterator(ctx, "db", "t", []byte("k"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v0", "v2"}, iteratorValues(t, it))
+
+	require.NoError(t, s.Close())
+	_, err = s.Get(ctx, "db", "t", []byte("k0"))
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestStorageCatalog(t *testing.T) {
+	s := NewStorage()
+	ctx := sql.NewEmptyContext()
+
+	require.NoError(t, s.CreateDatabase(ctx, "db"))
+	assert.True(t, sql.ErrDatabaseExists.Is(s.CreateDatabase(ctx, "db")))
+
+	table := NewTable("t", productsSchema)
+	require.NoError(t, s.CreateTable(ctx, "db", table))
+	assert.True(t, sql.ErrTableAlreadyExists.Is(s.CreateTable(ctx, "db", table)))
+	assert.True(t, sql.ErrDatabaseNotFound.Is(s.CreateTable(ctx, "nodb", table)))
+
+	got, err := s.GetTable(ctx, "db", "t")
+	require.NoError(t, err)
+	assert.Equal(t, table, got)
+
+	names, err := s.ListTables(ctx, "db")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"t"}, names)
+
+	require.NoError(t, s.Set(ctx, "db", "t", []byte("k"), []byte("v")))
+	require.NoError(t, s.DropTable(ctx, "db", "t"))
+	v, err := s.Get(ctx, "db", "t", []byte("k"))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+	_, err = s.GetTable(ctx, "db", "t")
+	assert.True(t, sql.ErrTableNotFound.Is(err))
+
+	require.NoError(t, s.CreateDatabase(ctx, "a"))
+	dbs, err := s.ListDatabases(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "db"}, dbs)
+
+	require.NoError(t, s.DropDatabase(ctx, "db"))
+	assert.True(t, sql.ErrDatabaseNotFound.Is(s.DropDatabase(ctx, "db")))
+}
+
+func TestTransaction(t *testing.T) {
+	s := NewStorage()
+	ctx := sql.NewEmptyContext()
+	require.NoError(t, s.Set(ctx, "db", "t", []byte("a"), []byte("1")))
+	require.NoError(t, s.Set(ctx, "db", "t", []byte("b"), []byte("2")))
+
+	key := func(k string) []byte { return rowKey("db", "t", []byte(k)) }
+
+	tx, err := s.NewTransaction(ctx, false)
+	require.NoError(t, err)
+	require.NoError(t, tx.Set(key("c"), []byte("3")))
+	require.NoError(t, tx.Delete(key("a")))
+
+	// The transaction reads its own writes, but nobody else does.
+	v, err := tx.Get(key("a"))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+	v, err = s.Get(ctx, "db", "t", []byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), v)
+
+	it, err := tx.Iterator(rowKey("db", "t", nil))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2", "3"}, iteratorValues(t, it))
+
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, ErrTransactionDone, tx.Commit())
+
+	it, err = s.Iterator(ctx, "db", "t", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2", "3"}, iteratorValues(t, it))
+
+	tx, err = s.NewTransaction(ctx, false)
+	require.NoError(t, err)
+	require.NoError(t, tx.Set(key("d"), []byte("4")))
+	require.NoError(t, tx.Rollback())
+	v, err = s.Get(ctx, "db", "t", []byte("d"))
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	tx, err = s.NewTransaction(ctx, true)
+	require.NoError(t, err)
+	assert.True(t, tx.IsReadOnly())
+	assert.Equal(t, ErrReadOnlyTransaction, tx.Set(key("d"), []byte("4")))
+	v, err = tx.Get(key("b"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("2"), v)
+}
+
+func iteratorValues(t *testing.T, it interfaces.Iterator) []string {
+	t.Helper()
+	defer it.Close()
+
+	var values []string
+	for it.Next() {
+		values = append(values, string(it.Value()))
+	}
+	require.NoError(t, it.Error())
+	return values
+}
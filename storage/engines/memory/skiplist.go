@@ -0,0 +1,127 @@
+package memory
+
+import "math/rand"
+
+// maxLevel is the maximum number of levels of a skip list, which is enough
+// for lists of a few billion elements.
+const maxLevel = 32
+
+// skipList is an ordered map of keys to values. The keys are kept sorted by
+// the compare function, so the elements can be read in order starting at any
+// key. It's not safe for concurrent use.
+type skipList struct {
+	compare func(a, b interface{}) int
+	head    *skipNode
+	level   int
+	len     int
+}
+
+type skipNode struct {
+	key   interface{}
+	value interface{}
+	next  []*skipNode
+}
+
+func newSkipList(compare func(a, b interface{}) int) *skipList {
+	return &skipList{
+		compare: compare,
+		head:    &skipNode{next: make([]*skipNode, maxLevel)},
+		level:   1,
+	}
+}
+
+// Next returns the element after the node, or nil if it's the last one.
+func (n *skipNode) Next() *skipNode {
+	return n.next[0]
+}
+
+// findPrev fills prev with the last node of each level whose key is less
+// than the given one, and returns the first node whose key is not.
+func (l *skipList) findPrev(key interface{}, prev []*skipNode) *skipNode {
+	n := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for n.next[i] != nil && l.compare(n.next[i].key, key) < 0 {
+			n = n.next[i]
+		}
+		if prev != nil {
+			prev[i] = n
+		}
+	}
+	return n.next[0]
+}
+
+// Get returns the value of the key, and false if the list doesn't have it.
+func (l *skipList) Get(key interface{}) (interface{}, bool) {
+	n := l.findPrev(key, nil)
+	if n == nil || l.compare(n.key, key) != 0 {
+		return nil, false
+	}
+	return n.value, true
+}
+
+// Set sets the value of the key, replacing the one it had, if any.
+func (l *skipList) Set(key, value interface{}) {
+	prev := make([]*skipNode, maxLevel)
+	if n := l.findPrev(key, prev); n != nil && l.compare(n.key, key) == 0 {
+		n.value = value
+		return
+	}
+
+	level := randomLevel()
+	if level > l.level {
+		for i := l.level; i < level; i++ {
+			prev[i] = l.head
+		}
+		l.level = level
+	}
+
+	n := &skipNode{key: key, value: value, next: make([]*skipNode, level)}
+	for i := 0; i < level; i++ {
+		n.next[i] = prev[i].next[i]
+		prev[i].next[i] = n
+	}
+	l.len++
+}
+
+// Delete removes the key, and returns false if the list didn't have it.
+func (l *skipList) Delete(key interface{}) bool {
+	prev := make([]*skipNode, maxLevel)
+	n := l.findPrev(key, prev)
+	if n == nil || l.compare(n.key, key) != 0 {
+		return false
+	}
+
+	for i := 0; i < len(n.next); i++ {
+		prev[i].next[i] = n.next[i]
+	}
+	for l.level > 1 && l.head.next[l.level-1] == nil {
+		l.level--
+	}
+	l.len--
+	return true
+}
+
+// Seek returns the first element whose key is not less than the given one,
+// or the first element of the list if the key is nil. It returns nil if
+// there is no such element.
+func (l *skipList) Seek(key interface{}) *skipNode {
+	if key == nil {
+		return l.head.next[0]
+	}
+	return l.findPrev(key, nil)
+}
+
+// Len returns the number of elements of the list.
+func (l *skipList) Len() int {
+	return l.len
+}
+
+// randomLevel returns the number of levels of a new node, each level being
+// half as likely as the one below.
+func randomLevel() int {
+	level := 1
+	for level < maxLevel && rand.Int63()&1 == 1 {
+		level++
+	}
+	return level
+}
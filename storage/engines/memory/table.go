@@ -0,0 +1,287 @@
+package memory
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrRowNotFound is returned when the row to update or delete is not in the
+// table.
+var ErrRowNotFound = errors.NewKind("row not found in table %s")
+
+// Table implements sql.Table. Its rows are kept in a skip list sorted by
+// their primary key, so they are read in primary key order like the rows of
+// a BadgerDB table. The rows of a table without a primary key are read in
+// insertion order.
+type Table struct {
+	name    string
+	schema  sql.Schema
+	options sql.TableOptions
+	checks  []sql.CheckConstraint
+	// pk are the positions of the columns of the primary key.
+	pk []int
+
+	mu   sync.RWMutex
+	rows *skipList
+	// seq is the key of the next row inserted in a table without a primary
+	// key.
+	seq uint64
+}
+
+// NewTable creates an empty table with the given name and schema.
+func NewTable(name string, schema sql.Schema) *Table {
+	t := &Table{
+		name:   name,
+		schema: schema,
+		pk:     primaryKeyColumns(schema),
+	}
+	if len(t.pk) > 0 {
+		t.rows = newSkipList(t.comparePrimaryKeys)
+	} else {
+		t.rows = newSkipList(compareSeq)
+	}
+	return t
+}
+
+// primaryKeyColumns returns the indexes of the columns of the declared
+// primary key of the schema, in schema order, or nil if it has none.
+func primaryKeyColumns(schema sql.Schema) []int {
+	var cols []int
+	for i, col := range schema {
+		if col.PrimaryKey {
+			cols = append(cols, i)
+		}
+	}
+	return cols
+}
+
+func compareSeq(a, b interface{}) int {
+	x, y := a.(uint64), b.(uint64)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePrimaryKeys compares two primary keys, which are the values of the
+// primary key columns of a row. NULL values come first.
+func (t *Table) comparePrimaryKeys(a, b interface{}) int {
+	x, y := a.(sql.Row), b.(sql.Row)
+	for i, c := range t.pk {
+		if cmp := compareValues(t.schema[c].Type, x[i], y[i]); cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+func compareValues(typ sql.Type, a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	cmp, err := typ.Compare(a, b)
+	if err != nil {
+		// Values of an unexpected type are still given a stable order.
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+	return cmp
+}
+
+// primaryKey returns the values of the primary key columns of the row.
+func (t *Table) primaryKey(row sql.Row) sql.Row {
+	key := make(sql.Row, len(t.pk))
+	for i, c := range t.pk {
+		key[i] = row[c]
+	}
+	return key
+}
+
+// Name returns the name of the table.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// String returns the name of the table.
+func (t *Table) String() string {
+	return t.name
+}
+
+// Schema returns the schema of the table.
+func (t *Table) Schema() sql.Schema {
+	return t.schema
+}
+
+// TableOptions returns the options the table was created with.
+func (t *Table) TableOptions() sql.TableOptions {
+	return t.options
+}
+
+// Checks returns the CHECK constraints of the table.
+func (t *Table) Checks() []sql.CheckConstraint {
+	return t.checks
+}
+
+// RowCount returns the number of rows of the table.
+func (t *Table) RowCount(ctx *sql.Context) (uint64, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return uint64(t.rows.Len()), nil
+}
+
+// Partitions returns the only partition of the table.
+func (t *Table) Partitions(ctx *sql.Context) (sql.PartitionIter, error) {
+	return &partitionIter{}, nil
+}
+
+// PartitionRows returns the rows of the table. They are the rows it had when
+// it was called, so the iterator is not affected by later writes.
+func (t *Table) PartitionRows(ctx *sql.Context, partition sql.Partition) (sql.RowIter, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	rows := make([]sql.Row, 0, t.rows.Len())
+	for n := t.rows.Seek(nil); n != nil; n = n.Next() {
+		rows = append(rows, n.value.(sql.Row).Copy())
+	}
+	return sql.RowsToRowIter(rows...), nil
+}
+
+// Insert inserts a row. It fails if the table has a primary key and a row
+// with the same primary key.
+func (t *Table) Insert(ctx *sql.Context, row sql.Row) error {
+	if len(row) != len(t.schema) {
+		return sql.ErrUnexpectedRowLength.New(len(t.schema), len(row))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.pk) == 0 {
+		t.rows.Set(t.seq, row.Copy())
+		t.seq++
+		return nil
+	}
+
+	key := t.primaryKey(row)
+	if _, ok := t.rows.Get(key); ok {
+		return sql.ErrDuplicateKey.New(primaryKeyEntry(key))
+	}
+	t.rows.Set(key, row.Copy())
+	return nil
+}
+
+// Update replaces the old row with the new one.
+func (t *Table) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row) error {
+	if len(newRow) != len(t.schema) {
+		return sql.ErrUnexpectedRowLength.New(len(t.schema), len(newRow))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key, err := t.find(oldRow)
+	if err != nil {
+		return err
+	}
+
+	if len(t.pk) > 0 {
+		newKey := t.primaryKey(newRow)
+		if t.comparePrimaryKeys(key, newKey) != 0 {
+			if _, ok := t.rows.Get(newKey); ok {
+				return sql.ErrDuplicateKey.New(primaryKeyEntry(newKey))
+			}
+			t.rows.Delete(key)
+			key = newKey
+		}
+	}
+
+	t.rows.Set(key, newRow.Copy())
+	return nil
+}
+
+// Delete deletes a row.
+func (t *Table) Delete(ctx *sql.Context, row sql.Row) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key, err := t.find(row)
+	if err != nil {
+		return err
+	}
+	t.rows.Delete(key)
+	return nil
+}
+
+// find returns the key of the row in the table. It must be called with t.mu
+// held.
+func (t *Table) find(row sql.Row) (interface{}, error) {
+	if len(t.pk) > 0 {
+		if len(row) != len(t.schema) {
+			return nil, sql.ErrUnexpectedRowLength.New(len(t.schema), len(row))
+		}
+		key := t.primaryKey(row)
+		if _, ok := t.rows.Get(key); !ok {
+			return nil, ErrRowNotFound.New(t.name)
+		}
+		return key, nil
+	}
+
+	for n := t.rows.Seek(nil); n != nil; n = n.Next() {
+		ok, err := n.value.(sql.Row).Equals(row, t.schema)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return n.key, nil
+		}
+	}
+	return nil, ErrRowNotFound.New(t.name)
+}
+
+// primaryKeyEntry formats the primary key as MySQL does in duplicate entry
+// errors.
+func primaryKeyEntry(key sql.Row) string {
+	values := make([]string, len(key))
+	for i, v := range key {
+		values[i] = fmt.Sprint(v)
+	}
+	return strings.Join(values, "-")
+}
+
+// partition is the only partition of a table.
+type partition struct{}
+
+func (partition) Key() []byte {
+	return []byte("memory")
+}
+
+type partitionIter struct {
+	done bool
+}
+
+func (i *partitionIter) Next() (sql.Partition, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+	i.done = true
+	return partition{}, nil
+}
+
+func (i *partitionIter) Close() error {
+	return nil
+}
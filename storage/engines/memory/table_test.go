@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/compute/sql"
+)
+
+func TestTablePrimaryKeyOrder(t *testing.T) {
+	schema := sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t", PrimaryKey: true},
+		{Name: "b", Type: sql.Text, Source: "t", PrimaryKey: true},
+		{Name: "c", Type: sql.Int64, Source: "t", Nullable: true},
+	}
+	table := NewTable("t", schema)
+	ctx := sql.NewEmptyContext()
+
+	var want []sql.Row
+	for _, i := range rand.Perm(50) {
+		for _, b := range []string{"y", "x"} {
+			row := sql.NewRow(int64(i), b, nil)
+			require.NoError(t, table.Insert(ctx, row))
+			want = append(want, row)
+		}
+	}
+	sort.Slice(want, func(i, j int) bool {
+		if want[i][0] != want[j][0] {
+			return want[i][0].(int64) < want[j][0].(int64)
+		}
+		return want[i][1].(string) < want[j][1].(string)
+	})
+	assert.Equal(t, want, tableRows(t, ctx, table))
+
+	// Changing the primary key moves the row.
+	require.NoError(t, table.Update(ctx, sql.NewRow(int64(0), "x", nil), sql.NewRow(int64(100), "x", int64(1))))
+	rows := tableRows(t, ctx, table)
+	assert.Equal(t, sql.NewRow(int64(0), "y", nil), rows[0])
+	assert.Equal(t, sql.NewRow(int64(100), "x", int64(1)), rows[len(rows)-1])
+
+	err := table.Update(ctx, sql.NewRow(int64(1), "x", nil), sql.NewRow(int64(1), "y", nil))
+	assert.True(t, sql.ErrDuplicateKey.Is(err))
+	assert.EqualError(t, err, "Duplicate entry '1-y' for key 'PRIMARY'")
+}
+
+func TestTableWithoutPrimaryKey(t *testing.T) {
+	schema := sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+		{Name: "b", Type: sql.Text, Source: "t"},
+	}
+	table := NewTable("t", schema)
+	ctx := sql.NewEmptyContext()
+
+	// Equal rows can be inserted, and they are read in insertion order.
+	for _, row := range []sql.Row{
+		sql.NewRow(int64(2), "b"),
+		sql.NewRow(int64(1), "a"),
+		sql.NewRow(int64(2), "b"),
+	} {
+		require.NoError(t, table.Insert(ctx, row))
+	}
+
+	require.NoError(t, table.Update(ctx, sql.NewRow(int64(1), "a"), sql.NewRow(int64(1), "z")))
+	require.NoError(t, table.Delete(ctx, sql.NewRow(int64(2), "b")))
+
+	assert.Equal(t, []sql.Row{
+		sql.NewRow(int64(1), "z"),
+		sql.NewRow(int64(2), "b"),
+	}, tableRows(t, ctx, table))
+
+	err := table.Insert(ctx, sql.NewRow(int64(1)))
+	assert.True(t, sql.ErrUnexpectedRowLength.Is(err))
+}
+
+func TestSkipList(t *testing.T) {
+	l := newSkipList(compareSeq)
+	keys := rand.Perm(1000)
+	for _, k := range keys {
+		l.Set(uint64(k), k)
+	}
+	assert.Equal(t, 1000, l.Len())
+
+	for k := 0; k < 1000; k += 2 {
+		assert.True(t, l.Delete(uint64(k)))
+	}
+	assert.False(t, l.Delete(uint64(0)))
+	assert.Equal(t, 500, l.Len())
+
+	v, ok := l.Get(uint64(501))
+	assert.True(t, ok)
+	assert.Equal(t, 501, v)
+	_, ok = l.Get(uint64(500))
+	assert.False(t, ok)
+
+	l.Set(uint64(501), -1)
+	v, _ = l.Get(uint64(501))
+	assert.Equal(t, -1, v)
+	assert.Equal(t, 500, l.Len())
+
+	// Seek starts at the first key that is not less than the given one.
+	var got []uint64
+	for n := l.Seek(uint64(990)); n != nil; n = n.Next() {
+		got = append(got, n.key.(uint64))
+	}
+	assert.Equal(t, []uint64{991, 993, 995, 997, 999}, got)
+	assert.Equal(t, uint64(1), l.Seek(nil).key)
+	assert.Nil(t, l.Seek(uint64(1000)))
+}
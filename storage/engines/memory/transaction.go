@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"bytes"
+
+	"github.com/turtacn/guocedb/interfaces"
+)
+
+// Transaction implements interfaces.Transaction. Its writes are kept apart
+// until it's committed, when all of them are applied to the storage at once.
+// It reads its own writes on top of the latest committed data.
+type Transaction struct {
+	s        *Storage
+	readOnly bool
+	// writes holds the values the transaction set, keyed by key. Deleted
+	// keys have a nil value.
+	writes *skipList
+	done   bool
+}
+
+func newTransaction(s *Storage, readOnly bool) *Transaction {
+	return &Transaction{
+		s:        s,
+		readOnly: readOnly,
+		writes:   newSkipList(compareBytes),
+	}
+}
+
+// Get retrieves a value for a given key. It returns nil if the key is not
+// found.
+func (t *Transaction) Get(key []byte) ([]byte, error) {
+	if t.done {
+		return nil, ErrTransactionDone
+	}
+	if v, ok := t.writes.Get(key); ok {
+		if v.([]byte) == nil {
+			return nil, nil
+		}
+		return append([]byte{}, v.([]byte)...), nil
+	}
+
+	t.s.mu.RLock()
+	defer t.s.mu.RUnlock()
+	if t.s.closed {
+		return nil, ErrClosed
+	}
+	return t.s.get(key), nil
+}
+
+// Set stores a key-value pair.
+func (t *Transaction) Set(key, value []byte) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	t.writes.Set(append([]byte{}, key...), append([]byte{}, value...))
+	return nil
+}
+
+// Delete removes a key.
+func (t *Transaction) Delete(key []byte) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	t.writes.Set(append([]byte{}, key...), []byte(nil))
+	return nil
+}
+
+func (t *Transaction) checkWritable() error {
+	if t.done {
+		return ErrTransactionDone
+	}
+	if t.readOnly {
+		return ErrReadOnlyTransaction
+	}
+	return nil
+}
+
+// Iterator returns an iterator for a given key prefix, which includes the
+// writes of the transaction.
+func (t *Transaction) Iterator(prefix []byte) (interfaces.Iterator, error) {
+	if t.done {
+		return nil, ErrTransactionDone
+	}
+
+	t.s.mu.RLock()
+	if t.s.closed {
+		t.s.mu.RUnlock()
+		return nil, ErrClosed
+	}
+	committed := t.s.scan(prefix)
+	t.s.mu.RUnlock()
+
+	merged := newSkipList(compareBytes)
+	for _, p := range committed {
+		merged.Set(p.key, p.value)
+	}
+	for n := t.writes.Seek(prefix); n != nil && bytes.HasPrefix(n.key.([]byte), prefix); n = n.Next() {
+		if n.value.([]byte) == nil {
+			merged.Delete(n.key)
+		} else {
+			merged.Set(n.key, n.value)
+		}
+	}
+
+	pairs := make([]kv, 0, merged.Len())
+	for n := merged.Seek(nil); n != nil; n = n.Next() {
+		pairs = append(pairs, kv{key: n.key.([]byte), value: n.value.([]byte)})
+	}
+	return newIterator(pairs), nil
+}
+
+// Commit applies the writes of the transaction to the storage.
+func (t *Transaction) Commit() error {
+	if t.done {
+		return ErrTransactionDone
+	}
+	t.done = true
+	if t.writes.Len() == 0 {
+		return nil
+	}
+
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	if t.s.closed {
+		return ErrClosed
+	}
+	for n := t.writes.Seek(nil); n != nil; n = n.Next() {
+		if n.value.([]byte) == nil {
+			t.s.data.Delete(n.key)
+		} else {
+			t.s.data.Set(n.key, n.value)
+		}
+	}
+	return nil
+}
+
+// Rollback discards the writes of the transaction.
+func (t *Transaction) Rollback() error {
+	if t.done {
+		return ErrTransactionDone
+	}
+	t.done = true
+	t.writes = newSkipList(compareBytes)
+	return nil
+}
+
+// IsReadOnly returns true if the transaction is read-only.
+func (t *Transaction) IsReadOnly() bool {
+	return t.readOnly
+}
@@ -0,0 +1,108 @@
+package replication
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v3"
+
+	badgerengine "github.com/turtacn/guocedb/storage/engines/badger"
+)
+
+// ReplicaApplier applies the batches of a primary to the BadgerDB of a
+// read replica.
+type ReplicaApplier struct {
+	db *badger.DB
+}
+
+// NewReplicaApplier creates an applier that writes to db.
+func NewReplicaApplier(db *badger.DB) *ReplicaApplier {
+	return &ReplicaApplier{db: db}
+}
+
+// AppliedLSN returns the LSN of the last batch applied to the replica, or
+// zero if none was.
+func (a *ReplicaApplier) AppliedLSN() (uint64, error) {
+	var lsn uint64
+	err := a.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(badgerengine.EncodeAppliedLSNKey())
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			if len(val) != 8 {
+				return fmt.Errorf("replication: invalid applied LSN of %d bytes", len(val))
+			}
+			lsn = binary.BigEndian.Uint64(val)
+			return nil
+		})
+	})
+	return lsn, err
+}
+
+// Apply writes the mutations of a batch and records its LSN in a single
+// transaction. Batches at or before the applied LSN are ignored, so a batch
+// sent again after a reconnection is only applied once.
+func (a *ReplicaApplier) Apply(b *Batch) error {
+	applied, err := a.AppliedLSN()
+	if err != nil {
+		return err
+	}
+	if b.LSN <= applied {
+		return nil
+	}
+
+	lsn := make([]byte, 8)
+	binary.BigEndian.PutUint64(lsn, b.LSN)
+
+	err = a.db.Update(func(txn *badger.Txn) error {
+		if err := writeMutations(txn, b.Mutations); err != nil {
+			return err
+		}
+		return txn.Set(badgerengine.EncodeAppliedLSNKey(), lsn)
+	})
+	if !errors.Is(err, badger.ErrTxnTooBig) {
+		return err
+	}
+
+	// The commit is too big for a single transaction of the replica. The
+	// mutations are written first, so the LSN is never ahead of the data,
+	// and a batch interrupted half-way is written again in full.
+	wb := a.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, m := range b.Mutations {
+		if m.Delete {
+			err = wb.Delete(m.Key)
+		} else {
+			err = wb.Set(m.Key, m.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return err
+	}
+	return a.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(badgerengine.EncodeAppliedLSNKey(), lsn)
+	})
+}
+
+func writeMutations(txn *badger.Txn, mutations []Mutation) error {
+	for _, m := range mutations {
+		var err error
+		if m.Delete {
+			err = txn.Delete(m.Key)
+		} else {
+			err = txn.Set(m.Key, m.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
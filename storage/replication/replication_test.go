@@ -0,0 +1,187 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func openDB(t *testing.T) *badger.DB {
+	t.Helper()
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func serve(t *testing.T, db *badger.DB) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	Register(srv, NewReplicationSource(db))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func set(t *testing.T, db *badger.DB, kvs ...string) {
+	t.Helper()
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		for i := 0; i < len(kvs); i += 2 {
+			if err := txn.Set([]byte(kvs[i]), []byte(kvs[i+1])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func del(t *testing.T, db *badger.DB, key string) {
+	t.Helper()
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(key))
+	}))
+}
+
+// get returns the value of key, or nil if it doesn't exist.
+func get(t *testing.T, db *badger.DB, key string) []byte {
+	t.Helper()
+	var val []byte
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		val, err = item.ValueCopy(nil)
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil
+	}
+	require.NoError(t, err)
+	return val
+}
+
+// follow starts following the primary at addr, and returns a function that
+// stops following it.
+func follow(t *testing.T, applier *ReplicaApplier, addr string) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- applier.Follow(ctx, addr, nil) }()
+	return func() {
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	}
+}
+
+func TestSourceSince(t *testing.T) {
+	require := require.New(t)
+	db := openDB(t)
+
+	set(t, db, "a", "1", "b", "2")
+	set(t, db, "c", "3")
+	del(t, db, "a")
+
+	batches, err := NewReplicationSource(db).Since(0)
+	require.NoError(err)
+	// The first commit only keeps b, since a was deleted later.
+	require.Len(batches, 3)
+	require.Equal([]Mutation{{Key: []byte("b"), Value: []byte("2")}}, batches[0].Mutations)
+	require.Equal([]Mutation{{Key: []byte("c"), Value: []byte("3")}}, batches[1].Mutations)
+	require.Equal([]Mutation{{Key: []byte("a"), Delete: true}}, batches[2].Mutations)
+	require.True(batches[0].LSN < batches[1].LSN && batches[1].LSN < batches[2].LSN)
+
+	batches, err = NewReplicationSource(db).Since(batches[1].LSN)
+	require.NoError(err)
+	require.Len(batches, 1)
+	require.Equal([]byte("a"), batches[0].Mutations[0].Key)
+}
+
+func TestReplicaCatchesUp(t *testing.T) {
+	primary, replica := openDB(t), openDB(t)
+	addr := serve(t, primary)
+
+	// Commits made before the replica connects are sent too.
+	set(t, primary, "k1", "v1", "k2", "v2")
+
+	applier := NewReplicaApplier(replica)
+	stop := follow(t, applier, addr)
+	defer stop()
+
+	require.Eventually(t, func() bool {
+		return string(get(t, replica, "k2")) == "v2"
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "v1", string(get(t, replica, "k1")))
+
+	set(t, primary, "k3", "v3")
+	del(t, primary, "k1")
+	require.Eventually(t, func() bool {
+		return string(get(t, replica, "k3")) == "v3" && get(t, replica, "k1") == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	lsn, err := applier.AppliedLSN()
+	require.NoError(t, err)
+	require.Equal(t, primary.MaxVersion(), lsn)
+}
+
+func TestReplicaResumesFromAppliedLSN(t *testing.T) {
+	primary, replica := openDB(t), openDB(t)
+	addr := serve(t, primary)
+	applier := NewReplicaApplier(replica)
+
+	set(t, primary, "a", "1", "b", "2")
+	stop := follow(t, applier, addr)
+	require.Eventually(t, func() bool {
+		return string(get(t, replica, "b")) == "2"
+	}, 5*time.Second, 10*time.Millisecond)
+	stop()
+
+	applied, err := applier.AppliedLSN()
+	require.NoError(t, err)
+
+	// Writes made while the replica is away are sent once it's back, and
+	// nothing before its applied LSN is sent again.
+	set(t, primary, "c", "3")
+	del(t, primary, "a")
+	batches, err := NewReplicationSource(primary).Since(applied)
+	require.NoError(t, err)
+	require.Len(t, batches, 2)
+
+	stop = follow(t, applier, addr)
+	defer stop()
+	require.Eventually(t, func() bool {
+		return string(get(t, replica, "c")) == "3" && get(t, replica, "a") == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "2", string(get(t, replica, "b")))
+}
+
+func TestApplyIgnoresAppliedBatches(t *testing.T) {
+	require := require.New(t)
+	applier := NewReplicaApplier(openDB(t))
+
+	require.NoError(applier.Apply(&Batch{LSN: 5, Mutations: []Mutation{{Key: []byte("k"), Value: []byte("new")}}}))
+	require.NoError(applier.Apply(&Batch{LSN: 3, Mutations: []Mutation{{Key: []byte("k"), Value: []byte("old")}}}))
+
+	require.Equal("new", string(get(t, applier.db, "k")))
+	lsn, err := applier.AppliedLSN()
+	require.NoError(err)
+	require.Equal(uint64(5), lsn)
+}
+
+func TestReplicaStateIsNotReplicated(t *testing.T) {
+	db := openDB(t)
+	require.NoError(t, NewReplicaApplier(db).Apply(&Batch{LSN: 1, Mutations: []Mutation{{Key: []byte("k"), Value: []byte("v")}}}))
+
+	batches, err := NewReplicationSource(db).Since(0)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.Equal(t, []Mutation{{Key: []byte("k"), Value: []byte("v")}}, batches[0].Mutations)
+}
@@ -0,0 +1,151 @@
+package replication
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+)
+
+// The replication service streams batches over gRPC. Its messages are Go
+// structs encoded with gob, rather than protobuf messages, so it's
+// registered with a codec of its own that the other services don't use.
+
+const (
+	codecName    = "guocedb-gob"
+	serviceName  = "guocedb.replication.v1.Replication"
+	streamMethod = "/" + serviceName + "/Stream"
+)
+
+func init() {
+	encoding.RegisterCodec(gobCodec{})
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (gobCodec) Name() string { return codecName }
+
+// StreamRequest asks a primary for the commits after an LSN.
+type StreamRequest struct {
+	FromLSN uint64
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Stream",
+		Handler:       streamHandler,
+		ServerStreams: true,
+	}},
+}
+
+func streamHandler(srv interface{}, stream grpc.ServerStream) error {
+	var req StreamRequest
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	return srv.(*ReplicationSource).Stream(stream.Context(), req.FromLSN, func(b *Batch) error {
+		return stream.SendMsg(b)
+	})
+}
+
+// Register serves the commits of source on a gRPC server.
+func Register(s *grpc.Server, source *ReplicationSource) {
+	s.RegisterService(&serviceDesc, source)
+}
+
+// Retry delays of Follow after the stream of the primary breaks.
+const (
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 5 * time.Second
+)
+
+// Follow applies the commits of the primary at the given gRPC address
+// until ctx is done. When the stream breaks, it reconnects and resumes
+// after the last applied LSN.
+func (a *ReplicaApplier) Follow(ctx context.Context, primary string, logger *slog.Logger) error {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	conn, err := grpc.NewClient(primary, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	backoff := minBackoff
+	for {
+		applied, err := a.follow(ctx, conn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if applied {
+			backoff = minBackoff
+		}
+		logger.Warn("Replication stream broken, reconnecting",
+			"primary", primary, "error", err, "retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// follow streams the commits after the applied LSN from the primary until
+// the stream breaks, and reports whether any of them was applied.
+func (a *ReplicaApplier) follow(ctx context.Context, conn *grpc.ClientConn) (bool, error) {
+	lsn, err := a.AppliedLSN()
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := conn.NewStream(ctx, &serviceDesc.Streams[0], streamMethod, grpc.CallContentSubtype(codecName))
+	if err != nil {
+		return false, err
+	}
+	if err := stream.SendMsg(&StreamRequest{FromLSN: lsn}); err != nil {
+		return false, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return false, err
+	}
+
+	applied := false
+	for {
+		var b Batch
+		if err := stream.RecvMsg(&b); err != nil {
+			return applied, err
+		}
+		if err := a.Apply(&b); err != nil {
+			return applied, err
+		}
+		applied = true
+	}
+}
@@ -0,0 +1,153 @@
+// Package replication streams the committed mutations of a BadgerDB
+// primary to read replicas, which apply them to their own BadgerDB.
+//
+// The position in the stream is the log sequence number (LSN) of a commit,
+// which is the version BadgerDB gave to the keys written by the commit.
+// Every commit is sent as a Batch of its mutations, in LSN order, and a
+// replica stores the LSN of the last batch it applied in the same write as
+// the batch, so it can resume the stream from there after a reconnection
+// or a restart.
+package replication
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/dgraph-io/badger/v3/pb"
+
+	badgerengine "github.com/turtacn/guocedb/storage/engines/badger"
+)
+
+// Mutation is the write of a single key by a commit.
+type Mutation struct {
+	Key    []byte
+	Value  []byte
+	Delete bool
+}
+
+// Batch holds the mutations of a single commit.
+type Batch struct {
+	LSN       uint64
+	Mutations []Mutation
+}
+
+// pollInterval is how often a source looks for new commits if it missed
+// the notification of a commit.
+const pollInterval = time.Second
+
+// ReplicationSource tails the commits of a BadgerDB.
+//
+// Only the latest version of every key is kept, so a source sends the
+// latest mutation of the keys written since the requested LSN, rather than
+// every commit that wrote them. Replicas end up with the same data either
+// way. Tombstones are discarded by compactions too, so a replica that falls
+// behind for long enough may miss deletes; such a replica should be
+// rebuilt from a copy of the primary.
+type ReplicationSource struct {
+	db *badger.DB
+}
+
+// NewReplicationSource creates a source for the commits of db.
+func NewReplicationSource(db *badger.DB) *ReplicationSource {
+	return &ReplicationSource{db: db}
+}
+
+// Stream calls send with the batches of the commits after fromLSN, in LSN
+// order, and keeps sending new commits as they happen. It returns when
+// ctx is done or send fails.
+func (s *ReplicationSource) Stream(ctx context.Context, fromLSN uint64, send func(*Batch) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Subscribe before the first scan, so commits made during a scan wake
+	// the source up for the next one.
+	notify := make(chan struct{}, 1)
+	go func() {
+		_ = s.db.Subscribe(ctx, func(*badger.KVList) error {
+			select {
+			case notify <- struct{}{}:
+			default:
+			}
+			return nil
+		}, []pb.Match{{Prefix: nil}})
+	}()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	lsn := fromLSN
+	for {
+		batches, err := s.Since(lsn)
+		if err != nil {
+			return err
+		}
+		for _, b := range batches {
+			if err := send(b); err != nil {
+				return err
+			}
+			lsn = b.LSN
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Since returns the batches of the commits after lsn, in LSN order.
+func (s *ReplicationSource) Since(lsn uint64) ([]*Batch, error) {
+	byLSN := make(map[uint64]*Batch)
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.AllVersions = true
+		opts.SinceTs = lsn
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		var last []byte
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			// Versions of a key come newest first, skip the older ones.
+			if last != nil && bytes.Equal(item.Key(), last) {
+				continue
+			}
+			last = item.KeyCopy(nil)
+			if len(last) > 0 && last[0] == badgerengine.ReplicationPrefix {
+				continue
+			}
+
+			m := Mutation{Key: last, Delete: item.IsDeletedOrExpired()}
+			if !m.Delete {
+				v, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				m.Value = v
+			}
+
+			b, ok := byLSN[item.Version()]
+			if !ok {
+				b = &Batch{LSN: item.Version()}
+				byLSN[b.LSN] = b
+			}
+			b.Mutations = append(b.Mutations, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	batches := make([]*Batch, 0, len(byLSN))
+	for _, b := range byLSN {
+		batches = append(batches, b)
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].LSN < batches[j].LSN })
+	return batches, nil
+}
@@ -2,148 +2,170 @@
 package sal
 
 import (
-	"github.com/turtacn/guocedb/compute/sql"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/turtacn/guocedb/common/config"
+	"github.com/turtacn/guocedb/common/constants"
+	"github.com/turtacn/guocedb/compute/mem"
+	"github.com/turtacn/guocedb/compute/sql"
 	"github.com/turtacn/guocedb/interfaces"
+	"github.com/turtacn/guocedb/maintenance/metrics"
 	"github.com/turtacn/guocedb/storage/engines/badger"
-	// Placeholders for future engines
-	// "github.com/turtacn/guocedb/storage/engines/kvd"
-	// "github.com/turtacn/guocedb/storage/engines/mdd"
-	// "github.com/turtacn/guocedb/storage/engines/mdi"
+	"github.com/turtacn/guocedb/storage/engines/memory"
 )
 
+// Factory opens a storage engine with the given configuration.
+type Factory func(cfg *config.Config) (interfaces.Storage, error)
+
 var (
-	// mu protects the drivers map
+	// mu protects the factories map
 	mu sync.RWMutex
-	// drivers stores the registered storage engine drivers
-	drivers = make(map[string]interfaces.Storage)
+	// factories stores the registered storage engines by name
+	factories = make(map[string]Factory)
 )
 
-// Register makes a storage driver available by name.
-// If Register is called twice with the same name or if the driver is nil,
+// Register makes a storage engine available by name.
+// If Register is called twice with the same name or if the factory is nil,
 // it panics.
-func Register(name string, driver interfaces.Storage) {
+func Register(name string, factory Factory) {
 	mu.Lock()
 	defer mu.Unlock()
-	if driver == nil {
-		panic("storage: Register driver is nil")
+	if factory == nil {
+		panic("storage: Register factory is nil")
 	}
-	if _, dup := drivers[name]; dup {
-		panic("storage: Register called twice for driver " + name)
+	if _, dup := factories[name]; dup {
+		panic("storage: Register called twice for engine " + name)
 	}
-	drivers[name] = driver
+	factories[name] = factory
 }
 
-// GetStorageEngine initializes and returns the storage engine specified in the configuration.
-func GetStorageEngine(cfg *config.Config) (interfaces.Storage, error) {
+// Engines returns the names of the registered storage engines, sorted.
+func Engines() []string {
 	mu.RLock()
 	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
-	engineName := cfg.Storage.Engine
-	driver, ok := drivers[engineName]
+// NewStorageEngine opens the storage engine named in the configuration.
+func NewStorageEngine(cfg *config.Config) (interfaces.Storage, error) {
+	mu.RLock()
+	factory, ok := factories[cfg.Storage.Engine]
+	mu.RUnlock()
 	if !ok {
-		return nil, fmt.Errorf("storage: unknown driver %q (forgotten import?)", engineName)
+		return nil, fmt.Errorf("unsupported storage engine: %s", cfg.Storage.Engine)
 	}
-	return driver, nil
+
+	engine, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s storage: %w", cfg.Storage.Engine, err)
+	}
+	return engine, nil
 }
 
-// init registers the default drivers.
+// init registers the storage engines shipped with guocedb.
 func init() {
-	// Register the BadgerDB engine
-	// In a real application, the badger instance would be initialized based on config.
-	// For now, we register a placeholder that would be configured later.
-	// A better approach would be a factory function.
-	// Let's assume a factory `badger.NewStorage` exists.
-
-	// This is a bit of a chicken-and-egg problem. We need the config to init the engine,
-	// but we register the *type* of engine here. Let's adjust the pattern.
-	// We'll register factory functions instead.
+	Register(constants.StorageEngineBadger, func(cfg *config.Config) (interfaces.Storage, error) {
+		return badger.NewStorage(cfg.Storage.DataDir, cfg.Storage.Badger)
+	})
+	Register(constants.StorageEngineMemory, func(cfg *config.Config) (interfaces.Storage, error) {
+		return memory.NewStorage(), nil
+	})
+}
 
-	// Let's simplify for now: we will initialize the engines in the server's main function
-	// and the adapter will simply hold the active engine.
+// RegisterEngines adds the storage engines shipped with guocedb to the given
+// registry, BadgerDB being the default one.
+func RegisterEngines(r *sql.EngineRegistry) {
+	r.RegisterEngine(badger.Engine)
+	r.RegisterEngine(mem.Engine)
 }
 
 // Adapter is a concrete implementation of the Storage interface that delegates
 // calls to a specific, underlying storage engine.
 type Adapter struct {
 	engine interfaces.Storage
+	// storage is what the calls are delegated to, which is the engine,
+	// unless it's instrumented.
+	storage interfaces.Storage
 }
 
 // NewAdapter creates a new storage adapter for the configured engine.
 func NewAdapter(cfg *config.Config) (*Adapter, error) {
-	var engine interfaces.Storage
-	var err error
-
-	switch cfg.Storage.Engine {
-	case "badger":
-		engine, err = badger.NewStorage(cfg.Storage.Badger)
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize badger storage: %w", err)
-		}
-	// case "kvd":
-	// 	engine, err = kvd.NewStorage(cfg.Storage.KVD)
-	// 	...
-	default:
-		return nil, fmt.Errorf("unsupported storage engine: %s", cfg.Storage.Engine)
+	engine, err := NewStorageEngine(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return &Adapter{engine: engine, storage: engine}, nil
+}
+
+// Engine returns the storage engine the adapter delegates to.
+func (a *Adapter) Engine() interfaces.Storage {
+	return a.engine
+}
 
-	return &Adapter{engine: engine}, nil
+// Instrument records the reads, writes, scans and commits run through the
+// adapter in the given collector, as InstrumentedStorage does.
+func (a *Adapter) Instrument(c *metrics.StorageOperationCollector) {
+	a.storage = NewInstrumentedStorage(a.engine, c)
 }
 
 // Forward all the interface methods to the underlying engine.
 // This is boilerplate but ensures the Adapter satisfies the interface.
 
 func (a *Adapter) Get(ctx *sql.Context, db, table string, key []byte) ([]byte, error) {
-	return a.engine.Get(ctx, db, table, key)
+	return a.storage.Get(ctx, db, table, key)
 }
 
 func (a *Adapter) Set(ctx *sql.Context, db, table string, key, value []byte) error {
-	return a.engine.Set(ctx, db, table, key, value)
+	return a.storage.Set(ctx, db, table, key, value)
 }
 
 func (a *Adapter) Delete(ctx *sql.Context, db, table string, key []byte) error {
-	return a.engine.Delete(ctx, db, table, key)
+	return a.storage.Delete(ctx, db, table, key)
 }
 
 func (a *Adapter) Iterator(ctx *sql.Context, db, table string, prefix []byte) (interfaces.Iterator, error) {
-	return a.engine.Iterator(ctx, db, table, prefix)
+	return a.storage.Iterator(ctx, db, table, prefix)
 }
 
 func (a *Adapter) NewTransaction(ctx *sql.Context, readOnly bool) (interfaces.Transaction, error) {
-	return a.engine.NewTransaction(ctx, readOnly)
+	return a.storage.NewTransaction(ctx, readOnly)
 }
 
 func (a *Adapter) CreateDatabase(ctx *sql.Context, name string) error {
-	return a.engine.CreateDatabase(ctx, name)
+	return a.storage.CreateDatabase(ctx, name)
 }
 
 func (a *Adapter) DropDatabase(ctx *sql.Context, name string) error {
-	return a.engine.DropDatabase(ctx, name)
+	return a.storage.DropDatabase(ctx, name)
 }
 
 func (a *Adapter) ListDatabases(ctx *sql.Context) ([]string, error) {
-	return a.engine.ListDatabases(ctx)
+	return a.storage.ListDatabases(ctx)
 }
 
 func (a *Adapter) CreateTable(ctx *sql.Context, dbName string, table sql.Table) error {
-	return a.engine.CreateTable(ctx, dbName, table)
+	return a.storage.CreateTable(ctx, dbName, table)
 }
 
 func (a *Adapter) DropTable(ctx *sql.Context, dbName, tableName string) error {
-	return a.engine.DropTable(ctx, dbName, tableName)
+	return a.storage.DropTable(ctx, dbName, tableName)
 }
 
 func (a *Adapter) GetTable(ctx *sql.Context, dbName, tableName string) (sql.Table, error) {
-	return a.engine.GetTable(ctx, dbName, tableName)
+	return a.storage.GetTable(ctx, dbName, tableName)
 }
 
 func (a *Adapter) ListTables(ctx *sql.Context, dbName string) ([]string, error) {
-	return a.engine.ListTables(ctx, dbName)
+	return a.storage.ListTables(ctx, dbName)
 }
 
 func (a *Adapter) Close() error {
-	return a.engine.Close()
+	return a.storage.Close()
 }
@@ -0,0 +1,143 @@
+package sal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/common/config"
+	cerrors "github.com/turtacn/guocedb/common/errors"
+	"github.com/turtacn/guocedb/common/types/enum"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/interfaces"
+	"github.com/turtacn/guocedb/storage/engines/badger"
+	"github.com/turtacn/guocedb/storage/engines/memory"
+)
+
+func TestEngines(t *testing.T) {
+	assert.Equal(t, []string{"badger", "memory"}, Engines())
+}
+
+func TestNewStorageEngine(t *testing.T) {
+	cfg := &config.Config{Storage: config.StorageConfig{Engine: "memory"}}
+	engine, err := NewStorageEngine(cfg)
+	require.NoError(t, err)
+	defer engine.Close()
+	assert.IsType(t, &memory.Storage{}, engine)
+
+	adapter, err := NewAdapter(cfg)
+	require.NoError(t, err)
+	defer adapter.Close()
+
+	ctx := sql.NewEmptyContext()
+	require.NoError(t, adapter.CreateDatabase(ctx, "db"))
+	dbs, err := adapter.ListDatabases(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db"}, dbs)
+
+	cfg.Storage.Engine = "nonexistent"
+	_, err = NewStorageEngine(cfg)
+	assert.EqualError(t, err, "unsupported storage engine: nonexistent")
+}
+
+func TestRegister(t *testing.T) {
+	factory := func(cfg *config.Config) (interfaces.Storage, error) {
+		return memory.NewStorage(), nil
+	}
+	Register("test", factory)
+	defer func() {
+		mu.Lock()
+		delete(factories, "test")
+		mu.Unlock()
+	}()
+
+	engine, err := NewStorageEngine(&config.Config{Storage: config.StorageConfig{Engine: "test"}})
+	require.NoError(t, err)
+	assert.NotNil(t, engine)
+
+	assert.Panics(t, func() { Register("test", factory) })
+	assert.Panics(t, func() { Register("nil", nil) })
+}
+
+func TestNewAdapterCorruptedValueLog(t *testing.T) {
+	ctx := sql.NewEmptyContext()
+	// Values above 1MB are kept in the value log, the rest in the LSM tree.
+	large := bytes.Repeat([]byte("x"), 2<<20)
+
+	openBadger := func(t *testing.T, dir string) (*Adapter, error) {
+		return NewAdapter(&config.Config{Storage: config.StorageConfig{
+			Engine:  "badger",
+			DataDir: dir,
+			Badger: config.BadgerConfig{
+				ValueLogFileSize:  64 << 20,
+				RecoverCorruption: true,
+			},
+		}})
+	}
+
+	populate := func(t *testing.T, dir string) {
+		adapter, err := openBadger(t, dir)
+		require.NoError(t, err)
+		require.NoError(t, adapter.Set(ctx, "app", "blobs", []byte("k1"), large))
+		require.NoError(t, adapter.Set(ctx, "app", "blobs", []byte("k2"), large))
+		require.NoError(t, adapter.Set(ctx, "other", "users", []byte("ann"), []byte("1")))
+		require.NoError(t, adapter.Close())
+	}
+
+	vlog := func(t *testing.T, dir string) string {
+		files, err := filepath.Glob(filepath.Join(dir, "*.vlog"))
+		require.NoError(t, err)
+		require.NotEmpty(t, files)
+		sort.Strings(files)
+		return files[0]
+	}
+
+	check := func(t *testing.T, dir string) {
+		adapter, err := openBadger(t, dir)
+		require.NoError(t, err)
+		defer adapter.Close()
+
+		value, err := adapter.Get(ctx, "other", "users", []byte("ann"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("1"), value)
+
+		_, err = adapter.Get(ctx, "app", "blobs", []byte("k1"))
+		require.Error(t, err)
+		assert.Equal(t, enum.DataCorruption, cerrors.CodeOf(err))
+		_, err = adapter.Iterator(ctx, "app", "blobs", nil)
+		assert.Equal(t, enum.DataCorruption, cerrors.CodeOf(err))
+
+		report := adapter.Engine().(*badger.Storage).Recovery()
+		assert.Equal(t, 2, report.LostKeys)
+		assert.True(t, report.Recovered)
+	}
+
+	t.Run("garbage", func(t *testing.T) {
+		dir := t.TempDir()
+		populate(t, dir)
+
+		f, err := os.OpenFile(vlog(t, dir), os.O_RDWR, 0)
+		require.NoError(t, err)
+		_, err = f.WriteAt(bytes.Repeat([]byte{0xff}, 64<<10), 1<<20)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		check(t, dir)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		dir := t.TempDir()
+		populate(t, dir)
+
+		path := vlog(t, dir)
+		require.NoError(t, os.Truncate(path, 0))
+
+		check(t, dir)
+		_, err := os.Stat(path + ".corrupt")
+		assert.NoError(t, err)
+	})
+}
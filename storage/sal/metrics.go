@@ -0,0 +1,140 @@
+package sal
+
+import (
+	"time"
+
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/interfaces"
+	"github.com/turtacn/guocedb/maintenance/metrics"
+)
+
+// InstrumentedStorage is a Storage that records the reads, writes, scans
+// and commits run on the storage it wraps, so their number and latency are
+// exported as metrics. The other operations are passed through as they
+// are. Scans last from the creation of their iterator until it's closed.
+type InstrumentedStorage struct {
+	interfaces.Storage
+	metrics *metrics.StorageOperationCollector
+}
+
+var _ interfaces.Storage = (*InstrumentedStorage)(nil)
+
+// NewInstrumentedStorage wraps the storage, recording its operations in
+// the given collector.
+func NewInstrumentedStorage(storage interfaces.Storage, c *metrics.StorageOperationCollector) *InstrumentedStorage {
+	return &InstrumentedStorage{Storage: storage, metrics: c}
+}
+
+// Unwrap returns the storage whose operations are recorded.
+func (s *InstrumentedStorage) Unwrap() interfaces.Storage {
+	return s.Storage
+}
+
+func (s *InstrumentedStorage) Get(ctx *sql.Context, db, table string, key []byte) ([]byte, error) {
+	start := time.Now()
+	value, err := s.Storage.Get(ctx, db, table, key)
+	s.metrics.Observe(metrics.StorageGet, time.Since(start), err)
+	return value, err
+}
+
+func (s *InstrumentedStorage) Set(ctx *sql.Context, db, table string, key, value []byte) error {
+	start := time.Now()
+	err := s.Storage.Set(ctx, db, table, key, value)
+	s.metrics.Observe(metrics.StorageSet, time.Since(start), err)
+	return err
+}
+
+func (s *InstrumentedStorage) Delete(ctx *sql.Context, db, table string, key []byte) error {
+	start := time.Now()
+	err := s.Storage.Delete(ctx, db, table, key)
+	s.metrics.Observe(metrics.StorageDelete, time.Since(start), err)
+	return err
+}
+
+func (s *InstrumentedStorage) Iterator(ctx *sql.Context, db, table string, prefix []byte) (interfaces.Iterator, error) {
+	return newInstrumentedIterator(s.metrics, func() (interfaces.Iterator, error) {
+		return s.Storage.Iterator(ctx, db, table, prefix)
+	})
+}
+
+func (s *InstrumentedStorage) NewTransaction(ctx *sql.Context, readOnly bool) (interfaces.Transaction, error) {
+	txn, err := s.Storage.NewTransaction(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedTransaction{Transaction: txn, metrics: s.metrics}, nil
+}
+
+// instrumentedTransaction records the operations of a transaction of an
+// InstrumentedStorage as those of the storage.
+type instrumentedTransaction struct {
+	interfaces.Transaction
+	metrics *metrics.StorageOperationCollector
+}
+
+func (t *instrumentedTransaction) Get(key []byte) ([]byte, error) {
+	start := time.Now()
+	value, err := t.Transaction.Get(key)
+	t.metrics.Observe(metrics.StorageGet, time.Since(start), err)
+	return value, err
+}
+
+func (t *instrumentedTransaction) Set(key, value []byte) error {
+	start := time.Now()
+	err := t.Transaction.Set(key, value)
+	t.metrics.Observe(metrics.StorageSet, time.Since(start), err)
+	return err
+}
+
+func (t *instrumentedTransaction) Delete(key []byte) error {
+	start := time.Now()
+	err := t.Transaction.Delete(key)
+	t.metrics.Observe(metrics.StorageDelete, time.Since(start), err)
+	return err
+}
+
+func (t *instrumentedTransaction) Iterator(prefix []byte) (interfaces.Iterator, error) {
+	return newInstrumentedIterator(t.metrics, func() (interfaces.Iterator, error) {
+		return t.Transaction.Iterator(prefix)
+	})
+}
+
+func (t *instrumentedTransaction) Commit() error {
+	start := time.Now()
+	err := t.Transaction.Commit()
+	t.metrics.Observe(metrics.StorageCommit, time.Since(start), err)
+	return err
+}
+
+// instrumentedIterator records a scan once its iterator is closed.
+type instrumentedIterator struct {
+	interfaces.Iterator
+	metrics *metrics.StorageOperationCollector
+	start   time.Time
+	closed  bool
+}
+
+// newInstrumentedIterator opens an iterator with open, whose scan is
+// recorded when it's closed, or right away if it can't be opened.
+func newInstrumentedIterator(c *metrics.StorageOperationCollector, open func() (interfaces.Iterator, error)) (interfaces.Iterator, error) {
+	start := time.Now()
+	iter, err := open()
+	if err != nil {
+		c.Observe(metrics.StorageScan, time.Since(start), err)
+		return nil, err
+	}
+	return &instrumentedIterator{Iterator: iter, metrics: c, start: start}, nil
+}
+
+func (it *instrumentedIterator) Close() error {
+	err := it.Iterator.Close()
+	if !it.closed {
+		it.closed = true
+		scanErr := it.Iterator.Error()
+		if scanErr == nil {
+			scanErr = err
+		}
+		it.metrics.Observe(metrics.StorageScan, time.Since(it.start), scanErr)
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package sal
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/turtacn/guocedb/common/config"
+	"github.com/turtacn/guocedb/compute/sql"
+	"github.com/turtacn/guocedb/maintenance/metrics"
+	"github.com/turtacn/guocedb/storage/engines/badger"
+)
+
+func TestAdapterInstrument(t *testing.T) {
+	adapter, err := NewAdapter(&config.Config{Storage: config.StorageConfig{
+		Engine:  "badger",
+		DataDir: t.TempDir(),
+		Badger:  config.BadgerConfig{ValueLogFileSize: 64 << 20},
+	}})
+	require.NoError(t, err)
+	defer adapter.Close()
+
+	operations := metrics.NewStorageOperationCollector()
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(operations)
+	adapter.Instrument(operations)
+
+	// The engine is still the one the adapter delegates to.
+	require.IsType(t, &badger.Storage{}, adapter.Engine())
+
+	// counts returns the number of operations and errors of each kind.
+	counts := func(name string) map[string]float64 {
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		result := make(map[string]float64)
+		for _, f := range families {
+			if f.GetName() != name {
+				continue
+			}
+			for _, m := range f.GetMetric() {
+				op := m.GetLabel()[0].GetValue()
+				if m.GetHistogram() != nil {
+					result[op] = float64(m.GetHistogram().GetSampleCount())
+				} else {
+					result[op] = m.GetCounter().GetValue()
+				}
+			}
+		}
+		return result
+	}
+
+	ctx := sql.NewEmptyContext()
+	require.NoError(t, adapter.Set(ctx, "db", "t", []byte("k1"), []byte("v1")))
+	require.NoError(t, adapter.Set(ctx, "db", "t", []byte("k2"), []byte("v2")))
+	value, err := adapter.Get(ctx, "db", "t", []byte("k1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("v1"), value)
+	require.NoError(t, adapter.Delete(ctx, "db", "t", []byte("k1")))
+	value, err = adapter.Get(ctx, "db", "t", []byte("k1"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	iter, err := adapter.Iterator(ctx, "db", "t", nil)
+	require.NoError(t, err)
+	n := 0
+	for iter.Next() {
+		n++
+	}
+	require.Equal(t, 1, n)
+	require.NoError(t, iter.Close())
+
+	txn, err := adapter.NewTransaction(ctx, false)
+	require.NoError(t, err)
+	require.NoError(t, txn.Set([]byte("k3"), []byte("v3")))
+	_, err = txn.Get([]byte("k3"))
+	require.NoError(t, err)
+	require.NoError(t, txn.Commit())
+
+	// Writes fail in read-only transactions.
+	readOnly, err := adapter.NewTransaction(ctx, true)
+	require.NoError(t, err)
+	require.Error(t, readOnly.Set([]byte("k4"), []byte("v4")))
+	require.NoError(t, readOnly.Rollback())
+
+	expected := map[string]float64{
+		metrics.StorageGet:    3,
+		metrics.StorageSet:    4,
+		metrics.StorageDelete: 1,
+		metrics.StorageScan:   1,
+		metrics.StorageCommit: 1,
+	}
+	require.Equal(t, expected, counts("guocedb_storage_operations_total"))
+	require.Equal(t, expected, counts("guocedb_storage_operation_duration_seconds"))
+	require.Equal(t, map[string]float64{metrics.StorageSet: 1}, counts("guocedb_storage_operation_errors_total"))
+}